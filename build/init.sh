@@ -2,6 +2,11 @@
 
 echo "Initialising AI News Processor"
 
+if [ "$ANP_DAEMON_ENABLED" = "true" ] || [ -n "$ANP_FEEDBACK_SECRET" ]; then
+    echo "Starting daemon on ${ANP_DAEMON_ADDR:-:8080}"
+    /app/daemon &
+fi
+
 if [ "$ANP_DEBUG_SKIP_CRON" = "true" ]; then
     echo "Debug mode: Skipping cron setup and running main directly"
     /app/main
@@ -0,0 +1,219 @@
+// Command bench scores benchmark runs. The compare mode checks a new evaluation from cmd/judge
+// against a stored baseline and exits non-zero when its quality or relevance accuracy regressed
+// beyond the tolerances, so prompt changes can be gated automatically. The golden mode scores the
+// relevance decisions of runs against a golden dataset of human labels. The prompt-diff mode shows
+// how the prompts of two benchmark runs differ and which relevance decisions changed with them:
+//
+//	bench compare -baseline baseline.json -candidate evaluation.json
+//	bench golden -dataset golden.jsonl
+//	bench prompt-diff -baseline benchmark_a.json -candidate benchmark_b.json
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/bakkerme/ai-news-processor/internal/bench"
+	"github.com/bakkerme/ai-news-processor/internal/specification"
+	"github.com/bakkerme/ai-news-processor/models"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	switch os.Args[1] {
+	case "compare":
+		os.Exit(compare(os.Args[2:]))
+	case "golden":
+		golden(os.Args[2:])
+	case "prompt-diff":
+		promptDiff(os.Args[2:])
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: bench compare -baseline <evaluation.json> -candidate <evaluation.json> [flags]")
+	fmt.Fprintln(os.Stderr, "       bench golden -dataset <golden.jsonl> [-input <benchmark.json>] [flags]")
+	fmt.Fprintln(os.Stderr, "       bench prompt-diff -baseline <benchmark.json> -candidate <benchmark.json>")
+	os.Exit(2)
+}
+
+// compare runs the compare mode and returns the exit code: 0 if the candidate passes, 1 if it regressed
+func compare(args []string) int {
+	flags := flag.NewFlagSet("compare", flag.ExitOnError)
+	baselineFlag := flags.String("baseline", "", "Evaluation JSON of the baseline run")
+	candidateFlag := flags.String("candidate", "", "Evaluation JSON of the run to check")
+	maxQualityDropFlag := flags.Float64("max-quality-drop", bench.DefaultMaxQualityDrop, "Largest allowed drop in mean quality score (1 to 5 scale)")
+	maxAccuracyDropFlag := flags.Float64("max-accuracy-drop", bench.DefaultMaxAccuracyDrop, "Largest allowed drop in relevance accuracy (0 to 1)")
+	flags.Parse(args)
+
+	if *baselineFlag == "" || *candidateFlag == "" {
+		log.Fatal("-baseline and -candidate are required")
+	}
+
+	baseline, err := bench.LoadEvaluation(*baselineFlag)
+	if err != nil {
+		log.Fatalf("Could not load baseline: %v", err)
+	}
+	candidate, err := bench.LoadEvaluation(*candidateFlag)
+	if err != nil {
+		log.Fatalf("Could not load candidate: %v", err)
+	}
+
+	baseMetrics, candMetrics := bench.Summarize(baseline), bench.Summarize(candidate)
+	fmt.Printf("%-20s %10s %10s\n", "", "baseline", "candidate")
+	fmt.Printf("%-20s %10d %10d\n", "items", baseMetrics.Items, candMetrics.Items)
+	fmt.Printf("%-20s %10.3f %10.3f\n", "mean quality", baseMetrics.MeanQuality, candMetrics.MeanQuality)
+	fmt.Printf("%-20s %10.3f %10.3f\n", "relevance accuracy", baseMetrics.RelevanceAccuracy, candMetrics.RelevanceAccuracy)
+
+	regressions := bench.Compare(baseMetrics, candMetrics, bench.Tolerances{
+		MaxQualityDrop:  *maxQualityDropFlag,
+		MaxAccuracyDrop: *maxAccuracyDropFlag,
+	})
+	if len(regressions) == 0 {
+		fmt.Println("\nNo regressions")
+		return 0
+	}
+
+	fmt.Println()
+	for _, regression := range regressions {
+		fmt.Printf("REGRESSION: %s\n", regression)
+	}
+	return 1
+}
+
+// golden runs the golden mode, scoring the latest run of each persona, or a single run, against
+// human labels
+func golden(args []string) {
+	flags := flag.NewFlagSet("golden", flag.ExitOnError)
+	datasetFlag := flags.String("dataset", "", "Golden dataset JSON Lines file (defaults to golden.jsonl in the benchmark directory)")
+	inputFlag := flags.String("input", "", "Benchmark JSON file to score (defaults to the latest run of every persona in the benchmark directory)")
+	personaFlag := flags.String("persona", "all", "Persona to score when no -input is given, or 'all'")
+	evaluationFlag := flags.String("evaluation", "", "Evaluation JSON from cmd/judge for -input, to compare judge quality scores with the labels")
+	outputFlag := flags.String("output", "", "File to write the scores JSON to")
+	flags.Parse(args)
+
+	if err := godotenv.Load(); err != nil {
+		log.Printf("No .env file found or error loading it: %v", err)
+	}
+	benchmarkDir := specification.LoadPaths().Benchmarks
+
+	datasetPath := *datasetFlag
+	if datasetPath == "" {
+		datasetPath = filepath.Join(benchmarkDir, "golden.jsonl")
+	}
+	labels, err := bench.LoadGolden(datasetPath)
+	if err != nil {
+		log.Fatalf("Could not load golden dataset: %v", err)
+	}
+
+	// Each run is paired with the evaluation of the same run, if there is one
+	type scoredRun struct {
+		data           *models.RunData
+		evaluationPath string
+	}
+	var runs []scoredRun
+	if *inputFlag != "" {
+		data, err := bench.LoadRunDataFile(*inputFlag)
+		if err != nil {
+			log.Fatalf("Could not load benchmark file: %v", err)
+		}
+		runs = append(runs, scoredRun{data: data, evaluationPath: *evaluationFlag})
+	} else {
+		all, err := bench.LoadRunData(benchmarkDir)
+		if err != nil {
+			log.Fatalf("Could not load run data: %v", err)
+		}
+		sort.Slice(all, func(i, j int) bool { return all[i].Persona.Name < all[j].Persona.Name })
+		for i := range all {
+			if *personaFlag != "all" && *personaFlag != "" && all[i].Persona.Name != *personaFlag {
+				continue
+			}
+			evaluationPath := filepath.Join(benchmarkDir, fmt.Sprintf("evaluation_%s.json", all[i].Persona.Name))
+			if _, err := os.Stat(evaluationPath); err != nil {
+				evaluationPath = ""
+			}
+			runs = append(runs, scoredRun{data: &all[i], evaluationPath: evaluationPath})
+		}
+		if len(runs) == 0 {
+			log.Fatalf("No run data for persona '%s' in %s", *personaFlag, benchmarkDir)
+		}
+	}
+
+	var scores []bench.GoldenScore
+	for _, run := range runs {
+		var eval *bench.Evaluation
+		if run.evaluationPath != "" {
+			eval, err = bench.LoadEvaluation(run.evaluationPath)
+			if err != nil {
+				log.Fatalf("Could not load evaluation: %v", err)
+			}
+		}
+
+		score := bench.ScoreGolden(run.data, labels, eval)
+		if score.Labels == 0 {
+			log.Printf("No golden labels for persona %s, skipping", score.Persona)
+			continue
+		}
+		bench.PrintGoldenScore(os.Stdout, score)
+		fmt.Println()
+		scores = append(scores, score)
+	}
+
+	if *outputFlag != "" {
+		jsonData, err := json.MarshalIndent(scores, "", "  ")
+		if err != nil {
+			log.Fatalf("Could not marshal scores: %v", err)
+		}
+		if err := os.WriteFile(*outputFlag, jsonData, 0644); err != nil {
+			log.Fatalf("Could not write scores: %v", err)
+		}
+		log.Printf("Scores written to %s", *outputFlag)
+	}
+}
+
+// promptDiff runs the prompt-diff mode, comparing the prompts and relevance decisions of two runs
+func promptDiff(args []string) {
+	flags := flag.NewFlagSet("prompt-diff", flag.ExitOnError)
+	baselineFlag := flags.String("baseline", "", "Benchmark JSON of the baseline run")
+	candidateFlag := flags.String("candidate", "", "Benchmark JSON of the run to compare")
+	outputFlag := flags.String("output", "", "File to write the comparison JSON to")
+	flags.Parse(args)
+
+	if *baselineFlag == "" || *candidateFlag == "" {
+		log.Fatal("-baseline and -candidate are required")
+	}
+
+	baseline, err := bench.LoadRunDataFile(*baselineFlag)
+	if err != nil {
+		log.Fatalf("Could not load baseline: %v", err)
+	}
+	candidate, err := bench.LoadRunDataFile(*candidateFlag)
+	if err != nil {
+		log.Fatalf("Could not load candidate: %v", err)
+	}
+
+	comparison := bench.ComparePrompts(baseline, candidate)
+	bench.PrintPromptComparison(os.Stdout, comparison)
+
+	if *outputFlag != "" {
+		jsonData, err := json.MarshalIndent(comparison, "", "  ")
+		if err != nil {
+			log.Fatalf("Could not marshal comparison: %v", err)
+		}
+		if err := os.WriteFile(*outputFlag, jsonData, 0644); err != nil {
+			log.Fatalf("Could not write comparison: %v", err)
+		}
+		log.Printf("Comparison written to %s", *outputFlag)
+	}
+}
@@ -0,0 +1,140 @@
+// Command daemon is the long-running companion of the scheduled processor. It serves the web
+// dashboard, the JSON REST API under /api/ (including the ingest endpoint), the /healthz and
+// /readyz probes and, when feedback links are configured, the reader feedback endpoint. When run data is sent to the audit service, it also retries submissions that
+// failed during runs. With ANP_MEGATHREAD_REFRESH_MINUTES set, it periodically summarizes the
+// megathreads sent over the last day again.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/api"
+	"github.com/bakkerme/ai-news-processor/internal/bench"
+	"github.com/bakkerme/ai-news-processor/internal/dashboard"
+	"github.com/bakkerme/ai-news-processor/internal/email"
+	"github.com/bakkerme/ai-news-processor/internal/health"
+	"github.com/bakkerme/ai-news-processor/internal/itemstore"
+	"github.com/bakkerme/ai-news-processor/internal/openai"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/internal/providers/ingest"
+	"github.com/bakkerme/ai-news-processor/internal/readerfeedback"
+	"github.com/bakkerme/ai-news-processor/internal/runhistory"
+	"github.com/bakkerme/ai-news-processor/internal/search"
+	"github.com/bakkerme/ai-news-processor/internal/specification"
+)
+
+// defaultRunCommand returns the processor binary next to the daemon's, where both the Docker image
+// and a local `go build` put it
+func defaultRunCommand() string {
+	executable, err := os.Executable()
+	if err != nil {
+		return "main"
+	}
+	return filepath.Join(filepath.Dir(executable), "main")
+}
+
+func main() {
+	runCommandFlag := flag.String("run-command", defaultRunCommand(), "Processor binary started for runs triggered through the API")
+	flag.Parse()
+	if _, err := os.Stat(*runCommandFlag); err != nil {
+		log.Printf("Warning: processor binary %s not found, runs triggered through the API will fail until -run-command is set: %v", *runCommandFlag, err)
+	}
+
+	s, err := specification.GetConfig()
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	sentLogBase := s.SentLogBasePath
+	items := itemstore.New(filepath.Join(sentLogBase, "items"))
+	runs := runhistory.New(filepath.Join(sentLogBase, "runs"))
+
+	// Personas are kept in memory and reloaded when their files change, so edits apply without a restart
+	var personas persona.Source = persona.Dir(s.PersonasPath)
+	if s.PersonaReloadSeconds > 0 {
+		registry, err := persona.NewRegistry(s.PersonasPath)
+		if err != nil {
+			log.Fatalf("Could not load personas: %v", err)
+		}
+		go registry.Watch(context.Background(), time.Duration(s.PersonaReloadSeconds)*time.Second)
+		personas = registry
+	}
+
+	runner := api.NewCommandRunner(*runCommandFlag)
+	if s.MegathreadRefreshMinutes > 0 {
+		// Megathreads keep being discussed after they were sent, so their summaries are brought up to date
+		go runner.RefreshMegathreads(context.Background(), time.Duration(s.MegathreadRefreshMinutes)*time.Minute)
+	}
+
+	mux := http.NewServeMux()
+	apiServer := api.NewServer(
+		personas,
+		runs,
+		items,
+		runner,
+		ingest.NewQueue(filepath.Join(sentLogBase, "ingest")),
+		s.ApiToken,
+	)
+	if embedder := search.NewEmbedder(s.LlmUrl, s.LlmApiKey, s.LlmEmbeddingModel, sentLogBase); embedder != nil {
+		apiServer.SetEmbedder(embedder)
+	}
+	mux.Handle("/api/", apiServer.Handler())
+
+	dashboardServer, err := dashboard.NewServer(personas, runs, items, s.ApiToken)
+	if err != nil {
+		log.Fatalf("Could not create dashboard: %v", err)
+	}
+	mux.Handle("/", dashboardServer.Handler())
+
+	// Liveness only covers the daemon itself, since restarting it cannot fix the persona files, the
+	// LLM or the mail server. Readiness checks the persona files on disk, not the copy in memory.
+	liveness := health.Personas(personas)
+	readiness := []health.Check{health.Personas(persona.Dir(s.PersonasPath))}
+	if !s.DebugMockLLM && s.LlmUrl != "" {
+		readiness = append(readiness, health.Check{Name: "llm", Run: func(ctx context.Context) error {
+			return openai.Ping(ctx, s.LlmUrl, s.LlmApiKey)
+		}})
+	}
+	if !s.DebugSkipEmail && s.EmailTransport == "smtp" && s.EmailHost != "" {
+		emailService, err := email.NewService(s)
+		if err != nil {
+			log.Fatalf("Could not set up email for health checks: %v", err)
+		}
+		readiness = append(readiness, health.Check{Name: "smtp", Run: func(ctx context.Context) error {
+			return emailService.Ping()
+		}})
+	}
+	mux.Handle("GET /healthz", health.NewChecker(liveness).Handler())
+	mux.Handle("GET /readyz", health.NewChecker(readiness...).Handler())
+
+	if s.FeedbackSecret != "" {
+		feedbackPath := filepath.Join(sentLogBase, "feedback.jsonl")
+		feedbackServer := readerfeedback.NewServer(
+			readerfeedback.NewLinks(s.FeedbackBaseURL, s.FeedbackSecret),
+			items,
+			feedbackPath,
+		)
+		// Only the signed vote links are public; the precision they add up to is served by the API
+		mux.Handle("/feedback", feedbackServer.Handler())
+		apiServer.SetFeedbackPath(feedbackPath)
+	}
+
+	if s.SendBenchmarkToAuditService {
+		// Retry run data the processor could not submit, so it reaches the audit service between runs
+		auditClient := bench.NewAuditClient(s.AuditServiceUrl, s.AuditServiceToken, filepath.Join(sentLogBase, "audit_outbox"))
+		auditClient.GzipMinBytes = s.AuditServiceGzipMinKB * 1024
+		go auditClient.RunOutbox(context.Background(), bench.DefaultAuditRetryInterval)
+	}
+
+	if s.ApiToken == "" {
+		log.Println("Warning: ANP_API_TOKEN is not set, the API and dashboard are open to anyone who can reach them")
+	}
+	log.Printf("Daemon listening on %s", s.DaemonAddr)
+	log.Fatal(http.ListenAndServe(s.DaemonAddr, mux))
+}
@@ -0,0 +1,65 @@
+// Command discover suggests additional subreddits for a persona by searching Reddit for each of
+// its focus areas and reporting subscriber and activity stats for the matches.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/bakkerme/ai-news-processor/internal/discovery"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/internal/secrets"
+	"github.com/bakkerme/ai-news-processor/internal/specification"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	personaFlag := flag.String("persona", "", "Name of the persona to find subreddits for")
+	limitFlag := flag.Int("limit", discovery.DefaultOptions.Limit, "Maximum number of suggestions")
+	minSubscribersFlag := flag.Int("min-subscribers", discovery.DefaultOptions.MinSubscribers, "Skip subreddits with fewer subscribers")
+	nsfwFlag := flag.Bool("nsfw", false, "Include NSFW subreddits")
+	flag.Parse()
+
+	if *personaFlag == "" || *personaFlag == "all" {
+		log.Fatal("-persona must name a single persona")
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Printf("No .env file found or error loading it: %v", err)
+	}
+
+	personas, err := persona.LoadAndSelect(specification.LoadPaths().Personas, *personaFlag)
+	if err != nil {
+		log.Fatalf("Could not load persona: %v", err)
+	}
+
+	searcher, err := discovery.NewRedditSearcher(
+		os.Getenv("ANP_REDDIT_CLIENT_ID"),
+		secrets.Getenv("ANP_REDDIT_CLIENT_SECRET"),
+		os.Getenv("ANP_REDDIT_USERNAME"),
+		secrets.Getenv("ANP_REDDIT_PASSWORD"),
+	)
+	if err != nil {
+		log.Fatalf("Could not create Reddit client: %v", err)
+	}
+
+	opts := discovery.DefaultOptions
+	opts.Limit = *limitFlag
+	opts.MinSubscribers = *minSubscribersFlag
+	opts.IncludeNSFW = *nsfwFlag
+
+	suggestions, err := discovery.Suggest(context.Background(), searcher, personas[0], opts)
+	if err != nil {
+		log.Fatalf("Could not discover subreddits: %v", err)
+	}
+	if len(suggestions) == 0 {
+		log.Printf("No subreddits found for persona %s", personas[0].Name)
+		return
+	}
+
+	if err := discovery.WriteTable(os.Stdout, suggestions); err != nil {
+		log.Fatalf("Could not write suggestions: %v", err)
+	}
+}
@@ -0,0 +1,145 @@
+// Command experiment compares two prompt, model or persona variants on the entries captured in a
+// benchmark file. Each entry is processed by both variants and a judge model picks the better
+// output; the command reports the win rate of each variant.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bakkerme/ai-news-processor/internal/bench"
+	"github.com/bakkerme/ai-news-processor/internal/experiments"
+	"github.com/bakkerme/ai-news-processor/internal/openai"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/internal/prompts"
+	"github.com/bakkerme/ai-news-processor/internal/secrets"
+	"github.com/bakkerme/ai-news-processor/internal/specification"
+	"github.com/joho/godotenv"
+)
+
+// variantFlags are the flags describing one side of the experiment
+type variantFlags struct {
+	name    *string
+	model   *string
+	prompt  *string
+	persona *string
+}
+
+func newVariantFlags(side string) variantFlags {
+	return variantFlags{
+		name:    flag.String(side+"-name", strings.ToUpper(side), "Name of variant "+side+" in the report"),
+		model:   flag.String(side+"-model", "", "Model of variant "+side+" (defaults to ANP_LLM_MODEL)"),
+		prompt:  flag.String(side+"-prompt", "", "Prompt template file replacing the base prompt for variant "+side),
+		persona: flag.String(side+"-persona", "", "Persona to compose the prompt of variant "+side+" from (defaults to the persona of the run)"),
+	}
+}
+
+func main() {
+	inputFlag := flag.String("input", "", "Benchmark JSON file with the captured entries (defaults to benchmark.json in the benchmark directory)")
+	judgeFlag := flag.String("judge", "", "Judge model (defaults to the first of ANP_JUDGE_MODELS, or ANP_LLM_MODEL)")
+	workersFlag := flag.Int("workers", 4, "Maximum number of entries processed at once")
+	limitFlag := flag.Int("limit", 0, "Only use the first n entries, 0 for all")
+	outputFlag := flag.String("output", "", "File to write the report JSON to (defaults to experiment.json next to the input)")
+	variantA := newVariantFlags("a")
+	variantB := newVariantFlags("b")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Printf("No .env file found or error loading it: %v", err)
+	}
+	paths := specification.LoadPaths()
+	if err := prompts.SetTemplateDir(paths.Prompts); err != nil {
+		log.Fatalf("Could not load prompt templates: %v", err)
+	}
+
+	input := *inputFlag
+	if input == "" {
+		input = filepath.Join(paths.Benchmarks, "benchmark.json")
+	}
+	data, err := bench.LoadRunDataFile(input)
+	if err != nil {
+		log.Fatalf("Could not load captured entries: %v", err)
+	}
+	if *limitFlag > 0 && len(data.EntrySummaries) > *limitFlag {
+		data.EntrySummaries = data.EntrySummaries[:*limitFlag]
+	}
+
+	url, apiKey := os.Getenv("ANP_LLM_URL"), secrets.Getenv("ANP_LLM_API_KEY")
+	a := buildVariant(variantA, data.Persona, paths.Personas, url, apiKey)
+	b := buildVariant(variantB, data.Persona, paths.Personas, url, apiKey)
+
+	judgeModel := *judgeFlag
+	if judgeModel == "" {
+		judgeModel, _, _ = strings.Cut(os.Getenv("ANP_JUDGE_MODELS"), ",")
+		judgeModel = strings.TrimSpace(judgeModel)
+	}
+	if judgeModel == "" {
+		judgeModel = os.Getenv("ANP_LLM_MODEL")
+	}
+	judgeKey := secrets.Getenv("ANP_JUDGE_API_KEY")
+	if judgeKey == "" {
+		judgeKey = apiKey
+	}
+	judge := openai.NewWithSafeTimeouts(envOr("ANP_JUDGE_URL", url), judgeKey, judgeModel)
+
+	log.Printf("Comparing %s and %s on %d entries, judged by %s", a.Name, b.Name, len(data.EntrySummaries), judgeModel)
+	report, err := experiments.Run(data, a, b, judge, judgeModel, *workersFlag)
+	if err != nil {
+		log.Fatalf("Could not run experiment: %v", err)
+	}
+
+	output := *outputFlag
+	if output == "" {
+		output = filepath.Join(filepath.Dir(input), "experiment.json")
+	}
+	if err := experiments.WriteReport(output, report); err != nil {
+		log.Fatalf("Could not write report: %v", err)
+	}
+	log.Printf("Report written to %s", output)
+
+	experiments.PrintReport(os.Stdout, report)
+}
+
+// buildVariant creates a variant from its flags, falling back to the persona of the run and the
+// default model
+func buildVariant(flags variantFlags, runPersona persona.Persona, personasPath, url, apiKey string) experiments.Variant {
+	p := runPersona
+	if *flags.persona != "" {
+		personas, err := persona.LoadAndSelect(personasPath, *flags.persona)
+		if err != nil {
+			log.Fatalf("Could not load persona for variant %s: %v", *flags.name, err)
+		}
+		p = personas[0]
+	}
+
+	var templateText string
+	if *flags.prompt != "" {
+		raw, err := os.ReadFile(*flags.prompt)
+		if err != nil {
+			log.Fatalf("Could not read prompt template for variant %s: %v", *flags.name, err)
+		}
+		templateText = string(raw)
+	}
+
+	model := os.Getenv("ANP_LLM_MODEL")
+	if *flags.model != "" {
+		model = *flags.model
+	}
+
+	variant, err := experiments.NewVariant(*flags.name, openai.NewWithSafeTimeouts(url, apiKey, model), p, templateText)
+	if err != nil {
+		log.Fatalf("Could not create variant: %v", err)
+	}
+	return variant
+}
+
+// envOr returns the environment variable key, or fallback if it is not set
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
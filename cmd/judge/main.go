@@ -0,0 +1,170 @@
+// Command judge evaluates benchmark runs with two or more LLM judges. Each judge gives a relevance
+// verdict and a quality score for every processed item; the command reports how well the judges
+// agree (Cohen's kappa on relevance) and lists the items they disagree on for human review.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bakkerme/ai-news-processor/internal/bench"
+	"github.com/bakkerme/ai-news-processor/internal/openai"
+	"github.com/bakkerme/ai-news-processor/internal/secrets"
+	"github.com/bakkerme/ai-news-processor/internal/specification"
+	"github.com/bakkerme/ai-news-processor/models"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	inputFlag := flag.String("input", "", "Benchmark JSON file to evaluate (defaults to the latest run of every persona in the benchmark directory)")
+	personaFlag := flag.String("persona", "all", "Persona to evaluate when no -input is given, or 'all'")
+	judgesFlag := flag.String("judges", "", "Comma-separated judge models (defaults to ANP_JUDGE_MODELS)")
+	outputFlag := flag.String("output", "", "File to write the evaluation JSON to (defaults to evaluation.json next to -input, or evaluation_summary.json in the benchmark directory)")
+	defaults := bench.DefaultEvaluateOptions()
+	maxSpreadFlag := flag.Int("max-quality-spread", defaults.MaxQualitySpread, "Largest difference in quality scores between judges before an item is flagged for review")
+	workersFlag := flag.Int("workers", 0, "Maximum number of judge requests in flight at once (defaults to ANP_JUDGE_WORKERS, or 4)")
+	rateFlag := flag.Int("requests-per-minute", -1, "Maximum requests per minute to each judge model, 0 for no limit (defaults to ANP_JUDGE_REQUESTS_PER_MINUTE, or 0)")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Printf("No .env file found or error loading it: %v", err)
+	}
+
+	modelList := *judgesFlag
+	if modelList == "" {
+		modelList = os.Getenv("ANP_JUDGE_MODELS")
+	}
+	url := envOr("ANP_JUDGE_URL", os.Getenv("ANP_LLM_URL"))
+	apiKey := secrets.Getenv("ANP_JUDGE_API_KEY")
+	if apiKey == "" {
+		apiKey = secrets.Getenv("ANP_LLM_API_KEY")
+	}
+
+	var judges []bench.Judge
+	for _, model := range strings.Split(modelList, ",") {
+		model = strings.TrimSpace(model)
+		if model == "" {
+			continue
+		}
+		judges = append(judges, bench.Judge{Name: model, Client: openai.NewWithSafeTimeouts(url, apiKey, model)})
+	}
+	if len(judges) < 2 {
+		log.Fatal("At least two judge models are required, set -judges or ANP_JUDGE_MODELS")
+	}
+
+	opts := bench.EvaluateOptions{
+		MaxQualitySpread:  *maxSpreadFlag,
+		Workers:           *workersFlag,
+		RequestsPerMinute: *rateFlag,
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = intEnvOr("ANP_JUDGE_WORKERS", defaults.Workers)
+	}
+	if opts.RequestsPerMinute < 0 {
+		opts.RequestsPerMinute = intEnvOr("ANP_JUDGE_REQUESTS_PER_MINUTE", defaults.RequestsPerMinute)
+	}
+
+	if *inputFlag != "" {
+		evaluateFile(*inputFlag, *outputFlag, judges, opts)
+		return
+	}
+	evaluatePersonas(*personaFlag, *outputFlag, judges, opts)
+}
+
+// evaluateFile evaluates a single benchmark file
+func evaluateFile(input, output string, judges []bench.Judge, opts bench.EvaluateOptions) {
+	data, err := bench.LoadRunDataFile(input)
+	if err != nil {
+		log.Fatalf("Could not load benchmark file: %v", err)
+	}
+
+	log.Printf("Evaluating %d entries from %s with %d judges (%d workers)", len(data.EntrySummaries), input, len(judges), opts.Workers)
+	eval, err := bench.EvaluateRun(data, judges, opts)
+	if err != nil {
+		log.Fatalf("Could not evaluate run: %v", err)
+	}
+
+	if output == "" {
+		output = filepath.Join(filepath.Dir(input), "evaluation.json")
+	}
+	if err := bench.WriteEvaluation(output, eval); err != nil {
+		log.Fatalf("Could not write evaluation: %v", err)
+	}
+	log.Printf("Evaluation written to %s", output)
+
+	bench.PrintEvaluation(os.Stdout, eval)
+}
+
+// evaluatePersonas evaluates the latest run of each selected persona in the benchmark directory,
+// writing an evaluation per persona and a combined summary
+func evaluatePersonas(personaName, output string, judges []bench.Judge, opts bench.EvaluateOptions) {
+	benchmarkDir := specification.LoadPaths().Benchmarks
+	runs, err := bench.LoadRunData(benchmarkDir)
+	if err != nil {
+		log.Fatalf("Could not load run data: %v", err)
+	}
+
+	var selected []models.RunData
+	for _, run := range runs {
+		if personaName == "all" || personaName == "" || run.Persona.Name == personaName {
+			selected = append(selected, run)
+		}
+	}
+	if len(selected) == 0 {
+		log.Fatalf("No run data for persona '%s' in %s", personaName, benchmarkDir)
+	}
+	sort.Slice(selected, func(i, j int) bool { return selected[i].Persona.Name < selected[j].Persona.Name })
+
+	summary := &bench.Summary{}
+	for i := range selected {
+		data := &selected[i]
+		log.Printf("Evaluating %d entries for persona %s with %d judges", len(data.EntrySummaries), data.Persona.Name, len(judges))
+		eval, err := bench.EvaluateRun(data, judges, opts)
+		if err != nil {
+			log.Fatalf("Could not evaluate run of persona %s: %v", data.Persona.Name, err)
+		}
+
+		evalPath := filepath.Join(benchmarkDir, fmt.Sprintf("evaluation_%s.json", data.Persona.Name))
+		if err := bench.WriteEvaluation(evalPath, eval); err != nil {
+			log.Fatalf("Could not write evaluation: %v", err)
+		}
+		log.Printf("Evaluation of persona %s written to %s", data.Persona.Name, evalPath)
+
+		bench.PrintEvaluation(os.Stdout, eval)
+		fmt.Println()
+		summary.Add(evalPath, eval)
+	}
+
+	if output == "" {
+		output = filepath.Join(benchmarkDir, "evaluation_summary.json")
+	}
+	if err := bench.WriteSummary(output, summary); err != nil {
+		log.Fatalf("Could not write summary: %v", err)
+	}
+	log.Printf("Summary written to %s", output)
+
+	bench.PrintSummary(os.Stdout, summary)
+}
+
+// envOr returns the environment variable key, or fallback if it is not set
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// intEnvOr returns the environment variable key as an integer, or fallback if it is not set or invalid
+func intEnvOr(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,135 @@
+// Command personas checks persona files before they are used in a production run. The validate
+// mode loads every persona file, validates it, renders its base, summary and image prompts and
+// reports an estimate of their size in tokens. It exits non-zero if any persona has a problem:
+//
+//	personas validate
+//	personas validate -persona LocalLLaMa -show
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/internal/prompts"
+	"github.com/bakkerme/ai-news-processor/internal/specification"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	switch os.Args[1] {
+	case "validate":
+		os.Exit(validate(os.Args[2:]))
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: personas validate [-dir <personas>] [-persona <name>] [-show] [-max-tokens <n>]")
+	os.Exit(2)
+}
+
+// validate runs the validate mode and returns the exit code: 0 if every persona is valid, 1 otherwise
+func validate(args []string) int {
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+	dirFlag := flags.String("dir", "", "Directory with the persona files (defaults to ANP_PERSONAS_PATH)")
+	personaFlag := flags.String("persona", "all", "Persona to check, or 'all'")
+	showFlag := flags.Bool("show", false, "Print the rendered prompts")
+	maxTokensFlag := flags.Int("max-tokens", 0, "Fail if a prompt is estimated to exceed this many tokens, 0 for no limit")
+	flags.Parse(args)
+
+	if err := godotenv.Load(); err != nil {
+		log.Printf("No .env file found or error loading it: %v", err)
+	}
+	paths := specification.LoadPaths()
+	if err := prompts.SetTemplateDir(paths.Prompts); err != nil {
+		log.Fatalf("Could not load prompt templates: %v", err)
+	}
+	dir := *dirFlag
+	if dir == "" {
+		dir = paths.Personas
+	}
+
+	files, err := persona.PersonaFiles(dir)
+	if err != nil {
+		log.Fatalf("Could not read personas: %v", err)
+	}
+	if len(files) == 0 {
+		log.Fatalf("No persona files in %s", dir)
+	}
+
+	failed := 0
+	checked := 0
+	seen := make(map[string]string) // Persona name to the file that defined it first
+	for _, path := range files {
+		file := filepath.Base(path)
+		p, err := persona.LoadFile(path, true)
+		if err != nil {
+			// The name is unknown, so a broken file is always reported
+			fmt.Printf("FAIL %s\n  %v\n\n", file, err)
+			failed++
+			continue
+		}
+		if *personaFlag != "all" && *personaFlag != "" && p.Name != *personaFlag {
+			continue
+		}
+		checked++
+
+		var problems []string
+		if first, ok := seen[p.Name]; ok {
+			problems = append(problems, fmt.Sprintf("name %s is also used by %s", p.Name, first))
+		} else {
+			seen[p.Name] = file
+		}
+
+		previews := prompts.PreviewPrompts(p)
+		for _, preview := range previews {
+			if preview.Err != nil {
+				problems = append(problems, fmt.Sprintf("%s prompt: %v", preview.Name, preview.Err))
+			} else if *maxTokensFlag > 0 && preview.Tokens > *maxTokensFlag {
+				problems = append(problems, fmt.Sprintf("%s prompt is ~%d tokens, more than %d", preview.Name, preview.Tokens, *maxTokensFlag))
+			}
+		}
+
+		status := "OK  "
+		if len(problems) > 0 {
+			status = "FAIL"
+			failed++
+		}
+		fmt.Printf("%s %s (%s, %s)\n", status, p.Name, file, p.GetProvider())
+		for _, problem := range problems {
+			fmt.Printf("  %s\n", problem)
+		}
+		for _, preview := range previews {
+			if preview.Err == nil {
+				fmt.Printf("  %-8s prompt %6d chars  ~%d tokens\n", preview.Name, len(preview.Text), preview.Tokens)
+			}
+		}
+		if *showFlag {
+			for _, preview := range previews {
+				if preview.Err == nil {
+					fmt.Printf("\n--- %s prompt ---\n%s\n", preview.Name, preview.Text)
+				}
+			}
+		}
+		fmt.Println()
+	}
+
+	if checked == 0 && failed == 0 {
+		log.Fatalf("Persona '%s' not found in %s", *personaFlag, dir)
+	}
+	if failed > 0 {
+		fmt.Printf("%d persona file(s) failed validation\n", failed)
+		return 1
+	}
+	fmt.Printf("All %d persona(s) are valid\n", checked)
+	return 0
+}
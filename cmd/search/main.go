@@ -0,0 +1,93 @@
+// Command search finds items sent in earlier digests by keyword, or by meaning when an embedding
+// model is configured with ANP_LLM_EMBEDDING_MODEL.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/itemstore"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/internal/search"
+	"github.com/bakkerme/ai-news-processor/internal/secrets"
+	"github.com/bakkerme/ai-news-processor/internal/specification"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	personaFlag := flag.String("persona", "all", "Name of the persona whose items are searched, or all")
+	sinceFlag := flag.String("since", "", "Only items sent on or after this date (YYYY-MM-DD)")
+	untilFlag := flag.String("until", "", "Only items sent before this date (YYYY-MM-DD)")
+	limitFlag := flag.Int("limit", search.DefaultLimit, "Maximum number of results")
+	semanticFlag := flag.Bool("semantic", false, "Rank items by similarity of meaning, using ANP_LLM_EMBEDDING_MODEL")
+	jsonFlag := flag.Bool("json", false, "Write the results as JSON instead of a table")
+	flag.Parse()
+
+	text := strings.Join(flag.Args(), " ")
+	if strings.TrimSpace(text) == "" {
+		log.Fatal("usage: search [flags] <query>")
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Printf("No .env file found or error loading it: %v", err)
+	}
+
+	paths := specification.LoadPaths()
+	personas, err := persona.LoadAndSelect(paths.Personas, *personaFlag)
+	if err != nil {
+		log.Fatalf("Could not load personas: %v", err)
+	}
+
+	query := search.Query{Text: text, Limit: *limitFlag, Semantic: *semanticFlag}
+	for _, p := range personas {
+		query.Personas = append(query.Personas, p.Name)
+	}
+	if query.Since, err = parseDate(*sinceFlag); err != nil {
+		log.Fatalf("Invalid -since: %v", err)
+	}
+	if query.Until, err = parseDate(*untilFlag); err != nil {
+		log.Fatalf("Invalid -until: %v", err)
+	}
+
+	embedder := search.NewEmbedder(
+		os.Getenv("ANP_LLM_URL"),
+		secrets.Getenv("ANP_LLM_API_KEY"),
+		os.Getenv("ANP_LLM_EMBEDDING_MODEL"),
+		paths.State,
+	)
+	searcher := search.New(itemstore.New(filepath.Join(paths.State, "items")), embedder)
+	results, err := searcher.Search(context.Background(), query)
+	if err != nil {
+		log.Fatalf("Could not search items: %v", err)
+	}
+
+	if *jsonFlag {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(results); err != nil {
+			log.Fatalf("Could not write results: %v", err)
+		}
+		return
+	}
+	if len(results) == 0 {
+		log.Printf("No items match %q", text)
+		return
+	}
+	if err := search.WriteTable(os.Stdout, results); err != nil {
+		log.Fatalf("Could not write results: %v", err)
+	}
+}
+
+// parseDate parses a YYYY-MM-DD date in local time, or returns the zero time for an empty value
+func parseDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.ParseInLocation("2006-01-02", value, time.Local)
+}
@@ -0,0 +1,89 @@
+// Command tune suggests edits to a persona's relevance and exclusion criteria based on recorded
+// misclassifications. The suggestion is printed as a unified diff of the persona YAML, which can be
+// reviewed and applied with -apply, patch(1) or git apply.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/bakkerme/ai-news-processor/internal/openai"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/internal/secrets"
+	"github.com/bakkerme/ai-news-processor/internal/specification"
+	"github.com/bakkerme/ai-news-processor/internal/tuning"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	personaFlag := flag.String("persona", "", "Name of the persona to tune")
+	feedbackFlag := flag.String("feedback", "", "Feedback JSON Lines file (defaults to feedback.jsonl next to the sent log)")
+	minMistakesFlag := flag.Int("min-mistakes", 3, "Minimum number of misclassifications required before suggesting changes")
+	applyFlag := flag.Bool("apply", false, "Write the suggested criteria to the persona file instead of only printing the diff")
+	flag.Parse()
+
+	if *personaFlag == "" || *personaFlag == "all" {
+		log.Fatal("-persona must name a single persona")
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Printf("No .env file found or error loading it: %v", err)
+	}
+
+	paths := specification.LoadPaths()
+	personas, err := persona.LoadAndSelect(paths.Personas, *personaFlag)
+	if err != nil {
+		log.Fatalf("Could not load persona: %v", err)
+	}
+	p := personas[0]
+
+	feedbackPath := *feedbackFlag
+	if feedbackPath == "" {
+		feedbackPath = filepath.Join(paths.State, "feedback.jsonl")
+	}
+	records, err := tuning.LoadFeedback(feedbackPath)
+	if err != nil {
+		log.Fatalf("Could not load feedback: %v", err)
+	}
+	mistakes := tuning.Mistakes(records, p.Name)
+	if len(mistakes) < *minMistakesFlag {
+		log.Printf("Only %d misclassifications recorded for persona %s (need %d), nothing to suggest", len(mistakes), p.Name, *minMistakesFlag)
+		return
+	}
+	log.Printf("Suggesting criteria changes for persona %s from %d misclassifications", p.Name, len(mistakes))
+
+	client := openai.NewWithSafeTimeouts(os.Getenv("ANP_LLM_URL"), secrets.Getenv("ANP_LLM_API_KEY"), os.Getenv("ANP_LLM_MODEL"))
+	suggestion, err := tuning.Suggest(client, p, mistakes)
+	if err != nil {
+		log.Fatalf("Could not generate suggestion: %v", err)
+	}
+
+	file, err := tuning.FindPersonaFile(paths.Personas, p.Name)
+	if err != nil {
+		log.Fatalf("Could not find persona file: %v", err)
+	}
+	original, err := os.ReadFile(file)
+	if err != nil {
+		log.Fatalf("Could not read persona file: %v", err)
+	}
+	updated := tuning.ApplySuggestion(string(original), suggestion)
+
+	diff := tuning.UnifiedDiff("a/"+filepath.Base(file), "b/"+filepath.Base(file), string(original), updated)
+	if diff == "" {
+		log.Printf("The suggested criteria match the current persona, nothing to change")
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "Rationale: %s\n\n", suggestion.Rationale)
+	fmt.Print(diff)
+
+	if *applyFlag {
+		if err := os.WriteFile(file, []byte(updated), 0644); err != nil {
+			log.Fatalf("Could not write persona file: %v", err)
+		}
+		log.Printf("Updated %s", file)
+	}
+}
@@ -0,0 +1,130 @@
+// Package analytics exports every processed item with the relevance decision made about it, so
+// filtering behavior can be analyzed across runs with tools such as pandas or DuckDB.
+package analytics
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
+	"github.com/bakkerme/ai-news-processor/models"
+)
+
+// What happened to a processed item
+const (
+	DecisionSelected        = "selected"         // Relevant and included in the digest
+	DecisionNotRelevant     = "not_relevant"     // Judged not relevant to the persona
+	DecisionAlreadySent     = "already_sent"     // Relevant, but sent in an earlier digest
+	DecisionBelowImportance = "below_importance" // Relevant, but scored below the persona's minimum importance
+)
+
+// Record is a processed item as exported, one per line
+type Record struct {
+	RunAt            time.Time   `json:"runAt"`
+	Persona          string      `json:"persona"`
+	EntryID          string      `json:"entryId"`
+	InputHash        string      `json:"inputHash,omitempty"`        // SHA-256 of the raw input sent to the LLM
+	SystemPromptHash string      `json:"systemPromptHash,omitempty"` // Hash of the system prompt, as in the run data
+	Model            string      `json:"model"`
+	PromptTokens     int64       `json:"promptTokens"`
+	CompletionTokens int64       `json:"completionTokens"`
+	ProcessingTimeMs int64       `json:"processingTimeMs"`
+	IsRelevant       bool        `json:"isRelevant"`
+	Watchlist        []string    `json:"watchlist,omitempty"` // Watchlist terms that kept the item regardless of relevance
+	ImportanceScore  int         `json:"importanceScore,omitempty"`
+	Decision         string      `json:"decision"` // One of the Decision* constants
+	Item             models.Item `json:"item"`     // The processed item, without the feed entry
+}
+
+// Records builds the records of a persona's run from its run data and items. selected are the
+// items that made it into the digest, and sentIDs the IDs sent by earlier runs.
+func Records(runAt time.Time, personaName string, data models.RunData, items, selected []models.Item, sentIDs map[string]struct{}) []Record {
+	summaries := make(map[string]models.EntrySummary, len(data.EntrySummaries))
+	for _, summary := range data.EntrySummaries {
+		summaries[summary.Results.ID] = summary
+	}
+	selectedIDs := make(map[string]struct{}, len(selected))
+	for _, item := range selected {
+		selectedIDs[item.ID] = struct{}{}
+	}
+
+	records := make([]Record, 0, len(items))
+	for _, item := range items {
+		record := Record{
+			RunAt:           runAt,
+			Persona:         personaName,
+			EntryID:         item.ID,
+			Model:           data.OverallModelUsed,
+			IsRelevant:      item.IsRelevant,
+			Watchlist:       item.Watchlist,
+			ImportanceScore: item.ImportanceScore,
+			Decision:        decision(item, selectedIDs, sentIDs),
+		}
+		if summary, ok := summaries[item.ID]; ok {
+			record.InputHash = hashInput(summary.RawInput)
+			record.SystemPromptHash = summary.SystemPromptHash
+			record.PromptTokens = summary.PromptTokens
+			record.CompletionTokens = summary.CompletionTokens
+			record.ProcessingTimeMs = summary.ProcessingTime
+		}
+		record.Item = item
+		record.Item.Entry = feeds.Entry{Published: item.Entry.Published}
+		records = append(records, record)
+	}
+	return records
+}
+
+// decision works out why an item was or was not included, in the order the filters run
+func decision(item models.Item, selectedIDs, sentIDs map[string]struct{}) string {
+	if _, ok := selectedIDs[item.ID]; ok {
+		return DecisionSelected
+	}
+	if !item.IsRelevant && !item.Unsure && len(item.Watchlist) == 0 {
+		return DecisionNotRelevant
+	}
+	if _, ok := sentIDs[item.ID]; ok {
+		return DecisionAlreadySent
+	}
+	return DecisionBelowImportance
+}
+
+func hashInput(input string) string {
+	if input == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(input))
+	return hex.EncodeToString(sum[:])
+}
+
+// Append adds records to the JSON Lines file at path, creating it if needed
+func Append(path string, records []Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("could not create analytics export directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("could not open analytics export: %w", err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return fmt.Errorf("could not encode record of item %s: %w", record.EntryID, err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("could not write analytics export: %w", err)
+	}
+	return nil
+}
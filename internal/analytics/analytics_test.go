@@ -0,0 +1,78 @@
+package analytics
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
+	"github.com/bakkerme/ai-news-processor/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecords(t *testing.T) {
+	runAt := time.Date(2025, time.March, 7, 6, 0, 0, 0, time.UTC)
+	items := []models.Item{
+		{ID: "picked", IsRelevant: true, ImportanceScore: 8, Entry: feeds.Entry{Content: "long post"}},
+		{ID: "off-topic", IsRelevant: false},
+		{ID: "watched", IsRelevant: false, Watchlist: []string{"Qwen"}},
+		{ID: "repeat", IsRelevant: true},
+		{ID: "minor", IsRelevant: true, ImportanceScore: 2},
+	}
+	data := models.RunData{
+		OverallModelUsed: "qwen3-30b",
+		EntrySummaries: []models.EntrySummary{
+			{RawInput: "input", Results: models.Item{ID: "picked"}, PromptTokens: 900, CompletionTokens: 120, ProcessingTime: 1500, SystemPromptHash: "abc"},
+		},
+	}
+	selected := []models.Item{items[0], items[2]}
+	sentIDs := map[string]struct{}{"repeat": {}}
+
+	records := Records(runAt, "LocalLLaMA", data, items, selected, sentIDs)
+	require.Len(t, records, 5)
+
+	decisions := make(map[string]string)
+	for _, record := range records {
+		decisions[record.EntryID] = record.Decision
+	}
+	assert.Equal(t, map[string]string{
+		"picked":    DecisionSelected,
+		"off-topic": DecisionNotRelevant,
+		"watched":   DecisionSelected,
+		"repeat":    DecisionAlreadySent,
+		"minor":     DecisionBelowImportance,
+	}, decisions)
+
+	picked := records[0]
+	assert.Equal(t, "LocalLLaMA", picked.Persona)
+	assert.Equal(t, "qwen3-30b", picked.Model)
+	assert.Equal(t, "c96c6d5be8d08a12e7b5cdc1b207fa6b2430974c86803d8891675e76fd992c20", picked.InputHash)
+	assert.Equal(t, int64(900), picked.PromptTokens)
+	assert.Equal(t, int64(120), picked.CompletionTokens)
+	assert.Equal(t, "abc", picked.SystemPromptHash)
+	assert.Empty(t, picked.Item.Entry.Content, "the feed entry is not exported")
+	assert.Empty(t, records[1].InputHash, "items without run data have no input hash")
+}
+
+func TestAppend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "exports", "items.jsonl")
+	require.NoError(t, Append(path, []Record{{EntryID: "a", Decision: DecisionSelected}}))
+	require.NoError(t, Append(path, []Record{{EntryID: "b", Decision: DecisionNotRelevant}}))
+	require.NoError(t, Append(path, nil))
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	var ids []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record Record
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		ids = append(ids, record.EntryID)
+	}
+	assert.Equal(t, []string{"a", "b"}, ids)
+}
@@ -0,0 +1,286 @@
+// Package api serves a JSON REST API over personas, runs and sent items, so dashboards and
+// automations can integrate with the processor without reading its files directly.
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/itemstore"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/internal/providers/ingest"
+	"github.com/bakkerme/ai-news-processor/internal/readerfeedback"
+	"github.com/bakkerme/ai-news-processor/internal/runhistory"
+	"github.com/bakkerme/ai-news-processor/internal/search"
+)
+
+// DefaultItemDays is how far back item listings go when no since parameter is given
+const DefaultItemDays = 7
+
+// Server serves the API
+type Server struct {
+	personas persona.Source
+	runs     *runhistory.Store
+	items    *itemstore.Store
+	search   *search.Searcher
+	runner   Runner
+	ingest   *ingest.Queue
+	token    string
+	feedback string // Reader feedback file; empty when reader feedback is off
+	now      func() time.Time
+}
+
+// NewServer creates an API server. Items posted for ingest personas are pushed to the ingest
+// queue, if it is not nil. If token is not empty, every request must carry it as a bearer token.
+func NewServer(personas persona.Source, runs *runhistory.Store, items *itemstore.Store, runner Runner, ingestQueue *ingest.Queue, token string) *Server {
+	return &Server{
+		personas: personas,
+		runs:     runs,
+		items:    items,
+		search:   search.New(items, nil),
+		runner:   runner,
+		ingest:   ingestQueue,
+		token:    token,
+		now:      time.Now,
+	}
+}
+
+// SetEmbedder enables semantic searches, which rank items by the similarity of their embeddings
+// to the query
+func (s *Server) SetEmbedder(embedder search.Embedder) {
+	s.search = search.New(s.items, embedder)
+}
+
+// SetFeedbackPath enables the reader precision per persona, tallied from the reader feedback file
+// at path
+func (s *Server) SetFeedbackPath(path string) {
+	s.feedback = path
+}
+
+// Handler returns the HTTP handler serving the API under /api/
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/personas", s.handlePersonas)
+	mux.HandleFunc("GET /api/personas/{name}/items", s.handleItems)
+	mux.HandleFunc("POST /api/personas/{name}/ingest", s.handleIngest)
+	mux.HandleFunc("GET /api/search", s.handleSearch)
+	mux.HandleFunc("GET /api/runs", s.handleRuns)
+	mux.HandleFunc("POST /api/runs", s.handleStartRun)
+	mux.HandleFunc("GET /api/runs/status", s.handleRunStatus)
+	mux.HandleFunc("GET /api/runs/{id}", s.handleRun)
+	mux.HandleFunc("GET /api/feedback/stats", s.handleFeedbackStats)
+	return s.authenticate(mux)
+}
+
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	if s.token == "" {
+		return next
+	}
+	expected := []byte("Bearer " + s.token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			writeError(w, http.StatusUnauthorized, "missing or invalid API token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handlePersonas(w http.ResponseWriter, r *http.Request) {
+	personas, err := s.personas.Personas()
+	if err != nil {
+		log.Printf("API: could not load personas: %v", err)
+		writeError(w, http.StatusInternalServerError, "could not load personas")
+		return
+	}
+	writeJSON(w, http.StatusOK, personas)
+}
+
+func (s *Server) handleItems(w http.ResponseWriter, r *http.Request) {
+	since := s.now().AddDate(0, 0, -DefaultItemDays)
+	if value := r.URL.Query().Get("since"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "since must be an RFC 3339 timestamp")
+			return
+		}
+		since = parsed
+	}
+
+	records, err := s.items.Since(r.PathValue("name"), since)
+	if err != nil {
+		log.Printf("API: could not load items: %v", err)
+		writeError(w, http.StatusInternalServerError, "could not load items")
+		return
+	}
+	if records == nil {
+		records = []itemstore.Record{}
+	}
+	writeJSON(w, http.StatusOK, records)
+}
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	query := search.Query{Text: params.Get("q"), Semantic: params.Get("mode") == "semantic"}
+	if query.Text == "" {
+		writeError(w, http.StatusBadRequest, "q is required")
+		return
+	}
+	if mode := params.Get("mode"); mode != "" && mode != "keyword" && mode != "semantic" {
+		writeError(w, http.StatusBadRequest, "mode must be keyword or semantic")
+		return
+	}
+	for name, bound := range map[string]*time.Time{"since": &query.Since, "until": &query.Until} {
+		if value := params.Get(name); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, name+" must be an RFC 3339 timestamp")
+				return
+			}
+			*bound = parsed
+		}
+	}
+	if value := params.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			writeError(w, http.StatusBadRequest, "limit must be a positive number")
+			return
+		}
+		query.Limit = limit
+	}
+
+	query.Personas = params["persona"]
+	if len(query.Personas) == 0 {
+		personas, err := s.personas.Personas()
+		if err != nil {
+			log.Printf("API: could not load personas: %v", err)
+			writeError(w, http.StatusInternalServerError, "could not load personas")
+			return
+		}
+		for _, p := range personas {
+			query.Personas = append(query.Personas, p.Name)
+		}
+	}
+
+	results, err := s.search.Search(r.Context(), query)
+	if err != nil {
+		if errors.Is(err, search.ErrNoEmbedder) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Printf("API: could not search items: %v", err)
+		writeError(w, http.StatusInternalServerError, "could not search items")
+		return
+	}
+	if results == nil {
+		results = []search.Result{}
+	}
+	writeJSON(w, http.StatusOK, results)
+}
+
+func (s *Server) handleRuns(w http.ResponseWriter, r *http.Request) {
+	runs, err := s.runs.List()
+	if err != nil {
+		log.Printf("API: could not list runs: %v", err)
+		writeError(w, http.StatusInternalServerError, "could not list runs")
+		return
+	}
+	// Digests and dropped entries can be large, they are served by the individual run endpoint
+	for i := range runs {
+		for j := range runs[i].Personas {
+			runs[i].Personas[j].Digest = nil
+			runs[i].Personas[j].Dropped = nil
+		}
+	}
+	writeJSON(w, http.StatusOK, runs)
+}
+
+func (s *Server) handleRun(w http.ResponseWriter, r *http.Request) {
+	run, err := s.runs.Get(r.PathValue("id"))
+	if errors.Is(err, runhistory.ErrNotFound) {
+		writeError(w, http.StatusNotFound, "run not found")
+		return
+	}
+	if err != nil {
+		log.Printf("API: could not load run: %v", err)
+		writeError(w, http.StatusInternalServerError, "could not load run")
+		return
+	}
+	writeJSON(w, http.StatusOK, run)
+}
+
+type startRunRequest struct {
+	Persona string `json:"persona"`
+}
+
+func (s *Server) handleStartRun(w http.ResponseWriter, r *http.Request) {
+	var request startRunRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+	}
+
+	// Check the persona exists before starting a process for it
+	personas, err := s.personas.Personas()
+	if err != nil {
+		log.Printf("API: could not load personas: %v", err)
+		writeError(w, http.StatusInternalServerError, "could not load personas")
+		return
+	}
+	if _, err := persona.Select(personas, request.Persona); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = s.runner.Start(request.Persona)
+	if errors.Is(err, ErrRunInProgress) {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("API: could not start run: %v", err)
+		writeError(w, http.StatusInternalServerError, "could not start run")
+		return
+	}
+	writeJSON(w, http.StatusAccepted, s.runner.Status())
+}
+
+func (s *Server) handleRunStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.runner.Status())
+}
+
+func (s *Server) handleFeedbackStats(w http.ResponseWriter, r *http.Request) {
+	if s.feedback == "" {
+		writeError(w, http.StatusNotFound, "reader feedback is not enabled")
+		return
+	}
+	stats, err := readerfeedback.LoadStats(s.feedback)
+	if err != nil {
+		log.Printf("API: could not load reader feedback: %v", err)
+		writeError(w, http.StatusInternalServerError, "could not load reader feedback")
+		return
+	}
+	if stats == nil {
+		stats = []readerfeedback.Precision{}
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("API: could not write response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,255 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/digest"
+	"github.com/bakkerme/ai-news-processor/internal/itemstore"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/internal/providers/ingest"
+	"github.com/bakkerme/ai-news-processor/internal/readerfeedback"
+	"github.com/bakkerme/ai-news-processor/internal/runhistory"
+	"github.com/bakkerme/ai-news-processor/internal/search"
+	"github.com/bakkerme/ai-news-processor/internal/tuning"
+	"github.com/bakkerme/ai-news-processor/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRunner struct {
+	started []string
+	err     error
+}
+
+func (r *fakeRunner) Start(personaName string) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.started = append(r.started, personaName)
+	return nil
+}
+
+func (r *fakeRunner) Status() RunStatus {
+	if len(r.started) == 0 {
+		return RunStatus{}
+	}
+	return RunStatus{Running: true, Persona: r.started[len(r.started)-1]}
+}
+
+func newTestServer(t *testing.T, token string) (*Server, *fakeRunner) {
+	t.Helper()
+	dir := t.TempDir()
+
+	personaDir := filepath.Join(dir, "personas")
+	require.NoError(t, os.MkdirAll(personaDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(personaDir, "localllama.yaml"), []byte("name: LocalLLaMA\nsubreddit: localllama\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(personaDir, "scraped.yaml"), []byte("name: Scraped\nprovider: ingest\n"), 0644))
+
+	now := time.Date(2025, time.March, 7, 12, 0, 0, 0, time.UTC)
+	items := itemstore.New(filepath.Join(dir, "items"))
+	require.NoError(t, items.Append("LocalLLaMA", []models.Item{{ID: "old", Title: "Old"}}, now.AddDate(0, 0, -10)))
+	require.NoError(t, items.Append("LocalLLaMA", []models.Item{{ID: "new", Title: "New"}}, now.AddDate(0, 0, -1)))
+
+	runs := runhistory.New(filepath.Join(dir, "runs"))
+	payload := digest.New("LocalLLaMA", []models.Item{{ID: "new", Title: "New"}}, nil, now)
+	require.NoError(t, runs.Save(runhistory.Run{
+		ID:        "20250307T060000Z",
+		StartedAt: now,
+		Personas:  []runhistory.Persona{{Name: "LocalLLaMA", Sent: 1, Digest: &payload}},
+	}))
+
+	runner := &fakeRunner{}
+	server := NewServer(persona.Dir(personaDir), runs, items, runner, ingest.NewQueue(filepath.Join(dir, "ingest")), token)
+	server.now = func() time.Time { return now }
+	return server, runner
+}
+
+func do(t *testing.T, handler http.Handler, method, target, body string, header http.Header) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestServer_ReadEndpoints(t *testing.T) {
+	server, _ := newTestServer(t, "")
+	handler := server.Handler()
+
+	rec := do(t, handler, http.MethodGet, "/api/personas", "", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var personas []struct{ Name string }
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &personas))
+	assert.Equal(t, "LocalLLaMA", personas[0].Name)
+
+	rec = do(t, handler, http.MethodGet, "/api/personas/LocalLLaMA/items", "", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var records []itemstore.Record
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &records))
+	require.Len(t, records, 1, "only the last week by default")
+	assert.Equal(t, "new", records[0].Item.ID)
+
+	rec = do(t, handler, http.MethodGet, "/api/personas/LocalLLaMA/items?since=2025-01-01T00:00:00Z", "", nil)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &records))
+	assert.Len(t, records, 2)
+
+	rec = do(t, handler, http.MethodGet, "/api/personas/LocalLLaMA/items?since=yesterday", "", nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = do(t, handler, http.MethodGet, "/api/personas/Unknown/items", "", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, "[]", rec.Body.String())
+
+	rec = do(t, handler, http.MethodGet, "/api/runs", "", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var runs []runhistory.Run
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &runs))
+	require.Len(t, runs, 1)
+	assert.Nil(t, runs[0].Personas[0].Digest, "run listings leave out digests")
+
+	rec = do(t, handler, http.MethodGet, "/api/runs/20250307T060000Z", "", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var run runhistory.Run
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &run))
+	require.NotNil(t, run.Personas[0].Digest)
+	assert.Equal(t, "New", run.Personas[0].Digest.Items[0].Title)
+
+	rec = do(t, handler, http.MethodGet, "/api/runs/20200101T000000Z", "", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestServer_StartRun(t *testing.T) {
+	server, runner := newTestServer(t, "")
+	handler := server.Handler()
+
+	rec := do(t, handler, http.MethodPost, "/api/runs", `{"persona":"LocalLLaMA"}`, nil)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Equal(t, []string{"LocalLLaMA"}, runner.started)
+
+	rec = do(t, handler, http.MethodPost, "/api/runs", "", nil)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Equal(t, []string{"LocalLLaMA", ""}, runner.started)
+
+	rec = do(t, handler, http.MethodPost, "/api/runs", `{"persona":"Unknown"}`, nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	runner.err = ErrRunInProgress
+	rec = do(t, handler, http.MethodPost, "/api/runs", `{"persona":"all"}`, nil)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = do(t, handler, http.MethodGet, "/api/runs/status", "", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"running":true`)
+}
+
+func TestServer_Ingest(t *testing.T) {
+	server, _ := newTestServer(t, "")
+	handler := server.Handler()
+
+	body := `{"items":[{"title":"New model","url":"https://example.com/model","comments":["Looks good"]}]}`
+	rec := do(t, handler, http.MethodPost, "/api/personas/Scraped/ingest", body, nil)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.JSONEq(t, `{"queued":1}`, rec.Body.String())
+
+	items, err := server.ingest.Drain("Scraped")
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, "New model", items[0].Title)
+	assert.NotEmpty(t, items[0].ID, "IDs default to a hash of the URL")
+
+	rec = do(t, handler, http.MethodPost, "/api/personas/Scraped/ingest", `{"items":[{"title":"No link"}]}`, nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "url or content is required")
+
+	rec = do(t, handler, http.MethodPost, "/api/personas/LocalLLaMA/ingest", body, nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "only ingest personas accept items")
+
+	rec = do(t, handler, http.MethodPost, "/api/personas/Unknown/ingest", body, nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = do(t, handler, http.MethodPost, "/api/personas/Scraped/ingest", "not json", nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestServer_Search(t *testing.T) {
+	server, _ := newTestServer(t, "")
+	handler := server.Handler()
+
+	rec := do(t, handler, http.MethodGet, "/api/search?q=old", "", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var results []search.Result
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &results))
+	require.Len(t, results, 1, "searches cover every persona and all dates by default")
+	assert.Equal(t, "LocalLLaMA", results[0].Persona)
+	assert.Equal(t, "old", results[0].Record.Item.ID)
+
+	rec = do(t, handler, http.MethodGet, "/api/search?q=old&persona=LocalLLaMA&since=2025-03-01T00:00:00Z", "", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, "[]", rec.Body.String())
+
+	rec = do(t, handler, http.MethodGet, "/api/search", "", nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = do(t, handler, http.MethodGet, "/api/search?q=old&limit=none", "", nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = do(t, handler, http.MethodGet, "/api/search?q=old&mode=semantic", "", nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "semantic search needs an embedding model")
+}
+
+func TestServer_Token(t *testing.T) {
+	server, _ := newTestServer(t, "s3cret")
+	handler := server.Handler()
+
+	rec := do(t, handler, http.MethodGet, "/api/runs", "", nil)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = do(t, handler, http.MethodGet, "/api/runs", "", http.Header{"Authorization": {"Bearer wrong"}})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = do(t, handler, http.MethodGet, "/api/runs", "", http.Header{"Authorization": {"Bearer s3cret"}})
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestServer_FeedbackStats(t *testing.T) {
+	server, _ := newTestServer(t, "s3cret")
+	handler := server.Handler()
+	auth := http.Header{"Authorization": {"Bearer s3cret"}}
+
+	rec := do(t, handler, http.MethodGet, "/api/feedback/stats", "", auth)
+	assert.Equal(t, http.StatusNotFound, rec.Code, "reader feedback is off")
+
+	feedbackPath := filepath.Join(t.TempDir(), "feedback.jsonl")
+	require.NoError(t, tuning.AppendFeedback(feedbackPath, tuning.Feedback{Persona: "LocalLLaMA", EntryID: "new", IsRelevant: true, ExpectedRelevant: true, Source: "reader"}))
+	server.SetFeedbackPath(feedbackPath)
+
+	rec = do(t, handler, http.MethodGet, "/api/feedback/stats", "", nil)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code, "stats need the API token")
+
+	rec = do(t, handler, http.MethodGet, "/api/feedback/stats", "", auth)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var stats []readerfeedback.Precision
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &stats))
+	assert.Equal(t, []readerfeedback.Precision{{Persona: "LocalLLaMA", Up: 1, Precision: 1}}, stats)
+}
+
+func TestCommandRunner(t *testing.T) {
+	runner := NewCommandRunner("sleep")
+	// sleep rejects the -persona flag and exits right away, which is enough to see the status change
+	require.NoError(t, runner.Start(""))
+	assert.Equal(t, "all", runner.Status().Persona)
+	assert.Eventually(t, func() bool { return !runner.Status().Running }, 5*time.Second, 10*time.Millisecond)
+	assert.NotEmpty(t, runner.Status().Error)
+
+	assert.Error(t, NewCommandRunner(filepath.Join(t.TempDir(), "missing")).Start("all"))
+}
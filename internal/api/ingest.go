@@ -0,0 +1,78 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/bakkerme/ai-news-processor/internal/providers/ingest"
+)
+
+// MaxIngestBytes is the largest request body accepted by the ingest endpoint
+const MaxIngestBytes = 5 << 20
+
+type ingestRequest struct {
+	Items []ingest.Item `json:"items"`
+}
+
+type ingestResponse struct {
+	Queued int `json:"queued"`
+}
+
+// handleIngest queues items for the next run of a persona with the ingest provider
+func (s *Server) handleIngest(w http.ResponseWriter, r *http.Request) {
+	if s.ingest == nil {
+		writeError(w, http.StatusNotFound, "ingest is not enabled")
+		return
+	}
+
+	personas, err := s.personas.Personas()
+	if err != nil {
+		log.Printf("API: could not load personas: %v", err)
+		writeError(w, http.StatusInternalServerError, "could not load personas")
+		return
+	}
+	name := r.PathValue("name")
+	found := false
+	for _, p := range personas {
+		if p.Name == name {
+			if p.GetProvider() != "ingest" {
+				writeError(w, http.StatusBadRequest, "persona does not use the ingest provider")
+				return
+			}
+			found = true
+		}
+	}
+	if !found {
+		writeError(w, http.StatusNotFound, "persona not found")
+		return
+	}
+
+	var request ingestRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, MaxIngestBytes)).Decode(&request); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, "request body is too large")
+			return
+		}
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if len(request.Items) == 0 {
+		writeError(w, http.StatusBadRequest, "items is required")
+		return
+	}
+
+	if err := s.ingest.Push(name, request.Items); err != nil {
+		var invalid *ingest.ValidationError
+		if errors.As(err, &invalid) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Printf("API: could not queue items: %v", err)
+		writeError(w, http.StatusInternalServerError, "could not queue items")
+		return
+	}
+	writeJSON(w, http.StatusAccepted, ingestResponse{Queued: len(request.Items)})
+}
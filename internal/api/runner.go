@@ -0,0 +1,126 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/runresult"
+)
+
+// ErrRunInProgress is returned by Start while a run triggered through the API is still going
+var ErrRunInProgress = errors.New("a run is already in progress")
+
+// Runner starts processing runs
+type Runner interface {
+	// Start begins a run for the named persona ("all" or empty for every persona) and returns
+	// without waiting for it to finish
+	Start(personaName string) error
+	// Status reports the current or most recent run
+	Status() RunStatus
+}
+
+// RunStatus describes the current or most recent run started through the API
+type RunStatus struct {
+	Running           bool      `json:"running"`
+	Persona           string    `json:"persona,omitempty"`
+	MegathreadRefresh bool      `json:"megathreadRefresh,omitempty"` // The run refreshes the summaries of megathreads instead of sending digests
+	StartedAt         time.Time `json:"startedAt,omitempty"`
+	FinishedAt        time.Time `json:"finishedAt,omitempty"`
+	Error             string    `json:"error,omitempty"`
+}
+
+// CommandRunner runs the processor binary as a child process, so a run started from the daemon
+// behaves exactly like a scheduled one. Only one run is started at a time.
+type CommandRunner struct {
+	Path string // Path to the processor binary
+
+	mu     sync.Mutex
+	status RunStatus
+}
+
+// NewCommandRunner creates a runner for the processor binary at path
+func NewCommandRunner(path string) *CommandRunner {
+	return &CommandRunner{Path: path}
+}
+
+// Start implements Runner
+func (r *CommandRunner) Start(personaName string) error {
+	if personaName == "" {
+		personaName = "all"
+	}
+	return r.start(RunStatus{Persona: personaName}, "-persona", personaName)
+}
+
+// StartMegathreadRefresh begins a run that summarizes the megathreads still being discussed again,
+// and returns without waiting for it to finish
+func (r *CommandRunner) StartMegathreadRefresh() error {
+	return r.start(RunStatus{Persona: "all", MegathreadRefresh: true}, "-refresh-megathreads")
+}
+
+// RefreshMegathreads starts a megathread refresh every interval until the context is cancelled.
+// A refresh is skipped while another run is still going.
+func (r *CommandRunner) RefreshMegathreads(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := r.StartMegathreadRefresh(); err != nil {
+			log.Printf("Skipping megathread refresh: %v", err)
+		}
+	}
+}
+
+// start runs the processor binary with args, unless a run is still going
+func (r *CommandRunner) start(status RunStatus, args ...string) error {
+	personaName := status.Persona
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.status.Running {
+		return ErrRunInProgress
+	}
+
+	cmd := exec.Command(r.Path, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	status.Running, status.StartedAt = true, time.Now()
+	r.status = status
+	log.Printf("Started run for persona %s (pid %d)", personaName, cmd.Process.Pid)
+
+	go func() {
+		err := cmd.Wait()
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.status.Running = false
+		r.status.FinishedAt = time.Now()
+		// A run that found nothing to send succeeded
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == runresult.ExitNothingSent {
+			err = nil
+		}
+		if err != nil {
+			r.status.Error = err.Error()
+			log.Printf("Run for persona %s failed: %v", personaName, err)
+		}
+	}()
+	return nil
+}
+
+// Status implements Runner
+func (r *CommandRunner) Status() RunStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status
+}
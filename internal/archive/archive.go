@@ -0,0 +1,126 @@
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/bakkerme/ai-news-processor/internal/fetcher"
+)
+
+// DefaultBaseURL is the base URL of the Wayback Machine availability API
+const DefaultBaseURL = "https://archive.org"
+
+// availabilityResponse mirrors the response of the /wayback/available endpoint
+type availabilityResponse struct {
+	ArchivedSnapshots struct {
+		Closest struct {
+			Available bool   `json:"available"`
+			URL       string `json:"url"`
+			Timestamp string `json:"timestamp"`
+			Status    string `json:"status"`
+		} `json:"closest"`
+	} `json:"archived_snapshots"`
+}
+
+// Client looks up archived copies of pages on the Wayback Machine
+type Client struct {
+	fetcher fetcher.Fetcher
+	baseURL string
+}
+
+// NewClient creates a new Wayback Machine client using the given fetcher.
+// If baseURL is empty, DefaultBaseURL will be used.
+func NewClient(f fetcher.Fetcher, baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{
+		fetcher: f,
+		baseURL: strings.TrimRight(baseURL, "/"),
+	}
+}
+
+// SnapshotURL returns the URL of the closest archived snapshot of u.
+// The returned URL points at the raw archived page, without the Wayback Machine toolbar.
+func (c *Client) SnapshotURL(ctx context.Context, u *url.URL) (*url.URL, error) {
+	apiURL, err := url.Parse(c.baseURL + "/wayback/available")
+	if err != nil {
+		return nil, fmt.Errorf("invalid archive base url: %w", err)
+	}
+	query := apiURL.Query()
+	query.Set("url", u.String())
+	apiURL.RawQuery = query.Encode()
+
+	resp, err := c.fetcher.Fetch(ctx, apiURL)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not query archive for %s: %w", u.String(), err)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not read archive response for %s: %w", u.String(), err)
+	}
+
+	var availability availabilityResponse
+	if err := json.Unmarshal(body, &availability); err != nil {
+		return nil, fmt.Errorf("could not parse archive response for %s: %w", u.String(), err)
+	}
+
+	closest := availability.ArchivedSnapshots.Closest
+	if !closest.Available || closest.URL == "" {
+		return nil, fmt.Errorf("no archived snapshot available for %s", u.String())
+	}
+	if closest.Status != "" && closest.Status != "200" {
+		return nil, fmt.Errorf("archived snapshot for %s has status %s", u.String(), closest.Status)
+	}
+
+	snapshot, err := url.Parse(rawSnapshotURL(closest.URL, closest.Timestamp))
+	if err != nil {
+		return nil, fmt.Errorf("invalid snapshot url %s: %w", closest.URL, err)
+	}
+	// The availability API reports plain http snapshot URLs, which only redirect to https
+	if snapshot.Host == "web.archive.org" {
+		snapshot.Scheme = "https"
+	}
+
+	return snapshot, nil
+}
+
+// FetchSnapshot fetches the closest archived snapshot of u and returns its body
+func (c *Client) FetchSnapshot(ctx context.Context, u *url.URL) ([]byte, error) {
+	snapshot, err := c.SnapshotURL(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.fetcher.Fetch(ctx, snapshot)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch archived snapshot %s: %w", snapshot.String(), err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("archived snapshot %s returned status %d", snapshot.String(), resp.StatusCode)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// rawSnapshotURL rewrites a Wayback snapshot URL to its id_ form, which serves the original
+// page without injected toolbar markup or rewritten links
+func rawSnapshotURL(snapshotURL string, timestamp string) string {
+	if timestamp == "" {
+		return snapshotURL
+	}
+	marker := "/web/" + timestamp + "/"
+	return strings.Replace(snapshotURL, marker, "/web/"+timestamp+"id_/", 1)
+}
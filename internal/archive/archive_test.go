@@ -0,0 +1,79 @@
+package archive
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/bakkerme/ai-news-processor/internal/fetcher"
+	"github.com/bakkerme/ai-news-processor/internal/http/retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_FetchSnapshot(t *testing.T) {
+	var server *httptest.Server
+	// ServeMux would clean the embedded // out of snapshot paths, so route by hand
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/wayback/available":
+			assert.Equal(t, "https://news.example.com/story", r.URL.Query().Get("url"))
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{
+				"archived_snapshots": {
+					"closest": {
+						"available": true,
+						"url": "` + server.URL + `/web/20250101000000/https://news.example.com/story",
+						"timestamp": "20250101000000",
+						"status": "200"
+					}
+				}
+			}`))
+		case "/web/20250101000000id_/https://news.example.com/story":
+			w.Write([]byte("<html><body>Archived story</body></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	f := fetcher.NewHTTPFetcher(server.Client(), retry.RetryConfig{}, "")
+	client := NewClient(f, server.URL)
+
+	u, err := url.Parse("https://news.example.com/story")
+	require.NoError(t, err)
+
+	body, err := client.FetchSnapshot(context.Background(), u)
+	require.NoError(t, err)
+	assert.Equal(t, "<html><body>Archived story</body></html>", string(body))
+}
+
+func TestClient_SnapshotURL_NotArchived(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"archived_snapshots": {}}`))
+	}))
+	defer server.Close()
+
+	f := fetcher.NewHTTPFetcher(server.Client(), retry.RetryConfig{}, "")
+	client := NewClient(f, server.URL)
+
+	u, err := url.Parse("https://news.example.com/new-story")
+	require.NoError(t, err)
+
+	_, err = client.SnapshotURL(context.Background(), u)
+	assert.Error(t, err)
+}
+
+func TestRawSnapshotURL(t *testing.T) {
+	assert.Equal(t,
+		"http://web.archive.org/web/20250101000000id_/https://example.com/",
+		rawSnapshotURL("http://web.archive.org/web/20250101000000/https://example.com/", "20250101000000"),
+	)
+	assert.Equal(t,
+		"http://web.archive.org/web/20250101000000/https://example.com/",
+		rawSnapshotURL("http://web.archive.org/web/20250101000000/https://example.com/", ""),
+	)
+}
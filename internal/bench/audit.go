@@ -0,0 +1,290 @@
+package bench
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/models"
+)
+
+// DefaultAuditGzipMinBytes is the payload size from which submissions are gzip-compressed
+const DefaultAuditGzipMinBytes = 64 * 1024
+
+// DefaultAuditRetryInterval is how often the daemon retries the outbox
+const DefaultAuditRetryInterval = 15 * time.Minute
+
+// staleClaimAge is how long a claimed outbox file may go unsent before it is treated as abandoned
+// by a process that crashed while sending it
+const staleClaimAge = time.Hour
+
+const (
+	outboxSuffix    = ".json"
+	claimedSuffix   = ".sending"
+	rejectedDirName = "rejected"
+)
+
+// errRejected marks submissions the audit service refused, which will never succeed on retry
+var errRejected = errors.New("rejected by audit service")
+
+// AuditClient submits run data to the ai-news-auditability-service. Submissions that fail are
+// spooled to an outbox directory and retried by Flush, on the next run or from the daemon.
+type AuditClient struct {
+	URL          string
+	Token        string // Sent as a bearer token when set
+	OutboxDir    string // Failed submissions are not kept when empty
+	GzipMinBytes int    // Payloads of at least this size are compressed; 0 disables compression
+	HTTPClient   *http.Client
+}
+
+// NewAuditClient creates a client for the audit service at url that spools failed submissions to
+// outboxDir
+func NewAuditClient(url, token, outboxDir string) *AuditClient {
+	return &AuditClient{
+		URL:          runsURL(url),
+		Token:        token,
+		OutboxDir:    outboxDir,
+		GzipMinBytes: DefaultAuditGzipMinBytes,
+		HTTPClient:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// runsURL appends the runs endpoint to the service URL unless it is already there
+func runsURL(url string) string {
+	if strings.HasSuffix(url, "/runs") {
+		return url
+	}
+	return strings.TrimSuffix(url, "/") + "/runs"
+}
+
+// Submit sends run data to the audit service. If that fails the data is spooled to the outbox and
+// the error is returned; a submission the service rejected is not spooled.
+func (c *AuditClient) Submit(data *models.RunData) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit service payload: %w", err)
+	}
+
+	err = c.send(payload)
+	if err == nil || errors.Is(err, errRejected) || c.OutboxDir == "" {
+		return err
+	}
+
+	path, spoolErr := c.spool(data.Persona.Name, payload)
+	if spoolErr != nil {
+		return fmt.Errorf("%w; could not queue it for retry: %v", err, spoolErr)
+	}
+	return fmt.Errorf("%w; queued for retry in %s", err, path)
+}
+
+// send posts a payload, compressing it if it is large
+func (c *AuditClient) send(payload []byte) error {
+	body := payload
+	compressed := c.GzipMinBytes > 0 && len(payload) >= c.GzipMinBytes
+	if compressed {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		if _, err := gz.Write(payload); err != nil {
+			return fmt.Errorf("failed to compress audit service payload: %w", err)
+		}
+		if err := gz.Close(); err != nil {
+			return fmt.Errorf("failed to compress audit service payload: %w", err)
+		}
+		body = buf.Bytes()
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create audit service request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if compressed {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request to audit service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		bodyBytes, readErr := io.ReadAll(resp.Body)
+		if readErr != nil {
+			return fmt.Errorf("audit service returned status %s; failed to read response body: %v", resp.Status, readErr)
+		}
+		err := fmt.Errorf("audit service returned status %s: %s", resp.Status, string(bodyBytes))
+		if permanentStatus(resp.StatusCode) {
+			return fmt.Errorf("%w: %w", errRejected, err)
+		}
+		return err
+	}
+
+	log.Printf("Run data successfully submitted to audit service at %s\n", c.URL)
+	return nil
+}
+
+// permanentStatus reports whether a response status means the same request will fail again. An
+// authentication failure is not permanent, since the token may be fixed before the next retry.
+func permanentStatus(code int) bool {
+	switch code {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return false
+	}
+	return code >= 400 && code < 500
+}
+
+// spool writes a payload to the outbox, named so files are retried in the order they failed
+func (c *AuditClient) spool(personaName string, payload []byte) (string, error) {
+	if err := os.MkdirAll(c.OutboxDir, 0755); err != nil {
+		return "", fmt.Errorf("error creating outbox: %w", err)
+	}
+	if personaName == "" {
+		personaName = "unknown"
+	}
+	name := fmt.Sprintf("%s_%s%s", time.Now().UTC().Format("20060102-150405.000000000"), personaName, outboxSuffix)
+	path := filepath.Join(c.OutboxDir, name)
+
+	// Write to a temporary file first so a flush never picks up a partial payload
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, payload, 0644); err != nil {
+		return "", fmt.Errorf("error writing outbox file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return "", fmt.Errorf("error writing outbox file: %w", err)
+	}
+	return path, nil
+}
+
+// Pending returns the number of submissions waiting in the outbox
+func (c *AuditClient) Pending() int {
+	files, _ := c.outboxFiles()
+	return len(files)
+}
+
+func (c *AuditClient) outboxFiles() ([]string, error) {
+	if c.OutboxDir == "" {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(c.OutboxDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read outbox: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		if strings.HasSuffix(name, claimedSuffix) {
+			// Release claims abandoned by a process that stopped mid-send
+			if info, err := entry.Info(); err == nil && time.Since(info.ModTime()) > staleClaimAge {
+				released := strings.TrimSuffix(name, claimedSuffix)
+				if os.Rename(filepath.Join(c.OutboxDir, name), filepath.Join(c.OutboxDir, released)) == nil {
+					names = append(names, released)
+				}
+			}
+			continue
+		}
+		if strings.HasSuffix(name, outboxSuffix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Flush retries the submissions in the outbox, oldest first, and returns how many were sent.
+// It stops at the first failure, since the service is most likely still unavailable. Submissions
+// the service rejects are moved to the rejected directory of the outbox for inspection.
+func (c *AuditClient) Flush() (int, error) {
+	names, err := c.outboxFiles()
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, name := range names {
+		path := filepath.Join(c.OutboxDir, name)
+
+		// Claim the file so a run and the daemon flushing at the same time do not both send it
+		claimed := path + claimedSuffix
+		if err := os.Rename(path, claimed); err != nil {
+			continue
+		}
+		// Refresh the modification time so the claim is not mistaken for an abandoned one
+		now := time.Now()
+		os.Chtimes(claimed, now, now)
+
+		payload, err := os.ReadFile(claimed)
+		if err != nil {
+			os.Rename(claimed, path)
+			return sent, fmt.Errorf("failed to read outbox file %s: %w", name, err)
+		}
+
+		err = c.send(payload)
+		switch {
+		case err == nil:
+			if err := os.Remove(claimed); err != nil {
+				log.Printf("Warning: could not remove sent outbox file %s: %v", name, err)
+			}
+			sent++
+		case errors.Is(err, errRejected):
+			log.Printf("Warning: audit service rejected %s, moving it to %s: %v", name, rejectedDirName, err)
+			rejectedDir := filepath.Join(c.OutboxDir, rejectedDirName)
+			if mkErr := os.MkdirAll(rejectedDir, 0755); mkErr == nil {
+				os.Rename(claimed, filepath.Join(rejectedDir, name))
+			}
+		default:
+			os.Rename(claimed, path)
+			return sent, fmt.Errorf("retrying %s: %w", name, err)
+		}
+	}
+	return sent, nil
+}
+
+// RunOutbox flushes the outbox every interval until the context is cancelled
+func (c *AuditClient) RunOutbox(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		c.FlushAndLog()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// FlushAndLog flushes the outbox if it holds anything, logging the outcome instead of returning it
+func (c *AuditClient) FlushAndLog() {
+	if c.Pending() == 0 {
+		return
+	}
+	sent, err := c.Flush()
+	if sent > 0 {
+		log.Printf("Sent %d queued run(s) to the audit service", sent)
+	}
+	if err != nil {
+		log.Printf("Warning: could not flush audit service outbox, %d run(s) still queued: %v", c.Pending(), err)
+	}
+}
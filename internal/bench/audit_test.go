@@ -0,0 +1,137 @@
+package bench
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// auditServer records the runs it receives and answers with a configurable status
+type auditServer struct {
+	mu       sync.Mutex
+	status   int
+	personas []string
+	headers  []http.Header
+}
+
+func (a *auditServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.headers = append(a.headers, r.Header.Clone())
+	if a.status != http.StatusCreated {
+		w.WriteHeader(a.status)
+		return
+	}
+
+	var body io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		body = gz
+	}
+	var data models.RunData
+	if err := json.NewDecoder(body).Decode(&data); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	a.personas = append(a.personas, data.Persona.Name)
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (a *auditServer) setStatus(status int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.status = status
+}
+
+func runData(name string) *models.RunData {
+	return &models.RunData{Persona: persona.Persona{Name: name}, JudgeInstructions: strings.Repeat("x", 100)}
+}
+
+func TestAuditClient_SubmitHeaders(t *testing.T) {
+	audit := &auditServer{status: http.StatusCreated}
+	server := httptest.NewServer(audit)
+	defer server.Close()
+
+	client := NewAuditClient(server.URL+"/", "secret", "")
+	assert.Equal(t, server.URL+"/runs", client.URL)
+
+	client.GzipMinBytes = 0
+	require.NoError(t, client.Submit(runData("small")))
+	client.GzipMinBytes = 10
+	require.NoError(t, client.Submit(runData("large")))
+
+	assert.Equal(t, []string{"small", "large"}, audit.personas)
+	assert.Equal(t, "Bearer secret", audit.headers[0].Get("Authorization"))
+	assert.Empty(t, audit.headers[0].Get("Content-Encoding"))
+	assert.Equal(t, "gzip", audit.headers[1].Get("Content-Encoding"))
+}
+
+func TestAuditClient_OutboxRetry(t *testing.T) {
+	audit := &auditServer{status: http.StatusServiceUnavailable}
+	server := httptest.NewServer(audit)
+	defer server.Close()
+
+	outbox := filepath.Join(t.TempDir(), "outbox")
+	client := NewAuditClient(server.URL, "", outbox)
+
+	err := client.Submit(runData("first"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "queued for retry")
+	require.Error(t, client.Submit(runData("second")))
+	assert.Equal(t, 2, client.Pending())
+
+	// Still down: nothing is sent and the queue is kept
+	sent, err := client.Flush()
+	require.Error(t, err)
+	assert.Equal(t, 0, sent)
+	assert.Equal(t, 2, client.Pending())
+
+	audit.setStatus(http.StatusCreated)
+	sent, err = client.Flush()
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent)
+	assert.Equal(t, 0, client.Pending())
+	assert.Equal(t, []string{"first", "second"}, audit.personas, "queued runs should be sent oldest first")
+}
+
+func TestAuditClient_Rejected(t *testing.T) {
+	audit := &auditServer{status: http.StatusServiceUnavailable}
+	server := httptest.NewServer(audit)
+	defer server.Close()
+
+	outbox := t.TempDir()
+	client := NewAuditClient(server.URL, "", outbox)
+	require.Error(t, client.Submit(runData("bad")))
+
+	// A payload the service refuses is set aside instead of blocking the queue
+	audit.setStatus(http.StatusUnprocessableEntity)
+	sent, err := client.Flush()
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent)
+	assert.Equal(t, 0, client.Pending())
+
+	rejected, err := os.ReadDir(filepath.Join(outbox, rejectedDirName))
+	require.NoError(t, err)
+	assert.Len(t, rejected, 1)
+
+	// Rejected submissions are not queued in the first place
+	require.Error(t, client.Submit(runData("bad")))
+	assert.Equal(t, 0, client.Pending())
+}
@@ -1,17 +1,17 @@
 package bench
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/bakkerme/ai-news-processor/internal/storage"
 	"github.com/bakkerme/ai-news-processor/models"
 )
 
@@ -26,10 +26,14 @@ func SerializeRunData(data *models.RunData) ([]byte, error) {
 	return jsonData, nil
 }
 
-var benchmarkDir = "../benchmarkresults"
+// WriteRunDataToDisk writes run data to a file in benchmarkDir and creates a backup if needed
+func WriteRunDataToDisk(benchmarkDir string, data *models.RunData) error {
+	return WriteRunData(context.Background(), storage.NewLocal(benchmarkDir), data)
+}
 
-// WriteRunDataToDisk writes run data to a file and creates a backup if needed
-func WriteRunDataToDisk(data *models.RunData) error {
+// WriteRunData writes run data to a timestamped benchmark file and benchmark.json in store,
+// backing up the previous benchmark.json first
+func WriteRunData(ctx context.Context, store storage.Store, data *models.RunData) error {
 	personaName := "unknown"
 	if data.Persona.Name != "" {
 		personaName = data.Persona.Name
@@ -37,29 +41,14 @@ func WriteRunDataToDisk(data *models.RunData) error {
 
 	timestamp := time.Now().Format("20060102-150405")
 	filename := fmt.Sprintf("benchmark_%s_%s.json", personaName, timestamp)
-	benchFilePath := filepath.Join(benchmarkDir, filename)
-
-	err := os.MkdirAll(benchmarkDir, 0755)
-	if err != nil {
-		return fmt.Errorf("error creating benchmark directory: %w", err)
-	}
 
-	defaultPath := filepath.Join(benchmarkDir, "benchmark.json")
-	if _, err := os.Stat(defaultPath); err == nil {
-		backupDir := filepath.Join(benchmarkDir, "backup")
-		err := os.MkdirAll(backupDir, 0755)
-		if err != nil {
-			return fmt.Errorf("error creating backup directory: %w", err)
-		}
-
-		backupPath := filepath.Join(backupDir, "benchmark.json")
-		backupData, errReadFile := os.ReadFile(defaultPath)
-		if errReadFile == nil {
-			errWrite := os.WriteFile(backupPath, backupData, 0644)
-			if errWrite != nil {
-				return fmt.Errorf("error creating backup: %w", errWrite)
-			}
+	backupData, err := store.Get(ctx, "benchmark.json")
+	if err == nil {
+		if err := store.Put(ctx, "backup/benchmark.json", backupData); err != nil {
+			return fmt.Errorf("error creating backup: %w", err)
 		}
+	} else if !errors.Is(err, storage.ErrNotExist) {
+		log.Printf("Warning: could not read benchmark.json for backup: %v\n", err)
 	}
 
 	jsonData, err := SerializeRunData(data)
@@ -67,69 +56,37 @@ func WriteRunDataToDisk(data *models.RunData) error {
 		return fmt.Errorf("error serializing run data: %w", err)
 	}
 
-	err = os.WriteFile(benchFilePath, jsonData, 0644)
+	err = store.Put(ctx, filename, jsonData)
 	if err != nil {
 		return fmt.Errorf("error writing to timestamped benchmark file: %w", err)
 	}
 
-	err = os.WriteFile(defaultPath, jsonData, 0644)
+	err = store.Put(ctx, "benchmark.json", jsonData)
 	if err != nil {
 		return fmt.Errorf("error writing to default benchmark file: %w", err)
 	}
 
-	log.Printf("Run data written to %s and %s\n", benchFilePath, defaultPath)
+	log.Printf("Run data written to %s in %s\n", filename, store)
 	return nil
 }
 
-// SubmitRunDataToAuditService sends the run data to the ai-news-auditability-service.
-func SubmitRunDataToAuditService(data *models.RunData, auditServiceURL string) error {
-	if !strings.HasSuffix(auditServiceURL, "/runs") {
-		if strings.HasSuffix(auditServiceURL, "/") {
-			auditServiceURL += "runs"
-		} else {
-			auditServiceURL += "/runs"
-		}
-	}
-
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		return fmt.Errorf("failed to marshal audit service payload: %w", err)
-	}
-
-	req, err := http.NewRequest("POST", auditServiceURL, bytes.NewBuffer(jsonData))
+// LoadRunDataFile loads the run data of a single benchmark file
+func LoadRunDataFile(path string) (*models.RunData, error) {
+	dataBytes, err := os.ReadFile(path)
 	if err != nil {
-		return fmt.Errorf("failed to create audit service request: %w", err)
+		return nil, fmt.Errorf("failed to read run data file %s: %w", path, err)
 	}
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to send request to audit service: %w", err)
+	var runData models.RunData
+	if err := json.Unmarshal(dataBytes, &runData); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal run data from file %s: %w", path, err)
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusCreated {
-		var bodyBytes []byte
-		var readErr error
-		if resp.Body != nil {
-			bodyBytes, readErr = io.ReadAll(resp.Body)
-		}
-
-		if readErr != nil {
-			return fmt.Errorf("audit service returned status %s; failed to read response body: %v", resp.Status, readErr)
-		}
-		return fmt.Errorf("audit service returned status %s: %s", resp.Status, string(bodyBytes))
-	}
-
-	log.Printf("Run data successfully submitted to audit service at %s\n", auditServiceURL)
-	return nil
+	return &runData, nil
 }
 
-// LoadRunData loads the most recent run data for each persona from a file
-func LoadRunData() ([]models.RunData, error) {
+// LoadRunData loads the most recent run data for each persona from benchmarkDir
+func LoadRunData(benchmarkDir string) ([]models.RunData, error) {
 	// read all benchmark files
-	files, err := os.ReadDir(filepath.Join(benchmarkDir)) // Assuming benchmarkDir is relative to where this runs or an absolute path
+	files, err := os.ReadDir(benchmarkDir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read benchmark files from %s: %w", benchmarkDir, err)
 	}
@@ -161,7 +118,7 @@ func LoadRunData() ([]models.RunData, error) {
 
 	for personaName, timestamp := range mostRecentRuns {
 		filename := fmt.Sprintf("benchmark_%s_%s.json", personaName, timestamp)
-		filePath := filepath.Join(benchmarkDir, filename) // Use benchmarkDir
+		filePath := filepath.Join(benchmarkDir, filename)
 		dataBytes, err := os.ReadFile(filePath)
 		if err != nil {
 			// It's possible a file was deleted between listing and reading, log and continue or handle
@@ -0,0 +1,113 @@
+package bench
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Default tolerances for Compare
+const (
+	DefaultMaxQualityDrop  = 0.25 // Mean quality score, on the 1 to 5 scale
+	DefaultMaxAccuracyDrop = 0.05 // Relevance accuracy, from 0 to 1
+)
+
+// Metrics are the headline numbers of an evaluation, used to compare runs
+type Metrics struct {
+	Items             int     `json:"items"`             // Items evaluated
+	MeanQuality       float64 `json:"meanQuality"`       // Mean quality score over all judge verdicts
+	Judged            int     `json:"judged"`            // Items with a majority relevance verdict among the judges
+	RelevanceAccuracy float64 `json:"relevanceAccuracy"` // Share of judged items where the run agrees with the majority
+}
+
+// Tolerances are the largest drops in each metric that are not a regression
+type Tolerances struct {
+	MaxQualityDrop  float64
+	MaxAccuracyDrop float64
+}
+
+// Regression describes a metric that dropped by more than its tolerance
+type Regression struct {
+	Metric    string
+	Baseline  float64
+	Candidate float64
+	Tolerance float64
+}
+
+func (r Regression) String() string {
+	return fmt.Sprintf("%s dropped from %.3f to %.3f (tolerance %.3f)", r.Metric, r.Baseline, r.Candidate, r.Tolerance)
+}
+
+// LoadEvaluation reads an evaluation written by WriteEvaluation
+func LoadEvaluation(path string) (*Evaluation, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading evaluation: %w", err)
+	}
+	var eval Evaluation
+	if err := json.Unmarshal(data, &eval); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal evaluation: %w", err)
+	}
+	return &eval, nil
+}
+
+// Summarize computes the metrics of an evaluation. Verdicts with errors are ignored, as are items
+// whose judges are split evenly on relevance.
+func Summarize(eval *Evaluation) Metrics {
+	metrics := Metrics{Items: len(eval.Items)}
+
+	var qualitySum, qualityCount, correct int
+	for _, item := range eval.Items {
+		relevant, valid := 0, 0
+		for _, verdict := range item.Verdicts {
+			if verdict.Error != "" {
+				continue
+			}
+			valid++
+			qualitySum += verdict.Quality
+			qualityCount++
+			if verdict.Relevant {
+				relevant++
+			}
+		}
+		if valid == 0 || relevant*2 == valid {
+			continue
+		}
+
+		metrics.Judged++
+		if (relevant*2 > valid) == item.MarkedRelevant {
+			correct++
+		}
+	}
+
+	if qualityCount > 0 {
+		metrics.MeanQuality = float64(qualitySum) / float64(qualityCount)
+	}
+	if metrics.Judged > 0 {
+		metrics.RelevanceAccuracy = float64(correct) / float64(metrics.Judged)
+	}
+	return metrics
+}
+
+// Compare returns the metrics of the candidate that regressed from the baseline by more than the
+// tolerances allow. An empty result means the candidate passes.
+func Compare(baseline, candidate Metrics, tolerances Tolerances) []Regression {
+	var regressions []Regression
+	if baseline.MeanQuality-candidate.MeanQuality > tolerances.MaxQualityDrop {
+		regressions = append(regressions, Regression{
+			Metric:    "mean quality",
+			Baseline:  baseline.MeanQuality,
+			Candidate: candidate.MeanQuality,
+			Tolerance: tolerances.MaxQualityDrop,
+		})
+	}
+	if baseline.RelevanceAccuracy-candidate.RelevanceAccuracy > tolerances.MaxAccuracyDrop {
+		regressions = append(regressions, Regression{
+			Metric:    "relevance accuracy",
+			Baseline:  baseline.RelevanceAccuracy,
+			Candidate: candidate.RelevanceAccuracy,
+			Tolerance: tolerances.MaxAccuracyDrop,
+		})
+	}
+	return regressions
+}
@@ -0,0 +1,53 @@
+package bench
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarize(t *testing.T) {
+	eval := &Evaluation{Items: []ItemEvaluation{
+		{MarkedRelevant: true, Verdicts: []Verdict{{Relevant: true, Quality: 4}, {Relevant: true, Quality: 5}}},
+		{MarkedRelevant: true, Verdicts: []Verdict{{Relevant: false, Quality: 3}, {Relevant: false, Quality: 2}, {Relevant: true, Quality: 3}}},
+		{MarkedRelevant: false, Verdicts: []Verdict{{Relevant: true, Quality: 4}, {Relevant: false, Quality: 4}}},
+		{MarkedRelevant: false, Verdicts: []Verdict{{Error: "timeout"}, {Relevant: false, Quality: 5}}},
+	}}
+
+	metrics := Summarize(eval)
+	assert.Equal(t, 4, metrics.Items)
+	assert.Equal(t, 3, metrics.Judged, "evenly split items are not judged")
+	assert.InDelta(t, 2.0/3.0, metrics.RelevanceAccuracy, 1e-9)
+	assert.InDelta(t, 30.0/8.0, metrics.MeanQuality, 1e-9, "failed verdicts are ignored")
+
+	assert.Equal(t, Metrics{}, Summarize(&Evaluation{}))
+}
+
+func TestCompare(t *testing.T) {
+	baseline := Metrics{MeanQuality: 4.0, RelevanceAccuracy: 0.9}
+	tolerances := Tolerances{MaxQualityDrop: DefaultMaxQualityDrop, MaxAccuracyDrop: DefaultMaxAccuracyDrop}
+
+	assert.Empty(t, Compare(baseline, Metrics{MeanQuality: 3.8, RelevanceAccuracy: 0.86}, tolerances))
+	assert.Empty(t, Compare(baseline, Metrics{MeanQuality: 4.5, RelevanceAccuracy: 1}, tolerances), "improvements never regress")
+
+	regressions := Compare(baseline, Metrics{MeanQuality: 3.5, RelevanceAccuracy: 0.8}, tolerances)
+	require.Len(t, regressions, 2)
+	assert.Equal(t, "mean quality", regressions[0].Metric)
+	assert.Equal(t, "relevance accuracy dropped from 0.900 to 0.800 (tolerance 0.050)", regressions[1].String())
+}
+
+func TestLoadEvaluation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "evaluation.json")
+	eval := &Evaluation{Persona: "LocalLLaMa", Judges: []string{"a", "b"}, NeedsReview: 1}
+	require.NoError(t, WriteEvaluation(path, eval))
+
+	loaded, err := LoadEvaluation(path)
+	require.NoError(t, err)
+	assert.Equal(t, eval.Persona, loaded.Persona)
+	assert.Equal(t, eval.Judges, loaded.Judges)
+
+	_, err = LoadEvaluation(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
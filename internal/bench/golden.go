@@ -0,0 +1,218 @@
+package bench
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/bakkerme/ai-news-processor/models"
+)
+
+// GoldenLabel is a human-assigned label for one item of a golden dataset
+type GoldenLabel struct {
+	Persona  string `json:"persona,omitempty"` // Persona the label applies to; empty applies to every persona
+	ID       string `json:"id"`                // Item ID, with or without the Reddit "t3_" prefix
+	Title    string `json:"title,omitempty"`
+	Relevant bool   `json:"relevant"`
+	Quality  int    `json:"quality,omitempty"` // 1 (poor) to 5 (excellent); 0 if not labelled
+	Notes    string `json:"notes,omitempty"`
+}
+
+// GoldenMistake is a labelled item whose relevance decision disagrees with the label
+type GoldenMistake struct {
+	ID             string `json:"id"`
+	Title          string `json:"title"`
+	MarkedRelevant bool   `json:"markedRelevant"`
+	Notes          string `json:"notes,omitempty"`
+}
+
+// GoldenScore measures a run against a golden dataset. Relevant is the positive class.
+type GoldenScore struct {
+	Persona        string          `json:"persona"`
+	Labels         int             `json:"labels"`  // Labels that apply to the persona
+	Matched        int             `json:"matched"` // Labelled items present in the run
+	TruePositives  int             `json:"truePositives"`
+	FalsePositives int             `json:"falsePositives"`
+	FalseNegatives int             `json:"falseNegatives"`
+	TrueNegatives  int             `json:"trueNegatives"`
+	Precision      float64         `json:"precision"`
+	Recall         float64         `json:"recall"`
+	F1             float64         `json:"f1"`
+	Accuracy       float64         `json:"accuracy"`
+	Mistakes       []GoldenMistake `json:"mistakes,omitempty"`
+
+	// Agreement of judge quality scores with the human quality labels, if an evaluation was given
+	QualityCompared int     `json:"qualityCompared,omitempty"` // Items with a quality label and judge scores
+	QualityMAE      float64 `json:"qualityMAE,omitempty"`      // Mean absolute error of the judges' mean score
+}
+
+// LoadGolden reads a golden dataset from a JSON Lines file with one GoldenLabel per line.
+// Blank lines are skipped.
+func LoadGolden(path string) ([]GoldenLabel, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open golden dataset: %w", err)
+	}
+	defer file.Close()
+
+	var labels []GoldenLabel
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var label GoldenLabel
+		if err := json.Unmarshal([]byte(text), &label); err != nil {
+			return nil, fmt.Errorf("invalid golden label on line %d: %w", line, err)
+		}
+		if label.ID == "" {
+			return nil, fmt.Errorf("golden label on line %d has no id", line)
+		}
+		if label.Quality < 0 || label.Quality > 5 {
+			return nil, fmt.Errorf("golden label on line %d has quality %d, must be 1 to 5", line, label.Quality)
+		}
+		labels = append(labels, label)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read golden dataset: %w", err)
+	}
+	return labels, nil
+}
+
+// ScoreGolden scores the relevance decisions of a run against the golden labels for its persona.
+// If eval is not nil, the judges' quality scores are compared with the human quality labels too.
+// Labelled items that are not in the run are ignored.
+func ScoreGolden(data *models.RunData, labels []GoldenLabel, eval *Evaluation) GoldenScore {
+	score := GoldenScore{Persona: data.Persona.Name}
+
+	items := map[string]models.Item{}
+	for _, entry := range data.EntrySummaries {
+		if !entry.Results.Unavailable {
+			items[goldenID(entry.Results.ID)] = entry.Results
+		}
+	}
+
+	judged := map[string]ItemEvaluation{}
+	if eval != nil {
+		for _, item := range eval.Items {
+			judged[goldenID(item.ID)] = item
+		}
+	}
+
+	var qualityError float64
+	for _, label := range labels {
+		if label.Persona != "" && label.Persona != data.Persona.Name {
+			continue
+		}
+		score.Labels++
+
+		id := goldenID(label.ID)
+		item, ok := items[id]
+		if !ok {
+			continue
+		}
+		score.Matched++
+
+		switch {
+		case item.IsRelevant && label.Relevant:
+			score.TruePositives++
+		case item.IsRelevant && !label.Relevant:
+			score.FalsePositives++
+		case !item.IsRelevant && label.Relevant:
+			score.FalseNegatives++
+		default:
+			score.TrueNegatives++
+		}
+		if item.IsRelevant != label.Relevant {
+			score.Mistakes = append(score.Mistakes, GoldenMistake{
+				ID:             item.ID,
+				Title:          item.Title,
+				MarkedRelevant: item.IsRelevant,
+				Notes:          label.Notes,
+			})
+		}
+
+		if label.Quality == 0 {
+			continue
+		}
+		if mean, ok := meanQuality(judged[id]); ok {
+			score.QualityCompared++
+			if diff := mean - float64(label.Quality); diff < 0 {
+				qualityError -= diff
+			} else {
+				qualityError += diff
+			}
+		}
+	}
+
+	if predicted := score.TruePositives + score.FalsePositives; predicted > 0 {
+		score.Precision = float64(score.TruePositives) / float64(predicted)
+	}
+	if actual := score.TruePositives + score.FalseNegatives; actual > 0 {
+		score.Recall = float64(score.TruePositives) / float64(actual)
+	}
+	if score.Precision+score.Recall > 0 {
+		score.F1 = 2 * score.Precision * score.Recall / (score.Precision + score.Recall)
+	}
+	if score.Matched > 0 {
+		score.Accuracy = float64(score.TruePositives+score.TrueNegatives) / float64(score.Matched)
+	}
+	if score.QualityCompared > 0 {
+		score.QualityMAE = qualityError / float64(score.QualityCompared)
+	}
+	return score
+}
+
+// goldenID normalizes an item ID so labels match with or without the Reddit "t3_" prefix
+func goldenID(id string) string {
+	return strings.TrimPrefix(strings.TrimSpace(id), "t3_")
+}
+
+// meanQuality returns the mean quality score of the judges' valid verdicts on an item
+func meanQuality(item ItemEvaluation) (float64, bool) {
+	sum, count := 0, 0
+	for _, verdict := range item.Verdicts {
+		if verdict.Error == "" {
+			sum += verdict.Quality
+			count++
+		}
+	}
+	if count == 0 {
+		return 0, false
+	}
+	return float64(sum) / float64(count), true
+}
+
+// PrintGoldenScore writes a human-readable summary of a golden dataset score
+func PrintGoldenScore(w io.Writer, score GoldenScore) {
+	fmt.Fprintf(w, "Persona %s: %d of %d labelled items found in the run\n", score.Persona, score.Matched, score.Labels)
+	fmt.Fprintf(w, "true positives %d, false positives %d, false negatives %d, true negatives %d\n",
+		score.TruePositives, score.FalsePositives, score.FalseNegatives, score.TrueNegatives)
+	fmt.Fprintf(w, "precision %.3f  recall %.3f  F1 %.3f  accuracy %.3f\n", score.Precision, score.Recall, score.F1, score.Accuracy)
+	if score.QualityCompared > 0 {
+		fmt.Fprintf(w, "judge quality vs labels: mean absolute error %.2f over %d items\n", score.QualityMAE, score.QualityCompared)
+	}
+
+	if len(score.Mistakes) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\nMistakes:\n")
+	for _, mistake := range score.Mistakes {
+		verdict := "marked relevant, labelled not relevant"
+		if !mistake.MarkedRelevant {
+			verdict = "excluded, labelled relevant"
+		}
+		fmt.Fprintf(w, "  %s (%s): %s", mistake.Title, mistake.ID, verdict)
+		if mistake.Notes != "" {
+			fmt.Fprintf(w, " - %s", mistake.Notes)
+		}
+		fmt.Fprintln(w)
+	}
+}
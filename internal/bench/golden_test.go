@@ -0,0 +1,97 @@
+package bench
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadGolden(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "golden.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte(`{"persona":"LocalLLaMa","id":"t3_a","relevant":true,"quality":4}
+
+{"id":"b","relevant":false,"notes":"meme"}
+`), 0644))
+
+	labels, err := LoadGolden(path)
+	require.NoError(t, err)
+	require.Len(t, labels, 2)
+	assert.Equal(t, GoldenLabel{Persona: "LocalLLaMa", ID: "t3_a", Relevant: true, Quality: 4}, labels[0])
+	assert.Equal(t, "meme", labels[1].Notes)
+
+	for name, content := range map[string]string{
+		"missing id":  `{"relevant":true}`,
+		"bad quality": `{"id":"a","relevant":true,"quality":7}`,
+		"not json":    `{`,
+	} {
+		path := filepath.Join(dir, "bad.jsonl")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		_, err := LoadGolden(path)
+		assert.Error(t, err, name)
+	}
+
+	_, err = LoadGolden(filepath.Join(dir, "missing.jsonl"))
+	assert.Error(t, err)
+}
+
+func TestScoreGolden(t *testing.T) {
+	data := &models.RunData{
+		Persona: persona.Persona{Name: "LocalLLaMa"},
+		EntrySummaries: []models.EntrySummary{
+			{Results: models.Item{ID: "t3_tp", Title: "TP", IsRelevant: true}},
+			{Results: models.Item{ID: "t3_fp", Title: "FP", IsRelevant: true}},
+			{Results: models.Item{ID: "t3_fn", Title: "FN", IsRelevant: false}},
+			{Results: models.Item{ID: "t3_tn", Title: "TN", IsRelevant: false}},
+			{Results: models.Item{ID: "t3_tp2", Title: "TP2", IsRelevant: true}},
+			{Results: models.Item{ID: "t3_gone", Title: "Gone", Unavailable: true}},
+		},
+	}
+	labels := []GoldenLabel{
+		{ID: "tp", Relevant: true, Quality: 4},
+		{ID: "t3_fp", Relevant: false, Notes: "funding news"},
+		{ID: "fn", Relevant: true, Quality: 2},
+		{ID: "tn", Relevant: false},
+		{ID: "tp2", Relevant: true},
+		{ID: "gone", Relevant: true},
+		{ID: "absent", Relevant: true},
+		{Persona: "Other", ID: "tn", Relevant: true},
+	}
+	eval := &Evaluation{Items: []ItemEvaluation{
+		{ID: "t3_tp", Verdicts: []Verdict{{Quality: 5}, {Quality: 4}}},
+		{ID: "t3_fn", Verdicts: []Verdict{{Quality: 4}, {Error: "timeout"}}},
+	}}
+
+	score := ScoreGolden(data, labels, eval)
+	assert.Equal(t, 7, score.Labels, "labels for other personas are skipped")
+	assert.Equal(t, 5, score.Matched, "unavailable and absent items are not scored")
+	assert.Equal(t, 2, score.TruePositives)
+	assert.Equal(t, 1, score.FalsePositives)
+	assert.Equal(t, 1, score.FalseNegatives)
+	assert.Equal(t, 1, score.TrueNegatives)
+	assert.InDelta(t, 2.0/3.0, score.Precision, 1e-9)
+	assert.InDelta(t, 2.0/3.0, score.Recall, 1e-9)
+	assert.InDelta(t, 2.0/3.0, score.F1, 1e-9)
+	assert.InDelta(t, 0.6, score.Accuracy, 1e-9)
+	assert.Equal(t, 2, score.QualityCompared)
+	assert.InDelta(t, (0.5+2.0)/2, score.QualityMAE, 1e-9)
+	require.Len(t, score.Mistakes, 2)
+	assert.Equal(t, GoldenMistake{ID: "t3_fp", Title: "FP", MarkedRelevant: true, Notes: "funding news"}, score.Mistakes[0])
+
+	withoutEval := ScoreGolden(data, labels, nil)
+	assert.Zero(t, withoutEval.QualityCompared)
+	assert.Equal(t, score.Precision, withoutEval.Precision)
+
+	var out bytes.Buffer
+	PrintGoldenScore(&out, score)
+	assert.Contains(t, out.String(), "5 of 7 labelled items found in the run")
+	assert.Contains(t, out.String(), "precision 0.667  recall 0.667")
+	assert.Contains(t, out.String(), "FP (t3_fp): marked relevant, labelled not relevant - funding news")
+	assert.Contains(t, out.String(), "FN (t3_fn): excluded, labelled relevant")
+}
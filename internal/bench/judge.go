@@ -0,0 +1,395 @@
+package bench
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/llm"
+	"github.com/bakkerme/ai-news-processor/internal/openai"
+	"github.com/bakkerme/ai-news-processor/internal/prompts"
+	"github.com/bakkerme/ai-news-processor/models"
+)
+
+// DefaultMaxQualitySpread is the largest difference between judges' quality scores of an item that
+// is not flagged for review
+const DefaultMaxQualitySpread = 1
+
+// Judge is an LLM that evaluates the processed items of a run
+type Judge struct {
+	Name   string // Shown in reports, usually the model name
+	Client openai.OpenAIClient
+}
+
+// Verdict is one judge's assessment of one processed item
+type Verdict struct {
+	Judge    string `json:"judge"`
+	Relevant bool   `json:"relevant"`
+	Quality  int    `json:"quality"` // 1 (poor) to 5 (excellent)
+	Reason   string `json:"reason,omitempty"`
+	Error    string `json:"error,omitempty"` // Set if the judge gave no usable verdict
+}
+
+// ItemEvaluation holds the verdicts of all judges on one processed item
+type ItemEvaluation struct {
+	ID             string    `json:"id"`
+	Title          string    `json:"title"`
+	MarkedRelevant bool      `json:"markedRelevant"` // The relevance decision of the run being evaluated
+	Verdicts       []Verdict `json:"verdicts"`
+	NeedsReview    bool      `json:"needsReview"`
+	ReviewReasons  []string  `json:"reviewReasons,omitempty"`
+}
+
+// JudgeAgreement measures how often two judges agree on relevance
+type JudgeAgreement struct {
+	JudgeA           string  `json:"judgeA"`
+	JudgeB           string  `json:"judgeB"`
+	Items            int     `json:"items"`            // Items both judges gave a verdict for
+	PercentAgreement float64 `json:"percentAgreement"` // Share of those items with the same verdict, from 0 to 1
+	Kappa            float64 `json:"kappa"`            // Cohen's kappa of the relevance verdicts
+}
+
+// Evaluation is the result of judging a run with several judges
+type Evaluation struct {
+	Persona     string           `json:"persona"`
+	RunDate     time.Time        `json:"runDate"`
+	Judges      []string         `json:"judges"`
+	Items       []ItemEvaluation `json:"items"`
+	Agreement   []JudgeAgreement `json:"agreement"`
+	NeedsReview int              `json:"needsReview"` // Number of items flagged for human review
+}
+
+// EvaluateOptions controls how a run is evaluated
+type EvaluateOptions struct {
+	MaxQualitySpread  int // Items whose quality scores differ by more than this are flagged for review
+	Workers           int // Maximum number of judge requests in flight at once
+	RequestsPerMinute int // Maximum request rate per judge; 0 means unlimited
+}
+
+// DefaultEvaluateOptions returns the options used when none are configured
+func DefaultEvaluateOptions() EvaluateOptions {
+	return EvaluateOptions{
+		MaxQualitySpread: DefaultMaxQualitySpread,
+		Workers:          4,
+	}
+}
+
+// EvaluateRun asks every judge for a verdict on each processed item of the run, measures the
+// agreement between judges and flags items they disagree on for human review. Up to opts.Workers
+// requests run at once, and each judge is sent at most opts.RequestsPerMinute requests a minute.
+func EvaluateRun(data *models.RunData, judges []Judge, opts EvaluateOptions) (*Evaluation, error) {
+	if len(judges) < 2 {
+		return nil, fmt.Errorf("at least two judges are required, got %d", len(judges))
+	}
+
+	systemPrompt, err := prompts.ComposeJudgePrompt(data.Persona, data.JudgeInstructions)
+	if err != nil {
+		return nil, fmt.Errorf("could not create judge prompt: %w", err)
+	}
+
+	eval := &Evaluation{
+		Persona: data.Persona.Name,
+		RunDate: data.RunDate,
+	}
+	limiters := make([]*rateLimiter, len(judges))
+	for j, judge := range judges {
+		eval.Judges = append(eval.Judges, judge.Name)
+		limiters[j] = newRateLimiter(opts.RequestsPerMinute)
+	}
+
+	var entries []models.EntrySummary
+	for _, entry := range data.EntrySummaries {
+		if !entry.Results.Unavailable {
+			entries = append(entries, entry)
+		}
+	}
+
+	// Each request writes to its own slot so the evaluation keeps entry and judge order
+	verdicts := make([][]Verdict, len(entries))
+	sem := make(chan struct{}, max(opts.Workers, 1))
+	var wg sync.WaitGroup
+	for i, entry := range entries {
+		verdicts[i] = make([]Verdict, len(judges))
+		for j, judge := range judges {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				sem <- struct{}{}
+				defer func() { <-sem }()
+
+				limiters[j].Wait()
+				verdict := judgeItem(judge, systemPrompt, entry)
+				if verdict.Error != "" {
+					log.Printf("Judge %s gave no verdict for item %s: %s", judge.Name, entry.Results.ID, verdict.Error)
+				}
+				verdicts[i][j] = verdict
+			}()
+		}
+	}
+	wg.Wait()
+
+	for i, entry := range entries {
+		itemEval := ItemEvaluation{
+			ID:             entry.Results.ID,
+			Title:          entry.Results.Title,
+			MarkedRelevant: entry.Results.IsRelevant,
+			Verdicts:       verdicts[i],
+		}
+		itemEval.ReviewReasons = reviewReasons(itemEval, opts.MaxQualitySpread)
+		itemEval.NeedsReview = len(itemEval.ReviewReasons) > 0
+		if itemEval.NeedsReview {
+			eval.NeedsReview++
+		}
+		eval.Items = append(eval.Items, itemEval)
+	}
+
+	eval.Agreement = pairwiseAgreement(eval.Judges, eval.Items)
+	return eval, nil
+}
+
+// rateLimiter spaces out requests evenly to stay under a per-minute limit
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// newRateLimiter returns a limiter for the given number of requests per minute, or nil for no limit
+func newRateLimiter(perMinute int) *rateLimiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &rateLimiter{interval: time.Minute / time.Duration(perMinute)}
+}
+
+// Wait blocks until the next request may be sent. A nil limiter never blocks.
+func (l *rateLimiter) Wait() {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	wait := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	time.Sleep(wait)
+}
+
+// verdictResponse is the JSON a judge answers with
+type verdictResponse struct {
+	Relevant *bool  `json:"relevant"`
+	Quality  int    `json:"quality" jsonschema:"minimum=1,maximum=5"`
+	Reason   string `json:"reason"`
+}
+
+// verdictSchema is the schema of a verdict, requested in the judge client's response format
+var verdictSchema = &openai.SchemaParameters{
+	Schema:      llm.GenerateSchema[verdictResponse](),
+	Name:        "verdict",
+	Description: "a relevance verdict and quality score of a processed post",
+}
+
+// judgeItem asks a single judge for its verdict on an entry
+func judgeItem(judge Judge, systemPrompt string, entry models.EntrySummary) Verdict {
+	verdict := Verdict{Judge: judge.Name}
+
+	result := judge.Client.ChatCompletion(
+		systemPrompt,
+		[]string{formatJudgeInput(entry)},
+		[]string{},
+		verdictSchema,
+		0.0, // temperature, judges should be as consistent as possible
+		0,   // max tokens (0 means no limit - needed for complete JSON generation)
+	)
+	if result.Err != nil {
+		verdict.Error = result.Err.Error()
+		return verdict
+	}
+
+	var response verdictResponse
+	if err := json.Unmarshal([]byte(judge.Client.PreprocessJSON(result.Content)), &response); err != nil {
+		verdict.Error = fmt.Sprintf("could not parse verdict: %v", err)
+		return verdict
+	}
+	if response.Relevant == nil {
+		verdict.Error = "verdict has no relevance decision"
+		return verdict
+	}
+	if response.Quality < 1 || response.Quality > 5 {
+		verdict.Error = fmt.Sprintf("quality score %d is outside 1 to 5", response.Quality)
+		return verdict
+	}
+
+	verdict.Relevant = *response.Relevant
+	verdict.Quality = response.Quality
+	verdict.Reason = response.Reason
+	return verdict
+}
+
+// formatJudgeInput combines the original post and the processed output for a judge
+func formatJudgeInput(entry models.EntrySummary) string {
+	item := entry.Results
+
+	var input strings.Builder
+	fmt.Fprintf(&input, "Original post:\n%s\n\n", entry.RawInput)
+	fmt.Fprintf(&input, "LLM output:\n")
+	fmt.Fprintf(&input, "Title: %s\n", item.Title)
+	fmt.Fprintf(&input, "Summary: %s\n", item.Summary)
+	if item.CommentSummary != "" {
+		fmt.Fprintf(&input, "Comment Summary: %s\n", item.CommentSummary)
+	}
+	fmt.Fprintf(&input, "Relevant: %t\n", item.IsRelevant)
+	if item.RelevanceToCriteria != "" {
+		fmt.Fprintf(&input, "Relevance Explanation: %s\n", item.RelevanceToCriteria)
+	}
+	return input.String()
+}
+
+// reviewReasons explains why the judges' verdicts on an item need a human to look at them, if at all
+func reviewReasons(item ItemEvaluation, maxQualitySpread int) []string {
+	var valid []Verdict
+	for _, verdict := range item.Verdicts {
+		if verdict.Error == "" {
+			valid = append(valid, verdict)
+		}
+	}
+	if len(valid) < 2 {
+		return nil
+	}
+
+	var reasons []string
+	relevant, minQuality, maxQuality := 0, valid[0].Quality, valid[0].Quality
+	for _, verdict := range valid {
+		if verdict.Relevant {
+			relevant++
+		}
+		minQuality = min(minQuality, verdict.Quality)
+		maxQuality = max(maxQuality, verdict.Quality)
+	}
+
+	switch {
+	case relevant > 0 && relevant < len(valid):
+		reasons = append(reasons, fmt.Sprintf("judges disagree on relevance (%d of %d relevant)", relevant, len(valid)))
+	case (relevant == len(valid)) != item.MarkedRelevant:
+		reasons = append(reasons, "judges unanimously disagree with the relevance decision")
+	}
+	if maxQuality-minQuality > maxQualitySpread {
+		reasons = append(reasons, fmt.Sprintf("quality scores range from %d to %d", minQuality, maxQuality))
+	}
+	return reasons
+}
+
+// pairwiseAgreement computes the relevance agreement of every pair of judges
+func pairwiseAgreement(judges []string, items []ItemEvaluation) []JudgeAgreement {
+	var agreement []JudgeAgreement
+	for a := 0; a < len(judges); a++ {
+		for b := a + 1; b < len(judges); b++ {
+			var verdictsA, verdictsB []bool
+			for _, item := range items {
+				if item.Verdicts[a].Error != "" || item.Verdicts[b].Error != "" {
+					continue
+				}
+				verdictsA = append(verdictsA, item.Verdicts[a].Relevant)
+				verdictsB = append(verdictsB, item.Verdicts[b].Relevant)
+			}
+
+			pair := JudgeAgreement{JudgeA: judges[a], JudgeB: judges[b], Items: len(verdictsA)}
+			if pair.Items > 0 {
+				pair.PercentAgreement, pair.Kappa = CohensKappa(verdictsA, verdictsB)
+			}
+			agreement = append(agreement, pair)
+		}
+	}
+	return agreement
+}
+
+// CohensKappa returns the observed agreement and Cohen's kappa of two raters' binary decisions on
+// the same items. Kappa is 1 for perfect agreement, 0 for agreement no better than chance and
+// negative for systematic disagreement. When both raters give every item the same single label,
+// chance agreement is total and kappa is undefined; it is reported as 1.
+func CohensKappa(a, b []bool) (observed float64, kappa float64) {
+	n := min(len(a), len(b))
+	if n == 0 {
+		return 0, 0
+	}
+
+	var agree, yesA, yesB int
+	for i := 0; i < n; i++ {
+		if a[i] == b[i] {
+			agree++
+		}
+		if a[i] {
+			yesA++
+		}
+		if b[i] {
+			yesB++
+		}
+	}
+
+	total := float64(n)
+	observed = float64(agree) / total
+	pYesA, pYesB := float64(yesA)/total, float64(yesB)/total
+	expected := pYesA*pYesB + (1-pYesA)*(1-pYesB)
+	if math.Abs(1-expected) < 1e-9 {
+		return observed, 1
+	}
+	return observed, (observed - expected) / (1 - expected)
+}
+
+// WriteEvaluation writes an evaluation as indented JSON
+func WriteEvaluation(path string, eval *Evaluation) error {
+	jsonData, err := json.MarshalIndent(eval, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal evaluation: %w", err)
+	}
+	if err := os.WriteFile(path, jsonData, 0644); err != nil {
+		return fmt.Errorf("error writing evaluation: %w", err)
+	}
+	return nil
+}
+
+// PrintEvaluation writes a human-readable summary of an evaluation: the agreement of each pair of
+// judges followed by the items that need review
+func PrintEvaluation(w io.Writer, eval *Evaluation) {
+	fmt.Fprintf(w, "Evaluated %d items for persona %s with %d judges\n\n", len(eval.Items), eval.Persona, len(eval.Judges))
+
+	fmt.Fprintln(w, "Relevance agreement:")
+	for _, pair := range eval.Agreement {
+		fmt.Fprintf(w, "  %s vs %s: %.0f%% agreement, kappa %.2f over %d items\n",
+			pair.JudgeA, pair.JudgeB, pair.PercentAgreement*100, pair.Kappa, pair.Items)
+	}
+
+	fmt.Fprintf(w, "\n%d items need review\n", eval.NeedsReview)
+	for _, item := range eval.Items {
+		if !item.NeedsReview {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s (%s)\n", item.Title, item.ID)
+		for _, reason := range item.ReviewReasons {
+			fmt.Fprintf(w, "  - %s\n", reason)
+		}
+		for _, verdict := range item.Verdicts {
+			if verdict.Error != "" {
+				fmt.Fprintf(w, "  %s: no verdict (%s)\n", verdict.Judge, verdict.Error)
+				continue
+			}
+			fmt.Fprintf(w, "  %s: relevant=%t quality=%d", verdict.Judge, verdict.Relevant, verdict.Quality)
+			if verdict.Reason != "" {
+				fmt.Fprintf(w, " (%s)", verdict.Reason)
+			}
+			fmt.Fprintln(w)
+		}
+	}
+}
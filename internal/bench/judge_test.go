@@ -0,0 +1,183 @@
+package bench
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/http/retry"
+	"github.com/bakkerme/ai-news-processor/internal/openai"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubJudge answers with the response registered for the first title found in the user prompt
+type stubJudge struct {
+	responses map[string]string
+}
+
+func (c *stubJudge) ChatCompletion(systemPrompt string, userPrompts []string, imageURLs []string, schemaParams *openai.SchemaParameters, temperature float64, maxTokens int) openai.Result {
+	for title, response := range c.responses {
+		if strings.Contains(userPrompts[0], "Title: "+title+"\n") {
+			return openai.Result{Content: response}
+		}
+	}
+	return openai.Result{Err: errors.New("no response")}
+}
+func (c *stubJudge) SetRetryConfig(config retry.RetryConfig) {}
+func (c *stubJudge) PreprocessYAML(response string) string   { return response }
+func (c *stubJudge) PreprocessJSON(response string) string   { return response }
+func (c *stubJudge) GetModelName() string                    { return "stub" }
+
+func TestCohensKappa(t *testing.T) {
+	observed, kappa := CohensKappa([]bool{true, false, true, false}, []bool{true, false, true, false})
+	assert.Equal(t, 1.0, observed)
+	assert.Equal(t, 1.0, kappa)
+
+	observed, kappa = CohensKappa([]bool{true, false}, []bool{false, true})
+	assert.Equal(t, 0.0, observed)
+	assert.InDelta(t, -1.0, kappa, 1e-9)
+
+	// 20 of 25 yes for A, 15 of 25 for B, 15 yes/yes and 5 no/no: po 0.8, pe 0.56
+	var a, b []bool
+	for i := 0; i < 25; i++ {
+		a = append(a, i < 20)
+		b = append(b, i < 15)
+	}
+	observed, kappa = CohensKappa(a, b)
+	assert.InDelta(t, 0.8, observed, 1e-9)
+	assert.InDelta(t, (0.8-0.56)/(1-0.56), kappa, 1e-9)
+
+	_, kappa = CohensKappa([]bool{true, true}, []bool{true, true})
+	assert.Equal(t, 1.0, kappa, "identical single-label verdicts count as full agreement")
+
+	observed, kappa = CohensKappa(nil, nil)
+	assert.Zero(t, observed)
+	assert.Zero(t, kappa)
+}
+
+func TestEvaluateRun(t *testing.T) {
+	data := &models.RunData{
+		Persona: persona.Persona{Name: "LocalLLaMa", PersonaIdentity: "an AI researcher"},
+		EntrySummaries: []models.EntrySummary{
+			{RawInput: "a", Results: models.Item{ID: "1", Title: "Agreed", IsRelevant: true}},
+			{RawInput: "b", Results: models.Item{ID: "2", Title: "Split", IsRelevant: true}},
+			{RawInput: "c", Results: models.Item{ID: "3", Title: "Overruled", IsRelevant: true}},
+			{RawInput: "d", Results: models.Item{ID: "4", Title: "Spread", IsRelevant: false}},
+			{RawInput: "e", Results: models.Item{ID: "5", Title: "Failed", IsRelevant: false}},
+			{RawInput: "f", Results: models.Item{ID: "6", Title: "Gone", Unavailable: true}},
+		},
+	}
+
+	first := &stubJudge{responses: map[string]string{
+		"Agreed":    `{"relevant":true,"quality":4,"reason":"solid"}`,
+		"Split":     `{"relevant":true,"quality":4}`,
+		"Overruled": `{"relevant":false,"quality":3}`,
+		"Spread":    `{"relevant":false,"quality":1}`,
+		"Failed":    `{"relevant":false,"quality":4}`,
+	}}
+	second := &stubJudge{responses: map[string]string{
+		"Agreed":    `{"relevant":true,"quality":5}`,
+		"Split":     `{"relevant":false,"quality":4}`,
+		"Overruled": `{"relevant":false,"quality":3}`,
+		"Spread":    `{"relevant":false,"quality":4}`,
+		"Failed":    `{"quality":4}`,
+	}}
+
+	_, err := EvaluateRun(data, []Judge{{Name: "first", Client: first}}, DefaultEvaluateOptions())
+	assert.Error(t, err, "a single judge cannot be compared")
+
+	eval, err := EvaluateRun(data, []Judge{{Name: "first", Client: first}, {Name: "second", Client: second}}, DefaultEvaluateOptions())
+	require.NoError(t, err)
+
+	require.Len(t, eval.Items, 5, "unavailable items are not judged")
+	assert.Equal(t, []string{"first", "second"}, eval.Judges)
+	assert.Equal(t, 3, eval.NeedsReview)
+
+	byTitle := map[string]ItemEvaluation{}
+	for _, item := range eval.Items {
+		byTitle[item.Title] = item
+	}
+	assert.False(t, byTitle["Agreed"].NeedsReview)
+	assert.Equal(t, "solid", byTitle["Agreed"].Verdicts[0].Reason)
+	assert.Equal(t, []string{"judges disagree on relevance (1 of 2 relevant)"}, byTitle["Split"].ReviewReasons)
+	assert.Equal(t, []string{"judges unanimously disagree with the relevance decision"}, byTitle["Overruled"].ReviewReasons)
+	assert.Equal(t, []string{"quality scores range from 1 to 4"}, byTitle["Spread"].ReviewReasons)
+	assert.False(t, byTitle["Failed"].NeedsReview, "a single valid verdict has nothing to disagree with")
+	assert.NotEmpty(t, byTitle["Failed"].Verdicts[1].Error)
+
+	require.Len(t, eval.Agreement, 1)
+	pair := eval.Agreement[0]
+	assert.Equal(t, 4, pair.Items, "items without a verdict from both judges are skipped")
+	assert.InDelta(t, 0.75, pair.PercentAgreement, 1e-9)
+	assert.InDelta(t, 0.5, pair.Kappa, 1e-9)
+
+	var out bytes.Buffer
+	PrintEvaluation(&out, eval)
+	assert.Contains(t, out.String(), "first vs second: 75% agreement, kappa 0.50 over 4 items")
+	assert.Contains(t, out.String(), "Split (2)")
+	assert.Contains(t, out.String(), "  first: relevant=true quality=4\n")
+	assert.NotContains(t, out.String(), "Agreed (1)")
+}
+
+// slowJudge records the most requests it saw in flight at once
+type slowJudge struct {
+	stubJudge
+	inFlight    atomic.Int32
+	maxInFlight atomic.Int32
+}
+
+func (c *slowJudge) ChatCompletion(systemPrompt string, userPrompts []string, imageURLs []string, schemaParams *openai.SchemaParameters, temperature float64, maxTokens int) openai.Result {
+	current := c.inFlight.Add(1)
+	for {
+		seen := c.maxInFlight.Load()
+		if current <= seen || c.maxInFlight.CompareAndSwap(seen, current) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	c.inFlight.Add(-1)
+	return openai.Result{Content: `{"relevant":true,"quality":4}`}
+}
+
+func TestEvaluateRun_Workers(t *testing.T) {
+	data := &models.RunData{Persona: persona.Persona{Name: "LocalLLaMa"}}
+	for i := 0; i < 12; i++ {
+		id := strconv.Itoa(i)
+		data.EntrySummaries = append(data.EntrySummaries, models.EntrySummary{Results: models.Item{ID: id, Title: "Item " + id, IsRelevant: true}})
+	}
+
+	client := &slowJudge{}
+	opts := DefaultEvaluateOptions()
+	opts.Workers = 3
+	eval, err := EvaluateRun(data, []Judge{{Name: "first", Client: client}, {Name: "second", Client: client}}, opts)
+	require.NoError(t, err)
+
+	assert.LessOrEqual(t, client.maxInFlight.Load(), int32(3))
+	assert.Greater(t, client.maxInFlight.Load(), int32(1), "requests run concurrently")
+	require.Len(t, eval.Items, 12)
+	for i, item := range eval.Items {
+		assert.Equal(t, strconv.Itoa(i), item.ID, "items keep their order")
+		assert.Equal(t, "first", item.Verdicts[0].Judge)
+		assert.Equal(t, "second", item.Verdicts[1].Judge)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	unlimited := newRateLimiter(0)
+	assert.Nil(t, unlimited)
+	unlimited.Wait()
+
+	limiter := newRateLimiter(6000) // one request every 10ms
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		limiter.Wait()
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+}
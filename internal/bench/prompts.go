@@ -0,0 +1,141 @@
+package bench
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/bakkerme/ai-news-processor/internal/tuning"
+	"github.com/bakkerme/ai-news-processor/models"
+)
+
+// TemplateChange is a prompt template whose version or text differs between two runs
+type TemplateChange struct {
+	Template  string               `json:"template"`
+	Baseline  models.PromptVersion `json:"baseline"`  // Zero if the baseline run did not record the template
+	Candidate models.PromptVersion `json:"candidate"` // Zero if the candidate run did not record the template
+}
+
+// RelevanceChange is an entry processed by both runs that only one of them marked relevant
+type RelevanceChange struct {
+	ID                string `json:"id"`
+	Title             string `json:"title"`
+	CandidateRelevant bool   `json:"candidateRelevant"`
+	SamePrompt        bool   `json:"samePrompt"` // Whether both runs processed the entry with the same system prompt
+}
+
+// PromptComparison shows how the prompts of two runs differ and which decisions changed with them,
+// so a change in benchmark scores can be traced to the prompt change behind it
+type PromptComparison struct {
+	Templates        []TemplateChange  `json:"templates"`        // Templates that changed
+	SystemPromptDiff string            `json:"systemPromptDiff"` // Unified diff of the entry system prompts; empty if they are equal
+	RelevanceChanges []RelevanceChange `json:"relevanceChanges"`
+	Compared         int               `json:"compared"` // Entries processed by both runs
+}
+
+// ComparePrompts compares the prompt provenance and relevance decisions of two runs
+func ComparePrompts(baseline, candidate *models.RunData) PromptComparison {
+	var comparison PromptComparison
+
+	baseVersions := promptVersionsByTemplate(baseline)
+	candVersions := promptVersionsByTemplate(candidate)
+	for name, base := range baseVersions {
+		if cand := candVersions[name]; cand != base {
+			comparison.Templates = append(comparison.Templates, TemplateChange{Template: name, Baseline: base, Candidate: cand})
+		}
+	}
+	for name, cand := range candVersions {
+		if _, ok := baseVersions[name]; !ok {
+			comparison.Templates = append(comparison.Templates, TemplateChange{Template: name, Candidate: cand})
+		}
+	}
+	sort.Slice(comparison.Templates, func(i, j int) bool { return comparison.Templates[i].Template < comparison.Templates[j].Template })
+
+	comparison.SystemPromptDiff = tuning.UnifiedDiff("baseline", "candidate", mainSystemPrompt(baseline), mainSystemPrompt(candidate))
+
+	baseEntries := make(map[string]models.EntrySummary)
+	for _, entry := range baseline.EntrySummaries {
+		baseEntries[goldenID(entry.Results.ID)] = entry
+	}
+	for _, entry := range candidate.EntrySummaries {
+		base, ok := baseEntries[goldenID(entry.Results.ID)]
+		if !ok || entry.Results.ID == "" {
+			continue
+		}
+		comparison.Compared++
+		if base.Results.IsRelevant != entry.Results.IsRelevant {
+			comparison.RelevanceChanges = append(comparison.RelevanceChanges, RelevanceChange{
+				ID:                entry.Results.ID,
+				Title:             entry.Results.Title,
+				CandidateRelevant: entry.Results.IsRelevant,
+				SamePrompt:        base.SystemPromptHash != "" && base.SystemPromptHash == entry.SystemPromptHash,
+			})
+		}
+	}
+	return comparison
+}
+
+func promptVersionsByTemplate(data *models.RunData) map[string]models.PromptVersion {
+	versions := make(map[string]models.PromptVersion, len(data.PromptVersions))
+	for _, version := range data.PromptVersions {
+		versions[version.Template] = version
+	}
+	return versions
+}
+
+// mainSystemPrompt returns the system prompt most entries of a run were processed with, or an
+// empty string for runs recorded before system prompts were
+func mainSystemPrompt(data *models.RunData) string {
+	counts := make(map[string]int)
+	for _, entry := range data.EntrySummaries {
+		counts[entry.SystemPromptHash]++
+	}
+	best, bestCount := "", 0
+	for hash, count := range counts {
+		if _, ok := data.SystemPrompts[hash]; ok && (count > bestCount || (count == bestCount && hash < best)) {
+			best, bestCount = hash, count
+		}
+	}
+	return data.SystemPrompts[best]
+}
+
+// PrintPromptComparison writes a human-readable prompt comparison to w
+func PrintPromptComparison(w io.Writer, comparison PromptComparison) {
+	if len(comparison.Templates) == 0 {
+		fmt.Fprintln(w, "Templates: unchanged")
+	} else {
+		fmt.Fprintln(w, "Templates:")
+		for _, change := range comparison.Templates {
+			fmt.Fprintf(w, "  %-10s %s -> %s\n", change.Template, formatPromptVersion(change.Baseline), formatPromptVersion(change.Candidate))
+		}
+	}
+
+	if comparison.SystemPromptDiff == "" {
+		fmt.Fprintln(w, "\nSystem prompt: unchanged")
+	} else {
+		fmt.Fprintf(w, "\nSystem prompt:\n%s", comparison.SystemPromptDiff)
+	}
+
+	fmt.Fprintf(w, "\n%d of %d entries processed by both runs changed relevance\n", len(comparison.RelevanceChanges), comparison.Compared)
+	for _, change := range comparison.RelevanceChanges {
+		verdict := "now excluded"
+		if change.CandidateRelevant {
+			verdict = "now relevant"
+		}
+		if change.SamePrompt {
+			verdict += ", same prompt"
+		}
+		fmt.Fprintf(w, "  %s (%s): %s\n", change.Title, change.ID, verdict)
+	}
+}
+
+func formatPromptVersion(version models.PromptVersion) string {
+	switch {
+	case version.Hash == "":
+		return "(none)"
+	case version.Version == "":
+		return version.Hash
+	default:
+		return fmt.Sprintf("%s (%s)", version.Version, version.Hash)
+	}
+}
@@ -0,0 +1,59 @@
+package bench
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/bakkerme/ai-news-processor/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComparePrompts(t *testing.T) {
+	baseline := &models.RunData{
+		PromptVersions: []models.PromptVersion{
+			{Template: "base", Version: "1.0.0", Hash: "aaa"},
+			{Template: "summary", Version: "1.0.0", Hash: "bbb"},
+		},
+		SystemPrompts: map[string]string{"p1": "You are a tester\nKeep it short\n"},
+		EntrySummaries: []models.EntrySummary{
+			{Results: models.Item{ID: "t3_1", Title: "Release", IsRelevant: true}, SystemPromptHash: "p1"},
+			{Results: models.Item{ID: "2", Title: "Funding", IsRelevant: true}, SystemPromptHash: "p1"},
+			{Results: models.Item{ID: "3", Title: "Only in baseline"}, SystemPromptHash: "p1"},
+		},
+	}
+	candidate := &models.RunData{
+		PromptVersions: []models.PromptVersion{
+			{Template: "base", Version: "1.1.0", Hash: "ccc"},
+			{Template: "summary", Version: "1.0.0", Hash: "bbb"},
+		},
+		SystemPrompts: map[string]string{"p2": "You are a tester\nKeep it very short\n"},
+		EntrySummaries: []models.EntrySummary{
+			{Results: models.Item{ID: "1", Title: "Release", IsRelevant: true}, SystemPromptHash: "p2"},
+			{Results: models.Item{ID: "2", Title: "Funding", IsRelevant: false}, SystemPromptHash: "p2"},
+		},
+	}
+
+	comparison := ComparePrompts(baseline, candidate)
+	assert.Equal(t, []TemplateChange{{
+		Template:  "base",
+		Baseline:  models.PromptVersion{Template: "base", Version: "1.0.0", Hash: "aaa"},
+		Candidate: models.PromptVersion{Template: "base", Version: "1.1.0", Hash: "ccc"},
+	}}, comparison.Templates)
+	assert.Contains(t, comparison.SystemPromptDiff, "\n-Keep it short\n")
+	assert.Contains(t, comparison.SystemPromptDiff, "\n+Keep it very short\n")
+	assert.Equal(t, 2, comparison.Compared, "entries are matched with or without the t3_ prefix")
+	assert.Equal(t, []RelevanceChange{{ID: "2", Title: "Funding", CandidateRelevant: false}}, comparison.RelevanceChanges)
+
+	var out bytes.Buffer
+	PrintPromptComparison(&out, comparison)
+	assert.Contains(t, out.String(), "base       1.0.0 (aaa) -> 1.1.0 (ccc)")
+	assert.Contains(t, out.String(), "1 of 2 entries processed by both runs changed relevance")
+	assert.Contains(t, out.String(), "Funding (2): now excluded")
+
+	same := ComparePrompts(baseline, baseline)
+	assert.Empty(t, same.Templates)
+	assert.Empty(t, same.SystemPromptDiff)
+	assert.Empty(t, same.RelevanceChanges)
+	require.Equal(t, 3, same.Compared)
+}
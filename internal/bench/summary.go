@@ -0,0 +1,70 @@
+package bench
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// PersonaResult holds the outcome of evaluating one persona's run
+type PersonaResult struct {
+	Persona     string           `json:"persona"`
+	Output      string           `json:"output"` // Evaluation file of the persona
+	Metrics     Metrics          `json:"metrics"`
+	NeedsReview int              `json:"needsReview"`
+	Agreement   []JudgeAgreement `json:"agreement"`
+}
+
+// Summary combines the evaluations of several personas
+type Summary struct {
+	Judges    []string        `json:"judges"`
+	Personas  []PersonaResult `json:"personas"`
+	Aggregate Metrics         `json:"aggregate"` // Metrics over the items of all personas
+
+	items []ItemEvaluation
+}
+
+// Add adds the evaluation of a persona to the summary and updates the aggregate metrics
+func (s *Summary) Add(output string, eval *Evaluation) {
+	if len(s.Judges) == 0 {
+		s.Judges = eval.Judges
+	}
+	s.Personas = append(s.Personas, PersonaResult{
+		Persona:     eval.Persona,
+		Output:      output,
+		Metrics:     Summarize(eval),
+		NeedsReview: eval.NeedsReview,
+		Agreement:   eval.Agreement,
+	})
+	s.items = append(s.items, eval.Items...)
+	s.Aggregate = Summarize(&Evaluation{Items: s.items})
+}
+
+// WriteSummary writes a summary as indented JSON
+func WriteSummary(path string, summary *Summary) error {
+	jsonData, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal summary: %w", err)
+	}
+	if err := os.WriteFile(path, jsonData, 0644); err != nil {
+		return fmt.Errorf("error writing summary: %w", err)
+	}
+	return nil
+}
+
+// PrintSummary writes a table of the metrics of each persona followed by the aggregate
+func PrintSummary(w io.Writer, summary *Summary) {
+	fmt.Fprintf(w, "%-24s %6s %8s %9s %7s\n", "persona", "items", "quality", "accuracy", "review")
+	for _, result := range summary.Personas {
+		m := result.Metrics
+		fmt.Fprintf(w, "%-24s %6d %8.2f %8.0f%% %7d\n", result.Persona, m.Items, m.MeanQuality, m.RelevanceAccuracy*100, result.NeedsReview)
+	}
+
+	needsReview := 0
+	for _, result := range summary.Personas {
+		needsReview += result.NeedsReview
+	}
+	m := summary.Aggregate
+	fmt.Fprintf(w, "%-24s %6d %8.2f %8.0f%% %7d\n", "all", m.Items, m.MeanQuality, m.RelevanceAccuracy*100, needsReview)
+}
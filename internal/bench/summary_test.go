@@ -0,0 +1,45 @@
+package bench
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummaryAdd(t *testing.T) {
+	summary := &Summary{}
+	summary.Add("evaluation_a.json", &Evaluation{
+		Persona:     "a",
+		Judges:      []string{"x", "y"},
+		NeedsReview: 1,
+		Items: []ItemEvaluation{
+			{MarkedRelevant: true, Verdicts: []Verdict{{Relevant: true, Quality: 5}, {Relevant: true, Quality: 5}}},
+		},
+	})
+	summary.Add("evaluation_b.json", &Evaluation{
+		Persona: "b",
+		Judges:  []string{"x", "y"},
+		Items: []ItemEvaluation{
+			{MarkedRelevant: true, Verdicts: []Verdict{{Relevant: false, Quality: 2}, {Relevant: false, Quality: 2}}},
+			{MarkedRelevant: false, Verdicts: []Verdict{{Relevant: false, Quality: 2}, {Relevant: false, Quality: 2}}},
+			{MarkedRelevant: false, Verdicts: []Verdict{{Relevant: false, Quality: 2}, {Relevant: false, Quality: 2}}},
+		},
+	})
+
+	require.Len(t, summary.Personas, 2)
+	assert.Equal(t, []string{"x", "y"}, summary.Judges)
+	assert.Equal(t, "evaluation_b.json", summary.Personas[1].Output)
+	assert.InDelta(t, 2.0/3.0, summary.Personas[1].Metrics.RelevanceAccuracy, 1e-9)
+
+	// The aggregate covers every item rather than averaging the personas
+	assert.Equal(t, 4, summary.Aggregate.Items)
+	assert.InDelta(t, 0.75, summary.Aggregate.RelevanceAccuracy, 1e-9)
+	assert.InDelta(t, 2.75, summary.Aggregate.MeanQuality, 1e-9)
+
+	var out bytes.Buffer
+	PrintSummary(&out, summary)
+	assert.Contains(t, out.String(), "a                             1     5.00      100%       1")
+	assert.Contains(t, out.String(), "all                           4     2.75       75%       1")
+}
@@ -0,0 +1,60 @@
+// Package budget caps the LLM work of a persona run. When a run reaches its limits, the entries
+// not yet processed are deferred to the persona's next run instead of being dropped.
+package budget
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/openai"
+)
+
+// Limits are the most a single persona run may use. Zero values are unlimited.
+type Limits struct {
+	MaxCalls    int           // LLM calls, including failed ones
+	MaxTokens   int64         // Prompt and completion tokens combined
+	MaxDuration time.Duration // Wall-clock time since the run started
+}
+
+// IsZero reports whether no limit is set
+func (l Limits) IsZero() bool {
+	return l == Limits{}
+}
+
+// Budget tracks a persona run against its limits. A nil Budget is never exceeded.
+type Budget struct {
+	limits  Limits
+	usage   func() openai.Usage // Usage of all clients so far, as tracked by the clients
+	start   openai.Usage
+	started time.Time
+	now     func() time.Time
+}
+
+// New starts a budget for a run. usage returns the combined usage of the LLM clients, which is
+// counted from the moment the budget starts.
+func New(limits Limits, usage func() openai.Usage) *Budget {
+	return &Budget{
+		limits:  limits,
+		usage:   usage,
+		start:   usage(),
+		started: time.Now(),
+		now:     time.Now,
+	}
+}
+
+// Exceeded returns an error describing the limit the run has reached, or nil if it may continue
+func (b *Budget) Exceeded() error {
+	if b == nil {
+		return nil
+	}
+	used := b.usage().Sub(b.start)
+	switch {
+	case b.limits.MaxCalls > 0 && used.Calls >= b.limits.MaxCalls:
+		return fmt.Errorf("budget of %d LLM calls reached", b.limits.MaxCalls)
+	case b.limits.MaxTokens > 0 && used.TotalTokens() >= b.limits.MaxTokens:
+		return fmt.Errorf("budget of %d tokens reached (%d used)", b.limits.MaxTokens, used.TotalTokens())
+	case b.limits.MaxDuration > 0 && b.now().Sub(b.started) >= b.limits.MaxDuration:
+		return fmt.Errorf("budget of %s reached", b.limits.MaxDuration)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package budget
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/openai"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBudgetExceeded(t *testing.T) {
+	usage := openai.Usage{Calls: 10, PromptTokens: 5000}
+	now := time.Date(2025, 3, 7, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		limits  Limits
+		used    openai.Usage
+		elapsed time.Duration
+		want    string
+	}{
+		{name: "unlimited", used: openai.Usage{Calls: 100, PromptTokens: 1e6}, elapsed: time.Hour},
+		{name: "under all limits", limits: Limits{MaxCalls: 5, MaxTokens: 1000, MaxDuration: time.Minute}, used: openai.Usage{Calls: 4, PromptTokens: 600, CompletionTokens: 300}, elapsed: 59 * time.Second},
+		{name: "calls", limits: Limits{MaxCalls: 5}, used: openai.Usage{Calls: 5}, want: "budget of 5 LLM calls reached"},
+		{name: "tokens", limits: Limits{MaxTokens: 1000}, used: openai.Usage{PromptTokens: 700, CompletionTokens: 300}, want: "budget of 1000 tokens reached (1000 used)"},
+		{name: "wall-clock", limits: Limits{MaxDuration: time.Minute}, elapsed: time.Minute, want: "budget of 1m0s reached"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := usage
+			b := New(tt.limits, func() openai.Usage { return current })
+			b.started = now
+			b.now = func() time.Time { return now.Add(tt.elapsed) }
+
+			// Only usage after the budget started counts
+			current = usage.Add(tt.used)
+			err := b.Exceeded()
+			if tt.want == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.want)
+			}
+		})
+	}
+
+	var unset *Budget
+	assert.NoError(t, unset.Exceeded())
+}
@@ -0,0 +1,140 @@
+package budget
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
+)
+
+// DefaultMaxAge is how long an entry stays deferred before it is given up on
+const DefaultMaxAge = 7 * 24 * time.Hour
+
+// Deferred is an entry left unprocessed by a run that ran out of budget
+type Deferred struct {
+	Entry      feeds.Entry `json:"entry"`
+	DeferredAt time.Time   `json:"deferredAt"` // When the entry was first deferred
+	Reason     string      `json:"reason"`
+}
+
+// Store keeps the deferred entries of each persona until its next run
+type Store struct {
+	path     string
+	now      func() time.Time
+	personas map[string][]Deferred
+}
+
+// LoadStore reads the deferred entries from disk, dropping entries deferred longer than maxAge.
+// If the file does not exist, an empty store is returned.
+func LoadStore(path string, maxAge time.Duration) (*Store, error) {
+	s := &Store{path: path, now: time.Now, personas: make(map[string][]Deferred)}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("could not read deferred entries: %w", err)
+	}
+
+	var personas map[string][]Deferred
+	if err := json.Unmarshal(data, &personas); err != nil {
+		return nil, fmt.Errorf("could not parse deferred entries: %w", err)
+	}
+	cutoff := s.now().Add(-maxAge)
+	for name, deferred := range personas {
+		for _, d := range deferred {
+			if d.DeferredAt.After(cutoff) {
+				s.personas[name] = append(s.personas[name], d)
+			}
+		}
+	}
+	return s, nil
+}
+
+// Entries returns the entries deferred for a persona, in the order they were deferred. A nil
+// store has no entries.
+func (s *Store) Entries(personaName string) []Deferred {
+	if s == nil {
+		return nil
+	}
+	return s.personas[personaName]
+}
+
+// Defer replaces the deferred entries of a persona with the entries left by its latest run. Entries
+// that were deferred before keep the time they were first deferred, so entries that never fit the
+// budget still expire.
+func (s *Store) Defer(personaName string, entries []feeds.Entry, reason string) {
+	if s == nil {
+		return
+	}
+	first := make(map[string]time.Time)
+	for _, d := range s.personas[personaName] {
+		first[d.Entry.ID] = d.DeferredAt
+	}
+	delete(s.personas, personaName)
+
+	now := s.now()
+	for _, entry := range entries {
+		deferredAt, ok := first[entry.ID]
+		if !ok {
+			deferredAt = now
+		}
+		// Enrichment is redone on the next run, so partial results are not kept
+		entry.ImageDescription = ""
+		entry.WebContentSummaries = nil
+		entry.WebContentSources = nil
+		s.personas[personaName] = append(s.personas[personaName], Deferred{Entry: entry, DeferredAt: deferredAt, Reason: reason})
+	}
+}
+
+// Save writes the deferred entries to disk
+func (s *Store) Save() error {
+	data, err := json.MarshalIndent(s.personas, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode deferred entries: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("could not create deferred entries directory: %w", err)
+	}
+	if err := os.WriteFile(s.path, data, 0644); err != nil {
+		return fmt.Errorf("could not write deferred entries: %w", err)
+	}
+	return nil
+}
+
+// Merge puts deferred entries ahead of the entries fetched this run, so they are processed
+// first. A deferred entry that was fetched again is replaced by its fresh version, and entries
+// sent since they were deferred are left out.
+func Merge(deferred []Deferred, entries []feeds.Entry, sentIDs map[string]struct{}) []feeds.Entry {
+	fresh := make(map[string]int, len(entries))
+	for i, entry := range entries {
+		fresh[entry.ID] = i
+	}
+
+	merged := make([]feeds.Entry, 0, len(deferred)+len(entries))
+	taken := make(map[string]struct{}, len(deferred))
+	for _, d := range deferred {
+		if _, ok := sentIDs[d.Entry.ID]; ok {
+			continue
+		}
+		if _, ok := taken[d.Entry.ID]; ok {
+			continue
+		}
+		taken[d.Entry.ID] = struct{}{}
+		if i, ok := fresh[d.Entry.ID]; ok {
+			merged = append(merged, entries[i])
+		} else {
+			merged = append(merged, d.Entry)
+		}
+	}
+	for _, entry := range entries {
+		if _, ok := taken[entry.ID]; !ok {
+			merged = append(merged, entry)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,82 @@
+package budget
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "deferred_entries.json")
+	store, err := LoadStore(path, DefaultMaxAge)
+	require.NoError(t, err)
+	assert.Empty(t, store.Entries("AI"))
+
+	start := time.Now().Add(-2 * time.Hour).Truncate(time.Second)
+	store.now = func() time.Time { return start }
+	store.Defer("AI", []feeds.Entry{
+		{ID: "1", Title: "First", ImageDescription: "A chart", WebContentSummaries: map[string]string{"https://example.com": "Summary"}},
+		{ID: "2", Title: "Second"},
+	}, "budget of 5 LLM calls reached")
+
+	// Entries deferred again keep the time they were first deferred
+	store.now = func() time.Time { return start.Add(time.Hour) }
+	store.Defer("AI", []feeds.Entry{{ID: "2", Title: "Second"}, {ID: "3", Title: "Third"}}, "budget of 5 LLM calls reached")
+	store.Defer("Gardening", []feeds.Entry{{ID: "g", Title: "Roses"}}, "budget of 1m0s reached")
+	require.NoError(t, store.Save())
+
+	loaded, err := LoadStore(path, DefaultMaxAge)
+	require.NoError(t, err)
+	loaded.now = store.now
+	deferred := loaded.Entries("AI")
+	require.Len(t, deferred, 2)
+	assert.Equal(t, "2", deferred[0].Entry.ID)
+	assert.True(t, deferred[0].DeferredAt.Equal(start))
+	assert.True(t, deferred[1].DeferredAt.Equal(start.Add(time.Hour)))
+	assert.Len(t, loaded.Entries("Gardening"), 1)
+
+	loaded.Defer("AI", nil, "")
+	assert.Empty(t, loaded.Entries("AI"), "a run without deferrals clears the persona")
+
+	// Entries deferred longer than the maximum age are given up on
+	expired, err := LoadStore(path, 30*time.Minute)
+	require.NoError(t, err)
+	assert.Len(t, expired.Entries("AI"), 0)
+
+	var unset *Store
+	assert.Nil(t, unset.Entries("AI"))
+	unset.Defer("AI", []feeds.Entry{{ID: "1"}}, "")
+}
+
+func TestDeferPrunesEnrichment(t *testing.T) {
+	store, err := LoadStore(filepath.Join(t.TempDir(), "deferred_entries.json"), DefaultMaxAge)
+	require.NoError(t, err)
+	store.Defer("AI", []feeds.Entry{{ID: "1", ImageDescription: "A chart", WebContentSummaries: map[string]string{"https://example.com": "Summary"}}}, "")
+
+	entry := store.Entries("AI")[0].Entry
+	assert.Empty(t, entry.ImageDescription)
+	assert.Nil(t, entry.WebContentSummaries)
+}
+
+func TestMerge(t *testing.T) {
+	deferred := []Deferred{
+		{Entry: feeds.Entry{ID: "old", Title: "Deferred"}},
+		{Entry: feeds.Entry{ID: "sent", Title: "Sent since"}},
+		{Entry: feeds.Entry{ID: "again", Title: "Stale title"}},
+	}
+	entries := []feeds.Entry{
+		{ID: "new", Title: "Fresh"},
+		{ID: "again", Title: "Updated title"},
+	}
+
+	merged := Merge(deferred, entries, map[string]struct{}{"sent": {}})
+	assert.Equal(t, []feeds.Entry{
+		{ID: "old", Title: "Deferred"},
+		{ID: "again", Title: "Updated title"},
+		{ID: "new", Title: "Fresh"},
+	}, merged)
+}
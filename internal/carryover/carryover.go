@@ -0,0 +1,147 @@
+// Package carryover keeps the relevant items of digests that were held back, by a persona's quiet
+// hours or because too few items were found, so they go out with the persona's next digest.
+package carryover
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/models"
+)
+
+// DefaultMaxAge is how long an item is held before it is given up on. Older items have usually
+// dropped out of the news.
+const DefaultMaxAge = 7 * 24 * time.Hour
+
+// Held is a relevant item of a digest that was not sent
+type Held struct {
+	Item   models.Item `json:"item"`
+	HeldAt time.Time   `json:"heldAt"` // When the item was first held
+	Reason string      `json:"reason"`
+}
+
+// Store keeps the held items of each persona until its next digest is sent
+type Store struct {
+	path     string
+	now      func() time.Time
+	personas map[string][]Held
+}
+
+// Load reads the held items from disk, dropping items held longer than maxAge. If the file does
+// not exist, an empty store is returned.
+func Load(path string, maxAge time.Duration) (*Store, error) {
+	s := &Store{path: path, now: time.Now, personas: make(map[string][]Held)}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("could not read held items: %w", err)
+	}
+
+	var personas map[string][]Held
+	if err := json.Unmarshal(data, &personas); err != nil {
+		return nil, fmt.Errorf("could not parse held items: %w", err)
+	}
+	cutoff := s.now().Add(-maxAge)
+	for name, held := range personas {
+		for _, h := range held {
+			if h.HeldAt.After(cutoff) {
+				s.personas[name] = append(s.personas[name], h)
+			}
+		}
+	}
+	return s, nil
+}
+
+// Items returns the items held for a persona, in the order they were held. A nil store holds no
+// items.
+func (s *Store) Items(personaName string) []Held {
+	if s == nil {
+		return nil
+	}
+	return s.personas[personaName]
+}
+
+// Hold replaces the held items of a persona with the items of its latest digest. Items that were
+// held before keep the time they were first held, so items that are never sent still expire.
+func (s *Store) Hold(personaName string, items []models.Item, reason string) {
+	if s == nil {
+		return
+	}
+	first := make(map[string]time.Time)
+	for _, h := range s.personas[personaName] {
+		first[h.Item.ID] = h.HeldAt
+	}
+	delete(s.personas, personaName)
+
+	now := s.now()
+	for _, item := range items {
+		heldAt, ok := first[item.ID]
+		if !ok {
+			heldAt = now
+		}
+		s.personas[personaName] = append(s.personas[personaName], Held{Item: item, HeldAt: heldAt, Reason: reason})
+	}
+}
+
+// Release forgets the held items of a persona once its digest was sent
+func (s *Store) Release(personaName string) {
+	if s == nil {
+		return
+	}
+	delete(s.personas, personaName)
+}
+
+// Save writes the held items to disk
+func (s *Store) Save() error {
+	data, err := json.MarshalIndent(s.personas, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode held items: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("could not create held items directory: %w", err)
+	}
+	if err := os.WriteFile(s.path, data, 0644); err != nil {
+		return fmt.Errorf("could not write held items: %w", err)
+	}
+	return nil
+}
+
+// Merge puts held items ahead of the relevant items of this run, as they were found first. A held
+// item that was processed again is replaced by its fresh version, and items sent since they were
+// held are left out.
+func Merge(held []Held, items []models.Item, sentIDs map[string]struct{}) []models.Item {
+	fresh := make(map[string]int, len(items))
+	for i, item := range items {
+		fresh[item.ID] = i
+	}
+
+	merged := make([]models.Item, 0, len(held)+len(items))
+	taken := make(map[string]struct{}, len(held))
+	for _, h := range held {
+		if _, ok := taken[h.Item.ID]; ok {
+			continue
+		}
+		i, isFresh := fresh[h.Item.ID]
+		if _, ok := sentIDs[h.Item.ID]; ok && !isFresh {
+			continue
+		}
+		taken[h.Item.ID] = struct{}{}
+		if isFresh {
+			merged = append(merged, items[i])
+		} else {
+			merged = append(merged, h.Item)
+		}
+	}
+	for _, item := range items {
+		if _, ok := taken[item.ID]; !ok {
+			merged = append(merged, item)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,85 @@
+package carryover
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "held_items.json")
+	store, err := Load(path, DefaultMaxAge)
+	require.NoError(t, err)
+	assert.Empty(t, store.Items("AI"))
+
+	start := time.Now().Add(-2 * time.Hour).Truncate(time.Second)
+	store.now = func() time.Time { return start }
+	store.Hold("AI", []models.Item{{ID: "1", Title: "First"}, {ID: "2", Title: "Second"}}, "2 items, fewer than 5")
+
+	// Items held again keep the time they were first held
+	store.now = func() time.Time { return start.Add(time.Hour) }
+	store.Hold("AI", []models.Item{{ID: "2", Title: "Second"}, {ID: "3", Title: "Third"}}, "quiet hours")
+	store.Hold("Gardening", []models.Item{{ID: "g", Title: "Roses"}}, "quiet hours")
+	require.NoError(t, store.Save())
+
+	loaded, err := Load(path, DefaultMaxAge)
+	require.NoError(t, err)
+	held := loaded.Items("AI")
+	require.Len(t, held, 2)
+	assert.Equal(t, "2", held[0].Item.ID)
+	assert.True(t, held[0].HeldAt.Equal(start))
+	assert.True(t, held[1].HeldAt.Equal(start.Add(time.Hour)))
+	assert.Equal(t, "quiet hours", held[1].Reason)
+	assert.Len(t, loaded.Items("Gardening"), 1)
+
+	loaded.Release("AI")
+	assert.Empty(t, loaded.Items("AI"), "a sent digest releases the persona's items")
+	assert.Len(t, loaded.Items("Gardening"), 1)
+
+	// Items held longer than the maximum age are given up on
+	expired, err := Load(path, 90*time.Minute)
+	require.NoError(t, err)
+	require.Len(t, expired.Items("AI"), 1)
+	assert.Equal(t, "3", expired.Items("AI")[0].Item.ID)
+
+	var unset *Store
+	assert.Nil(t, unset.Items("AI"))
+	unset.Hold("AI", []models.Item{{ID: "1"}}, "")
+	unset.Release("AI")
+}
+
+func TestLoad_InvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "held_items.json")
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0644))
+	_, err := Load(path, DefaultMaxAge)
+	assert.Error(t, err)
+}
+
+func TestMerge(t *testing.T) {
+	held := []Held{
+		{Item: models.Item{ID: "old", Title: "Held"}},
+		{Item: models.Item{ID: "sent", Title: "Sent since"}},
+		{Item: models.Item{ID: "again", Title: "Stale summary"}},
+		{Item: models.Item{ID: "updated", Title: "Edited"}},
+	}
+	items := []models.Item{
+		{ID: "new", Title: "Fresh"},
+		{ID: "again", Title: "Fresh summary"},
+		{ID: "updated", Title: "Edited again", Updated: true},
+	}
+
+	merged := Merge(held, items, map[string]struct{}{"sent": {}, "updated": {}})
+	assert.Equal(t, []models.Item{
+		{ID: "old", Title: "Held"},
+		{ID: "again", Title: "Fresh summary"},
+		{ID: "updated", Title: "Edited again", Updated: true},
+		{ID: "new", Title: "Fresh"},
+	}, merged)
+
+	assert.Equal(t, items, Merge(nil, items, nil))
+}
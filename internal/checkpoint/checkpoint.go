@@ -0,0 +1,152 @@
+// Package checkpoint records the entries of a persona run as they are processed, so a run that
+// crashed or was interrupted can be resumed with --resume instead of starting over.
+package checkpoint
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/bakkerme/ai-news-processor/models"
+)
+
+// Record is an entry that was processed during a run, as stored on disk
+type Record struct {
+	EntryID string      `json:"entryId"`
+	Item    models.Item `json:"item"`
+}
+
+// Checkpoint records the entries of a persona as they are processed, so a run that crashed or was
+// interrupted can be resumed without paying for them again. Records are appended to a JSON Lines
+// file as each entry completes; the file is removed once the persona has finished.
+type Checkpoint struct {
+	path string
+
+	mu        sync.Mutex
+	completed map[string]models.Item
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// Open returns the checkpoint of a persona in dir. With resume set, the entries recorded by an
+// earlier run are loaded; otherwise any earlier checkpoint is discarded.
+func Open(dir, personaName string, resume bool) (*Checkpoint, error) {
+	name := unsafeFileChars.ReplaceAllString(strings.ToLower(personaName), "_")
+	c := &Checkpoint{
+		path:      filepath.Join(dir, name+".jsonl"),
+		completed: make(map[string]models.Item),
+	}
+
+	if !resume {
+		if err := os.Remove(c.path); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("could not discard checkpoint: %w", err)
+		}
+		return c, nil
+	}
+
+	file, err := os.Open(c.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return c, nil
+		}
+		return nil, fmt.Errorf("could not open checkpoint: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4<<20)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			// The last record may have been cut off by the crash the run is resumed from
+			log.Printf("Warning: skipping invalid checkpoint record on line %d of %s: %v", line, c.path, err)
+			continue
+		}
+		if record.EntryID != "" {
+			c.completed[record.EntryID] = record.Item
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read checkpoint: %w", err)
+	}
+	return c, nil
+}
+
+// Len returns the number of entries recorded in the checkpoint
+func (c *Checkpoint) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.completed)
+}
+
+// Completed returns the item an entry was processed into by an earlier run, if any
+func (c *Checkpoint) Completed(entryID string) (models.Item, bool) {
+	if c == nil {
+		return models.Item{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	item, ok := c.completed[entryID]
+	return item, ok
+}
+
+// Record appends a processed entry to the checkpoint. The file is synced, so the record survives
+// the process being killed right after.
+func (c *Checkpoint) Record(entryID string, item models.Item) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, err := json.Marshal(Record{EntryID: entryID, Item: item})
+	if err != nil {
+		return fmt.Errorf("could not encode checkpoint record for entry %s: %w", entryID, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("could not create checkpoint directory: %w", err)
+	}
+	file, err := os.OpenFile(c.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("could not open checkpoint: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("could not write checkpoint: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("could not sync checkpoint: %w", err)
+	}
+	c.completed[entryID] = item
+	return nil
+}
+
+// Clear removes the checkpoint once the persona no longer needs resuming
+func (c *Checkpoint) Clear() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.completed = make(map[string]models.Item)
+	if err := os.Remove(c.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("could not remove checkpoint: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package checkpoint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
+	"github.com/bakkerme/ai-news-processor/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckpoint_RecordAndResume(t *testing.T) {
+	dir := t.TempDir()
+	c, err := Open(dir, "Local LLaMA", false)
+	require.NoError(t, err)
+	assert.Equal(t, 0, c.Len())
+
+	item := models.Item{ID: "a", Title: "First", Summary: "A summary", Entry: feeds.Entry{ID: "a", Content: "content"}}
+	require.NoError(t, c.Record("a", item))
+	require.NoError(t, c.Record("b", models.Item{ID: "b"}))
+
+	resumed, err := Open(dir, "Local LLaMA", true)
+	require.NoError(t, err)
+	assert.Equal(t, 2, resumed.Len())
+	got, ok := resumed.Completed("a")
+	require.True(t, ok)
+	assert.Equal(t, item, got, "the item and its entry survive a restart")
+	_, ok = resumed.Completed("c")
+	assert.False(t, ok)
+
+	other, err := Open(dir, "Other", true)
+	require.NoError(t, err)
+	assert.Equal(t, 0, other.Len(), "checkpoints are kept per persona")
+
+	restarted, err := Open(dir, "Local LLaMA", false)
+	require.NoError(t, err)
+	assert.Equal(t, 0, restarted.Len())
+	again, err := Open(dir, "Local LLaMA", true)
+	require.NoError(t, err)
+	assert.Equal(t, 0, again.Len(), "a run without resume discards the checkpoint")
+}
+
+func TestCheckpoint_SkipsTruncatedRecord(t *testing.T) {
+	dir := t.TempDir()
+	c, err := Open(dir, "test", false)
+	require.NoError(t, err)
+	require.NoError(t, c.Record("a", models.Item{ID: "a"}))
+
+	file, err := os.OpenFile(filepath.Join(dir, "test.jsonl"), os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"entryId":"b","item":{"id"`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	resumed, err := Open(dir, "test", true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, resumed.Len())
+}
+
+func TestCheckpoint_Clear(t *testing.T) {
+	dir := t.TempDir()
+	c, err := Open(dir, "test", false)
+	require.NoError(t, err)
+	require.NoError(t, c.Record("a", models.Item{ID: "a"}))
+	require.NoError(t, c.Clear())
+	assert.Equal(t, 0, c.Len())
+	assert.NoFileExists(t, filepath.Join(dir, "test.jsonl"))
+	require.NoError(t, c.Clear(), "clearing twice is fine")
+
+	var none *Checkpoint
+	assert.NoError(t, none.Record("a", models.Item{}))
+	assert.NoError(t, none.Clear())
+	_, ok := none.Completed("a")
+	assert.False(t, ok)
+}
@@ -13,7 +13,9 @@ import (
 type ArticleData struct {
 	Title       string
 	CleanedText string
-	// Future fields: Excerpt, SiteName, Favicon, Language, etc.
+	SiteName    string // Site name from Open Graph or other page metadata, if present
+	Favicon     string // Favicon URL declared by the page, if present
+	// Future fields: Excerpt, Language, etc.
 }
 
 // ArticleExtractor defines the interface for extracting article data from an HTML source.
@@ -52,5 +54,7 @@ func (d *DefaultArticleExtractor) Extract(body io.Reader, sourceURL *url.URL) (*
 	return &ArticleData{
 		Title:       article.Title,
 		CleanedText: cleanedText,
+		SiteName:    article.SiteName,
+		Favicon:     article.Favicon,
 	}, nil
 }
@@ -0,0 +1,313 @@
+package contentextractor
+
+import (
+	"encoding/json"
+	"fmt"
+	stdhtml "html"
+	"io"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/jsonextract"
+	"golang.org/x/net/html"
+)
+
+// MinArticleWords is the minimum number of words an extracted article must contain to be
+// considered usable. Shorter extractions are usually cookie banners, login walls or
+// JavaScript-rendered shells.
+const MinArticleWords = 200
+
+// PageMetadata holds the Open Graph, Twitter Card and JSON-LD metadata declared by a page
+type PageMetadata struct {
+	Title       string
+	Description string
+	Image       string
+	SiteName    string
+	Type        string
+	Author      string
+	Published   string // Publish date as YYYY-MM-DD, or as declared when it could not be parsed
+}
+
+// IsEmpty reports whether the page declared no useful metadata
+func (m *PageMetadata) IsEmpty() bool {
+	return m.Title == "" && m.Description == ""
+}
+
+// String renders the metadata as a short structured summary for the entry prompt
+func (m *PageMetadata) String() string {
+	var s strings.Builder
+	s.WriteString("Page metadata (full article text was unavailable):\n")
+	if m.SiteName != "" {
+		fmt.Fprintf(&s, "Site: %s\n", m.SiteName)
+	}
+	if m.Title != "" {
+		fmt.Fprintf(&s, "Title: %s\n", m.Title)
+	}
+	if m.Author != "" {
+		fmt.Fprintf(&s, "Author: %s\n", m.Author)
+	}
+	if m.Published != "" {
+		fmt.Fprintf(&s, "Published: %s\n", m.Published)
+	}
+	if m.Description != "" {
+		fmt.Fprintf(&s, "Description: %s\n", m.Description)
+	}
+	if m.Image != "" {
+		fmt.Fprintf(&s, "Image: %s\n", m.Image)
+	}
+	return s.String()
+}
+
+// WordCount returns the number of whitespace separated words in text
+func WordCount(text string) int {
+	return len(strings.Fields(text))
+}
+
+// ExtractMetadata parses Open Graph and Twitter Card meta tags and JSON-LD structured data from an
+// HTML document. Open Graph values take precedence, then Twitter Card values, then JSON-LD, then
+// the <title> element and the standard meta tags. Relative image URLs are resolved against sourceURL.
+func ExtractMetadata(body io.Reader, sourceURL *url.URL) (*PageMetadata, error) {
+	if body == nil {
+		return nil, fmt.Errorf("contentextractor: body cannot be nil")
+	}
+
+	doc, err := html.Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("contentextractor: failed to parse HTML for metadata: %w", err)
+	}
+
+	tags := make(map[string]string)
+	var documentTitle string
+	var linkedData []string
+
+	var walk func(*html.Node, bool)
+	walk = func(n *html.Node, inBody bool) {
+		if n.Type == html.ElementNode {
+			switch {
+			case n.Data == "script" && isLinkedData(n):
+				// JSON-LD is often placed in the body, next to the content it describes
+				if n.FirstChild != nil {
+					linkedData = append(linkedData, n.FirstChild.Data)
+				}
+			case inBody:
+				// Meta tags in the body belong to embedded content rather than the page
+			case n.Data == "meta":
+				var key, content string
+				for _, a := range n.Attr {
+					switch strings.ToLower(a.Key) {
+					case "property", "name":
+						if key == "" {
+							key = strings.ToLower(strings.TrimSpace(a.Val))
+						}
+					case "content":
+						content = strings.TrimSpace(a.Val)
+					}
+				}
+				// The first occurrence of a tag wins, matching how most link unfurlers behave
+				if key != "" && content != "" {
+					if _, exists := tags[key]; !exists {
+						tags[key] = content
+					}
+				}
+			case n.Data == "title":
+				if documentTitle == "" && n.FirstChild != nil {
+					documentTitle = strings.TrimSpace(n.FirstChild.Data)
+				}
+			case n.Data == "body":
+				inBody = true
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c, inBody)
+		}
+	}
+	walk(doc, false)
+
+	ld := parseLinkedData(linkedData)
+	// article:author is often the URL of the author's profile rather than a name
+	metaAuthor := tags["article:author"]
+	if strings.HasPrefix(metaAuthor, "http://") || strings.HasPrefix(metaAuthor, "https://") {
+		metaAuthor = ""
+	}
+
+	meta := &PageMetadata{
+		Title:       firstNonEmpty(tags["og:title"], tags["twitter:title"], ld.title, documentTitle),
+		Description: firstNonEmpty(tags["og:description"], tags["twitter:description"], ld.description, tags["description"]),
+		Image:       firstNonEmpty(tags["og:image"], tags["og:image:url"], tags["twitter:image"], tags["twitter:image:src"], ld.image),
+		SiteName:    firstNonEmpty(tags["og:site_name"], tags["twitter:site"], ld.publisher),
+		Type:        tags["og:type"],
+		Author:      firstNonEmpty(metaAuthor, ld.author, tags["author"], tags["citation_author"], tags["twitter:creator"]),
+		Published: normalizeDate(firstNonEmpty(tags["article:published_time"], tags["og:article:published_time"], ld.published,
+			tags["citation_publication_date"], tags["date"], tags["dc.date"])),
+	}
+
+	if meta.Image != "" && sourceURL != nil {
+		if parsed, err := url.Parse(meta.Image); err == nil {
+			meta.Image = sourceURL.ResolveReference(parsed).String()
+		}
+	}
+
+	return meta, nil
+}
+
+// firstNonEmpty returns the first non-empty string in values
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// linkedDataNode holds the fields of a JSON-LD node that describe a page
+type linkedDataNode struct {
+	title       string
+	description string
+	image       string
+	publisher   string
+	author      string
+	published   string
+}
+
+// articleTypes are the schema.org types of JSON-LD nodes describing the page content, preferred
+// over nodes describing the site or its breadcrumbs
+var articleTypes = map[string]bool{
+	"Article":             true,
+	"NewsArticle":         true,
+	"BlogPosting":         true,
+	"TechArticle":         true,
+	"ScholarlyArticle":    true,
+	"Report":              true,
+	"AnalysisNewsArticle": true,
+	"SocialMediaPosting":  true,
+	"VideoObject":         true,
+	"SoftwareApplication": true,
+	"SoftwareSourceCode":  true,
+}
+
+// isLinkedData reports whether a script element holds JSON-LD
+func isLinkedData(n *html.Node) bool {
+	for _, a := range n.Attr {
+		if strings.EqualFold(a.Key, "type") && strings.EqualFold(strings.TrimSpace(a.Val), "application/ld+json") {
+			return true
+		}
+	}
+	return false
+}
+
+// parseLinkedData returns the fields of the JSON-LD node describing the page content: the first
+// node of an article type, or else the first node with a title. Scripts that do not parse, even
+// after repairing the comments and trailing commas some sites leave in, are skipped.
+func parseLinkedData(scripts []string) linkedDataNode {
+	var nodes []map[string]any
+	for _, script := range scripts {
+		var value any
+		if err := json.Unmarshal([]byte(jsonextract.Repair(strings.TrimSpace(script))), &value); err != nil {
+			continue
+		}
+		nodes = append(nodes, flattenLinkedData(value)...)
+	}
+
+	var chosen map[string]any
+	for _, node := range nodes {
+		if hasArticleType(node["@type"]) {
+			chosen = node
+			break
+		}
+		if chosen == nil && firstNonEmpty(linkedDataText(node["headline"]), linkedDataText(node["name"])) != "" {
+			chosen = node
+		}
+	}
+	if chosen == nil {
+		return linkedDataNode{}
+	}
+
+	return linkedDataNode{
+		title:       firstNonEmpty(linkedDataText(chosen["headline"]), linkedDataText(chosen["name"])),
+		description: linkedDataText(chosen["description"]),
+		image:       linkedDataText(chosen["image"]),
+		publisher:   linkedDataText(chosen["publisher"]),
+		author:      linkedDataText(chosen["author"]),
+		published:   firstNonEmpty(linkedDataText(chosen["datePublished"]), linkedDataText(chosen["uploadDate"]), linkedDataText(chosen["dateCreated"])),
+	}
+}
+
+// flattenLinkedData returns the nodes of a JSON-LD document, which is a node, a list of nodes or
+// a node with a @graph of nodes
+func flattenLinkedData(value any) []map[string]any {
+	switch v := value.(type) {
+	case []any:
+		var nodes []map[string]any
+		for _, item := range v {
+			nodes = append(nodes, flattenLinkedData(item)...)
+		}
+		return nodes
+	case map[string]any:
+		if graph, ok := v["@graph"]; ok {
+			return flattenLinkedData(graph)
+		}
+		return []map[string]any{v}
+	}
+	return nil
+}
+
+// hasArticleType reports whether a @type value, a string or a list of strings, names an article type
+func hasArticleType(value any) bool {
+	switch v := value.(type) {
+	case string:
+		return articleTypes[v]
+	case []any:
+		for _, item := range v {
+			if hasArticleType(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// linkedDataText returns the text of a JSON-LD value. Nodes such as a Person or an ImageObject
+// are represented by their name or URL, and lists by their values joined with commas.
+func linkedDataText(value any) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(stdhtml.UnescapeString(v))
+	case map[string]any:
+		return firstNonEmpty(linkedDataText(v["name"]), linkedDataText(v["url"]))
+	case []any:
+		var values []string
+		for _, item := range v {
+			if text := linkedDataText(item); text != "" && !slices.Contains(values, text) {
+				values = append(values, text)
+			}
+		}
+		return strings.Join(values, ", ")
+	}
+	return ""
+}
+
+// dateLayouts are the publish date formats found in meta tags and JSON-LD
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"2006/01/02",
+	time.RFC1123Z,
+	time.RFC1123,
+}
+
+// normalizeDate renders a declared publish date as YYYY-MM-DD. Dates in other formats are kept as they are.
+func normalizeDate(value string) string {
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.Format("2006-01-02")
+		}
+	}
+	return value
+}
@@ -0,0 +1,164 @@
+package contentextractor
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestExtractMetadata(t *testing.T) {
+	tests := []struct {
+		name   string
+		html   string
+		expect PageMetadata
+	}{
+		{
+			name: "Open Graph tags",
+			html: `<html><head>
+				<title>Fallback Title</title>
+				<meta property="og:title" content="OG Title">
+				<meta property="og:description" content="OG description">
+				<meta property="og:image" content="/images/cover.png">
+				<meta property="og:site_name" content="Example News">
+				<meta property="og:type" content="article">
+				<meta name="twitter:title" content="Twitter Title">
+			</head><body><p>Please enable JavaScript</p></body></html>`,
+			expect: PageMetadata{
+				Title:       "OG Title",
+				Description: "OG description",
+				Image:       "https://example.com/images/cover.png",
+				SiteName:    "Example News",
+				Type:        "article",
+			},
+		},
+		{
+			name: "Twitter Card tags",
+			html: `<html><head>
+				<meta name="twitter:title" content="Twitter Title">
+				<meta name="twitter:description" content="Twitter description">
+				<meta name="twitter:image" content="https://cdn.example.com/card.jpg">
+				<meta name="twitter:site" content="@example">
+			</head><body></body></html>`,
+			expect: PageMetadata{
+				Title:       "Twitter Title",
+				Description: "Twitter description",
+				Image:       "https://cdn.example.com/card.jpg",
+				SiteName:    "@example",
+			},
+		},
+		{
+			name: "Standard title and description",
+			html: `<html><head>
+				<title> Plain Title </title>
+				<meta name="description" content="Plain description">
+			</head><body></body></html>`,
+			expect: PageMetadata{
+				Title:       "Plain Title",
+				Description: "Plain description",
+			},
+		},
+		{
+			name: "Article meta tags",
+			html: `<html><head>
+				<meta property="og:title" content="OG Title">
+				<meta property="article:author" content="https://example.com/authors/jane">
+				<meta name="author" content="Jane Doe">
+				<meta property="article:published_time" content="2025-03-04T09:30:00+01:00">
+			</head><body></body></html>`,
+			expect: PageMetadata{
+				Title:     "OG Title",
+				Author:    "Jane Doe",
+				Published: "2025-03-04",
+			},
+		},
+		{
+			name: "JSON-LD graph in the body",
+			html: `<html><head><title>Site | Headline</title></head><body>
+				<script type="application/ld+json">{
+					"@context": "https://schema.org",
+					"@graph": [
+						{"@type": "WebSite", "name": "Example News"},
+						{
+							"@type": ["NewsArticle"],
+							"headline": "Model beats benchmark",
+							"description": "A new model &amp; its results.",
+							"image": {"@type": "ImageObject", "url": "https://example.com/cover.jpg"},
+							"datePublished": "2025-05-01T08:00:00Z",
+							"author": [{"@type": "Person", "name": "Jane Doe"}, {"@type": "Person", "name": "John Roe"}],
+							"publisher": {"@type": "Organization", "name": "Example News"},
+						}
+					]
+				}</script>
+				<p>Please enable JavaScript</p>
+			</body></html>`,
+			expect: PageMetadata{
+				Title:       "Model beats benchmark",
+				Description: "A new model & its results.",
+				Image:       "https://example.com/cover.jpg",
+				SiteName:    "Example News",
+				Author:      "Jane Doe, John Roe",
+				Published:   "2025-05-01",
+			},
+		},
+		{
+			name: "Invalid JSON-LD is skipped",
+			html: `<html><head>
+				<title>Plain Title</title>
+				<script type="application/ld+json">{"headline": </script>
+				<meta name="date" content="May 2025">
+			</head><body></body></html>`,
+			expect: PageMetadata{
+				Title:     "Plain Title",
+				Published: "May 2025",
+			},
+		},
+		{
+			name: "Meta tags in body are ignored",
+			html: `<html><head></head><body>
+				<meta property="og:title" content="Body Title">
+			</body></html>`,
+			expect: PageMetadata{},
+		},
+	}
+
+	sourceURL, _ := url.Parse("https://example.com/articles/1")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ExtractMetadata(strings.NewReader(tt.html), sourceURL)
+			if err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+			if *result != tt.expect {
+				t.Errorf("Metadata mismatch\nExpected: %+v\nGot: %+v", tt.expect, *result)
+			}
+		})
+	}
+}
+
+func TestExtractMetadataNilReader(t *testing.T) {
+	_, err := ExtractMetadata(nil, nil)
+	if err == nil {
+		t.Error("Expected error with nil reader, got none")
+	}
+}
+
+func TestPageMetadataString(t *testing.T) {
+	meta := &PageMetadata{
+		Title:       "OG Title",
+		Description: "OG description",
+		SiteName:    "Example News",
+		Author:      "Jane Doe",
+		Published:   "2025-05-01",
+	}
+
+	result := meta.String()
+	for _, expected := range []string{"Site: Example News", "Title: OG Title", "Description: OG description", "Author: Jane Doe", "Published: 2025-05-01"} {
+		if !strings.Contains(result, expected) {
+			t.Errorf("Expected summary to contain: %s\nGot: %s", expected, result)
+		}
+	}
+	if strings.Contains(result, "Image:") {
+		t.Errorf("Expected summary to omit empty image\nGot: %s", result)
+	}
+}
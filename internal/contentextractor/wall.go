@@ -0,0 +1,75 @@
+package contentextractor
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+)
+
+// WallType identifies the kind of interstitial blocking a page's content
+type WallType string
+
+const (
+	WallNone    WallType = ""
+	WallPaywall WallType = "paywall"
+	WallConsent WallType = "consent"
+)
+
+// paywallMarkers are lower-cased snippets that indicate the article body is hidden behind a subscription
+var paywallMarkers = []string{
+	`"isaccessibleforfree":false`,
+	`"isaccessibleforfree":"false"`,
+	`"isaccessibleforfree": false`,
+	`"isaccessibleforfree": "false"`,
+	"subscribe to continue reading",
+	"subscribe to read the full",
+	"this article is for subscribers",
+	"this content is for subscribers",
+	"to continue reading, please subscribe",
+	"you've reached your free article limit",
+	"you have reached your free article limit",
+	"tinypass.com",
+}
+
+// consentMarkers are lower-cased snippets that indicate the page is a cookie or privacy consent interstitial
+var consentMarkers = []string{
+	"before you continue to google",
+	"consent.google.com",
+	"consent.yahoo.com",
+	"guce.yahoo.com",
+	"we and our partners need your consent",
+	"please accept cookies to continue",
+}
+
+// consentHostPrefixes are hostnames that sites redirect to while asking for consent
+var consentHostPrefixes = []string{
+	"consent.",
+	"guce.",
+}
+
+// DetectWall reports whether a fetched page is a paywall or consent interstitial rather than the article itself.
+// finalURL is the URL the page was served from after redirects and may be nil.
+func DetectWall(finalURL *url.URL, pageBody []byte) WallType {
+	if finalURL != nil {
+		host := strings.ToLower(finalURL.Hostname())
+		for _, prefix := range consentHostPrefixes {
+			if strings.HasPrefix(host, prefix) {
+				return WallConsent
+			}
+		}
+	}
+
+	lower := bytes.ToLower(pageBody)
+	for _, marker := range consentMarkers {
+		if bytes.Contains(lower, []byte(marker)) {
+			return WallConsent
+		}
+	}
+	for _, marker := range paywallMarkers {
+		if bytes.Contains(lower, []byte(marker)) {
+			return WallPaywall
+		}
+	}
+
+	return WallNone
+}
@@ -0,0 +1,60 @@
+package contentextractor
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestDetectWall(t *testing.T) {
+	tests := []struct {
+		name     string
+		finalURL string
+		html     string
+		expect   WallType
+	}{
+		{
+			name:     "Regular article",
+			finalURL: "https://example.com/article",
+			html:     `<html><body><article><p>Researchers announced a new model today.</p></article></body></html>`,
+			expect:   WallNone,
+		},
+		{
+			name:     "Structured data paywall",
+			finalURL: "https://news.example.com/story",
+			html:     `<html><head><script type="application/ld+json">{"@type":"NewsArticle","isAccessibleForFree":false}</script></head><body></body></html>`,
+			expect:   WallPaywall,
+		},
+		{
+			name:     "Subscription prompt",
+			finalURL: "https://news.example.com/story",
+			html:     `<html><body><p>Subscribe to continue reading this story.</p></body></html>`,
+			expect:   WallPaywall,
+		},
+		{
+			name:     "Consent page text",
+			finalURL: "https://www.google.com/",
+			html:     `<html><body><h1>Before you continue to Google</h1></body></html>`,
+			expect:   WallConsent,
+		},
+		{
+			name:     "Redirected to consent host",
+			finalURL: "https://consent.yahoo.com/v2/collectConsent",
+			html:     `<html><body></body></html>`,
+			expect:   WallConsent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			finalURL, err := url.Parse(tt.finalURL)
+			if err != nil {
+				t.Fatalf("Failed to parse URL %s: %v", tt.finalURL, err)
+			}
+
+			result := DetectWall(finalURL, []byte(tt.html))
+			if result != tt.expect {
+				t.Errorf("Wall type mismatch\nExpected: %q\nGot: %q", tt.expect, result)
+			}
+		})
+	}
+}
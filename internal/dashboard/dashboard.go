@@ -0,0 +1,216 @@
+// Package dashboard serves a small web UI from the daemon for browsing run history, what was
+// dropped from each digest and why, the digests themselves and the items sent to each persona.
+package dashboard
+
+import (
+	"crypto/subtle"
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/itemstore"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/internal/runhistory"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// DefaultItemDays is how far back the item browser goes when no days parameter is given
+const DefaultItemDays = 30
+
+var funcs = template.FuncMap{
+	"formatTime": func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Local().Format("2006-01-02 15:04")
+	},
+	"duration": func(start, end time.Time) string {
+		if end.Before(start) {
+			return ""
+		}
+		return end.Sub(start).Round(time.Second).String()
+	},
+	"ms": func(ms int64) string {
+		return (time.Duration(ms) * time.Millisecond).Round(time.Millisecond).String()
+	},
+	"totalSent": func(run runhistory.Run) int {
+		total := 0
+		for _, p := range run.Personas {
+			total += p.Sent
+		}
+		return total
+	},
+	"totalFailures": func(run runhistory.Run) int {
+		total := 0
+		for _, p := range run.Personas {
+			total += len(p.Failures)
+		}
+		return total
+	},
+	"totalTokens": func(run runhistory.Run) int64 {
+		var total int64
+		for _, u := range run.Usage {
+			total += u.PromptTokens + u.CompletionTokens
+		}
+		return total
+	},
+}
+
+// Server serves the dashboard
+type Server struct {
+	personas persona.Source
+	runs     *runhistory.Store
+	items    *itemstore.Store
+	token    string
+	pages    map[string]*template.Template
+	now      func() time.Time
+}
+
+// NewServer creates a dashboard server. If token is not empty, browsers must log in with HTTP
+// basic authentication using the token as password.
+func NewServer(personas persona.Source, runs *runhistory.Store, items *itemstore.Store, token string) (*Server, error) {
+	pages := make(map[string]*template.Template)
+	for _, page := range []string{"runs", "run", "digest", "items"} {
+		tmpl, err := template.New("layout.tmpl").Funcs(funcs).ParseFS(templateFS, "templates/layout.tmpl", "templates/"+page+".tmpl")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s template: %w", page, err)
+		}
+		pages[page] = tmpl
+	}
+	return &Server{
+		personas: personas,
+		runs:     runs,
+		items:    items,
+		token:    token,
+		pages:    pages,
+		now:      time.Now,
+	}, nil
+}
+
+// Handler returns the HTTP handler serving the dashboard
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.handleRuns)
+	mux.HandleFunc("GET /runs/{id}", s.handleRun)
+	mux.HandleFunc("GET /runs/{id}/digests/{persona}", s.handleDigest)
+	mux.HandleFunc("GET /items", s.handleItems)
+	return s.authenticate(mux)
+}
+
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	if s.token == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, password, ok := r.BasicAuth()
+		if !ok || subtle.ConstantTimeCompare([]byte(password), []byte(s.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="AI News Processor"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleRuns(w http.ResponseWriter, r *http.Request) {
+	runs, err := s.runs.List()
+	if err != nil {
+		s.serverError(w, "list runs", err)
+		return
+	}
+	s.render(w, "runs", map[string]interface{}{"Runs": runs})
+}
+
+func (s *Server) handleRun(w http.ResponseWriter, r *http.Request) {
+	run, ok := s.loadRun(w, r.PathValue("id"))
+	if !ok {
+		return
+	}
+	s.render(w, "run", map[string]interface{}{"Run": run})
+}
+
+func (s *Server) handleDigest(w http.ResponseWriter, r *http.Request) {
+	run, ok := s.loadRun(w, r.PathValue("id"))
+	if !ok {
+		return
+	}
+	for _, p := range run.Personas {
+		if p.Name == r.PathValue("persona") && p.Digest != nil {
+			s.render(w, "digest", map[string]interface{}{"Run": run, "Digest": p.Digest})
+			return
+		}
+	}
+	http.NotFound(w, r)
+}
+
+func (s *Server) handleItems(w http.ResponseWriter, r *http.Request) {
+	personas, err := s.personas.Personas()
+	if err != nil {
+		s.serverError(w, "load personas", err)
+		return
+	}
+
+	days := DefaultItemDays
+	if value := r.URL.Query().Get("days"); value != "" {
+		if days, err = strconv.Atoi(value); err != nil || days <= 0 {
+			http.Error(w, "days must be a positive number", http.StatusBadRequest)
+			return
+		}
+	}
+
+	selected := r.URL.Query().Get("persona")
+	if selected == "" && len(personas) > 0 {
+		selected = personas[0].Name
+	}
+	var records []itemstore.Record
+	if selected != "" {
+		records, err = s.items.Since(selected, s.now().AddDate(0, 0, -days))
+		if err != nil {
+			s.serverError(w, "load items", err)
+			return
+		}
+	}
+	// Most recent first
+	for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+		records[i], records[j] = records[j], records[i]
+	}
+
+	s.render(w, "items", map[string]interface{}{
+		"Personas": personas,
+		"Selected": selected,
+		"Days":     days,
+		"Records":  records,
+	})
+}
+
+func (s *Server) loadRun(w http.ResponseWriter, id string) (*runhistory.Run, bool) {
+	run, err := s.runs.Get(id)
+	if errors.Is(err, runhistory.ErrNotFound) {
+		http.Error(w, "run not found", http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		s.serverError(w, "load run", err)
+		return nil, false
+	}
+	return run, true
+}
+
+func (s *Server) render(w http.ResponseWriter, page string, data interface{}) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.pages[page].Execute(w, data); err != nil {
+		log.Printf("Dashboard: could not render %s page: %v", page, err)
+	}
+}
+
+func (s *Server) serverError(w http.ResponseWriter, action string, err error) {
+	log.Printf("Dashboard: could not %s: %v", action, err)
+	http.Error(w, "could not "+action, http.StatusInternalServerError)
+}
@@ -0,0 +1,111 @@
+package dashboard
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/digest"
+	"github.com/bakkerme/ai-news-processor/internal/itemstore"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/internal/runhistory"
+	"github.com/bakkerme/ai-news-processor/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T, token string) *Server {
+	t.Helper()
+	dir := t.TempDir()
+
+	personaDir := filepath.Join(dir, "personas")
+	require.NoError(t, os.MkdirAll(personaDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(personaDir, "localllama.yaml"), []byte("name: LocalLLaMA\nsubreddit: localllama\n"), 0644))
+
+	now := time.Date(2025, time.March, 7, 12, 0, 0, 0, time.UTC)
+	items := itemstore.New(filepath.Join(dir, "items"))
+	require.NoError(t, items.Append("LocalLLaMA", []models.Item{{ID: "a", Title: "New quant format", Summary: "Smaller files"}}, now.AddDate(0, 0, -1)))
+
+	summary := &models.SummaryResponse{KeyDevelopments: []models.KeyDevelopment{{Text: "Quantization <improves>", ItemID: "a"}}}
+	payload := digest.New("LocalLLaMA", []models.Item{{ID: "a", Title: "New quant format", Link: "https://example.com/a"}}, summary, now)
+	runs := runhistory.New(filepath.Join(dir, "runs"))
+	require.NoError(t, runs.Save(runhistory.Run{
+		ID:         "20250307T060000Z",
+		StartedAt:  now,
+		FinishedAt: now.Add(90 * time.Second),
+		Personas: []runhistory.Persona{{
+			Name:     "LocalLLaMA",
+			Fetched:  3,
+			Sent:     1,
+			Failures: []string{"submit to audit service: timeout"},
+			Dropped:  []runhistory.Dropped{{ID: "b", Title: "Meme", Reason: "not relevant: meme"}},
+			Digest:   &payload,
+		}},
+		Stages: []runhistory.Stage{{Persona: "LocalLLaMA", Name: "fetch", DurationMs: 1200}},
+		Usage:  []runhistory.Usage{{Model: "model", Calls: 2, PromptTokens: 1000, CompletionTokens: 200}},
+	}))
+
+	server, err := NewServer(persona.Dir(personaDir), runs, items, token)
+	require.NoError(t, err)
+	server.now = func() time.Time { return now }
+	return server
+}
+
+func get(t *testing.T, handler http.Handler, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	return rec
+}
+
+func TestServer_Pages(t *testing.T) {
+	handler := newTestServer(t, "").Handler()
+
+	rec := get(t, handler, "/")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `href="/runs/20250307T060000Z"`)
+	assert.Contains(t, rec.Body.String(), "1m30s")
+	assert.Contains(t, rec.Body.String(), "1200")
+
+	rec = get(t, handler, "/runs/20250307T060000Z")
+	require.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	assert.Contains(t, body, "submit to audit service: timeout")
+	assert.Contains(t, body, "not relevant: meme")
+	assert.Contains(t, body, "1.2s")
+	assert.Contains(t, body, `href="/runs/20250307T060000Z/digests/LocalLLaMA"`)
+
+	rec = get(t, handler, "/runs/20250307T060000Z/digests/LocalLLaMA")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Quantization &lt;improves&gt;", "content is escaped")
+	assert.Contains(t, rec.Body.String(), `<a href="https://example.com/a">New quant format</a>`)
+
+	rec = get(t, handler, "/items?persona=LocalLLaMA&days=7")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Smaller files")
+
+	rec = get(t, handler, "/items")
+	assert.Contains(t, rec.Body.String(), "Smaller files", "defaults to the first persona")
+
+	assert.Equal(t, http.StatusBadRequest, get(t, handler, "/items?days=-1").Code)
+	assert.Equal(t, http.StatusNotFound, get(t, handler, "/runs/20200101T000000Z").Code)
+	assert.Equal(t, http.StatusNotFound, get(t, handler, "/runs/20250307T060000Z/digests/Other").Code)
+	assert.Equal(t, http.StatusNotFound, get(t, handler, "/unknown").Code)
+}
+
+func TestServer_Token(t *testing.T) {
+	handler := newTestServer(t, "s3cret").Handler()
+
+	rec := get(t, handler, "/")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.NotEmpty(t, rec.Header().Get("WWW-Authenticate"))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.SetBasicAuth("admin", "s3cret")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,142 @@
+package digest
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
+	"github.com/bakkerme/ai-news-processor/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update the published schema in schemas/")
+
+const publishedSchema = "../../schemas/digest.v" + SchemaVersion + ".json"
+
+// TestPublishedSchema fails when the payload types change without the published schema being
+// regenerated with: go test ./internal/digest -update
+func TestPublishedSchema(t *testing.T) {
+	generated, err := SchemaJSON()
+	require.NoError(t, err)
+
+	if *update {
+		require.NoError(t, os.MkdirAll(filepath.Dir(publishedSchema), 0755))
+		require.NoError(t, os.WriteFile(publishedSchema, generated, 0644))
+	}
+
+	published, err := os.ReadFile(publishedSchema)
+	require.NoError(t, err)
+	assert.Equal(t, string(published), string(generated), "schema changed; if the change is compatible run go test ./internal/digest -update, otherwise bump SchemaVersion")
+}
+
+func testItems() []models.Item {
+	return []models.Item{
+		{
+			ID:       "a",
+			Title:    "Qwen 3 released",
+			Link:     "https://example.com/a",
+			Overview: []string{"New model"},
+			Summary:  "Summary",
+			Entities: []models.Entity{{Name: "Qwen 3", Type: models.EntityModel}},
+			Fields: []models.Field{
+				{Name: "license", Label: "License", Value: "Apache-2.0"},
+				{Name: "benchmarkNumbers", Label: "Benchmarks", Value: []string{"MMLU 81.2"}},
+			},
+			Entry: feeds.Entry{
+				Published: time.Date(2025, time.March, 7, 6, 0, 0, 0, time.UTC),
+				Comments:  []feeds.EntryComments{{Content: "nice"}},
+			},
+		},
+		{ID: "b", Title: "Untagged"},
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	summary := &models.SummaryResponse{
+		KeyDevelopments: []models.KeyDevelopment{{Text: "Qwen 3", ItemID: "a"}},
+		RisingTopics:    []models.RisingTopic{{Term: "qwen", Count: 4, Average: 0.5}},
+	}
+	p := New("LocalLLaMA", testItems(), summary, time.Date(2025, time.March, 7, 12, 0, 0, 0, time.UTC))
+
+	data, err := Marshal(p)
+	require.NoError(t, err)
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, "1", decoded["schemaVersion"])
+	items := decoded["items"].([]interface{})
+	require.Len(t, items, 2)
+	first := items[0].(map[string]interface{})
+	assert.Equal(t, "2025-03-07T06:00:00Z", first["publishedAt"])
+	assert.Equal(t, float64(1), first["comments"])
+	assert.Equal(t, map[string]interface{}{"license": "Apache-2.0", "benchmarkNumbers": []interface{}{"MMLU 81.2"}}, first["fields"])
+	second := items[1].(map[string]interface{})
+	assert.Equal(t, []interface{}{}, second["topics"], "empty lists are encoded as [] rather than null")
+	assert.NotContains(t, second, "publishedAt")
+	assert.NotContains(t, second, "fields")
+
+	// Payloads without a summary are still valid
+	_, err = Marshal(New("LocalLLaMA", nil, nil, time.Now()))
+	require.NoError(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	valid, err := Marshal(New("LocalLLaMA", testItems(), nil, time.Now()))
+	require.NoError(t, err)
+
+	mutate := func(change func(map[string]interface{})) []byte {
+		var payload map[string]interface{}
+		require.NoError(t, json.Unmarshal(valid, &payload))
+		change(payload)
+		data, err := json.Marshal(payload)
+		require.NoError(t, err)
+		return data
+	}
+	firstItem := func(payload map[string]interface{}) map[string]interface{} {
+		return payload["items"].([]interface{})[0].(map[string]interface{})
+	}
+
+	tests := []struct {
+		name    string
+		payload []byte
+		errText string
+	}{
+		{"missing required", mutate(func(p map[string]interface{}) { delete(p, "persona") }), `missing required property "persona"`},
+		{"unknown property", mutate(func(p map[string]interface{}) { p["extra"] = true }), `unexpected property "extra"`},
+		{"wrong type", mutate(func(p map[string]interface{}) { p["items"] = "none" }), "$.items: expected array"},
+		{"null list", mutate(func(p map[string]interface{}) { firstItem(p)["topics"] = nil }), "$.items[0].topics: expected array"},
+		{"bad enum", mutate(func(p map[string]interface{}) {
+			firstItem(p)["entities"] = []interface{}{map[string]interface{}{"name": "x", "type": "planet"}}
+		}), "planet is not one of"},
+		{"bad version", mutate(func(p map[string]interface{}) { p["schemaVersion"] = "2" }), "2 is not one of"},
+		{"bad date", mutate(func(p map[string]interface{}) { p["generatedAt"] = "yesterday" }), "invalid date-time"},
+		{"negative count", mutate(func(p map[string]interface{}) { firstItem(p)["comments"] = -1 }), "below the minimum"},
+		{"fractional count", mutate(func(p map[string]interface{}) { firstItem(p)["comments"] = 1.5 }), "expected integer"},
+		{"relative link", mutate(func(p map[string]interface{}) { firstItem(p)["link"] = "/a" }), "invalid uri"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.payload)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errText)
+		})
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	p := New("Local LLaMA", testItems(), nil, time.Date(2025, time.March, 7, 12, 0, 0, 0, time.UTC))
+
+	path, err := WriteFile(dir, p)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "local_llama-20250307T120000Z.json"), path)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, Validate(data))
+}
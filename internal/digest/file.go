@@ -0,0 +1,30 @@
+package digest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var unsafeFileChars = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// WriteFile validates the payload and writes it to dir as <persona>-<timestamp>.json,
+// returning the path of the written file
+func WriteFile(dir string, p Payload) (string, error) {
+	data, err := Marshal(p)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("could not create digest output directory: %w", err)
+	}
+
+	name := unsafeFileChars.ReplaceAllString(strings.ToLower(p.Persona), "_")
+	path := filepath.Join(dir, fmt.Sprintf("%s-%s.json", name, p.GeneratedAt.UTC().Format("20060102T150405Z")))
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("could not write digest: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,146 @@
+// Package digest defines the versioned JSON payload describing a sent digest. It is the contract for
+// every machine-readable output (files, webhooks, APIs), has a published JSON Schema, and every
+// payload is validated against that schema before it leaves the process.
+package digest
+
+import (
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/models"
+)
+
+// SchemaVersion is the major version of the payload schema. It changes whenever a change could
+// break consumers, such as removing or renaming a field. Adding optional fields does not change it.
+const SchemaVersion = "1"
+
+// Payload is a digest sent for one persona
+type Payload struct {
+	SchemaVersion   string           `json:"schemaVersion" jsonschema:"enum=1,description=Major version of this schema"`
+	Persona         string           `json:"persona" jsonschema:"description=Name of the persona the digest was built for"`
+	GeneratedAt     time.Time        `json:"generatedAt"`
+	KeyDevelopments []KeyDevelopment `json:"keyDevelopments"`
+	RisingTopics    []RisingTopic    `json:"risingTopics"`
+	Items           []Item           `json:"items"`
+}
+
+// KeyDevelopment is one entry of the digest summary
+type KeyDevelopment struct {
+	Text   string `json:"text"`
+	ItemID string `json:"itemId" jsonschema:"description=ID of the item the development refers to"`
+}
+
+// RisingTopic is a term mentioned noticeably more often than in previous runs
+type RisingTopic struct {
+	Term    string   `json:"term"`
+	Count   int      `json:"count" jsonschema:"minimum=0"`
+	Average float64  `json:"average" jsonschema:"minimum=0"`
+	ItemIDs []string `json:"itemIds"`
+}
+
+// Item is a relevant item included in the digest
+type Item struct {
+	ID             string     `json:"id"`
+	Title          string     `json:"title"`
+	Link           string     `json:"link,omitempty" jsonschema:"format=uri"`
+	Overview       []string   `json:"overview"`
+	Summary        string     `json:"summary"`
+	CommentSummary string     `json:"commentSummary,omitempty"`
+	ThumbnailURL   string     `json:"thumbnailUrl,omitempty" jsonschema:"format=uri"`
+	PublishedAt    *time.Time `json:"publishedAt,omitempty"`
+	Comments       int        `json:"comments" jsonschema:"minimum=0"`
+	Entities       []Entity   `json:"entities"`
+	Topics         []string   `json:"topics"`
+	Unavailable    bool       `json:"unavailable,omitempty" jsonschema:"description=Set when the item could not be processed and only its title and link are known"`
+	Flair          string     `json:"flair,omitempty" jsonschema:"description=Reddit link flair or the first RSS category"`
+	Updated        bool       `json:"updated,omitempty" jsonschema:"description=Set when the item was sent before and is sent again because its post was edited substantially since"`
+	Megathread     bool       `json:"megathread,omitempty" jsonschema:"description=Set when the item is a live thread or megathread, summarized by the themes of its discussion"`
+	Unsure         bool       `json:"unsure,omitempty" jsonschema:"description=Set when the LLM was unsure whether the item is relevant. Such items are listed apart from the digest"`
+
+	Fields map[string]any `json:"fields,omitempty" jsonschema:"description=Values of the custom fields of the persona by field name. Values are strings or numbers or booleans or lists of strings"`
+
+	ImportanceScore  int    `json:"importanceScore,omitempty" jsonschema:"minimum=1,maximum=10,description=Importance assigned by the LLM from 1 (minor) to 10 (major news)"`
+	ImportanceReason string `json:"importanceReason,omitempty" jsonschema:"description=One-line justification of the importance score"`
+
+	RelevanceConfidence int `json:"relevanceConfidence,omitempty" jsonschema:"minimum=1,maximum=100,description=How sure the LLM was of its relevance decision from 1 to 100"`
+}
+
+// Entity is a named thing an item is about
+type Entity struct {
+	Name string `json:"name"`
+	Type string `json:"type" jsonschema:"enum=model,enum=company,enum=library,enum=dataset,enum=person,enum=other"`
+}
+
+// New builds the payload for a digest. summary may be nil.
+func New(personaName string, items []models.Item, summary *models.SummaryResponse, generatedAt time.Time) Payload {
+	p := Payload{
+		SchemaVersion:   SchemaVersion,
+		Persona:         personaName,
+		GeneratedAt:     generatedAt,
+		KeyDevelopments: []KeyDevelopment{},
+		RisingTopics:    []RisingTopic{},
+		Items:           make([]Item, 0, len(items)),
+	}
+
+	if summary != nil {
+		for _, kd := range summary.KeyDevelopments {
+			p.KeyDevelopments = append(p.KeyDevelopments, KeyDevelopment{Text: kd.Text, ItemID: kd.ItemID})
+		}
+		for _, topic := range summary.RisingTopics {
+			p.RisingTopics = append(p.RisingTopics, RisingTopic{
+				Term:    topic.Term,
+				Count:   topic.Count,
+				Average: topic.Average,
+				ItemIDs: nonNil(topic.ItemIDs),
+			})
+		}
+	}
+
+	for _, item := range items {
+		out := Item{
+			ID:             item.ID,
+			Title:          item.Title,
+			Link:           item.Link,
+			Overview:       nonNil(item.Overview),
+			Summary:        item.Summary,
+			CommentSummary: item.CommentSummary,
+			ThumbnailURL:   item.ThumbnailURL,
+			Comments:       len(item.Entry.Comments),
+			Entities:       make([]Entity, 0, len(item.Entities)),
+			Topics:         nonNil(item.Topics),
+			Unavailable:    item.Unavailable,
+			Flair:          item.Entry.Flair,
+			Updated:        item.Updated,
+			Megathread:     item.Megathread,
+			Unsure:         item.Unsure,
+
+			ImportanceScore:  item.ImportanceScore,
+			ImportanceReason: item.ImportanceReason,
+
+			RelevanceConfidence: item.RelevanceConfidence,
+		}
+		if !item.Entry.Published.IsZero() {
+			published := item.Entry.Published
+			out.PublishedAt = &published
+		}
+		for _, entity := range item.Entities {
+			out.Entities = append(out.Entities, Entity{Name: entity.Name, Type: entity.Type})
+		}
+		if len(item.Fields) > 0 {
+			out.Fields = make(map[string]any, len(item.Fields))
+			for _, field := range item.Fields {
+				out.Fields[field.Name] = field.Value
+			}
+		}
+		p.Items = append(p.Items, out)
+	}
+
+	return p
+}
+
+// nonNil returns s, or an empty slice if s is nil, so it is encoded as [] rather than null
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
@@ -0,0 +1,161 @@
+package digest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/invopop/jsonschema"
+)
+
+// SchemaID is the identifier of the published schema. The schema itself is kept in
+// schemas/digest.v1.json at the repository root.
+const SchemaID = "https://github.com/bakkerme/ai-news-processor/schemas/digest.v" + SchemaVersion + ".json"
+
+// Schema returns the JSON Schema of Payload
+func Schema() *jsonschema.Schema {
+	reflector := jsonschema.Reflector{
+		AllowAdditionalProperties: false,
+		DoNotReference:            true,
+	}
+	schema := reflector.Reflect(Payload{})
+	schema.ID = jsonschema.ID(SchemaID)
+	schema.Title = "AI News Processor digest"
+	return schema
+}
+
+// SchemaJSON returns the JSON Schema of Payload, indented for publishing
+func SchemaJSON() ([]byte, error) {
+	data, err := json.MarshalIndent(Schema(), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("could not encode digest schema: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// Marshal encodes the payload and validates the result against the schema, so a payload that
+// would break consumers is never sent
+func Marshal(p Payload) ([]byte, error) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode digest: %w", err)
+	}
+	if err := Validate(data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Validate checks an encoded payload against the schema. It supports the subset of JSON Schema
+// produced for Payload: types, properties, required, additionalProperties, items, enum, minimum
+// and the date-time and uri formats.
+func Validate(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("digest is not valid JSON: %w", err)
+	}
+	if err := validate(Schema(), value, "$"); err != nil {
+		return fmt.Errorf("digest does not match schema v%s: %w", SchemaVersion, err)
+	}
+	return nil
+}
+
+func validate(schema *jsonschema.Schema, value interface{}, path string) error {
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected object", path)
+		}
+		for _, name := range schema.Required {
+			if _, ok := object[name]; !ok {
+				return fmt.Errorf("%s: missing required property %q", path, name)
+			}
+		}
+		for name, child := range object {
+			var propertySchema *jsonschema.Schema
+			if schema.Properties != nil {
+				propertySchema, _ = schema.Properties.Get(name)
+			}
+			if propertySchema == nil {
+				if schema.AdditionalProperties == jsonschema.FalseSchema {
+					return fmt.Errorf("%s: unexpected property %q", path, name)
+				}
+				continue
+			}
+			if err := validate(propertySchema, child, path+"."+name); err != nil {
+				return err
+			}
+		}
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected array", path)
+		}
+		if schema.Items != nil {
+			for i, child := range array {
+				if err := validate(schema.Items, child, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s: expected string", path)
+		}
+		if err := validateFormat(schema.Format, s); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	case "integer", "number":
+		n, ok := value.(json.Number)
+		if !ok {
+			return fmt.Errorf("%s: expected %s", path, schema.Type)
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return fmt.Errorf("%s: invalid number %s", path, n)
+		}
+		if schema.Type == "integer" && f != math.Trunc(f) {
+			return fmt.Errorf("%s: expected integer, got %s", path, n)
+		}
+		if schema.Minimum != "" {
+			if minimum, err := schema.Minimum.Float64(); err == nil && f < minimum {
+				return fmt.Errorf("%s: %s is below the minimum of %s", path, n, schema.Minimum)
+			}
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: expected boolean", path)
+		}
+	}
+
+	if len(schema.Enum) > 0 && !slices.ContainsFunc(schema.Enum, func(allowed any) bool {
+		return fmt.Sprint(allowed) == fmt.Sprint(value)
+	}) {
+		return fmt.Errorf("%s: %v is not one of %v", path, value, schema.Enum)
+	}
+	return nil
+}
+
+func validateFormat(format string, s string) error {
+	switch format {
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, s); err != nil {
+			return fmt.Errorf("invalid date-time %q", s)
+		}
+	case "uri":
+		u, err := url.Parse(s)
+		if err != nil || u.Scheme == "" || strings.ContainsAny(s, " \t\n") {
+			return fmt.Errorf("invalid uri %q", s)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+)
+
+// Subreddit describes a subreddit returned by a search
+type Subreddit struct {
+	Name        string
+	Title       string
+	Description string
+	Subscribers int
+	ActiveUsers int
+	NSFW        bool
+}
+
+// Post holds the fields of a recent post used to estimate subreddit activity
+type Post struct {
+	Created  time.Time
+	Comments int
+}
+
+// Searcher is the subset of the Reddit API needed to discover subreddits
+type Searcher interface {
+	// SearchSubreddits returns up to limit subreddits matching query
+	SearchSubreddits(ctx context.Context, query string, limit int) ([]Subreddit, error)
+	// RecentPosts returns up to limit of the newest posts in a subreddit
+	RecentPosts(ctx context.Context, subreddit string, limit int) ([]Post, error)
+}
+
+// Options controls how suggestions are gathered and ranked
+type Options struct {
+	ResultsPerQuery int  // Subreddits requested per focus area search
+	Limit           int  // Maximum number of suggestions returned
+	MinSubscribers  int  // Subreddits with fewer subscribers are skipped
+	IncludeNSFW     bool // Whether NSFW subreddits are suggested
+	SamplePosts     int  // Recent posts fetched to estimate activity
+}
+
+// DefaultOptions provides sensible defaults for the discovery command
+var DefaultOptions = Options{
+	ResultsPerQuery: 10,
+	Limit:           10,
+	MinSubscribers:  1000,
+	IncludeNSFW:     false,
+	SamplePosts:     25,
+}
+
+// Suggestion is a subreddit that matched one or more of a persona's focus areas
+type Suggestion struct {
+	Subreddit      Subreddit
+	MatchedQueries []string // Focus areas whose search returned this subreddit
+	PostsPerDay    float64  // Estimated from the newest posts (0 if unknown)
+	MedianComments int      // Median comment count of the newest posts
+}
+
+// FeedURL returns the RSS feed URL for the suggested subreddit
+func (s Suggestion) FeedURL() string {
+	return fmt.Sprintf("https://www.reddit.com/r/%s/.rss", s.Subreddit.Name)
+}
+
+// Suggest searches for subreddits matching each of the persona's focus areas (or its topic if it has none)
+// and returns the best matches ranked by how many focus areas they matched, then by subscribers.
+// The persona's own subreddit is never suggested.
+func Suggest(ctx context.Context, searcher Searcher, p persona.Persona, opts Options) ([]Suggestion, error) {
+	queries := p.FocusAreas
+	if len(queries) == 0 && p.Topic != "" {
+		queries = []string{p.Topic}
+	}
+	if len(queries) == 0 {
+		return nil, fmt.Errorf("persona %s has no focus areas or topic to search for", p.Name)
+	}
+
+	byName := make(map[string]*Suggestion)
+	var failed int
+	for _, query := range queries {
+		results, err := searcher.SearchSubreddits(ctx, query, opts.ResultsPerQuery)
+		if err != nil {
+			log.Printf("Warning: subreddit search for %q failed: %v", query, err)
+			failed++
+			continue
+		}
+
+		for _, sub := range results {
+			key := strings.ToLower(sub.Name)
+			if key == "" || key == strings.ToLower(p.Subreddit) {
+				continue
+			}
+			if sub.NSFW && !opts.IncludeNSFW {
+				continue
+			}
+			if sub.Subscribers < opts.MinSubscribers {
+				continue
+			}
+
+			suggestion, ok := byName[key]
+			if !ok {
+				suggestion = &Suggestion{Subreddit: sub}
+				byName[key] = suggestion
+			}
+			suggestion.MatchedQueries = append(suggestion.MatchedQueries, query)
+		}
+	}
+	if failed == len(queries) {
+		return nil, fmt.Errorf("all %d subreddit searches failed", failed)
+	}
+
+	suggestions := make([]Suggestion, 0, len(byName))
+	for _, suggestion := range byName {
+		suggestions = append(suggestions, *suggestion)
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		if len(a.MatchedQueries) != len(b.MatchedQueries) {
+			return len(a.MatchedQueries) > len(b.MatchedQueries)
+		}
+		if a.Subreddit.Subscribers != b.Subreddit.Subscribers {
+			return a.Subreddit.Subscribers > b.Subreddit.Subscribers
+		}
+		return a.Subreddit.Name < b.Subreddit.Name
+	})
+
+	if opts.Limit > 0 && len(suggestions) > opts.Limit {
+		suggestions = suggestions[:opts.Limit]
+	}
+
+	// Activity stats need one request per subreddit, so they are only gathered for the final list
+	if opts.SamplePosts > 0 {
+		for i := range suggestions {
+			posts, err := searcher.RecentPosts(ctx, suggestions[i].Subreddit.Name, opts.SamplePosts)
+			if err != nil {
+				log.Printf("Warning: could not fetch recent posts for r/%s: %v", suggestions[i].Subreddit.Name, err)
+				continue
+			}
+			suggestions[i].PostsPerDay, suggestions[i].MedianComments = activity(posts)
+		}
+	}
+
+	return suggestions, nil
+}
+
+// activity estimates posting frequency and typical discussion size from a sample of recent posts
+func activity(posts []Post) (postsPerDay float64, medianComments int) {
+	if len(posts) == 0 {
+		return 0, 0
+	}
+
+	newest, oldest := posts[0].Created, posts[0].Created
+	comments := make([]int, len(posts))
+	for i, post := range posts {
+		if post.Created.After(newest) {
+			newest = post.Created
+		}
+		if post.Created.Before(oldest) {
+			oldest = post.Created
+		}
+		comments[i] = post.Comments
+	}
+	sort.Ints(comments)
+	medianComments = comments[len(comments)/2]
+
+	if span := newest.Sub(oldest); len(posts) > 1 && span > 0 {
+		postsPerDay = float64(len(posts)-1) / span.Hours() * 24
+	}
+	return postsPerDay, medianComments
+}
+
+// WriteTable writes suggestions as an aligned text table
+func WriteTable(w io.Writer, suggestions []Suggestion) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SUBREDDIT\tSUBSCRIBERS\tACTIVE\tPOSTS/DAY\tMEDIAN COMMENTS\tMATCHED\tFEED")
+	for _, s := range suggestions {
+		fmt.Fprintf(tw, "r/%s\t%d\t%d\t%.1f\t%d\t%s\t%s\n",
+			s.Subreddit.Name,
+			s.Subreddit.Subscribers,
+			s.Subreddit.ActiveUsers,
+			s.PostsPerDay,
+			s.MedianComments,
+			strings.Join(s.MatchedQueries, ", "),
+			s.FeedURL(),
+		)
+	}
+	return tw.Flush()
+}
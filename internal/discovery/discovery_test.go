@@ -0,0 +1,136 @@
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSearcher struct {
+	results map[string][]Subreddit
+	posts   map[string][]Post
+	failing map[string]bool
+}
+
+func (f *fakeSearcher) SearchSubreddits(ctx context.Context, query string, limit int) ([]Subreddit, error) {
+	if f.failing[query] {
+		return nil, fmt.Errorf("search failed")
+	}
+	return f.results[query], nil
+}
+
+func (f *fakeSearcher) RecentPosts(ctx context.Context, subreddit string, limit int) ([]Post, error) {
+	posts, ok := f.posts[subreddit]
+	if !ok {
+		return nil, fmt.Errorf("no posts")
+	}
+	return posts, nil
+}
+
+func TestSuggest(t *testing.T) {
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	searcher := &fakeSearcher{
+		results: map[string][]Subreddit{
+			"LLM releases": {
+				{Name: "LocalLLaMA", Subscribers: 500000},
+				{Name: "MachineLearning", Subscribers: 3000000},
+				{Name: "singularity", Subscribers: 2000000},
+			},
+			"Model infrastructure": {
+				{Name: "MachineLearning", Subscribers: 3000000},
+				{Name: "mlops", Subscribers: 20000},
+				{Name: "tinysub", Subscribers: 12},
+				{Name: "nsfwsub", Subscribers: 50000, NSFW: true},
+			},
+		},
+		posts: map[string][]Post{
+			"MachineLearning": {
+				{Created: now, Comments: 10},
+				{Created: now.Add(-6 * time.Hour), Comments: 2},
+				{Created: now.Add(-12 * time.Hour), Comments: 40},
+			},
+		},
+	}
+
+	p := persona.Persona{
+		Name:       "LocalLLaMA",
+		Subreddit:  "localllama",
+		FocusAreas: []string{"LLM releases", "Model infrastructure"},
+	}
+
+	suggestions, err := Suggest(context.Background(), searcher, p, DefaultOptions)
+	require.NoError(t, err)
+
+	names := make([]string, len(suggestions))
+	for i, s := range suggestions {
+		names[i] = s.Subreddit.Name
+	}
+	assert.Equal(t, []string{"MachineLearning", "singularity", "mlops"}, names)
+
+	assert.Equal(t, []string{"LLM releases", "Model infrastructure"}, suggestions[0].MatchedQueries)
+	assert.InDelta(t, 4.0, suggestions[0].PostsPerDay, 0.001)
+	assert.Equal(t, 10, suggestions[0].MedianComments)
+	assert.Equal(t, "https://www.reddit.com/r/MachineLearning/.rss", suggestions[0].FeedURL())
+
+	// Stats are left empty when recent posts can't be fetched
+	assert.Zero(t, suggestions[1].PostsPerDay)
+}
+
+func TestSuggest_Options(t *testing.T) {
+	searcher := &fakeSearcher{
+		results: map[string][]Subreddit{
+			"gardening": {
+				{Name: "gardening", Subscribers: 100},
+				{Name: "vegetablegardening", Subscribers: 50},
+				{Name: "plantsnsfw", Subscribers: 80, NSFW: true},
+			},
+		},
+	}
+	p := persona.Persona{Name: "Garden", Topic: "gardening"}
+
+	opts := Options{Limit: 2, MinSubscribers: 60, IncludeNSFW: true}
+	suggestions, err := Suggest(context.Background(), searcher, p, opts)
+	require.NoError(t, err)
+	require.Len(t, suggestions, 2)
+	assert.Equal(t, "gardening", suggestions[0].Subreddit.Name)
+	assert.Equal(t, "plantsnsfw", suggestions[1].Subreddit.Name)
+}
+
+func TestSuggest_Errors(t *testing.T) {
+	_, err := Suggest(context.Background(), &fakeSearcher{}, persona.Persona{Name: "empty"}, DefaultOptions)
+	assert.Error(t, err)
+
+	searcher := &fakeSearcher{failing: map[string]bool{"a": true, "b": true}}
+	_, err = Suggest(context.Background(), searcher, persona.Persona{Name: "p", FocusAreas: []string{"a", "b"}}, DefaultOptions)
+	assert.Error(t, err)
+
+	// A single failing search doesn't fail the whole run
+	searcher.results = map[string][]Subreddit{"b": {{Name: "sub", Subscribers: 5000}}}
+	searcher.failing = map[string]bool{"a": true}
+	suggestions, err := Suggest(context.Background(), searcher, persona.Persona{Name: "p", FocusAreas: []string{"a", "b"}}, DefaultOptions)
+	require.NoError(t, err)
+	assert.Len(t, suggestions, 1)
+}
+
+func TestWriteTable(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteTable(&buf, []Suggestion{{
+		Subreddit:      Subreddit{Name: "mlops", Subscribers: 20000, ActiveUsers: 40},
+		MatchedQueries: []string{"Model infrastructure"},
+		PostsPerDay:    3.25,
+		MedianComments: 4,
+	}})
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], "SUBREDDIT"))
+	assert.Equal(t, []string{"r/mlops", "20000", "40", "3.2", "4", "Model", "infrastructure", "https://www.reddit.com/r/mlops/.rss"}, strings.Fields(lines[1]))
+}
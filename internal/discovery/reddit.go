@@ -0,0 +1,73 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/vartanbeno/go-reddit/v2/reddit"
+)
+
+// RedditSearcher implements Searcher using the Reddit API
+type RedditSearcher struct {
+	client *reddit.Client
+}
+
+// NewRedditSearcher creates a searcher authenticated with the given Reddit API credentials
+func NewRedditSearcher(clientID, clientSecret, username, password string) (*RedditSearcher, error) {
+	client, err := reddit.NewClient(reddit.Credentials{
+		ID:       clientID,
+		Secret:   clientSecret,
+		Username: username,
+		Password: password,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Reddit client: %w", err)
+	}
+	return &RedditSearcher{client: client}, nil
+}
+
+// SearchSubreddits implements Searcher
+func (r *RedditSearcher) SearchSubreddits(ctx context.Context, query string, limit int) ([]Subreddit, error) {
+	// The client puts the query into the URL as-is, so it has to be escaped here
+	results, _, err := r.client.Subreddit.Search(ctx, url.QueryEscape(query), &reddit.ListSubredditOptions{
+		ListOptions: reddit.ListOptions{Limit: limit},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search subreddits for %q: %w", query, err)
+	}
+
+	subreddits := make([]Subreddit, 0, len(results))
+	for _, result := range results {
+		sub := Subreddit{
+			Name:        result.Name,
+			Title:       result.Title,
+			Description: result.Description,
+			Subscribers: result.Subscribers,
+			NSFW:        result.NSFW,
+		}
+		if result.ActiveUserCount != nil {
+			sub.ActiveUsers = *result.ActiveUserCount
+		}
+		subreddits = append(subreddits, sub)
+	}
+	return subreddits, nil
+}
+
+// RecentPosts implements Searcher
+func (r *RedditSearcher) RecentPosts(ctx context.Context, subreddit string, limit int) ([]Post, error) {
+	results, _, err := r.client.Subreddit.NewPosts(ctx, subreddit, &reddit.ListOptions{Limit: limit})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch new posts from r/%s: %w", subreddit, err)
+	}
+
+	posts := make([]Post, 0, len(results))
+	for _, result := range results {
+		post := Post{Comments: result.NumberOfComments}
+		if result.Created != nil {
+			post.Created = result.Created.Time
+		}
+		posts = append(posts, post)
+	}
+	return posts, nil
+}
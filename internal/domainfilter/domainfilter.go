@@ -0,0 +1,87 @@
+package domainfilter
+
+import (
+	"net/url"
+	"strings"
+)
+
+// DefaultDenyDomains are sites that never yield useful article text without a login or JavaScript
+var DefaultDenyDomains = []string{
+	"twitter.com",
+	"x.com",
+	"facebook.com",
+	"instagram.com",
+	"linkedin.com",
+	"tiktok.com",
+}
+
+// Filter decides which external URLs may be fetched based on their domain.
+// A domain matches itself and all of its subdomains. Allowed domains take precedence over denied ones,
+// so a deny entry such as "google.com" can be narrowed with an allow entry such as "blog.google.com".
+type Filter struct {
+	deny  []string
+	allow []string
+}
+
+// New creates a filter from lists of denied and force-allowed domains
+func New(deny []string, allow []string) *Filter {
+	return &Filter{
+		deny:  normalize(deny),
+		allow: normalize(allow),
+	}
+}
+
+// With returns a new filter that adds the given denied and allowed domains to f.
+// It is used to layer persona settings on top of the global configuration.
+func (f *Filter) With(deny []string, allow []string) *Filter {
+	if f == nil {
+		return New(deny, allow)
+	}
+	return &Filter{
+		deny:  append(append([]string{}, f.deny...), normalize(deny)...),
+		allow: append(append([]string{}, f.allow...), normalize(allow)...),
+	}
+}
+
+// Allowed reports whether u may be fetched
+func (f *Filter) Allowed(u *url.URL) bool {
+	if f == nil {
+		return true
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if matchesAny(host, f.allow) {
+		return true
+	}
+	return !matchesAny(host, f.deny)
+}
+
+// matchesAny reports whether host equals or is a subdomain of any of the domains
+func matchesAny(host string, domains []string) bool {
+	for _, domain := range domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalize lower-cases domains and strips schemes, paths and a leading www. so list entries can be written loosely
+func normalize(domains []string) []string {
+	normalized := make([]string, 0, len(domains))
+	for _, domain := range domains {
+		domain = strings.ToLower(strings.TrimSpace(domain))
+		if i := strings.Index(domain, "://"); i >= 0 {
+			domain = domain[i+3:]
+		}
+		if i := strings.IndexAny(domain, "/:"); i >= 0 {
+			domain = domain[:i]
+		}
+		domain = strings.TrimPrefix(domain, "www.")
+		domain = strings.Trim(domain, ".")
+		if domain != "" {
+			normalized = append(normalized, domain)
+		}
+	}
+	return normalized
+}
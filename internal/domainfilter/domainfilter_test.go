@@ -0,0 +1,59 @@
+package domainfilter
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilter_Allowed(t *testing.T) {
+	filter := New(
+		[]string{"twitter.com", "https://www.Google.com/", " medium.com "},
+		[]string{"blog.google.com"},
+	)
+
+	tests := []struct {
+		url      string
+		expected bool
+	}{
+		{"https://twitter.com/user/status/1", false},
+		{"https://mobile.twitter.com/user/status/1", false},
+		{"https://nottwitter.com/article", true},
+		{"https://www.google.com/search?q=llm", false},
+		{"https://blog.google.com/technology/ai/", true},
+		{"https://ai.blog.google.com/post", true},
+		{"https://towardsai.medium.com/post", false},
+		{"https://example.com/article", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, filter.Allowed(u))
+		})
+	}
+}
+
+func TestFilter_With(t *testing.T) {
+	global := New(DefaultDenyDomains, nil)
+	persona := global.With([]string{"reddit.com"}, []string{"x.com"})
+
+	xURL, _ := url.Parse("https://x.com/user/status/1")
+	redditURL, _ := url.Parse("https://www.reddit.com/r/LocalLLaMA")
+
+	assert.False(t, global.Allowed(xURL))
+	assert.True(t, global.Allowed(redditURL))
+
+	assert.True(t, persona.Allowed(xURL), "persona allow list overrides the global deny list")
+	assert.False(t, persona.Allowed(redditURL))
+}
+
+func TestFilter_Nil(t *testing.T) {
+	var filter *Filter
+	u, _ := url.Parse("https://twitter.com/")
+	assert.True(t, filter.Allowed(u))
+	assert.False(t, filter.With([]string{"twitter.com"}, nil).Allowed(u))
+}
@@ -0,0 +1,186 @@
+// Package edits detects posts that were edited after they were sent. Posts get corrected, expanded
+// with benchmarks or updated with a release after they first appear, and the sent log would
+// otherwise skip them forever after their first digest.
+package edits
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// DefaultMaxAge is how long the content of a sent entry is remembered. Posts are rarely edited
+// after they have left the feed.
+const DefaultMaxAge = 30 * 24 * time.Hour
+
+// signatureSize is the number of hashes in a fingerprint. More hashes estimate the change more
+// precisely; with 128 the estimate is usually within a few percent.
+const signatureSize = 128
+
+// shingleWords is the number of words in a shingle. Comparing runs of words rather than single
+// words also counts reordered and rewritten sentences as changes.
+const shingleWords = 3
+
+// Fingerprint is a MinHash signature of the words of a post, from which the share of content two
+// versions of the post have in common can be estimated
+type Fingerprint []uint32
+
+// Compute returns the fingerprint of a post. Case, punctuation and whitespace are ignored, so
+// fixing a typo's capitalisation or reflowing a paragraph does not count as an edit.
+func Compute(title, content string) Fingerprint {
+	words := strings.FieldsFunc(strings.ToLower(title+"\n"+content), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	if len(words) == 0 {
+		return Fingerprint{}
+	}
+
+	n := min(shingleWords, len(words))
+	signature := make(Fingerprint, signatureSize)
+	for i := range signature {
+		signature[i] = ^uint32(0)
+	}
+	for i := 0; i+n <= len(words); i++ {
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join(words[i:i+n], " ")))
+		shingle := h.Sum64()
+		for j := range signature {
+			if v := mix(shingle, j); v < signature[j] {
+				signature[j] = v
+			}
+		}
+	}
+	return signature
+}
+
+// mix derives the jth hash of a shingle with the SplitMix64 finalizer
+func mix(shingle uint64, j int) uint32 {
+	x := shingle + uint64(j+1)*0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return uint32(x ^ (x >> 31))
+}
+
+// Change estimates the share of the content of two versions of a post that differs, from 0 for
+// the same content to 1 for nothing in common
+func (f Fingerprint) Change(other Fingerprint) float64 {
+	if len(f) == 0 && len(other) == 0 {
+		return 0
+	}
+	if len(f) != len(other) {
+		return 1
+	}
+	differ := 0
+	for i := range f {
+		if f[i] != other[i] {
+			differ++
+		}
+	}
+	return float64(differ) / float64(len(f))
+}
+
+// record is the content of an entry as it was sent
+type record struct {
+	Fingerprint Fingerprint `json:"fingerprint"`
+	SentAt      time.Time   `json:"sentAt"`
+}
+
+// Store remembers the content of sent entries, so later runs can tell whether they were edited
+type Store struct {
+	path string
+	now  func() time.Time
+
+	mu      sync.Mutex
+	records map[string]record
+}
+
+// Load reads the store from disk, forgetting entries sent longer than maxAge ago. If the file
+// does not exist, an empty store is returned.
+func Load(path string, maxAge time.Duration) (*Store, error) {
+	s := &Store{
+		path:    path,
+		now:     time.Now,
+		records: make(map[string]record),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("could not read sent content: %w", err)
+	}
+
+	var records map[string]record
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("could not parse sent content: %w", err)
+	}
+
+	cutoff := s.now().Add(-maxAge)
+	for id, r := range records {
+		if id == "" || r.SentAt.Before(cutoff) {
+			continue
+		}
+		s.records[id] = r
+	}
+
+	return s, nil
+}
+
+// Change returns how much of the content of entry id changed since it was sent, and false if its
+// content was not recorded. A nil store has recorded nothing.
+func (s *Store) Change(id string, fp Fingerprint) (float64, bool) {
+	if s == nil {
+		return 0, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.records[id]
+	if !ok {
+		return 0, false
+	}
+	return r.Fingerprint.Change(fp), true
+}
+
+// Record remembers the content entry id was sent with, replacing what was recorded before
+func (s *Store) Record(id string, fp Fingerprint) {
+	if s == nil || id == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[id] = record{Fingerprint: fp, SentAt: s.now()}
+}
+
+// Save persists the store to disk as a JSON object keyed by entry ID
+func (s *Store) Save() error {
+	s.mu.Lock()
+	payload, err := json.MarshalIndent(s.records, "", "  ")
+	s.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("could not encode sent content: %w", err)
+	}
+
+	dir := filepath.Dir(s.path)
+	if dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("could not create sent content directory: %w", err)
+		}
+	}
+
+	if err := os.WriteFile(s.path, payload, 0644); err != nil {
+		return fmt.Errorf("could not write sent content: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package edits
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const post = `We are releasing Qwen 3 today, a family of open weight models from 0.6B to 235B parameters.
+The models support a thinking mode for complex reasoning and a fast mode for chat, and can switch
+between them within a conversation. Weights are available under the Apache 2.0 license on Hugging
+Face and ModelScope, and the models run in llama.cpp, vLLM and Ollama from day one.`
+
+func TestFingerprint_Change(t *testing.T) {
+	original := Compute("Qwen 3 released", post)
+
+	assert.Equal(t, 0.0, original.Change(Compute("Qwen 3 released", post)))
+	assert.Equal(t, 0.0, original.Change(Compute("QWEN 3 released!", strings.ReplaceAll(post, "\n", " "))),
+		"case, punctuation and whitespace are ignored")
+
+	typo := Compute("Qwen 3 released", strings.Replace(post, "conversation", "conversaton", 1))
+	assert.Less(t, original.Change(typo), 0.3, "fixing a typo is a small change")
+
+	expanded := Compute("Qwen 3 released", post+`
+
+Edit: benchmarks are in. The 235B model beats DeepSeek R1 on AIME, LiveCodeBench and
+Codeforces, and the 30B mixture of experts model matches QwQ 32B with a tenth of the active
+parameters. We also published a technical report with the full training recipe and data mix.`)
+	assert.Greater(t, original.Change(expanded), 0.3, "adding a paragraph of results is a substantial change")
+
+	assert.Equal(t, 1.0, original.Change(Compute("Something else", "entirely different content")))
+	assert.Equal(t, 0.0, Compute("", "").Change(Compute("", "")))
+	assert.Equal(t, 1.0, Compute("", "").Change(original))
+}
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "sent_content.json")
+	s, err := Load(path, DefaultMaxAge)
+	require.NoError(t, err)
+
+	original := Compute("Qwen 3 released", post)
+	_, ok := s.Change("abc", original)
+	assert.False(t, ok, "entries that were never recorded have no change")
+
+	s.Record("abc", original)
+	s.Record("", original)
+	require.NoError(t, s.Save())
+
+	loaded, err := Load(path, DefaultMaxAge)
+	require.NoError(t, err)
+	change, ok := loaded.Change("abc", Compute("Something else", "entirely different content"))
+	require.True(t, ok)
+	assert.Equal(t, 1.0, change)
+
+	// Entries sent longer than the max age ago are forgotten
+	loaded.now = func() time.Time { return time.Now().Add(-2 * DefaultMaxAge) }
+	loaded.Record("old", original)
+	require.NoError(t, loaded.Save())
+	reloaded, err := Load(path, DefaultMaxAge)
+	require.NoError(t, err)
+	_, ok = reloaded.Change("old", original)
+	assert.False(t, ok)
+	_, ok = reloaded.Change("abc", original)
+	assert.True(t, ok)
+}
+
+func TestStore_Nil(t *testing.T) {
+	var s *Store
+	s.Record("abc", Compute("title", "content"))
+	_, ok := s.Change("abc", Compute("title", "content"))
+	assert.False(t, ok)
+}
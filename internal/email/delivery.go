@@ -0,0 +1,55 @@
+package email
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/internal/templatefuncs"
+)
+
+// subjectData is available to persona subject templates
+type subjectData struct {
+	Persona string
+	Topic   string
+	Date    string // The send date, formatted for the persona's locale
+	Count   int    // Number of items in the digest
+}
+
+// Subject returns the digest subject line for a persona, from its subject template or else the
+// locale's title
+func Subject(p persona.Persona, loc Locale, count int, now time.Time) (string, error) {
+	if p.SubjectTemplate == "" {
+		return fmt.Sprintf(loc.Title, p.Name), nil
+	}
+	tmpl, err := template.New("subject").Funcs(templatefuncs.FuncMap()).Parse(p.SubjectTemplate)
+	if err != nil {
+		return "", fmt.Errorf("invalid subject template: %w", err)
+	}
+	var subject strings.Builder
+	data := subjectData{
+		Persona: p.Name,
+		Topic:   p.Topic,
+		Date:    loc.FormatDate(now.In(p.Location())),
+		Count:   count,
+	}
+	if err := tmpl.Execute(&subject, data); err != nil {
+		return "", fmt.Errorf("could not render subject: %w", err)
+	}
+	// Header values cannot span lines
+	return strings.Join(strings.Fields(subject.String()), " "), nil
+}
+
+// Recipients returns the addresses a persona's digests go to: its own recipients, or else the
+// global ANP_EMAIL_TO address
+func (s *Service) Recipients(p persona.Persona) []string {
+	if len(p.Recipients) > 0 {
+		return p.Recipients
+	}
+	if s.config.EmailTo != "" {
+		return []string{s.config.EmailTo}
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package email
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/internal/specification"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubject(t *testing.T) {
+	now := time.Date(2024, 6, 3, 7, 0, 0, 0, time.UTC)
+
+	subject, err := Subject(persona.Persona{Name: "LocalLLaMA"}, LookupLocale("en"), 3, now)
+	require.NoError(t, err)
+	assert.Equal(t, "LocalLLaMA News", subject)
+
+	p := persona.Persona{
+		Name:            "LocalLLaMA",
+		Topic:           "Local LLMs",
+		Timezone:        "UTC",
+		SubjectTemplate: "{{.Topic}} for {{.Date}}: {{.Count}} {{pluralize .Count \"item\" \"items\"}}\n",
+	}
+	subject, err = Subject(p, LookupLocale("en"), 1, now)
+	require.NoError(t, err)
+	assert.Equal(t, "Local LLMs for "+LookupLocale("en").FormatDate(now)+": 1 item", subject)
+}
+
+func TestService_Recipients(t *testing.T) {
+	service := &Service{config: &specification.Specification{EmailTo: "default@example.com"}}
+
+	assert.Equal(t, []string{"default@example.com"}, service.Recipients(persona.Persona{}))
+	assert.Equal(t, []string{"a@example.com", "b@example.com"},
+		service.Recipients(persona.Persona{Recipients: []string{"a@example.com", "b@example.com"}}))
+	assert.Empty(t, (&Service{config: &specification.Specification{}}).Recipients(persona.Persona{}))
+}
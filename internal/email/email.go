@@ -1,10 +1,18 @@
 package email
 
 import (
+	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
+	"log"
+	"net"
 	"net/smtp"
+	"net/textproto"
 	"strings"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/http/retry"
 )
 
 // EmailSender defines the interface for sending emails
@@ -12,6 +20,42 @@ type EmailSender interface {
 	Send(recipient string, subject string, htmlContent string) error
 }
 
+// TLS modes for connecting to the SMTP server
+const (
+	TLSAuto     = "auto"     // Implicit TLS on port 465, otherwise STARTTLS when the server offers it
+	TLSStartTLS = "starttls" // Require STARTTLS
+	TLSImplicit = "tls"      // Connect over TLS from the start (SMTPS)
+	TLSNone     = "none"     // Never encrypt, only for local relays
+)
+
+// Authentication mechanisms
+const (
+	AuthPlain   = "plain"
+	AuthLogin   = "login"
+	AuthCRAMMD5 = "cram-md5"
+)
+
+// Options configure how the client connects, authenticates and retries
+type Options struct {
+	TLS     string        // One of the TLS* modes
+	Auth    string        // One of the Auth* mechanisms
+	Timeout time.Duration // Limit for connecting and for the whole SMTP session of one attempt
+	Retry   retry.RetryConfig
+}
+
+// DefaultOptions matches the behavior of net/smtp.SendMail, with a timeout and retries added
+var DefaultOptions = Options{
+	TLS:     TLSAuto,
+	Auth:    AuthPlain,
+	Timeout: 30 * time.Second,
+	Retry: retry.RetryConfig{
+		MaxRetries:     3,
+		InitialBackoff: 5 * time.Second,
+		MaxBackoff:     time.Minute,
+		BackoffFactor:  2.0,
+	},
+}
+
 // Client represents an SMTP email client
 type Client struct {
 	host     string
@@ -19,25 +63,58 @@ type Client struct {
 	username string
 	password string
 	sender   string
+	options  Options
 }
 
-// New creates a new SMTP email client
+// New creates a new SMTP email client with DefaultOptions
 func New(host, port, username, password, sender string) (*Client, error) {
+	return NewWithOptions(host, port, username, password, sender, DefaultOptions)
+}
+
+// NewWithOptions creates a new SMTP email client. Empty TLS and Auth options use the defaults.
+func NewWithOptions(host, port, username, password, sender string, options Options) (*Client, error) {
 	if host == "" || port == "" || username == "" || password == "" || sender == "" {
 		return nil, errors.New("all fields (host, port, username, password, sender) are required")
 	}
 
+	options.TLS = strings.ToLower(options.TLS)
+	if options.TLS == "" {
+		options.TLS = DefaultOptions.TLS
+	}
+	switch options.TLS {
+	case TLSAuto, TLSStartTLS, TLSImplicit, TLSNone:
+	default:
+		return nil, fmt.Errorf("unsupported TLS mode %q, must be auto, starttls, tls or none", options.TLS)
+	}
+
+	options.Auth = strings.ToLower(options.Auth)
+	if options.Auth == "" {
+		options.Auth = DefaultOptions.Auth
+	}
+	switch options.Auth {
+	case AuthPlain, AuthLogin, AuthCRAMMD5:
+	default:
+		return nil, fmt.Errorf("unsupported auth mechanism %q, must be plain, login or cram-md5", options.Auth)
+	}
+
 	return &Client{
 		host:     host,
 		port:     port,
 		username: username,
 		password: password,
 		sender:   sender,
+		options:  options,
 	}, nil
 }
 
-// Send sends an HTML email to the specified recipient
+// Send sends an HTML email to the specified recipient. Transient failures, such as dropped
+// connections and 4xx replies, are retried with backoff.
 func (c *Client) Send(recipient string, subject string, htmlContent string) error {
+	return c.SendWithImages(recipient, subject, htmlContent, nil)
+}
+
+// SendWithImages sends an HTML email with inline images referenced from the HTML by content ID
+func (c *Client) SendWithImages(recipient string, subject string, htmlContent string, images []InlineImage) error {
 	if recipient == "" {
 		return errors.New("recipient email cannot be empty")
 	}
@@ -47,32 +124,179 @@ func (c *Client) Send(recipient string, subject string, htmlContent string) erro
 		return errors.New("invalid recipient email format")
 	}
 
-	// Set up authentication
-	auth := smtp.PlainAuth("", c.username, c.password, c.host)
+	message := buildMessage(c.sender, recipient, subject, htmlContent, images)
 
-	// Construct MIME headers
-	headers := make(map[string]string)
-	headers["From"] = c.sender
-	headers["To"] = recipient
-	headers["Subject"] = subject
-	headers["MIME-Version"] = "1.0"
-	headers["Content-Type"] = "text/html; charset=\"UTF-8\""
+	attempt := 0
+	_, err := retry.RetryWithBackoff(context.Background(), c.options.Retry, func(ctx context.Context) (struct{}, error) {
+		attempt++
+		if attempt > 1 {
+			log.Printf("Retrying email to %s (attempt %d)", recipient, attempt)
+		}
+		return struct{}{}, c.send(recipient, message)
+	}, isTransientSMTPError)
+	return err
+}
 
-	// Build message from headers
-	var message strings.Builder
-	for k, v := range headers {
-		message.WriteString(fmt.Sprintf("%s: %s\r\n", k, v))
+// send delivers a message in one SMTP session
+func (c *Client) send(recipient string, message []byte) error {
+	client, err := c.connect()
+	if err != nil {
+		return err
 	}
-	message.WriteString("\r\n" + htmlContent)
+	defer client.Close()
 
-	// Send email
-	err := smtp.SendMail(
-		fmt.Sprintf("%s:%s", c.host, c.port),
-		auth,
-		c.sender,
-		[]string{recipient},
-		[]byte(message.String()),
-	)
+	if ok, _ := client.Extension("AUTH"); ok {
+		if err := client.Auth(c.auth()); err != nil {
+			var protoErr *textproto.Error
+			var netErr net.Error
+			if !errors.As(err, &protoErr) && !errors.As(err, &netErr) {
+				// Refused before anything was sent, such as credentials over an unencrypted connection
+				err = errPermanent{err}
+			}
+			return fmt.Errorf("authentication failed: %w", err)
+		}
+	}
 
-	return err
+	if err := client.Mail(c.sender); err != nil {
+		return fmt.Errorf("MAIL FROM failed: %w", err)
+	}
+	if err := client.Rcpt(recipient); err != nil {
+		return fmt.Errorf("RCPT TO failed: %w", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("DATA failed: %w", err)
+	}
+	if _, err := w.Write(message); err != nil {
+		return fmt.Errorf("could not write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("message was not accepted: %w", err)
+	}
+	return client.Quit()
+}
+
+// connect opens an SMTP session and upgrades it to TLS as configured
+func (c *Client) connect() (*smtp.Client, error) {
+	addr := net.JoinHostPort(c.host, c.port)
+	dialer := &net.Dialer{Timeout: c.options.Timeout}
+	tlsConfig := &tls.Config{ServerName: c.host}
+
+	implicitTLS := c.options.TLS == TLSImplicit || (c.options.TLS == TLSAuto && c.port == "465")
+	var conn net.Conn
+	var err error
+	if implicitTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to %s: %w", addr, err)
+	}
+	if c.options.Timeout > 0 {
+		if err := conn.SetDeadline(time.Now().Add(c.options.Timeout)); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	client, err := smtp.NewClient(conn, c.host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("could not start SMTP session: %w", err)
+	}
+
+	if !implicitTLS && c.options.TLS != TLSNone {
+		ok, _ := client.Extension("STARTTLS")
+		if !ok && c.options.TLS == TLSStartTLS {
+			client.Close()
+			return nil, errPermanent{errors.New("server does not support STARTTLS")}
+		}
+		if ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				client.Close()
+				return nil, fmt.Errorf("STARTTLS failed: %w", err)
+			}
+		}
+	}
+	return client, nil
+}
+
+// Ping checks that the SMTP server accepts a session, without sending anything
+func (c *Client) Ping() error {
+	client, err := c.connect()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if err := client.Noop(); err != nil {
+		return fmt.Errorf("SMTP server did not respond: %w", err)
+	}
+	return client.Quit()
+}
+
+func (c *Client) auth() smtp.Auth {
+	switch c.options.Auth {
+	case AuthLogin:
+		return &loginAuth{username: c.username, password: c.password, host: c.host}
+	case AuthCRAMMD5:
+		return smtp.CRAMMD5Auth(c.username, c.password)
+	default:
+		return smtp.PlainAuth("", c.username, c.password, c.host)
+	}
+}
+
+// errPermanent marks a failure that retrying cannot fix
+type errPermanent struct{ error }
+
+func (e errPermanent) Unwrap() error { return e.error }
+
+// isTransientSMTPError reports whether a send is worth retrying: connection problems and 4xx
+// replies are, 5xx replies (rejected recipients, bad credentials) and configuration errors are not
+func isTransientSMTPError(err error) bool {
+	var permanent errPermanent
+	if errors.As(err, &permanent) {
+		return false
+	}
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		return protoErr.Code < 500
+	}
+	return true
+}
+
+// loginAuth implements the LOGIN mechanism, which net/smtp does not provide. Like smtp.PlainAuth
+// it only sends credentials over TLS or to localhost.
+type loginAuth struct {
+	username string
+	password string
+	host     string
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	if server.Name != a.host {
+		return "", nil, errors.New("wrong host name")
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(string(fromServer))) {
+	case "username:":
+		return []byte(a.username), nil
+	case "password:":
+		return []byte(a.password), nil
+	default:
+		return nil, fmt.Errorf("unexpected LOGIN challenge %q", fromServer)
+	}
+}
+
+func isLocalhost(name string) bool {
+	return name == "localhost" || name == "127.0.0.1" || name == "::1"
 }
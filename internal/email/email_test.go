@@ -0,0 +1,212 @@
+package email
+
+import (
+	"bufio"
+	"encoding/base64"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/http/retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSMTPServer is a minimal SMTP server speaking just enough of the protocol for net/smtp
+type fakeSMTPServer struct {
+	listener net.Listener
+	// mailReplies are the replies to MAIL FROM for each session in order, later sessions get a 250
+	mailReplies []string
+	rcptReply   string
+
+	mu       sync.Mutex
+	sessions int
+	auth     []string // Decoded credentials per AUTH exchange
+	messages []string
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &fakeSMTPServer{listener: listener, rcptReply: "250 OK"}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeSMTPServer) port() string {
+	_, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	return port
+}
+
+func (s *fakeSMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	s.mu.Lock()
+	s.sessions++
+	session := s.sessions
+	s.mu.Unlock()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+	readLine := func() string {
+		line, _ := r.ReadString('\n')
+		return strings.TrimRight(line, "\r\n")
+	}
+	decode := func(s string) string {
+		b, _ := base64.StdEncoding.DecodeString(s)
+		return string(b)
+	}
+
+	reply("220 localhost ESMTP")
+	for {
+		line := readLine()
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch {
+		case verb == "EHLO":
+			reply("250-localhost")
+			reply("250 AUTH PLAIN LOGIN")
+		case strings.HasPrefix(line, "AUTH PLAIN "):
+			s.mu.Lock()
+			s.auth = append(s.auth, strings.ReplaceAll(decode(strings.TrimPrefix(line, "AUTH PLAIN ")), "\x00", " "))
+			s.mu.Unlock()
+			reply("235 Authenticated")
+		case line == "AUTH LOGIN":
+			reply("334 " + base64.StdEncoding.EncodeToString([]byte("Username:")))
+			user := decode(readLine())
+			reply("334 " + base64.StdEncoding.EncodeToString([]byte("Password:")))
+			password := decode(readLine())
+			s.mu.Lock()
+			s.auth = append(s.auth, user+" "+password)
+			s.mu.Unlock()
+			reply("235 Authenticated")
+		case verb == "MAIL":
+			if session <= len(s.mailReplies) {
+				reply(s.mailReplies[session-1])
+				continue
+			}
+			reply("250 OK")
+		case verb == "RCPT":
+			reply(s.rcptReply)
+		case verb == "DATA":
+			reply("354 Go ahead")
+			var data strings.Builder
+			for {
+				l := readLine()
+				if l == "." {
+					break
+				}
+				data.WriteString(l + "\n")
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, data.String())
+			s.mu.Unlock()
+			reply("250 Queued")
+		case verb == "QUIT":
+			reply("221 Bye")
+			return
+		case line == "":
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func testOptions(auth string) Options {
+	return Options{
+		TLS:     TLSAuto,
+		Auth:    auth,
+		Timeout: 5 * time.Second,
+		Retry:   retry.RetryConfig{MaxRetries: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, BackoffFactor: 1},
+	}
+}
+
+func TestClientSend(t *testing.T) {
+	for _, auth := range []string{AuthPlain, AuthLogin} {
+		t.Run(auth, func(t *testing.T) {
+			server := newFakeSMTPServer(t)
+			client, err := NewWithOptions("127.0.0.1", server.port(), "user", "secret", "news@example.com", testOptions(auth))
+			require.NoError(t, err)
+
+			require.NoError(t, client.Send("reader@example.com", "Digest", "<p>Hello</p>"))
+
+			require.Len(t, server.messages, 1)
+			assert.Contains(t, server.messages[0], "Subject: Digest")
+			assert.Contains(t, server.messages[0], "<p>Hello</p>")
+			require.Len(t, server.auth, 1)
+			assert.Equal(t, "user secret", strings.TrimSpace(server.auth[0]))
+		})
+	}
+}
+
+func TestClientSend_RetriesTransientFailures(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	server.mailReplies = []string{"421 Try again later", "451 Temporary local problem"}
+	client, err := NewWithOptions("127.0.0.1", server.port(), "user", "secret", "news@example.com", testOptions(AuthPlain))
+	require.NoError(t, err)
+
+	require.NoError(t, client.Send("reader@example.com", "Digest", "<p>Hello</p>"))
+	assert.Equal(t, 3, server.sessions)
+	assert.Len(t, server.messages, 1)
+}
+
+func TestClientSend_DoesNotRetryPermanentFailures(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	server.rcptReply = "550 No such user"
+	client, err := NewWithOptions("127.0.0.1", server.port(), "user", "secret", "news@example.com", testOptions(AuthPlain))
+	require.NoError(t, err)
+
+	err = client.Send("nobody@example.com", "Digest", "<p>Hello</p>")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "No such user")
+	assert.Equal(t, 1, server.sessions)
+}
+
+func TestClientSend_RequireStartTLS(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	options := testOptions(AuthPlain)
+	options.TLS = TLSStartTLS
+	client, err := NewWithOptions("127.0.0.1", server.port(), "user", "secret", "news@example.com", options)
+	require.NoError(t, err)
+
+	err = client.Send("reader@example.com", "Digest", "<p>Hello</p>")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "STARTTLS")
+	assert.Equal(t, 1, server.sessions, "a server without STARTTLS is not retried")
+	assert.Empty(t, server.messages)
+}
+
+func TestClientPing(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	client, err := NewWithOptions("127.0.0.1", server.port(), "user", "secret", "news@example.com", testOptions(AuthPlain))
+	require.NoError(t, err)
+
+	require.NoError(t, client.Ping())
+	assert.Equal(t, 1, server.sessions)
+	assert.Empty(t, server.messages, "a ping sends nothing")
+
+	server.listener.Close()
+	assert.Error(t, client.Ping(), "a server that is down fails the ping")
+}
+
+func TestNewWithOptions(t *testing.T) {
+	client, err := NewWithOptions("smtp.example.com", "587", "user", "secret", "news@example.com", Options{})
+	require.NoError(t, err)
+	assert.Equal(t, TLSAuto, client.options.TLS)
+	assert.Equal(t, AuthPlain, client.options.Auth)
+
+	_, err = NewWithOptions("smtp.example.com", "587", "user", "secret", "news@example.com", Options{TLS: "ssl"})
+	assert.ErrorContains(t, err, "unsupported TLS mode")
+	_, err = NewWithOptions("smtp.example.com", "587", "user", "secret", "news@example.com", Options{Auth: "xoauth2"})
+	assert.ErrorContains(t, err, "unsupported auth mechanism")
+}
@@ -0,0 +1,55 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/http/retry"
+)
+
+// apiStatusError is a non-success response from an email API
+type apiStatusError struct {
+	status  int
+	message string
+}
+
+func (e *apiStatusError) Error() string {
+	return fmt.Sprintf("email API returned status %d: %s", e.status, e.message)
+}
+
+// postWithRetry sends the request built by newRequest, retrying connection failures, rate limits
+// and server errors with backoff. A new request is built for every attempt so the body can be
+// read again.
+func postWithRetry(client *http.Client, config retry.RetryConfig, newRequest func() (*http.Request, error)) error {
+	_, err := retry.RetryWithBackoff(context.Background(), config, func(ctx context.Context) (struct{}, error) {
+		req, err := newRequest()
+		if err != nil {
+			return struct{}{}, err
+		}
+		resp, err := client.Do(req.WithContext(ctx))
+		if err != nil {
+			return struct{}{}, fmt.Errorf("failed to call email API: %w", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+			return struct{}{}, &apiStatusError{status: resp.StatusCode, message: strings.TrimSpace(string(message))}
+		}
+		return struct{}{}, nil
+	}, func(err error) bool {
+		if statusErr, ok := err.(*apiStatusError); ok {
+			return statusErr.status == http.StatusTooManyRequests || statusErr.status >= 500
+		}
+		return true
+	})
+	return err
+}
+
+func newAPIClient(timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout}
+}
@@ -0,0 +1,89 @@
+package email
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// apiServer records requests and answers with the given statuses in order, then 202
+func apiServer(t *testing.T, statuses ...int) (*httptest.Server, *[]*http.Request, *[]string) {
+	var requests []*http.Request
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, r)
+		bodies = append(bodies, string(body))
+		if len(requests) <= len(statuses) {
+			w.WriteHeader(statuses[len(requests)-1])
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests, &bodies
+}
+
+func TestSendGridClient(t *testing.T) {
+	server, requests, bodies := apiServer(t, http.StatusServiceUnavailable)
+	client, err := NewSendGrid("SG.key", "AI News <news@example.com>", testOptions(AuthPlain))
+	require.NoError(t, err)
+	client.endpoint = server.URL
+
+	require.NoError(t, client.Send("reader@example.com", "Digest", "<p>Hello</p>"))
+
+	require.Len(t, *requests, 2, "server errors are retried")
+	assert.Equal(t, "Bearer SG.key", (*requests)[1].Header.Get("Authorization"))
+	var message sendGridMessage
+	require.NoError(t, json.Unmarshal([]byte((*bodies)[1]), &message))
+	assert.Equal(t, sendGridMessage{
+		Personalizations: []sendGridPersonalization{{To: []sendGridAddress{{Email: "reader@example.com"}}}},
+		From:             sendGridAddress{Email: "news@example.com", Name: "AI News"},
+		Subject:          "Digest",
+		Content:          []sendGridContent{{Type: "text/html", Value: "<p>Hello</p>"}},
+	}, message)
+}
+
+func TestSendGridClient_DoesNotRetryRejections(t *testing.T) {
+	server, requests, _ := apiServer(t, http.StatusBadRequest)
+	client, err := NewSendGrid("SG.key", "news@example.com", testOptions(AuthPlain))
+	require.NoError(t, err)
+	client.endpoint = server.URL
+
+	err = client.Send("reader@example.com", "Digest", "<p>Hello</p>")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "400")
+	assert.Len(t, *requests, 1)
+}
+
+func TestSESClient(t *testing.T) {
+	server, requests, bodies := apiServer(t, http.StatusTooManyRequests)
+	client, err := NewSES("eu-west-1", "AKID", "secret", "session", "news@example.com", testOptions(AuthPlain))
+	require.NoError(t, err)
+	assert.Equal(t, "https://email.eu-west-1.amazonaws.com/v2/email/outbound-emails", client.endpoint)
+	client.endpoint = server.URL + "/v2/email/outbound-emails"
+	client.now = func() time.Time { return time.Date(2025, time.March, 7, 6, 0, 0, 0, time.UTC) }
+
+	require.NoError(t, client.Send("reader@example.com", "Digest", "<p>Hello</p>"))
+
+	require.Len(t, *requests, 2, "throttling is retried")
+	req := (*requests)[1]
+	assert.Equal(t, "20250307T060000Z", req.Header.Get("X-Amz-Date"))
+	assert.Equal(t, "session", req.Header.Get("X-Amz-Security-Token"))
+	assert.True(t, strings.HasPrefix(req.Header.Get("Authorization"),
+		"AWS4-HMAC-SHA256 Credential=AKID/20250307/eu-west-1/ses/aws4_request, SignedHeaders=content-type;host;x-amz-date;x-amz-security-token, Signature="))
+
+	var message sesMessage
+	require.NoError(t, json.Unmarshal([]byte((*bodies)[1]), &message))
+	assert.Equal(t, "news@example.com", message.FromEmailAddress)
+	assert.Equal(t, []string{"reader@example.com"}, message.Destination.ToAddresses)
+	assert.Equal(t, "Digest", message.Content.Simple.Subject.Data)
+	assert.Equal(t, "<p>Hello</p>", message.Content.Simple.Body.Html.Data)
+}
@@ -0,0 +1,147 @@
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/imageprep"
+	"github.com/bakkerme/ai-news-processor/models"
+)
+
+// InlineImage is an image attached to an email and referenced from its HTML as cid:<ContentID>
+type InlineImage struct {
+	ContentID   string
+	ContentType string
+	Data        []byte
+}
+
+// InlineSender is implemented by senders that can attach inline images
+type InlineSender interface {
+	SendWithImages(recipient string, subject string, htmlContent string, images []InlineImage) error
+}
+
+// inlineImageOptions shrinks thumbnails to the width of the email body
+var inlineImageOptions = imageprep.Options{
+	MaxDimension: 600,
+	JPEGQuality:  80,
+}
+
+// maxThumbnailDownload bounds how much of a thumbnail is downloaded before it is shrunk
+const maxThumbnailDownload = 10 << 20
+
+// ImageDownloader downloads an image and returns its data and content type
+type ImageDownloader func(url string) ([]byte, string, error)
+
+// embedThumbnails downloads the thumbnails of items and points them at inline attachments, so
+// they render in clients that block remote images. Thumbnails that cannot be downloaded or are
+// larger than maxBytes after shrinking keep their remote URL. items is not modified.
+func embedThumbnails(items []models.Item, download ImageDownloader, maxBytes int) ([]models.Item, []InlineImage) {
+	embedded := make([]models.Item, len(items))
+	copy(embedded, items)
+	var images []InlineImage
+
+	for i := range embedded {
+		url := embedded[i].ThumbnailURL
+		if url == "" || strings.HasPrefix(url, "cid:") {
+			continue
+		}
+		data, contentType, err := download(url)
+		if err != nil {
+			log.Printf("Could not download thumbnail %s, linking it instead: %v", url, err)
+			continue
+		}
+		if prepared, preparedType, err := imageprep.Prepare(data, contentType, inlineImageOptions); err == nil {
+			data, contentType = prepared, preparedType
+		}
+		if maxBytes > 0 && len(data) > maxBytes {
+			log.Printf("Thumbnail %s is %d bytes, over the inline limit, linking it instead", url, len(data))
+			continue
+		}
+
+		contentID := fmt.Sprintf("thumb%d@ai-news-processor", len(images)+1)
+		images = append(images, InlineImage{ContentID: contentID, ContentType: contentType, Data: data})
+		embedded[i].ThumbnailURL = "cid:" + contentID
+	}
+	return embedded, images
+}
+
+// DownloadImage fetches an image over HTTP
+func DownloadImage(url string) ([]byte, string, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("status code %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxThumbnailDownload))
+	if err != nil {
+		return nil, "", err
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if !strings.HasPrefix(contentType, "image/") {
+		contentType = http.DetectContentType(data)
+	}
+	if !strings.HasPrefix(contentType, "image/") {
+		return nil, "", fmt.Errorf("not an image: %s", contentType)
+	}
+	return data, contentType, nil
+}
+
+// buildMessage builds a MIME message with an HTML body and, if there are any, inline images
+func buildMessage(sender, recipient, subject, htmlContent string, images []InlineImage) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("From: " + sender + "\r\n")
+	buf.WriteString("To: " + recipient + "\r\n")
+	buf.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n")
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	if len(images) == 0 {
+		buf.WriteString("Content-Type: text/html; charset=\"UTF-8\"\r\n\r\n")
+		buf.WriteString(htmlContent)
+		return buf.Bytes()
+	}
+
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	buf.WriteString(fmt.Sprintf("Content-Type: multipart/related; boundary=%q; type=\"text/html\"\r\n\r\n", parts.Boundary()))
+
+	htmlPart, _ := parts.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/html; charset=\"UTF-8\""}})
+	htmlPart.Write([]byte(htmlContent))
+	for _, image := range images {
+		part, _ := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {image.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-ID":                {"<" + image.ContentID + ">"},
+			"Content-Disposition":       {"inline"},
+		})
+		part.Write(wrapBase64(image.Data))
+	}
+	parts.Close()
+
+	buf.Write(body.Bytes())
+	return buf.Bytes()
+}
+
+// wrapBase64 encodes data as base64 in lines of 76 characters, as MIME requires
+func wrapBase64(data []byte) []byte {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	var buf bytes.Buffer
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded + "\r\n")
+	return buf.Bytes()
+}
@@ -0,0 +1,115 @@
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/bakkerme/ai-news-processor/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func thumbnailPNG(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 20, 10))))
+	return buf.Bytes()
+}
+
+func TestEmbedThumbnails(t *testing.T) {
+	thumbnail := thumbnailPNG(t)
+	download := func(url string) ([]byte, string, error) {
+		if url == "https://example.com/broken.png" {
+			return nil, "", errors.New("not found")
+		}
+		return thumbnail, "image/png", nil
+	}
+	items := []models.Item{
+		{ID: "1", ThumbnailURL: "https://example.com/a.png"},
+		{ID: "2"},
+		{ID: "3", ThumbnailURL: "https://example.com/broken.png"},
+	}
+
+	embedded, images := embedThumbnails(items, download, 100*1024)
+
+	require.Len(t, images, 1)
+	assert.Equal(t, "cid:"+images[0].ContentID, embedded[0].ThumbnailURL)
+	assert.Equal(t, "image/png", images[0].ContentType)
+	assert.Equal(t, thumbnail, images[0].Data)
+	assert.Empty(t, embedded[1].ThumbnailURL)
+	assert.Equal(t, "https://example.com/broken.png", embedded[2].ThumbnailURL, "failed downloads stay linked")
+	assert.Equal(t, "https://example.com/a.png", items[0].ThumbnailURL, "items are not modified")
+
+	embedded, images = embedThumbnails(items, download, 10)
+	assert.Empty(t, images, "thumbnails over the limit stay linked")
+	assert.Equal(t, "https://example.com/a.png", embedded[0].ThumbnailURL)
+}
+
+func TestBuildMessage_InlineImages(t *testing.T) {
+	images := []InlineImage{{ContentID: "thumb1@ai-news-processor", ContentType: "image/png", Data: thumbnailPNG(t)}}
+	raw := buildMessage("news@example.com", "reader@example.com", "Digest", `<img src="cid:thumb1@ai-news-processor">`, images)
+
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	require.NoError(t, err)
+	assert.Equal(t, "Digest", msg.Header.Get("Subject"))
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/related", mediaType)
+
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+	htmlPart, err := parts.NextPart()
+	require.NoError(t, err)
+	html, _ := io.ReadAll(htmlPart)
+	assert.Contains(t, string(html), "cid:thumb1@ai-news-processor")
+
+	imagePart, err := parts.NextPart()
+	require.NoError(t, err)
+	assert.Equal(t, "<thumb1@ai-news-processor>", imagePart.Header.Get("Content-ID"))
+	encoded, _ := io.ReadAll(imagePart)
+	data, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(string(encoded), "\r\n", ""))
+	require.NoError(t, err)
+	assert.Equal(t, images[0].Data, data)
+}
+
+func TestClientSendWithImages(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	client, err := NewWithOptions("127.0.0.1", server.port(), "user", "secret", "news@example.com", testOptions(AuthPlain))
+	require.NoError(t, err)
+
+	images := []InlineImage{{ContentID: "thumb1@ai-news-processor", ContentType: "image/png", Data: thumbnailPNG(t)}}
+	require.NoError(t, client.SendWithImages("reader@example.com", "Digest", "<p>Hello</p>", images))
+
+	require.Len(t, server.messages, 1)
+	assert.Contains(t, server.messages[0], "multipart/related")
+	assert.Contains(t, server.messages[0], "Content-ID: <thumb1@ai-news-processor>")
+}
+
+func TestSendGridClient_InlineImages(t *testing.T) {
+	server, _, bodies := apiServer(t)
+	client, err := NewSendGrid("SG.key", "news@example.com", testOptions(AuthPlain))
+	require.NoError(t, err)
+	client.endpoint = server.URL
+
+	images := []InlineImage{{ContentID: "thumb1@ai-news-processor", ContentType: "image/png", Data: []byte("png")}}
+	require.NoError(t, client.SendWithImages("reader@example.com", "Digest", "<p>Hello</p>", images))
+
+	var message sendGridMessage
+	require.NoError(t, json.Unmarshal([]byte((*bodies)[0]), &message))
+	assert.Equal(t, []sendGridAttachment{{
+		Content:     base64.StdEncoding.EncodeToString([]byte("png")),
+		Type:        "image/png",
+		Filename:    "thumb1@ai-news-processor",
+		Disposition: "inline",
+		ContentID:   "thumb1@ai-news-processor",
+	}}, message.Attachments)
+}
@@ -0,0 +1,163 @@
+package email
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/models"
+)
+
+// Layout controls the order and sections of the items in a digest
+type Layout struct {
+	Order    string // persona.OrderFeed or persona.OrderImportance
+	GroupBy  string // persona.GroupNone, persona.GroupTopic or persona.GroupFlair
+	TopStory bool   // Lead with the story of the first key development
+	MaxItems int    // Items rendered in full, or 0 for all of them
+}
+
+// LayoutFor returns the digest layout configured for a persona
+func LayoutFor(p persona.Persona) Layout {
+	return Layout{Order: p.DigestOrder, GroupBy: p.DigestGroupBy, TopStory: p.TopStory, MaxItems: p.DigestMaxItems}
+}
+
+// TopStory is the story a digest leads with
+type TopStory struct {
+	Text string // The key development the story was chosen for
+	Item models.Item
+}
+
+// Section is a group of digest items under an optional heading
+type Section struct {
+	Title string // Empty when the digest is not grouped
+	Items []models.Item
+}
+
+// orderItems returns the items in the layout's order. Ordering by importance keeps the feed order
+// between items of the same importance. items is not modified.
+func (l Layout) orderItems(items []models.Item) []models.Item {
+	ordered := make([]models.Item, len(items))
+	copy(ordered, items)
+	if l.Order == persona.OrderImportance {
+		sort.SliceStable(ordered, func(i, j int) bool {
+			return ordered[i].ImportanceScore > ordered[j].ImportanceScore
+		})
+	}
+	return ordered
+}
+
+// limitItems keeps the MaxItems most important of the ordered items, in their order, and returns
+// the rest as overflow, most important first. Items of the same importance are kept in order, and
+// items that could not be processed go to the overflow first. items is not modified.
+func (l Layout) limitItems(items []models.Item) (kept []models.Item, overflow []models.Item) {
+	if l.MaxItems <= 0 || len(items) <= l.MaxItems {
+		return items, nil
+	}
+
+	ranked := make([]int, len(items))
+	for i := range ranked {
+		ranked[i] = i
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, b := items[ranked[i]], items[ranked[j]]
+		if a.Unavailable != b.Unavailable {
+			return !a.Unavailable
+		}
+		return a.ImportanceScore > b.ImportanceScore
+	})
+
+	keep := make(map[int]bool, l.MaxItems)
+	for _, i := range ranked[:l.MaxItems] {
+		keep[i] = true
+	}
+	for i, item := range items {
+		if keep[i] {
+			kept = append(kept, item)
+		}
+	}
+	for _, i := range ranked[l.MaxItems:] {
+		overflow = append(overflow, items[i])
+	}
+	return kept, overflow
+}
+
+// splitUnsure separates the items the LLM was unsure of, which are listed apart from the digest,
+// keeping the order of both. items is not modified.
+func splitUnsure(items []models.Item) (sure []models.Item, unsure []models.Item) {
+	for _, item := range items {
+		if item.Unsure {
+			unsure = append(unsure, item)
+		} else {
+			sure = append(sure, item)
+		}
+	}
+	return sure, unsure
+}
+
+// sections groups ordered items by topic or flair. Groups appear in the order of their first
+// item, and items without a topic or flair go last under otherTitle.
+func (l Layout) sections(items []models.Item, otherTitle string) []Section {
+	var key func(models.Item) string
+	switch l.GroupBy {
+	case persona.GroupTopic:
+		key = func(item models.Item) string {
+			if len(item.Topics) > 0 {
+				return item.Topics[0]
+			}
+			return ""
+		}
+	case persona.GroupFlair:
+		key = func(item models.Item) string { return item.Entry.Flair }
+	default:
+		return []Section{{Items: items}}
+	}
+
+	var sections []Section
+	index := make(map[string]int)
+	var other []models.Item
+	for _, item := range items {
+		k := key(item)
+		if k == "" {
+			other = append(other, item)
+			continue
+		}
+		folded := strings.ToLower(k)
+		i, ok := index[folded]
+		if !ok {
+			i = len(sections)
+			index[folded] = i
+			sections = append(sections, Section{Title: capitalize(k)})
+		}
+		sections[i].Items = append(sections[i].Items, item)
+	}
+	if len(sections) == 0 {
+		return []Section{{Items: items}}
+	}
+	if len(other) > 0 {
+		sections = append(sections, Section{Title: otherTitle, Items: other})
+	}
+	return sections
+}
+
+// topStory picks the item of the first key development that refers to a summarized item
+func (l Layout) topStory(items []models.Item, summary *models.SummaryResponse) *TopStory {
+	if !l.TopStory || summary == nil {
+		return nil
+	}
+	for _, development := range summary.KeyDevelopments {
+		id := strings.TrimPrefix(development.ItemID, "t3_")
+		for _, item := range items {
+			if item.ID == id && !item.Unavailable {
+				return &TopStory{Text: development.Text, Item: item}
+			}
+		}
+	}
+	return nil
+}
+
+func capitalize(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[size:]
+}
@@ -0,0 +1,156 @@
+package email
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func itemIDs(items []models.Item) []string {
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+	return ids
+}
+
+func TestLayout_OrderItems(t *testing.T) {
+	items := []models.Item{{ID: "a", ImportanceScore: 4}, {ID: "b", ImportanceScore: 9}, {ID: "c"}, {ID: "d", ImportanceScore: 4}}
+
+	assert.Equal(t, []string{"a", "b", "c", "d"}, itemIDs(Layout{}.orderItems(items)))
+	assert.Equal(t, []string{"b", "a", "d", "c"}, itemIDs(Layout{Order: persona.OrderImportance}.orderItems(items)))
+	assert.Equal(t, "a", items[0].ID, "items are not modified")
+}
+
+func TestLayout_LimitItems(t *testing.T) {
+	items := []models.Item{
+		{ID: "a", ImportanceScore: 4},
+		{ID: "b", ImportanceScore: 9},
+		{ID: "c", Unavailable: true},
+		{ID: "d", ImportanceScore: 4},
+		{ID: "e", ImportanceScore: 6},
+	}
+
+	kept, overflow := Layout{}.limitItems(items)
+	assert.Len(t, kept, 5)
+	assert.Empty(t, overflow)
+
+	kept, overflow = Layout{MaxItems: 5}.limitItems(items)
+	assert.Len(t, kept, 5)
+	assert.Empty(t, overflow)
+
+	kept, overflow = Layout{MaxItems: 3}.limitItems(items)
+	assert.Equal(t, []string{"a", "b", "e"}, itemIDs(kept), "the most important items are kept in their order")
+	assert.Equal(t, []string{"d", "c"}, itemIDs(overflow), "the rest is ranked by importance, placeholders last")
+	assert.Equal(t, "a", items[0].ID, "items are not modified")
+}
+
+func TestLayout_Sections(t *testing.T) {
+	items := []models.Item{
+		{ID: "a", Topics: []string{"quantization"}, Entry: feeds.Entry{Flair: "Discussion"}},
+		{ID: "b"},
+		{ID: "c", Topics: []string{"benchmarks"}, Entry: feeds.Entry{Flair: "New Model"}},
+		{ID: "d", Topics: []string{"quantization", "benchmarks"}, Entry: feeds.Entry{Flair: "discussion"}},
+	}
+
+	sections := Layout{}.sections(items, "Other")
+	require.Len(t, sections, 1)
+	assert.Empty(t, sections[0].Title)
+	assert.Len(t, sections[0].Items, 4)
+
+	sections = Layout{GroupBy: persona.GroupTopic}.sections(items, "Other")
+	require.Len(t, sections, 3)
+	assert.Equal(t, "Quantization", sections[0].Title)
+	assert.Equal(t, []string{"a", "d"}, itemIDs(sections[0].Items))
+	assert.Equal(t, "Benchmarks", sections[1].Title)
+	assert.Equal(t, "Other", sections[2].Title)
+	assert.Equal(t, []string{"b"}, itemIDs(sections[2].Items))
+
+	sections = Layout{GroupBy: persona.GroupFlair}.sections(items, "Other")
+	require.Len(t, sections, 3)
+	assert.Equal(t, "Discussion", sections[0].Title)
+	assert.Equal(t, []string{"a", "d"}, itemIDs(sections[0].Items), "flairs are grouped regardless of case")
+
+	sections = Layout{GroupBy: persona.GroupFlair}.sections([]models.Item{{ID: "a"}}, "Other")
+	require.Len(t, sections, 1)
+	assert.Empty(t, sections[0].Title, "a digest without any flairs is not sectioned")
+}
+
+func TestLayout_TopStory(t *testing.T) {
+	items := []models.Item{{ID: "a", Title: "First"}, {ID: "b", Title: "Failed", Unavailable: true}, {ID: "c", Title: "Third"}}
+	summary := &models.SummaryResponse{KeyDevelopments: []models.KeyDevelopment{
+		{Text: "Made up", ItemID: "x"},
+		{Text: "Failed item", ItemID: "t3_b"},
+		{Text: "Third matters", ItemID: "t3_c"},
+	}}
+
+	assert.Nil(t, Layout{}.topStory(items, summary))
+	story := Layout{TopStory: true}.topStory(items, summary)
+	require.NotNil(t, story)
+	assert.Equal(t, "Third matters", story.Text)
+	assert.Equal(t, "c", story.Item.ID)
+	assert.Nil(t, Layout{TopStory: true}.topStory(items, nil))
+}
+
+func TestRenderEmail_Overflow(t *testing.T) {
+	items := []models.Item{
+		{ID: "a", Title: "Minor fix", Link: "https://example.com/a", Summary: "Summary A", ImportanceScore: 3, Entry: feeds.Entry{Comments: make([]feeds.EntryComments, 12)}},
+		{ID: "b", Title: "Big release", Link: "https://example.com/b", Summary: "Summary B", ImportanceScore: 9},
+		{ID: "c", Title: "Nice benchmark", Link: "https://example.com/c", Summary: "Summary C", ImportanceScore: 6},
+	}
+
+	html, err := renderEmail(items, nil, "LocalLLaMA", LookupLocale("en"), time.Now(), nil, renderOptions{Layout: Layout{MaxItems: 2}})
+	require.NoError(t, err)
+	assert.Contains(t, html, "Summary B")
+	assert.Contains(t, html, "Summary C")
+	assert.NotContains(t, html, "Summary A", "overflow items are not rendered in full")
+	assert.Contains(t, html, "Also noteworthy")
+	assert.Contains(t, html, `<li><a id="item-t3_a"></a><a href="https://example.com/a">Minor fix</a> <span class="overflow-meta">· 12 comments</span></li>`)
+
+	html, err = renderEmail(items, nil, "LocalLLaMA", LookupLocale("en"), time.Now(), nil, renderOptions{})
+	require.NoError(t, err)
+	assert.Contains(t, html, "Summary A")
+	assert.NotContains(t, html, "Also noteworthy")
+}
+
+func TestRenderEmail_Unsure(t *testing.T) {
+	items := []models.Item{
+		{ID: "a", Title: "Maybe a release", Link: "https://example.com/a", Summary: "Summary A", ImportanceScore: 9, Unsure: true, RelevanceToCriteria: "Could be a new model"},
+		{ID: "b", Title: "Big release", Link: "https://example.com/b", Summary: "Summary B", ImportanceScore: 8},
+		{ID: "c", Title: "Nice benchmark", Link: "https://example.com/c", Summary: "Summary C", ImportanceScore: 6},
+	}
+
+	html, err := renderEmail(items, nil, "LocalLLaMA", LookupLocale("en"), time.Now(), nil, renderOptions{Layout: Layout{MaxItems: 1}})
+	require.NoError(t, err)
+	assert.NotContains(t, html, "Summary A", "unsure items are not rendered in full")
+	assert.NotContains(t, html, "Summary C")
+	assert.Contains(t, html, "Summary B", "unsure items do not count towards the limit")
+	assert.Contains(t, html, `<summary class="section-title">Possibly relevant (1)</summary>`)
+	assert.Contains(t, html, `<li><a id="item-t3_a"></a><a href="https://example.com/a">Maybe a release</a> <span class="overflow-meta">· Could be a new model</span></li>`)
+
+	html, err = renderEmail(items[1:], nil, "LocalLLaMA", LookupLocale("en"), time.Now(), nil, renderOptions{})
+	require.NoError(t, err)
+	assert.NotContains(t, html, "Possibly relevant")
+}
+
+func TestRenderEmail_Layout(t *testing.T) {
+	items := []models.Item{
+		{ID: "a", Title: "Minor tweak", ImportanceScore: 2, Topics: []string{"tooling"}},
+		{ID: "b", Title: "Big release", ImportanceScore: 9, Topics: []string{"releases"}},
+	}
+	summary := &models.SummaryResponse{KeyDevelopments: []models.KeyDevelopment{{Text: "A big model was released", ItemID: "t3_b"}}}
+	layout := Layout{Order: persona.OrderImportance, GroupBy: persona.GroupTopic, TopStory: true}
+
+	html, err := renderEmail(items, summary, "LocalLLaMA", LookupLocale("de"), time.Now(), nil, renderOptions{Layout: layout})
+	require.NoError(t, err)
+	assert.Contains(t, html, `<div class="top-story-label">Top-Thema</div>`)
+	assert.Contains(t, html, `<div class="top-story-title"><a href="#item-t3_b">Big release</a></div>`)
+	assert.Contains(t, html, `<div class="section-title">Releases</div>`)
+	assert.Less(t, strings.Index(html, `<div class="section-title">Releases</div>`), strings.Index(html, `<div class="section-title">Tooling</div>`))
+}
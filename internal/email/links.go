@@ -0,0 +1,95 @@
+package email
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/linkcheck"
+	"github.com/bakkerme/ai-news-processor/models"
+)
+
+// LinkChecker tells whether the links and thumbnails of a digest still resolve
+type LinkChecker interface {
+	CheckLink(ctx context.Context, url string) linkcheck.Status
+	CheckImage(ctx context.Context, url string) linkcheck.Status
+}
+
+// linkCheckWorkers bounds how many links are checked at once
+const linkCheckWorkers = 8
+
+// linkCheckTimeout bounds how long checking the links of one digest may hold up sending it
+const linkCheckTimeout = time.Minute
+
+// checkLinks checks the links, source links and thumbnails of items. Dead thumbnails are removed
+// and marked with ThumbnailDead, so the email shows a placeholder instead of a broken image, and
+// dead links are listed in DeadLinks. Links that cannot be checked count as alive. items is not
+// modified.
+func checkLinks(items []models.Item, checker LinkChecker) []models.Item {
+	checked := make([]models.Item, len(items))
+	copy(checked, items)
+
+	type check struct {
+		item  int
+		url   string
+		image bool
+	}
+	var checks []check
+	for i, item := range checked {
+		checked[i].DeadLinks = nil
+		if item.ThumbnailURL != "" {
+			checks = append(checks, check{item: i, url: item.ThumbnailURL, image: true})
+		}
+		if item.Link != "" {
+			checks = append(checks, check{item: i, url: item.Link})
+		}
+		for url := range item.Entry.WebContentSources {
+			if url != item.Link {
+				checks = append(checks, check{item: i, url: url})
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), linkCheckTimeout)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	queue := make(chan check)
+	for range min(linkCheckWorkers, len(checks)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range queue {
+				var status linkcheck.Status
+				if c.image {
+					status = checker.CheckImage(ctx, c.url)
+				} else {
+					status = checker.CheckLink(ctx, c.url)
+				}
+				if status != linkcheck.Dead {
+					continue
+				}
+
+				mu.Lock()
+				if c.image {
+					log.Printf("Thumbnail %s of item %s no longer loads, leaving it out", c.url, checked[c.item].ID)
+					checked[c.item].ThumbnailURL = ""
+					checked[c.item].ThumbnailDead = true
+				} else {
+					log.Printf("Link %s of item %s no longer resolves", c.url, checked[c.item].ID)
+					checked[c.item].DeadLinks = append(checked[c.item].DeadLinks, c.url)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, c := range checks {
+		queue <- c
+	}
+	close(queue)
+	wg.Wait()
+
+	return checked
+}
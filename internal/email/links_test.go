@@ -0,0 +1,87 @@
+package email
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
+	"github.com/bakkerme/ai-news-processor/internal/linkcheck"
+	"github.com/bakkerme/ai-news-processor/internal/sitemeta"
+	"github.com/bakkerme/ai-news-processor/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubLinkChecker reports the URLs in dead as dead and every other URL as alive
+type stubLinkChecker struct {
+	dead map[string]bool
+}
+
+func (s stubLinkChecker) CheckLink(_ context.Context, url string) linkcheck.Status {
+	if s.dead[url] {
+		return linkcheck.Dead
+	}
+	return linkcheck.Alive
+}
+
+func (s stubLinkChecker) CheckImage(ctx context.Context, url string) linkcheck.Status {
+	return s.CheckLink(ctx, url)
+}
+
+func TestCheckLinks(t *testing.T) {
+	checker := stubLinkChecker{dead: map[string]bool{
+		"https://i.imgur.com/gone.png":  true,
+		"https://example.com/removed":   true,
+		"https://blog.example.com/post": true,
+	}}
+	items := []models.Item{
+		{
+			ID:           "1",
+			Link:         "https://example.com/removed",
+			ThumbnailURL: "https://i.imgur.com/gone.png",
+			Entry: feeds.Entry{WebContentSources: map[string]sitemeta.Metadata{
+				"https://blog.example.com/post": {SiteName: "Blog"},
+				"https://docs.example.com/":     {SiteName: "Docs"},
+			}},
+		},
+		{ID: "2", Link: "https://example.com/fine", ThumbnailURL: "https://example.com/fine.png"},
+	}
+
+	checked := checkLinks(items, checker)
+
+	require.Len(t, checked, 2)
+	assert.Empty(t, checked[0].ThumbnailURL)
+	assert.True(t, checked[0].ThumbnailDead)
+	assert.ElementsMatch(t, []string{"https://example.com/removed", "https://blog.example.com/post"}, checked[0].DeadLinks)
+	assert.True(t, checked[0].IsDeadLink("https://blog.example.com/post"))
+	assert.False(t, checked[0].IsDeadLink("https://docs.example.com/"))
+
+	assert.Equal(t, "https://example.com/fine.png", checked[1].ThumbnailURL)
+	assert.False(t, checked[1].ThumbnailDead)
+	assert.Empty(t, checked[1].DeadLinks)
+
+	assert.Equal(t, "https://i.imgur.com/gone.png", items[0].ThumbnailURL, "items is not modified")
+}
+
+func TestRenderEmail_DeadLinks(t *testing.T) {
+	items := []models.Item{{
+		ID:            "1",
+		Title:         "Gone",
+		Link:          "https://example.com/removed",
+		Summary:       "Summary",
+		ThumbnailDead: true,
+		DeadLinks:     []string{"https://example.com/removed", "https://blog.example.com/post"},
+		Entry: feeds.Entry{WebContentSources: map[string]sitemeta.Metadata{
+			"https://blog.example.com/post": {SiteName: "Blog"},
+		}},
+	}}
+
+	html, err := renderEmail(items, nil, "Test", LookupLocale("en"), time.Now(), nil, renderOptions{})
+	require.NoError(t, err)
+
+	assert.Contains(t, html, `<div class="thumbnail-removed">Image no longer available</div>`)
+	assert.Contains(t, html, `<a href="https://blog.example.com/post">Blog</a> <span class="dead-link">(Link appears to be broken)</span>`)
+	assert.Contains(t, html, `<span class="dead-link">Link appears to be broken</span>`)
+	assert.NotContains(t, html, `class="thumbnail" `)
+}
@@ -0,0 +1,429 @@
+package email
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Locale holds the strings and formats used for the digest chrome in one language.
+// It only covers the fixed parts of the email; LLM-generated content is not translated.
+type Locale struct {
+	Tag string // BCP 47 language tag, e.g. "en" or "de"
+
+	// Headings and labels. Strings containing %s are formatted with the persona name.
+	Title           string
+	Developments    string
+	KeyDevelopments string
+	RisingTopics    string
+	RisingCounts    string // Formatted with the number of items in this run and the trailing average
+	StarterNote     string // Shown on a persona's first digest
+	ImagesDegraded  string // Shown when the image model was down and images were not described
+	LinksDegraded   string // Shown when linked pages could not be fetched and were not summarized
+	ItemUnavailable string // Shown instead of the summary of an item that could not be processed
+	ImageRemoved    string // Shown in place of a thumbnail that no longer loads
+	LinkDead        string // Shown next to a link that no longer resolves
+	Updated         string // Shown before the title of an item sent before, whose post was edited since
+	Discussion      string // Heading of the collapsible comment summary
+	Claims          string // Heading of the footnotes listing an item's claims and their sources
+	SourcePost      string // Source of a claim taken from the post itself
+	SourceComment   string // Source of a claim taken from a comment
+	SourceLink      string // Source of a claim taken from a linked page
+	SourceImage     string // Source of a claim taken from the image description
+	TopStory        string // Label of the story the digest leads with
+	Watchlist       string // Label of the items that matched the persona's watchlist, followed by the matched entries
+	Megathread      string // Label of live threads and megathreads, whose summary covers the discussion
+	Yes             string // Value of a custom field that is true
+	No              string // Value of a custom field that is false
+	OtherItems      string // Heading of the items without a topic or flair when a digest is grouped
+	AlsoNoteworthy  string // Heading of the links to the items beyond the persona's digest_max_items
+	Unsure          string // Heading of the collapsed list of items the LLM was unsure of
+	ReadFullPost    string
+	FeedbackPrompt  string
+	GeneratedBy     string
+	CommentSingular string
+	CommentPlural   string
+	JustNow         string
+	MinutesAgo      string // Formatted with the number of minutes (always 2 or more)
+	HoursAgo        string // Formatted with the number of hours (always 2 or more)
+	DaysAgo         string // Formatted with the number of days (always 2 or more)
+	Yesterday       string
+	DateLayout      string // Go time layout with month and weekday names in English, replaced after formatting
+	Months          [12]string
+	Weekdays        [7]string // Starting with Sunday, matching time.Weekday
+	GroupSeparator  string
+	DecimalMark     string
+
+	// Weekly rollup email. RollupTitle is formatted with the persona name.
+	RollupTitle string
+	Themes      string
+	Trends      string
+	AllStories  string
+}
+
+// DefaultLocale is used when a persona does not specify a locale or specifies an unknown one
+var DefaultLocale = locales["en"]
+
+var locales = map[string]Locale{
+	"en": {
+		Tag:             "en",
+		Title:           "%s News",
+		Developments:    "Today's %s Developments",
+		KeyDevelopments: "Key Developments",
+		RisingTopics:    "Rising Topics",
+		RisingCounts:    "%s mentions, usually %s",
+		StarterNote:     "This is the first %s digest, so it only covers the top stories currently in the feed. Later digests include everything new.",
+		ImagesDegraded:  "Images were not described in this digest because the image model was unavailable.",
+		LinksDegraded:   "Linked articles were not summarized in this digest because they could not be fetched.",
+		ItemUnavailable: "This story could not be summarized. Follow the link to read it.",
+		ImageRemoved:    "Image no longer available",
+		LinkDead:        "Link appears to be broken",
+		Updated:         "Updated",
+		Discussion:      "What commenters say",
+		Claims:          "Claims and sources",
+		SourcePost:      "the post",
+		SourceComment:   "a comment",
+		SourceLink:      "a linked page",
+		SourceImage:     "the image",
+		TopStory:        "Top Story",
+		Watchlist:       "Watchlist",
+		Megathread:      "Megathread",
+		Yes:             "Yes",
+		No:              "No",
+		OtherItems:      "Other",
+		AlsoNoteworthy:  "Also noteworthy",
+		Unsure:          "Possibly relevant",
+		ReadFullPost:    "Read Full Post",
+		FeedbackPrompt:  "Was this relevant?",
+		GeneratedBy:     "Generated by",
+		CommentSingular: "%s comment",
+		CommentPlural:   "%s comments",
+		JustNow:         "just now",
+		MinutesAgo:      "%d minutes ago",
+		HoursAgo:        "%d hours ago",
+		DaysAgo:         "%d days ago",
+		Yesterday:       "yesterday",
+		DateLayout:      "Monday, January 2, 2006",
+		Months:          [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+		Weekdays:        [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+		GroupSeparator:  ",",
+		DecimalMark:     ".",
+		RollupTitle:     "%s: Week in Review",
+		Themes:          "Themes",
+		Trends:          "Trends",
+		AllStories:      "All Stories",
+	},
+	"de": {
+		Tag:             "de",
+		Title:           "%s Nachrichten",
+		Developments:    "%s: Entwicklungen des Tages",
+		KeyDevelopments: "Wichtigste Entwicklungen",
+		RisingTopics:    "Aufstrebende Themen",
+		RisingCounts:    "%s Erwähnungen, sonst %s",
+		StarterNote:     "Dies ist der erste %s-Digest, daher enthält er nur die wichtigsten aktuellen Beiträge aus dem Feed. Spätere Ausgaben enthalten alles Neue.",
+		ImagesDegraded:  "Bilder wurden in diesem Digest nicht beschrieben, weil das Bildmodell nicht erreichbar war.",
+		LinksDegraded:   "Verlinkte Artikel wurden in diesem Digest nicht zusammengefasst, weil sie nicht abgerufen werden konnten.",
+		ItemUnavailable: "Für diesen Beitrag ist keine Zusammenfassung verfügbar. Über den Link geht es zum Original.",
+		ImageRemoved:    "Bild nicht mehr verfügbar",
+		LinkDead:        "Link scheint nicht mehr zu funktionieren",
+		Updated:         "Aktualisiert",
+		Discussion:      "Was die Kommentare sagen",
+		Claims:          "Aussagen und Quellen",
+		SourcePost:      "der Beitrag",
+		SourceComment:   "ein Kommentar",
+		SourceLink:      "eine verlinkte Seite",
+		SourceImage:     "das Bild",
+		TopStory:        "Top-Thema",
+		Watchlist:       "Beobachtungsliste",
+		Megathread:      "Sammelthread",
+		Yes:             "Ja",
+		No:              "Nein",
+		OtherItems:      "Sonstiges",
+		AlsoNoteworthy:  "Ebenfalls erwähnenswert",
+		Unsure:          "Möglicherweise relevant",
+		ReadFullPost:    "Ganzen Beitrag lesen",
+		FeedbackPrompt:  "War das relevant?",
+		GeneratedBy:     "Erstellt von",
+		CommentSingular: "%s Kommentar",
+		CommentPlural:   "%s Kommentare",
+		JustNow:         "gerade eben",
+		MinutesAgo:      "vor %d Minuten",
+		HoursAgo:        "vor %d Stunden",
+		DaysAgo:         "vor %d Tagen",
+		Yesterday:       "gestern",
+		DateLayout:      "Monday, 2. January 2006",
+		Months:          [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		Weekdays:        [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		GroupSeparator:  ".",
+		DecimalMark:     ",",
+		RollupTitle:     "%s: Wochenrückblick",
+		Themes:          "Themen",
+		Trends:          "Trends",
+		AllStories:      "Alle Beiträge",
+	},
+	"nl": {
+		Tag:             "nl",
+		Title:           "%s Nieuws",
+		Developments:    "%s: ontwikkelingen van vandaag",
+		KeyDevelopments: "Belangrijkste ontwikkelingen",
+		RisingTopics:    "Opkomende onderwerpen",
+		RisingCounts:    "%s vermeldingen, normaal %s",
+		StarterNote:     "Dit is de eerste %s-digest en bevat daarom alleen de belangrijkste berichten die nu in de feed staan. Volgende edities bevatten alles wat nieuw is.",
+		ImagesDegraded:  "Afbeeldingen zijn in deze digest niet beschreven omdat het beeldmodel niet beschikbaar was.",
+		LinksDegraded:   "Gelinkte artikelen zijn in deze digest niet samengevat omdat ze niet konden worden opgehaald.",
+		ItemUnavailable: "Voor dit bericht is geen samenvatting beschikbaar. Volg de link om het te lezen.",
+		ImageRemoved:    "Afbeelding niet meer beschikbaar",
+		LinkDead:        "Link lijkt niet meer te werken",
+		Updated:         "Bijgewerkt",
+		Discussion:      "Wat reageerders zeggen",
+		Claims:          "Beweringen en bronnen",
+		SourcePost:      "het bericht",
+		SourceComment:   "een reactie",
+		SourceLink:      "een gelinkte pagina",
+		SourceImage:     "de afbeelding",
+		TopStory:        "Uitgelicht",
+		Watchlist:       "Volglijst",
+		Megathread:      "Verzameltopic",
+		Yes:             "Ja",
+		No:              "Nee",
+		OtherItems:      "Overig",
+		AlsoNoteworthy:  "Ook het vermelden waard",
+		Unsure:          "Mogelijk relevant",
+		ReadFullPost:    "Lees het volledige bericht",
+		FeedbackPrompt:  "Was dit relevant?",
+		GeneratedBy:     "Gegenereerd door",
+		CommentSingular: "%s reactie",
+		CommentPlural:   "%s reacties",
+		JustNow:         "zojuist",
+		MinutesAgo:      "%d minuten geleden",
+		HoursAgo:        "%d uur geleden",
+		DaysAgo:         "%d dagen geleden",
+		Yesterday:       "gisteren",
+		DateLayout:      "Monday 2 January 2006",
+		Months:          [12]string{"januari", "februari", "maart", "april", "mei", "juni", "juli", "augustus", "september", "oktober", "november", "december"},
+		Weekdays:        [7]string{"zondag", "maandag", "dinsdag", "woensdag", "donderdag", "vrijdag", "zaterdag"},
+		GroupSeparator:  ".",
+		DecimalMark:     ",",
+		RollupTitle:     "%s: Weekoverzicht",
+		Themes:          "Thema's",
+		Trends:          "Trends",
+		AllStories:      "Alle berichten",
+	},
+	"fr": {
+		Tag:             "fr",
+		Title:           "Actualités %s",
+		Developments:    "%s : les nouveautés du jour",
+		KeyDevelopments: "Points clés",
+		RisingTopics:    "Sujets en hausse",
+		RisingCounts:    "%s mentions, %s en moyenne",
+		StarterNote:     "Ceci est le premier digest %s : il ne reprend que les principaux articles actuellement dans le flux. Les prochains incluront toutes les nouveautés.",
+		ImagesDegraded:  "Les images n'ont pas été décrites dans ce digest car le modèle d'images était indisponible.",
+		LinksDegraded:   "Les articles liés n'ont pas été résumés dans ce digest car ils n'ont pas pu être récupérés.",
+		ItemUnavailable: "Le résumé de cet article n'est pas disponible. Suivez le lien pour le lire.",
+		ImageRemoved:    "Image plus disponible",
+		LinkDead:        "Le lien semble rompu",
+		Updated:         "Mis à jour",
+		Discussion:      "Ce qu'en disent les commentaires",
+		Claims:          "Affirmations et sources",
+		SourcePost:      "la publication",
+		SourceComment:   "un commentaire",
+		SourceLink:      "une page liée",
+		SourceImage:     "l'image",
+		TopStory:        "À la une",
+		Watchlist:       "Liste de veille",
+		Megathread:      "Fil de discussion",
+		Yes:             "Oui",
+		No:              "Non",
+		OtherItems:      "Autres",
+		AlsoNoteworthy:  "À noter également",
+		Unsure:          "Peut-être pertinent",
+		ReadFullPost:    "Lire la publication complète",
+		FeedbackPrompt:  "Était-ce pertinent ?",
+		GeneratedBy:     "Généré par",
+		CommentSingular: "%s commentaire",
+		CommentPlural:   "%s commentaires",
+		JustNow:         "à l'instant",
+		MinutesAgo:      "il y a %d minutes",
+		HoursAgo:        "il y a %d heures",
+		DaysAgo:         "il y a %d jours",
+		Yesterday:       "hier",
+		DateLayout:      "Monday 2 January 2006",
+		Months:          [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		Weekdays:        [7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		GroupSeparator:  " ",
+		DecimalMark:     ",",
+		RollupTitle:     "%s : la semaine en revue",
+		Themes:          "Thèmes",
+		Trends:          "Tendances",
+		AllStories:      "Tous les articles",
+	},
+	"es": {
+		Tag:             "es",
+		Title:           "Noticias de %s",
+		Developments:    "Novedades de hoy en %s",
+		KeyDevelopments: "Novedades clave",
+		RisingTopics:    "Temas en alza",
+		RisingCounts:    "%s menciones, normalmente %s",
+		StarterNote:     "Este es el primer resumen de %s, por eso solo incluye las historias destacadas que hay ahora en el feed. Los próximos incluirán todo lo nuevo.",
+		ImagesDegraded:  "Las imágenes no se describieron en este resumen porque el modelo de imágenes no estaba disponible.",
+		LinksDegraded:   "Los artículos enlazados no se resumieron en este resumen porque no se pudieron descargar.",
+		ItemUnavailable: "El resumen de esta publicación no está disponible. Sigue el enlace para leerla.",
+		ImageRemoved:    "Imagen ya no disponible",
+		LinkDead:        "El enlace parece roto",
+		Updated:         "Actualizado",
+		Discussion:      "Lo que dicen los comentarios",
+		Claims:          "Afirmaciones y fuentes",
+		SourcePost:      "la publicación",
+		SourceComment:   "un comentario",
+		SourceLink:      "una página enlazada",
+		SourceImage:     "la imagen",
+		TopStory:        "Destacado",
+		Watchlist:       "Lista de seguimiento",
+		Megathread:      "Hilo de discusión",
+		Yes:             "Sí",
+		No:              "No",
+		OtherItems:      "Otros",
+		AlsoNoteworthy:  "También destacable",
+		Unsure:          "Posiblemente relevante",
+		ReadFullPost:    "Leer la publicación completa",
+		FeedbackPrompt:  "¿Te resultó relevante?",
+		GeneratedBy:     "Generado por",
+		CommentSingular: "%s comentario",
+		CommentPlural:   "%s comentarios",
+		JustNow:         "justo ahora",
+		MinutesAgo:      "hace %d minutos",
+		HoursAgo:        "hace %d horas",
+		DaysAgo:         "hace %d días",
+		Yesterday:       "ayer",
+		DateLayout:      "Monday, 2 de January de 2006",
+		Months:          [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		Weekdays:        [7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		GroupSeparator:  ".",
+		DecimalMark:     ",",
+		RollupTitle:     "%s: resumen semanal",
+		Themes:          "Temas",
+		Trends:          "Tendencias",
+		AllStories:      "Todas las noticias",
+	},
+}
+
+// LookupLocale returns the locale for a BCP 47 tag such as "de" or "de-AT".
+// Region subtags fall back to their base language, and unknown tags fall back to DefaultLocale.
+func LookupLocale(tag string) Locale {
+	tag = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+	if loc, ok := locales[tag]; ok {
+		return loc
+	}
+	base, _, _ := strings.Cut(tag, "-")
+	if loc, ok := locales[base]; ok {
+		return loc
+	}
+	return DefaultLocale
+}
+
+// FormatDate formats t as a long date with localized month and weekday names
+func (l Locale) FormatDate(t time.Time) string {
+	// Use placeholders so English names in the layout can be swapped without clobbering other text
+	layout := strings.Replace(l.DateLayout, "Monday", "\x00W\x00", 1)
+	layout = strings.Replace(layout, "January", "\x00M\x00", 1)
+
+	formatted := t.Format(layout)
+	formatted = strings.Replace(formatted, "\x00W\x00", l.Weekdays[t.Weekday()], 1)
+	formatted = strings.Replace(formatted, "\x00M\x00", l.Months[t.Month()-1], 1)
+	return formatted
+}
+
+// FormatNumber formats an integer using the locale's digit grouping
+func (l Locale) FormatNumber(n int) string {
+	digits := strconv.Itoa(n)
+	sign := ""
+	if n < 0 {
+		sign, digits = "-", digits[1:]
+	}
+
+	var s strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			s.WriteString(l.GroupSeparator)
+		}
+		s.WriteRune(d)
+	}
+	return sign + s.String()
+}
+
+// FormatDecimal formats a number with one decimal using the locale's decimal mark and digit grouping
+func (l Locale) FormatDecimal(f float64) string {
+	tenths := int(math.Round(f * 10))
+	sign := ""
+	if tenths < 0 {
+		sign, tenths = "-", -tenths
+	}
+	return fmt.Sprintf("%s%s%s%d", sign, l.FormatNumber(tenths/10), l.DecimalMark, tenths%10)
+}
+
+// FormatField formats the value of a custom field: numbers with the locale's digit grouping and
+// decimal mark, booleans as yes or no and lists separated by commas
+func (l Locale) FormatField(value any) string {
+	switch v := value.(type) {
+	case bool:
+		if v {
+			return l.Yes
+		}
+		return l.No
+	case int64:
+		return l.FormatNumber(int(v))
+	case float64:
+		// Stored items decode every number as a float64
+		if v == math.Trunc(v) && math.Abs(v) < 1e15 {
+			return l.FormatNumber(int(v))
+		}
+		whole, fraction, _ := strings.Cut(strconv.FormatFloat(math.Abs(v), 'f', -1, 64), ".")
+		n, _ := strconv.Atoi(whole)
+		sign := ""
+		if v < 0 {
+			sign = "-"
+		}
+		return sign + l.FormatNumber(n) + l.DecimalMark + fraction
+	case []string:
+		return strings.Join(v, ", ")
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, value := range v {
+			values = append(values, l.FormatField(value))
+		}
+		return strings.Join(values, ", ")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// FormatComments renders a comment count, e.g. "1,234 comments"
+func (l Locale) FormatComments(n int) string {
+	if n == 1 {
+		return fmt.Sprintf(l.CommentSingular, l.FormatNumber(n))
+	}
+	return fmt.Sprintf(l.CommentPlural, l.FormatNumber(n))
+}
+
+// RelativeTime describes how long before now t was, e.g. "3 hours ago".
+// Times more than a week old are rendered as a date instead.
+func (l Locale) RelativeTime(t time.Time, now time.Time) string {
+	elapsed := now.Sub(t)
+	switch {
+	// Thresholds start at two units so only plural forms are needed
+	case elapsed < 2*time.Minute:
+		return l.JustNow
+	case elapsed < 2*time.Hour:
+		return fmt.Sprintf(l.MinutesAgo, int(elapsed.Minutes()))
+	case elapsed < 24*time.Hour:
+		return fmt.Sprintf(l.HoursAgo, int(elapsed.Hours()))
+	case elapsed < 48*time.Hour:
+		return l.Yesterday
+	case elapsed < 7*24*time.Hour:
+		return fmt.Sprintf(l.DaysAgo, int(elapsed.Hours()/24))
+	default:
+		return l.FormatDate(t)
+	}
+}
@@ -0,0 +1,113 @@
+package email
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
+	"github.com/bakkerme/ai-news-processor/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookupLocale(t *testing.T) {
+	assert.Equal(t, "en", LookupLocale("").Tag)
+	assert.Equal(t, "de", LookupLocale("de").Tag)
+	assert.Equal(t, "de", LookupLocale("de-AT").Tag)
+	assert.Equal(t, "nl", LookupLocale("nl_BE").Tag)
+	assert.Equal(t, "en", LookupLocale("xx-unknown").Tag)
+}
+
+func TestLocale_FormatDate(t *testing.T) {
+	date := time.Date(2025, time.March, 4, 9, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, "Tuesday, March 4, 2025", LookupLocale("en").FormatDate(date))
+	assert.Equal(t, "Dienstag, 4. März 2025", LookupLocale("de").FormatDate(date))
+	assert.Equal(t, "dinsdag 4 maart 2025", LookupLocale("nl").FormatDate(date))
+	assert.Equal(t, "martes, 4 de marzo de 2025", LookupLocale("es").FormatDate(date))
+}
+
+func TestLocale_FormatNumber(t *testing.T) {
+	assert.Equal(t, "0", LookupLocale("en").FormatNumber(0))
+	assert.Equal(t, "999", LookupLocale("en").FormatNumber(999))
+	assert.Equal(t, "1,234,567", LookupLocale("en").FormatNumber(1234567))
+	assert.Equal(t, "1.234", LookupLocale("de").FormatNumber(1234))
+	assert.Equal(t, "-12,345", LookupLocale("en").FormatNumber(-12345))
+}
+
+func TestLocale_FormatDecimal(t *testing.T) {
+	assert.Equal(t, "1,234.5", LookupLocale("en").FormatDecimal(1234.49))
+	assert.Equal(t, "0,7", LookupLocale("de").FormatDecimal(0.666))
+	assert.Equal(t, "-2,0", LookupLocale("fr").FormatDecimal(-1.96))
+}
+
+func TestLocale_FormatField(t *testing.T) {
+	en, de := LookupLocale("en"), LookupLocale("de")
+	assert.Equal(t, "Apache-2.0", en.FormatField("Apache-2.0"))
+	assert.Equal(t, "Yes", en.FormatField(true))
+	assert.Equal(t, "Nein", de.FormatField(false))
+	assert.Equal(t, "32,768", en.FormatField(int64(32768)))
+	assert.Equal(t, "32.768", de.FormatField(float64(32768)), "stored whole numbers are floats")
+	assert.Equal(t, "7,62", de.FormatField(7.62))
+	assert.Equal(t, "-0.5", en.FormatField(-0.5))
+	assert.Equal(t, "MMLU 74.2, GSM8K 88", en.FormatField([]string{"MMLU 74.2", "GSM8K 88"}))
+	assert.Equal(t, "a, b", en.FormatField([]any{"a", "b"}))
+}
+
+func TestLocale_FormatComments(t *testing.T) {
+	assert.Equal(t, "1 comment", LookupLocale("en").FormatComments(1))
+	assert.Equal(t, "1,500 comments", LookupLocale("en").FormatComments(1500))
+	assert.Equal(t, "2 Kommentare", LookupLocale("de").FormatComments(2))
+}
+
+func TestLocale_RelativeTime(t *testing.T) {
+	now := time.Date(2025, time.March, 4, 12, 0, 0, 0, time.UTC)
+	en := LookupLocale("en")
+
+	tests := []struct {
+		name     string
+		ago      time.Duration
+		expected string
+	}{
+		{"just now", 30 * time.Second, "just now"},
+		{"minutes", 45 * time.Minute, "45 minutes ago"},
+		{"hours", 5 * time.Hour, "5 hours ago"},
+		{"yesterday", 30 * time.Hour, "yesterday"},
+		{"days", 3 * 24 * time.Hour, "3 days ago"},
+		{"older than a week", 10 * 24 * time.Hour, "Saturday, February 22, 2025"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, en.RelativeTime(now.Add(-tt.ago), now))
+		})
+	}
+
+	assert.Equal(t, "vor 5 Stunden", LookupLocale("de").RelativeTime(now.Add(-5*time.Hour), now))
+}
+
+func TestRenderEmail_Localized(t *testing.T) {
+	now := time.Date(2025, time.March, 4, 12, 0, 0, 0, time.UTC)
+	items := []models.Item{
+		{
+			Title: "New model released",
+			ID:    "abc",
+			Link:  "https://example.com/post",
+			Entry: feeds.Entry{
+				Published: now.Add(-3 * time.Hour),
+				Comments:  make([]feeds.EntryComments, 1200),
+			},
+		},
+	}
+
+	html, err := renderEmail(items, nil, "LocalLLaMA", LookupLocale("de"), now, nil, renderOptions{})
+	require.NoError(t, err)
+
+	assert.Contains(t, html, `<html lang="de">`)
+	assert.Contains(t, html, "LocalLLaMA Nachrichten")
+	assert.Contains(t, html, "Dienstag, 4. März 2025")
+	assert.Contains(t, html, "vor 3 Stunden · 1.200 Kommentare")
+	assert.Contains(t, html, "Ganzen Beitrag lesen")
+	assert.False(t, strings.Contains(html, "Read Full Post"))
+}
@@ -2,30 +2,63 @@ package email
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
 	"strings"
 	"text/template"
+	"time"
 
 	"embed"
 
+	"github.com/bakkerme/ai-news-processor/internal/itemstore"
+	"github.com/bakkerme/ai-news-processor/internal/templatefuncs"
 	"github.com/bakkerme/ai-news-processor/models"
 )
 
 //go:embed templates/*.tmpl
 var templateFS embed.FS
 
+// FeedbackLinker builds the links readers follow to rate an item as relevant or not
+type FeedbackLinker interface {
+	FeedbackURL(personaName string, itemID string, relevant bool) string
+}
+
 type EmailData struct {
 	Summary     *models.SummaryResponse
-	Items       []models.Item
+	Items       []models.Item // Items rendered in full, in the layout's order
+	Overflow    []models.Item // Items beyond the layout's limit, most important first, listed as links only
+	Unsure      []models.Item // Items the LLM was unsure of, listed in a collapsed section
 	PersonaName string
+	Locale      Locale
+	Date        time.Time
+	TopStory    *TopStory // Nil unless the layout has a top story and one was found
+	Sections    []Section // Items by section; a single untitled section if the layout has no grouping
 }
 
-func RenderEmail(items []models.Item, summary *models.SummaryResponse, personaName string) (string, error) {
-	tmplContent, err := templateFS.ReadFile("templates/email_template.tmpl")
-	if err != nil {
-		return "", fmt.Errorf("failed to read template: %w", err)
-	}
+// renderOptions are the persona's settings for rendering its digest
+type renderOptions struct {
+	TemplateDir string // Directory with template overrides, or empty for the built-in template
+	Layout      Layout
+}
 
+// RenderEmail renders the digest email. localeTag selects the language of the email chrome
+// (headings, dates and counts); an empty or unknown tag renders in English.
+func RenderEmail(items []models.Item, summary *models.SummaryResponse, personaName string, localeTag string) (string, error) {
+	return renderEmail(items, summary, personaName, LookupLocale(localeTag), time.Now(), nil, renderOptions{})
+}
+
+// RenderEmailWithFeedback renders the digest email with thumbs-up and thumbs-down links under each item
+func RenderEmailWithFeedback(items []models.Item, summary *models.SummaryResponse, personaName string, localeTag string, feedback FeedbackLinker) (string, error) {
+	return renderEmail(items, summary, personaName, LookupLocale(localeTag), time.Now(), feedback, renderOptions{})
+}
+
+// renderEmail renders the digest email from email_template.tmpl in the options' template
+// directory, or from the built-in template, with the items laid out as the options say
+func renderEmail(items []models.Item, summary *models.SummaryResponse, personaName string, loc Locale, now time.Time, feedback FeedbackLinker, options renderOptions) (string, error) {
 	// Create template functions
 	funcMap := template.FuncMap{
 		"split": strings.Split,
@@ -39,29 +72,134 @@ func RenderEmail(items []models.Item, summary *models.SummaryResponse, personaNa
 			}
 			return s
 		},
+		"localize": func(format string) string {
+			return fmt.Sprintf(format, personaName)
+		},
+		"formatDate":     loc.FormatDate,
+		"formatComments": loc.FormatComments,
+		"relativeTime": func(t time.Time) string {
+			return loc.RelativeTime(t, now)
+		},
+		"risingCounts": func(count int, average float64) string {
+			return fmt.Sprintf(loc.RisingCounts, loc.FormatNumber(count), loc.FormatDecimal(average))
+		},
+		"add": func(a, b int) int {
+			return a + b
+		},
+		"feedbackURL": func(itemID string, relevant bool) string {
+			if feedback == nil {
+				return ""
+			}
+			return feedback.FeedbackURL(personaName, itemID, relevant)
+		},
 	}
 
-	// Create and parse the template
-	tmpl, err := template.New("email").Funcs(funcMap).Parse(string(tmplContent))
-	if err != nil {
-		return "", fmt.Errorf("failed to parse template: %w", err)
-	}
-
+	items, unsure := splitUnsure(items)
+	items, overflow := options.Layout.limitItems(options.Layout.orderItems(items))
 	data := EmailData{
 		Summary:     summary,
 		Items:       items,
+		Overflow:    overflow,
+		Unsure:      unsure,
 		PersonaName: personaName,
+		Locale:      loc,
+		Date:        now,
+		TopStory:    options.Layout.topStory(items, summary),
+		Sections:    options.Layout.sections(items, loc.OtherItems),
 	}
 
-	// Execute the template into a buffer
-	var buf bytes.Buffer
-	err = tmpl.Execute(&buf, data)
+	return executeTemplate(options.TemplateDir, "email_template.tmpl", funcMap, data)
+}
+
+// RollupData is the data passed to the rollup email template
+type RollupData struct {
+	Rollup      *models.RollupResponse
+	Records     []itemstore.Record
+	PersonaName string
+	Locale      Locale
+	From        time.Time
+	Date        time.Time
+}
+
+// RenderRollupEmail renders the review email for the items sent since from, using the rollup
+// generated by the LLM. localeTag selects the language of the email chrome.
+func RenderRollupEmail(records []itemstore.Record, rollup *models.RollupResponse, personaName string, localeTag string, from time.Time) (string, error) {
+	return renderRollupEmail(records, rollup, personaName, LookupLocale(localeTag), from, time.Now(), "")
+}
+
+// renderRollupEmail renders the rollup email from rollup_template.tmpl in templateDir, or from the
+// built-in template if templateDir is empty
+func renderRollupEmail(records []itemstore.Record, rollup *models.RollupResponse, personaName string, loc Locale, from time.Time, now time.Time, templateDir string) (string, error) {
+	byID := make(map[string]models.Item, len(records))
+	for _, record := range records {
+		byID[record.Item.ID] = record.Item
+	}
+
+	funcMap := template.FuncMap{
+		"localize": func(format string) string {
+			return fmt.Sprintf(format, personaName)
+		},
+		"formatDate": loc.FormatDate,
+		// itemsByID resolves the item IDs referenced by a theme, skipping IDs the LLM made up
+		"itemsByID": func(ids []string) []models.Item {
+			var items []models.Item
+			for _, id := range ids {
+				if item, ok := byID[id]; ok {
+					items = append(items, item)
+				}
+			}
+			return items
+		},
+	}
+
+	data := RollupData{
+		Rollup:      rollup,
+		Records:     records,
+		PersonaName: personaName,
+		Locale:      loc,
+		From:        from,
+		Date:        now,
+	}
+
+	return executeTemplate(templateDir, "rollup_template.tmpl", funcMap, data)
+}
+
+// executeTemplate renders the named template from templateDir, falling back to the built-in
+// template when templateDir has no such file or its template fails, so a broken override never
+// costs a digest. Overrides are read on every render, so edits apply from the next email on
+// without a restart.
+func executeTemplate(templateDir, name string, funcMap template.FuncMap, data interface{}) (string, error) {
+	if templateDir != "" {
+		path := filepath.Join(templateDir, name)
+		content, err := os.ReadFile(path)
+		switch {
+		case err == nil:
+			result, err := parseAndExecute(name, string(content), funcMap, data)
+			if err == nil {
+				return result, nil
+			}
+			log.Printf("Warning: template %s failed, using the built-in template: %v", path, err)
+		case !errors.Is(err, fs.ErrNotExist):
+			log.Printf("Warning: could not read template %s, using the built-in template: %v", path, err)
+		}
+	}
+
+	content, err := templateFS.ReadFile("templates/" + name)
 	if err != nil {
-		panic(err)
+		return "", fmt.Errorf("failed to read template: %w", err)
 	}
+	return parseAndExecute(name, string(content), funcMap, data)
+}
 
-	// Get the result as a string
-	result := buf.String()
+func parseAndExecute(name, content string, funcMap template.FuncMap, data interface{}) (string, error) {
+	tmpl, err := template.New(name).Funcs(templatefuncs.FuncMap()).Funcs(funcMap).Parse(content)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template: %w", err)
+	}
 
-	return result, nil
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to execute template: %w", err)
+	}
+	return buf.String(), nil
 }
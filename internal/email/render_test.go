@@ -0,0 +1,235 @@
+package email
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/itemstore"
+	"github.com/bakkerme/ai-news-processor/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderEmail_RisingTopics(t *testing.T) {
+	now := time.Date(2025, time.March, 7, 12, 0, 0, 0, time.UTC)
+	items := []models.Item{{ID: "a", Title: "Qwen 3 released"}}
+	summary := &models.SummaryResponse{
+		KeyDevelopments: []models.KeyDevelopment{{Text: "Qwen 3", ItemID: "a"}},
+		RisingTopics:    []models.RisingTopic{{Term: "qwen", Count: 6, Average: 0.67, ItemIDs: []string{"a", "b"}}},
+	}
+
+	html, err := renderEmail(items, summary, "LocalLLaMA", LookupLocale("de"), now, nil, renderOptions{})
+	require.NoError(t, err)
+	assert.Contains(t, html, "Aufstrebende Themen")
+	assert.Contains(t, html, `<strong>qwen</strong> · 6 Erwähnungen, sonst 0,7 · <a href="#item-t3_a">1</a>, <a href="#item-t3_b">2</a>`)
+
+	summary.RisingTopics = nil
+	html, err = renderEmail(items, summary, "LocalLLaMA", LookupLocale("en"), now, nil, renderOptions{})
+	require.NoError(t, err)
+	assert.NotContains(t, html, "Rising Topics")
+}
+
+func TestRenderEmail_Chips(t *testing.T) {
+	items := []models.Item{
+		{ID: "a", Title: "Qwen 3 released", Entities: []models.Entity{{Name: "Qwen 3", Type: models.EntityModel}}, Topics: []string{"benchmarks"}},
+		{ID: "b", Title: "Untagged"},
+	}
+
+	html, err := renderEmail(items, nil, "LocalLLaMA", LookupLocale("en"), time.Now(), nil, renderOptions{})
+	require.NoError(t, err)
+	assert.Contains(t, html, `<span class="chip chip-model">Qwen 3</span>`)
+	assert.Contains(t, html, `<span class="chip chip-topic">benchmarks</span>`)
+	assert.Equal(t, 1, strings.Count(html, `<div class="chips">`))
+}
+
+func TestRenderRollupEmail(t *testing.T) {
+	now := time.Date(2025, time.March, 7, 12, 0, 0, 0, time.UTC)
+	from := now.AddDate(0, 0, -7)
+	records := []itemstore.Record{
+		{SentAt: now.AddDate(0, 0, -3), Item: models.Item{ID: "a", Title: "New quantization format", Link: "https://example.com/a"}},
+		{SentAt: now.AddDate(0, 0, -1), Item: models.Item{ID: "b", Title: "Llama release", Link: "https://example.com/b"}},
+	}
+	rollup := &models.RollupResponse{
+		Overview: "A busy week for local models.",
+		Themes: []models.RollupTheme{
+			{Title: "Smaller models", Summary: "Quantization keeps improving.", ItemIDs: []string{"a", "missing"}},
+		},
+		Trends: []string{"Open weights are catching up."},
+	}
+
+	html, err := renderRollupEmail(records, rollup, "LocalLLaMA", LookupLocale("en"), from, now, "")
+	require.NoError(t, err)
+
+	assert.Contains(t, html, "LocalLLaMA: Week in Review")
+	assert.Contains(t, html, "Friday, February 28, 2025 – Friday, March 7, 2025")
+	assert.Contains(t, html, "A busy week for local models.")
+	assert.Contains(t, html, `<div class="source"><a href="https://example.com/a">New quantization format</a></div>`)
+	assert.NotContains(t, html, "missing")
+	assert.Contains(t, html, "Open weights are catching up.")
+	assert.Contains(t, html, `<a href="https://example.com/b">Llama release</a>`)
+
+	html, err = renderRollupEmail(records, &models.RollupResponse{}, "LocalLLaMA", LookupLocale("de"), from, now, "")
+	require.NoError(t, err)
+	assert.Contains(t, html, "LocalLLaMA: Wochenrückblick")
+	assert.Contains(t, html, "Alle Beiträge")
+	assert.NotContains(t, html, "Themen")
+}
+
+type stubLinker struct{}
+
+func (stubLinker) FeedbackURL(personaName string, itemID string, relevant bool) string {
+	return fmt.Sprintf("https://news.example.com/feedback?p=%s&i=%s&up=%t", personaName, itemID, relevant)
+}
+
+func TestRenderEmail_FeedbackLinks(t *testing.T) {
+	items := []models.Item{{ID: "abc", Title: "Item", Link: "https://example.com", IsRelevant: true}}
+
+	html, err := renderEmail(items, nil, "LocalLLaMA", LookupLocale("en"), time.Now(), stubLinker{}, renderOptions{})
+	require.NoError(t, err)
+	assert.Contains(t, html, "Was this relevant?")
+	assert.Contains(t, html, "https://news.example.com/feedback?p=LocalLLaMA&i=abc&up=true")
+	assert.Contains(t, html, "https://news.example.com/feedback?p=LocalLLaMA&i=abc&up=false")
+
+	html, err = renderEmail(items, nil, "LocalLLaMA", LookupLocale("en"), time.Now(), nil, renderOptions{})
+	require.NoError(t, err)
+	assert.NotContains(t, html, "Was this relevant?")
+}
+
+func TestRenderEmail_StarterNote(t *testing.T) {
+	items := []models.Item{{ID: "a", Title: "Item"}}
+	summary := &models.SummaryResponse{Starter: true}
+
+	html, err := renderEmail(items, summary, "LocalLLaMA", LookupLocale("en"), time.Now(), nil, renderOptions{})
+	require.NoError(t, err)
+	assert.Contains(t, html, "This is the first LocalLLaMA digest")
+
+	summary.Starter = false
+	html, err = renderEmail(items, summary, "LocalLLaMA", LookupLocale("en"), time.Now(), nil, renderOptions{})
+	require.NoError(t, err)
+	assert.NotContains(t, html, `<div class="starter-note">`)
+}
+
+func TestRenderEmail_DegradedNotice(t *testing.T) {
+	items := []models.Item{{ID: "a", Title: "Item"}}
+	summary := &models.SummaryResponse{Degraded: []string{"links"}}
+
+	html, err := renderEmail(items, summary, "LocalLLaMA", LookupLocale("en"), time.Now(), nil, renderOptions{})
+	require.NoError(t, err)
+	assert.Contains(t, html, "Linked articles were not summarized")
+	assert.NotContains(t, html, "Images were not described")
+
+	summary.Degraded = []string{"images", "links"}
+	html, err = renderEmail(items, summary, "LocalLLaMA", LookupLocale("de"), time.Now(), nil, renderOptions{})
+	require.NoError(t, err)
+	assert.Contains(t, html, "weil das Bildmodell nicht erreichbar war")
+	assert.Contains(t, html, "nicht abgerufen werden konnten")
+}
+
+func TestRenderEmail_WatchlistItem(t *testing.T) {
+	items := []models.Item{
+		{ID: "a", Title: "Qwen 3 rumours", Watchlist: []string{"Qwen", "Alibaba"}},
+		{ID: "b", Title: "Unrelated"},
+	}
+
+	html, err := renderEmail(items, nil, "LocalLLaMA", LookupLocale("en"), time.Now(), nil, renderOptions{})
+	require.NoError(t, err)
+	assert.Contains(t, html, `<span class="chip chip-watchlist">Watchlist: Qwen and Alibaba</span>`)
+	assert.Equal(t, 1, strings.Count(html, `class="chip chip-watchlist"`))
+}
+
+func TestRenderEmail_Claims(t *testing.T) {
+	items := []models.Item{
+		{ID: "a", Title: "Llama 4", Summary: "Llama 4 is out", Claims: []models.Claim{
+			{Claim: "Llama 4 scores 81 on MMLU", Source: models.ClaimSourceLink, Quote: "81.2 on MMLU", URL: "https://example.com/card"},
+			{Claim: "It runs at 12 tok/s on a 3090", Source: models.ClaimSourceComment},
+		}},
+		{ID: "b", Title: "No claims"},
+	}
+
+	html, err := renderEmail(items, nil, "LocalLLaMA", LookupLocale("en"), time.Now(), nil, renderOptions{})
+	require.NoError(t, err)
+	assert.Contains(t, html, "Claims and sources")
+	assert.Contains(t, html, `<li>Llama 4 scores 81 on MMLU <span class="claim-source">(<a href="https://example.com/card">a linked page</a>: “81.2 on MMLU”)</span></li>`)
+	assert.Contains(t, html, `<li>It runs at 12 tok/s on a 3090 <span class="claim-source">(a comment)</span></li>`)
+	assert.Equal(t, 1, strings.Count(html, `<div class="claims">`))
+}
+
+func TestRenderEmail_Fields(t *testing.T) {
+	items := []models.Item{
+		{ID: "a", Title: "Qwen 3", Summary: "Qwen 3 is out", Fields: []models.Field{
+			{Name: "license", Label: "License", Value: "Apache-2.0"},
+			{Name: "openWeights", Label: "Open weights", Value: true},
+			{Name: "benchmarkNumbers", Label: "Benchmarks", Value: []string{"MMLU 81.2", "GSM8K 92"}},
+		}},
+		{ID: "b", Title: "No fields"},
+	}
+
+	html, err := renderEmail(items, nil, "LocalLLaMA", LookupLocale("en"), time.Now(), nil, renderOptions{})
+	require.NoError(t, err)
+	assert.Contains(t, html, "<tr><th>License</th><td>Apache-2.0</td></tr>")
+	assert.Contains(t, html, "<tr><th>Open weights</th><td>Yes</td></tr>")
+	assert.Contains(t, html, "<tr><th>Benchmarks</th><td>MMLU 81.2, GSM8K 92</td></tr>")
+	assert.Equal(t, 1, strings.Count(html, `<table class="fields"`))
+}
+
+func TestRenderEmail_UnavailableItem(t *testing.T) {
+	items := []models.Item{
+		{ID: "ok", Title: "Processed", Summary: "A real summary", IsRelevant: true},
+		{ID: "failed", Title: "Big launch", Link: "https://example.com/launch", IsRelevant: true, Unavailable: true, Summary: "should not render"},
+	}
+
+	html, err := renderEmail(items, nil, "LocalLLaMA", LookupLocale("de"), time.Now(), nil, renderOptions{})
+	require.NoError(t, err)
+	assert.Contains(t, html, "Big launch")
+	assert.Contains(t, html, "https://example.com/launch")
+	assert.Contains(t, html, LookupLocale("de").ItemUnavailable)
+	assert.Contains(t, html, "A real summary")
+	assert.NotContains(t, html, "should not render")
+}
+
+func TestRenderEmail_TemplateOverride(t *testing.T) {
+	dir := t.TempDir()
+	items := []models.Item{{ID: "a", Title: "Qwen 3 released"}}
+	override := filepath.Join(dir, "email_template.tmpl")
+	require.NoError(t, os.WriteFile(override, []byte(`{{range .Items}}<h1>{{.Title | truncate 6}}</h1>{{end}}{{formatDate .Date}}`), 0644))
+
+	html, err := renderEmail(items, nil, "LocalLLaMA", LookupLocale("en"), time.Now(), nil, renderOptions{TemplateDir: dir})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(html, "<h1>Qwen…</h1>"))
+
+	// Edits apply to the next render
+	require.NoError(t, os.WriteFile(override, []byte(`{{.PersonaName}} digest`), 0644))
+	html, err = renderEmail(items, nil, "LocalLLaMA", LookupLocale("en"), time.Now(), nil, renderOptions{TemplateDir: dir})
+	require.NoError(t, err)
+	assert.Equal(t, "LocalLLaMA digest", html)
+
+	// Broken and missing overrides fall back to the built-in templates
+	require.NoError(t, os.WriteFile(override, []byte(`{{.Missing`), 0644))
+	html, err = renderEmail(items, nil, "LocalLLaMA", LookupLocale("en"), time.Now(), nil, renderOptions{TemplateDir: dir})
+	require.NoError(t, err)
+	assert.Contains(t, html, "Qwen 3 released")
+
+	html, err = renderRollupEmail(nil, &models.RollupResponse{}, "LocalLLaMA", LookupLocale("en"), time.Now(), time.Now(), dir)
+	require.NoError(t, err)
+	assert.Contains(t, html, "Week in Review")
+}
+
+func TestRenderEmail_ResponsiveDarkMode(t *testing.T) {
+	items := []models.Item{
+		{ID: "a", Title: "Qwen 3 released", CommentSummary: "Commenters like the small models."},
+		{ID: "b", Title: "No comments"},
+	}
+
+	html, err := renderEmail(items, nil, "LocalLLaMA", LookupLocale("nl"), time.Now(), nil, renderOptions{})
+	require.NoError(t, err)
+	assert.Contains(t, html, `<meta name="color-scheme" content="light dark">`)
+	assert.Contains(t, html, "@media (prefers-color-scheme: dark)")
+	assert.Contains(t, html, "@media only screen and (max-width: 600px)")
+	assert.Equal(t, 1, strings.Count(html, `<details class="discussion">`), "only items with a comment summary get one")
+	assert.Contains(t, html, "<summary>Wat reageerders zeggen</summary>")
+	assert.Contains(t, html, "Commenters like the small models.")
+}
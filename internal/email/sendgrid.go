@@ -0,0 +1,114 @@
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+)
+
+// sendGridEndpoint is the SendGrid v3 mail send API
+const sendGridEndpoint = "https://api.sendgrid.com/v3/mail/send"
+
+// SendGridClient sends email through the SendGrid API, for deployments without an SMTP relay
+type SendGridClient struct {
+	apiKey   string
+	sender   *mail.Address
+	options  Options
+	client   *http.Client
+	endpoint string
+}
+
+// NewSendGrid creates a SendGrid client. sender may include a display name, as in
+// "News <news@example.com>". Only the timeout and retry options apply.
+func NewSendGrid(apiKey, sender string, options Options) (*SendGridClient, error) {
+	if apiKey == "" || sender == "" {
+		return nil, errors.New("SendGrid API key and sender are required")
+	}
+	from, err := mail.ParseAddress(sender)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender address: %w", err)
+	}
+	return &SendGridClient{
+		apiKey:   apiKey,
+		sender:   from,
+		options:  options,
+		client:   newAPIClient(options.Timeout),
+		endpoint: sendGridEndpoint,
+	}, nil
+}
+
+type sendGridAddress struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+type sendGridPersonalization struct {
+	To []sendGridAddress `json:"to"`
+}
+
+type sendGridContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type sendGridAttachment struct {
+	Content     string `json:"content"`
+	Type        string `json:"type"`
+	Filename    string `json:"filename"`
+	Disposition string `json:"disposition"`
+	ContentID   string `json:"content_id"`
+}
+
+type sendGridMessage struct {
+	Personalizations []sendGridPersonalization `json:"personalizations"`
+	From             sendGridAddress           `json:"from"`
+	Subject          string                    `json:"subject"`
+	Content          []sendGridContent         `json:"content"`
+	Attachments      []sendGridAttachment      `json:"attachments,omitempty"`
+}
+
+// Send sends an HTML email to the specified recipient
+func (c *SendGridClient) Send(recipient string, subject string, htmlContent string) error {
+	return c.SendWithImages(recipient, subject, htmlContent, nil)
+}
+
+// SendWithImages sends an HTML email with inline images referenced from the HTML by content ID
+func (c *SendGridClient) SendWithImages(recipient string, subject string, htmlContent string, images []InlineImage) error {
+	if recipient == "" {
+		return errors.New("recipient email cannot be empty")
+	}
+
+	message := sendGridMessage{
+		Personalizations: []sendGridPersonalization{{To: []sendGridAddress{{Email: recipient}}}},
+		From:             sendGridAddress{Email: c.sender.Address, Name: c.sender.Name},
+		Subject:          subject,
+		Content:          []sendGridContent{{Type: "text/html", Value: htmlContent}},
+	}
+	for _, image := range images {
+		message.Attachments = append(message.Attachments, sendGridAttachment{
+			Content:     base64.StdEncoding.EncodeToString(image.Data),
+			Type:        image.ContentType,
+			Filename:    image.ContentID,
+			Disposition: "inline",
+			ContentID:   image.ContentID,
+		})
+	}
+	body, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to encode SendGrid message: %w", err)
+	}
+
+	return postWithRetry(c.client, c.options.Retry, func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodPost, c.endpoint, bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create SendGrid request: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
+}
@@ -1,54 +1,169 @@
 package email
 
 import (
+	"errors"
 	"fmt"
+	"html"
 	"log"
 	"os"
 	"time"
 
+	"github.com/bakkerme/ai-news-processor/internal/itemstore"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
 	"github.com/bakkerme/ai-news-processor/internal/specification"
 	"github.com/bakkerme/ai-news-processor/models"
 )
 
 // Service handles email rendering and delivery
 type Service struct {
-	emailer *Client
-	config  *specification.Specification
+	emailer  EmailSender
+	config   *specification.Specification
+	feedback FeedbackLinker
+	links    LinkChecker
+	// downloadImage fetches thumbnails to embed, overridden in tests
+	downloadImage ImageDownloader
 }
 
-// NewService creates a new email service
+// NewService creates a new email service sending through the configured transport
 func NewService(config *specification.Specification) (*Service, error) {
-	emailer, err := New(
-		config.EmailHost,
-		config.EmailPort,
-		config.EmailUsername,
-		config.EmailPassword,
-		config.EmailFrom,
-	)
+	options := DefaultOptions
+	options.TLS = config.EmailTLS
+	options.Auth = config.EmailAuth
+	options.Timeout = time.Duration(config.EmailTimeoutSeconds) * time.Second
+	options.Retry.MaxRetries = config.EmailRetries
+
+	var emailer EmailSender
+	var err error
+	switch config.EmailTransport {
+	case "sendgrid":
+		emailer, err = NewSendGrid(config.SendGridApiKey, config.EmailFrom, options)
+	case "ses":
+		emailer, err = NewSES(config.SesRegion, config.SesAccessKeyID, config.SesSecretAccessKey, config.SesSessionToken, config.EmailFrom, options)
+	default:
+		emailer, err = NewWithOptions(
+			config.EmailHost,
+			config.EmailPort,
+			config.EmailUsername,
+			config.EmailPassword,
+			config.EmailFrom,
+			options,
+		)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("could not set up emailer: %w", err)
 	}
 
 	return &Service{
-		emailer: emailer,
-		config:  config,
+		emailer:       emailer,
+		config:        config,
+		downloadImage: DownloadImage,
 	}, nil
 }
 
-// RenderAndSend handles rendering and sending an email with the specified items and summary
-func (s *Service) RenderAndSend(items []models.Item, summary *models.SummaryResponse, personaName string) error {
-	email, err := RenderEmail(items, summary, personaName)
+// Ping checks that the mail server accepts connections. Transports that send through an HTTP API
+// have no connection to check and always succeed.
+func (s *Service) Ping() error {
+	if pinger, ok := s.emailer.(interface{ Ping() error }); ok {
+		return pinger.Ping()
+	}
+	return nil
+}
+
+// SetFeedbackLinks adds reader feedback links to every item of the digests sent from now on
+func (s *Service) SetFeedbackLinks(feedback FeedbackLinker) {
+	s.feedback = feedback
+}
+
+// SetLinkChecker checks the links and thumbnails of every digest sent from now on just before it
+// is rendered
+func (s *Service) SetLinkChecker(links LinkChecker) {
+	s.links = links
+}
+
+// RenderAndSend renders the digest of a persona and sends it to each of the persona's recipients,
+// in the persona's locale and with its subject line
+func (s *Service) RenderAndSend(items []models.Item, summary *models.SummaryResponse, p persona.Persona) error {
+	loc := LookupLocale(p.Locale)
+	if s.config.FailedItemNote != "" {
+		loc.ItemUnavailable = s.config.FailedItemNote
+	}
+
+	if s.links != nil {
+		items = checkLinks(items, s.links)
+	}
+
+	// Thumbnails are only embedded when actually sending, so debug emails on disk keep working links
+	inline, canInline := s.emailer.(InlineSender)
+	var images []InlineImage
+	if s.config.EmailInlineImages && canInline && !s.config.DebugSkipEmail {
+		items, images = embedThumbnails(items, s.downloadImage, s.config.EmailInlineImageMaxKB*1024)
+	}
+
+	now := time.Now()
+	email, err := renderEmail(items, summary, p.Name, loc, now, s.feedback, renderOptions{TemplateDir: p.TemplateDir, Layout: LayoutFor(p)})
 	if err != nil {
 		return fmt.Errorf("could not render email: %w", err)
 	}
 
-	if !s.config.DebugSkipEmail {
-		log.Printf("Sending email to %s\n", s.config.EmailTo)
-		return s.emailer.Send(s.config.EmailTo, fmt.Sprintf("%s News", personaName), email)
+	if s.config.DebugSkipEmail {
+		// If in debug mode, write to disk instead
+		return writeEmailToDisk(email)
 	}
 
-	// If in debug mode, write to disk instead
-	return writeEmailToDisk(email)
+	subject, err := Subject(p, loc, len(items), now)
+	if err != nil {
+		return err
+	}
+	return s.sendToRecipients(p, func(recipient string) error {
+		if len(images) > 0 {
+			return inline.SendWithImages(recipient, subject, email, images)
+		}
+		return s.emailer.Send(recipient, subject, email)
+	})
+}
+
+// RenderAndSendRollup renders and sends the review email for the items sent to a persona since from
+func (s *Service) RenderAndSendRollup(records []itemstore.Record, rollup *models.RollupResponse, p persona.Persona, from time.Time) error {
+	email, err := renderRollupEmail(records, rollup, p.Name, LookupLocale(p.Locale), from, time.Now(), p.TemplateDir)
+	if err != nil {
+		return fmt.Errorf("could not render rollup email: %w", err)
+	}
+
+	if s.config.DebugSkipEmail {
+		return writeEmailToDisk(email)
+	}
+
+	subject := fmt.Sprintf(LookupLocale(p.Locale).RollupTitle, p.Name)
+	return s.sendToRecipients(p, func(recipient string) error {
+		return s.emailer.Send(recipient, subject, email)
+	})
+}
+
+// sendToRecipients sends a separate email to each recipient of a persona, so recipients of a
+// mailing list do not see each other's addresses. Every recipient is tried even if one fails.
+func (s *Service) sendToRecipients(p persona.Persona, send func(recipient string) error) error {
+	recipients := s.Recipients(p)
+	if len(recipients) == 0 {
+		return fmt.Errorf("no recipients for persona %s, set recipients or ANP_EMAIL_TO", p.Name)
+	}
+	var errs []error
+	for _, recipient := range recipients {
+		log.Printf("Sending email to %s\n", recipient)
+		if err := send(recipient); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", recipient, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SendOperatorReport sends a plain text run report to the operator. In debug mode the report is
+// only logged by the caller and nothing is sent.
+func (s *Service) SendOperatorReport(recipient string, subject string, report string) error {
+	if s.config.DebugSkipEmail {
+		return nil
+	}
+	log.Printf("Sending operator report to %s\n", recipient)
+	return s.emailer.Send(recipient, subject, "<pre>"+html.EscapeString(report)+"</pre>")
 }
 
 // writeEmailToDisk writes the email content to a file for debugging
@@ -0,0 +1,114 @@
+package email
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/http/awsauth"
+)
+
+// SESClient sends email through the Amazon SES v2 API, for deployments without an SMTP relay
+type SESClient struct {
+	region          string
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+	sender          string
+	options         Options
+	client          *http.Client
+	endpoint        string
+	now             func() time.Time
+}
+
+// NewSES creates an SES client for the given region. sessionToken is only needed for temporary
+// credentials. Only the timeout and retry options apply.
+func NewSES(region, accessKeyID, secretAccessKey, sessionToken, sender string, options Options) (*SESClient, error) {
+	if region == "" || accessKeyID == "" || secretAccessKey == "" || sender == "" {
+		return nil, errors.New("SES region, access key ID, secret access key and sender are required")
+	}
+	return &SESClient{
+		region:          region,
+		accessKeyID:     accessKeyID,
+		secretAccessKey: secretAccessKey,
+		sessionToken:    sessionToken,
+		sender:          sender,
+		options:         options,
+		client:          newAPIClient(options.Timeout),
+		endpoint:        fmt.Sprintf("https://email.%s.amazonaws.com/v2/email/outbound-emails", region),
+		now:             time.Now,
+	}, nil
+}
+
+type sesContent struct {
+	Data    string `json:"Data"`
+	Charset string `json:"Charset"`
+}
+
+type sesSimple struct {
+	Subject sesContent `json:"Subject"`
+	Body    struct {
+		Html sesContent `json:"Html"`
+	} `json:"Body"`
+}
+
+// sesRaw is a complete MIME message, used when the email has inline images
+type sesRaw struct {
+	Data []byte `json:"Data"`
+}
+
+type sesMessage struct {
+	FromEmailAddress string `json:"FromEmailAddress"`
+	Destination      struct {
+		ToAddresses []string `json:"ToAddresses"`
+	} `json:"Destination"`
+	Content struct {
+		Simple *sesSimple `json:"Simple,omitempty"`
+		Raw    *sesRaw    `json:"Raw,omitempty"`
+	} `json:"Content"`
+}
+
+// Send sends an HTML email to the specified recipient
+func (c *SESClient) Send(recipient string, subject string, htmlContent string) error {
+	return c.SendWithImages(recipient, subject, htmlContent, nil)
+}
+
+// SendWithImages sends an HTML email with inline images. SES only accepts attachments in raw
+// MIME messages, so those are used when there are images.
+func (c *SESClient) SendWithImages(recipient string, subject string, htmlContent string, images []InlineImage) error {
+	if recipient == "" {
+		return errors.New("recipient email cannot be empty")
+	}
+
+	var message sesMessage
+	message.FromEmailAddress = c.sender
+	message.Destination.ToAddresses = []string{recipient}
+	if len(images) > 0 {
+		message.Content.Raw = &sesRaw{Data: buildMessage(c.sender, recipient, subject, htmlContent, images)}
+	} else {
+		simple := &sesSimple{Subject: sesContent{Data: subject, Charset: "UTF-8"}}
+		simple.Body.Html = sesContent{Data: htmlContent, Charset: "UTF-8"}
+		message.Content.Simple = simple
+	}
+
+	body, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to encode SES message: %w", err)
+	}
+
+	return postWithRetry(c.client, c.options.Retry, func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodPost, c.endpoint, bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create SES request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if c.sessionToken != "" {
+			req.Header.Set("X-Amz-Security-Token", c.sessionToken)
+		}
+		awsauth.SignV4(req, body, c.accessKeyID, c.secretAccessKey, c.region, "ses", c.now())
+		return req, nil
+	})
+}
@@ -0,0 +1,284 @@
+// Package experiments compares two variants of the entry prompt, model or persona on the same
+// captured entries. Each entry is processed by both variants, and a judge model picks the better
+// output of each pair, so prompt changes can be measured by their win rate.
+package experiments
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/bakkerme/ai-news-processor/internal/llm"
+	"github.com/bakkerme/ai-news-processor/internal/openai"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/internal/prompts"
+	"github.com/bakkerme/ai-news-processor/models"
+)
+
+// Judge preferences
+const (
+	PreferA   = "A"
+	PreferB   = "B"
+	PreferTie = "tie"
+)
+
+// Variant is one side of an experiment: a model and the system prompt it is given
+type Variant struct {
+	Name         string
+	Client       openai.OpenAIClient
+	SystemPrompt string
+	Prompt       models.PromptVersion // Template the system prompt was rendered from
+}
+
+// NewVariant composes the system prompt for a variant from a persona and, if templateText is not
+// empty, a replacement for the base prompt template
+func NewVariant(name string, client openai.OpenAIClient, p persona.Persona, templateText string) (Variant, error) {
+	var systemPrompt string
+	var version models.PromptVersion
+	var err error
+	if templateText != "" {
+		systemPrompt, err = prompts.ComposePromptFromTemplate(p, "", templateText)
+		version = prompts.CustomTemplateVersion(templateText)
+	} else {
+		systemPrompt, err = prompts.ComposePrompt(p, "")
+		version, _ = prompts.ActiveTemplateVersion(prompts.BaseTemplate)
+	}
+	if err != nil {
+		return Variant{}, fmt.Errorf("could not compose prompt for variant %s: %w", name, err)
+	}
+	return Variant{Name: name, Client: client, SystemPrompt: systemPrompt, Prompt: version}, nil
+}
+
+// Pair holds the outputs of both variants for one entry and the judge's preference
+type Pair struct {
+	EntryID    string      `json:"entryId"`
+	Title      string      `json:"title"`
+	A          models.Item `json:"a"`
+	B          models.Item `json:"b"`
+	AError     string      `json:"aError,omitempty"`
+	BError     string      `json:"bError,omitempty"`
+	Preference string      `json:"preference,omitempty"` // One of the Prefer* constants; empty if the pair was not judged
+	Reason     string      `json:"reason,omitempty"`
+	JudgeError string      `json:"judgeError,omitempty"`
+}
+
+// Report is the outcome of an experiment
+type Report struct {
+	Persona  string               `json:"persona"`
+	VariantA string               `json:"variantA"`
+	VariantB string               `json:"variantB"`
+	PromptA  models.PromptVersion `json:"promptA"` // Template of variant A's system prompt
+	PromptB  models.PromptVersion `json:"promptB"` // Template of variant B's system prompt
+	Judge    string               `json:"judge"`
+	Pairs    []Pair               `json:"pairs"`
+	WinsA    int                  `json:"winsA"`
+	WinsB    int                  `json:"winsB"`
+	Ties     int                  `json:"ties"`
+	Failed   int                  `json:"failed"`   // Pairs missing an output or a preference
+	WinRateA float64              `json:"winRateA"` // Share of judged pairs won by A
+	WinRateB float64              `json:"winRateB"` // Share of judged pairs won by B
+}
+
+// Run processes every captured entry of the run data with both variants and asks the judge which
+// output is better. Up to workers entries are handled at once.
+func Run(data *models.RunData, a, b Variant, judge openai.OpenAIClient, judgeName string, workers int) (*Report, error) {
+	judgePrompt, err := prompts.ComposePairwisePrompt(data.Persona, data.JudgeInstructions)
+	if err != nil {
+		return nil, fmt.Errorf("could not create pairwise prompt: %w", err)
+	}
+
+	var entries []models.EntrySummary
+	for _, entry := range data.EntrySummaries {
+		if entry.RawInput != "" && !entry.Results.Unavailable {
+			entries = append(entries, entry)
+		}
+	}
+
+	// Each entry writes to its own slot so the report keeps entry order
+	pairs := make([]Pair, len(entries))
+	sem := make(chan struct{}, max(workers, 1))
+	var wg sync.WaitGroup
+	for i, entry := range entries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			pairs[i] = runPair(entry, a, b, judge, judgePrompt, i%2 == 1)
+		}()
+	}
+	wg.Wait()
+
+	report := &Report{
+		Persona:  data.Persona.Name,
+		VariantA: a.Name,
+		VariantB: b.Name,
+		PromptA:  a.Prompt,
+		PromptB:  b.Prompt,
+		Judge:    judgeName,
+		Pairs:    pairs,
+	}
+	for _, pair := range pairs {
+		switch pair.Preference {
+		case PreferA:
+			report.WinsA++
+		case PreferB:
+			report.WinsB++
+		case PreferTie:
+			report.Ties++
+		default:
+			report.Failed++
+		}
+	}
+	if judged := report.WinsA + report.WinsB + report.Ties; judged > 0 {
+		report.WinRateA = float64(report.WinsA) / float64(judged)
+		report.WinRateB = float64(report.WinsB) / float64(judged)
+	}
+	return report, nil
+}
+
+// preferenceResponse is the JSON the judge answers a pair with
+type preferenceResponse struct {
+	Preference string `json:"preference"`
+	Reason     string `json:"reason"`
+}
+
+// preferenceSchema is the schema of a preference, requested in the judge client's response format
+var preferenceSchema = &openai.SchemaParameters{
+	Schema:      llm.GenerateSchema[preferenceResponse](),
+	Name:        "preference",
+	Description: "which of two outputs for a post is better",
+}
+
+// runPair processes one entry with both variants and judges the outputs. When swap is set the
+// outputs are shown to the judge in reverse order, so position bias cancels out over the run.
+func runPair(entry models.EntrySummary, a, b Variant, judge openai.OpenAIClient, judgePrompt string, swap bool) Pair {
+	pair := Pair{EntryID: entry.Results.ID, Title: entry.Results.Title}
+
+	var err error
+	if pair.A, err = llm.ProcessRawEntry(a.Client, a.SystemPrompt, entry.RawInput); err != nil {
+		pair.AError = err.Error()
+	}
+	if pair.B, err = llm.ProcessRawEntry(b.Client, b.SystemPrompt, entry.RawInput); err != nil {
+		pair.BError = err.Error()
+	}
+	if pair.AError != "" || pair.BError != "" {
+		log.Printf("Skipping judgement of entry %s: a variant failed", pair.EntryID)
+		return pair
+	}
+
+	first, second := pair.A, pair.B
+	if swap {
+		first, second = second, first
+	}
+
+	result := judge.ChatCompletion(
+		judgePrompt,
+		[]string{formatPair(entry.RawInput, first, second)},
+		[]string{},
+		preferenceSchema,
+		0.0, // temperature, judges should be as consistent as possible
+		0,   // max tokens (0 means no limit - needed for complete JSON generation)
+	)
+	if result.Err != nil {
+		pair.JudgeError = result.Err.Error()
+		return pair
+	}
+
+	var response preferenceResponse
+	if err := json.Unmarshal([]byte(judge.PreprocessJSON(result.Content)), &response); err != nil {
+		pair.JudgeError = fmt.Sprintf("could not parse preference: %v", err)
+		return pair
+	}
+
+	switch strings.ToUpper(strings.TrimSpace(response.Preference)) {
+	case "TIE":
+		pair.Preference = PreferTie
+	case "A":
+		pair.Preference = PreferA
+		if swap {
+			pair.Preference = PreferB
+		}
+	case "B":
+		pair.Preference = PreferB
+		if swap {
+			pair.Preference = PreferA
+		}
+	default:
+		pair.JudgeError = fmt.Sprintf("unknown preference %q", response.Preference)
+		return pair
+	}
+	pair.Reason = response.Reason
+	return pair
+}
+
+// formatPair combines the original post and two outputs for the judge
+func formatPair(rawInput string, first, second models.Item) string {
+	var input strings.Builder
+	fmt.Fprintf(&input, "Original post:\n%s\n", rawInput)
+	for _, output := range []struct {
+		label string
+		item  models.Item
+	}{{"A", first}, {"B", second}} {
+		fmt.Fprintf(&input, "\nOutput %s:\n", output.label)
+		fmt.Fprintf(&input, "Summary: %s\n", output.item.Summary)
+		if output.item.CommentSummary != "" {
+			fmt.Fprintf(&input, "Comment Summary: %s\n", output.item.CommentSummary)
+		}
+		fmt.Fprintf(&input, "Relevant: %t\n", output.item.IsRelevant)
+		if output.item.RelevanceToCriteria != "" {
+			fmt.Fprintf(&input, "Relevance Explanation: %s\n", output.item.RelevanceToCriteria)
+		}
+	}
+	return input.String()
+}
+
+// WriteReport writes a report as indented JSON
+func WriteReport(path string, report *Report) error {
+	jsonData, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal report: %w", err)
+	}
+	if err := os.WriteFile(path, jsonData, 0644); err != nil {
+		return fmt.Errorf("error writing report: %w", err)
+	}
+	return nil
+}
+
+// PrintReport writes the win rates of an experiment and the pairs where the variants disagreed on
+// relevance
+func PrintReport(w io.Writer, report *Report) {
+	fmt.Fprintf(w, "%s vs %s on %d entries for persona %s, judged by %s\n\n",
+		report.VariantA, report.VariantB, len(report.Pairs), report.Persona, report.Judge)
+	fmt.Fprintf(w, "%s wins: %d (%.0f%%)\n", report.VariantA, report.WinsA, report.WinRateA*100)
+	fmt.Fprintf(w, "%s wins: %d (%.0f%%)\n", report.VariantB, report.WinsB, report.WinRateB*100)
+	fmt.Fprintf(w, "Ties: %d\n", report.Ties)
+	if report.Failed > 0 {
+		fmt.Fprintf(w, "Failed: %d\n", report.Failed)
+	}
+
+	for _, pair := range report.Pairs {
+		if pair.Preference == "" || pair.A.IsRelevant == pair.B.IsRelevant {
+			continue
+		}
+		winner := pair.Preference
+		switch pair.Preference {
+		case PreferA:
+			winner = report.VariantA
+		case PreferB:
+			winner = report.VariantB
+		}
+		fmt.Fprintf(w, "\nRelevance differs: %s (%s)\n  %s relevant=%t, %s relevant=%t, preferred: %s",
+			pair.Title, pair.EntryID, report.VariantA, pair.A.IsRelevant, report.VariantB, pair.B.IsRelevant, winner)
+		if pair.Reason != "" {
+			fmt.Fprintf(w, " (%s)", pair.Reason)
+		}
+		fmt.Fprintln(w)
+	}
+}
@@ -0,0 +1,136 @@
+package experiments
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bakkerme/ai-news-processor/internal/http/retry"
+	"github.com/bakkerme/ai-news-processor/internal/openai"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubClient answers every request with the result of respond
+type stubClient struct {
+	respond func(systemPrompt, userPrompt string) (string, error)
+}
+
+func (c *stubClient) ChatCompletion(systemPrompt string, userPrompts []string, imageURLs []string, schemaParams *openai.SchemaParameters, temperature float64, maxTokens int) openai.Result {
+	value, err := c.respond(systemPrompt, userPrompts[0])
+	return openai.Result{Content: value, Err: err}
+}
+func (c *stubClient) SetRetryConfig(config retry.RetryConfig) {}
+func (c *stubClient) PreprocessYAML(response string) string   { return response }
+func (c *stubClient) PreprocessJSON(response string) string   { return response }
+func (c *stubClient) GetModelName() string                    { return "stub" }
+
+// variantClient answers with an item whose summary is the given text, failing for raw input "broken"
+func variantClient(summary string, relevant bool) *stubClient {
+	return &stubClient{respond: func(systemPrompt, userPrompt string) (string, error) {
+		if userPrompt == "broken" {
+			return "", errors.New("timeout")
+		}
+		if relevant {
+			return `{"title":"t","summary":"` + summary + `","isRelevant":true}`, nil
+		}
+		return `{"title":"t","summary":"` + summary + `","isRelevant":false}`, nil
+	}}
+}
+
+func testRunData() *models.RunData {
+	return &models.RunData{
+		Persona: persona.Persona{Name: "LocalLLaMa", PersonaIdentity: "an AI researcher"},
+		EntrySummaries: []models.EntrySummary{
+			{RawInput: "first post", Results: models.Item{ID: "1", Title: "First"}},
+			{RawInput: "second post", Results: models.Item{ID: "2", Title: "Second"}},
+			{RawInput: "third post", Results: models.Item{ID: "3", Title: "Third"}},
+			{RawInput: "fourth post", Results: models.Item{ID: "4", Title: "Fourth"}},
+			{RawInput: "", Results: models.Item{ID: "5", Title: "Not captured"}},
+		},
+	}
+}
+
+func TestRun(t *testing.T) {
+	a := Variant{Name: "baseline", Client: variantClient("plain", true), SystemPrompt: "prompt a"}
+	b := Variant{Name: "candidate", Client: variantClient("detailed", false), SystemPrompt: "prompt b"}
+
+	// The judge prefers the detailed summary wherever it is shown
+	judge := &stubClient{respond: func(systemPrompt, userPrompt string) (string, error) {
+		outputA := userPrompt[strings.Index(userPrompt, "Output A:"):strings.Index(userPrompt, "Output B:")]
+		if strings.Contains(outputA, "detailed") {
+			return `{"preference":"A","reason":"more detail"}`, nil
+		}
+		return `{"preference":"B","reason":"more detail"}`, nil
+	}}
+
+	report, err := Run(testRunData(), a, b, judge, "judge-model", 2)
+	require.NoError(t, err)
+
+	require.Len(t, report.Pairs, 4, "entries without raw input are skipped")
+	assert.Equal(t, 4, report.WinsB, "preferences are mapped back when the order is swapped")
+	assert.Zero(t, report.WinsA)
+	assert.Equal(t, 1.0, report.WinRateB)
+	assert.Equal(t, "plain", report.Pairs[0].A.Summary)
+	assert.Equal(t, "detailed", report.Pairs[0].B.Summary)
+	assert.Equal(t, "First", report.Pairs[0].Title)
+
+	var out bytes.Buffer
+	PrintReport(&out, report)
+	assert.Contains(t, out.String(), "candidate wins: 4 (100%)")
+	assert.Contains(t, out.String(), "Relevance differs: First (1)")
+	assert.Contains(t, out.String(), "preferred: candidate (more detail)")
+}
+
+func TestRun_PositionBias(t *testing.T) {
+	a := Variant{Name: "a", Client: variantClient("same", true)}
+	b := Variant{Name: "b", Client: variantClient("same", true)}
+	alwaysFirst := &stubClient{respond: func(systemPrompt, userPrompt string) (string, error) {
+		return `{"preference":"A"}`, nil
+	}}
+
+	report, err := Run(testRunData(), a, b, alwaysFirst, "judge", 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.WinsA, "alternating the order cancels a judge that always picks the first output")
+	assert.Equal(t, 2, report.WinsB)
+}
+
+func TestRun_Failures(t *testing.T) {
+	data := testRunData()
+	data.EntrySummaries[0].RawInput = "broken"
+
+	a := Variant{Name: "a", Client: variantClient("x", true)}
+	b := Variant{Name: "b", Client: variantClient("y", true)}
+	judge := &stubClient{respond: func(systemPrompt, userPrompt string) (string, error) {
+		if strings.Contains(userPrompt, "second post") {
+			return `{"preference":"neither"}`, nil
+		}
+		return `{"preference":"tie"}`, nil
+	}}
+
+	report, err := Run(data, a, b, judge, "judge", 4)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Failed)
+	assert.Equal(t, 2, report.Ties)
+	assert.NotEmpty(t, report.Pairs[0].AError)
+	assert.Contains(t, report.Pairs[1].JudgeError, "unknown preference")
+	assert.Zero(t, report.WinRateA)
+}
+
+func TestNewVariant(t *testing.T) {
+	p := persona.Persona{Name: "LocalLLaMa", PersonaIdentity: "an AI researcher", Topic: "local LLMs"}
+
+	variant, err := NewVariant("custom", nil, p, "Summarize posts about {{.Topic}} for {{.PersonaIdentity}}")
+	require.NoError(t, err)
+	assert.Equal(t, "Summarize posts about local LLMs for an AI researcher", variant.SystemPrompt)
+
+	variant, err = NewVariant("default", nil, p, "")
+	require.NoError(t, err)
+	assert.Contains(t, variant.SystemPrompt, "an AI researcher")
+
+	_, err = NewVariant("broken", nil, p, "{{.Missing")
+	assert.Error(t, err)
+}
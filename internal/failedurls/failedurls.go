@@ -0,0 +1,154 @@
+package failedurls
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Entry records the failure history of a single URL
+type Entry struct {
+	URL         string    `json:"url"`
+	Failures    int       `json:"failures"`
+	LastError   string    `json:"lastError"`
+	FirstFailed time.Time `json:"firstFailed"`
+	LastFailed  time.Time `json:"lastFailed"`
+	SkipUntil   time.Time `json:"skipUntil,omitempty"`
+}
+
+// Store tracks URLs that consistently fail fetching or extraction so they can be skipped on later runs.
+// Once a URL has failed threshold times it is skipped until its TTL expires, after which it is re-checked once.
+type Store struct {
+	path      string
+	threshold int
+	ttl       time.Duration
+	now       func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*Entry
+}
+
+// Load reads the failed URL store from disk.
+// If the file does not exist, an empty store is returned.
+// A threshold of 0 or less disables skipping, but failures are still recorded.
+func Load(path string, threshold int, ttl time.Duration) (*Store, error) {
+	s := &Store{
+		path:      path,
+		threshold: threshold,
+		ttl:       ttl,
+		now:       time.Now,
+		entries:   make(map[string]*Entry),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("could not read failed url store: %w", err)
+	}
+
+	var list []Entry
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("could not parse failed url store: %w", err)
+	}
+
+	// Drop entries that have not failed for a long time so the store does not grow forever
+	cutoff := s.now().Add(-2 * ttl)
+	for i := range list {
+		if list[i].URL == "" || list[i].LastFailed.Before(cutoff) {
+			continue
+		}
+		s.entries[list[i].URL] = &list[i]
+	}
+
+	return s, nil
+}
+
+// ShouldSkip reports whether the URL has failed often enough that it should not be fetched this run
+func (s *Store) ShouldSkip(u string) bool {
+	if s == nil || s.threshold <= 0 {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[u]
+	if !ok || entry.Failures < s.threshold {
+		return false
+	}
+	return s.now().Before(entry.SkipUntil)
+}
+
+// RecordFailure registers a permanent-looking failure for the URL
+func (s *Store) RecordFailure(u string, reason string) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	entry, ok := s.entries[u]
+	if !ok {
+		entry = &Entry{URL: u, FirstFailed: now}
+		s.entries[u] = entry
+	}
+	entry.Failures++
+	entry.LastError = reason
+	entry.LastFailed = now
+
+	if s.threshold > 0 && entry.Failures >= s.threshold {
+		entry.SkipUntil = now.Add(s.ttl)
+	}
+}
+
+// RecordSuccess clears any failure history for the URL
+func (s *Store) RecordSuccess(u string) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, u)
+}
+
+// Save persists the store to disk as a JSON array
+func (s *Store) Save() error {
+	s.mu.Lock()
+	list := make([]Entry, 0, len(s.entries))
+	for _, entry := range s.entries {
+		list = append(list, *entry)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].URL < list[j].URL
+	})
+
+	payload, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode failed url store: %w", err)
+	}
+
+	dir := filepath.Dir(s.path)
+	if dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("could not create failed url store directory: %w", err)
+		}
+	}
+
+	if err := os.WriteFile(s.path, payload, 0644); err != nil {
+		return fmt.Errorf("could not write failed url store: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package failedurls
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_SkipsAfterThreshold(t *testing.T) {
+	store, err := Load(filepath.Join(t.TempDir(), "failed_urls.json"), 2, time.Hour)
+	require.NoError(t, err)
+
+	const u = "https://example.com/missing"
+
+	store.RecordFailure(u, "http error: status code 404")
+	assert.False(t, store.ShouldSkip(u), "should not skip before reaching the threshold")
+
+	store.RecordFailure(u, "http error: status code 404")
+	assert.True(t, store.ShouldSkip(u), "should skip once the threshold is reached")
+
+	store.RecordSuccess(u)
+	assert.False(t, store.ShouldSkip(u), "a success should clear the failure history")
+}
+
+func TestStore_RechecksAfterTTL(t *testing.T) {
+	store, err := Load(filepath.Join(t.TempDir(), "failed_urls.json"), 1, time.Hour)
+	require.NoError(t, err)
+
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	const u = "https://blocked.example.com/article"
+	store.RecordFailure(u, "http error: status code 403")
+	assert.True(t, store.ShouldSkip(u))
+
+	now = now.Add(61 * time.Minute)
+	assert.False(t, store.ShouldSkip(u), "should re-check once the TTL has expired")
+
+	store.RecordFailure(u, "http error: status code 403")
+	assert.True(t, store.ShouldSkip(u), "a failed re-check should skip for another TTL")
+}
+
+func TestStore_DisabledThreshold(t *testing.T) {
+	store, err := Load(filepath.Join(t.TempDir(), "failed_urls.json"), 0, time.Hour)
+	require.NoError(t, err)
+
+	const u = "https://example.com/missing"
+	for i := 0; i < 5; i++ {
+		store.RecordFailure(u, "http error: status code 404")
+	}
+	assert.False(t, store.ShouldSkip(u))
+}
+
+func TestStore_SaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "failed_urls.json")
+
+	store, err := Load(path, 1, 24*time.Hour)
+	require.NoError(t, err)
+	store.RecordFailure("https://example.com/a", "http error: status code 410")
+	require.NoError(t, store.Save())
+
+	reloaded, err := Load(path, 1, 24*time.Hour)
+	require.NoError(t, err)
+	assert.True(t, reloaded.ShouldSkip("https://example.com/a"))
+	assert.False(t, reloaded.ShouldSkip("https://example.com/b"))
+}
+
+func TestStore_NilIsSafe(t *testing.T) {
+	var store *Store
+	assert.False(t, store.ShouldSkip("https://example.com"))
+	store.RecordFailure("https://example.com", "error")
+	store.RecordSuccess("https://example.com")
+}
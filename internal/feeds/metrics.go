@@ -0,0 +1,159 @@
+package feeds
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MetricsReporter is implemented by feed providers that record metrics about their requests
+type MetricsReporter interface {
+	// Metrics returns a snapshot of the provider's request metrics
+	Metrics() FetchMetricsSnapshot
+}
+
+// FetchMetrics counts the requests, bytes, cache hits and failures of a feed provider.
+// It is safe for concurrent use.
+type FetchMetrics struct {
+	provider string
+
+	mu        sync.Mutex
+	requests  int
+	bytes     int64
+	cacheHits int
+	failures  map[int]int
+}
+
+// FetchMetricsSnapshot is a point-in-time copy of FetchMetrics
+type FetchMetricsSnapshot struct {
+	Provider  string      `json:"provider"`
+	Requests  int         `json:"requests"`
+	Bytes     int64       `json:"bytes"`
+	CacheHits int         `json:"cacheHits"`
+	Failures  map[int]int `json:"failures,omitempty"` // Keyed by HTTP status code, 0 for transport errors
+}
+
+// NewFetchMetrics creates an empty metrics recorder for the named provider
+func NewFetchMetrics(provider string) *FetchMetrics {
+	return &FetchMetrics{
+		provider: provider,
+		failures: make(map[int]int),
+	}
+}
+
+// RecordResponse records the outcome of a single request. A nil response records a transport error.
+// 304 Not Modified responses count as cache hits and statuses of 400 and above as failures.
+func (m *FetchMetrics) RecordResponse(resp *http.Response) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests++
+	switch {
+	case resp == nil:
+		m.failures[0]++
+	case resp.StatusCode == http.StatusNotModified:
+		m.cacheHits++
+	case resp.StatusCode >= http.StatusBadRequest:
+		m.failures[resp.StatusCode]++
+	}
+}
+
+// RecordCacheHit records a request that was answered without contacting the remote server
+func (m *FetchMetrics) RecordCacheHit() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cacheHits++
+}
+
+// AddBytes records n bytes of response body read
+func (m *FetchMetrics) AddBytes(n int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.bytes += n
+}
+
+// Snapshot returns a copy of the current metrics
+func (m *FetchMetrics) Snapshot() FetchMetricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	failures := make(map[int]int, len(m.failures))
+	for status, count := range m.failures {
+		failures[status] = count
+	}
+
+	return FetchMetricsSnapshot{
+		Provider:  m.provider,
+		Requests:  m.requests,
+		Bytes:     m.bytes,
+		CacheHits: m.cacheHits,
+		Failures:  failures,
+	}
+}
+
+// Transport wraps base so every request made through it is recorded.
+// A nil base uses http.DefaultTransport.
+func (m *FetchMetrics) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &metricsTransport{base: base, metrics: m}
+}
+
+// String formats the snapshot as key=value pairs for logging
+func (s FetchMetricsSnapshot) String() string {
+	statuses := make([]int, 0, len(s.Failures))
+	for status := range s.Failures {
+		statuses = append(statuses, status)
+	}
+	sort.Ints(statuses)
+
+	failures := make([]string, len(statuses))
+	for i, status := range statuses {
+		label := fmt.Sprintf("%d", status)
+		if status == 0 {
+			label = "network"
+		}
+		failures[i] = fmt.Sprintf("%s:%d", label, s.Failures[status])
+	}
+
+	return fmt.Sprintf("provider=%s requests=%d bytes=%d cache_hits=%d failures=[%s]",
+		s.Provider, s.Requests, s.Bytes, s.CacheHits, strings.Join(failures, " "))
+}
+
+// metricsTransport is an http.RoundTripper that records requests to a FetchMetrics
+type metricsTransport struct {
+	base    http.RoundTripper
+	metrics *FetchMetrics
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		t.metrics.RecordResponse(nil)
+		return nil, err
+	}
+
+	t.metrics.RecordResponse(resp)
+	resp.Body = &countingBody{ReadCloser: resp.Body, metrics: t.metrics}
+	return resp, nil
+}
+
+// countingBody adds the number of bytes read from a response body to the metrics
+type countingBody struct {
+	io.ReadCloser
+	metrics *FetchMetrics
+}
+
+// Read implements io.Reader
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.metrics.AddBytes(int64(n))
+	}
+	return n, err
+}
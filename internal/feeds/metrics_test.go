@@ -0,0 +1,70 @@
+package feeds
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchMetrics_Transport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.Write([]byte("hello world"))
+		case "/cached":
+			w.WriteHeader(http.StatusNotModified)
+		case "/missing":
+			http.NotFound(w, r)
+		default:
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer server.Close()
+
+	metrics := NewFetchMetrics("test")
+	client := &http.Client{Transport: metrics.Transport(nil)}
+
+	for _, path := range []string{"/ok", "/cached", "/missing", "/limited", "/limited"} {
+		resp, err := client.Get(server.URL + path)
+		require.NoError(t, err)
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	// Closed port produces a transport error
+	_, err := client.Get("http://127.0.0.1:1/")
+	require.Error(t, err)
+
+	snapshot := metrics.Snapshot()
+	assert.Equal(t, "test", snapshot.Provider)
+	assert.Equal(t, 6, snapshot.Requests)
+	assert.Equal(t, 1, snapshot.CacheHits)
+	assert.Equal(t, map[int]int{0: 1, 404: 1, 429: 2}, snapshot.Failures)
+	assert.GreaterOrEqual(t, snapshot.Bytes, int64(len("hello world")))
+}
+
+func TestFetchMetricsSnapshot_String(t *testing.T) {
+	snapshot := FetchMetricsSnapshot{
+		Provider:  "rss",
+		Requests:  4,
+		Bytes:     2048,
+		CacheHits: 1,
+		Failures:  map[int]int{503: 1, 0: 2},
+	}
+
+	assert.Equal(t, "provider=rss requests=4 bytes=2048 cache_hits=1 failures=[network:2 503:1]", snapshot.String())
+}
+
+func TestFetchMetrics_SnapshotIsCopy(t *testing.T) {
+	metrics := NewFetchMetrics("test")
+	metrics.RecordResponse(nil)
+
+	snapshot := metrics.Snapshot()
+	snapshot.Failures[0] = 10
+
+	assert.Equal(t, 1, metrics.Snapshot().Failures[0])
+}
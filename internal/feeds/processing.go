@@ -2,11 +2,13 @@ package feeds
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 
 	"github.com/bakkerme/ai-news-processor/internal/persona"
 	"github.com/bakkerme/ai-news-processor/internal/urlextraction"
+	"github.com/bakkerme/ai-news-processor/internal/urlnorm"
 )
 
 // FeedProvider defines the interface for fetching and processing feed data
@@ -18,67 +20,111 @@ type FeedProvider interface {
 	FetchComments(ctx context.Context, entry Entry) (*CommentFeed, error)
 }
 
-// FetchAndProcessFeed fetches a feed for the given persona and processes it
+// ErrNoEntries is returned by FetchAndProcessFeed when the feed is empty
+var ErrNoEntries = errors.New("no entries found in feed")
+
+// EntryFilter returns the reason an entry should be dropped, or an empty string to keep it
+type EntryFilter func(Entry) string
+
+// DroppedEntry is an entry removed by an EntryFilter
+type DroppedEntry struct {
+	Entry  Entry
+	Reason string
+}
+
+// FetchAndProcessFeed fetches a feed for the given persona and processes it. Entries are passed to
+// filter once their URLs are extracted, and the entries it drops are returned without fetching
+// their comments. filter may be nil. Entries linking the same page as an earlier entry, up to
+// tracking parameters, are dropped as duplicates.
 // TODO: most of this logic should be in the reddit provider itself
-func FetchAndProcessFeed(provider FeedProvider, urlExtractor urlextraction.Extractor, persona persona.Persona, debugDump bool) ([]Entry, error) {
+func FetchAndProcessFeed(provider FeedProvider, urlExtractor urlextraction.Extractor, persona persona.Persona, debugDump bool, filter EntryFilter) ([]Entry, []DroppedEntry, error) {
 	log.Printf("Loading feed for persona: %s\n", persona.Name)
 
 	feed, err := provider.FetchFeed(context.Background(), persona)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load feed data: %w", err)
+		return nil, nil, fmt.Errorf("failed to load feed data: %w", err)
 	}
 
-	entries := feed.Entries
-	if len(entries) == 0 {
-		return nil, fmt.Errorf("no entries found in feed")
+	if len(feed.Entries) == 0 {
+		return nil, nil, ErrNoEntries
 	}
 
-	for i, entry := range entries {
-		commentFeed, err := provider.FetchComments(context.Background(), entry)
-		if err != nil {
-			return nil, fmt.Errorf("failed to load comment data for entry %s: %w", entry.ID, err)
-		}
-
-		// Filter out the original post from comments (Reddit includes the original post as first comment entry)
-		var filteredComments []EntryComments
-		for _, comment := range commentFeed.Entries {
-			// Skip comment entries that have the same ID as the main post (this prevents duplication)
-			if comment.Content != "" && len(comment.Content) > 0 {
-				// Check if this comment entry is actually the original post by comparing a portion of content
-				// or simply filter based on position (first entry is typically the original post)
-				filteredComments = append(filteredComments, comment)
+	entries := make([]Entry, 0, len(feed.Entries))
+	var dropped []DroppedEntry
+	linkedBy := make(map[string]string, len(feed.Entries)) // ID of the first entry linking each page, by urlnorm.Key
+	for _, entry := range feed.Entries {
+		if entry.Link.Href != "" {
+			key := urlnorm.Key(entry.Link.Href)
+			if first, ok := linkedBy[key]; ok {
+				dropped = append(dropped, DroppedEntry{Entry: entry, Reason: "duplicate of " + first})
+				continue
 			}
+			linkedBy[key] = entry.ID
 		}
 
-		// Remove the first comment entry if it exists, as Reddit comment feeds include the original post as the first entry
-		if len(filteredComments) > 0 {
-			filteredComments = filteredComments[1:]
-		}
-
-		entries[i].Comments = filteredComments
-
-		if len(entries[i].ImageURLs) == 0 {
+		if len(entry.ImageURLs) == 0 {
 			// extract image urls
 			imageURLs, err := urlExtractor.ExtractImageURLsFromEntry(entry)
 			if err != nil {
-				return nil, fmt.Errorf("failed to extract image URLs: %w", err)
+				return nil, nil, fmt.Errorf("failed to extract image URLs: %w", err)
 			}
 
-			entries[i].ImageURLs = imageURLs
+			entry.ImageURLs = imageURLs
 		}
 
-		if len(entries[i].ExternalURLs) == 0 {
+		if len(entry.ExternalURLs) == 0 {
 			// extract external urls
 			externalURLs, err := urlExtractor.ExtractExternalURLsFromEntry(entry)
 			if err != nil {
-				return nil, fmt.Errorf("failed to extract external URLs: %w", err)
+				return nil, nil, fmt.Errorf("failed to extract external URLs: %w", err)
+			}
+
+			entry.ExternalURLs = externalURLs
+		}
+		entry.ExternalURLs = urlnorm.CleanAll(entry.ExternalURLs)
+
+		if filter != nil {
+			if reason := filter(entry); reason != "" {
+				dropped = append(dropped, DroppedEntry{Entry: entry, Reason: reason})
+				continue
 			}
+		}
 
-			entries[i].ExternalURLs = externalURLs
+		comments, err := FetchEntryComments(provider, entry)
+		if err != nil {
+			return nil, nil, err
 		}
+
+		entry.Comments = comments
+		entries = append(entries, entry)
 	}
 
-	return entries, nil
+	return entries, dropped, nil
+}
+
+// FetchEntryComments fetches the comments of an entry, without the original post
+func FetchEntryComments(provider FeedProvider, entry Entry) ([]EntryComments, error) {
+	commentFeed, err := provider.FetchComments(context.Background(), entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load comment data for entry %s: %w", entry.ID, err)
+	}
+
+	// Filter out the original post from comments (Reddit includes the original post as first comment entry)
+	var filteredComments []EntryComments
+	for _, comment := range commentFeed.Entries {
+		// Skip comment entries that have the same ID as the main post (this prevents duplication)
+		if comment.Content != "" && len(comment.Content) > 0 {
+			// Check if this comment entry is actually the original post by comparing a portion of content
+			// or simply filter based on position (first entry is typically the original post)
+			filteredComments = append(filteredComments, comment)
+		}
+	}
+
+	// Remove the first comment entry if it exists, as Reddit comment feeds include the original post as the first entry
+	if len(filteredComments) > 0 {
+		filteredComments = filteredComments[1:]
+	}
+	return filteredComments, nil
 }
 
 // FindEntryByID finds a feed entry with the given ID
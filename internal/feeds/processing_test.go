@@ -0,0 +1,71 @@
+package feeds
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/internal/urlextraction"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubProvider struct {
+	entries   []Entry
+	commented []string
+}
+
+func (p *stubProvider) FetchFeed(ctx context.Context, persona persona.Persona) (*Feed, error) {
+	return &Feed{Entries: p.entries}, nil
+}
+
+func (p *stubProvider) FetchComments(ctx context.Context, entry Entry) (*CommentFeed, error) {
+	p.commented = append(p.commented, entry.ID)
+	return &CommentFeed{Entries: []EntryComments{{Content: "original post"}, {Content: "first!"}}}, nil
+}
+
+func TestFetchAndProcessFeed_Filter(t *testing.T) {
+	provider := &stubProvider{entries: []Entry{
+		{ID: "a", Title: "Keep", Content: `<a href="https://example.com/paper">paper</a>`},
+		{ID: "b", Title: "Block", Content: `<a href="https://blocked.example.org/post">post</a>`},
+	}}
+	filter := func(entry Entry) string {
+		for _, u := range entry.ExternalURLs {
+			if u.Host == "blocked.example.org" {
+				return "blocked domain"
+			}
+		}
+		return ""
+	}
+
+	entries, dropped, err := FetchAndProcessFeed(provider, urlextraction.NewRedditExtractor(), persona.Persona{Name: "Test"}, false, filter)
+	require.NoError(t, err)
+
+	require.Len(t, entries, 1)
+	assert.Equal(t, "a", entries[0].ID)
+	assert.Equal(t, []EntryComments{{Content: "first!"}}, entries[0].Comments)
+	require.Len(t, dropped, 1)
+	assert.Equal(t, "b", dropped[0].Entry.ID)
+	assert.Equal(t, "blocked domain", dropped[0].Reason)
+	assert.Equal(t, []string{"a"}, provider.commented, "comments are not fetched for dropped entries")
+}
+
+func TestFetchAndProcessFeed_DuplicateLinks(t *testing.T) {
+	provider := &stubProvider{entries: []Entry{
+		{ID: "a", Link: Link{Href: "https://example.com/article?utm_source=rss"}, Content: `<a href="https://example.com/paper?utm_medium=social">paper</a> <a href="https://example.com/paper">again</a>`},
+		{ID: "b", Link: Link{Href: "https://www.example.com/article/?fbclid=abc"}},
+		{ID: "c", Link: Link{Href: "https://example.com/other"}},
+	}}
+
+	entries, dropped, err := FetchAndProcessFeed(provider, urlextraction.NewRedditExtractor(), persona.Persona{Name: "Test"}, false, nil)
+	require.NoError(t, err)
+
+	require.Len(t, entries, 2)
+	assert.Equal(t, "a", entries[0].ID)
+	assert.Equal(t, "c", entries[1].ID)
+	require.Len(t, entries[0].ExternalURLs, 1)
+	assert.Equal(t, "https://example.com/paper", entries[0].ExternalURLs[0].String())
+	require.Len(t, dropped, 1)
+	assert.Equal(t, "b", dropped[0].Entry.ID)
+	assert.Equal(t, "duplicate of a", dropped[0].Reason)
+}
@@ -0,0 +1,37 @@
+package feeds
+
+import (
+	"context"
+
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/internal/runcache"
+)
+
+// SharedProvider wraps a provider so personas that read the same source in one run fetch its
+// feed and the comments of its entries once
+type SharedProvider struct {
+	provider FeedProvider
+	cache    *runcache.Cache
+	name     string // Provider type, such as "reddit"
+	source   string // Feed the persona reads, such as the subreddit
+}
+
+// NewSharedProvider wraps provider, caching its results in cache. name is the provider type and
+// source identifies the feed, so personas with the same name and source share it.
+func NewSharedProvider(provider FeedProvider, cache *runcache.Cache, name, source string) *SharedProvider {
+	return &SharedProvider{provider: provider, cache: cache, name: name, source: source}
+}
+
+// FetchFeed returns the feed of the source, fetching it for the first persona that reads it
+func (s *SharedProvider) FetchFeed(ctx context.Context, persona persona.Persona) (*Feed, error) {
+	return runcache.Do(s.cache, "feed:"+s.name+":"+s.source, func() (*Feed, error) {
+		return s.provider.FetchFeed(ctx, persona)
+	})
+}
+
+// FetchComments returns the comments of an entry, fetching them for the first persona that needs them
+func (s *SharedProvider) FetchComments(ctx context.Context, entry Entry) (*CommentFeed, error) {
+	return runcache.Do(s.cache, "comments:"+s.name+":"+entry.ID, func() (*CommentFeed, error) {
+		return s.provider.FetchComments(ctx, entry)
+	})
+}
@@ -0,0 +1,30 @@
+package feeds
+
+import (
+	"testing"
+
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/internal/runcache"
+	"github.com/bakkerme/ai-news-processor/internal/urlextraction"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSharedProvider(t *testing.T) {
+	cache := runcache.New()
+	local := &stubProvider{entries: []Entry{{ID: "a", Title: "Qwen3 released"}}}
+	other := &stubProvider{entries: []Entry{{ID: "b", Title: "Something else"}}}
+
+	for _, name := range []string{"LocalLLaMA", "LocalLLaMA-Nerds"} {
+		provider := NewSharedProvider(local, cache, "reddit", "localllama")
+		entries, _, err := FetchAndProcessFeed(provider, urlextraction.NewRedditExtractor(), persona.Persona{Name: name}, false, nil)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, []EntryComments{{Content: "first!"}}, entries[0].Comments)
+	}
+	assert.Equal(t, []string{"a"}, local.commented, "the second persona reuses the comments")
+
+	entries, _, err := FetchAndProcessFeed(NewSharedProvider(other, cache, "reddit", "machinelearning"), urlextraction.NewRedditExtractor(), persona.Persona{Name: "ML"}, false, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "b", entries[0].ID, "other sources are fetched on their own")
+}
@@ -5,6 +5,9 @@ import (
 	"net/url"
 	"strings"
 	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/sitemeta"
+	"github.com/bakkerme/ai-news-processor/internal/tokens"
 )
 
 // Feedlike is an interface that can be used to represent any type that has a FeedString method
@@ -34,17 +37,23 @@ func (cf *CommentFeed) FeedString() string {
 
 // Entry represents a single content item (post, article, etc.)
 type Entry struct {
-	Title               string            `json:"title"`
-	Link                Link              `json:"link"`
-	ID                  string            `json:"id"`
-	Published           time.Time         `json:"published"`
-	Content             string            `json:"content"`
-	Comments            []EntryComments   `json:"comments"`
-	ExternalURLs        []url.URL         `json:"externalURLs"`        // External URLs found in content
-	ImageURLs           []url.URL         `json:"imageURLs"`           // Extracted image URLs
-	MediaThumbnail      MediaThumbnail    `json:"mediaThumbnail"`      // Thumbnail information
-	ImageDescription    string            `json:"imageDescription"`    // Generated image descriptions
-	WebContentSummaries map[string]string `json:"webContentSummaries"` // Summaries of external URLs
+	Title               string                       `json:"title"`
+	Link                Link                         `json:"link"`
+	ID                  string                       `json:"id"`
+	Published           time.Time                    `json:"published"`
+	Content             string                       `json:"content"`
+	Comments            []EntryComments              `json:"comments"`
+	ExternalURLs        []url.URL                    `json:"externalURLs"`                // External URLs found in content
+	ImageURLs           []url.URL                    `json:"imageURLs"`                   // Extracted image URLs
+	MediaThumbnail      MediaThumbnail               `json:"mediaThumbnail"`              // Thumbnail information
+	ImageDescription    string                       `json:"imageDescription"`            // Generated image descriptions
+	WebContentSummaries map[string]string            `json:"webContentSummaries"`         // Summaries of external URLs
+	WebContentSources   map[string]sitemeta.Metadata `json:"webContentSources,omitempty"` // Site name and favicon for each summarized URL
+	Flair               string                       `json:"flair,omitempty"`             // Reddit link flair or the first RSS category
+	Author              string                       `json:"author,omitempty"`            // Reddit username or RSS author
+	Views               int                          `json:"views,omitempty"`             // View count, for providers that report one (YouTube)
+	Likes               int                          `json:"likes,omitempty"`             // Like count, for providers that report one (YouTube)
+	CanonicalID         string                       `json:"canonicalID,omitempty"`       // ID of the original post of a Reddit crosspost or repost
 }
 
 // EntryComments represents a comment on an entry
@@ -68,7 +77,7 @@ func (e *Entry) String(disableTruncation bool) string {
 	s.WriteString(fmt.Sprintf("Title: %s\nID: %s\nContent: %s\nImageDescription: %s\n",
 		strings.Trim(e.Title, " "),
 		e.ID,
-		cleanContent(e.Content, 1200, disableTruncation),
+		cleanContent(e.Content, 300, disableTruncation),
 		e.ImageDescription,
 	))
 
@@ -88,7 +97,7 @@ func (e *Entry) String(disableTruncation bool) string {
 
 	s.WriteString("Comments:\n")
 	for _, comment := range e.Comments {
-		s.WriteString(fmt.Sprintf("- %s\n", cleanContent(comment.Content, 600, disableTruncation)))
+		s.WriteString(fmt.Sprintf("- %s\n", cleanContent(comment.Content, 150, disableTruncation)))
 	}
 
 	return s.String()
@@ -99,6 +108,15 @@ func (e *Entry) GetCommentURL() string {
 	return fmt.Sprintf("%s.rss?depth=1", e.Link.Href)
 }
 
+// PostID returns the ID of the post the entry is about: the original post of a crosspost or
+// repost, or the entry's own ID
+func (e Entry) PostID() string {
+	if e.CanonicalID != "" {
+		return e.CanonicalID
+	}
+	return e.ID
+}
+
 // GetID returns the Entry's ID, implementing the ContentProvider interface
 func (e Entry) GetID() string {
 	return e.ID
@@ -109,8 +127,8 @@ func (e Entry) GetContent() string {
 	return e.Content
 }
 
-// cleanContent cleans and optionally truncates content
-func cleanContent(s string, maxLen int, disableTruncation bool) string {
+// cleanContent cleans and optionally truncates content to about maxTokens, at a sentence boundary
+func cleanContent(s string, maxTokens int, disableTruncation bool) string {
 	// Basic HTML entity cleanup
 	cleaned := strings.ReplaceAll(s, "&#39;", "'")
 	cleaned = strings.ReplaceAll(cleaned, "&#32;", " ")
@@ -123,19 +141,12 @@ func cleanContent(s string, maxLen int, disableTruncation bool) string {
 		return cleaned
 	}
 
-	lenToUse := maxLen
-	strLen := len(cleaned)
-
-	if strLen < lenToUse {
-		lenToUse = strLen
-	}
-
-	truncated := cleaned[0:lenToUse]
+	truncated, cut := tokens.Truncate(cleaned, maxTokens)
 
 	// Add ellipsis if truncated
-	if lenToUse != strLen {
+	if cut {
 		truncated += "..."
 	}
 
 	return truncated
-}
\ No newline at end of file
+}
@@ -0,0 +1,27 @@
+package feeds
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEntryStringTruncatesAtSentences(t *testing.T) {
+	sentence := "The new model beats the previous release on every benchmark. "
+	entry := Entry{
+		ID:       "1",
+		Title:    "Release",
+		Content:  strings.Repeat(sentence, 40),
+		Comments: []EntryComments{{Content: "Nice &amp; fast. " + strings.Repeat(sentence, 20)}},
+	}
+
+	truncated := entry.String(false)
+	assert.Contains(t, truncated, "benchmark....\n", "content is cut after a sentence")
+	assert.Contains(t, truncated, "- Nice & fast. ")
+	assert.Less(t, len(truncated), len(entry.String(true)))
+
+	full := entry.String(true)
+	assert.Contains(t, full, strings.TrimSpace(strings.Repeat(sentence, 40)))
+	assert.NotContains(t, full, "...")
+}
@@ -38,7 +38,8 @@ type Fetcher interface {
 type HTTPFetcher struct {
 	client      *http.Client
 	retryConfig retry.RetryConfig
-	userAgent   string // Added User-Agent field
+	userAgent   string      // Added User-Agent field
+	politeness  *politeness // Per-host robots.txt, rate limit and backoff state (nil when disabled)
 }
 
 // NewHTTPFetcher creates a new HTTPFetcher with a default http.Client,
@@ -65,7 +66,25 @@ func NewHTTPFetcher(client *http.Client, cfg retry.RetryConfig, userAgent string
 // Fetch performs an HTTP GET request to the specified URL with retry logic.
 // The caller is responsible for closing the response body if the error is nil.
 func (hf *HTTPFetcher) Fetch(ctx context.Context, url *url.URL) (*http.Response, error) {
-	retryableFunc := func(innerCtx context.Context) (*http.Response, error) {
+	var domain *domainState
+	if hf.politeness != nil {
+		state, release, err := hf.beforeFetch(ctx, url)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		domain = state
+	}
+
+	retryableFunc := func(innerCtx context.Context) (resp *http.Response, err error) {
+		// Space out requests to the same host and respect any backoff from earlier failures
+		if domain != nil {
+			if err := domain.wait(innerCtx, domain.interval(hf.politeness.config)); err != nil {
+				return nil, err
+			}
+			defer func() { domain.record(err, hf.politeness.config) }()
+		}
+
 		req, err := http.NewRequestWithContext(innerCtx, http.MethodGet, url.String(), nil)
 		if err != nil {
 			// This error is likely non-retryable (e.g., malformed URL)
@@ -75,7 +94,7 @@ func (hf *HTTPFetcher) Fetch(ctx context.Context, url *url.URL) (*http.Response,
 		// Set the custom User-Agent header
 		req.Header.Set("User-Agent", hf.userAgent)
 
-		resp, err := hf.client.Do(req)
+		resp, err = hf.client.Do(req)
 		if err != nil {
 			// Network error or other error from client.Do
 			// resp might be nil here, or might have partial info.
@@ -134,6 +153,32 @@ func (hf *HTTPFetcher) Fetch(ctx context.Context, url *url.URL) (*http.Response,
 	return retry.RetryWithBackoff(ctx, hf.retryConfig, retryableFunc, shouldRetryHTTP)
 }
 
+// IsPermanentError reports whether the error indicates the URL is unlikely to succeed on a later attempt,
+// such as a missing page, a request blocked by the remote site or a path disallowed by robots.txt.
+// Transient failures (5xx, 429, timeouts) are not considered permanent.
+func IsPermanentError(err error) bool {
+	if errors.Is(err, ErrDisallowedByRobots) {
+		return true
+	}
+
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) {
+		return false
+	}
+
+	switch httpErr.StatusCode {
+	case http.StatusBadRequest,
+		http.StatusUnauthorized,
+		http.StatusPaymentRequired,
+		http.StatusForbidden,
+		http.StatusNotFound,
+		http.StatusGone,
+		http.StatusUnavailableForLegalReasons:
+		return true
+	}
+	return false
+}
+
 // shouldRetryHTTP determines if an HTTP request should be retried based on the error.
 func shouldRetryHTTP(err error) bool {
 	if err == nil {
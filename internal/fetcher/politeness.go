@@ -0,0 +1,238 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrDisallowedByRobots is returned when a site's robots.txt does not allow fetching a URL
+var ErrDisallowedByRobots = errors.New("fetch disallowed by robots.txt")
+
+// maxCrawlDelay caps the Crawl-delay honored from robots.txt so a single site cannot stall a run
+const maxCrawlDelay = 30 * time.Second
+
+// robotsTimeout bounds how long fetching a robots.txt file may take
+const robotsTimeout = 10 * time.Second
+
+// PolitenessConfig controls how the fetcher treats the sites it requests pages from
+type PolitenessConfig struct {
+	RespectRobots          bool          // Whether robots.txt is fetched and honored
+	MinDomainInterval      time.Duration // Minimum time between requests to the same host
+	MaxConcurrentPerDomain int           // Maximum number of in-flight requests per host (0 means unlimited)
+	BackoffInitial         time.Duration // Host backoff after the first 429 or 5xx response
+	BackoffMax             time.Duration // Upper bound for host backoff
+	RobotsTTL              time.Duration // How long a fetched robots.txt is cached
+}
+
+// DefaultPolitenessConfig provides sensible defaults for fetching third-party sites
+var DefaultPolitenessConfig = PolitenessConfig{
+	RespectRobots:          true,
+	MinDomainInterval:      1 * time.Second,
+	MaxConcurrentPerDomain: 2,
+	BackoffInitial:         5 * time.Second,
+	BackoffMax:             5 * time.Minute,
+	RobotsTTL:              24 * time.Hour,
+}
+
+// domainState tracks rate limiting, backoff and robots.txt rules for a single host
+type domainState struct {
+	slots chan struct{} // nil when concurrency is unlimited
+
+	mu           sync.Mutex
+	nextRequest  time.Time
+	backoffUntil time.Time
+	failures     int
+
+	robotsMu      sync.Mutex // Serializes robots.txt fetches for the host
+	robots        *robotsRules
+	robotsFetched time.Time
+}
+
+// politeness holds the per-host state shared by all requests made through an HTTPFetcher
+type politeness struct {
+	config PolitenessConfig
+
+	mu      sync.Mutex
+	domains map[string]*domainState
+}
+
+// SetPoliteness enables robots.txt handling, per-host rate limits, concurrency caps and backoff for this fetcher
+func (hf *HTTPFetcher) SetPoliteness(cfg PolitenessConfig) {
+	hf.politeness = &politeness{
+		config:  cfg,
+		domains: make(map[string]*domainState),
+	}
+}
+
+// domain returns the state for the host of u, creating it if needed
+func (p *politeness) domain(u *url.URL) *domainState {
+	host := strings.ToLower(u.Host)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	state, ok := p.domains[host]
+	if !ok {
+		state = &domainState{}
+		if p.config.MaxConcurrentPerDomain > 0 {
+			state.slots = make(chan struct{}, p.config.MaxConcurrentPerDomain)
+		}
+		p.domains[host] = state
+	}
+	return state
+}
+
+// acquire blocks until a concurrency slot for the host is free. The returned function releases it.
+func (s *domainState) acquire(ctx context.Context) (func(), error) {
+	if s.slots == nil {
+		return func() {}, nil
+	}
+	select {
+	case s.slots <- struct{}{}:
+		return func() { <-s.slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// wait blocks until the host's rate limit and backoff allow another request
+func (s *domainState) wait(ctx context.Context, interval time.Duration) error {
+	s.mu.Lock()
+	now := time.Now()
+	start := now
+	if s.nextRequest.After(start) {
+		start = s.nextRequest
+	}
+	if s.backoffUntil.After(start) {
+		start = s.backoffUntil
+	}
+	// Reserve the slot before sleeping so concurrent callers queue up behind each other
+	s.nextRequest = start.Add(interval)
+	s.mu.Unlock()
+
+	delay := start.Sub(now)
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// record updates the host's backoff state from the outcome of a request
+func (s *domainState) record(err error, cfg PolitenessConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) {
+		if err == nil {
+			s.failures = 0
+		}
+		return
+	}
+
+	if httpErr.StatusCode != http.StatusTooManyRequests && httpErr.StatusCode < 500 {
+		return
+	}
+
+	s.failures++
+	backoff := cfg.BackoffInitial
+	for i := 1; i < s.failures && backoff < cfg.BackoffMax; i++ {
+		backoff *= 2
+	}
+	if cfg.BackoffMax > 0 && backoff > cfg.BackoffMax {
+		backoff = cfg.BackoffMax
+	}
+	if httpErr.RetryAfter != nil && *httpErr.RetryAfter > backoff {
+		backoff = *httpErr.RetryAfter
+	}
+	s.backoffUntil = time.Now().Add(backoff)
+}
+
+// interval returns the minimum spacing between requests to the host, taking Crawl-delay into account
+func (s *domainState) interval(cfg PolitenessConfig) time.Duration {
+	s.robotsMu.Lock()
+	defer s.robotsMu.Unlock()
+
+	interval := cfg.MinDomainInterval
+	if s.robots != nil && s.robots.crawlDelay > interval {
+		interval = min(s.robots.crawlDelay, maxCrawlDelay)
+	}
+	return interval
+}
+
+// allowedByRobots checks the host's robots.txt, fetching and caching it if needed
+func (hf *HTTPFetcher) allowedByRobots(ctx context.Context, u *url.URL, state *domainState) bool {
+	state.robotsMu.Lock()
+	defer state.robotsMu.Unlock()
+
+	if state.robots == nil || time.Since(state.robotsFetched) > hf.politeness.config.RobotsTTL {
+		state.robots = hf.fetchRobots(ctx, u)
+		state.robotsFetched = time.Now()
+	}
+
+	path := u.EscapedPath()
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return state.robots.Allowed(path)
+}
+
+// fetchRobots downloads and parses robots.txt for the host of u.
+// Missing or unreachable robots.txt files are treated as allowing everything.
+func (hf *HTTPFetcher) fetchRobots(ctx context.Context, u *url.URL) *robotsRules {
+	robotsURL := &url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/robots.txt"}
+
+	ctx, cancel := context.WithTimeout(ctx, robotsTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL.String(), nil)
+	if err != nil {
+		return &robotsRules{}
+	}
+	req.Header.Set("User-Agent", hf.userAgent)
+
+	resp, err := hf.client.Do(req)
+	if err != nil {
+		return &robotsRules{}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &robotsRules{}
+	}
+
+	return parseRobots(resp.Body, hf.userAgent)
+}
+
+// beforeFetch applies robots.txt and concurrency limits ahead of a fetch.
+// The returned function must be called once the fetch completes.
+func (hf *HTTPFetcher) beforeFetch(ctx context.Context, u *url.URL) (*domainState, func(), error) {
+	state := hf.politeness.domain(u)
+
+	if hf.politeness.config.RespectRobots && !hf.allowedByRobots(ctx, u, state) {
+		return nil, nil, fmt.Errorf("%w: %s", ErrDisallowedByRobots, u.String())
+	}
+
+	release, err := state.acquire(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return state, release, nil
+}
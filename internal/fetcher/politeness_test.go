@@ -0,0 +1,115 @@
+package fetcher_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/fetcher"
+	"github.com/bakkerme/ai-news-processor/internal/http/retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPFetcher_Fetch_RobotsDisallowed(t *testing.T) {
+	var pageRequests int32
+	server, serverURL := setupTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.Write([]byte("User-agent: *\nDisallow: /private/\n"))
+			return
+		}
+		atomic.AddInt32(&pageRequests, 1)
+		w.WriteHeader(http.StatusOK)
+	})
+	defer server.Close()
+
+	f := fetcher.NewHTTPFetcher(server.Client(), retry.RetryConfig{}, "test-agent-robots/1.0")
+	cfg := fetcher.DefaultPolitenessConfig
+	cfg.MinDomainInterval = 0
+	f.SetPoliteness(cfg)
+
+	_, err := f.Fetch(context.Background(), serverURL.JoinPath("private", "page"))
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, fetcher.ErrDisallowedByRobots))
+	assert.True(t, fetcher.IsPermanentError(err))
+
+	resp, err := f.Fetch(context.Background(), serverURL.JoinPath("public"))
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&pageRequests), "only the allowed page should be requested")
+}
+
+func TestHTTPFetcher_Fetch_RobotsMissing(t *testing.T) {
+	server, serverURL := setupTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	defer server.Close()
+
+	f := fetcher.NewHTTPFetcher(server.Client(), retry.RetryConfig{}, "test-agent-robots/1.0")
+	cfg := fetcher.DefaultPolitenessConfig
+	cfg.MinDomainInterval = 0
+	f.SetPoliteness(cfg)
+
+	resp, err := f.Fetch(context.Background(), serverURL.JoinPath("private", "page"))
+	require.NoError(t, err)
+	resp.Body.Close()
+}
+
+func TestHTTPFetcher_Fetch_DomainInterval(t *testing.T) {
+	server, serverURL := setupTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	defer server.Close()
+
+	f := fetcher.NewHTTPFetcher(server.Client(), retry.RetryConfig{}, "test-agent-interval/1.0")
+	f.SetPoliteness(fetcher.PolitenessConfig{
+		MinDomainInterval:      100 * time.Millisecond,
+		MaxConcurrentPerDomain: 1,
+	})
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		resp, err := f.Fetch(context.Background(), serverURL)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	// The first request goes out immediately, the next two wait for the interval
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+}
+
+func TestHTTPFetcher_Fetch_DomainBackoff(t *testing.T) {
+	var requests int32
+	server, serverURL := setupTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	defer server.Close()
+
+	f := fetcher.NewHTTPFetcher(server.Client(), retry.RetryConfig{}, "test-agent-backoff/1.0")
+	f.SetPoliteness(fetcher.PolitenessConfig{
+		BackoffInitial: 150 * time.Millisecond,
+		BackoffMax:     time.Second,
+	})
+
+	_, err := f.Fetch(context.Background(), serverURL)
+	require.Error(t, err)
+
+	// The next request to the same host waits out the backoff triggered by the 503
+	start := time.Now()
+	resp, err := f.Fetch(context.Background(), serverURL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+}
@@ -0,0 +1,164 @@
+package fetcher
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRobotsBytes limits how much of a robots.txt file is read
+const maxRobotsBytes = 512 * 1024
+
+// robotsRule is a single Allow or Disallow line from a robots.txt group
+type robotsRule struct {
+	pattern string
+	allow   bool
+}
+
+// robotsRules holds the rules from robots.txt that apply to our user agent
+type robotsRules struct {
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+// robotsGroup is a set of rules that applies to one or more user agents
+type robotsGroup struct {
+	agents     []string
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+// parseRobots parses a robots.txt file and returns the rules that apply to userAgent.
+// A group naming our agent takes precedence over the wildcard group.
+func parseRobots(r io.Reader, userAgent string) *robotsRules {
+	token := robotsAgentToken(userAgent)
+
+	var groups []*robotsGroup
+	var current *robotsGroup
+	lastWasAgent := false
+
+	scanner := bufio.NewScanner(io.LimitReader(r, maxRobotsBytes))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// Consecutive user-agent lines share the group that follows them
+			if current == nil || !lastWasAgent {
+				current = &robotsGroup{}
+				groups = append(groups, current)
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+			lastWasAgent = true
+		case "allow", "disallow":
+			lastWasAgent = false
+			if current == nil {
+				continue
+			}
+			// An empty Disallow allows everything, so it adds no rule
+			if value == "" {
+				continue
+			}
+			current.rules = append(current.rules, robotsRule{pattern: value, allow: key == "allow"})
+		case "crawl-delay":
+			lastWasAgent = false
+			if current == nil {
+				continue
+			}
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+				current.crawlDelay = time.Duration(seconds * float64(time.Second))
+			}
+		default:
+			lastWasAgent = false
+		}
+	}
+
+	var wildcard *robotsGroup
+	for _, group := range groups {
+		for _, agent := range group.agents {
+			if token != "" && agent != "*" && strings.Contains(token, agent) {
+				return &robotsRules{rules: group.rules, crawlDelay: group.crawlDelay}
+			}
+			if agent == "*" && wildcard == nil {
+				wildcard = group
+			}
+		}
+	}
+	if wildcard != nil {
+		return &robotsRules{rules: wildcard.rules, crawlDelay: wildcard.crawlDelay}
+	}
+	return &robotsRules{}
+}
+
+// Allowed reports whether the given path (including any query string) may be fetched.
+// The longest matching rule wins, and Allow wins ties.
+func (r *robotsRules) Allowed(path string) bool {
+	if r == nil {
+		return true
+	}
+	if path == "" {
+		path = "/"
+	}
+
+	allowed := true
+	longest := -1
+	for _, rule := range r.rules {
+		if !robotsMatch(rule.pattern, path) {
+			continue
+		}
+		if len(rule.pattern) > longest || (len(rule.pattern) == longest && rule.allow) {
+			longest = len(rule.pattern)
+			allowed = rule.allow
+		}
+	}
+	return allowed
+}
+
+// robotsMatch matches a robots.txt path pattern, supporting the * wildcard and $ end anchor
+func robotsMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	parts := strings.Split(strings.TrimSuffix(pattern, "$"), "*")
+
+	if anchored {
+		// The final literal part must sit at the very end of the path
+		last := parts[len(parts)-1]
+		if len(parts) == 1 {
+			return path == last
+		}
+		if !strings.HasSuffix(path, last) {
+			return false
+		}
+		path = path[:len(path)-len(last)]
+		parts = parts[:len(parts)-1]
+	}
+
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	pos := len(parts[0])
+	for _, part := range parts[1:] {
+		i := strings.Index(path[pos:], part)
+		if i < 0 {
+			return false
+		}
+		pos += i + len(part)
+	}
+	return true
+}
+
+// robotsAgentToken returns the lower-cased product token of a User-Agent string, e.g. "ai-news-processor-fetcher"
+func robotsAgentToken(userAgent string) string {
+	token, _, _ := strings.Cut(userAgent, "/")
+	return strings.ToLower(strings.TrimSpace(token))
+}
@@ -0,0 +1,70 @@
+package fetcher
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testRobots = `
+# Example robots.txt
+User-agent: *
+Disallow: /private/
+Disallow: /*.pdf$
+Allow: /private/public-page
+Crawl-delay: 2
+
+User-agent: BadBot
+User-agent: ai-news-processor-fetcher
+Disallow: /no-bots/
+`
+
+func TestParseRobots_WildcardGroup(t *testing.T) {
+	rules := parseRobots(strings.NewReader(testRobots), "some-other-agent/1.0")
+
+	assert.True(t, rules.Allowed("/"))
+	assert.True(t, rules.Allowed("/articles/1"))
+	assert.False(t, rules.Allowed("/private/secret"))
+	assert.True(t, rules.Allowed("/private/public-page"))
+	assert.False(t, rules.Allowed("/papers/paper.pdf"))
+	assert.True(t, rules.Allowed("/papers/paper.pdf?download=1"))
+	assert.Equal(t, 2*time.Second, rules.crawlDelay)
+}
+
+func TestParseRobots_SpecificGroup(t *testing.T) {
+	rules := parseRobots(strings.NewReader(testRobots), DefaultUserAgent)
+
+	// Our own group replaces the wildcard group entirely
+	assert.True(t, rules.Allowed("/private/secret"))
+	assert.False(t, rules.Allowed("/no-bots/page"))
+	assert.Equal(t, time.Duration(0), rules.crawlDelay)
+}
+
+func TestParseRobots_Empty(t *testing.T) {
+	rules := parseRobots(strings.NewReader(""), DefaultUserAgent)
+	assert.True(t, rules.Allowed("/anything"))
+}
+
+func TestRobotsMatch(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		path     string
+		expected bool
+	}{
+		{"/private", "/private/page", true},
+		{"/private", "/public", false},
+		{"/*/drafts/", "/blog/drafts/1", true},
+		{"/*.pdf$", "/a/b.pdf", true},
+		{"/*.pdf$", "/a/b.pdf.html", false},
+		{"/exact$", "/exact", true},
+		{"/exact$", "/exact/more", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.expected, robotsMatch(tt.pattern, tt.path))
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package fetcher
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// ErrNotSnapshotted is returned by a ReplayFetcher for a URL that was not fetched when the snapshot
+// was taken
+var ErrNotSnapshotted = errors.New("url not in snapshot")
+
+// SnapshotPage is a fetched page as stored in a snapshot
+type SnapshotPage struct {
+	URL         string `json:"url"`
+	StatusCode  int    `json:"statusCode"`
+	ContentType string `json:"contentType,omitempty"`
+	Body        []byte `json:"body"`
+}
+
+func snapshotPath(dir string, u *url.URL) string {
+	sum := sha256.Sum256([]byte(u.String()))
+	return filepath.Join(dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// RecordingFetcher wraps a Fetcher and saves every page it fetches to a snapshot directory, to be
+// served back by a ReplayFetcher
+type RecordingFetcher struct {
+	fetcher Fetcher
+	dir     string
+}
+
+// NewRecordingFetcher creates a fetcher that saves the pages fetched by f in dir
+func NewRecordingFetcher(f Fetcher, dir string) (*RecordingFetcher, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating snapshot directory: %w", err)
+	}
+	return &RecordingFetcher{fetcher: f, dir: dir}, nil
+}
+
+// Fetch fetches the URL with the wrapped fetcher and records the response. HTTP error responses are
+// recorded too, so a replay fails the same way; network errors are not.
+func (rf *RecordingFetcher) Fetch(ctx context.Context, u *url.URL) (*http.Response, error) {
+	resp, err := rf.fetcher.Fetch(ctx, u)
+	if resp == nil || resp.Body == nil {
+		return resp, err
+	}
+
+	body, readErr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if readErr != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", readErr)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	page := SnapshotPage{
+		URL:         u.String(),
+		StatusCode:  resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Body:        body,
+	}
+	if writeErr := writeSnapshotPage(rf.dir, u, page); writeErr != nil {
+		log.Printf("Could not record %s in snapshot: %v", u, writeErr)
+	}
+	return resp, err
+}
+
+func writeSnapshotPage(dir string, u *url.URL, page SnapshotPage) error {
+	data, err := json.Marshal(page)
+	if err != nil {
+		return fmt.Errorf("failed to marshal page: %w", err)
+	}
+	return os.WriteFile(snapshotPath(dir, u), data, 0644)
+}
+
+// ReplayFetcher serves the pages saved by a RecordingFetcher instead of fetching them
+type ReplayFetcher struct {
+	dir string
+}
+
+// NewReplayFetcher creates a fetcher that serves the pages recorded in dir
+func NewReplayFetcher(dir string) *ReplayFetcher {
+	return &ReplayFetcher{dir: dir}
+}
+
+// Fetch returns the recorded response for the URL, or ErrNotSnapshotted. Recorded error responses
+// are returned as an HTTPError, like HTTPFetcher does.
+func (rf *ReplayFetcher) Fetch(ctx context.Context, u *url.URL) (*http.Response, error) {
+	data, err := os.ReadFile(snapshotPath(rf.dir, u))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrNotSnapshotted, u)
+		}
+		return nil, fmt.Errorf("failed to read snapshot of %s: %w", u, err)
+	}
+
+	var page SnapshotPage
+	if err := json.Unmarshal(data, &page); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot of %s: %w", u, err)
+	}
+
+	resp := &http.Response{
+		Status:        fmt.Sprintf("%d %s", page.StatusCode, http.StatusText(page.StatusCode)),
+		StatusCode:    page.StatusCode,
+		Header:        make(http.Header),
+		Body:          io.NopCloser(bytes.NewReader(page.Body)),
+		ContentLength: int64(len(page.Body)),
+		Request:       &http.Request{Method: http.MethodGet, URL: u},
+	}
+	if page.ContentType != "" {
+		resp.Header.Set("Content-Type", page.ContentType)
+	}
+	if page.StatusCode >= 400 {
+		return resp, &HTTPError{StatusCode: resp.StatusCode, Status: resp.Status, Response: resp}
+	}
+	return resp, nil
+}
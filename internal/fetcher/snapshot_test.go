@@ -0,0 +1,69 @@
+package fetcher_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/bakkerme/ai-news-processor/internal/fetcher"
+	"github.com/bakkerme/ai-news-processor/internal/http/retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordAndReplayFetcher(t *testing.T) {
+	t.Parallel()
+
+	server, serverURL := setupTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte("<p>" + r.URL.Path + "</p>"))
+	})
+	defer server.Close()
+
+	retryCfg := retry.DefaultRetryConfig
+	retryCfg.MaxRetries = 0
+	dir := filepath.Join(t.TempDir(), "urls")
+	recorder, err := fetcher.NewRecordingFetcher(fetcher.NewHTTPFetcher(server.Client(), retryCfg, ""), dir)
+	require.NoError(t, err)
+
+	page := serverURL.JoinPath("article")
+	resp, err := recorder.Fetch(context.Background(), page)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "<p>/article</p>", string(body), "the recorder should pass the body through")
+
+	missing := serverURL.JoinPath("missing")
+	_, err = recorder.Fetch(context.Background(), missing)
+	require.Error(t, err)
+
+	// The server is no longer needed once the pages are recorded
+	server.Close()
+	replay := fetcher.NewReplayFetcher(dir)
+
+	resp, err = replay.Fetch(context.Background(), page)
+	require.NoError(t, err)
+	body, err = io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "<p>/article</p>", string(body))
+	assert.Equal(t, "text/html", resp.Header.Get("Content-Type"))
+
+	resp, err = replay.Fetch(context.Background(), missing)
+	var httpErr *fetcher.HTTPError
+	require.ErrorAs(t, err, &httpErr, "recorded error responses should replay as HTTP errors")
+	assert.Equal(t, http.StatusNotFound, httpErr.StatusCode)
+	assert.True(t, fetcher.IsPermanentError(err))
+	require.NotNil(t, resp)
+
+	unknown, _ := url.Parse("https://example.com/never-fetched")
+	_, err = replay.Fetch(context.Background(), unknown)
+	assert.ErrorIs(t, err, fetcher.ErrNotSnapshotted)
+}
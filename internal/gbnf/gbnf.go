@@ -0,0 +1,271 @@
+// Package gbnf converts JSON Schemas into GBNF grammars, the format llama.cpp uses to constrain
+// what a model generates. A model sampled with the grammar of a schema can only write JSON that
+// parses and has the fields of the schema.
+package gbnf
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/invopop/jsonschema"
+)
+
+// maxEnumeratedRange is the largest integer range written out as alternatives, so a score between
+// 1 and 10 can only be one of those numbers
+const maxEnumeratedRange = 32
+
+// Primitive rules, as in llama.cpp's json.gbnf. Whitespace is limited so a model cannot pad its
+// response with newlines forever.
+var primitives = map[string]string{
+	"ws":      `| " " | "\n" [ \t]{0,20}`,
+	"string":  `"\"" ( [^"\\\x7F\x00-\x1F] | "\\" (["\\bfnrt/] | "u" [0-9a-fA-F]{4}) )* "\"" ws`,
+	"number":  `("-"? ([0-9] | [1-9] [0-9]{0,15})) ("." [0-9]+)? ([eE] [-+]? [0-9]+)? ws`,
+	"integer": `("-"? ([0-9] | [1-9] [0-9]{0,15})) ws`,
+	"boolean": `("true" | "false") ws`,
+	"null":    `"null" ws`,
+	"value":   `object | array | string | number | boolean | null`,
+	"object":  `"{" ws ( string ":" ws value ("," ws string ":" ws value)* )? "}" ws`,
+	"array":   `"[" ws ( value ("," ws value)* )? "]" ws`,
+}
+
+// Rules a primitive rule refers to
+var primitiveDependencies = map[string][]string{
+	"string":  {"ws"},
+	"number":  {"ws"},
+	"integer": {"ws"},
+	"boolean": {"ws"},
+	"null":    {"ws"},
+	"value":   {"object", "array", "string", "number", "boolean", "null"},
+	"object":  {"ws", "string", "value"},
+	"array":   {"ws", "value"},
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9-]+`)
+
+// FromSchema returns the GBNF grammar of a JSON Schema, given as a *jsonschema.Schema or as
+// anything that encodes to one. Objects, arrays, strings, numbers, integers, booleans, enums,
+// consts and anyOf/oneOf are supported; other keywords such as patterns and lengths are not
+// enforced. Properties are generated in schema order, required ones first.
+func FromSchema(schema any) (string, error) {
+	s, ok := schema.(*jsonschema.Schema)
+	if !ok {
+		data, err := json.Marshal(schema)
+		if err != nil {
+			return "", fmt.Errorf("could not encode schema: %w", err)
+		}
+		s = &jsonschema.Schema{}
+		if err := json.Unmarshal(data, s); err != nil {
+			return "", fmt.Errorf("could not decode schema: %w", err)
+		}
+	}
+
+	// Every value may be followed by whitespace
+	g := &grammar{rules: map[string]string{}}
+	g.primitive("ws")
+	root, err := g.visit(s, "root")
+	if err != nil {
+		return "", err
+	}
+	if root != "root" {
+		g.add("root", root)
+	}
+	return g.String(), nil
+}
+
+// grammar collects rules in the order they are added
+type grammar struct {
+	names []string
+	rules map[string]string
+}
+
+// add adds a rule, numbering its name if another rule has it, and returns the name it got
+func (g *grammar) add(name, rule string) string {
+	unique := name
+	for n := 2; ; n++ {
+		if _, taken := g.rules[unique]; !taken {
+			break
+		}
+		unique = fmt.Sprintf("%s-%d", name, n)
+	}
+	g.names = append(g.names, unique)
+	g.rules[unique] = rule
+	return unique
+}
+
+// primitive adds a primitive rule and the rules it uses, and returns its name
+func (g *grammar) primitive(name string) string {
+	if _, ok := g.rules[name]; ok {
+		return name
+	}
+	g.names = append(g.names, name)
+	g.rules[name] = primitives[name]
+	for _, dependency := range primitiveDependencies[name] {
+		g.primitive(dependency)
+	}
+	return name
+}
+
+// visit returns a rule expression matching s, adding the rules it needs named after path
+func (g *grammar) visit(s *jsonschema.Schema, path string) (string, error) {
+	if s == nil {
+		return g.primitive("value"), nil
+	}
+
+	switch {
+	case s.Const != nil:
+		return literal(s.Const)
+	case len(s.Enum) > 0:
+		alternatives := make([]string, len(s.Enum))
+		for i, value := range s.Enum {
+			alternative, err := literal(value)
+			if err != nil {
+				return "", err
+			}
+			alternatives[i] = alternative
+		}
+		return g.add(path, "("+strings.Join(alternatives, " | ")+")"), nil
+	case len(s.AnyOf) > 0 || len(s.OneOf) > 0:
+		options := append(append([]*jsonschema.Schema{}, s.AnyOf...), s.OneOf...)
+		alternatives := make([]string, len(options))
+		for i, option := range options {
+			alternative, err := g.visit(option, fmt.Sprintf("%s-%d", path, i))
+			if err != nil {
+				return "", err
+			}
+			alternatives[i] = alternative
+		}
+		return g.add(path, strings.Join(alternatives, " | ")), nil
+	}
+
+	switch s.Type {
+	case "object":
+		return g.object(s, path)
+	case "array":
+		item, err := g.visit(s.Items, path+"-item")
+		if err != nil {
+			return "", err
+		}
+		return g.add(path, fmt.Sprintf(`"[" ws ( %s ("," ws %s)* )? "]" ws`, item, item)), nil
+	case "integer":
+		if rule, ok := integerRange(s); ok {
+			return g.add(path, rule), nil
+		}
+		return g.primitive("integer"), nil
+	case "string", "number", "boolean", "null":
+		return g.primitive(s.Type), nil
+	case "":
+		return g.primitive("value"), nil
+	default:
+		return "", fmt.Errorf("unsupported schema type %q at %s", s.Type, path)
+	}
+}
+
+// object returns a rule for an object with the properties of s. Required properties come first,
+// in schema order, followed by the optional ones, each of which may be left out.
+func (g *grammar) object(s *jsonschema.Schema, path string) (string, error) {
+	if s.Properties == nil || s.Properties.Len() == 0 {
+		return g.primitive("object"), nil
+	}
+
+	required := make(map[string]bool, len(s.Required))
+	for _, name := range s.Required {
+		required[name] = true
+	}
+
+	var mandatory, optional []string
+	for pair := s.Properties.Oldest(); pair != nil; pair = pair.Next() {
+		value, err := g.visit(pair.Value, path+"-"+ruleName(pair.Key))
+		if err != nil {
+			return "", err
+		}
+		key, err := literal(pair.Key)
+		if err != nil {
+			return "", err
+		}
+		member := fmt.Sprintf(`%s ":" ws %s`, key, value)
+		if required[pair.Key] {
+			mandatory = append(mandatory, member)
+		} else {
+			optional = append(optional, member)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(`"{" ws `)
+	switch {
+	case len(mandatory) > 0:
+		b.WriteString(strings.Join(mandatory, ` "," ws `))
+		for _, member := range optional {
+			fmt.Fprintf(&b, ` ("," ws %s)?`, member)
+		}
+	default:
+		// Without required properties the first optional one decides whether there are any
+		fmt.Fprintf(&b, "(%s", optional[0])
+		for _, member := range optional[1:] {
+			fmt.Fprintf(&b, ` ("," ws %s)?`, member)
+		}
+		b.WriteString(")?")
+	}
+	b.WriteString(` "}" ws`)
+	return g.add(path, b.String()), nil
+}
+
+// integerRange returns a rule listing every integer of a small bounded range
+func integerRange(s *jsonschema.Schema) (string, bool) {
+	if s.Minimum == "" || s.Maximum == "" {
+		return "", false
+	}
+	minimum, err := strconv.Atoi(s.Minimum.String())
+	if err != nil {
+		return "", false
+	}
+	maximum, err := strconv.Atoi(s.Maximum.String())
+	if err != nil || maximum < minimum || maximum-minimum >= maxEnumeratedRange {
+		return "", false
+	}
+
+	alternatives := make([]string, 0, maximum-minimum+1)
+	for n := minimum; n <= maximum; n++ {
+		alternatives = append(alternatives, strconv.Quote(strconv.Itoa(n)))
+	}
+	return "(" + strings.Join(alternatives, " | ") + ") ws", true
+}
+
+// literal returns a rule matching value encoded as JSON, followed by whitespace
+func literal(value any) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("could not encode %v: %w", value, err)
+	}
+	return quote(string(data)) + " ws", nil
+}
+
+// quote returns text as a GBNF string literal
+func quote(text string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+	return `"` + replacer.Replace(text) + `"`
+}
+
+// ruleName turns a property name into something GBNF accepts in rule names
+func ruleName(name string) string {
+	name = strings.Trim(invalidNameChars.ReplaceAllString(name, "-"), "-")
+	if name == "" {
+		return "property"
+	}
+	return name
+}
+
+// String renders the grammar, one rule per line with the root rule first
+func (g *grammar) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "root ::= %s\n", g.rules["root"])
+	for _, name := range g.names {
+		if name != "root" {
+			fmt.Fprintf(&b, "%s ::= %s\n", name, g.rules[name])
+		}
+	}
+	return b.String()
+}
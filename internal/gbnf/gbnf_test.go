@@ -0,0 +1,92 @@
+package gbnf
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/invopop/jsonschema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type entity struct {
+	Name string `json:"name"`
+	Type string `json:"type" jsonschema:"enum=model,enum=company"`
+}
+
+type item struct {
+	ID              string   `json:"id"`
+	Overview        []string `json:"overview"`
+	IsRelevant      bool     `json:"isRelevant"`
+	Entities        []entity `json:"entities"`
+	ImportanceScore int      `json:"importanceScore" jsonschema:"minimum=1,maximum=10"`
+	Confidence      float64  `json:"confidence"`
+	CommentSummary  string   `json:"commentSummary,omitempty"`
+}
+
+func reflect(v any) *jsonschema.Schema {
+	reflector := jsonschema.Reflector{AllowAdditionalProperties: false, DoNotReference: true}
+	return reflector.Reflect(v)
+}
+
+// rules parses a grammar into its rules by name
+func rules(t *testing.T, grammar string) map[string]string {
+	parsed := map[string]string{}
+	for _, line := range strings.Split(strings.TrimSpace(grammar), "\n") {
+		name, rule, ok := strings.Cut(line, " ::= ")
+		require.True(t, ok, "malformed rule %q", line)
+		require.NotContains(t, parsed, name, "rule %s is defined twice", name)
+		parsed[name] = rule
+	}
+	return parsed
+}
+
+// references returns the rule names a rule refers to, skipping literals and character classes
+func references(rule string) []string {
+	literals := regexp.MustCompile(`"(?:[^"\\]|\\.)*"|\[(?:[^\]\\]|\\.)*\]|\{[0-9,]+\}`)
+	return regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9-]*`).FindAllString(literals.ReplaceAllString(rule, " "), -1)
+}
+
+func TestFromSchema(t *testing.T) {
+	grammar, err := FromSchema(reflect(item{}))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(grammar, "root ::= "), "the root rule comes first")
+
+	parsed := rules(t, grammar)
+	for name, rule := range parsed {
+		for _, ref := range references(rule) {
+			assert.Contains(t, parsed, ref, "rule %s refers to undefined rule %s", name, ref)
+		}
+	}
+
+	assert.Equal(t, `"{" ws "\"id\"" ws ":" ws string "," ws "\"overview\"" ws ":" ws root-overview "," ws "\"isRelevant\"" ws ":" ws boolean "," ws "\"entities\"" ws ":" ws root-entities "," ws "\"importanceScore\"" ws ":" ws root-importanceScore "," ws "\"confidence\"" ws ":" ws number ("," ws "\"commentSummary\"" ws ":" ws string)? "}" ws`, parsed["root"])
+	assert.Equal(t, `"[" ws ( string ("," ws string)* )? "]" ws`, parsed["root-overview"])
+	assert.Equal(t, `("\"model\"" ws | "\"company\"" ws)`, parsed["root-entities-item-type"])
+	assert.Equal(t, `("1" | "2" | "3" | "4" | "5" | "6" | "7" | "8" | "9" | "10") ws`, parsed["root-importanceScore"])
+}
+
+func TestFromSchema_EncodedSchema(t *testing.T) {
+	schema := map[string]any{
+		"type": "array",
+		"items": map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"note": map[string]any{"type": "string"}},
+		},
+	}
+	grammar, err := FromSchema(schema)
+	require.NoError(t, err)
+
+	parsed := rules(t, grammar)
+	assert.Equal(t, `"[" ws ( root-item ("," ws root-item)* )? "]" ws`, parsed["root"])
+	assert.Equal(t, `"{" ws ("\"note\"" ws ":" ws string)? "}" ws`, parsed["root-item"], "without required properties the object may be empty")
+}
+
+func TestFromSchema_Unsupported(t *testing.T) {
+	_, err := FromSchema(map[string]any{"type": "tuple"})
+	assert.ErrorContains(t, err, "unsupported schema type")
+}
+
+func TestQuote(t *testing.T) {
+	assert.Equal(t, `"\"a\\\"b\\nc\""`, quote(`"a\"b\nc"`))
+}
@@ -0,0 +1,140 @@
+// Package health serves the liveness and readiness endpoints of the daemon, so container
+// orchestrators can restart broken instances and hold traffic while a dependency is down.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+)
+
+const (
+	// DefaultTimeout bounds how long a single check may take
+	DefaultTimeout = 5 * time.Second
+	// DefaultCacheFor is how long results are reused, so frequent probes do not hammer dependencies
+	DefaultCacheFor = 10 * time.Second
+)
+
+// Check is a named check of something the daemon depends on
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Result is the outcome of a check
+type Result struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// Report is the outcome of all checks of a Checker
+type Report struct {
+	OK        bool              `json:"ok"`
+	CheckedAt time.Time         `json:"checkedAt"`
+	Checks    map[string]Result `json:"checks"`
+}
+
+// Checker runs a set of checks concurrently and caches their report for a short time
+type Checker struct {
+	checks   []Check
+	timeout  time.Duration
+	cacheFor time.Duration
+	now      func() time.Time
+
+	mu   sync.Mutex
+	last *Report
+}
+
+// NewChecker creates a checker for checks with the default timeout and cache duration
+func NewChecker(checks ...Check) *Checker {
+	return &Checker{checks: checks, timeout: DefaultTimeout, cacheFor: DefaultCacheFor, now: time.Now}
+}
+
+// Check runs the checks, or returns the report of the last run if it is recent enough
+func (c *Checker) Check(ctx context.Context) Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.last != nil && c.now().Sub(c.last.CheckedAt) < c.cacheFor {
+		return *c.last
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	results := make([]Result, len(c.checks))
+	var wg sync.WaitGroup
+	for i, check := range c.checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			results[i] = run(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	report := Report{OK: true, CheckedAt: c.now(), Checks: make(map[string]Result, len(c.checks))}
+	for i, check := range c.checks {
+		report.Checks[check.Name] = results[i]
+		if !results[i].OK {
+			report.OK = false
+			log.Printf("Health check %s failed: %s", check.Name, results[i].Error)
+		}
+	}
+	c.last = &report
+	return report
+}
+
+// run runs a check, giving up when ctx is done even if the check does not watch it
+func run(ctx context.Context, check Check) Result {
+	done := make(chan error, 1)
+	go func() { done <- check.Run(ctx) }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("timed out: %w", ctx.Err())
+	}
+	if err != nil {
+		return Result{Error: err.Error()}
+	}
+	return Result{OK: true}
+}
+
+// Handler serves the report as JSON, with status 200 if every check passed and 503 otherwise
+func (c *Checker) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := c.Check(r.Context())
+		status := http.StatusOK
+		if !report.OK {
+			status = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(status)
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			log.Printf("Health: could not write response: %v", err)
+		}
+	})
+}
+
+// Personas checks that the personas load, which fails if any of them is invalid
+func Personas(source persona.Source) Check {
+	return Check{Name: "personas", Run: func(ctx context.Context) error {
+		personas, err := source.Personas()
+		if err != nil {
+			return err
+		}
+		if len(personas) == 0 {
+			return errors.New("no personas found")
+		}
+		return nil
+	}}
+}
@@ -0,0 +1,75 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckerHandler(t *testing.T) {
+	var llmErr error
+	checker := NewChecker(
+		Check{Name: "llm", Run: func(ctx context.Context) error { return llmErr }},
+		Check{Name: "smtp", Run: func(ctx context.Context) error { return nil }},
+	)
+	checker.cacheFor = 0
+
+	get := func() (int, Report) {
+		recorder := httptest.NewRecorder()
+		checker.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		var report Report
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &report))
+		return recorder.Code, report
+	}
+
+	status, report := get()
+	assert.Equal(t, http.StatusOK, status)
+	assert.True(t, report.OK)
+	assert.Equal(t, Result{OK: true}, report.Checks["llm"])
+
+	llmErr = errors.New("connection refused")
+	status, report = get()
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.False(t, report.OK)
+	assert.Equal(t, Result{Error: "connection refused"}, report.Checks["llm"])
+	assert.True(t, report.Checks["smtp"].OK)
+}
+
+func TestCheckerTimesOutAndCaches(t *testing.T) {
+	calls := 0
+	checker := NewChecker(Check{Name: "slow", Run: func(ctx context.Context) error {
+		calls++
+		time.Sleep(time.Second)
+		return nil
+	}})
+	checker.timeout = 10 * time.Millisecond
+
+	report := checker.Check(context.Background())
+	assert.False(t, report.OK)
+	assert.Contains(t, report.Checks["slow"].Error, "timed out")
+
+	checker.Check(context.Background())
+	assert.Equal(t, 1, calls, "a recent report is reused")
+}
+
+func TestPersonas(t *testing.T) {
+	dir := t.TempDir()
+	check := Personas(persona.Dir(dir))
+	assert.EqualError(t, check.Run(context.Background()), "no personas found")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "localllama.yaml"), []byte("name: LocalLLaMA\nsubreddit: localllama\n"), 0644))
+	assert.NoError(t, check.Run(context.Background()))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.yaml"), []byte("name: Broken\nprovider: rss\n"), 0644))
+	assert.ErrorContains(t, check.Run(context.Background()), "invalid persona in file broken.yaml")
+}
@@ -4,9 +4,12 @@ import (
 	"encoding/base64"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/imageprep"
 )
 
 type ImageFetcher interface {
@@ -14,7 +17,11 @@ type ImageFetcher interface {
 }
 
 // DefaultImageFetcher is the default implementation of imagefetcher.ImageFetcher
-type DefaultImageFetcher struct{}
+type DefaultImageFetcher struct {
+	// Preprocess controls downscaling and re-encoding before base64 encoding.
+	// The zero value sends images verbatim.
+	Preprocess imageprep.Options
+}
 
 // FetchAsBase64 fetches an image from a URL and returns it as a base64-encoded data URI.
 // It implements the imagefetcher.ImageFetcher interface.
@@ -55,6 +62,16 @@ func (dif *DefaultImageFetcher) FetchAsBase64(imageURL string) (string, error) {
 		}
 	}
 
+	if dif.Preprocess.MaxDimension > 0 {
+		prepared, preparedType, err := imageprep.Prepare(imageData, contentType, dif.Preprocess)
+		if err != nil {
+			// Formats the standard library cannot decode (e.g. WebP) are sent as-is
+			log.Printf("Could not preprocess image %s, sending original: %v", imageURL, err)
+		} else {
+			imageData, contentType = prepared, preparedType
+		}
+	}
+
 	base64Encoded := base64.StdEncoding.EncodeToString(imageData)
 	dataURI := fmt.Sprintf("data:%s;base64,%s", contentType, base64Encoded)
 
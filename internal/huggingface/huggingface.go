@@ -0,0 +1,282 @@
+package huggingface
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/bakkerme/ai-news-processor/internal/fetcher"
+)
+
+// DefaultBaseURL is the base URL of the public Hugging Face Hub
+const DefaultBaseURL = "https://huggingface.co"
+
+// maxCardLength limits how much of the model card README is included in the summary
+const maxCardLength = 2000
+
+// RepoType identifies the kind of Hub repository a URL points at
+type RepoType string
+
+const (
+	RepoTypeModel   RepoType = "model"
+	RepoTypeDataset RepoType = "dataset"
+)
+
+// reservedPaths are top-level huggingface.co paths that are not model repositories
+var reservedPaths = map[string]bool{
+	"blog":          true,
+	"chat":          true,
+	"collections":   true,
+	"docs":          true,
+	"join":          true,
+	"learn":         true,
+	"login":         true,
+	"models":        true,
+	"organizations": true,
+	"papers":        true,
+	"posts":         true,
+	"pricing":       true,
+	"settings":      true,
+	"spaces":        true,
+	"tasks":         true,
+}
+
+// Repo identifies a single model or dataset repository on the Hub
+type Repo struct {
+	Type RepoType
+	ID   string // e.g. "Qwen/Qwen3-8B"
+}
+
+// RepoInfo holds the structured metadata pulled from the Hub API
+type RepoInfo struct {
+	Repo           Repo
+	Author         string
+	License        string
+	PipelineTag    string
+	LibraryName    string
+	Tags           []string
+	Downloads      int64
+	Likes          int64
+	ParameterCount int64
+	Gated          bool
+	Card           string // Model card README with front matter removed
+}
+
+// apiResponse mirrors the subset of the /api/models and /api/datasets responses we use
+type apiResponse struct {
+	ID          string          `json:"id"`
+	Author      string          `json:"author"`
+	Downloads   int64           `json:"downloads"`
+	Likes       int64           `json:"likes"`
+	PipelineTag string          `json:"pipeline_tag"`
+	LibraryName string          `json:"library_name"`
+	Tags        []string        `json:"tags"`
+	Gated       json.RawMessage `json:"gated"` // false, "auto" or "manual"
+	CardData    struct {
+		License json.RawMessage `json:"license"` // string or list of strings
+	} `json:"cardData"`
+	Safetensors struct {
+		Total int64 `json:"total"`
+	} `json:"safetensors"`
+}
+
+// Client fetches repository metadata from the Hugging Face Hub API
+type Client struct {
+	fetcher fetcher.Fetcher
+	baseURL string
+}
+
+// NewClient creates a new Hub client using the given fetcher.
+// If baseURL is empty, DefaultBaseURL will be used.
+func NewClient(f fetcher.Fetcher, baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{
+		fetcher: f,
+		baseURL: strings.TrimRight(baseURL, "/"),
+	}
+}
+
+// ParseRepoURL detects huggingface.co model and dataset URLs and returns the repository they reference.
+// The second return value is false for non-Hub URLs and Hub pages that are not a model or dataset.
+func ParseRepoURL(u *url.URL) (Repo, bool) {
+	if u == nil {
+		return Repo{}, false
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	if host != "huggingface.co" && host != "hf.co" {
+		return Repo{}, false
+	}
+
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	repoType := RepoTypeModel
+	if len(segments) > 0 && segments[0] == "datasets" {
+		repoType = RepoTypeDataset
+		segments = segments[1:]
+	}
+
+	// Repositories are always namespaced as owner/name
+	if len(segments) < 2 || segments[0] == "" || segments[1] == "" {
+		return Repo{}, false
+	}
+	if repoType == RepoTypeModel && reservedPaths[segments[0]] {
+		return Repo{}, false
+	}
+
+	return Repo{
+		Type: repoType,
+		ID:   segments[0] + "/" + segments[1],
+	}, true
+}
+
+// FetchRepoInfo pulls the metadata and model card for a repository
+func (c *Client) FetchRepoInfo(ctx context.Context, repo Repo) (*RepoInfo, error) {
+	apiPath := "/api/models/"
+	cardPath := "/"
+	if repo.Type == RepoTypeDataset {
+		apiPath = "/api/datasets/"
+		cardPath = "/datasets/"
+	}
+
+	body, err := c.get(ctx, c.baseURL+apiPath+repo.ID)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch hub metadata for %s: %w", repo.ID, err)
+	}
+
+	var resp apiResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("could not parse hub metadata for %s: %w", repo.ID, err)
+	}
+
+	info := &RepoInfo{
+		Repo:           repo,
+		Author:         resp.Author,
+		License:        parseLicense(resp.CardData.License),
+		PipelineTag:    resp.PipelineTag,
+		LibraryName:    resp.LibraryName,
+		Tags:           resp.Tags,
+		Downloads:      resp.Downloads,
+		Likes:          resp.Likes,
+		ParameterCount: resp.Safetensors.Total,
+		Gated:          len(resp.Gated) > 0 && string(resp.Gated) != "false",
+	}
+
+	// The model card is optional; a missing README should not fail the enrichment
+	card, err := c.get(ctx, c.baseURL+cardPath+repo.ID+"/raw/main/README.md")
+	if err == nil {
+		info.Card = stripFrontMatter(string(card))
+	}
+
+	return info, nil
+}
+
+// get fetches the given URL and returns the response body
+func (c *Client) get(ctx context.Context, rawURL string) ([]byte, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url %s: %w", rawURL, err)
+	}
+
+	resp, err := c.fetcher.Fetch(ctx, u)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// String renders the repository info as a structured summary for inclusion in the entry prompt
+func (i *RepoInfo) String() string {
+	var s strings.Builder
+
+	label := "Hugging Face model"
+	if i.Repo.Type == RepoTypeDataset {
+		label = "Hugging Face dataset"
+	}
+	fmt.Fprintf(&s, "%s: %s\n", label, i.Repo.ID)
+
+	if i.ParameterCount > 0 {
+		fmt.Fprintf(&s, "Parameters: %s\n", FormatParameterCount(i.ParameterCount))
+	}
+	if i.License != "" {
+		fmt.Fprintf(&s, "License: %s\n", i.License)
+	}
+	if i.PipelineTag != "" {
+		fmt.Fprintf(&s, "Task: %s\n", i.PipelineTag)
+	}
+	if i.LibraryName != "" {
+		fmt.Fprintf(&s, "Library: %s\n", i.LibraryName)
+	}
+	fmt.Fprintf(&s, "Downloads (last 30 days): %d\n", i.Downloads)
+	fmt.Fprintf(&s, "Likes: %d\n", i.Likes)
+	if i.Gated {
+		s.WriteString("Access: gated (requires accepting terms)\n")
+	}
+
+	if i.Card != "" {
+		card := i.Card
+		if len(card) > maxCardLength {
+			card = card[:maxCardLength] + "..."
+		}
+		fmt.Fprintf(&s, "Model card:\n%s\n", card)
+	}
+
+	return s.String()
+}
+
+// FormatParameterCount renders a raw parameter count in the B/M/K notation used by model authors
+func FormatParameterCount(count int64) string {
+	switch {
+	case count >= 1_000_000_000:
+		return fmt.Sprintf("%.1fB", float64(count)/1_000_000_000)
+	case count >= 1_000_000:
+		return fmt.Sprintf("%.0fM", float64(count)/1_000_000)
+	case count >= 1_000:
+		return fmt.Sprintf("%.0fK", float64(count)/1_000)
+	default:
+		return fmt.Sprintf("%d", count)
+	}
+}
+
+// parseLicense handles license fields that are either a string or a list of strings
+func parseLicense(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+
+	var single string
+	if err := json.Unmarshal(raw, &single); err == nil {
+		return single
+	}
+
+	var list []string
+	if err := json.Unmarshal(raw, &list); err == nil {
+		return strings.Join(list, ", ")
+	}
+
+	return ""
+}
+
+// stripFrontMatter removes the YAML metadata block at the top of a model card
+func stripFrontMatter(card string) string {
+	card = strings.TrimSpace(card)
+	if !strings.HasPrefix(card, "---") {
+		return card
+	}
+
+	rest := card[3:]
+	end := strings.Index(rest, "\n---")
+	if end == -1 {
+		return card
+	}
+
+	return strings.TrimSpace(rest[end+len("\n---"):])
+}
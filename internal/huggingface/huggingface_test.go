@@ -0,0 +1,147 @@
+package huggingface
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/bakkerme/ai-news-processor/internal/fetcher"
+	"github.com/bakkerme/ai-news-processor/internal/http/retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRepoURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		expected Repo
+		ok       bool
+	}{
+		{
+			name:     "model url",
+			url:      "https://huggingface.co/Qwen/Qwen3-8B",
+			expected: Repo{Type: RepoTypeModel, ID: "Qwen/Qwen3-8B"},
+			ok:       true,
+		},
+		{
+			name:     "model url with file path",
+			url:      "https://huggingface.co/unsloth/Qwen3-8B-GGUF/tree/main",
+			expected: Repo{Type: RepoTypeModel, ID: "unsloth/Qwen3-8B-GGUF"},
+			ok:       true,
+		},
+		{
+			name:     "dataset url",
+			url:      "https://huggingface.co/datasets/HuggingFaceFW/fineweb",
+			expected: Repo{Type: RepoTypeDataset, ID: "HuggingFaceFW/fineweb"},
+			ok:       true,
+		},
+		{
+			name:     "short hf.co domain",
+			url:      "https://hf.co/google/gemma-3-4b-it",
+			expected: Repo{Type: RepoTypeModel, ID: "google/gemma-3-4b-it"},
+			ok:       true,
+		},
+		{
+			name: "blog post",
+			url:  "https://huggingface.co/blog/smollm3",
+			ok:   false,
+		},
+		{
+			name: "spaces are not enriched",
+			url:  "https://huggingface.co/spaces/open-llm-leaderboard/open_llm_leaderboard",
+			ok:   false,
+		},
+		{
+			name: "organization page",
+			url:  "https://huggingface.co/Qwen",
+			ok:   false,
+		},
+		{
+			name: "non hub domain",
+			url:  "https://github.com/Qwen/Qwen3",
+			ok:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			require.NoError(t, err)
+
+			repo, ok := ParseRepoURL(u)
+			assert.Equal(t, tt.ok, ok)
+			if tt.ok {
+				assert.Equal(t, tt.expected, repo)
+			}
+		})
+	}
+}
+
+func TestClient_FetchRepoInfo(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/models/Qwen/Qwen3-8B", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"id": "Qwen/Qwen3-8B",
+			"author": "Qwen",
+			"downloads": 123456,
+			"likes": 789,
+			"pipeline_tag": "text-generation",
+			"library_name": "transformers",
+			"tags": ["transformers", "safetensors"],
+			"gated": false,
+			"cardData": {"license": "apache-2.0"},
+			"safetensors": {"total": 8190735360}
+		}`))
+	})
+	mux.HandleFunc("/Qwen/Qwen3-8B/raw/main/README.md", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("---\nlicense: apache-2.0\n---\n\n# Qwen3-8B\n\nThe latest Qwen model."))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	f := fetcher.NewHTTPFetcher(server.Client(), retry.RetryConfig{}, "")
+	client := NewClient(f, server.URL)
+
+	info, err := client.FetchRepoInfo(context.Background(), Repo{Type: RepoTypeModel, ID: "Qwen/Qwen3-8B"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "apache-2.0", info.License)
+	assert.Equal(t, int64(8190735360), info.ParameterCount)
+	assert.Equal(t, int64(123456), info.Downloads)
+	assert.False(t, info.Gated)
+	assert.Equal(t, "# Qwen3-8B\n\nThe latest Qwen model.", info.Card)
+
+	summary := info.String()
+	assert.Contains(t, summary, "Hugging Face model: Qwen/Qwen3-8B")
+	assert.Contains(t, summary, "Parameters: 8.2B")
+	assert.Contains(t, summary, "License: apache-2.0")
+	assert.Contains(t, summary, "Downloads (last 30 days): 123456")
+	assert.Contains(t, summary, "The latest Qwen model.")
+}
+
+func TestClient_FetchRepoInfo_NotFound(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	f := fetcher.NewHTTPFetcher(server.Client(), retry.RetryConfig{}, "")
+	client := NewClient(f, server.URL)
+
+	_, err := client.FetchRepoInfo(context.Background(), Repo{Type: RepoTypeModel, ID: "missing/model"})
+	assert.Error(t, err)
+}
+
+func TestParseLicense(t *testing.T) {
+	assert.Equal(t, "mit", parseLicense([]byte(`"mit"`)))
+	assert.Equal(t, "mit, apache-2.0", parseLicense([]byte(`["mit", "apache-2.0"]`)))
+	assert.Equal(t, "", parseLicense(nil))
+}
+
+func TestFormatParameterCount(t *testing.T) {
+	assert.Equal(t, "70.6B", FormatParameterCount(70_553_706_496))
+	assert.Equal(t, "135M", FormatParameterCount(134_515_008))
+	assert.Equal(t, "512", FormatParameterCount(512))
+}
@@ -0,0 +1,149 @@
+package imageprep
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"math"
+)
+
+// Options controls how images are prepared before being sent to a vision model
+type Options struct {
+	MaxDimension int // Longest edge in pixels; larger images are downscaled. 0 disables preprocessing
+	MaxBytes     int // Images larger than this are re-encoded even when within MaxDimension. 0 disables the check
+	JPEGQuality  int // Quality used when re-encoding opaque images as JPEG
+}
+
+// DefaultOptions keeps images large enough for vision models to read text in screenshots
+// while avoiding multi-megabyte payloads
+var DefaultOptions = Options{
+	MaxDimension: 1536,
+	MaxBytes:     1 << 20,
+	JPEGQuality:  85,
+}
+
+// Prepare downscales, flattens animated GIFs to their first frame and re-encodes an image.
+// Images that are already within limits are returned unchanged. Opaque images are re-encoded
+// as JPEG and images with transparency as PNG. An error is returned for formats that cannot be
+// decoded (e.g. WebP), in which case callers should fall back to the original data.
+func Prepare(data []byte, contentType string, opts Options) ([]byte, string, error) {
+	if opts.MaxDimension <= 0 {
+		return data, contentType, nil
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("imageprep: could not decode image config: %w", err)
+	}
+
+	needsResize := config.Width > opts.MaxDimension || config.Height > opts.MaxDimension
+	tooLarge := opts.MaxBytes > 0 && len(data) > opts.MaxBytes
+
+	var img image.Image
+	animated := false
+	if format == "gif" {
+		anim, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return nil, "", fmt.Errorf("imageprep: could not decode gif: %w", err)
+		}
+		animated = len(anim.Image) > 1
+		img = firstFrame(anim)
+	}
+
+	if !needsResize && !tooLarge && !animated {
+		return data, contentType, nil
+	}
+
+	if img == nil {
+		img, _, err = image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, "", fmt.Errorf("imageprep: could not decode %s image: %w", format, err)
+		}
+	}
+
+	if needsResize {
+		img = downscale(img, opts.MaxDimension)
+	}
+
+	var buf bytes.Buffer
+	outType := "image/png"
+	if isOpaque(img) {
+		outType = "image/jpeg"
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: opts.JPEGQuality})
+	} else {
+		err = png.Encode(&buf, img)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("imageprep: could not encode image: %w", err)
+	}
+
+	// Re-encoding only for size can make already well-compressed images bigger
+	if !needsResize && !animated && buf.Len() >= len(data) {
+		return data, contentType, nil
+	}
+
+	return buf.Bytes(), outType, nil
+}
+
+// firstFrame renders the first frame of a GIF onto a canvas of the full logical screen size
+func firstFrame(anim *gif.GIF) image.Image {
+	frame := anim.Image[0]
+	bounds := image.Rect(0, 0, anim.Config.Width, anim.Config.Height)
+	if bounds.Empty() {
+		return frame
+	}
+	canvas := image.NewRGBA(bounds)
+	draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+	return canvas
+}
+
+// downscale resizes img so its longest edge is maxDim, averaging the source pixels covered by
+// each destination pixel
+func downscale(img image.Image, maxDim int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	scale := float64(maxDim) / float64(max(w, h))
+	dw := max(1, int(math.Round(float64(w)*scale)))
+	dh := max(1, int(math.Round(float64(h)*scale)))
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		sy0 := y * h / dh
+		sy1 := max((y+1)*h/dh, sy0+1)
+		for x := 0; x < dw; x++ {
+			sx0 := x * w / dw
+			sx1 := max((x+1)*w/dw, sx0+1)
+
+			var r, g, bl, a, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					cr, cg, cb, ca := img.At(b.Min.X+sx, b.Min.Y+sy).RGBA()
+					r += uint64(cr)
+					g += uint64(cg)
+					bl += uint64(cb)
+					a += uint64(ca)
+					n++
+				}
+			}
+
+			i := dst.PixOffset(x, y)
+			dst.Pix[i+0] = uint8(r / n >> 8)
+			dst.Pix[i+1] = uint8(g / n >> 8)
+			dst.Pix[i+2] = uint8(bl / n >> 8)
+			dst.Pix[i+3] = uint8(a / n >> 8)
+		}
+	}
+	return dst
+}
+
+// isOpaque reports whether an image has no transparent pixels
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
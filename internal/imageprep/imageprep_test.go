@@ -0,0 +1,108 @@
+package imageprep
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func solidImage(w, h int, c color.Color) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestPrepare_SmallImageUnchanged(t *testing.T) {
+	data := encodePNG(t, solidImage(100, 50, color.NRGBA{R: 255, A: 255}))
+
+	out, contentType, err := Prepare(data, "image/png", DefaultOptions)
+	require.NoError(t, err)
+	assert.Equal(t, data, out)
+	assert.Equal(t, "image/png", contentType)
+}
+
+func TestPrepare_DisabledReturnsOriginal(t *testing.T) {
+	data := []byte("not an image")
+
+	out, contentType, err := Prepare(data, "image/webp", Options{})
+	require.NoError(t, err)
+	assert.Equal(t, data, out)
+	assert.Equal(t, "image/webp", contentType)
+}
+
+func TestPrepare_DownscalesLargeOpaqueImageToJPEG(t *testing.T) {
+	data := encodePNG(t, solidImage(400, 200, color.NRGBA{G: 200, A: 255}))
+
+	out, contentType, err := Prepare(data, "image/png", Options{MaxDimension: 100, JPEGQuality: 85})
+	require.NoError(t, err)
+	assert.Equal(t, "image/jpeg", contentType)
+
+	img, err := jpeg.Decode(bytes.NewReader(out))
+	require.NoError(t, err)
+	assert.Equal(t, 100, img.Bounds().Dx())
+	assert.Equal(t, 50, img.Bounds().Dy())
+}
+
+func TestPrepare_KeepsTransparencyAsPNG(t *testing.T) {
+	data := encodePNG(t, solidImage(300, 300, color.NRGBA{B: 255, A: 128}))
+
+	out, contentType, err := Prepare(data, "image/png", Options{MaxDimension: 150})
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", contentType)
+
+	img, err := png.Decode(bytes.NewReader(out))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 150, 150), img.Bounds())
+	_, _, _, a := img.At(75, 75).RGBA()
+	assert.InDelta(t, 128, a>>8, 1)
+}
+
+func TestPrepare_FlattensAnimatedGIF(t *testing.T) {
+	anim := &gif.GIF{}
+	for _, c := range []color.Color{color.White, color.Black} {
+		frame := image.NewPaletted(image.Rect(0, 0, 20, 20), palette.Plan9)
+		for y := 0; y < 20; y++ {
+			for x := 0; x < 20; x++ {
+				frame.Set(x, y, c)
+			}
+		}
+		anim.Image = append(anim.Image, frame)
+		anim.Delay = append(anim.Delay, 10)
+	}
+	var buf bytes.Buffer
+	require.NoError(t, gif.EncodeAll(&buf, anim))
+
+	out, contentType, err := Prepare(buf.Bytes(), "image/gif", DefaultOptions)
+	require.NoError(t, err)
+	assert.Equal(t, "image/jpeg", contentType)
+
+	img, err := jpeg.Decode(bytes.NewReader(out))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 20, 20), img.Bounds())
+	r, _, _, _ := img.At(10, 10).RGBA()
+	assert.Greater(t, r>>8, uint32(200), "expected the first (white) frame")
+}
+
+func TestPrepare_UndecodableImage(t *testing.T) {
+	_, _, err := Prepare([]byte("RIFF....WEBP"), "image/webp", DefaultOptions)
+	assert.Error(t, err)
+}
@@ -0,0 +1,140 @@
+package itemstore
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
+	"github.com/bakkerme/ai-news-processor/models"
+)
+
+// Record is an item that was sent in a digest, as stored on disk
+type Record struct {
+	SentAt      time.Time   `json:"sentAt"`
+	RefreshedAt *time.Time  `json:"refreshedAt,omitempty"` // When the item was last summarized again after it was sent
+	Item        models.Item `json:"item"`
+}
+
+// Store persists sent items per persona as JSON Lines files in a directory, so later runs
+// (such as weekly rollups) can build on previous digests
+type Store struct {
+	dir string
+}
+
+// New creates a store that keeps its files in dir
+func New(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// path returns the file holding the items of a persona
+func (s *Store) path(personaName string) string {
+	name := unsafeFileChars.ReplaceAllString(strings.ToLower(personaName), "_")
+	return filepath.Join(s.dir, name+".jsonl")
+}
+
+// Append records items sent to a persona at sentAt. The feed entry attached to each item is
+// not stored, apart from its publish time, to keep the files small.
+func (s *Store) Append(personaName string, items []models.Item, sentAt time.Time) error {
+	records := make([]Record, 0, len(items))
+	for _, item := range items {
+		records = append(records, Record{SentAt: sentAt, Item: item})
+	}
+	return s.write(personaName, records)
+}
+
+// Refresh records a new version of an item sent to a persona at sentAt, such as a megathread
+// summarized again from its latest comments. Since returns it in place of the version sent.
+func (s *Store) Refresh(personaName string, item models.Item, sentAt time.Time, refreshedAt time.Time) error {
+	return s.write(personaName, []Record{{SentAt: sentAt, RefreshedAt: &refreshedAt, Item: item}})
+}
+
+// write appends records to the file of a persona
+func (s *Store) write(personaName string, records []Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("could not create item store directory: %w", err)
+	}
+
+	file, err := os.OpenFile(s.path(personaName), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("could not open item store: %w", err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, record := range records {
+		record.Item.Entry = feeds.Entry{Published: record.Item.Entry.Published}
+		if err := encoder.Encode(record); err != nil {
+			return fmt.Errorf("could not encode item %s: %w", record.Item.ID, err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("could not write item store: %w", err)
+	}
+	return nil
+}
+
+// HasItems reports whether any items were ever stored for a persona
+func (s *Store) HasItems(personaName string) (bool, error) {
+	info, err := os.Stat(s.path(personaName))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("could not check item store: %w", err)
+	}
+	return info.Size() > 0, nil
+}
+
+// Since returns the items sent to a persona at or after the given time, oldest first.
+// An item sent more than once is returned once, with its latest record.
+func (s *Store) Since(personaName string, since time.Time) ([]Record, error) {
+	file, err := os.Open(s.path(personaName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("could not open item store: %w", err)
+	}
+	defer file.Close()
+
+	var records []Record
+	index := make(map[string]int)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4<<20)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("invalid item store record on line %d: %w", line, err)
+		}
+		if record.SentAt.Before(since) {
+			continue
+		}
+		if i, ok := index[record.Item.ID]; ok && record.Item.ID != "" {
+			records[i] = record
+			continue
+		}
+		index[record.Item.ID] = len(records)
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read item store: %w", err)
+	}
+	return records, nil
+}
@@ -0,0 +1,98 @@
+package itemstore
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
+	"github.com/bakkerme/ai-news-processor/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_AppendAndSince(t *testing.T) {
+	store := New(t.TempDir())
+	now := time.Date(2025, time.March, 7, 12, 0, 0, 0, time.UTC)
+	published := now.Add(-48 * time.Hour)
+
+	old := []models.Item{{ID: "a", Title: "Old"}}
+	require.NoError(t, store.Append("Local LLaMA", old, now.AddDate(0, 0, -10)))
+
+	recent := []models.Item{
+		{ID: "b", Title: "First", Entry: feeds.Entry{Title: "raw", Content: "long content", Published: published}},
+		{ID: "c", Title: "Second"},
+	}
+	require.NoError(t, store.Append("Local LLaMA", recent, now.AddDate(0, 0, -2)))
+	require.NoError(t, store.Append("Local LLaMA", []models.Item{{ID: "b", Title: "First, updated"}}, now.AddDate(0, 0, -1)))
+	require.NoError(t, store.Append("Other", []models.Item{{ID: "d"}}, now))
+	require.NoError(t, store.Append("Local LLaMA", nil, now))
+
+	records, err := store.Since("Local LLaMA", now.AddDate(0, 0, -7))
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "First, updated", records[0].Item.Title, "a repeated item keeps its latest record")
+	assert.Equal(t, "Second", records[1].Item.Title)
+	assert.Equal(t, now.AddDate(0, 0, -2), records[1].SentAt.UTC())
+
+	all, err := store.Since("Local LLaMA", time.Time{})
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, "a", all[0].Item.ID)
+
+	none, err := store.Since("Unknown", time.Time{})
+	require.NoError(t, err)
+	assert.Empty(t, none)
+}
+
+func TestStore_StripsEntryContent(t *testing.T) {
+	dir := t.TempDir()
+	store := New(dir)
+	published := time.Date(2025, time.March, 5, 8, 0, 0, 0, time.UTC)
+	item := models.Item{ID: "b", Entry: feeds.Entry{Content: "long content", Published: published}}
+	require.NoError(t, store.Append("test", []models.Item{item}, published))
+
+	data, err := os.ReadFile(store.path("test"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "long content")
+
+	records, err := store.Since("test", time.Time{})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.True(t, published.Equal(records[0].Item.Entry.Published))
+}
+
+func TestStore_HasItems(t *testing.T) {
+	store := New(t.TempDir())
+
+	has, err := store.HasItems("Local LLaMA")
+	require.NoError(t, err)
+	assert.False(t, has)
+
+	require.NoError(t, store.Append("Local LLaMA", []models.Item{{ID: "a"}}, time.Now()))
+	has, err = store.HasItems("Local LLaMA")
+	require.NoError(t, err)
+	assert.True(t, has)
+
+	has, err = store.HasItems("Other")
+	require.NoError(t, err)
+	assert.False(t, has)
+}
+
+func TestStore_Refresh(t *testing.T) {
+	store := New(t.TempDir())
+	sentAt := time.Date(2025, time.March, 7, 8, 0, 0, 0, time.UTC)
+	refreshedAt := sentAt.Add(6 * time.Hour)
+
+	require.NoError(t, store.Append("test", []models.Item{{ID: "a", Summary: "Early comments"}, {ID: "b"}}, sentAt))
+	require.NoError(t, store.Refresh("test", models.Item{ID: "a", Summary: "Later comments"}, sentAt, refreshedAt))
+
+	records, err := store.Since("test", sentAt)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "Later comments", records[0].Item.Summary, "a refreshed item replaces the sent one in place")
+	assert.Equal(t, sentAt, records[0].SentAt.UTC())
+	require.NotNil(t, records[0].RefreshedAt)
+	assert.Equal(t, refreshedAt, records[0].RefreshedAt.UTC())
+	assert.Nil(t, records[1].RefreshedAt)
+}
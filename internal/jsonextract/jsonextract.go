@@ -0,0 +1,256 @@
+// Package jsonextract finds the JSON value in an LLM response. Models wrap their JSON in code
+// fences, put prose around it, add comments and trailing commas, and write raw newlines inside
+// strings; Extract copes with all of these without cutting the JSON short when one of its strings
+// contains a fence or a brace.
+package jsonextract
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// fenceLanguages are the code fence info strings that mark JSON. An empty info string counts
+// too, as models often leave it out.
+var fenceLanguages = map[string]bool{"": true, "json": true, "jsonc": true, "json5": true}
+
+// Extract returns the JSON value in response, repaired so it parses, and reports whether it
+// found one. JSON in a code fence is preferred over JSON elsewhere in the response, and of several
+// values the longest is returned. When there is no valid value, Extract returns its best guess:
+// the text from the first opening brace or bracket, or the trimmed response.
+func Extract(response string) (string, bool) {
+	regions := fencedJSON(response)
+	regions = append(regions, response)
+	for _, region := range regions {
+		if value, ok := longestValue(region); ok {
+			return value, true
+		}
+	}
+
+	for _, region := range regions {
+		if start := strings.IndexAny(region, "{["); start != -1 {
+			return strings.TrimSpace(Repair(region[start:])), false
+		}
+	}
+	return strings.TrimSpace(response), false
+}
+
+// longestValue returns the longest JSON object or array in text that parses after Repair.
+// The values nested in a valid one are not considered separately.
+func longestValue(text string) (string, bool) {
+	var best string
+	// Brackets left open by a value cut off at the end of text cannot start a complete value
+	// either, so they are not scanned again
+	unclosed := map[int]bool{}
+	for i := 0; i < len(text); i++ {
+		if (text[i] != '{' && text[i] != '[') || unclosed[i] {
+			continue
+		}
+		end, open := balanced(text, i)
+		if end == -1 {
+			for _, position := range open {
+				unclosed[position] = true
+			}
+			continue
+		}
+		candidate := Repair(text[i:end])
+		if !json.Valid([]byte(candidate)) {
+			continue
+		}
+		if len(candidate) > len(best) {
+			best = candidate
+		}
+		i = end - 1
+	}
+	return best, best != ""
+}
+
+// scanner tracks whether a position of a JSON-like text is inside a string or a comment
+type scanner struct {
+	inString     bool
+	escaped      bool
+	lineComment  bool
+	blockComment bool
+}
+
+// step moves the scanner past text[i] and reports how many bytes it consumed and whether they are
+// code, as opposed to part of a string or comment. Comment and string delimiters count as not code.
+func (s *scanner) step(text string, i int) (int, bool) {
+	c := text[i]
+	switch {
+	case s.inString:
+		switch {
+		case s.escaped:
+			s.escaped = false
+		case c == '\\':
+			s.escaped = true
+		case c == '"':
+			s.inString = false
+		}
+		return 1, false
+	case s.lineComment:
+		if c == '\n' {
+			s.lineComment = false
+		}
+		return 1, false
+	case s.blockComment:
+		if strings.HasPrefix(text[i:], "*/") {
+			s.blockComment = false
+			return 2, false
+		}
+		return 1, false
+	case c == '"':
+		s.inString = true
+		return 1, false
+	case strings.HasPrefix(text[i:], "//"):
+		s.lineComment = true
+		return 2, false
+	case strings.HasPrefix(text[i:], "/*"):
+		s.blockComment = true
+		return 2, false
+	}
+	return 1, true
+}
+
+// balanced returns the end of the object or array starting at text[start], skipping brackets in
+// strings and comments. It returns -1 when the brackets do not match, and when the value is cut
+// off also the positions of the brackets that are still open.
+func balanced(text string, start int) (int, []int) {
+	var open []int
+	var s scanner
+	for i := start; i < len(text); {
+		n, code := s.step(text, i)
+		if code {
+			switch c := text[i]; c {
+			case '{', '[':
+				open = append(open, i)
+			case '}', ']':
+				if len(open) == 0 || closer(text[open[len(open)-1]]) != c {
+					return -1, nil
+				}
+				open = open[:len(open)-1]
+				if len(open) == 0 {
+					return i + 1, nil
+				}
+			}
+		}
+		i += n
+	}
+	return -1, open
+}
+
+// closer returns the bracket closing opener
+func closer(opener byte) byte {
+	if opener == '{' {
+		return '}'
+	}
+	return ']'
+}
+
+// Repair removes what models add to JSON that the standard does not allow: // and /* */
+// comments, commas before a closing brace or bracket, and raw control characters such as
+// newlines inside strings, which are escaped. Valid JSON is returned unchanged.
+func Repair(text string) string {
+	var b strings.Builder
+	b.Grow(len(text))
+	var s scanner
+	var pendingCommas []int // positions in b of commas that may turn out to be trailing
+	for i := 0; i < len(text); {
+		c := text[i]
+		wasString := s.inString
+		n, code := s.step(text, i)
+		switch {
+		case wasString || s.inString:
+			if c < 0x20 {
+				b.WriteString(escapeControl(c))
+			} else {
+				b.WriteByte(c)
+			}
+			if !wasString {
+				pendingCommas = nil
+			}
+		case !code:
+			// Comments are dropped, apart from the newline ending a line comment
+			if c == '\n' {
+				b.WriteByte(c)
+			}
+		case c == '}' || c == ']':
+			for j := len(pendingCommas) - 1; j >= 0; j-- {
+				dropByte(&b, pendingCommas[j])
+			}
+			pendingCommas = nil
+			b.WriteByte(c)
+		case c == ',':
+			pendingCommas = append(pendingCommas, b.Len())
+			b.WriteByte(c)
+		default:
+			if !isSpace(c) {
+				pendingCommas = nil
+			}
+			b.WriteByte(c)
+		}
+		i += n
+	}
+	return b.String()
+}
+
+// escapeControl returns the JSON escape of a control character
+func escapeControl(c byte) string {
+	switch c {
+	case '\n':
+		return `\n`
+	case '\r':
+		return `\r`
+	case '\t':
+		return `\t`
+	default:
+		return fmt.Sprintf(`\u%04x`, c)
+	}
+}
+
+// dropByte removes the byte at position i of b
+func dropByte(b *strings.Builder, i int) {
+	text := b.String()
+	b.Reset()
+	b.WriteString(text[:i])
+	b.WriteString(text[i+1:])
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// fencedJSON returns the contents of the JSON code fences in text, in order. A fence runs from a
+// line starting with ``` to a line that is only ```, or to the end of the text. Models sometimes
+// put the language on the line after the opening fence, which is handled too.
+func fencedJSON(text string) []string {
+	var regions []string
+	lines := strings.SplitAfter(text, "\n")
+	for i := 0; i < len(lines); i++ {
+		opening := strings.TrimSpace(lines[i])
+		if !strings.HasPrefix(opening, "```") {
+			continue
+		}
+		language := strings.ToLower(strings.TrimSpace(strings.TrimLeft(opening, "`")))
+		if !fenceLanguages[language] {
+			// Skip the fence, so its closing line is not taken for an opening one
+			for i++; i < len(lines) && strings.TrimSpace(lines[i]) != "```"; i++ {
+			}
+			continue
+		}
+
+		start := i + 1
+		if language == "" && start < len(lines) && strings.EqualFold(strings.TrimSpace(lines[start]), "json") {
+			start++
+		}
+		end := start
+		for end < len(lines) && strings.TrimSpace(lines[end]) != "```" {
+			end++
+		}
+		if start < end {
+			regions = append(regions, strings.Join(lines[start:end], ""))
+		}
+		i = end
+	}
+	return regions
+}
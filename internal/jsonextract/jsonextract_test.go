@@ -0,0 +1,151 @@
+package jsonextract
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		ok       bool
+	}{
+		{
+			name:     "plain object",
+			input:    `{"a": 1}`,
+			expected: `{"a": 1}`,
+			ok:       true,
+		},
+		{
+			name:     "fenced",
+			input:    "```json\n{\"a\": 1}\n```",
+			expected: `{"a": 1}`,
+			ok:       true,
+		},
+		{
+			name:     "fence closed on the last line of the json",
+			input:    "```json\n{\"a\": 1}```",
+			expected: `{"a": 1}`,
+			ok:       true,
+		},
+		{
+			name:     "language on the line after the fence",
+			input:    "```\njson\n[1, 2]\n```",
+			expected: `[1, 2]`,
+			ok:       true,
+		},
+		{
+			name:     "fence in a string",
+			input:    "```json\n{\"code\": \"```\\nls\\n```\"}\n```",
+			expected: "{\"code\": \"```\\nls\\n```\"}",
+			ok:       true,
+		},
+		{
+			name:     "braces in a string",
+			input:    `Result: {"text": "a } and a {"} done`,
+			expected: `{"text": "a } and a {"}`,
+			ok:       true,
+		},
+		{
+			name:     "fenced json preferred over json in the prose",
+			input:    "I considered {\"draft\": true, \"longer\": \"than the final answer\"}.\n```json\n{\"final\": 1}\n```",
+			expected: `{"final": 1}`,
+			ok:       true,
+		},
+		{
+			name:     "other fences skipped",
+			input:    "```python\nprint({'a': 1})\n```\n{\"a\": 1}",
+			expected: `{"a": 1}`,
+			ok:       true,
+		},
+		{
+			name:     "longest value",
+			input:    "See [1].\n{\"items\": [1, 2, 3]}",
+			expected: `{"items": [1, 2, 3]}`,
+			ok:       true,
+		},
+		{
+			name:     "comments and trailing commas",
+			input:    "{\n  \"a\": [1, 2,], /* note */\n  \"b\": \"http://example.com\", // link\n}",
+			expected: "{\n  \"a\": [1, 2], \n  \"b\": \"http://example.com\" \n}",
+			ok:       true,
+		},
+		{
+			name:     "raw newline in a string",
+			input:    "{\"a\": \"line one\nline two\"}",
+			expected: `{"a": "line one\nline two"}`,
+			ok:       true,
+		},
+		{
+			name:     "truncated",
+			input:    "Sure:\n{\"a\": \"unfinished",
+			expected: `{"a": "unfinished`,
+		},
+		{
+			name:     "no json",
+			input:    "  I cannot help with that.  ",
+			expected: "I cannot help with that.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, ok := Extract(tt.input)
+			assert.Equal(t, tt.expected, result)
+			assert.Equal(t, tt.ok, ok)
+		})
+	}
+}
+
+func TestRepair(t *testing.T) {
+	assert.Equal(t, `{"a": "b\u0001"}`, Repair("{\"a\": \"b\x01\"}"))
+	assert.Equal(t, `{"a": "x,}"}`, Repair(`{"a": "x,}"}`), "commas in strings are kept")
+	assert.Equal(t, `{"a": "//"}`, Repair(`{"a": "//"}`), "comment markers in strings are kept")
+}
+
+var seeds = []string{
+	"",
+	`{"a": 1}`,
+	"```json\n{\"a\": [1, 2,]}\n```",
+	"<json>{\"a\": \"b\"}</json>",
+	"```\njson\n[{\"a\": null}]\n```",
+	"{\"a\": \"line\nbreak\"} // done",
+	"text { \"a\": /* c */ 1, } [",
+	`{"a": "\"}\\"}`,
+	"```json\n{\"a\": \"```\"}",
+}
+
+func FuzzExtract(f *testing.F) {
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, response string) {
+		result, ok := Extract(response)
+		if ok && !json.Valid([]byte(result)) {
+			t.Errorf("Extract(%q) = %q, which is not valid JSON", response, result)
+		}
+		if !ok && strings.ContainsAny(response, "{[") && result == "" && strings.TrimSpace(response) != "" {
+			t.Errorf("Extract(%q) returned nothing for a response with brackets", response)
+		}
+	})
+}
+
+func FuzzRepair(f *testing.F) {
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, text string) {
+		repaired := Repair(text)
+		if json.Valid([]byte(text)) && repaired != text {
+			t.Errorf("Repair changed valid JSON %q into %q", text, repaired)
+		}
+		if again := Repair(repaired); again != repaired {
+			t.Errorf("Repair(%q) = %q, but repairing it again gives %q", text, repaired, again)
+		}
+	})
+}
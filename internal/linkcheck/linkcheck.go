@@ -0,0 +1,185 @@
+// Package linkcheck verifies that links and images still resolve before a digest is sent. Pages
+// get taken down and image hosts expire their links between fetching a post and sending its
+// digest, and a broken image or dead link makes the whole digest look broken.
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// DefaultTTL is how long the result of a check is reused
+const DefaultTTL = time.Hour
+
+// userAgent identifies the checker. Some sites refuse requests without one.
+const userAgent = "ai-news-processor-linkcheck/1.0"
+
+// Status is the outcome of checking a URL
+type Status int
+
+const (
+	// Unknown means the check could not tell, such as after a timeout, a server error or a bot
+	// challenge. Unknown URLs are used as if they were alive.
+	Unknown Status = iota
+	Alive
+	Dead
+)
+
+func (s Status) String() string {
+	switch s {
+	case Alive:
+		return "alive"
+	case Dead:
+		return "dead"
+	default:
+		return "unknown"
+	}
+}
+
+type result struct {
+	status    Status
+	checkedAt time.Time
+}
+
+// Checker checks URLs with HEAD requests, falling back to a one-byte GET for servers that do not
+// answer HEAD, and caches the results for its TTL
+type Checker struct {
+	client *http.Client
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]result
+}
+
+// New creates a checker. If client is nil, a client with a 10-second timeout is used. If ttl is
+// 0, DefaultTTL is used.
+func New(client *http.Client, ttl time.Duration) *Checker {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if ttl == 0 {
+		ttl = DefaultTTL
+	}
+	return &Checker{
+		client: client,
+		ttl:    ttl,
+		now:    time.Now,
+		cache:  make(map[string]result),
+	}
+}
+
+// CheckLink checks a link to a page. Only answers that the page is gone, 404 Not Found and 410
+// Gone, and hosts that do not exist or refuse connections count as dead; many sites answer
+// automated requests with 403 or 429 while the page works fine in a browser.
+func (c *Checker) CheckLink(ctx context.Context, rawURL string) Status {
+	return c.check(ctx, rawURL, false)
+}
+
+// CheckImage checks a link to an image. Any error response counts as dead, as does a response
+// that is not an image, since a mail client cannot show either.
+func (c *Checker) CheckImage(ctx context.Context, rawURL string) Status {
+	return c.check(ctx, rawURL, true)
+}
+
+func (c *Checker) check(ctx context.Context, rawURL string, image bool) Status {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		// Inline attachments (cid:) and data URLs are not checked
+		return Unknown
+	}
+
+	key := rawURL
+	if image {
+		key = "image " + rawURL
+	}
+	c.mu.Lock()
+	cached, ok := c.cache[key]
+	c.mu.Unlock()
+	if ok && c.now().Sub(cached.checkedAt) < c.ttl {
+		return cached.status
+	}
+
+	status := c.request(ctx, u, image)
+	c.mu.Lock()
+	c.cache[key] = result{status: status, checkedAt: c.now()}
+	c.mu.Unlock()
+	return status
+}
+
+// request sends the HEAD request, and the GET request if the server does not answer HEAD
+func (c *Checker) request(ctx context.Context, u *url.URL, image bool) Status {
+	resp, err := c.do(ctx, http.MethodHead, u)
+	if err == nil && headUnsupported(resp.StatusCode) {
+		resp, err = c.do(ctx, http.MethodGet, u)
+	}
+	if err != nil {
+		if unreachable(err) {
+			return Dead
+		}
+		return Unknown
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return Dead
+	case image && resp.StatusCode >= 400:
+		return Dead
+	case resp.StatusCode >= 400:
+		return Unknown
+	}
+
+	if image {
+		// Imgur redirects deleted images to a "removed" placeholder image
+		if resp.Request != nil && strings.HasSuffix(resp.Request.URL.Path, "/removed.png") {
+			return Dead
+		}
+		contentType := resp.Header.Get("Content-Type")
+		if contentType != "" && !strings.HasPrefix(contentType, "image/") && !strings.HasPrefix(contentType, "application/octet-stream") {
+			return Dead
+		}
+	}
+	return Alive
+}
+
+// do sends a request and closes the response body, as only the status and headers are used
+func (c *Checker) do(ctx context.Context, method string, u *url.URL) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	if method == http.MethodGet {
+		req.Header.Set("Range", "bytes=0-0")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return resp, nil
+}
+
+// headUnsupported reports whether a status code may mean the server does not answer HEAD requests
+func headUnsupported(code int) bool {
+	return code == http.StatusMethodNotAllowed || code == http.StatusNotImplemented ||
+		code == http.StatusForbidden || code == http.StatusBadRequest
+}
+
+// unreachable reports whether err means the host does not exist or refuses connections, as
+// opposed to a timeout or a network problem on our side
+func unreachable(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsNotFound
+	}
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
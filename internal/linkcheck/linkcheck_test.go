@@ -0,0 +1,83 @@
+package linkcheck
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChecker_CheckLink(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/gone", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusGone) })
+	mux.HandleFunc("/blocked", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusForbidden) })
+	mux.HandleFunc("/error", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusBadGateway) })
+	mux.HandleFunc("/no-head", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		assert.Equal(t, "bytes=0-0", r.Header.Get("Range"))
+		w.WriteHeader(http.StatusPartialContent)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	checker := New(server.Client(), 0)
+	ctx := context.Background()
+
+	assert.Equal(t, Alive, checker.CheckLink(ctx, server.URL+"/ok"))
+	assert.Equal(t, Dead, checker.CheckLink(ctx, server.URL+"/missing"))
+	assert.Equal(t, Dead, checker.CheckLink(ctx, server.URL+"/gone"))
+	assert.Equal(t, Unknown, checker.CheckLink(ctx, server.URL+"/blocked"), "bot blocks are not dead links")
+	assert.Equal(t, Unknown, checker.CheckLink(ctx, server.URL+"/error"))
+	assert.Equal(t, Alive, checker.CheckLink(ctx, server.URL+"/no-head"))
+	assert.Equal(t, Unknown, checker.CheckLink(ctx, "cid:thumb1@ai-news-processor"))
+}
+
+func TestChecker_CheckImage(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/a.png", func(w http.ResponseWriter, r *http.Request) { w.Header().Set("Content-Type", "image/png") })
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) { w.Header().Set("Content-Type", "text/html") })
+	mux.HandleFunc("/forbidden.png", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusForbidden) })
+	mux.HandleFunc("/deleted.png", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/removed.png", http.StatusFound)
+	})
+	mux.HandleFunc("/removed.png", func(w http.ResponseWriter, r *http.Request) { w.Header().Set("Content-Type", "image/png") })
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	checker := New(server.Client(), 0)
+	ctx := context.Background()
+
+	assert.Equal(t, Alive, checker.CheckImage(ctx, server.URL+"/a.png"))
+	assert.Equal(t, Dead, checker.CheckImage(ctx, server.URL+"/page"))
+	assert.Equal(t, Dead, checker.CheckImage(ctx, server.URL+"/forbidden.png"))
+	assert.Equal(t, Dead, checker.CheckImage(ctx, server.URL+"/deleted.png"))
+}
+
+func TestChecker_Cache(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer server.Close()
+
+	now := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	checker := New(server.Client(), time.Hour)
+	checker.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	assert.Equal(t, Alive, checker.CheckLink(ctx, server.URL))
+	assert.Equal(t, Alive, checker.CheckLink(ctx, server.URL))
+	assert.Equal(t, int32(1), requests.Load(), "the second check is answered from the cache")
+
+	now = now.Add(2 * time.Hour)
+	checker.CheckLink(ctx, server.URL)
+	assert.Equal(t, int32(2), requests.Load(), "expired results are checked again")
+}
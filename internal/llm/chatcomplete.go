@@ -1,7 +1,6 @@
 package llm
 
 import (
-	"github.com/bakkerme/ai-news-processor/internal/customerrors"
 	"github.com/bakkerme/ai-news-processor/internal/openai"
 	"github.com/bakkerme/ai-news-processor/models"
 	"github.com/invopop/jsonschema"
@@ -13,9 +12,44 @@ const (
 	MaxTokensWebSummary   = 1000 // For web content summaries (non-JSON, can be safely limited)
 )
 
+// maxPageBytes caps how much of an external page is read into memory for extraction
+const maxPageBytes = 5 << 20
+
 // Generate the JSON schema at initialization time
 var ItemResponseSchema = GenerateSchema[[]models.Item]()
-var SummaryResponseSchema = GenerateSchema[models.SummaryResponse]()
+
+// entryResponse is the JSON the LLM answers an entry with
+type entryResponse struct {
+	models.ItemSubset
+	Claims []models.Claim `json:"claims,omitempty"`
+}
+
+// summaryResponse is the JSON the LLM answers a summary request with. Rising topics and the other
+// fields of models.SummaryResponse are filled in by the processor.
+type summaryResponse struct {
+	KeyDevelopments []models.KeyDevelopment `json:"keyDevelopments"`
+}
+
+// EntryResponseSchema is the schema of an entry response, requested in the client's response format
+var EntryResponseSchema = &openai.SchemaParameters{
+	Schema:      GenerateSchema[entryResponse](),
+	Name:        "post_item",
+	Description: "an object representing a post",
+}
+
+// SummaryResponseSchema is the schema of a summary response
+var SummaryResponseSchema = &openai.SchemaParameters{
+	Schema:      GenerateSchema[summaryResponse](),
+	Name:        "summary",
+	Description: "a summary of multiple AI news items",
+}
+
+// RollupResponseSchema is the schema of a rollup response
+var RollupResponseSchema = &openai.SchemaParameters{
+	Schema:      GenerateSchema[models.RollupResponse](),
+	Name:        "rollup",
+	Description: "a review of the items sent over several days",
+}
 
 // GenerateSchema creates a JSON schema for the given type
 func GenerateSchema[T any]() interface{} {
@@ -30,88 +64,127 @@ func GenerateSchema[T any]() interface{} {
 	return schema
 }
 
-// chatCompletionForEntrySummary sends a ChatCompletion to get summaries for RSS entries
-func chatCompletionForEntrySummary(client openai.OpenAIClient, systemPrompt string, userPrompts []string, imageURLs []string, results chan customerrors.ErrorString) {
-	// Schema parameters commented for future reference:
-	// Schema: ItemResponseSchema
-	// Name: "post_item"
-	// Description: "an object representing a post"
-	client.ChatCompletion(
+// chatCompletionForEntrySummary sends a ChatCompletion to get summaries for RSS entries.
+// schemaParams is the schema of the response, or nil for responses that are not an entry.
+func chatCompletionForEntrySummary(client openai.OpenAIClient, systemPrompt string, userPrompts []string, imageURLs []string, schemaParams *openai.SchemaParameters) openai.Result {
+	return client.ChatCompletion(
 		systemPrompt,
 		userPrompts,
 		imageURLs,
-		nil, // Schema parameters currently disabled
+		schemaParams,
 		0.5, // temperature
 		0,   // max tokens (0 means no limit - needed for complete JSON generation)
-		results,
 	)
 }
 
-// chatCompletionForFeedSummary sends a ChatCompletion to get a summary for an entire feed
-func chatCompletionForFeedSummary(client openai.OpenAIClient, systemPrompt string, userPrompts []string, results chan customerrors.ErrorString) {
-	// Feed summaries don't include images directly
-	// Schema parameters commented for future reference:
-	// Schema: SummaryResponseSchema
-	// Name: "summary"
-	// Description: "a summary of multiple AI news items"
-	client.ChatCompletion(
+// chatCompletionForFeedSummary sends a ChatCompletion to get a summary for an entire feed, with
+// a response matching schemaParams
+func chatCompletionForFeedSummary(client openai.OpenAIClient, systemPrompt string, userPrompts []string, schemaParams *openai.SchemaParameters) openai.Result {
+	return client.ChatCompletion(
 		systemPrompt,
 		userPrompts,
 		[]string{}, // No images for feed summaries
-		nil,        // Schema parameters currently disabled
-		0.5,        // temperature
-		0,          // max tokens (0 means no limit - needed for complete JSON generation)
-		results,
+		schemaParams,
+		0.5, // temperature
+		0,   // max tokens (0 means no limit - needed for complete JSON generation)
+	)
+}
+
+// chatCompletionForSecondOpinion sends a ChatCompletion judging again whether an item is relevant
+func chatCompletionForSecondOpinion(client openai.OpenAIClient, systemPrompt string, itemString string) openai.Result {
+	return client.ChatCompletion(
+		systemPrompt,
+		[]string{itemString},
+		[]string{},
+		nil,
+		0.1, // temperature, low as this is a judgement
+		0,   // max tokens (0 means no limit - needed for complete JSON generation)
 	)
 }
 
 // chatCompletionImageSummary sends a ChatCompletion to get descriptions for images
 func chatCompletionImageSummary(client openai.OpenAIClient, systemPrompt string, imageURLs []string) (string, error) {
-	results := make(chan customerrors.ErrorString, 1)
-
 	// Empty userPrompt as the image is the content
 	// No schema parameters needed for image analysis
-	client.ChatCompletion(
+	result := client.ChatCompletion(
 		systemPrompt,
 		[]string{}, // No additional text prompt, just let the model analyze the images
 		imageURLs,
 		nil,                  // Schema parameters not needed for image analysis
 		0.1,                  // temperature
 		MaxTokensImageSummary, // max tokens to prevent infinite generation
-		results,
 	)
 
-	result := <-results
-	close(results)
-
 	if result.Err != nil {
 		return "", result.Err
 	}
 
-	return result.Value, nil
+	return result.Content, nil
 }
 
 // chatCompletionForWebSummary handles the LLM call for web summarization
 func (p *Processor) chatCompletionForWebSummary(systemPrompt string, userPrompt string) (string, error) {
-	results := make(chan customerrors.ErrorString, 1)
-
-	// Start the OpenAI call in a goroutine
-	p.client.ChatCompletion(
+	result := p.client.ChatCompletion(
 		systemPrompt,
 		[]string{userPrompt},
 		[]string{},
 		nil,
 		0.5,                // temperature
 		MaxTokensWebSummary, // reasonable limit for web summaries (non-JSON)
-		results,
 	)
 
-	result := <-results
-	close(results)
+	if result.Err != nil {
+		return "", result.Err
+	}
+
+	return result.Content, nil
+}
+
+// chatCompletionForCondensation handles the LLM call condensing part of an entry that does not fit the context
+func (p *Processor) chatCompletionForCondensation(systemPrompt string, text string) (string, error) {
+	result := p.client.ChatCompletion(
+		systemPrompt,
+		[]string{text},
+		[]string{},
+		nil,
+		0.3,                   // temperature
+		MaxTokensCondensation, // condensations are read by the entry prompt, not by people
+	)
+
+	if result.Err != nil {
+		return "", result.Err
+	}
+
+	return result.Content, nil
+}
+
+// chatCompletionForDenseNotes handles the LLM call taking notes on a long entry for its dense summary
+func chatCompletionForDenseNotes(client openai.OpenAIClient, systemPrompt string, entryString string) openai.Result {
+	return client.ChatCompletion(
+		systemPrompt,
+		[]string{entryString},
+		[]string{},
+		nil,
+		0.3,                 // temperature
+		MaxTokensDenseNotes, // notes are read by the rewrite pass, not by people
+	)
+}
+
+// chatCompletionImageBatchSummary sends a single ChatCompletion describing several images at once.
+// The token limit scales with the number of images so each description has the same budget as a single request.
+func chatCompletionImageBatchSummary(client openai.OpenAIClient, systemPrompt string, imageURLs []string) (string, error) {
+	result := client.ChatCompletion(
+		systemPrompt,
+		[]string{},
+		imageURLs,
+		nil,
+		0.1,
+		MaxTokensImageSummary*len(imageURLs),
+	)
 
 	if result.Err != nil {
 		return "", result.Err
 	}
 
-	return result.Value, nil
+	return result.Content, nil
 }
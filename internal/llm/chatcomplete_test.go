@@ -1,12 +1,20 @@
 package llm
 
 import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
-	"github.com/bakkerme/ai-news-processor/internal/customerrors"
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
 	"github.com/bakkerme/ai-news-processor/internal/http/retry"
 	"github.com/bakkerme/ai-news-processor/internal/openai"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/models"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // MockOpenAIClient is a mock implementation of the openai.OpenAIClient interface.
@@ -35,7 +43,7 @@ type MockOpenAIClient struct {
 }
 
 // ChatCompletion implements the openai.OpenAIClient interface.
-func (m *MockOpenAIClient) ChatCompletion(systemPrompt string, userPrompts []string, imageURLs []string, schemaParams *openai.SchemaParameters, temperature float64, maxTokens int, results chan customerrors.ErrorString) {
+func (m *MockOpenAIClient) ChatCompletion(systemPrompt string, userPrompts []string, imageURLs []string, schemaParams *openai.SchemaParameters, temperature float64, maxTokens int) openai.Result {
 	m.CalledChatCompletion = true
 	m.LastSystemPrompt = systemPrompt
 	m.LastUserPrompts = userPrompts
@@ -44,10 +52,8 @@ func (m *MockOpenAIClient) ChatCompletion(systemPrompt string, userPrompts []str
 	m.LastTemperature = temperature
 	m.LastMaxTokens = maxTokens
 
-	// Default behavior: send an empty successful result
-	go func() {
-		results <- customerrors.ErrorString{Value: "mocked response", Err: nil}
-	}()
+	// Default behavior: return a successful result
+	return openai.Result{Content: "mocked response", Model: "mock-model"}
 }
 
 // SetRetryConfig implements the openai.OpenAIClient interface.
@@ -94,12 +100,10 @@ func TestChatCompletionForEntrySummary(t *testing.T) {
 	systemPrompt := "test system prompt for entry summary"
 	userPrompts := []string{"user prompt 1", "user prompt 2"}
 	imageURLs := []string{"http://example.com/image1.jpg"}
-	results := make(chan customerrors.ErrorString, 1)
+	result := chatCompletionForEntrySummary(mockClient, systemPrompt, userPrompts, imageURLs, nil)
 
-	chatCompletionForEntrySummary(mockClient, systemPrompt, userPrompts, imageURLs, results)
-
-	// Wait for the goroutine in ChatCompletion to send a result
-	<-results
+	assert.NoError(t, result.Err)
+	assert.Equal(t, "mocked response", result.Content)
 
 	assert.True(t, mockClient.CalledChatCompletion, "ChatCompletion should have been called")
 	assert.Equal(t, systemPrompt, mockClient.LastSystemPrompt)
@@ -111,23 +115,37 @@ func TestChatCompletionForEntrySummary(t *testing.T) {
 	assert.Equal(t, 0, mockClient.LastMaxTokens)
 }
 
+func TestProcessor_EntrySchema(t *testing.T) {
+	processor := &Processor{}
+	require.Same(t, EntryResponseSchema, processor.entrySchema(persona.Persona{}))
+
+	data, err := json.Marshal(EntryResponseSchema.Schema)
+	require.NoError(t, err)
+	var schema struct {
+		Properties map[string]any `json:"properties"`
+		Required   []string       `json:"required"`
+	}
+	require.NoError(t, json.Unmarshal(data, &schema))
+	assert.Contains(t, schema.Properties, "claims")
+	assert.Contains(t, schema.Required, "importanceScore")
+	assert.NotContains(t, schema.Required, "claims")
+	assert.NotContains(t, schema.Properties, "entry", "fields the processor fills in are not asked of the LLM")
+}
+
 func TestChatCompletionForFeedSummary(t *testing.T) {
 	mockClient := &MockOpenAIClient{}
 	systemPrompt := "test system prompt for feed summary"
 	userPrompts := []string{"feed user prompt 1", "feed user prompt 2"}
-	results := make(chan customerrors.ErrorString, 1)
-
-	chatCompletionForFeedSummary(mockClient, systemPrompt, userPrompts, results)
+	result := chatCompletionForFeedSummary(mockClient, systemPrompt, userPrompts, SummaryResponseSchema)
 
-	// Wait for the goroutine in ChatCompletion to send a result
-	<-results
+	assert.NoError(t, result.Err)
+	assert.Equal(t, "mocked response", result.Content)
 
 	assert.True(t, mockClient.CalledChatCompletion, "ChatCompletion should have been called")
 	assert.Equal(t, systemPrompt, mockClient.LastSystemPrompt)
 	assert.Equal(t, userPrompts, mockClient.LastUserPrompts)
 	assert.Equal(t, []string{}, mockClient.LastImageURLs, "ImageURLs should be empty for feed summary")
-	// SchemaParams are currently nil in the tested function
-	assert.Nil(t, mockClient.LastSchemaParams)
+	assert.Same(t, SummaryResponseSchema, mockClient.LastSchemaParams)
 	assert.Equal(t, 0.5, mockClient.LastTemperature)
 	assert.Equal(t, 0, mockClient.LastMaxTokens)
 }
@@ -156,20 +174,14 @@ func TestChatCompletionImageSummary(t *testing.T) {
 func TestSafeApproachToPreventInfiniteGeneration(t *testing.T) {
 	t.Run("EntrySummary_UnlimitedForJSON", func(t *testing.T) {
 		mockClient := &MockOpenAIClient{}
-		results := make(chan customerrors.ErrorString, 1)
-
-		chatCompletionForEntrySummary(mockClient, "test", []string{"test"}, nil, results)
-		<-results
+		chatCompletionForEntrySummary(mockClient, "test", []string{"test"}, nil, nil)
 
 		assert.Equal(t, 0, mockClient.LastMaxTokens, "Entry summary should use unlimited tokens (0) to ensure complete JSON")
 	})
 
 	t.Run("FeedSummary_UnlimitedForJSON", func(t *testing.T) {
 		mockClient := &MockOpenAIClient{}
-		results := make(chan customerrors.ErrorString, 1)
-
-		chatCompletionForFeedSummary(mockClient, "test", []string{"test"}, results)
-		<-results
+		chatCompletionForFeedSummary(mockClient, "test", []string{"test"}, SummaryResponseSchema)
 
 		assert.Equal(t, 0, mockClient.LastMaxTokens, "Feed summary should use unlimited tokens (0) to ensure complete JSON")
 	})
@@ -196,4 +208,94 @@ func TestSafeApproachToPreventInfiniteGeneration(t *testing.T) {
 	})
 }
 
-// TODO: Add tests for error cases, e.g., when the client.ChatCompletion sends an error on the results channel.
+// TODO: Add tests for error cases, e.g., when the client.ChatCompletion returns a result with an error.
+
+func TestProcessor_ResponseFormat(t *testing.T) {
+	var requests []map[string]any
+	reject := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var request map[string]any
+		require.NoError(t, json.Unmarshal(body, &request))
+		requests = append(requests, request)
+
+		format, _ := request["response_format"].(map[string]any)
+		if format != nil && format["type"] == reject {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"message":"response_format ` + reject + ` is not supported","type":"invalid_request_error"}}`))
+			return
+		}
+		content := `{"isRelevant":true,"summary":"A summary"}`
+		if schema, _ := format["json_schema"].(map[string]any); schema != nil && schema["name"] == "summary" {
+			content = `{"keyDevelopments":[{"text":"A development","itemID":"1"}]}`
+		}
+		if format == nil {
+			content = "Here it is: <json>" + content + "</json>"
+		}
+		reply, _ := json.Marshal(map[string]any{
+			"id": "1", "object": "chat.completion", "created": 0, "model": "test-model",
+			"choices": []any{map[string]any{"index": 0, "finish_reason": "stop", "message": map[string]any{"role": "assistant", "content": content}}},
+		})
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(reply)
+	}))
+	defer server.Close()
+
+	p := persona.Persona{Name: "Test", PersonaIdentity: "a tester", FocusAreas: []string{"testing"}}
+	entries := []feeds.Entry{{ID: "1", Title: "Story"}}
+	config := EntryProcessConfig{InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, BackoffFactor: 1}
+	process := func(client openai.OpenAIClient) []models.Item {
+		processor := NewProcessor(client, client, config, &mockArticleExtractor{}, &mockFetcher{}, &mockURLExtractor{}, &mockImageFetcher{})
+		items, _, err := processor.ProcessEntries("system", entries, p)
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		return items
+	}
+	responseFormat := func(request map[string]any) (string, string) {
+		format, _ := request["response_format"].(map[string]any)
+		if format == nil {
+			return "", ""
+		}
+		schema, _ := format["json_schema"].(map[string]any)
+		name, _ := schema["name"].(string)
+		return format["type"].(string), name
+	}
+
+	// Entries and summaries are requested with their schemas in the default format
+	client := openai.New(server.URL, "key", "test-model")
+	items := process(client)
+	summary, err := GenerateSummary(client, items, p)
+	require.NoError(t, err)
+	require.Len(t, summary.KeyDevelopments, 1)
+	require.Len(t, requests, 2)
+	kind, name := responseFormat(requests[0])
+	assert.Equal(t, "json_schema", kind)
+	assert.Equal(t, "post_item", name)
+	assert.Equal(t, false, requests[0]["response_format"].(map[string]any)["json_schema"].(map[string]any)["strict"], "entries have optional properties, which strict mode rejects")
+	kind, name = responseFormat(requests[1])
+	assert.Equal(t, "json_schema", kind)
+	assert.Equal(t, "summary", name)
+	assert.Equal(t, true, requests[1]["response_format"].(map[string]any)["json_schema"].(map[string]any)["strict"])
+
+	// A server that rejects json_schema is asked for json_object, with the schema in the system prompt
+	requests, reject = nil, "json_schema"
+	client = openai.New(server.URL, "key", "other-model")
+	assert.Equal(t, "A summary", process(client)[0].Summary)
+	require.Len(t, requests, 2)
+	kind, _ = responseFormat(requests[1])
+	assert.Equal(t, "json_object", kind)
+	messages := requests[1]["messages"].([]any)
+	assert.Contains(t, messages[0].(map[string]any)["content"], `"isRelevant"`)
+
+	// In the prompt format the JSON is read from between the markers
+	requests, reject = nil, ""
+	client = openai.New(server.URL, "key", "test-model")
+	client.SetResponseFormat(openai.FormatPrompt, true)
+	assert.Equal(t, "A summary", process(client)[0].Summary)
+	require.Len(t, requests, 1)
+	kind, _ = responseFormat(requests[0])
+	assert.Empty(t, kind)
+	messages = requests[0]["messages"].([]any)
+	assert.Contains(t, messages[0].(map[string]any)["content"], "<json>")
+}
@@ -0,0 +1,47 @@
+package llm
+
+import (
+	"log"
+	"strings"
+
+	"github.com/bakkerme/ai-news-processor/internal/urlnorm"
+	"github.com/bakkerme/ai-news-processor/models"
+)
+
+// maxClaimsPerItem caps the number of claims kept per item
+const maxClaimsPerItem = 5
+
+var claimSources = map[string]string{
+	"post":    models.ClaimSourcePost,
+	"body":    models.ClaimSourcePost,
+	"comment": models.ClaimSourceComment,
+	"link":    models.ClaimSourceLink,
+	"article": models.ClaimSourceLink,
+	"image":   models.ClaimSourceImage,
+}
+
+// normalizeClaims cleans up the claims returned by the LLM: text is trimmed, claims without text
+// or with an unknown source are dropped, and only claims from links keep a URL
+func normalizeClaims(item *models.Item) {
+	var claims []models.Claim
+	for _, claim := range item.Claims {
+		text := strings.TrimSpace(claim.Claim)
+		if text == "" {
+			continue
+		}
+		source, ok := claimSources[strings.ToLower(strings.TrimSpace(claim.Source))]
+		if !ok {
+			log.Printf("Dropping claim of item %s with unknown source %q", item.ID, claim.Source)
+			continue
+		}
+		normalized := models.Claim{Claim: text, Source: source, Quote: strings.Trim(strings.TrimSpace(claim.Quote), `"“”`)}
+		if source == models.ClaimSourceLink {
+			normalized.URL = urlnorm.CleanString(strings.TrimSpace(claim.URL))
+		}
+		claims = append(claims, normalized)
+		if len(claims) == maxClaimsPerItem {
+			break
+		}
+	}
+	item.Claims = claims
+}
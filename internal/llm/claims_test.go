@@ -0,0 +1,25 @@
+package llm
+
+import (
+	"testing"
+
+	"github.com/bakkerme/ai-news-processor/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeClaims(t *testing.T) {
+	item := models.Item{ID: "1", Claims: []models.Claim{
+		{Claim: " Llama 4 scores 81 on MMLU ", Source: "Link", Quote: `"81.2 on MMLU"`, URL: "https://example.com/card"},
+		{Claim: "It runs at 12 tok/s", Source: "comment", URL: "https://example.com/ignored"},
+		{Claim: "", Source: "post"},
+		{Claim: "Made up", Source: "rumour"},
+		{Claim: "The weights are on the Hub", Source: "body"},
+	}}
+
+	normalizeClaims(&item)
+	assert.Equal(t, []models.Claim{
+		{Claim: "Llama 4 scores 81 on MMLU", Source: models.ClaimSourceLink, Quote: "81.2 on MMLU", URL: "https://example.com/card"},
+		{Claim: "It runs at 12 tok/s", Source: models.ClaimSourceComment},
+		{Claim: "The weights are on the Hub", Source: models.ClaimSourcePost},
+	}, item.Claims)
+}
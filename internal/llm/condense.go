@@ -0,0 +1,174 @@
+package llm
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/internal/tokens"
+)
+
+const (
+	// ResponseReserveTokens is the room kept free in the context window for the entry's JSON response
+	ResponseReserveTokens = 2048
+	// MaxTokensCondensation limits each intermediate condensation (non-JSON, can be safely limited)
+	MaxTokensCondensation = 800
+	// maxCondenseRounds bounds how often condensations are condensed again
+	maxCondenseRounds = 3
+)
+
+const condensePromptTemplate = `You condense the %s of a post titled %q for %s.
+
+The text is too long to be analyzed at once. Rewrite it as a dense summary within 400 words that keeps every distinct point, claim, number, name, link and disagreement. Drop repetition, jokes and chit-chat. Do not add anything that is not in the text.
+
+Respond with the summary only, as plain text.`
+
+// entryPrompt renders an entry for the entry prompt. An entry that would not fit the model's context
+// window next to the system prompt is condensed first: its comments, then its external URL
+// summaries, then its content are summarized in chunks until it fits. The entry itself is not
+// changed, so the digest still links and credits the original.
+func (p *Processor) entryPrompt(systemPrompt string, entry feeds.Entry, persona persona.Persona) (string, error) {
+	rendered := entry.String(true)
+	if p.config.ContextTokens <= 0 {
+		return rendered, nil
+	}
+	available := p.config.ContextTokens - tokens.Estimate(systemPrompt) - ResponseReserveTokens
+	needed := tokens.Estimate(rendered)
+	if needed <= available {
+		return rendered, nil
+	}
+	if available <= 0 {
+		log.Printf("warning: the system prompt leaves no room for entry %s in a context of %d tokens\n", entry.ID, p.config.ContextTokens)
+		return rendered, nil
+	}
+	log.Printf("Entry %s needs about %d tokens but only %d fit the context, condensing it\n", entry.ID, needed, available)
+
+	if len(entry.Comments) > 0 {
+		comments := make([]string, len(entry.Comments))
+		for i, comment := range entry.Comments {
+			comments[i] = comment.Content
+		}
+		without := entry
+		without.Comments = nil
+		condensed, err := p.condense(persona, "discussion", entry.Title, comments, available-tokens.Estimate(without.String(true)))
+		if err != nil {
+			return "", fmt.Errorf("could not condense comments: %w", err)
+		}
+		entry.Comments = make([]feeds.EntryComments, len(condensed))
+		for i, text := range condensed {
+			entry.Comments[i] = feeds.EntryComments{Content: "Condensed discussion: " + text}
+		}
+		if rendered = entry.String(true); tokens.Estimate(rendered) <= available {
+			return rendered, nil
+		}
+	}
+
+	if len(entry.WebContentSummaries) > 0 {
+		urls := make([]string, 0, len(entry.WebContentSummaries))
+		for url := range entry.WebContentSummaries {
+			urls = append(urls, url)
+		}
+		sort.Strings(urls)
+		summaries := make([]string, len(urls))
+		for i, url := range urls {
+			summaries[i] = url + ": " + entry.WebContentSummaries[url]
+		}
+		without := entry
+		without.WebContentSummaries = nil
+		condensed, err := p.condense(persona, "linked pages", entry.Title, summaries, available-tokens.Estimate(without.String(true)))
+		if err != nil {
+			return "", fmt.Errorf("could not condense external URL summaries: %w", err)
+		}
+		entry.WebContentSummaries = map[string]string{"condensed linked pages": strings.Join(condensed, "\n")}
+		if rendered = entry.String(true); tokens.Estimate(rendered) <= available {
+			return rendered, nil
+		}
+	}
+
+	without := entry
+	without.Content = ""
+	condensed, err := p.condense(persona, "post", entry.Title, []string{entry.Content}, available-tokens.Estimate(without.String(true)))
+	if err != nil {
+		return "", fmt.Errorf("could not condense content: %w", err)
+	}
+	entry.Content = strings.Join(condensed, "\n")
+	rendered = entry.String(true)
+	if needed := tokens.Estimate(rendered); needed > available {
+		log.Printf("warning: entry %s still needs about %d tokens after condensing, %d fit\n", entry.ID, needed, available)
+	}
+	return rendered, nil
+}
+
+// condense map-reduces texts: they are packed into chunks that fit a request, each chunk is
+// summarized, and the summaries are condensed again until they fit target tokens
+func (p *Processor) condense(persona persona.Persona, what, title string, texts []string, target int) ([]string, error) {
+	// A chunk leaves half the context for the prompt and the summary
+	chunkTokens := max(p.config.ContextTokens/2, 1)
+	for round := 1; ; round++ {
+		chunks := chunkTexts(texts, chunkTokens)
+		condensed := make([]string, 0, len(chunks))
+		for _, chunk := range chunks {
+			summary, err := p.condenseChunk(persona, what, title, chunk)
+			if err != nil {
+				return nil, err
+			}
+			condensed = append(condensed, strings.TrimSpace(summary))
+		}
+		texts = condensed
+
+		total := 0
+		for _, text := range texts {
+			total += tokens.Estimate(text)
+		}
+		log.Printf("Condensed the %s of %q into %d parts of about %d tokens (round %d)\n", what, title, len(texts), total, round)
+		if total <= target || len(texts) == 1 || round == maxCondenseRounds {
+			return texts, nil
+		}
+	}
+}
+
+// condenseChunk summarizes one chunk with retry support
+func (p *Processor) condenseChunk(persona persona.Persona, what, title, chunk string) (string, error) {
+	systemPrompt := fmt.Sprintf(condensePromptTemplate, what, title, persona.Name)
+	return p.retryStringFunc(func() (string, error) {
+		result, err := p.chatCompletionForCondensation(systemPrompt, chunk)
+		if err != nil {
+			return "", fmt.Errorf("could not condense %s: %w", what, err)
+		}
+		return result, nil
+	}, "condensation")
+}
+
+// chunkTexts packs texts into chunks of about maxTokens, one text per line. Texts larger than a
+// chunk are split at sentence boundaries.
+func chunkTexts(texts []string, maxTokens int) []string {
+	var chunks []string
+	var current strings.Builder
+	currentTokens := 0
+	flush := func() {
+		if current.Len() > 0 {
+			chunks = append(chunks, current.String())
+			current.Reset()
+			currentTokens = 0
+		}
+	}
+
+	for _, text := range texts {
+		for _, piece := range tokens.Split(text, maxTokens) {
+			pieceTokens := tokens.Estimate(piece)
+			if currentTokens+pieceTokens > maxTokens {
+				flush()
+			}
+			if current.Len() > 0 {
+				current.WriteString("\n")
+			}
+			current.WriteString("- " + piece)
+			currentTokens += pieceTokens
+		}
+	}
+	flush()
+	return chunks
+}
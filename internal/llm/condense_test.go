@@ -0,0 +1,124 @@
+package llm
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
+	"github.com/bakkerme/ai-news-processor/internal/openai"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/internal/tokens"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEntryPromptCondensesLongDiscussions(t *testing.T) {
+	var mu sync.Mutex
+	var condensed []string
+	client := &mockOpenAIClient{
+		ChatCompletionFunc: func(systemPrompt string, userPrompts []string, imageURLs []string, schemaParams *openai.SchemaParameters, temperature float64, maxTokens int) openai.Result {
+			mu.Lock()
+			defer mu.Unlock()
+			if !strings.HasPrefix(systemPrompt, "You condense the discussion") {
+				t.Errorf("unexpected request: %s", systemPrompt)
+			}
+			condensed = append(condensed, userPrompts[0])
+			return openai.Result{Content: "People compare the model with Llama."}
+		},
+	}
+	config := EntryProcessConfig{InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, BackoffFactor: 1, ContextTokens: 3000}
+	processor := NewProcessor(client, client, config, &mockArticleExtractor{}, &mockFetcher{}, &mockURLExtractor{}, &mockImageFetcher{})
+
+	entry := feeds.Entry{ID: "1", Title: "A new open model", Content: "It is out."}
+	for i := 0; i < 50; i++ {
+		entry.Comments = append(entry.Comments, feeds.EntryComments{Content: strings.Repeat("benchmark numbers look good ", 15)})
+	}
+
+	rendered, err := processor.entryPrompt("system", entry, persona.Persona{Name: "AI"})
+	require.NoError(t, err)
+	assert.Len(t, condensed, 3, "about 3750 tokens of comments are condensed in chunks of 1500")
+	assert.Contains(t, rendered, "- Condensed discussion: People compare the model with Llama.")
+	assert.NotContains(t, rendered, "benchmark numbers")
+	assert.LessOrEqual(t, tokens.Estimate(rendered), 3000-ResponseReserveTokens)
+	assert.Len(t, entry.Comments, 50, "the entry itself is not changed")
+}
+
+func TestEntryPromptCondensesLongContent(t *testing.T) {
+	client := &mockOpenAIClient{
+		ChatCompletionFunc: func(systemPrompt string, userPrompts []string, imageURLs []string, schemaParams *openai.SchemaParameters, temperature float64, maxTokens int) openai.Result {
+			switch {
+			case strings.HasPrefix(systemPrompt, "You condense the linked pages"):
+				return openai.Result{Content: "The paper reports a 10% gain."}
+			case strings.HasPrefix(systemPrompt, "You condense the post"):
+				return openai.Result{Content: "A long release announcement."}
+			default:
+				t.Errorf("unexpected request: %s", systemPrompt)
+				return openai.Result{}
+			}
+		},
+	}
+	config := EntryProcessConfig{InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, BackoffFactor: 1, ContextTokens: 3000}
+	processor := NewProcessor(client, client, config, &mockArticleExtractor{}, &mockFetcher{}, &mockURLExtractor{}, &mockImageFetcher{})
+
+	entry := feeds.Entry{
+		ID:                  "1",
+		Title:               "Release notes",
+		Content:             strings.Repeat("changelog line ", 400),
+		WebContentSummaries: map[string]string{"https://example.com/paper": strings.Repeat("paper details ", 300)},
+	}
+	rendered, err := processor.entryPrompt("system", entry, persona.Persona{Name: "AI"})
+	require.NoError(t, err)
+	assert.Contains(t, rendered, "- condensed linked pages: The paper reports a 10% gain.")
+	assert.Contains(t, rendered, "Content: A long release announcement.")
+	assert.LessOrEqual(t, tokens.Estimate(rendered), 3000-ResponseReserveTokens)
+}
+
+func TestEntryPromptLeavesEntriesThatFit(t *testing.T) {
+	client := &mockOpenAIClient{
+		ChatCompletionFunc: func(systemPrompt string, userPrompts []string, imageURLs []string, schemaParams *openai.SchemaParameters, temperature float64, maxTokens int) openai.Result {
+			t.Errorf("unexpected request: %s", systemPrompt)
+			return openai.Result{}
+		},
+	}
+	entry := feeds.Entry{ID: "1", Title: "Short", Content: strings.Repeat("word ", 2000)}
+
+	for _, contextTokens := range []int{32768, 0} {
+		config := EntryProcessConfig{ContextTokens: contextTokens}
+		processor := NewProcessor(client, client, config, &mockArticleExtractor{}, &mockFetcher{}, &mockURLExtractor{}, &mockImageFetcher{})
+		rendered, err := processor.entryPrompt("system", entry, persona.Persona{Name: "AI"})
+		require.NoError(t, err)
+		assert.Equal(t, entry.String(true), rendered)
+	}
+}
+
+func TestChunkTexts(t *testing.T) {
+	chunks := chunkTexts([]string{"Yes.", "No.", "One sentence here. Another sentence there."}, 5)
+	assert.Equal(t, []string{
+		"- Yes.\n- No.",
+		"- One sentence here.",
+		"- Another sentence there.",
+	}, chunks)
+}
+
+func TestSummarizeWebSiteTruncatesLongPages(t *testing.T) {
+	var userPrompt string
+	client := &mockOpenAIClient{
+		ChatCompletionFunc: func(systemPrompt string, userPrompts []string, imageURLs []string, schemaParams *openai.SchemaParameters, temperature float64, maxTokens int) openai.Result {
+			userPrompt = userPrompts[0]
+			return openai.Result{Content: "A summary."}
+		},
+	}
+	config := EntryProcessConfig{InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, BackoffFactor: 1, ContextTokens: 3000}
+	processor := NewProcessor(client, client, config, &mockArticleExtractor{}, &mockFetcher{}, &mockURLExtractor{}, &mockImageFetcher{})
+
+	page, _ := url.Parse("https://example.com/paper")
+	content := strings.Repeat("The paper reports results on many benchmarks. ", 500)
+	summary, err := processor.summarizeWebSite("Paper", page, content)
+	require.NoError(t, err)
+	assert.Equal(t, "A summary.", summary)
+	assert.LessOrEqual(t, tokens.Estimate(userPrompt), 3000-MaxTokensWebSummary)
+	assert.Contains(t, userPrompt, "benchmarks.\n\nTitle: Paper", "the page is cut after a sentence")
+}
@@ -0,0 +1,107 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrEntryTimeout is the error of an entry given up on after EntryTimeout
+var ErrEntryTimeout = errors.New("entry timed out")
+
+// entryDeadlines tracks the time spent on each entry across the image, URL and summary phases,
+// so an entry that hangs is given up on once it has used up EntryTimeout instead of holding up
+// the rest of the run. Without a limit, every entry has all the time it needs.
+type entryDeadlines struct {
+	limit time.Duration
+
+	mu      sync.Mutex
+	spent   map[string]time.Duration
+	expired map[string]error
+}
+
+func newEntryDeadlines(limit time.Duration) *entryDeadlines {
+	return &entryDeadlines{
+		limit:   limit,
+		spent:   make(map[string]time.Duration),
+		expired: make(map[string]error),
+	}
+}
+
+// remaining returns how long the entries ids may still take together: the least time any of
+// them has left
+func (d *entryDeadlines) remaining(ids []string) time.Duration {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	remaining := d.limit
+	for _, id := range ids {
+		remaining = min(remaining, d.limit-d.spent[id])
+	}
+	return remaining
+}
+
+// charge adds time spent on entry id
+func (d *entryDeadlines) charge(id string, spent time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.spent[id] += spent
+}
+
+// expire marks entry id as given up on during phase
+func (d *entryDeadlines) expire(id string, phase string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.expired[id]; !ok {
+		d.expired[id] = fmt.Errorf("%w after %s while %s", ErrEntryTimeout, d.limit, phase)
+	}
+}
+
+// err returns the timeout error of entry id, or nil if it has time left
+func (d *entryDeadlines) err(id string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.expired[id]
+}
+
+// run runs fn for the entries ids within the time they have left, charging each of them the
+// time it took. If fn does not finish in time the entries are expired and run returns false
+// without waiting for it; fn keeps running in the background, so it must not write anything the
+// caller reads afterwards.
+func (d *entryDeadlines) run(ids []string, phase string, fn func()) bool {
+	if d.limit <= 0 {
+		fn()
+		return true
+	}
+	limit := d.remaining(ids)
+	if limit <= 0 {
+		for _, id := range ids {
+			d.expire(id, phase)
+		}
+		return false
+	}
+
+	start := time.Now()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn()
+	}()
+
+	finished := true
+	timer := time.NewTimer(limit)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+		finished = false
+	}
+
+	for _, id := range ids {
+		d.charge(id, time.Since(start))
+		if !finished {
+			d.expire(id, phase)
+		}
+	}
+	return finished
+}
@@ -0,0 +1,40 @@
+package llm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEntryDeadlines(t *testing.T) {
+	deadlines := newEntryDeadlines(100 * time.Millisecond)
+
+	assert.True(t, deadlines.run([]string{"a"}, "describing its image", func() {}))
+	assert.NoError(t, deadlines.err("a"))
+
+	// Time is charged across phases, so an entry that used most of its time earlier has little left
+	deadlines.charge("a", 90*time.Millisecond)
+	release := make(chan struct{})
+	defer close(release)
+	assert.False(t, deadlines.run([]string{"a", "b"}, "fetching its links", func() { <-release }))
+	assert.ErrorIs(t, deadlines.err("a"), ErrEntryTimeout)
+	assert.ErrorContains(t, deadlines.err("b"), "entry timed out after 100ms while fetching its links")
+
+	// An entry that has used up its time is not run again, and keeps the phase it timed out in
+	deadlines.charge("c", time.Second)
+	assert.False(t, deadlines.run([]string{"c"}, "summarizing it", func() { t.Error("ran an entry without time left") }))
+	assert.ErrorContains(t, deadlines.err("c"), "while summarizing it")
+	assert.False(t, deadlines.run([]string{"a"}, "summarizing it", func() {}))
+	assert.ErrorContains(t, deadlines.err("a"), "while fetching its links")
+}
+
+func TestEntryDeadlines_NoLimit(t *testing.T) {
+	deadlines := newEntryDeadlines(0)
+	deadlines.charge("a", time.Hour)
+
+	ran := false
+	assert.True(t, deadlines.run([]string{"a"}, "summarizing it", func() { ran = true }))
+	assert.True(t, ran)
+	assert.NoError(t, deadlines.err("a"))
+}
@@ -0,0 +1,62 @@
+package llm
+
+import (
+	"log"
+	"sync/atomic"
+)
+
+// Enrichments ProcessEntries continues without when their backend keeps failing
+const (
+	DegradedImages = "images" // Image descriptions, when the image model is down
+	DegradedLinks  = "links"  // External URL summaries, when URL fetching keeps failing
+)
+
+// breaker trips after a number of consecutive failures so a stage whose backend is down is
+// skipped instead of failing, and waiting on retries, for every remaining entry.
+// It is safe for concurrent use, and a nil breaker never trips.
+type breaker struct {
+	name      string
+	threshold int64 // Consecutive failures that trip the breaker (0 never trips)
+	failures  atomic.Int64
+	open      atomic.Bool
+}
+
+// newBreaker creates a breaker for the named enrichment that trips after threshold consecutive failures
+func newBreaker(name string, threshold int) *breaker {
+	return &breaker{name: name, threshold: int64(max(threshold, 0))}
+}
+
+// Success resets the consecutive failure count
+func (b *breaker) Success() {
+	if b == nil {
+		return
+	}
+	b.failures.Store(0)
+}
+
+// Failure counts a failure and trips the breaker once the threshold is reached
+func (b *breaker) Failure() {
+	if b == nil || b.threshold == 0 {
+		return
+	}
+	if b.failures.Add(1) >= b.threshold && !b.open.Swap(true) {
+		log.Printf("Degrading: skipping %s for the remaining entries after %d consecutive failures\n", b.name, b.threshold)
+	}
+}
+
+// Open reports whether the breaker has tripped
+func (b *breaker) Open() bool {
+	if b == nil {
+		return false
+	}
+	return b.open.Load()
+}
+
+// Reset closes the breaker and clears its failure count
+func (b *breaker) Reset() {
+	if b == nil {
+		return
+	}
+	b.failures.Store(0)
+	b.open.Store(false)
+}
@@ -0,0 +1,112 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
+	"github.com/bakkerme/ai-news-processor/internal/openai"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/internal/urlextraction"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBreaker(t *testing.T) {
+	b := newBreaker("images", 2)
+	b.Failure()
+	b.Success()
+	b.Failure()
+	assert.False(t, b.Open(), "a success resets the consecutive failures")
+	b.Failure()
+	assert.True(t, b.Open())
+	b.Reset()
+	assert.False(t, b.Open())
+
+	disabled := newBreaker("images", 0)
+	for range 10 {
+		disabled.Failure()
+	}
+	assert.False(t, disabled.Open(), "a threshold of 0 never trips")
+
+	var none *breaker
+	none.Failure()
+	assert.False(t, none.Open())
+}
+
+// failingFetcher fails every fetch, like a network outage
+type failingFetcher struct {
+	fetched atomic.Int32
+}
+
+func (f *failingFetcher) Fetch(ctx context.Context, u *url.URL) (*http.Response, error) {
+	f.fetched.Add(1)
+	return nil, errors.New("dial tcp: connection refused")
+}
+
+// linkExtractor returns the same external URL for every entry
+type linkExtractor struct {
+	mockURLExtractor
+	link url.URL
+}
+
+func (e *linkExtractor) ExtractExternalURLsFromEntry(entry urlextraction.ContentProvider) ([]url.URL, error) {
+	return []url.URL{e.link}, nil
+}
+
+func TestProcessEntries_DegradesEnrichment(t *testing.T) {
+	var imageCalls atomic.Int32
+	var imageModelDown atomic.Bool
+	imageModelDown.Store(true)
+	client := &mockOpenAIClient{
+		ChatCompletionFunc: func(systemPrompt string, userPrompts []string, imageURLs []string, schemaParams *openai.SchemaParameters, temperature float64, maxTokens int) openai.Result {
+			if len(imageURLs) > 0 {
+				imageCalls.Add(1)
+				if imageModelDown.Load() {
+					return openai.Result{Err: errors.New("connection refused")}
+				}
+				return openai.Result{Content: "A chart"}
+			}
+			return openai.Result{Content: `{"isRelevant":true,"summary":"A summary"}`}
+		},
+	}
+
+	imageURL, _ := url.Parse("https://i.redd.it/image.png")
+	link, _ := url.Parse("https://example.com/article")
+	entries := make([]feeds.Entry, 6)
+	for i := range entries {
+		entries[i] = feeds.Entry{ID: string(rune('a' + i)), Title: "Story", ImageURLs: []url.URL{*imageURL}}
+	}
+
+	config := EntryProcessConfig{
+		InitialBackoff:       time.Millisecond,
+		MaxBackoff:           time.Millisecond,
+		BackoffFactor:        1,
+		ImageEnabled:         true,
+		ImageConcurrency:     1,
+		URLSummaryEnabled:    true,
+		DegradeAfterFailures: 2,
+	}
+	pages := &failingFetcher{}
+	processor := NewProcessor(client, client, config, &mockArticleExtractor{}, pages, &linkExtractor{link: *link}, &mockImageFetcher{})
+
+	items, runData, err := processor.ProcessEntries("system", entries, persona.Persona{Name: "Test"})
+	require.NoError(t, err, "the persona continues without images and links")
+	assert.Len(t, items, len(entries))
+	assert.Equal(t, int32(2), imageCalls.Load(), "images are skipped once the image model failed twice")
+	assert.Equal(t, int32(2), pages.fetched.Load(), "external URLs are skipped once fetching failed twice")
+	assert.Equal(t, []string{DegradedImages, DegradedLinks}, runData.Degraded)
+	assert.Equal(t, runData.Degraded, processor.Stats().Degraded)
+
+	// The next call tries the image model again
+	imageModelDown.Store(false)
+	_, runData, err = processor.ProcessEntries("system", entries, persona.Persona{Name: "Test"})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2+len(entries)), imageCalls.Load())
+	assert.Equal(t, []string{DegradedLinks}, runData.Degraded)
+}
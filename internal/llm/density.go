@@ -0,0 +1,94 @@
+package llm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bakkerme/ai-news-processor/internal/openai"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/internal/tokens"
+	"github.com/bakkerme/ai-news-processor/models"
+)
+
+// MaxTokensDenseNotes limits the notes taken by the first pass of a dense summary (non-JSON, can be safely limited)
+const MaxTokensDenseNotes = 1000
+
+const denseNotesPromptTemplate = `You take notes on a long post titled %q for %s.
+
+List every distinct fact, number, name, link, claim and disagreement in the post, its linked pages and its discussion that a reader of a technical newsletter would want to know, one per line, most important first. Note who disagrees with what. Leave out jokes, repetition and chit-chat. Do not add anything that is not in the text.
+
+Respond with the list only, as plain text.`
+
+const denseRewritePromptTemplate = `You edit the newsletter write-up of a long post titled %q for %s.
+
+You receive the current summary, the current comment summary and notes taken from the post and its discussion. Rewrite both so they are denser: work in the facts, numbers and names from the notes that they leave out, and make room by cutting filler, vague phrases and repetition. Keep their length, paragraphs, style and language. Do not add anything that is not in the notes or the current text.
+
+Respond only with valid JSON. Put JSON in ` + "```json" + ` tags. Use the following JSON structure:
+{"summary": "...", "commentSummary": "..."}`
+
+// denseSummary is the response of the rewrite pass of a dense summary
+type denseSummary struct {
+	Summary        string `json:"summary"`
+	CommentSummary string `json:"commentSummary"`
+}
+
+// wantsDenseSummary reports whether a rendered entry is long enough for the persona's dense
+// summary mode
+func wantsDenseSummary(persona persona.Persona, entryString string) bool {
+	return persona.DenseSummaryTokens > 0 && tokens.Estimate(entryString) >= persona.DenseSummaryTokens
+}
+
+// densify rewrites the summaries of an item processed from a long entry in two more passes: notes
+// are extracted from the entry, then the summary and comment summary are rewritten to hold the
+// details of the notes they miss. The single-pass prompt stays vague on megathreads, where it has
+// to judge, summarize and cover hundreds of comments at once. The other fields are kept.
+func (p *Processor) densify(item models.Item, entryString string, persona persona.Persona) (models.Item, openai.Usage, error) {
+	var usage openai.Usage
+
+	notesPrompt := fmt.Sprintf(denseNotesPromptTemplate, item.Title, persona.Name)
+	notes, err := p.retryStringFunc(func() (string, error) {
+		result := chatCompletionForDenseNotes(p.client, notesPrompt, entryString)
+		usage = usage.Add(result.Usage)
+		if result.Err != nil {
+			return "", fmt.Errorf("could not take notes: %w", result.Err)
+		}
+		return strings.TrimSpace(result.Content), nil
+	}, "dense summary notes")
+	if err != nil {
+		return item, usage, err
+	}
+
+	current := fmt.Sprintf("Summary: %s\n\nComment summary: %s\n\nNotes:\n%s", item.Summary, item.CommentSummary, notes)
+	rewritePrompt := fmt.Sprintf(denseRewritePromptTemplate, item.Title, persona.Name)
+	rewritten, err := retryLLM(p.config, "dense summary rewrite", func() (denseSummary, error) {
+		result := chatCompletionForEntrySummary(p.client, rewritePrompt, []string{current}, nil, nil)
+		usage = usage.Add(result.Usage)
+		if result.Err != nil {
+			return denseSummary{}, fmt.Errorf("could not rewrite the summary: %w", result.Err)
+		}
+		var dense denseSummary
+		if err := json.Unmarshal([]byte(p.client.PreprocessJSON(result.Content)), &dense); err != nil {
+			openai.Discard(p.client, result)
+			return denseSummary{}, fmt.Errorf("could not parse the rewritten summary: %w", err)
+		}
+		if strings.TrimSpace(dense.Summary) == "" {
+			openai.Discard(p.client, result)
+			return denseSummary{}, errors.New("the rewritten summary is empty")
+		}
+		return dense, nil
+	}, &p.retries)
+	if err != nil {
+		return item, usage, err
+	}
+
+	log.Printf("Rewrote the summary of entry %s densely, from %d to %d words\n", item.ID, len(strings.Fields(item.Summary)), len(strings.Fields(rewritten.Summary)))
+	item.Summary = rewritten.Summary
+	// The discussion of a post without comments is left unsummarized
+	if item.CommentSummary != "" && rewritten.CommentSummary != "" {
+		item.CommentSummary = rewritten.CommentSummary
+	}
+	return item, usage, nil
+}
@@ -0,0 +1,75 @@
+package llm
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
+	"github.com/bakkerme/ai-news-processor/internal/openai"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const singlePassResponse = `{"id": "1", "title": "Megathread", "summary": "People discuss the release.", "commentSummary": "Opinions differ.", "isRelevant": true}`
+
+func TestProcessEntryRewritesLongEntriesDensely(t *testing.T) {
+	var requests []string
+	client := &mockOpenAIClient{
+		ChatCompletionFunc: func(systemPrompt string, userPrompts []string, imageURLs []string, schemaParams *openai.SchemaParameters, temperature float64, maxTokens int) openai.Result {
+			switch {
+			case strings.HasPrefix(systemPrompt, "You take notes"):
+				requests = append(requests, "notes")
+				return openai.Result{Content: "- Llama 4 scores 81 on MMLU\n- Users report 12 tok/s on a 3090", Usage: openai.Usage{Calls: 1}}
+			case strings.HasPrefix(systemPrompt, "You edit"):
+				requests = append(requests, "rewrite")
+				assert.Contains(t, userPrompts[0], "Users report 12 tok/s")
+				return openai.Result{Content: `{"summary": "Llama 4 scores 81 on MMLU.", "commentSummary": "Users see 12 tok/s on a 3090."}`, Usage: openai.Usage{Calls: 1}}
+			default:
+				requests = append(requests, "entry")
+				return openai.Result{Content: singlePassResponse, Usage: openai.Usage{Calls: 1}}
+			}
+		},
+	}
+	config := EntryProcessConfig{InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, BackoffFactor: 1}
+	processor := NewProcessor(client, client, config, &mockArticleExtractor{}, &mockFetcher{}, &mockURLExtractor{}, &mockImageFetcher{})
+
+	entry := feeds.Entry{ID: "1", Title: "Megathread", Content: strings.Repeat("benchmark numbers ", 200)}
+	item, usage, err := processor.processEntryWithRetry("system", entry, persona.Persona{Name: "AI", DenseSummaryTokens: 100})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"entry", "notes", "rewrite"}, requests)
+	assert.Equal(t, "Llama 4 scores 81 on MMLU.", item.Summary)
+	assert.Equal(t, "Users see 12 tok/s on a 3090.", item.CommentSummary)
+	assert.True(t, item.IsRelevant, "fields other than the summaries are kept")
+	assert.Equal(t, 3, usage.Calls)
+
+	requests = nil
+	short := feeds.Entry{ID: "2", Title: "Short", Content: "A short post."}
+	item, _, err = processor.processEntryWithRetry("system", short, persona.Persona{Name: "AI", DenseSummaryTokens: 100})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"entry"}, requests, "short entries get a single pass")
+	assert.Equal(t, "People discuss the release.", item.Summary)
+}
+
+func TestProcessEntryKeepsSinglePassSummaryWhenRewriteFails(t *testing.T) {
+	client := &mockOpenAIClient{
+		ChatCompletionFunc: func(systemPrompt string, userPrompts []string, imageURLs []string, schemaParams *openai.SchemaParameters, temperature float64, maxTokens int) openai.Result {
+			switch {
+			case strings.HasPrefix(systemPrompt, "You take notes"):
+				return openai.Result{Content: "- a note"}
+			case strings.HasPrefix(systemPrompt, "You edit"):
+				return openai.Result{Content: `{"summary": ""}`}
+			default:
+				return openai.Result{Content: singlePassResponse}
+			}
+		},
+	}
+	config := EntryProcessConfig{InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, BackoffFactor: 1, MaxRetries: 1}
+	processor := NewProcessor(client, client, config, &mockArticleExtractor{}, &mockFetcher{}, &mockURLExtractor{}, &mockImageFetcher{})
+
+	entry := feeds.Entry{ID: "1", Title: "Megathread", Content: strings.Repeat("benchmark numbers ", 200)}
+	item, _, err := processor.processEntryWithRetry("system", entry, persona.Persona{Name: "AI", DenseSummaryTokens: 100})
+	require.NoError(t, err)
+	assert.Equal(t, "People discuss the release.", item.Summary)
+}
@@ -0,0 +1,84 @@
+package llm
+
+import (
+	"log"
+	"strings"
+
+	"github.com/bakkerme/ai-news-processor/models"
+)
+
+// maxTopicsPerItem caps the number of topic labels kept per item
+const maxTopicsPerItem = 3
+
+var entityTypes = map[string]string{
+	"model":   models.EntityModel,
+	"llm":     models.EntityModel,
+	"company": models.EntityCompany,
+	"org":     models.EntityCompany,
+	"library": models.EntityLibrary,
+	"tool":    models.EntityLibrary,
+	"dataset": models.EntityDataset,
+	"person":  models.EntityPerson,
+}
+
+// Bounds of the importance score the LLM assigns to an item, as in the item JSON schema
+const (
+	minImportanceScore = 1
+	maxImportanceScore = 10
+)
+
+// normalizeImportance validates the importance score returned by the LLM. A score outside the
+// schema's bounds is dropped along with its justification, leaving the item unscored.
+func normalizeImportance(item *models.Item) {
+	item.ImportanceReason = strings.TrimSpace(item.ImportanceReason)
+	if item.ImportanceScore < minImportanceScore || item.ImportanceScore > maxImportanceScore {
+		if item.ImportanceScore != 0 {
+			log.Printf("Ignoring importance score %d of item %s, must be %d to %d", item.ImportanceScore, item.ID, minImportanceScore, maxImportanceScore)
+		}
+		item.ImportanceScore = 0
+		item.ImportanceReason = ""
+	}
+}
+
+// normalizeTags cleans up the entities and topics returned by the LLM: names are trimmed,
+// unknown entity types become "other", topics are lowercased and duplicates are dropped
+func normalizeTags(item *models.Item) {
+	seen := make(map[string]struct{})
+	var entities []models.Entity
+	for _, entity := range item.Entities {
+		name := strings.TrimSpace(entity.Name)
+		if name == "" {
+			continue
+		}
+		key := strings.ToLower(name)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+
+		entityType, ok := entityTypes[strings.ToLower(strings.TrimSpace(entity.Type))]
+		if !ok {
+			entityType = models.EntityOther
+		}
+		entities = append(entities, models.Entity{Name: name, Type: entityType})
+	}
+	item.Entities = entities
+
+	seen = make(map[string]struct{})
+	var topics []string
+	for _, topic := range item.Topics {
+		topic = strings.ToLower(strings.TrimSpace(topic))
+		if topic == "" {
+			continue
+		}
+		if _, ok := seen[topic]; ok {
+			continue
+		}
+		seen[topic] = struct{}{}
+		topics = append(topics, topic)
+		if len(topics) == maxTopicsPerItem {
+			break
+		}
+	}
+	item.Topics = topics
+}
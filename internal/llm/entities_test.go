@@ -0,0 +1,83 @@
+package llm
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bakkerme/ai-news-processor/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	item := models.Item{
+		Entities: []models.Entity{
+			{Name: " Llama 3.1 ", Type: "Model"},
+			{Name: "llama 3.1", Type: "model"},
+			{Name: "llama.cpp", Type: "tool"},
+			{Name: "Meta", Type: "organisation"},
+			{Name: "", Type: "model"},
+		},
+		Topics: []string{"Quantization", "quantization ", "", "benchmarks", "local inference", "fine-tuning"},
+	}
+
+	normalizeTags(&item)
+
+	assert.Equal(t, []models.Entity{
+		{Name: "Llama 3.1", Type: models.EntityModel},
+		{Name: "llama.cpp", Type: models.EntityLibrary},
+		{Name: "Meta", Type: models.EntityOther},
+	}, item.Entities)
+	assert.Equal(t, []string{"quantization", "benchmarks", "local inference"}, item.Topics)
+
+	empty := models.Item{}
+	normalizeTags(&empty)
+	assert.Nil(t, empty.Entities)
+	assert.Nil(t, empty.Topics)
+}
+
+func TestNormalizeImportance(t *testing.T) {
+	for given, expected := range map[int]int{-1: 0, 0: 0, 1: 1, 7: 7, 10: 10, 11: 0} {
+		item := models.Item{ImportanceScore: given, ImportanceReason: " Reason "}
+		normalizeImportance(&item)
+		assert.Equal(t, expected, item.ImportanceScore, "importance %d", given)
+		if expected == 0 {
+			assert.Empty(t, item.ImportanceReason, "importance %d", given)
+		} else {
+			assert.Equal(t, "Reason", item.ImportanceReason)
+		}
+	}
+}
+
+func TestFilterImportantItems(t *testing.T) {
+	items := []models.Item{{ID: "a", ImportanceScore: 3}, {ID: "b", ImportanceScore: 6}, {ID: "c"}, {ID: "d", ImportanceScore: 5}, {ID: "e", ImportanceScore: 1, Watchlist: []string{"Qwen"}}}
+
+	kept := FilterImportantItems(items, 5)
+
+	ids := make([]string, len(kept))
+	for i, item := range kept {
+		ids[i] = item.ID
+	}
+	assert.Equal(t, []string{"b", "c", "d", "e"}, ids, "unscored and watched items are kept")
+}
+
+func TestItemResponseSchema_ImportanceScore(t *testing.T) {
+	data, err := json.Marshal(ItemResponseSchema)
+	require.NoError(t, err)
+	var schema struct {
+		Items struct {
+			Properties map[string]struct {
+				Type    string `json:"type"`
+				Minimum int    `json:"minimum"`
+				Maximum int    `json:"maximum"`
+			} `json:"properties"`
+		} `json:"items"`
+	}
+	require.NoError(t, json.Unmarshal(data, &schema))
+
+	score := schema.Items.Properties["importanceScore"]
+	assert.Equal(t, "integer", score.Type)
+	assert.Equal(t, minImportanceScore, score.Minimum)
+	assert.Equal(t, maxImportanceScore, score.Maximum)
+	assert.Contains(t, schema.Items.Properties, "importanceReason")
+}
@@ -0,0 +1,112 @@
+package llm
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+
+	"github.com/bakkerme/ai-news-processor/internal/openai"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/models"
+	"github.com/invopop/jsonschema"
+)
+
+// entryResponseSchema returns the schema of an entry response with the custom fields of a
+// persona. The fields are optional, as the LLM leaves out what a post does not say.
+func entryResponseSchema(fields []persona.Field) *openai.SchemaParameters {
+	if len(fields) == 0 {
+		return EntryResponseSchema
+	}
+	schema := GenerateSchema[entryResponse]().(*jsonschema.Schema)
+	for _, f := range fields {
+		schema.Properties.Set(f.Name, fieldSchema(f))
+	}
+	return &openai.SchemaParameters{
+		Schema:      schema,
+		Name:        EntryResponseSchema.Name,
+		Description: EntryResponseSchema.Description,
+	}
+}
+
+// fieldSchema returns the schema of the value of a custom field
+func fieldSchema(f persona.Field) *jsonschema.Schema {
+	switch f.GetType() {
+	case persona.FieldList:
+		return &jsonschema.Schema{Type: "array", Items: &jsonschema.Schema{Type: "string"}, Description: f.Description}
+	case persona.FieldString:
+		return &jsonschema.Schema{Type: "string", Description: f.Description}
+	default:
+		return &jsonschema.Schema{Type: f.GetType(), Description: f.Description}
+	}
+}
+
+// customFields reads the values of the custom fields of a persona from an entry response. Fields
+// the LLM left out, left empty or answered with a value of the wrong type are skipped.
+func customFields(fields []persona.Field, response string) []models.Field {
+	if len(fields) == 0 {
+		return nil
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(response), &raw); err != nil {
+		return nil
+	}
+
+	var values []models.Field
+	for _, f := range fields {
+		data, ok := raw[f.Name]
+		if !ok {
+			continue
+		}
+		value, ok := fieldValue(f.GetType(), data)
+		if !ok {
+			continue
+		}
+		values = append(values, models.Field{Name: f.Name, Label: f.GetLabel(), Value: value})
+	}
+	return values
+}
+
+// fieldValue decodes the value of a custom field of the given type, returning false for a value
+// that is empty or of another type
+func fieldValue(fieldType string, data json.RawMessage) (any, bool) {
+	switch fieldType {
+	case persona.FieldNumber:
+		var v float64
+		if err := json.Unmarshal(data, &v); err != nil {
+			return nil, false
+		}
+		return v, true
+	case persona.FieldInteger:
+		// Models write whole numbers as 7.0 now and then
+		var v float64
+		if err := json.Unmarshal(data, &v); err != nil || v != math.Trunc(v) {
+			return nil, false
+		}
+		return int64(v), true
+	case persona.FieldBoolean:
+		var v bool
+		if err := json.Unmarshal(data, &v); err != nil {
+			return nil, false
+		}
+		return v, true
+	case persona.FieldList:
+		var v []string
+		if err := json.Unmarshal(data, &v); err != nil {
+			return nil, false
+		}
+		list := make([]string, 0, len(v))
+		for _, s := range v {
+			if s = strings.TrimSpace(s); s != "" {
+				list = append(list, s)
+			}
+		}
+		return list, len(list) > 0
+	default:
+		var v string
+		if err := json.Unmarshal(data, &v); err != nil {
+			return nil, false
+		}
+		v = strings.TrimSpace(v)
+		return v, v != ""
+	}
+}
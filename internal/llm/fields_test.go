@@ -0,0 +1,95 @@
+package llm
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bakkerme/ai-news-processor/internal/gbnf"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testFields = []persona.Field{
+	{Name: "license", Description: "The license of the weights", Label: "License"},
+	{Name: "modelParams", Type: persona.FieldNumber, Description: "Parameters in billions"},
+	{Name: "contextLength", Type: persona.FieldInteger, Description: "Context length in tokens"},
+	{Name: "openWeights", Type: persona.FieldBoolean, Description: "Whether the weights can be downloaded"},
+	{Name: "benchmarkNumbers", Type: persona.FieldList, Description: "Benchmark results with their scores"},
+}
+
+func TestCustomFields(t *testing.T) {
+	response := `{"summary":"A summary","license":" Apache-2.0 ","modelParams":7.6,"contextLength":32768.0,"openWeights":true,"benchmarkNumbers":["MMLU 74.2",""," "]}`
+	fields := customFields(testFields, response)
+	assert.Equal(t, []models.Field{
+		{Name: "license", Label: "License", Value: "Apache-2.0"},
+		{Name: "modelParams", Label: "modelParams", Value: 7.6},
+		{Name: "contextLength", Label: "contextLength", Value: int64(32768)},
+		{Name: "openWeights", Label: "openWeights", Value: true},
+		{Name: "benchmarkNumbers", Label: "benchmarkNumbers", Value: []string{"MMLU 74.2"}},
+	}, fields)
+
+	fields = customFields(testFields, `{"license":"","modelParams":"seven","contextLength":1.5,"benchmarkNumbers":[]}`)
+	assert.Empty(t, fields, "empty values and values of the wrong type are left out")
+
+	assert.Nil(t, customFields(nil, response))
+	assert.Nil(t, customFields(testFields, "not json"))
+}
+
+func TestEntryResponseSchema_Fields(t *testing.T) {
+	require.Same(t, EntryResponseSchema, entryResponseSchema(nil))
+
+	params := entryResponseSchema(testFields)
+	data, err := json.Marshal(params.Schema)
+	require.NoError(t, err)
+	var schema struct {
+		Properties map[string]struct {
+			Type  string `json:"type"`
+			Items *struct {
+				Type string `json:"type"`
+			} `json:"items"`
+		} `json:"properties"`
+		Required []string `json:"required"`
+	}
+	require.NoError(t, json.Unmarshal(data, &schema))
+	assert.Equal(t, "string", schema.Properties["license"].Type)
+	assert.Equal(t, "number", schema.Properties["modelParams"].Type)
+	assert.Equal(t, "integer", schema.Properties["contextLength"].Type)
+	assert.Equal(t, "boolean", schema.Properties["openWeights"].Type)
+	require.NotNil(t, schema.Properties["benchmarkNumbers"].Items)
+	assert.Equal(t, "string", schema.Properties["benchmarkNumbers"].Items.Type)
+	assert.NotContains(t, schema.Required, "license", "custom fields are optional")
+	assert.Contains(t, schema.Properties, "summary")
+
+	_, hasLicense := getProperties(t, EntryResponseSchema.Schema)["license"]
+	assert.False(t, hasLicense, "the shared schema is left alone")
+
+	grammar, err := gbnf.FromSchema(params.Schema)
+	require.NoError(t, err)
+	assert.Contains(t, grammar, "benchmarkNumbers")
+}
+
+func getProperties(t *testing.T, schema any) map[string]any {
+	data, err := json.Marshal(schema)
+	require.NoError(t, err)
+	var parsed struct {
+		Properties map[string]any `json:"properties"`
+	}
+	require.NoError(t, json.Unmarshal(data, &parsed))
+	return parsed.Properties
+}
+
+func TestReservedFieldNames(t *testing.T) {
+	// A custom field named like a built-in field would overwrite it when the response is decoded
+	itemType := reflect.TypeOf(models.Item{})
+	for i := 0; i < itemType.NumField(); i++ {
+		name := strings.Split(itemType.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		assert.Contains(t, persona.ReservedFieldNames, name, "custom fields must not be named like models.Item fields")
+	}
+}
@@ -0,0 +1,300 @@
+package llm
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
+	"github.com/bakkerme/ai-news-processor/internal/ocr"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/internal/prompts"
+	"github.com/bakkerme/ai-news-processor/internal/runcache"
+	"github.com/bakkerme/ai-news-processor/models"
+)
+
+// ocrTimeout bounds a single OCR run
+const ocrTimeout = 30 * time.Second
+
+// imageSectionPattern matches the "### Image N" headers that separate descriptions in a batched response.
+// Models sometimes drop the heading marker or bold the label, so those variants are accepted too.
+var imageSectionPattern = regexp.MustCompile(`(?mi)^[ \t]*(?:#+[ \t]*)?\**[ \t]*image[ \t]+(\d+)[ \t]*:?[ \t]*\**[ \t]*:?[ \t]*$`)
+
+// processImages describes the first image of every entry that has one.
+// Entries are grouped into batches of ImageBatchSize and up to ImageConcurrency batches run at once,
+// bounded by the processor's shared image semaphore. Once the image model has failed
+// DegradeAfterFailures times in a row, the remaining batches are skipped, and a batch that takes
+// longer than the EntryTimeout of its entries is given up on.
+func (p *Processor) processImages(entries []feeds.Entry, persona persona.Persona, benchmarkData *models.RunData) {
+	var indexes []int
+	for i := range entries {
+		if len(entries[i].ImageURLs) > 0 {
+			indexes = append(indexes, i)
+		}
+	}
+
+	batchSize := max(p.config.ImageBatchSize, 1)
+	var batches [][]int
+	for start := 0; start < len(indexes); start += batchSize {
+		batches = append(batches, indexes[start:min(start+batchSize, len(indexes))])
+	}
+
+	// Each batch writes to its own slot so benchmark data keeps entry order
+	results := make([][]models.ImageSummary, len(batches))
+
+	var wg sync.WaitGroup
+	for b, batch := range batches {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			p.imageSem <- struct{}{}
+			defer func() { <-p.imageSem }()
+
+			if err := p.budget.Exceeded(); err != nil {
+				log.Printf("Skipping images of entries %v: %v\n", batch, err)
+				return
+			}
+			if p.imageBreaker.Open() {
+				log.Printf("Skipping images of entries %v: the image model keeps failing\n", batch)
+				return
+			}
+
+			ids := make([]string, len(batch))
+			for n, i := range batch {
+				ids[n] = entries[i].ID
+			}
+			var summaries []models.ImageSummary
+			if !p.deadlines.run(ids, "describing its image", func() {
+				if len(batch) == 1 {
+					if summary, ok := p.describeImage(entries, batch[0], persona); ok {
+						summaries = []models.ImageSummary{summary}
+					}
+					return
+				}
+				summaries = p.describeImageBatch(entries, batch, persona)
+			}) {
+				log.Printf("Giving up on the images of entries %v after %s\n", batch, p.config.EntryTimeout)
+				return
+			}
+			results[b] = summaries
+		}()
+	}
+	wg.Wait()
+
+	// Descriptions are stored on the entries once all batches are done, as a batch that ran out
+	// of time carries on in the background
+	for b, summaries := range results {
+		for _, summary := range summaries {
+			for _, i := range batches[b] {
+				if entries[i].ID == summary.EntryID {
+					entries[i].ImageDescription = summary.ImageDescription
+				}
+			}
+		}
+		benchmarkData.ImageSummaries = append(benchmarkData.ImageSummaries, summaries...)
+	}
+}
+
+// describeImage describes the first image of a single entry
+func (p *Processor) describeImage(entries []feeds.Entry, i int, persona persona.Persona) (models.ImageSummary, bool) {
+	imagePrompt, err := prompts.ComposeImagePrompt(persona, entries[i].Title)
+	if err != nil {
+		log.Printf("Error creating image prompt for entry %d: %v\n", i, err)
+		return models.ImageSummary{}, false
+	}
+
+	imgURL := entries[i].ImageURLs[0].String()
+	log.Printf("Processing image for entry %d: %s\n", i, imgURL)
+
+	// Personas with the same identity get the same prompt, so they share the description
+	imgStartTime := time.Now()
+	key := runcache.Key("image description", p.imageClient.GetModelName(), imagePrompt, imgURL)
+	imageDescription, err := runcache.Do(p.runCache, key, func() (string, error) {
+		dataURI, err := p.fetchImage(imgURL)
+		if err != nil {
+			return "", fmt.Errorf("could not fetch image from %s: %w", imgURL, err)
+		}
+		description, err := p.processImageWithRetry(dataURI, imagePrompt)
+		if err != nil {
+			p.imageBreaker.Failure()
+			return "", err
+		}
+		p.imageBreaker.Success()
+		return p.appendOCRText(description, dataURI, i), nil
+	})
+	if err != nil {
+		log.Printf("Error processing image for entry %d: %v\n", i, err)
+		return models.ImageSummary{}, false
+	}
+
+	log.Printf("Image processing successful for entry %d\n", i)
+
+	return models.ImageSummary{
+		ImageURL:         entries[i].ImageURLs[0].String(),
+		ImageDescription: imageDescription,
+		Title:            entries[i].Title,
+		EntryID:          entries[i].ID,
+		ProcessingTime:   time.Since(imgStartTime).Milliseconds(),
+	}, true
+}
+
+// describeImageBatch describes the first image of several entries in one multimodal request.
+// If the batch request fails or its response cannot be split per image, each entry is retried on its own.
+func (p *Processor) describeImageBatch(entries []feeds.Entry, batch []int, persona persona.Persona) []models.ImageSummary {
+	batchStartTime := time.Now()
+
+	var included []int
+	var titles []string
+	var dataURIs []string
+	for _, i := range batch {
+		imgURL := entries[i].ImageURLs[0].String()
+		dataURI, err := p.fetchImage(imgURL)
+		if err != nil {
+			log.Printf("Error fetching image for entry %d from %s: %v\n", i, imgURL, err)
+			continue
+		}
+		included = append(included, i)
+		titles = append(titles, entries[i].Title)
+		dataURIs = append(dataURIs, dataURI)
+	}
+	if len(included) == 0 {
+		return nil
+	}
+
+	descriptions, err := p.requestImageBatch(titles, dataURIs, persona)
+	if err != nil {
+		log.Printf("Batched image request for entries %v failed, falling back to individual requests: %v\n", included, err)
+
+		var summaries []models.ImageSummary
+		for _, i := range included {
+			if p.imageBreaker.Open() {
+				break
+			}
+			if summary, ok := p.describeImage(entries, i, persona); ok {
+				summaries = append(summaries, summary)
+			}
+		}
+		return summaries
+	}
+
+	// The batch time is shared evenly so totals stay comparable with unbatched runs
+	processingTime := time.Since(batchStartTime).Milliseconds() / int64(len(included))
+
+	summaries := make([]models.ImageSummary, 0, len(included))
+	for n, i := range included {
+		description := p.appendOCRText(descriptions[n], dataURIs[n], i)
+		summaries = append(summaries, models.ImageSummary{
+			ImageURL:         entries[i].ImageURLs[0].String(),
+			ImageDescription: description,
+			Title:            entries[i].Title,
+			EntryID:          entries[i].ID,
+			ProcessingTime:   processingTime,
+		})
+	}
+	log.Printf("Batched image processing successful for entries %v\n", included)
+
+	return summaries
+}
+
+// fetchImage fetches an image as a data URI, once per run for all personas whose entries show it
+func (p *Processor) fetchImage(imgURL string) (string, error) {
+	return runcache.Do(p.runCache, "image:"+imgURL, func() (string, error) {
+		return p.imageFetcher.FetchAsBase64(imgURL)
+	})
+}
+
+// requestImageBatch sends one request for all images and splits the response into a description per image
+func (p *Processor) requestImageBatch(titles []string, dataURIs []string, persona persona.Persona) ([]string, error) {
+	batchPrompt, err := prompts.ComposeImageBatchPrompt(persona, titles)
+	if err != nil {
+		return nil, fmt.Errorf("could not create batch image prompt: %w", err)
+	}
+
+	response, err := p.retryStringFunc(func() (string, error) {
+		return chatCompletionImageBatchSummary(p.imageClient, batchPrompt, dataURIs)
+	}, "image batch")
+	if err != nil {
+		p.imageBreaker.Failure()
+		return nil, err
+	}
+	p.imageBreaker.Success()
+
+	return splitImageBatchResponse(response, len(dataURIs))
+}
+
+// appendOCRText appends text recognized in the image to its description so exact figures in
+// screenshots and benchmark tables survive. The description is returned unchanged when OCR is
+// disabled, fails, or finds too little text.
+func (p *Processor) appendOCRText(description string, dataURI string, i int) string {
+	if p.ocr == nil {
+		return description
+	}
+
+	imageData, err := decodeDataURI(dataURI)
+	if err != nil {
+		log.Printf("Could not decode image for OCR on entry %d: %v\n", i, err)
+		return description
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ocrTimeout)
+	defer cancel()
+
+	text, err := p.ocr.Recognize(ctx, imageData)
+	if err != nil {
+		log.Printf("OCR failed for entry %d: %v\n", i, err)
+		return description
+	}
+
+	text = ocr.Clean(text)
+	if !ocr.IsTextHeavy(text) {
+		return description
+	}
+
+	log.Printf("Appending %d characters of OCR text to image description for entry %d\n", len(text), i)
+	return fmt.Sprintf("%s\n\nText extracted from the image (OCR):\n%s", description, text)
+}
+
+// decodeDataURI returns the raw bytes of a base64 data URI
+func decodeDataURI(dataURI string) ([]byte, error) {
+	_, data, ok := strings.Cut(dataURI, ";base64,")
+	if !ok {
+		return nil, fmt.Errorf("not a base64 data URI")
+	}
+	return base64.StdEncoding.DecodeString(data)
+}
+
+// splitImageBatchResponse splits a batched image response on its "### Image N" headers.
+// It returns an error unless a non-empty description is found for every image.
+func splitImageBatchResponse(response string, count int) ([]string, error) {
+	descriptions := make([]string, count)
+
+	matches := imageSectionPattern.FindAllStringSubmatchIndex(response, -1)
+	for m, match := range matches {
+		n, err := strconv.Atoi(response[match[2]:match[3]])
+		if err != nil || n < 1 || n > count {
+			continue
+		}
+
+		end := len(response)
+		if m+1 < len(matches) {
+			end = matches[m+1][0]
+		}
+		if descriptions[n-1] == "" {
+			descriptions[n-1] = strings.TrimSpace(response[match[1]:end])
+		}
+	}
+
+	for n, description := range descriptions {
+		if description == "" {
+			return nil, fmt.Errorf("batched response is missing a description for image %d", n+1)
+		}
+	}
+	return descriptions, nil
+}
@@ -0,0 +1,241 @@
+package llm
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
+	"github.com/bakkerme/ai-news-processor/internal/openai"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/internal/runcache"
+	"github.com/bakkerme/ai-news-processor/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitImageBatchResponse(t *testing.T) {
+	tests := []struct {
+		name      string
+		response  string
+		count     int
+		expected  []string
+		expectErr bool
+	}{
+		{
+			name:     "markdown headers",
+			response: "### Image 1\nA benchmark chart.\n\n### Image 2\nA terminal screenshot.",
+			count:    2,
+			expected: []string{"A benchmark chart.", "A terminal screenshot."},
+		},
+		{
+			name:     "bold labels with preamble",
+			response: "Here are the descriptions.\n**Image 1:**\nA GPU.\n**Image 2:**\nA diagram.",
+			count:    2,
+			expected: []string{"A GPU.", "A diagram."},
+		},
+		{
+			name:      "missing section",
+			response:  "### Image 1\nA GPU.",
+			count:     2,
+			expectErr: true,
+		},
+		{
+			name:      "no sections",
+			response:  "Both images show GPUs.",
+			count:     2,
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			descriptions, err := splitImageBatchResponse(tt.response, tt.count)
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, descriptions)
+		})
+	}
+}
+
+func TestProcessImages_Batched(t *testing.T) {
+	var calls int32
+	client := &mockOpenAIClient{
+		ChatCompletionFunc: func(systemPrompt string, userPrompts []string, imageURLs []string, schemaParams *openai.SchemaParameters, temperature float64, maxTokens int) openai.Result {
+			atomic.AddInt32(&calls, 1)
+			var response strings.Builder
+			for n := range imageURLs {
+				fmt.Fprintf(&response, "### Image %d\ndescription %d\n", n+1, n+1)
+			}
+			return openai.Result{Content: response.String()}
+		},
+	}
+
+	config := DefaultEntryProcessConfig
+	config.ImageEnabled = true
+	config.ImageBatchSize = 2
+	config.ImageConcurrency = 2
+	processor := NewProcessor(client, client, config, &mockArticleExtractor{}, &mockFetcher{}, &mockURLExtractor{}, &mockImageFetcher{})
+
+	imageURL, _ := url.Parse("https://i.redd.it/image.png")
+	entries := []feeds.Entry{
+		{ID: "a", Title: "A", ImageURLs: []url.URL{*imageURL}},
+		{ID: "b", Title: "B"},
+		{ID: "c", Title: "C", ImageURLs: []url.URL{*imageURL}},
+		{ID: "d", Title: "D", ImageURLs: []url.URL{*imageURL}},
+	}
+
+	var benchmarkData models.RunData
+	processor.processImages(entries, persona.Persona{PersonaIdentity: "a tester"}, &benchmarkData)
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls), "three images in batches of two should take two requests")
+	assert.Equal(t, "description 1", entries[0].ImageDescription)
+	assert.Equal(t, "", entries[1].ImageDescription)
+	assert.Equal(t, "description 2", entries[2].ImageDescription)
+	require.Len(t, benchmarkData.ImageSummaries, 3)
+	assert.Equal(t, []string{"a", "c", "d"}, []string{
+		benchmarkData.ImageSummaries[0].EntryID,
+		benchmarkData.ImageSummaries[1].EntryID,
+		benchmarkData.ImageSummaries[2].EntryID,
+	})
+}
+
+func TestProcessImages_ConcurrencyLimit(t *testing.T) {
+	var inFlight, peak int32
+	client := &mockOpenAIClient{
+		ChatCompletionFunc: func(systemPrompt string, userPrompts []string, imageURLs []string, schemaParams *openai.SchemaParameters, temperature float64, maxTokens int) openai.Result {
+			current := atomic.AddInt32(&inFlight, 1)
+			for {
+				old := atomic.LoadInt32(&peak)
+				if current <= old || atomic.CompareAndSwapInt32(&peak, old, current) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			atomic.AddInt32(&inFlight, -1)
+			return openai.Result{Content: "description"}
+		},
+	}
+
+	config := DefaultEntryProcessConfig
+	config.ImageEnabled = true
+	config.ImageBatchSize = 1
+	config.ImageConcurrency = 2
+	processor := NewProcessor(client, client, config, &mockArticleExtractor{}, &mockFetcher{}, &mockURLExtractor{}, &mockImageFetcher{})
+
+	imageURL, _ := url.Parse("https://i.redd.it/image.png")
+	entries := make([]feeds.Entry, 6)
+	for i := range entries {
+		entries[i].ImageURLs = []url.URL{*imageURL}
+	}
+
+	var benchmarkData models.RunData
+	processor.processImages(entries, persona.Persona{PersonaIdentity: "a tester"}, &benchmarkData)
+
+	assert.Len(t, benchmarkData.ImageSummaries, 6)
+	assert.LessOrEqual(t, atomic.LoadInt32(&peak), int32(2))
+}
+
+// countingImageFetcher counts the images it fetches
+type countingImageFetcher struct {
+	fetched atomic.Int32
+}
+
+func (f *countingImageFetcher) FetchAsBase64(url string) (string, error) {
+	f.fetched.Add(1)
+	return "data:image/png;base64,", nil
+}
+
+func TestProcessImages_SharedAcrossPersonas(t *testing.T) {
+	var calls atomic.Int32
+	client := &mockOpenAIClient{
+		ChatCompletionFunc: func(systemPrompt string, userPrompts []string, imageURLs []string, schemaParams *openai.SchemaParameters, temperature float64, maxTokens int) openai.Result {
+			calls.Add(1)
+			return openai.Result{Content: "a chart"}
+		},
+	}
+	config := DefaultEntryProcessConfig
+	config.ImageEnabled = true
+	cache := runcache.New()
+	images := &countingImageFetcher{}
+
+	imageURL, _ := url.Parse("https://i.redd.it/chart.png")
+	for _, identity := range []string{"a tester", "a tester", "an editor"} {
+		processor := NewProcessor(client, client, config, &mockArticleExtractor{}, &mockFetcher{}, &mockURLExtractor{}, images)
+		processor.SetRunCache(cache)
+		entries := []feeds.Entry{{ID: "a", Title: "Chart", ImageURLs: []url.URL{*imageURL}}}
+
+		var benchmarkData models.RunData
+		processor.processImages(entries, persona.Persona{PersonaIdentity: identity}, &benchmarkData)
+		assert.Equal(t, "a chart", entries[0].ImageDescription)
+	}
+
+	assert.Equal(t, int32(2), calls.Load(), "personas with the same prompt share the description")
+	assert.Equal(t, int32(1), images.fetched.Load(), "the image is fetched once")
+}
+
+type dataURIImageFetcher struct{}
+
+func (f *dataURIImageFetcher) FetchAsBase64(url string) (string, error) {
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString([]byte("image:"+url)), nil
+}
+
+type fakeRecognizer struct {
+	text string
+}
+
+func (r *fakeRecognizer) Recognize(ctx context.Context, image []byte) (string, error) {
+	return r.text + " (" + string(image) + ")", nil
+}
+
+func TestProcessImages_AppendsOCRText(t *testing.T) {
+	client := &mockOpenAIClient{
+		ChatCompletionFunc: func(systemPrompt string, userPrompts []string, imageURLs []string, schemaParams *openai.SchemaParameters, temperature float64, maxTokens int) openai.Result {
+			return openai.Result{Content: "A benchmark table."}
+		},
+	}
+
+	tests := []struct {
+		name     string
+		ocrText  string
+		expected string
+	}{
+		{
+			name:     "text-heavy image",
+			ocrText:  "Model MMLU GSM8K\nLlama 70.1 56.3\nMistral 64.2 52.1",
+			expected: "A benchmark table.\n\nText extracted from the image (OCR):\nModel MMLU GSM8K\nLlama 70.1 56.3\nMistral 64.2 52.1 (image:https://i.redd.it/table.png)",
+		},
+		{
+			name:     "little text",
+			ocrText:  "lol",
+			expected: "A benchmark table.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultEntryProcessConfig
+			config.ImageEnabled = true
+			processor := NewProcessor(client, client, config, &mockArticleExtractor{}, &mockFetcher{}, &mockURLExtractor{}, &dataURIImageFetcher{})
+			processor.SetOCR(&fakeRecognizer{text: tt.ocrText})
+
+			imageURL, _ := url.Parse("https://i.redd.it/table.png")
+			entries := []feeds.Entry{{ID: "a", Title: "A", ImageURLs: []url.URL{*imageURL}}}
+
+			var benchmarkData models.RunData
+			processor.processImages(entries, persona.Persona{PersonaIdentity: "a tester"}, &benchmarkData)
+
+			assert.Equal(t, tt.expected, entries[0].ImageDescription)
+			require.Len(t, benchmarkData.ImageSummaries, 1)
+			assert.Equal(t, tt.expected, benchmarkData.ImageSummaries[0].ImageDescription)
+		})
+	}
+}
@@ -1,25 +1,42 @@
 package llm
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"maps"
+	"math/rand/v2"
 	"net/http"
 	"net/url"
-	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/bakkerme/ai-news-processor/internal/archive"
+	"github.com/bakkerme/ai-news-processor/internal/budget"
+	"github.com/bakkerme/ai-news-processor/internal/checkpoint"
 	"github.com/bakkerme/ai-news-processor/internal/contentextractor"
-	"github.com/bakkerme/ai-news-processor/internal/customerrors"
+	"github.com/bakkerme/ai-news-processor/internal/domainfilter"
+	"github.com/bakkerme/ai-news-processor/internal/failedurls"
 	"github.com/bakkerme/ai-news-processor/internal/feeds"
 	"github.com/bakkerme/ai-news-processor/internal/fetcher"
 	httputil "github.com/bakkerme/ai-news-processor/internal/http"
-	"github.com/bakkerme/ai-news-processor/internal/http/retry"
+	"github.com/bakkerme/ai-news-processor/internal/huggingface"
+	"github.com/bakkerme/ai-news-processor/internal/megathread"
+	"github.com/bakkerme/ai-news-processor/internal/ocr"
 	"github.com/bakkerme/ai-news-processor/internal/openai"
 	"github.com/bakkerme/ai-news-processor/internal/persona"
 	"github.com/bakkerme/ai-news-processor/internal/prompts"
+	"github.com/bakkerme/ai-news-processor/internal/quarantine"
+	"github.com/bakkerme/ai-news-processor/internal/runcache"
+	"github.com/bakkerme/ai-news-processor/internal/sitemeta"
+	"github.com/bakkerme/ai-news-processor/internal/tokens"
+	"github.com/bakkerme/ai-news-processor/internal/twitter"
 	"github.com/bakkerme/ai-news-processor/internal/urlextraction"
+	"github.com/bakkerme/ai-news-processor/internal/urlnorm"
 	"github.com/bakkerme/ai-news-processor/models"
 )
 
@@ -27,6 +44,26 @@ import (
 
 // NewProcessor creates a new LLM processor with the given clients and configuration
 func NewProcessor(client openai.OpenAIClient, imageClient openai.OpenAIClient, config EntryProcessConfig, articleExtractor contentextractor.ArticleExtractor, urlFetcher fetcher.Fetcher, urlExtractor urlextraction.Extractor, imageFetcher httputil.ImageFetcher) *Processor {
+	var hfClient *huggingface.Client
+	if config.HuggingFaceEnabled {
+		hfClient = huggingface.NewClient(urlFetcher, "")
+	}
+
+	var twitterClient *twitter.Client
+	if config.TwitterEnabled {
+		twitterClient = twitter.NewClient(urlFetcher, "", "")
+	}
+
+	var urlResolver *urlnorm.Resolver
+	if config.ResolveShortLinks {
+		urlResolver = urlnorm.NewResolver(nil)
+	}
+
+	var archiveClient *archive.Client
+	if config.ArchiveEnabled {
+		archiveClient = archive.NewClient(urlFetcher, "")
+	}
+
 	return &Processor{
 		client:               client,
 		imageClient:          imageClient,
@@ -38,6 +75,15 @@ func NewProcessor(client openai.OpenAIClient, imageClient openai.OpenAIClient, c
 		debugOutputBenchmark: config.DebugOutputBenchmark,
 		imageFetcher:         imageFetcher,
 		articleExtractor:     articleExtractor,
+		hfClient:             hfClient,
+		twitterClient:        twitterClient,
+		urlResolver:          urlResolver,
+		archiveClient:        archiveClient,
+		domainFilter:         domainfilter.New(config.DenyDomains, config.AllowDomains),
+		imageSem:             make(chan struct{}, max(config.ImageConcurrency, 1)),
+		imageBreaker:         newBreaker("image descriptions", config.DegradeAfterFailures),
+		linkBreaker:          newBreaker("external URLs", config.DegradeAfterFailures),
+		deadlines:            newEntryDeadlines(config.EntryTimeout),
 	}
 }
 
@@ -45,6 +91,15 @@ func NewProcessor(client openai.OpenAIClient, imageClient openai.OpenAIClient, c
 func (p *Processor) ProcessEntries(systemPrompt string, entries []feeds.Entry, persona persona.Persona) ([]models.Item, models.RunData, error) {
 	var items []models.Item
 	var processingErrors []error
+	processed := 0
+	p.deferred, p.deferReason = nil, nil
+	p.stats = RunStats{}
+	p.violations = nil
+	p.deadlines = newEntryDeadlines(p.config.EntryTimeout)
+	p.retries.Store(0)
+	p.imageBreaker.Reset()
+	p.linkBreaker.Reset()
+	failed := 0
 
 	benchmarkData := models.RunData{
 		EntrySummaries:                []models.EntrySummary{},
@@ -60,53 +115,52 @@ func (p *Processor) ProcessEntries(systemPrompt string, entries []feeds.Entry, p
 		ImageTotalProcessingTime:      0,
 		WebContentTotalProcessingTime: 0,
 		SuccessRate:                   0,
+		SystemPrompts:                 map[string]string{},
 	}
+	systemPromptHash := prompts.Hash(systemPrompt)
+	benchmarkData.SystemPrompts[systemPromptHash] = systemPrompt
 
 	// Track total processing time if benchmarking is enabled
 	startTime := time.Now()
 
+	// Entries that failed too many runs in a row are not retried
+	var quarantined []QuarantinedEntry
+	if p.quarantine != nil {
+		remaining := make([]feeds.Entry, 0, len(entries))
+		for _, entry := range entries {
+			if record, ok := p.quarantine.Quarantined(entry.ID); ok {
+				log.Printf("Skipping entry %s, which failed processing in %d runs in a row: %s\n", entry.ID, record.Failures, record.LastError)
+				quarantined = append(quarantined, QuarantinedEntry{Entry: entry, Record: record})
+				continue
+			}
+			remaining = append(remaining, entry)
+		}
+		entries = remaining
+	}
+
+	// Entries an interrupted run already processed are taken from its checkpoint
+	var resumed []models.Item
+	if p.checkpoint.Len() > 0 {
+		remaining := make([]feeds.Entry, 0, len(entries))
+		for _, entry := range entries {
+			if item, ok := p.checkpoint.Completed(entry.ID); ok {
+				resumed = append(resumed, item)
+				continue
+			}
+			remaining = append(remaining, entry)
+		}
+		log.Printf("Resuming from checkpoint: %d of %d entries were already processed\n", len(resumed), len(entries))
+		entries = remaining
+		items = append(items, resumed...)
+		processed += len(resumed)
+	}
+
 	// PHASE 1: Process all images first if image processing is enabled. This needs to be done first because the image processing uses a seperate model that takes time to load.
 	if p.imageEnabled {
 		log.Println("Phase 1: Processing all images")
 
 		imageStartTime := time.Now()
-		for i := range entries {
-			if len(entries[i].ImageURLs) > 0 {
-				// Create the image prompt
-				imagePrompt, err := prompts.ComposeImagePrompt(persona, entries[i].Title)
-				if err != nil {
-					log.Printf("Error creating image prompt for entry %d: %v\n", i, err)
-					continue
-				}
-
-				log.Printf("Processing image for entry %d: %s\n", i, entries[i].ImageURLs[0].String())
-
-				// Track image processing time if benchmarking is enabled
-				imgStartTime := time.Now()
-
-				imageDescription, err := p.processImageWithRetry(entries[i], imagePrompt)
-
-				// Calculate processing time for benchmarking
-				imgProcessingTime := time.Since(imgStartTime).Milliseconds()
-
-				if err != nil {
-					log.Printf("Error processing image for entry %d: %v\n", i, err)
-				} else {
-					entries[i].ImageDescription = imageDescription
-					log.Printf("Image processing successful for entry %d\n", i)
-
-					// Add to benchmark data
-					imgSummary := models.ImageSummary{
-						ImageURL:         entries[i].ImageURLs[0].String(),
-						ImageDescription: imageDescription,
-						Title:            entries[i].Title,
-						EntryID:          entries[i].ID,
-						ProcessingTime:   imgProcessingTime,
-					}
-					benchmarkData.ImageSummaries = append(benchmarkData.ImageSummaries, imgSummary)
-				}
-			}
-		}
+		p.processImages(entries, persona, &benchmarkData)
 
 		benchmarkData.ImageTotalProcessingTime = time.Since(imageStartTime).Milliseconds()
 	}
@@ -117,8 +171,37 @@ func (p *Processor) ProcessEntries(systemPrompt string, entries []feeds.Entry, p
 
 		webStartTime := time.Now()
 		for i := range entries {
+			if err := p.budget.Exceeded(); err != nil {
+				log.Printf("Skipping external URLs from entry %d: %v\n", i, err)
+				break
+			}
+			// Fetching keeps failing, so the remaining entries are summarized without their links
+			if p.linkBreaker.Open() {
+				log.Printf("Skipping external URLs from entry %d: URL fetching keeps failing\n", i)
+				break
+			}
+			// An entry that ran out of time describing its image is reported as failed in Phase 3
+			if p.deadlines.err(entries[i].ID) != nil {
+				continue
+			}
 			log.Printf("Processing external URLs for entry %d\n", i)
-			summaries, err := p.processExternalURLs(&entries[i], persona, &benchmarkData)
+
+			// The URLs are processed on a copy of the entry, as processing carries on in the
+			// background for an entry that runs out of time
+			entry := entries[i]
+			entry.WebContentSummaries = maps.Clone(entry.WebContentSummaries)
+			entry.WebContentSources = maps.Clone(entry.WebContentSources)
+			var urlData models.RunData
+			var summaries map[string]string
+			var err error
+			if !p.deadlines.run([]string{entry.ID}, "fetching its links", func() {
+				summaries, err = p.processExternalURLs(&entry, persona, &urlData)
+			}) {
+				log.Printf("Giving up on the external URLs of entry %d after %s\n", i, p.config.EntryTimeout)
+				continue
+			}
+			entries[i] = entry
+			benchmarkData.WebContentSummaries = append(benchmarkData.WebContentSummaries, urlData.WebContentSummaries...)
 			if err != nil {
 				log.Printf("Error processing external URLs for entry %d: %v\n", i, err)
 				processingErrors = append(processingErrors, fmt.Errorf("entry %d: %w", i, err))
@@ -136,23 +219,62 @@ func (p *Processor) ProcessEntries(systemPrompt string, entries []feeds.Entry, p
 	log.Println("Phase 3: Processing all text summarizations")
 	overallStartTime := time.Now()
 	for i, entry := range entries {
+		// Entries the budget has no room for are left for the next run
+		if err := p.budget.Exceeded(); err != nil {
+			log.Printf("Deferring %d entries to the next run: %v\n", len(entries)-i, err)
+			p.deferred, p.deferReason = entries[i:], err
+			break
+		}
 		log.Printf("Processing entry text %d\n", i)
 
 		entryStartTime := time.Now()
 
-		// Process the main entry text (including external URL summaries if available)
-		item, err := p.processEntryWithRetry(systemPrompt, entry)
+		// Megathreads are summarized by the themes of their discussion
+		prompt, promptHash := systemPrompt, systemPromptHash
+		megathreadReason := p.megathreads.Reason(entry)
+		if megathreadReason != "" {
+			log.Printf("Entry %d is a megathread (%s), summarizing its discussion\n", i, megathreadReason)
+			prompt, promptHash = p.megathreadPrompt, prompts.Hash(p.megathreadPrompt)
+			benchmarkData.SystemPrompts[promptHash] = prompt
+		}
+
+		// Process the main entry text (including external URL summaries if available). An entry
+		// that runs out of time is failed and left to finish in the background.
+		item, usage, err := models.Item{}, openai.Usage{}, p.deadlines.err(entry.ID)
+		if err == nil {
+			var result struct {
+				item  models.Item
+				usage openai.Usage
+				err   error
+			}
+			if p.deadlines.run([]string{entry.ID}, "summarizing it", func() {
+				result.item, result.usage, result.err = p.processEntryWithRetry(prompt, entry, persona)
+			}) {
+				item, usage, err = result.item, result.usage, result.err
+			} else {
+				err = p.deadlines.err(entry.ID)
+			}
+		}
 
 		if err != nil {
 			log.Printf("Error processing entry %d: %v\n", i, err)
 			processingErrors = append(processingErrors, fmt.Errorf("entry %d: %w", i, err))
+			failed++
+			p.quarantine.RecordFailure(entry.ID, entry.Title, err.Error())
+			if p.config.FailurePlaceholders {
+				items = append(items, PlaceholderItem(entry))
+			}
 			continue
 		}
+		processed++
+		p.quarantine.RecordSuccess(entry.ID)
 
 		item.Title = entry.Title
+		item.Megathread = megathreadReason != ""
 
 		item.Entry = entry // Associate the processed item with the original entry
-		item.Link = entry.Link.Href
+		item.Link = urlnorm.CleanString(entry.Link.Href)
+		item.CanonicalID = entry.CanonicalID
 
 		if len(entry.ImageURLs) > 0 {
 			item.ThumbnailURL = entry.ImageURLs[0].String()
@@ -164,19 +286,30 @@ func (p *Processor) ProcessEntries(systemPrompt string, entries []feeds.Entry, p
 
 		log.Printf("Processed item %d successfully\n", i)
 		items = append(items, item)
+		if err := p.checkpoint.Record(entry.ID, item); err != nil {
+			log.Printf("Warning: could not checkpoint entry %s: %v\n", entry.ID, err)
+		}
 
 		// Add to benchmark data
 		entrySummary := models.EntrySummary{
-			RawInput:       entry.String(true),
-			Results:        item,
-			ProcessingTime: entryProcessingTime,
+			RawInput:         entry.String(true),
+			Results:          item,
+			ProcessingTime:   entryProcessingTime,
+			PromptTokens:     usage.PromptTokens,
+			CompletionTokens: usage.CompletionTokens,
+			SystemPromptHash: promptHash,
 		}
 		benchmarkData.EntrySummaries = append(benchmarkData.EntrySummaries, entrySummary)
 	}
 	benchmarkData.EntryTotalProcessingTime = time.Since(overallStartTime).Milliseconds()
-
-	// If all entries failed, return an error
-	if len(items) == 0 && len(processingErrors) > 0 {
+	p.violationsMu.Lock()
+	benchmarkData.Violations = p.violations
+	p.violationsMu.Unlock()
+	benchmarkData.Degraded = p.degraded()
+	p.stats = RunStats{FailedEntries: failed, Retries: int(p.retries.Load()), Errors: processingErrors, Degraded: benchmarkData.Degraded, Quarantined: quarantined}
+
+	// If all entries failed, return an error. A digest of nothing but placeholders is not worth sending.
+	if processed == 0 && len(processingErrors) > 0 {
 		return nil, benchmarkData, fmt.Errorf("all entries failed processing: %v", processingErrors[0])
 	}
 
@@ -188,14 +321,84 @@ func (p *Processor) ProcessEntries(systemPrompt string, entries []feeds.Entry, p
 	// Finalize benchmark data
 	benchmarkData.TotalProcessingTime = time.Since(startTime).Milliseconds()
 
-	if len(entries) > 0 {
-		successCount := len(items)
-		benchmarkData.SuccessRate = float64(successCount) / float64(len(entries))
+	if attempted := len(resumed) + len(entries) - len(p.deferred); attempted > 0 {
+		benchmarkData.SuccessRate = float64(processed) / float64(attempted)
 	}
 
 	return items, benchmarkData, nil
 }
 
+// SetBudget sets the budget that limits the LLM calls of ProcessEntries. A nil budget is unlimited.
+func (p *Processor) SetBudget(b *budget.Budget) {
+	p.budget = b
+}
+
+// SetMegathreads sets the detector of megathreads and the system prompt they are summarized with
+// instead of the persona's. A nil detector summarizes megathreads like any other entry.
+func (p *Processor) SetMegathreads(detector *megathread.Detector, systemPrompt string) {
+	p.megathreads = detector
+	p.megathreadPrompt = systemPrompt
+}
+
+// SetCheckpoint sets the checkpoint ProcessEntries records processed entries in, and takes the
+// entries an interrupted run already processed from. A nil checkpoint disables checkpointing.
+func (p *Processor) SetCheckpoint(c *checkpoint.Checkpoint) {
+	p.checkpoint = c
+}
+
+// Stats returns the failed entries, retries, errors and degraded enrichments of the last ProcessEntries call
+func (p *Processor) Stats() RunStats {
+	return p.stats
+}
+
+// degraded returns the enrichments the last ProcessEntries call skipped after repeated failures
+func (p *Processor) degraded() []string {
+	var degraded []string
+	if p.imageBreaker.Open() {
+		degraded = append(degraded, DegradedImages)
+	}
+	if p.linkBreaker.Open() {
+		degraded = append(degraded, DegradedLinks)
+	}
+	return degraded
+}
+
+// Deferred returns the entries the last ProcessEntries call left unprocessed because the budget
+// was exceeded, and the limit that was reached
+func (p *Processor) Deferred() ([]feeds.Entry, error) {
+	return p.deferred, p.deferReason
+}
+
+// SetFailedURLStore sets the store used to skip URLs that consistently fail fetching or extraction.
+// A nil store disables skipping.
+func (p *Processor) SetFailedURLStore(store *failedurls.Store) {
+	p.failedURLs = store
+}
+
+// SetQuarantine sets the store used to skip entries that failed processing too many runs in a
+// row, and to record the entries that fail or succeed
+func (p *Processor) SetQuarantine(store *quarantine.Store) {
+	p.quarantine = store
+}
+
+// SetOCR sets the recognizer used to extract text from text-heavy images.
+// A nil recognizer disables OCR.
+func (p *Processor) SetOCR(recognizer ocr.Recognizer) {
+	p.ocr = recognizer
+}
+
+// SetSiteMetadataCache sets the cache used to attach site names and favicons to summarized URLs.
+// A nil cache disables source metadata enrichment.
+func (p *Processor) SetSiteMetadataCache(cache *sitemeta.Cache) {
+	p.siteMeta = cache
+}
+
+// SetRunCache sets the cache that shares fetched pages and images, image descriptions and page
+// summaries with the other personas of the run. A nil cache disables sharing.
+func (p *Processor) SetRunCache(cache *runcache.Cache) {
+	p.runCache = cache
+}
+
 // processExternalURLs extracts and processes external URLs from an entry
 func (p *Processor) processExternalURLs(entry *feeds.Entry, persona persona.Persona, benchmarkData *models.RunData) (map[string]string, error) {
 	// 1. Extract external URLs
@@ -204,6 +407,13 @@ func (p *Processor) processExternalURLs(entry *feeds.Entry, persona persona.Pers
 		return nil, fmt.Errorf("failed to extract external URLs: %w", err)
 	}
 
+	// Clean and resolve the URLs, so a page is fetched, cached and cited under one URL whatever
+	// tracking parameters or short link it was shared with
+	for i := range extractedURLs {
+		extractedURLs[i] = *p.urlResolver.Resolve(context.Background(), &extractedURLs[i])
+	}
+	extractedURLs = urlnorm.CleanAll(extractedURLs)
+
 	// Store all extracted URLs in the ExternalURLs field
 	entry.ExternalURLs = extractedURLs
 
@@ -216,77 +426,284 @@ func (p *Processor) processExternalURLs(entry *feeds.Entry, persona persona.Pers
 		return nil, nil
 	}
 
+	// Drop URLs on denied domains so the first fetchable URL is the one summarized. Tweets are read
+	// from the embed endpoints rather than the page, so they are kept even though X is denied.
+	domainFilter := p.domainFilter.With(persona.FetchDenyDomains, persona.FetchAllowDomains)
+	fetchableURLs := make([]url.URL, 0, len(extractedURLs))
+	for _, u := range extractedURLs {
+		if !domainFilter.Allowed(&u) && !p.isUnrollableTweet(&u) {
+			log.Printf("skipping URL on denied domain: %s\n", u.String())
+			continue
+		}
+		fetchableURLs = append(fetchableURLs, u)
+	}
+	if len(fetchableURLs) == 0 {
+		return nil, nil
+	}
+
 	// Only process the first URL for now
-	extractedURLs = []url.URL{extractedURLs[0]}
+	extractedURLs = []url.URL{fetchableURLs[0]}
 	summaries := make(map[string]string)
 
 	// 2. Process each extracted URL
 	for _, extractedURLStr := range extractedURLs {
 		log.Printf("processing external URL: %s\n", extractedURLStr.String())
 
+		// Skip URLs that have consistently failed on previous runs
+		if p.failedURLs.ShouldSkip(extractedURLStr.String()) {
+			log.Printf("skipping previously failing URL: %s\n", extractedURLStr.String())
+			continue
+		}
+
 		// Start timing for benchmarking
 		webStartTime := time.Now()
 
-		// 2a. Fetch the content
-		resp, err := p.urlFetcher.Fetch(context.Background(), &extractedURLStr)
-		if err != nil {
-			log.Printf("warning: Failed to fetch content for %s: %v\n", extractedURLStr.String(), err)
-			continue // Skip to the next URL if fetching fails
+		// storeSummary records a summary for the current URL along with its source metadata and benchmark data
+		storeSummary := func(summary string, title string, originalContent string, source sitemeta.Metadata) {
+			summaries[extractedURLStr.String()] = summary
+			p.recordSource(entry, &extractedURLStr, source)
+
+			// Add to benchmark data if benchmarking is enabled
+			if benchmarkData != nil {
+				webSummary := models.WebContentSummary{
+					URL:             extractedURLStr.String(),
+					OriginalContent: originalContent,
+					Summary:         summary,
+					Title:           title,
+					EntryID:         entry.ID,
+					ProcessingTime:  time.Since(webStartTime).Milliseconds(),
+				}
+				benchmarkData.WebContentSummaries = append(benchmarkData.WebContentSummaries, webSummary)
+			}
 		}
-		defer resp.Body.Close()
 
-		if resp.StatusCode != http.StatusOK {
-			log.Printf("warning: Received non-OK status code for %s: %d\n", extractedURLStr.String(), resp.StatusCode)
-			continue // Skip to the next URL for non-OK status codes
+		// Hugging Face repos are summarized from the Hub API rather than the heavy HTML page
+		if summary, title, ok := p.summarizeHuggingFaceRepo(&extractedURLStr); ok {
+			storeSummary(summary, title, summary, sitemeta.Metadata{SiteName: "Hugging Face"})
+			continue
 		}
 
-		// 2b. Extract the article text
-		articleData, err := p.articleExtractor.Extract(resp.Body, &extractedURLStr)
+		// Tweets and threads are read from the embed endpoints, as X pages have no text without JavaScript
+		if thread, ok, err := p.unrollTweet(&extractedURLStr); ok {
+			if err != nil {
+				log.Printf("warning: %v\n", err)
+				p.failedURLs.RecordFailure(extractedURLStr.String(), err.Error())
+				continue
+			}
+			p.failedURLs.RecordSuccess(extractedURLStr.String())
+
+			summary, err := p.summarizeWebSite(thread.Title(), &extractedURLStr, thread.String())
+			if err != nil {
+				log.Printf("warning: Failed to summarize content for %s: %v\n", extractedURLStr.String(), err)
+				continue
+			}
+			storeSummary(summary, thread.Title(), thread.String(), sitemeta.Metadata{SiteName: "X"})
+			continue
+		}
+
+		// 2a. Fetch the content, once per run for all personas that link it
+		pageBody, err := runcache.Do(p.runCache, "page:"+extractedURLStr.String(), func() ([]byte, error) {
+			return p.fetchPage(&extractedURLStr)
+		})
 		if err != nil {
-			log.Printf("warning: Failed to extract article content for %s: %v\n", extractedURLStr.String(), err)
-			continue // Skip to the next URL if extraction fails
+			log.Printf("warning: %v\n", err)
+			p.linkBreaker.Failure()
+			continue // Skip to the next URL if fetching fails
 		}
+		p.linkBreaker.Success()
+
+		// 2b. Extract the article text
+		articleData, err := p.articleExtractor.Extract(bytes.NewReader(pageBody), &extractedURLStr)
+		if err != nil || contentextractor.WordCount(articleData.CleanedText) < contentextractor.MinArticleWords {
+			// Fall back to Open Graph / Twitter Card metadata so the entry still gets minimal web context
+			if metadata, ok := extractPageMetadata(pageBody, &extractedURLStr); ok {
+				log.Printf("using page metadata fallback for %s\n", extractedURLStr.String())
+				p.failedURLs.RecordSuccess(extractedURLStr.String())
+				storeSummary(metadata.String(), metadata.Title, metadata.String(), sitemeta.Metadata{SiteName: metadata.SiteName})
+				continue
+			}
+
+			if err != nil {
+				log.Printf("warning: Failed to extract article content for %s: %v\n", extractedURLStr.String(), err)
+				p.failedURLs.RecordFailure(extractedURLStr.String(), err.Error())
+				continue // Skip to the next URL if extraction fails
+			}
+		}
+		p.failedURLs.RecordSuccess(extractedURLStr.String())
 
 		// 2c. Summarize the extracted content with LLM
-		summary, err := p.summarizeWebSite(articleData.Title, &extractedURLStr, articleData.CleanedText, persona)
+		summary, err := p.summarizeWebSite(articleData.Title, &extractedURLStr, articleData.CleanedText)
 		if err != nil {
 			log.Printf("warning: Failed to summarize content for %s: %v\n", extractedURLStr.String(), err)
 			continue // Skip to the next URL if summarization fails
 		}
 
-		// Calculate processing time for benchmarking
-		webProcessingTime := time.Since(webStartTime).Milliseconds()
-
 		// 2d. Store the summary
-		summaries[extractedURLStr.String()] = summary
-
-		// Add to benchmark data if benchmarking is enabled
-		if benchmarkData != nil {
-			webSummary := models.WebContentSummary{
-				URL:             extractedURLStr.String(),
-				OriginalContent: articleData.CleanedText,
-				Summary:         summary,
-				Title:           articleData.Title,
-				EntryID:         entry.ID,
-				ProcessingTime:  webProcessingTime,
-			}
-			benchmarkData.WebContentSummaries = append(benchmarkData.WebContentSummaries, webSummary)
-		}
+		storeSummary(summary, articleData.Title, articleData.CleanedText, sitemeta.Metadata{
+			SiteName:   articleData.SiteName,
+			FaviconURL: articleData.Favicon,
+		})
 	}
 
 	return summaries, nil
 }
 
-// summarizeTextWithLLM summarizes given content using an LLM
-func (p *Processor) summarizeWebSite(pageTitle string, url *url.URL, content string, persona persona.Persona) (string, error) {
+// fetchPage fetches a page and returns its body, or that of an archived copy if the page is
+// behind a paywall or consent wall
+func (p *Processor) fetchPage(u *url.URL) ([]byte, error) {
+	resp, err := p.urlFetcher.Fetch(context.Background(), u)
+	if err != nil {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		if fetcher.IsPermanentError(err) {
+			p.failedURLs.RecordFailure(u.String(), err.Error())
+		}
+		return nil, fmt.Errorf("failed to fetch content for %s: %w", u.String(), err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("received non-OK status code for %s: %d", u.String(), resp.StatusCode)
+	}
+
+	// Buffer the page so it can be parsed for both the article and its metadata
+	pageBody, err := io.ReadAll(io.LimitReader(resp.Body, maxPageBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read content for %s: %w", u.String(), err)
+	}
+
+	// Paywalls and consent walls hide the article, so try an archived copy instead
+	if wall := contentextractor.DetectWall(finalURL(resp, u), pageBody); wall != contentextractor.WallNone {
+		if archived, ok := p.fetchArchivedPage(u, wall); ok {
+			pageBody = archived
+		}
+	}
+	return pageBody, nil
+}
+
+// fetchArchivedPage retrieves an archived snapshot of a page that was blocked by a paywall or consent wall
+func (p *Processor) fetchArchivedPage(u *url.URL, wall contentextractor.WallType) ([]byte, bool) {
+	if p.archiveClient == nil {
+		return nil, false
+	}
+
+	log.Printf("detected %s for %s, trying archived snapshot\n", wall, u.String())
+	body, err := p.archiveClient.FetchSnapshot(context.Background(), u)
+	if err != nil {
+		log.Printf("warning: Failed to fetch archived snapshot for %s: %v\n", u.String(), err)
+		return nil, false
+	}
+	if len(body) == 0 {
+		return nil, false
+	}
+	return body, true
+}
+
+// finalURL returns the URL a response was served from after redirects, falling back to the requested URL
+func finalURL(resp *http.Response, requested *url.URL) *url.URL {
+	if resp.Request != nil && resp.Request.URL != nil {
+		return resp.Request.URL
+	}
+	return requested
+}
+
+// extractPageMetadata parses Open Graph, Twitter Card and JSON-LD metadata from a fetched page.
+// It returns false if the page declares neither a title nor a description.
+func extractPageMetadata(pageBody []byte, u *url.URL) (*contentextractor.PageMetadata, bool) {
+	metadata, err := contentextractor.ExtractMetadata(bytes.NewReader(pageBody), u)
+	if err != nil {
+		log.Printf("warning: Failed to extract page metadata for %s: %v\n", u.String(), err)
+		return nil, false
+	}
+	if metadata.IsEmpty() {
+		return nil, false
+	}
+	return metadata, true
+}
+
+// recordSource attaches the site name and favicon for a summarized URL to the entry
+func (p *Processor) recordSource(entry *feeds.Entry, u *url.URL, hint sitemeta.Metadata) {
+	if p.siteMeta == nil {
+		return
+	}
+
+	if entry.WebContentSources == nil {
+		entry.WebContentSources = make(map[string]sitemeta.Metadata)
+	}
+	entry.WebContentSources[u.String()] = p.siteMeta.Lookup(context.Background(), u, hint)
+}
+
+// summarizeHuggingFaceRepo builds a structured summary for huggingface.co model and dataset URLs.
+// It returns false if the URL is not a Hub repository or the Hub API could not be reached,
+// in which case the caller should fall back to the regular fetch and extract path.
+func (p *Processor) summarizeHuggingFaceRepo(u *url.URL) (string, string, bool) {
+	if p.hfClient == nil {
+		return "", "", false
+	}
+
+	repo, ok := huggingface.ParseRepoURL(u)
+	if !ok {
+		return "", "", false
+	}
+
+	info, err := p.hfClient.FetchRepoInfo(context.Background(), repo)
+	if err != nil {
+		log.Printf("warning: Failed to fetch Hugging Face metadata for %s, falling back to page extraction: %v\n", u.String(), err)
+		return "", "", false
+	}
+
+	return info.String(), repo.ID, true
+}
+
+// isUnrollableTweet reports whether u links a tweet that unrollTweet can read
+func (p *Processor) isUnrollableTweet(u *url.URL) bool {
+	if p.twitterClient == nil {
+		return false
+	}
+	_, ok := twitter.ParseStatusURL(u)
+	return ok
+}
+
+// unrollTweet reads the tweet or thread u links to. It returns false if u is not a tweet or
+// unrolling is disabled. The thread is read once per run for all personas that link it.
+func (p *Processor) unrollTweet(u *url.URL) (*twitter.Thread, bool, error) {
+	if p.twitterClient == nil {
+		return nil, false, nil
+	}
+
+	id, ok := twitter.ParseStatusURL(u)
+	if !ok {
+		return nil, false, nil
+	}
+
+	thread, err := runcache.Do(p.runCache, "tweet:"+id, func() (*twitter.Thread, error) {
+		return p.twitterClient.FetchThread(context.Background(), id)
+	})
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to read tweet %s: %w", u.String(), err)
+	}
+	return thread, true, nil
+}
+
+// summarizeWebSite summarizes a page with the LLM. The summary does not depend on the persona, so
+// personas that link the same page share it.
+func (p *Processor) summarizeWebSite(pageTitle string, url *url.URL, content string) (string, error) {
 	// Create a system prompt for summarization
-	systemPrompt := fmt.Sprintf("You are a concise summarizer for %s. Provide brief, informative summaries of web content. Keep summaries to 300-500 words and focus on key technical insights.", persona.Name)
+	systemPrompt := "You are a concise summarizer for a news digest. Provide brief, informative summaries of web content. Keep summaries to 300-500 words and focus on key technical insights."
 
 	// Use simple prompt for initial implementation
-	userPrompt := fmt.Sprintf("Please provide a concise summary of the following article content (aim for 300-500 words):\n\n%s\n\nTitle: %s\n\nURL: %s", content, pageTitle, url)
-
-	// disable qwen thinking
-	// userPrompt += "\n/no_thinking"
+	userPromptTemplate := "Please provide a concise summary of the following article content (aim for 300-500 words):\n\n%s\n\nTitle: %s\n\nURL: %s"
+
+	// Keep the page within the context window next to the prompt and the summary
+	if p.config.ContextTokens > 0 {
+		budget := p.config.ContextTokens - tokens.Estimate(systemPrompt) - tokens.Estimate(fmt.Sprintf(userPromptTemplate, "", pageTitle, url)) - MaxTokensWebSummary
+		if truncated, cut := tokens.Truncate(content, max(budget, 0)); cut {
+			log.Printf("Page %s needs about %d tokens, truncating it to %d\n", url, tokens.Estimate(content), budget)
+			content = truncated
+		}
+	}
+	userPrompt := fmt.Sprintf(userPromptTemplate, content, pageTitle, url)
 
 	// Function to execute the LLM call
 	processFn := func() (string, error) {
@@ -296,208 +713,253 @@ func (p *Processor) summarizeWebSite(pageTitle string, url *url.URL, content str
 			return "", fmt.Errorf("could not process value from LLM: %w", err)
 		}
 
-		// strip the result of any think tags. They should be empty with the /no_thinking flag
-		result = strings.ReplaceAll(result, "<think>", "")
-		result = strings.ReplaceAll(result, "</think>", "")
-
 		return result, nil
 	}
 
 	// Retry the LLM call if it fails
-	return p.retryStringFunc(processFn)
+	return runcache.Do(p.runCache, runcache.Key("web summary", p.client.GetModelName(), systemPrompt, userPrompt), func() (string, error) {
+		return p.retryStringFunc(processFn, "web summary")
+	})
 }
 
-// processEntryWithRetry processes a single entry with retry support
-func (p *Processor) processEntryWithRetry(systemPrompt string, entry feeds.Entry) (models.Item, error) {
-	entryString := entry.String(true)
-
-	// noThink := "/no_thinking"
-	noThink := ""
+// processEntryWithRetry processes a single entry with retry support. It also returns the usage
+// of the entry summary requests, including those of failed attempts.
+func (p *Processor) processEntryWithRetry(systemPrompt string, entry feeds.Entry, persona persona.Persona) (models.Item, openai.Usage, error) {
+	var usage openai.Usage
+	entryString, err := p.entryPrompt(systemPrompt, entry, persona)
+	if err != nil {
+		return models.Item{}, usage, err
+	}
 
 	processFn := func() (models.Item, error) {
 		// Process the entry
-		results := make(chan customerrors.ErrorString, 1)
-		chatCompletionForEntrySummary(p.client, systemPrompt, []string{entryString, noThink}, nil, results)
-		result := <-results
-		close(results)
+		result := chatCompletionForEntrySummary(p.client, systemPrompt, []string{entryString}, nil, p.entrySchema(persona))
+		usage = usage.Add(result.Usage)
 
 		if result.Err != nil {
 			return models.Item{}, fmt.Errorf("could not process value from LLM: %w", result.Err)
 		}
 
-		processedValue := p.client.PreprocessJSON(result.Value)
+		processedValue := p.client.PreprocessJSON(result.Content)
 
 		item, err := llmResponseToItems(processedValue)
 		if err != nil {
+			// The retry must ask the LLM again rather than get the same response from the cache
+			openai.Discard(p.client, result)
 			return models.Item{}, fmt.Errorf("could not convert llm output to json. %s: %w", processedValue, err)
 		}
 
+		normalizeTags(&item)
+		normalizeImportance(&item)
+		normalizeConfidence(&item)
+		normalizeClaims(&item)
+		item.Fields = customFields(persona.Fields, processedValue)
 		item.Entry = entry // Associate the processed item with the original entry
 		return item, nil
 	}
 
-	return p.retryItemFunc(processFn, "entry")
+	item, err := p.retryItemFunc(processFn, "entry")
+	if err != nil {
+		return item, usage, err
+	}
+
+	if wantsDenseSummary(persona, entryString) {
+		// A failed dense pass keeps the single-pass summary rather than failing the entry
+		dense, denseUsage, err := p.densify(item, entryString, persona)
+		usage = usage.Add(denseUsage)
+		if err != nil {
+			log.Printf("warning: keeping the single-pass summary of entry %s: %v\n", entry.ID, err)
+		} else {
+			item = dense
+		}
+	}
+
+	// URLs and IDs that are not in the entry are made up; the correction is requested once
+	item, violations := p.verifyItem(item, entry, func(correction string) (models.Item, error) {
+		result := chatCompletionForEntrySummary(p.client, systemPrompt, []string{entryString, correction}, nil, p.entrySchema(persona))
+		usage = usage.Add(result.Usage)
+		if result.Err != nil {
+			return models.Item{}, result.Err
+		}
+		processedValue := p.client.PreprocessJSON(result.Content)
+		corrected, err := llmResponseToItems(processedValue)
+		if err != nil {
+			openai.Discard(p.client, result)
+			return models.Item{}, err
+		}
+		normalizeTags(&corrected)
+		normalizeImportance(&corrected)
+		normalizeConfidence(&corrected)
+		normalizeClaims(&corrected)
+		corrected.Fields = customFields(persona.Fields, processedValue)
+		corrected.Entry = entry
+		return corrected, nil
+	})
+	p.violationsMu.Lock()
+	p.violations = append(p.violations, violations...)
+	p.violationsMu.Unlock()
+	return item, usage, nil
 }
 
-// processImageWithRetry processes an image with retry support
-func (p *Processor) processImageWithRetry(entry feeds.Entry, imagePrompt string) (string, error) {
-	if len(entry.ImageURLs) == 0 {
-		return "", nil // No image to process
+// entrySchema returns the schema of the entry responses of a persona, which the client requests
+// in its response format
+func (p *Processor) entrySchema(persona persona.Persona) *openai.SchemaParameters {
+	return entryResponseSchema(persona.Fields)
+}
+
+// ProcessRawEntry processes an entry that was already rendered for the LLM, such as the raw input
+// recorded in benchmark data, with a single request. It is used to replay captured entries through
+// different prompts or models.
+func ProcessRawEntry(client openai.OpenAIClient, systemPrompt string, rawInput string) (models.Item, error) {
+	result := chatCompletionForEntrySummary(client, systemPrompt, []string{rawInput}, nil, nil)
+	if result.Err != nil {
+		return models.Item{}, fmt.Errorf("could not process value from LLM: %w", result.Err)
 	}
 
-	imgURL := entry.ImageURLs[0].String()
-	dataURI, err := p.imageFetcher.FetchAsBase64(imgURL)
+	processedValue := client.PreprocessJSON(result.Content)
+	item, err := llmResponseToItems(processedValue)
 	if err != nil {
-		return "", fmt.Errorf("could not fetch image using imageFetcher from URL %s: %w", imgURL, err)
+		openai.Discard(client, result)
+		return models.Item{}, fmt.Errorf("could not convert llm output to json. %s: %w", processedValue, err)
 	}
 
+	normalizeTags(&item)
+	normalizeImportance(&item)
+	normalizeConfidence(&item)
+	normalizeClaims(&item)
+	return item, nil
+}
+
+// processImageWithRetry describes an already fetched image with retry support
+func (p *Processor) processImageWithRetry(dataURI string, imagePrompt string) (string, error) {
 	processFn := func() (string, error) {
 		// Process the image
 		return chatCompletionImageSummary(p.imageClient, imagePrompt, []string{dataURI})
 	}
 
-	return p.retryStringFunc(processFn)
+	return p.retryStringFunc(processFn, "image")
 }
 
 // retryStringFunc is a helper to retry a function that returns a string and error
-func (p *Processor) retryStringFunc(processFn func() (string, error)) (string, error) {
-	// Create retry config from processor's config
-	retryConfig := retry.RetryConfig{
-		InitialBackoff: p.config.InitialBackoff,
-		BackoffFactor:  p.config.BackoffFactor,
-		MaxRetries:     p.config.MaxRetries,
-		MaxBackoff:     p.config.MaxBackoff,
-	}
-
-	// Create a basic shouldRetry function that handles common errors
-	shouldRetry := func(err error) bool {
-		if err == nil {
-			return false // No error, no need to retry
-		}
-		// Add more sophisticated retry logic as needed
-		return true // For now, retry on any error
-	}
-
-	return retry.RetryWithBackoff(context.Background(), retryConfig, func(ctx context.Context) (string, error) {
-		// The provided processFn might not take a context, but RetryWithBackoff requires one.
-		return processFn()
-	}, shouldRetry)
+func (p *Processor) retryStringFunc(processFn func() (string, error), processType string) (string, error) {
+	return retryLLM(p.config, processType, processFn, &p.retries)
 }
 
 // retryItemFunc is a helper to retry a function that returns a models.Item and error
 func (p *Processor) retryItemFunc(processFn func() (models.Item, error), processType string) (models.Item, error) {
-	// Create retry config from processor's config
-	retryConfig := retry.RetryConfig{
-		InitialBackoff: p.config.InitialBackoff,
-		BackoffFactor:  p.config.BackoffFactor,
-		MaxRetries:     p.config.MaxRetries,
-		MaxBackoff:     p.config.MaxBackoff,
-	}
+	return retryLLM(p.config, processType, processFn, &p.retries)
+}
 
-	// Create a basic shouldRetry function that handles common errors
-	shouldRetry := func(err error) bool {
-		if err == nil {
-			return false // No error, no need to retry
-		}
-		// Add more sophisticated retry logic as needed
-		return true // For now, retry on any error
-	}
+// retrySummaryFunc is a helper to retry a function that returns a models.SummaryResponse and error
+func (p *Processor) retrySummaryFunc(processFn func() (*models.SummaryResponse, error), processType string) (*models.SummaryResponse, error) {
+	return retryLLM(p.config, processType, processFn, &p.retries)
+}
 
-	emptyItem := models.Item{}
-	var result models.Item
-	var lastErr error
+// maxRateLimitWait caps how long a rate limited request waits for the API, whatever it asks for
+const maxRateLimitWait = 2 * time.Minute
 
-	// Manually implement retry logic since we can't use type parameters on methods
-	// and retry.RetryWithBackoff expects T to match for both the function and return value
-	backoff := retryConfig.InitialBackoff
-	for attempt := 0; attempt <= retryConfig.MaxRetries; attempt++ {
+// retryLLM calls processFn until it succeeds, fails in a way retrying cannot fix, or runs out of
+// retries. Failures are classified with openai.Classify: prompts that are too long, blocked or
+// rejected fail straight away, and the others wait a delay suited to their kind before retrying.
+// Each retry is counted in retries, if it is not nil.
+func retryLLM[T any](config EntryProcessConfig, processType string, processFn func() (T, error), retries *atomic.Int64) (T, error) {
+	var zero T
+	var lastErr error
+	backoff := config.InitialBackoff
+	for attempt := 0; attempt <= config.MaxRetries; attempt++ {
 		if attempt > 0 {
-			log.Printf("retrying %s processing (attempt %d/%d) after error: %v\n",
-				processType, attempt, retryConfig.MaxRetries, lastErr)
-			time.Sleep(backoff)
-			backoff = time.Duration(float64(backoff) * retryConfig.BackoffFactor)
-			if backoff > retryConfig.MaxBackoff {
-				backoff = retryConfig.MaxBackoff
+			wait := retryDelay(lastErr, backoff, config)
+			log.Printf("retrying %s processing (attempt %d/%d) in %s after %s error: %v\n",
+				processType, attempt, config.MaxRetries, wait.Round(time.Millisecond), openai.Classify(lastErr), lastErr)
+			if retries != nil {
+				retries.Add(1)
 			}
+			time.Sleep(wait)
+			backoff = min(time.Duration(float64(backoff)*config.BackoffFactor), config.MaxBackoff)
 		}
 
-		var err error
-		result, err = processFn()
+		result, err := processFn()
 		if err == nil {
-			return result, nil // Success
+			return result, nil
 		}
-
 		lastErr = err
-		if !shouldRetry(err) {
-			break // Don't retry non-retryable errors
+
+		if kind := openai.Classify(err); !kind.Retryable() {
+			return zero, fmt.Errorf("%s failed with a non-retryable %s error: %w", processType, kind, err)
 		}
 	}
+	return zero, fmt.Errorf("max retries exceeded for %s: %w", processType, lastErr)
+}
 
-	if lastErr != nil {
-		return emptyItem, fmt.Errorf("max retries exceeded for %s: %w", processType, lastErr)
+// retryDelay returns how long to wait before retrying after err. Delays are jittered so requests
+// that failed together, such as concurrent image requests, do not retry together.
+func retryDelay(err error, backoff time.Duration, config EntryProcessConfig) time.Duration {
+	switch openai.Classify(err) {
+	case openai.ErrorSchema:
+		// A malformed response is sampled again; waiting longer does not make the next one better
+		return jitter(config.InitialBackoff)
+	case openai.ErrorRateLimit:
+		// Rate limits clear slower than other failures, so wait at least twice the backoff
+		wait := 2 * backoff
+		var classified *openai.Error
+		if errors.As(err, &classified) && classified.RetryAfter > wait {
+			wait = classified.RetryAfter
+		}
+		wait = min(wait, maxRateLimitWait)
+		return wait + time.Duration(rand.Int64N(int64(wait)/4+1))
+	default:
+		return jitter(backoff)
 	}
-	return result, nil
 }
 
-// retrySummaryFunc is a helper to retry a function that returns a models.SummaryResponse and error
-func (p *Processor) retrySummaryFunc(processFn func() (*models.SummaryResponse, error), processType string) (*models.SummaryResponse, error) {
-	// Create retry config from processor's config
-	retryConfig := retry.RetryConfig{
-		InitialBackoff: p.config.InitialBackoff,
-		BackoffFactor:  p.config.BackoffFactor,
-		MaxRetries:     p.config.MaxRetries,
-		MaxBackoff:     p.config.MaxBackoff,
+// jitter returns a random delay between half of d and d
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
 	}
+	return d/2 + time.Duration(rand.Int64N(int64(d)/2+1))
+}
 
-	// Create a basic shouldRetry function that handles common errors
-	shouldRetry := func(err error) bool {
-		if err == nil {
-			return false // No error, no need to retry
-		}
-		// Add more sophisticated retry logic as needed
-		return true // For now, retry on any error
+// PlaceholderItem creates the item shown in place of an entry that failed processing, so readers
+// still see its title and link. It counts as relevant since it passed filtering before the LLM.
+func PlaceholderItem(entry feeds.Entry) models.Item {
+	item := models.Item{
+		ID:          entry.ID,
+		Title:       entry.Title,
+		Link:        urlnorm.CleanString(entry.Link.Href),
+		IsRelevant:  true,
+		Unavailable: true,
+		CanonicalID: entry.CanonicalID,
+		Entry:       entry,
 	}
-
-	var result *models.SummaryResponse
-	var lastErr error
-
-	// Manually implement retry logic since we can't use type parameters on methods
-	// and retry.RetryWithBackoff expects T to match for both the function and return value
-	backoff := retryConfig.InitialBackoff
-	for attempt := 0; attempt <= retryConfig.MaxRetries; attempt++ {
-		if attempt > 0 {
-			log.Printf("retrying %s processing (attempt %d/%d) after error: %v\n",
-				processType, attempt, retryConfig.MaxRetries, lastErr)
-			time.Sleep(backoff)
-			backoff = time.Duration(float64(backoff) * retryConfig.BackoffFactor)
-			if backoff > retryConfig.MaxBackoff {
-				backoff = retryConfig.MaxBackoff
-			}
-		}
-
-		var err error
-		result, err = processFn()
-		if err == nil {
-			return result, nil // Success
-		}
-
-		lastErr = err
-		if !shouldRetry(err) {
-			break // Don't retry non-retryable errors
-		}
+	if len(entry.ImageURLs) > 0 {
+		item.ThumbnailURL = entry.ImageURLs[0].String()
+	} else if entry.MediaThumbnail.URL != "" {
+		item.ThumbnailURL = entry.MediaThumbnail.URL
 	}
+	return item
+}
 
-	if lastErr != nil {
-		return nil, fmt.Errorf("max retries exceeded for %s: %w", processType, lastErr)
+// FilterImportantItems keeps the items with an importance score of at least minScore. Unscored
+// items, including placeholders, are kept since their importance is unknown, and so are items that
+// matched the persona's watchlist.
+func FilterImportantItems(items []models.Item, minScore int) []models.Item {
+	var important []models.Item
+	for _, item := range items {
+		if item.ImportanceScore == 0 || item.ImportanceScore >= minScore || len(item.Watchlist) > 0 {
+			important = append(important, item)
+		}
 	}
-	return result, nil
+	return important
 }
 
-// FilterRelevantItems filters items by relevance and non-empty ID
+// FilterRelevantItems filters items by relevance and non-empty ID. Items that matched the persona's
+// watchlist are kept even if they were judged not relevant, and so are unsure items, which the
+// digest lists apart.
 func FilterRelevantItems(items []models.Item) []models.Item {
 	var relevantItems []models.Item
 	for _, item := range items {
-		if item.IsRelevant && item.ID != "" {
+		if (item.IsRelevant || item.Unsure || len(item.Watchlist) > 0) && item.ID != "" {
 			relevantItems = append(relevantItems, item)
 		}
 	}
@@ -516,29 +978,33 @@ func llmResponseToItems(jsonStr string) (models.Item, error) {
 
 // generateSummaryWithRetry generates a summary with retry support
 func (p *Processor) generateSummaryWithRetry(items []models.Item, persona persona.Persona) (*models.SummaryResponse, error) {
-	processFn := func() (*models.SummaryResponse, error) {
-		// Create input for summary
-		summaryInputs := make([]string, len(items))
-		for i, item := range items {
-			summaryInputs[i] = item.ToSummaryString()
+	// Create input for summary. Placeholders have no summary, the model could only guess from their titles.
+	summaryInputs := make([]string, 0, len(items))
+	for _, item := range items {
+		if item.Unavailable {
+			continue
 		}
+		summaryInputs = append(summaryInputs, item.ToSummaryString())
+	}
+	if len(summaryInputs) == 0 {
+		return &models.SummaryResponse{}, nil
+	}
 
-		summaryChannel := make(chan customerrors.ErrorString, 1)
+	processFn := func() (*models.SummaryResponse, error) {
 		summaryPrompt, err := prompts.ComposeSummaryPrompt(persona)
 		if err != nil {
 			return nil, fmt.Errorf("could not compose summary prompt for persona %s: %w", persona.Name, err)
 		}
 
-		go chatCompletionForFeedSummary(p.client, summaryPrompt, summaryInputs, summaryChannel)
-
-		summaryResult := <-summaryChannel
+		summaryResult := chatCompletionForFeedSummary(p.client, summaryPrompt, summaryInputs, SummaryResponseSchema)
 		if summaryResult.Err != nil {
 			return nil, fmt.Errorf("could not generate summary: %w", summaryResult.Err)
 		}
 
-		processedSummary := p.client.PreprocessJSON(summaryResult.Value)
+		processedSummary := p.client.PreprocessJSON(summaryResult.Content)
 		summary, err := models.UnmarshalSummaryResponseJSON([]byte(processedSummary))
 		if err != nil {
+			openai.Discard(p.client, summaryResult)
 			return nil, fmt.Errorf("could not parse summary response: %w", err)
 		}
 
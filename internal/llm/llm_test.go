@@ -2,33 +2,44 @@ package llm
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/bakkerme/ai-news-processor/internal/budget"
+	"github.com/bakkerme/ai-news-processor/internal/checkpoint"
 	"github.com/bakkerme/ai-news-processor/internal/contentextractor"
-	"github.com/bakkerme/ai-news-processor/internal/customerrors"
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
 	"github.com/bakkerme/ai-news-processor/internal/http/retry"
+	"github.com/bakkerme/ai-news-processor/internal/megathread"
 	"github.com/bakkerme/ai-news-processor/internal/openai"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/internal/quarantine"
 	"github.com/bakkerme/ai-news-processor/internal/urlextraction"
 	"github.com/bakkerme/ai-news-processor/models"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // Mock implementations for dependencies
 type mockOpenAIClient struct {
-	ChatCompletionFunc func(systemPrompt string, userPrompts []string, imageURLs []string, schemaParams *openai.SchemaParameters, temperature float64, maxTokens int, results chan customerrors.ErrorString)
+	ChatCompletionFunc func(systemPrompt string, userPrompts []string, imageURLs []string, schemaParams *openai.SchemaParameters, temperature float64, maxTokens int) openai.Result
 }
 
-func (m *mockOpenAIClient) ChatCompletion(systemPrompt string, userPrompts []string, imageURLs []string, schemaParams *openai.SchemaParameters, temperature float64, maxTokens int, results chan customerrors.ErrorString) {
+func (m *mockOpenAIClient) ChatCompletion(systemPrompt string, userPrompts []string, imageURLs []string, schemaParams *openai.SchemaParameters, temperature float64, maxTokens int) openai.Result {
 	if m.ChatCompletionFunc != nil {
-		m.ChatCompletionFunc(systemPrompt, userPrompts, imageURLs, schemaParams, temperature, maxTokens, results)
-		return
+		return m.ChatCompletionFunc(systemPrompt, userPrompts, imageURLs, schemaParams, temperature, maxTokens)
 	}
 	// Default mock behavior if ChatCompletionFunc is not set
-	close(results) // Or send a default response
+	return openai.Result{}
 }
 func (m *mockOpenAIClient) PreprocessJSON(s string) string          { return s }
 func (m *mockOpenAIClient) SetRetryConfig(config retry.RetryConfig) {}
@@ -131,6 +142,16 @@ func TestFilterRelevantItems(t *testing.T) {
 		assert.Empty(t, filteredNoRelevant, "should return empty slice when no items are relevant")
 	})
 
+	t.Run("watched items are kept", func(t *testing.T) {
+		items := []models.Item{
+			{ID: "1", IsRelevant: false, Title: "Irrelevant"},
+			{ID: "2", IsRelevant: false, Title: "Qwen 3 rumours", Watchlist: []string{"Qwen"}},
+		}
+
+		filtered := FilterRelevantItems(items)
+		assert.Equal(t, items[1:], filtered, "should keep irrelevant items that matched the watchlist")
+	})
+
 	t.Run("all relevant items", func(t *testing.T) {
 		allRelevantItems := []models.Item{
 			{ID: "1", IsRelevant: true, Title: "Relevant 1"},
@@ -205,3 +226,345 @@ func TestLlmResponseToItems(t *testing.T) {
 		assert.Equal(t, expectedItem, item, "parsed item should match expected, ignoring extra fields")
 	})
 }
+
+func TestProcessEntriesKeepsFailedEntriesAsPlaceholders(t *testing.T) {
+	client := &mockOpenAIClient{
+		ChatCompletionFunc: func(systemPrompt string, userPrompts []string, imageURLs []string, schemaParams *openai.SchemaParameters, temperature float64, maxTokens int) openai.Result {
+			if strings.Contains(userPrompts[0], "Broken") {
+				return openai.Result{Err: errors.New("model overloaded")}
+			}
+			return openai.Result{Content: `{"id":"good","isRelevant":true,"summary":"A summary"}`}
+		},
+	}
+	entries := []feeds.Entry{
+		{ID: "good", Title: "Working story", Link: feeds.Link{Href: "https://example.com/good"}},
+		{ID: "bad", Title: "Broken story", Link: feeds.Link{Href: "https://example.com/bad"}},
+	}
+
+	newProcessor := func(placeholders bool) *Processor {
+		config := EntryProcessConfig{InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, BackoffFactor: 1, MaxRetries: 2, FailurePlaceholders: placeholders}
+		return NewProcessor(client, client, config, &mockArticleExtractor{}, &mockFetcher{}, &mockURLExtractor{}, &mockImageFetcher{})
+	}
+
+	processor := newProcessor(true)
+	items, runData, err := processor.ProcessEntries("system", entries, persona.Persona{Name: "Test"})
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.False(t, items[0].Unavailable)
+	assert.Equal(t, models.Item{
+		ID:          "bad",
+		Title:       "Broken story",
+		Link:        "https://example.com/bad",
+		IsRelevant:  true,
+		Unavailable: true,
+		Entry:       entries[1],
+	}, items[1])
+	assert.Len(t, FilterRelevantItems(items), 2, "placeholders stay in the digest")
+	assert.InDelta(t, 0.5, runData.SuccessRate, 1e-9)
+	stats := processor.Stats()
+	assert.Equal(t, 1, stats.FailedEntries)
+	assert.Equal(t, 2, stats.Retries, "the broken entry is retried twice")
+	require.Len(t, stats.Errors, 1)
+	assert.ErrorContains(t, stats.Errors[0], "model overloaded")
+
+	items, _, err = newProcessor(false).ProcessEntries("system", entries, persona.Persona{Name: "Test"})
+	require.NoError(t, err)
+	require.Len(t, items, 1, "without placeholders failed entries are left out")
+
+	_, _, err = newProcessor(true).ProcessEntries("system", entries[1:], persona.Persona{Name: "Test"})
+	assert.Error(t, err, "a run where every entry failed is still an error")
+}
+
+func TestProcessEntriesGivesUpOnSlowEntries(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	client := &mockOpenAIClient{
+		ChatCompletionFunc: func(systemPrompt string, userPrompts []string, imageURLs []string, schemaParams *openai.SchemaParameters, temperature float64, maxTokens int) openai.Result {
+			if strings.Contains(userPrompts[0], "Hanging") {
+				<-release
+			}
+			return openai.Result{Content: `{"isRelevant":true,"summary":"A summary"}`}
+		},
+	}
+	config := EntryProcessConfig{MaxRetries: 1, FailurePlaceholders: true, EntryTimeout: 50 * time.Millisecond}
+	processor := NewProcessor(client, client, config, &mockArticleExtractor{}, &mockFetcher{}, &mockURLExtractor{}, &mockImageFetcher{})
+	entries := []feeds.Entry{
+		{ID: "slow", Title: "Hanging story", Link: feeds.Link{Href: "https://example.com/slow"}},
+		{ID: "fast", Title: "Working story", Link: feeds.Link{Href: "https://example.com/fast"}},
+	}
+
+	items, _, err := processor.ProcessEntries("system", entries, persona.Persona{Name: "Test"})
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.True(t, items[0].Unavailable, "the hanging entry is kept as a placeholder")
+	assert.Equal(t, "A summary", items[1].Summary, "the run moves on to the next entry")
+
+	stats := processor.Stats()
+	assert.Equal(t, 1, stats.FailedEntries)
+	require.Len(t, stats.Errors, 1)
+	assert.ErrorIs(t, stats.Errors[0], ErrEntryTimeout)
+	assert.ErrorContains(t, stats.Errors[0], "while summarizing it")
+}
+
+func TestProcessEntriesSkipsQuarantinedEntries(t *testing.T) {
+	client := &mockOpenAIClient{
+		ChatCompletionFunc: func(systemPrompt string, userPrompts []string, imageURLs []string, schemaParams *openai.SchemaParameters, temperature float64, maxTokens int) openai.Result {
+			if strings.Contains(userPrompts[0], "Broken") {
+				return openai.Result{Err: errors.New("prompt is too long")}
+			}
+			return openai.Result{Content: `{"isRelevant":true,"summary":"A summary"}`}
+		},
+	}
+	entries := []feeds.Entry{
+		{ID: "good", Title: "Working story"},
+		{ID: "bad", Title: "Broken story"},
+	}
+	path := filepath.Join(t.TempDir(), "quarantined_entries.json")
+
+	for run := 1; run <= 3; run++ {
+		store, err := quarantine.Load(path, 2, quarantine.DefaultMaxAge)
+		require.NoError(t, err)
+		processor := NewProcessor(client, client, EntryProcessConfig{FailurePlaceholders: true}, &mockArticleExtractor{}, &mockFetcher{}, &mockURLExtractor{}, &mockImageFetcher{})
+		processor.SetQuarantine(store)
+
+		items, _, err := processor.ProcessEntries("system", entries, persona.Persona{Name: "Test"})
+		require.NoError(t, err)
+		require.NoError(t, store.Save())
+
+		stats := processor.Stats()
+		if run < 3 {
+			assert.Len(t, items, 2, "run %d still tries the broken entry", run)
+			assert.Equal(t, 1, stats.FailedEntries)
+			assert.Empty(t, stats.Quarantined)
+			continue
+		}
+		require.Len(t, items, 1, "the broken entry is skipped after failing two runs in a row")
+		assert.Equal(t, "good", items[0].Entry.ID)
+		assert.Equal(t, 0, stats.FailedEntries)
+		require.Len(t, stats.Quarantined, 1)
+		assert.Equal(t, "bad", stats.Quarantined[0].Entry.ID)
+		assert.Equal(t, 2, stats.Quarantined[0].Record.Failures)
+		assert.Contains(t, stats.Quarantined[0].Record.LastError, "prompt is too long")
+	}
+}
+
+func TestProcessEntriesRecordsTokenUsage(t *testing.T) {
+	attempts := 0
+	client := &mockOpenAIClient{
+		ChatCompletionFunc: func(systemPrompt string, userPrompts []string, imageURLs []string, schemaParams *openai.SchemaParameters, temperature float64, maxTokens int) openai.Result {
+			attempts++
+			usage := openai.Usage{Calls: 1, PromptTokens: 100, CompletionTokens: 20}
+			if attempts == 1 {
+				return openai.Result{Usage: usage, Err: errors.New("model overloaded")}
+			}
+			return openai.Result{Content: `{"isRelevant":true,"summary":"A summary"}`, Usage: usage}
+		},
+	}
+	config := EntryProcessConfig{InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, BackoffFactor: 1, MaxRetries: 1}
+	processor := NewProcessor(client, client, config, &mockArticleExtractor{}, &mockFetcher{}, &mockURLExtractor{}, &mockImageFetcher{})
+
+	_, runData, err := processor.ProcessEntries("system", []feeds.Entry{{ID: "1", Title: "Story"}}, persona.Persona{Name: "Test"})
+	require.NoError(t, err)
+	require.Len(t, runData.EntrySummaries, 1)
+	assert.Equal(t, int64(200), runData.EntrySummaries[0].PromptTokens, "the failed attempt counts too")
+	assert.Equal(t, int64(40), runData.EntrySummaries[0].CompletionTokens)
+}
+
+func TestProcessEntriesDoesNotRetryCachedBadResponses(t *testing.T) {
+	dir := t.TempDir()
+	response := "Sorry, I cannot help with that."
+	calls := 0
+	inner := &mockOpenAIClient{
+		ChatCompletionFunc: func(systemPrompt string, userPrompts []string, imageURLs []string, schemaParams *openai.SchemaParameters, temperature float64, maxTokens int) openai.Result {
+			calls++
+			return openai.Result{Content: response}
+		},
+	}
+	// An earlier run cached a response that is not JSON
+	earlier, err := openai.NewCachingClient(inner, dir, time.Hour)
+	require.NoError(t, err)
+	entryPrompt, err := NewProcessor(earlier, earlier, EntryProcessConfig{}, &mockArticleExtractor{}, &mockFetcher{}, &mockURLExtractor{}, &mockImageFetcher{}).entryPrompt("system", feeds.Entry{ID: "1", Title: "Story"}, persona.Persona{Name: "Test"})
+	require.NoError(t, err)
+	earlier.ChatCompletion("system", []string{entryPrompt}, nil, EntryResponseSchema, 0.5, 0)
+	require.Equal(t, 1, calls)
+
+	response = `{"isRelevant":true,"summary":"A summary"}`
+	client, err := openai.NewCachingClient(inner, dir, time.Hour)
+	require.NoError(t, err)
+	config := EntryProcessConfig{InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, BackoffFactor: 1, MaxRetries: 2}
+	processor := NewProcessor(client, client, config, &mockArticleExtractor{}, &mockFetcher{}, &mockURLExtractor{}, &mockImageFetcher{})
+
+	items, _, err := processor.ProcessEntries("system", []feeds.Entry{{ID: "1", Title: "Story"}}, persona.Persona{Name: "Test"})
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, "A summary", items[0].Summary, "the retry asks the LLM instead of the cache")
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, 1, client.Usage().CachedCalls)
+}
+
+func TestProcessEntriesDefersEntriesOverBudget(t *testing.T) {
+	var usage openai.Usage
+	client := &mockOpenAIClient{
+		ChatCompletionFunc: func(systemPrompt string, userPrompts []string, imageURLs []string, schemaParams *openai.SchemaParameters, temperature float64, maxTokens int) openai.Result {
+			usage.Calls++
+			return openai.Result{Content: `{"isRelevant":true,"summary":"A summary"}`}
+		},
+	}
+	entries := []feeds.Entry{
+		{ID: "1", Title: "First story"},
+		{ID: "2", Title: "Second story"},
+		{ID: "3", Title: "Third story"},
+	}
+	config := EntryProcessConfig{InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, BackoffFactor: 1}
+	processor := NewProcessor(client, client, config, &mockArticleExtractor{}, &mockFetcher{}, &mockURLExtractor{}, &mockImageFetcher{})
+	processor.SetBudget(budget.New(budget.Limits{MaxCalls: 2}, func() openai.Usage { return usage }))
+
+	items, runData, err := processor.ProcessEntries("system", entries, persona.Persona{Name: "Test"})
+	require.NoError(t, err)
+	assert.Len(t, items, 2)
+	assert.InDelta(t, 1.0, runData.SuccessRate, 1e-9, "deferred entries do not count as failures")
+
+	deferred, reason := processor.Deferred()
+	assert.Equal(t, entries[2:], deferred)
+	assert.EqualError(t, reason, "budget of 2 LLM calls reached")
+
+	processor.SetBudget(nil)
+	items, _, err = processor.ProcessEntries("system", entries, persona.Persona{Name: "Test"})
+	require.NoError(t, err)
+	assert.Len(t, items, 3)
+	deferred, reason = processor.Deferred()
+	assert.Empty(t, deferred)
+	assert.NoError(t, reason)
+}
+
+func TestProcessEntriesResumesFromCheckpoint(t *testing.T) {
+	var prompts []string
+	client := &mockOpenAIClient{
+		ChatCompletionFunc: func(systemPrompt string, userPrompts []string, imageURLs []string, schemaParams *openai.SchemaParameters, temperature float64, maxTokens int) openai.Result {
+			prompts = append(prompts, userPrompts[0])
+			return openai.Result{Content: `{"isRelevant":true,"summary":"A summary"}`}
+		},
+	}
+	entries := []feeds.Entry{
+		{ID: "1", Title: "First story"},
+		{ID: "2", Title: "Second story"},
+	}
+	dir := t.TempDir()
+	config := EntryProcessConfig{InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, BackoffFactor: 1}
+	processor := NewProcessor(client, client, config, &mockArticleExtractor{}, &mockFetcher{}, &mockURLExtractor{}, &mockImageFetcher{})
+
+	// An earlier run processed the first entry before it was interrupted
+	earlier, err := checkpoint.Open(dir, "Test", false)
+	require.NoError(t, err)
+	require.NoError(t, earlier.Record("1", models.Item{ID: "1", Title: "First story", Summary: "From the earlier run"}))
+
+	resumed, err := checkpoint.Open(dir, "Test", true)
+	require.NoError(t, err)
+	processor.SetCheckpoint(resumed)
+	items, runData, err := processor.ProcessEntries("system", entries, persona.Persona{Name: "Test"})
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, "From the earlier run", items[0].Summary)
+	assert.Equal(t, "A summary", items[1].Summary)
+	require.Len(t, prompts, 1, "only the entry missing from the checkpoint is sent to the LLM")
+	assert.Contains(t, prompts[0], "Second story")
+	assert.InDelta(t, 1.0, runData.SuccessRate, 1e-9)
+	_, ok := resumed.Completed("2")
+	assert.True(t, ok, "newly processed entries are checkpointed")
+}
+
+func TestProcessEntriesSummarizesMegathreadsWithTheirPrompt(t *testing.T) {
+	systemPrompts := make(map[string]string)
+	client := &mockOpenAIClient{
+		ChatCompletionFunc: func(systemPrompt string, userPrompts []string, imageURLs []string, schemaParams *openai.SchemaParameters, temperature float64, maxTokens int) openai.Result {
+			systemPrompts[userPrompts[0]] = systemPrompt
+			return openai.Result{Content: `{"isRelevant":true,"summary":"A summary"}`}
+		},
+	}
+	entries := []feeds.Entry{
+		{ID: "1", Title: "New quantization method"},
+		{ID: "2", Title: "Llama 4 release megathread"},
+	}
+	detector, err := megathread.New(persona.Megathreads{})
+	require.NoError(t, err)
+	processor := NewProcessor(client, client, EntryProcessConfig{}, &mockArticleExtractor{}, &mockFetcher{}, &mockURLExtractor{}, &mockImageFetcher{})
+	processor.SetMegathreads(detector, "megathread system")
+
+	items, runData, err := processor.ProcessEntries("system", entries, persona.Persona{Name: "Test"})
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.False(t, items[0].Megathread)
+	assert.True(t, items[1].Megathread)
+	require.Len(t, systemPrompts, 2)
+	for userPrompt, systemPrompt := range systemPrompts {
+		if strings.Contains(userPrompt, "megathread") {
+			assert.Equal(t, "megathread system", systemPrompt)
+		} else {
+			assert.Equal(t, "system", systemPrompt)
+		}
+	}
+	assert.Len(t, runData.SystemPrompts, 2, "both prompts are kept with the run data")
+}
+
+func TestRetryLLMClassifiesErrors(t *testing.T) {
+	config := EntryProcessConfig{InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, BackoffFactor: 2, MaxRetries: 3}
+
+	tests := []struct {
+		name      string
+		err       error
+		wantCalls int
+		wantErr   string
+	}{
+		{name: "context length fails fast", err: &openai.Error{Kind: openai.ErrorContextLength, Err: errors.New("prompt too long")}, wantCalls: 1, wantErr: "entry failed with a non-retryable context length error: prompt too long"},
+		{name: "content filter fails fast", err: &openai.Error{Kind: openai.ErrorContentFilter, Err: errors.New("blocked")}, wantCalls: 1, wantErr: "non-retryable content filter error"},
+		{name: "rate limits are retried", err: &openai.Error{Kind: openai.ErrorRateLimit, Err: errors.New("slow down")}, wantCalls: 4, wantErr: "max retries exceeded for entry: slow down"},
+		{name: "malformed responses are retried", err: fmt.Errorf("could not convert llm output to json: %w", json.Unmarshal([]byte("{"), &struct{}{})), wantCalls: 4, wantErr: "max retries exceeded"},
+		{name: "unclassified errors are retried", err: errors.New("something odd"), wantCalls: 4, wantErr: "max retries exceeded"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			var retries atomic.Int64
+			_, err := retryLLM(config, "entry", func() (string, error) {
+				calls++
+				return "", tt.err
+			}, &retries)
+			assert.Equal(t, int64(tt.wantCalls-1), retries.Load())
+			assert.Equal(t, tt.wantCalls, calls)
+			assert.ErrorContains(t, err, tt.wantErr)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+
+	calls := 0
+	result, err := retryLLM(config, "entry", func() (string, error) {
+		calls++
+		if calls < 3 {
+			return "", &openai.Error{Kind: openai.ErrorNetwork, Err: errors.New("connection reset")}
+		}
+		return "done", nil
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "done", result)
+}
+
+func TestRetryDelay(t *testing.T) {
+	config := EntryProcessConfig{InitialBackoff: time.Second, MaxBackoff: 10 * time.Second}
+
+	for i := 0; i < 20; i++ {
+		network := retryDelay(&openai.Error{Kind: openai.ErrorNetwork}, 4*time.Second, config)
+		assert.True(t, network >= 2*time.Second && network <= 4*time.Second, "network delays are jittered within the backoff, got %s", network)
+
+		schema := retryDelay(&openai.Error{Kind: openai.ErrorSchema}, 4*time.Second, config)
+		assert.True(t, schema <= time.Second, "schema errors do not back off, got %s", schema)
+
+		rateLimit := retryDelay(&openai.Error{Kind: openai.ErrorRateLimit}, 4*time.Second, config)
+		assert.True(t, rateLimit >= 8*time.Second && rateLimit <= 10*time.Second, "rate limits wait twice the backoff, got %s", rateLimit)
+
+		retryAfter := retryDelay(&openai.Error{Kind: openai.ErrorRateLimit, RetryAfter: 30 * time.Second}, 4*time.Second, config)
+		assert.True(t, retryAfter >= 30*time.Second, "rate limits honour Retry-After, got %s", retryAfter)
+
+		capped := retryDelay(&openai.Error{Kind: openai.ErrorRateLimit, RetryAfter: time.Hour}, 4*time.Second, config)
+		assert.True(t, capped <= maxRateLimitWait*5/4, "Retry-After is capped, got %s", capped)
+	}
+}
@@ -1,13 +1,30 @@
 package llm
 
 import (
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/bakkerme/ai-news-processor/internal/archive"
+	"github.com/bakkerme/ai-news-processor/internal/budget"
+	"github.com/bakkerme/ai-news-processor/internal/checkpoint"
 	"github.com/bakkerme/ai-news-processor/internal/contentextractor"
+	"github.com/bakkerme/ai-news-processor/internal/domainfilter"
+	"github.com/bakkerme/ai-news-processor/internal/failedurls"
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
 	"github.com/bakkerme/ai-news-processor/internal/fetcher"
 	"github.com/bakkerme/ai-news-processor/internal/http"
+	"github.com/bakkerme/ai-news-processor/internal/huggingface"
+	"github.com/bakkerme/ai-news-processor/internal/megathread"
+	"github.com/bakkerme/ai-news-processor/internal/ocr"
 	"github.com/bakkerme/ai-news-processor/internal/openai"
+	"github.com/bakkerme/ai-news-processor/internal/quarantine"
+	"github.com/bakkerme/ai-news-processor/internal/runcache"
+	"github.com/bakkerme/ai-news-processor/internal/sitemeta"
+	"github.com/bakkerme/ai-news-processor/internal/twitter"
 	"github.com/bakkerme/ai-news-processor/internal/urlextraction"
+	"github.com/bakkerme/ai-news-processor/internal/urlnorm"
+	"github.com/bakkerme/ai-news-processor/models"
 )
 
 // EntryProcessConfig holds configuration for entry processing
@@ -16,10 +33,23 @@ type EntryProcessConfig struct {
 	BackoffFactor        float64
 	MaxRetries           int
 	MaxBackoff           time.Duration
-	ImageEnabled         bool // Whether image processing is enabled
-	DebugOutputBenchmark bool // Whether to output benchmark inputs
-	URLSummaryEnabled    bool // Whether URL summarization is enabled
-	BenchmarkEnabled     bool // Whether to collect benchmark data
+	ImageEnabled         bool          // Whether image processing is enabled
+	DebugOutputBenchmark bool          // Whether to output benchmark inputs
+	URLSummaryEnabled    bool          // Whether URL summarization is enabled
+	BenchmarkEnabled     bool          // Whether to collect benchmark data
+	HuggingFaceEnabled   bool          // Whether Hugging Face URLs are enriched via the Hub API
+	TwitterEnabled       bool          // Whether tweet URLs are read via the embed endpoints of X, even on denied domains
+	ResolveShortLinks    bool          // Whether links of link shorteners such as bit.ly are resolved before they are fetched and compared
+	ArchiveEnabled       bool          // Whether paywalled or consent-walled pages are retried via the Wayback Machine
+	DenyDomains          []string      // Domains whose external URLs are never fetched
+	AllowDomains         []string      // Domains that are always fetched, overriding DenyDomains
+	ImageConcurrency     int           // Maximum number of concurrent image summarization requests
+	ImageBatchSize       int           // Maximum number of images sent in one multimodal request (1 disables batching)
+	FailurePlaceholders  bool          // Whether entries that fail processing are kept as title and link placeholders
+	ContextTokens        int           // Context window of the model in tokens; larger entries are condensed first (0 disables)
+	VerifyReprompt       bool          // Whether a response with made-up URLs or IDs is requested once more before they are stripped
+	DegradeAfterFailures int           // Consecutive image model or URL fetch failures after which that enrichment is skipped (0 disables)
+	EntryTimeout         time.Duration // Time an entry may take across its image, URLs and summary before it is given up on (0 disables)
 }
 
 // DefaultEntryProcessConfig provides default configuration for entry processing
@@ -32,6 +62,16 @@ var DefaultEntryProcessConfig = EntryProcessConfig{
 	DebugOutputBenchmark: false,
 	URLSummaryEnabled:    true,
 	BenchmarkEnabled:     false,
+	HuggingFaceEnabled:   true,
+	TwitterEnabled:       true,
+	ResolveShortLinks:    true,
+	ArchiveEnabled:       true,
+	DenyDomains:          domainfilter.DefaultDenyDomains,
+	ImageConcurrency:     2,
+	ImageBatchSize:       1,
+	FailurePlaceholders:  true,
+	ContextTokens:        0,
+	DegradeAfterFailures: 5,
 }
 
 // Processor handles the processing of RSS entries with LLM integration
@@ -46,4 +86,43 @@ type Processor struct {
 	debugOutputBenchmark bool                              // Whether to output benchmark inputs
 	imageFetcher         http.ImageFetcher                 // Fetcher for images
 	articleExtractor     contentextractor.ArticleExtractor // Article content extractor
+	hfClient             *huggingface.Client               // Hub client for Hugging Face URL enrichment (nil when disabled)
+	twitterClient        *twitter.Client                   // Reads tweets and threads linked from entries (nil when disabled)
+	urlResolver          *urlnorm.Resolver                 // Resolves short links to the page they point at (nil when disabled)
+	failedURLs           *failedurls.Store                 // Store of consistently failing URLs to skip (nil when disabled)
+	quarantine           *quarantine.Store                 // Store of entries that failed too many runs in a row to process (nil when disabled)
+	siteMeta             *sitemeta.Cache                   // Cache of site names and favicons for summarized URLs (nil when disabled)
+	runCache             *runcache.Cache                   // Pages, images and summaries shared with the other personas of the run (nil when disabled)
+	archiveClient        *archive.Client                   // Wayback Machine client for walled pages (nil when disabled)
+	domainFilter         *domainfilter.Filter              // Global deny/allow list for external URL domains
+	imageSem             chan struct{}                     // Bounds concurrent image summarization requests
+	ocr                  ocr.Recognizer                    // Extracts text from text-heavy images (nil when disabled)
+	budget               *budget.Budget                    // Limits the LLM work of a run (nil when unlimited)
+	megathreads          *megathread.Detector              // Detects the entries summarized with megathreadPrompt (nil when disabled)
+	megathreadPrompt     string                            // System prompt of megathreads, which asks for the themes of the discussion
+	deferred             []feeds.Entry                     // Entries the last run left for the next one
+	deferReason          error                             // Limit that caused entries to be deferred
+	checkpoint           *checkpoint.Checkpoint            // Records processed entries so an interrupted run can resume (nil when disabled)
+	imageBreaker         *breaker                          // Trips when the image model keeps failing, so images are skipped
+	linkBreaker          *breaker                          // Trips when URL fetching keeps failing, so Phase 2 is skipped
+	retries              atomic.Int64                      // LLM requests retried since ProcessEntries started
+	violationsMu         sync.Mutex                        // Guards violations, as entries given up on may still finish
+	violations           []models.Violation                // URLs and IDs the LLM made up since ProcessEntries started
+	deadlines            *entryDeadlines                   // Time spent on each entry since ProcessEntries started
+	stats                RunStats                          // Outcome of the last ProcessEntries call
+}
+
+// RunStats describes the problems of a ProcessEntries call, for the operator's run report
+type RunStats struct {
+	FailedEntries int      // Entries the LLM could not process
+	Retries       int      // LLM requests that were retried
+	Errors        []error  // Entry and external URL failures, in the order they happened
+	Degraded      []string // Enrichments skipped after repeated failures, DegradedImages or DegradedLinks
+	Quarantined   []QuarantinedEntry
+}
+
+// QuarantinedEntry is an entry skipped because it failed processing too many runs in a row
+type QuarantinedEntry struct {
+	Entry  feeds.Entry
+	Record quarantine.Entry
 }
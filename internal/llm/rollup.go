@@ -0,0 +1,46 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/bakkerme/ai-news-processor/internal/itemstore"
+	"github.com/bakkerme/ai-news-processor/internal/openai"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/internal/prompts"
+	"github.com/bakkerme/ai-news-processor/models"
+)
+
+// GenerateRollup asks the LLM for a review of the items sent to a persona over the last days,
+// with themes connecting the items and an analysis of trends over the period
+func GenerateRollup(client openai.OpenAIClient, records []itemstore.Record, p persona.Persona, days int) (*models.RollupResponse, error) {
+	if len(records) == 0 {
+		return nil, fmt.Errorf("no items to review for persona %s", p.Name)
+	}
+	log.Printf("Generating %d day rollup of %d items for persona %s", days, len(records), p.Name)
+
+	systemPrompt, err := prompts.ComposeRollupPrompt(p, days)
+	if err != nil {
+		return nil, fmt.Errorf("could not compose rollup prompt for persona %s: %w", p.Name, err)
+	}
+
+	inputs := make([]string, len(records))
+	for i, record := range records {
+		inputs[i] = fmt.Sprintf("Sent: %s\n%s", record.SentAt.Format("Monday 2006-01-02"), record.Item.ToSummaryString())
+	}
+
+	return retryLLM(DefaultEntryProcessConfig, "rollup", func() (*models.RollupResponse, error) {
+		result := chatCompletionForFeedSummary(client, systemPrompt, inputs, RollupResponseSchema)
+		if result.Err != nil {
+			return nil, fmt.Errorf("could not generate rollup: %w", result.Err)
+		}
+
+		var rollup models.RollupResponse
+		if err := json.Unmarshal([]byte(client.PreprocessJSON(result.Content)), &rollup); err != nil {
+			openai.Discard(client, result)
+			return nil, fmt.Errorf("could not parse rollup response: %w", err)
+		}
+		return &rollup, nil
+	}, nil)
+}
@@ -0,0 +1,58 @@
+package llm
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/itemstore"
+	"github.com/bakkerme/ai-news-processor/internal/openai"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateRollup(t *testing.T) {
+	p := persona.Persona{
+		Name:              "TestPersona",
+		PersonaIdentity:   "An AI assistant specialized in summarizing tech news.",
+		SummaryPromptTask: "Summarize the week",
+	}
+	records := []itemstore.Record{
+		{SentAt: time.Date(2025, time.March, 3, 9, 0, 0, 0, time.UTC), Item: models.Item{ID: "id1", Title: "Entry 1", Summary: "Summary 1"}},
+	}
+
+	mockClient := &MockOpenAIClient{
+		PreprocessJSONFunc: func(response string) string {
+			return `{"overview":"Quiet week","themes":[{"title":"Theme","summary":"Sum","itemIDs":["id1"]}],"trends":["More of the same"]}`
+		},
+	}
+
+	rollup, err := GenerateRollup(mockClient, records, p, 7)
+	require.NoError(t, err)
+	assert.Equal(t, "Quiet week", rollup.Overview)
+	require.Len(t, rollup.Themes, 1)
+	assert.Equal(t, []string{"id1"}, rollup.Themes[0].ItemIDs)
+	assert.Equal(t, []string{"More of the same"}, rollup.Trends)
+	assert.Contains(t, mockClient.LastSystemPrompt, "last 7 days")
+	require.Len(t, mockClient.LastUserPrompts, 1)
+	assert.Contains(t, mockClient.LastUserPrompts[0], "Sent: Monday 2025-03-03")
+	assert.Contains(t, mockClient.LastUserPrompts[0], "ID: id1")
+
+	_, err = GenerateRollup(mockClient, nil, p, 7)
+	assert.Error(t, err)
+}
+
+func TestGenerateRollup_NonRetryable(t *testing.T) {
+	records := []itemstore.Record{{SentAt: time.Now(), Item: models.Item{ID: "id1", Title: "Entry 1"}}}
+	calls := 0
+	client := &mockOpenAIClient{ChatCompletionFunc: func(systemPrompt string, userPrompts []string, imageURLs []string, schemaParams *openai.SchemaParameters, temperature float64, maxTokens int) openai.Result {
+		calls++
+		return openai.Result{Err: &openai.Error{Kind: openai.ErrorRequest, Err: errors.New("invalid api key")}}
+	}}
+
+	_, err := GenerateRollup(client, records, persona.Persona{Name: "TestPersona", PersonaIdentity: "A tester", SummaryPromptTask: "Summarize the week"}, 7)
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls, "errors retrying cannot fix are not retried")
+}
@@ -46,6 +46,17 @@ func TestGenerateSummary(t *testing.T) {
 		assert.True(t, mockClient.CalledPreprocessJSON, "PreprocessJSON should have been called")
 	})
 
+	t.Run("OnlyPlaceholdersSkipsTheModel", func(t *testing.T) {
+		mockClient := &MockOpenAIClient{}
+
+		summary, err := GenerateSummary(mockClient, []models.Item{{ID: "id3", Title: "Entry 3", IsRelevant: true, Unavailable: true}}, testPersona)
+
+		assert.NoError(t, err)
+		require.NotNil(t, summary)
+		assert.Empty(t, summary.KeyDevelopments)
+		assert.False(t, mockClient.CalledChatCompletion, "ChatCompletion should not have been called")
+	})
+
 	t.Run("ErrorInComposeSummaryPrompt", func(t *testing.T) {
 		// To reliably trigger an error in ComposeSummaryPrompt, we should use a persona
 		// that is known to cause an error. For example, if it uses Go templates and
@@ -0,0 +1,131 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/bakkerme/ai-news-processor/internal/openai"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/internal/prompts"
+	"github.com/bakkerme/ai-news-processor/models"
+)
+
+// secondOpinion is the response of the second opinion prompt
+type secondOpinion struct {
+	IsRelevant          bool   `json:"isRelevant"`
+	RelevanceConfidence int    `json:"relevanceConfidence"`
+	RelevanceToCriteria string `json:"relevanceToCriteria"`
+}
+
+// normalizeConfidence drops a relevance confidence outside 1 to 100, leaving the decision trusted.
+// Only Triage marks items unsure.
+func normalizeConfidence(item *models.Item) {
+	if item.RelevanceConfidence < 1 || item.RelevanceConfidence > 100 {
+		if item.RelevanceConfidence != 0 {
+			log.Printf("Ignoring relevance confidence %d of item %s, must be 1 to 100", item.RelevanceConfidence, item.ID)
+		}
+		item.RelevanceConfidence = 0
+	}
+	item.Unsure = false
+}
+
+// IsUnsure reports whether the LLM was less confident of an item's relevance than minConfidence.
+// Items without a confidence, placeholders and items on the watchlist, which are always sent, are
+// never unsure.
+func IsUnsure(item models.Item, minConfidence int) bool {
+	return minConfidence > 0 && item.RelevanceConfidence > 0 && item.RelevanceConfidence < minConfidence &&
+		!item.Unavailable && len(item.Watchlist) == 0
+}
+
+// Triage settles the relevance of the items the LLM was unsure of, as the persona's unsure
+// settings say. With the second opinion action, each unsure item is judged again from its summary
+// with client, which may be a smaller model; a confident second opinion replaces the first. The
+// items still unsure are marked Unsure, so they are sent in a section of their own whatever
+// IsRelevant says. It returns the number of second opinions asked and the errors of those that
+// failed, whose items stay unsure.
+func Triage(client openai.OpenAIClient, items []models.Item, p persona.Persona) (int, []error) {
+	minConfidence := p.Unsure.MinConfidence
+	var unsure []int
+	for i := range items {
+		if IsUnsure(items[i], minConfidence) {
+			unsure = append(unsure, i)
+		}
+	}
+	if len(unsure) == 0 {
+		return 0, nil
+	}
+
+	if p.Unsure.GetAction() != persona.UnsureSecondOpinion {
+		for _, i := range unsure {
+			items[i].Unsure = true
+		}
+		return 0, nil
+	}
+
+	systemPrompt, err := prompts.ComposeSecondOpinionPrompt(p)
+	if err != nil {
+		for _, i := range unsure {
+			items[i].Unsure = true
+		}
+		return 0, []error{fmt.Errorf("could not compose second opinion prompt: %w", err)}
+	}
+
+	log.Printf("Asking a second opinion on %d items of persona %s\n", len(unsure), p.Name)
+	var errs []error
+	for _, i := range unsure {
+		opinion, err := askSecondOpinion(client, systemPrompt, items[i])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("entry %s: second opinion: %w", items[i].ID, err))
+			items[i].Unsure = true
+			continue
+		}
+		if opinion.RelevanceConfidence < minConfidence {
+			items[i].Unsure = true
+			continue
+		}
+		items[i].IsRelevant = opinion.IsRelevant
+		items[i].RelevanceConfidence = opinion.RelevanceConfidence
+		items[i].RelevanceToCriteria = opinion.RelevanceToCriteria
+	}
+	return len(unsure), errs
+}
+
+// askSecondOpinion judges the relevance of an item again from its summary. Failures are retried
+// like those of entries, so prompts that cannot succeed are not sent again.
+func askSecondOpinion(client openai.OpenAIClient, systemPrompt string, item models.Item) (secondOpinion, error) {
+	verdict := "not relevant"
+	if item.IsRelevant {
+		verdict = "relevant"
+	}
+	input := fmt.Sprintf("%sFirst reviewer (%d%% sure it is %s): %s\n", item.ToSummaryString(), item.RelevanceConfidence, verdict, item.RelevanceToCriteria)
+
+	return retryLLM(DefaultEntryProcessConfig, "second opinion", func() (secondOpinion, error) {
+		result := chatCompletionForSecondOpinion(client, systemPrompt, input)
+		if result.Err != nil {
+			return secondOpinion{}, result.Err
+		}
+
+		var opinion secondOpinion
+		if err := json.Unmarshal([]byte(client.PreprocessJSON(result.Content)), &opinion); err != nil {
+			openai.Discard(client, result)
+			return secondOpinion{}, fmt.Errorf("could not parse second opinion: %w", err)
+		}
+		if opinion.RelevanceConfidence < 1 || opinion.RelevanceConfidence > 100 {
+			openai.Discard(client, result)
+			return secondOpinion{}, fmt.Errorf("second opinion has a confidence of %d, must be between 1 and 100", opinion.RelevanceConfidence)
+		}
+		return opinion, nil
+	}, nil)
+}
+
+// SureItems returns the items the LLM was sure of, leaving out those marked Unsure
+func SureItems(items []models.Item) []models.Item {
+	var sure []models.Item
+	for _, item := range items {
+		if !item.Unsure {
+			sure = append(sure, item)
+		}
+	}
+	return sure
+}
@@ -0,0 +1,138 @@
+package llm
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bakkerme/ai-news-processor/internal/openai"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func triageItems() []models.Item {
+	return []models.Item{
+		{ID: "sure", Title: "Qwen 3 released", IsRelevant: true, RelevanceConfidence: 95},
+		{ID: "unknown", Title: "No confidence", IsRelevant: true},
+		{ID: "flip", Title: "A GPU price list", IsRelevant: true, RelevanceConfidence: 40, RelevanceToCriteria: "Maybe hardware news"},
+		{ID: "still", Title: "A vague question", RelevanceConfidence: 30},
+		{ID: "watched", Title: "Llama rumours", RelevanceConfidence: 20, Watchlist: []string{"llama"}},
+	}
+}
+
+func TestIsUnsure(t *testing.T) {
+	assert.False(t, IsUnsure(models.Item{RelevanceConfidence: 40}, 0), "a persona without a minimum trusts every decision")
+	assert.True(t, IsUnsure(models.Item{RelevanceConfidence: 40}, 60))
+	assert.False(t, IsUnsure(models.Item{RelevanceConfidence: 60}, 60))
+	assert.False(t, IsUnsure(models.Item{}, 60), "items without a confidence are trusted")
+	assert.False(t, IsUnsure(models.Item{RelevanceConfidence: 40, Unavailable: true}, 60))
+}
+
+func TestTriage_List(t *testing.T) {
+	items := triageItems()
+	client := &mockOpenAIClient{}
+	asked, errs := Triage(client, items, persona.Persona{Name: "Test", Unsure: persona.Unsure{MinConfidence: 60}})
+
+	assert.Zero(t, asked)
+	assert.Empty(t, errs)
+	var unsure []string
+	for _, item := range items {
+		if item.Unsure {
+			unsure = append(unsure, item.ID)
+		}
+	}
+	assert.Equal(t, []string{"flip", "still"}, unsure)
+
+	relevant := FilterRelevantItems(items)
+	require.Len(t, relevant, 5, "unsure items are kept whatever IsRelevant says")
+	assert.Len(t, SureItems(relevant), 3)
+}
+
+func TestTriage_SecondOpinion(t *testing.T) {
+	items := triageItems()
+	var inputs []string
+	client := &mockOpenAIClient{ChatCompletionFunc: func(systemPrompt string, userPrompts []string, imageURLs []string, schemaParams *openai.SchemaParameters, temperature float64, maxTokens int) openai.Result {
+		assert.Contains(t, systemPrompt, "* Local models")
+		inputs = append(inputs, userPrompts[0])
+		if strings.Contains(userPrompts[0], "GPU price list") {
+			return openai.Result{Content: `{"isRelevant": false, "relevanceConfidence": 85, "relevanceToCriteria": "Prices are not news"}`}
+		}
+		return openai.Result{Content: `{"isRelevant": true, "relevanceConfidence": 50, "relevanceToCriteria": "Hard to say"}`}
+	}}
+	p := persona.Persona{
+		Name:              "Test",
+		PersonaIdentity:   "an AI researcher",
+		Topic:             "local LLMs",
+		FocusAreas:        []string{"Local models"},
+		RelevanceCriteria: []string{"About running models locally"},
+		Unsure:            persona.Unsure{MinConfidence: 60, Action: persona.UnsureSecondOpinion},
+	}
+
+	asked, errs := Triage(client, items, p)
+	assert.Equal(t, 2, asked)
+	assert.Empty(t, errs)
+	require.Len(t, inputs, 2)
+	assert.Contains(t, inputs[0], "Title: A GPU price list")
+	assert.Contains(t, inputs[0], "First reviewer (40% sure it is relevant): Maybe hardware news")
+
+	// A confident second opinion replaces the first
+	flip := items[2]
+	assert.False(t, flip.Unsure)
+	assert.False(t, flip.IsRelevant)
+	assert.Equal(t, 85, flip.RelevanceConfidence)
+	assert.Equal(t, "Prices are not news", flip.RelevanceToCriteria)
+
+	// An item the second opinion is unsure of as well keeps its first judgement and is listed apart
+	still := items[3]
+	assert.True(t, still.Unsure)
+	assert.False(t, still.IsRelevant)
+	assert.Equal(t, 30, still.RelevanceConfidence)
+
+	assert.False(t, items[4].Unsure, "watched items are always sent")
+}
+
+func TestTriage_SecondOpinionWithoutPrompt(t *testing.T) {
+	items := triageItems()
+	client := &mockOpenAIClient{}
+	asked, errs := Triage(client, items, persona.Persona{Name: "Test", Unsure: persona.Unsure{MinConfidence: 60, Action: persona.UnsureSecondOpinion}})
+
+	assert.Zero(t, asked)
+	require.Len(t, errs, 1)
+	assert.True(t, items[2].Unsure, "items stay unsure when no second opinion could be asked")
+	assert.True(t, items[3].Unsure)
+}
+
+func TestTriage_SecondOpinionNonRetryable(t *testing.T) {
+	items := triageItems()
+	calls := 0
+	client := &mockOpenAIClient{ChatCompletionFunc: func(systemPrompt string, userPrompts []string, imageURLs []string, schemaParams *openai.SchemaParameters, temperature float64, maxTokens int) openai.Result {
+		calls++
+		return openai.Result{Err: &openai.Error{Kind: openai.ErrorContextLength, Err: errors.New("maximum context length exceeded")}}
+	}}
+	p := persona.Persona{
+		Name:              "Test",
+		PersonaIdentity:   "an AI researcher",
+		Topic:             "local LLMs",
+		RelevanceCriteria: []string{"About running models locally"},
+		Unsure:            persona.Unsure{MinConfidence: 60, Action: persona.UnsureSecondOpinion},
+	}
+
+	asked, errs := Triage(client, items, p)
+	assert.Equal(t, 2, asked)
+	assert.Len(t, errs, 2)
+	assert.Equal(t, 2, calls, "errors retrying cannot fix are not retried")
+	assert.True(t, items[2].Unsure)
+}
+
+func TestNormalizeConfidence(t *testing.T) {
+	item := models.Item{ID: "a", RelevanceConfidence: 150, Unsure: true}
+	normalizeConfidence(&item)
+	assert.Zero(t, item.RelevanceConfidence)
+	assert.False(t, item.Unsure, "only triage marks items unsure")
+
+	item = models.Item{ID: "b", RelevanceConfidence: 70}
+	normalizeConfidence(&item)
+	assert.Equal(t, 70, item.RelevanceConfidence)
+}
@@ -0,0 +1,238 @@
+package llm
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
+	"github.com/bakkerme/ai-news-processor/internal/urlnorm"
+	"github.com/bakkerme/ai-news-processor/models"
+)
+
+// Kinds of violations
+const (
+	violationURL = "url"
+	violationID  = "id"
+)
+
+// urlPattern finds URLs in the text of an entry or an LLM response
+var urlPattern = regexp.MustCompile(`(?i)https?://[^\s<>"'\x60\[\]{}|\\^]+`)
+
+// emptyParens matches the brackets left behind when a URL is stripped from text
+var emptyParens = regexp.MustCompile(`\(\s*\)|\[\s*\]`)
+
+const correctionPromptTemplate = `Your response referenced URLs or IDs that are not in the post:
+%s
+The ID of the post is %q. Respond again with the full JSON response, using only URLs that appear in the post, its linked pages or its comments.`
+
+// knownURLs collects the URLs an entry gave the LLM, normalized with normalizeURL
+func knownURLs(entry feeds.Entry) map[string]struct{} {
+	known := make(map[string]struct{})
+	add := func(u string) {
+		if u != "" {
+			known[normalizeURL(u)] = struct{}{}
+		}
+	}
+	add(entry.Link.Href)
+	for _, u := range entry.ExternalURLs {
+		add(u.String())
+	}
+	for _, u := range entry.ImageURLs {
+		add(u.String())
+	}
+	texts := []string{entry.Content, entry.ImageDescription}
+	for u, summary := range entry.WebContentSummaries {
+		add(u)
+		texts = append(texts, summary)
+	}
+	for _, comment := range entry.Comments {
+		texts = append(texts, comment.Content)
+	}
+	for _, text := range texts {
+		for _, u := range urlPattern.FindAllString(text, -1) {
+			add(trimURL(u))
+		}
+	}
+	return known
+}
+
+// trimURL drops the punctuation that ends the sentence a URL is in
+func trimURL(u string) string {
+	return strings.TrimRight(u, ".,;:!?)*_")
+}
+
+// normalizeURL reduces a URL to what identifies it, so a response may switch the scheme, drop
+// "www.", a trailing slash or tracking parameters and still match its input
+func normalizeURL(u string) string {
+	u = strings.ToLower(urlnorm.Key(trimURL(strings.TrimSpace(u))))
+	u = strings.TrimPrefix(strings.TrimPrefix(u, "https://"), "http://")
+	u = strings.TrimPrefix(u, "www.")
+	return strings.TrimRight(u, "/")
+}
+
+// isKnownURL reports whether u is one of the known URLs, or a shortened form of one, such as a
+// repository URL cited without the path of a file in it
+func isKnownURL(u string, known map[string]struct{}) bool {
+	normalized := normalizeURL(u)
+	if _, ok := known[normalized]; ok {
+		return true
+	}
+	for k := range known {
+		if strings.HasPrefix(k, normalized+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// findViolations returns the URLs and IDs in an item that are not in the entry it was processed
+// from, without changing the item
+func findViolations(item models.Item, entry feeds.Entry) []models.Violation {
+	var violations []models.Violation
+	if item.ID != "" && strings.TrimPrefix(item.ID, "t3_") != entry.ID {
+		violations = append(violations, models.Violation{EntryID: entry.ID, Field: "id", Kind: violationID, Value: item.ID})
+	}
+
+	known := knownURLs(entry)
+	check := func(field, text string) {
+		for _, u := range urlPattern.FindAllString(text, -1) {
+			if u = trimURL(u); !isKnownURL(u, known) {
+				violations = append(violations, models.Violation{EntryID: entry.ID, Field: field, Kind: violationURL, Value: u})
+			}
+		}
+	}
+	check("summary", item.Summary)
+	check("commentSummary", item.CommentSummary)
+	check("relevanceToCriteria", item.RelevanceToCriteria)
+	check("importanceReason", item.ImportanceReason)
+	for _, line := range item.Overview {
+		check("overview", line)
+	}
+	for _, claim := range item.Claims {
+		check("claims", claim.Claim)
+		if claim.URL != "" && !isKnownURL(claim.URL, known) {
+			violations = append(violations, models.Violation{EntryID: entry.ID, Field: "claims", Kind: violationURL, Value: claim.URL})
+		}
+	}
+	return violations
+}
+
+// stripViolations removes the made-up URLs of an item and replaces a made-up ID with the ID of its
+// entry. It records the action taken in each violation.
+func stripViolations(item *models.Item, entry feeds.Entry, violations []models.Violation) {
+	known := knownURLs(entry)
+	strip := func(text string) string {
+		stripped := urlPattern.ReplaceAllStringFunc(text, func(u string) string {
+			trimmed := trimURL(u)
+			if isKnownURL(trimmed, known) {
+				return u
+			}
+			return u[len(trimmed):]
+		})
+		if stripped == text {
+			return text
+		}
+		stripped = emptyParens.ReplaceAllString(stripped, "")
+		return strings.Join(strings.Fields(stripped), " ")
+	}
+
+	for i := range violations {
+		violations[i].Action = models.ViolationStripped
+		if violations[i].Kind == violationID {
+			violations[i].Action = models.ViolationReplaced
+		}
+	}
+
+	item.ID = entry.ID
+	item.Summary = strip(item.Summary)
+	item.CommentSummary = strip(item.CommentSummary)
+	item.RelevanceToCriteria = strip(item.RelevanceToCriteria)
+	item.ImportanceReason = strip(item.ImportanceReason)
+	for i, line := range item.Overview {
+		item.Overview[i] = strip(line)
+	}
+	for i, claim := range item.Claims {
+		item.Claims[i].Claim = strip(claim.Claim)
+		if claim.URL != "" && !isKnownURL(claim.URL, known) {
+			item.Claims[i].URL = ""
+		}
+	}
+}
+
+// correctionPrompt asks the LLM to respond again without the violations of its last response
+func correctionPrompt(entry feeds.Entry, violations []models.Violation) string {
+	var list strings.Builder
+	for _, v := range violations {
+		fmt.Fprintf(&list, "- %s %q in %s\n", v.Kind, v.Value, v.Field)
+	}
+	return fmt.Sprintf(correctionPromptTemplate, list.String(), entry.ID)
+}
+
+// verifyItem checks the URLs and IDs of an item processed from entry. With corrective re-prompts
+// enabled, an item with violations is requested once more, and the new response is used if it has
+// fewer of them. Whatever violations remain are stripped. It returns the verified item and its
+// violations.
+func (p *Processor) verifyItem(item models.Item, entry feeds.Entry, reprompt func(correction string) (models.Item, error)) (models.Item, []models.Violation) {
+	violations := findViolations(item, entry)
+	if len(violations) == 0 {
+		return item, nil
+	}
+	log.Printf("warning: the response for entry %s has %d URLs or IDs that are not in the entry\n", entry.ID, len(violations))
+
+	var fixed []models.Violation
+	if p.config.VerifyReprompt && reprompt != nil {
+		corrected, err := reprompt(correctionPrompt(entry, violations))
+		if err != nil {
+			log.Printf("warning: could not re-prompt entry %s: %v\n", entry.ID, err)
+		} else if remaining := findViolations(corrected, entry); len(remaining) < len(violations) {
+			for _, v := range violations {
+				if !containsViolation(remaining, v) {
+					v.Action = models.ViolationReprompted
+					fixed = append(fixed, v)
+				}
+			}
+			item, violations = corrected, remaining
+		}
+	}
+
+	stripViolations(&item, entry, violations)
+	return item, append(fixed, violations...)
+}
+
+// containsViolation reports whether violations holds one with the field, kind and value of v
+func containsViolation(violations []models.Violation, v models.Violation) bool {
+	for _, other := range violations {
+		if other.Field == v.Field && other.Kind == v.Kind && other.Value == v.Value {
+			return true
+		}
+	}
+	return false
+}
+
+// VerifySummary clears the item IDs of key developments that refer to none of the items, so the
+// email does not link to a story that is not in it. It returns what was cleared.
+func VerifySummary(summary *models.SummaryResponse, items []models.Item) []models.Violation {
+	if summary == nil {
+		return nil
+	}
+	ids := make(map[string]struct{}, len(items))
+	for _, item := range items {
+		ids[strings.TrimPrefix(item.ID, "t3_")] = struct{}{}
+	}
+
+	var violations []models.Violation
+	for i, development := range summary.KeyDevelopments {
+		if development.ItemID == "" {
+			continue
+		}
+		if _, ok := ids[strings.TrimPrefix(development.ItemID, "t3_")]; ok {
+			continue
+		}
+		log.Printf("warning: key development %q refers to unknown item %s\n", development.Text, development.ItemID)
+		violations = append(violations, models.Violation{Field: "keyDevelopments", Kind: violationID, Value: development.ItemID, Action: models.ViolationStripped})
+		summary.KeyDevelopments[i].ItemID = ""
+	}
+	return violations
+}
@@ -0,0 +1,101 @@
+package llm
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
+	"github.com/bakkerme/ai-news-processor/internal/openai"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func verifyTestEntry() feeds.Entry {
+	paper, _ := url.Parse("https://arxiv.org/abs/2501.00001")
+	return feeds.Entry{
+		ID:           "abc",
+		Title:        "New paper",
+		Link:         feeds.Link{Href: "https://www.reddit.com/r/LocalLLaMA/comments/abc/new_paper/"},
+		Content:      "Code is at https://github.com/example/model/tree/main/src.",
+		ExternalURLs: []url.URL{*paper},
+		Comments:     []feeds.EntryComments{{Content: "Weights: https://huggingface.co/example/model"}},
+	}
+}
+
+func TestFindViolations(t *testing.T) {
+	entry := verifyTestEntry()
+	item := models.Item{
+		ID:      "t3_abc",
+		Summary: "See http://arxiv.org/abs/2501.00001/ and https://github.com/example/model, or HTTPS://HUGGINGFACE.CO/example/model.",
+	}
+	assert.Empty(t, findViolations(item, entry), "scheme, case, trailing slashes and shortened URLs still match")
+
+	item.ID = "xyz"
+	item.CommentSummary = "Benchmarks are at https://example.com/made-up."
+	item.Claims = []models.Claim{{Claim: "It is fast", Source: models.ClaimSourceLink, URL: "https://blog.example.com/fast"}}
+	assert.Equal(t, []models.Violation{
+		{EntryID: "abc", Field: "id", Kind: "id", Value: "xyz"},
+		{EntryID: "abc", Field: "commentSummary", Kind: "url", Value: "https://example.com/made-up"},
+		{EntryID: "abc", Field: "claims", Kind: "url", Value: "https://blog.example.com/fast"},
+	}, findViolations(item, entry))
+}
+
+func TestVerifyItemStripsViolations(t *testing.T) {
+	entry := verifyTestEntry()
+	processor := NewProcessor(&mockOpenAIClient{}, &mockOpenAIClient{}, EntryProcessConfig{}, &mockArticleExtractor{}, &mockFetcher{}, &mockURLExtractor{}, &mockImageFetcher{})
+	item := models.Item{
+		ID:       "xyz",
+		Summary:  "The paper (https://example.com/made-up) is at https://arxiv.org/abs/2501.00001.",
+		Overview: []string{"Read more at https://example.com/other."},
+	}
+
+	verified, violations := processor.verifyItem(item, entry, nil)
+	assert.Equal(t, "abc", verified.ID)
+	assert.Equal(t, "The paper is at https://arxiv.org/abs/2501.00001.", verified.Summary)
+	assert.Equal(t, []string{"Read more at ."}, verified.Overview)
+	require.Len(t, violations, 3)
+	assert.Equal(t, models.ViolationReplaced, violations[0].Action)
+	assert.Equal(t, models.ViolationStripped, violations[1].Action)
+}
+
+func TestProcessEntryRepromptsViolations(t *testing.T) {
+	var prompts [][]string
+	client := &mockOpenAIClient{
+		ChatCompletionFunc: func(systemPrompt string, userPrompts []string, imageURLs []string, schemaParams *openai.SchemaParameters, temperature float64, maxTokens int) openai.Result {
+			prompts = append(prompts, userPrompts)
+			if len(prompts) == 1 {
+				return openai.Result{Content: `{"id": "abc", "summary": "Details at https://example.com/made-up", "isRelevant": true}`}
+			}
+			return openai.Result{Content: `{"id": "abc", "summary": "Details are in the paper", "isRelevant": true}`}
+		},
+	}
+	config := EntryProcessConfig{InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, BackoffFactor: 1, VerifyReprompt: true}
+	processor := NewProcessor(client, client, config, &mockArticleExtractor{}, &mockFetcher{}, &mockURLExtractor{}, &mockImageFetcher{})
+
+	item, _, err := processor.processEntryWithRetry("system", verifyTestEntry(), persona.Persona{Name: "AI"})
+	require.NoError(t, err)
+	assert.Equal(t, "Details are in the paper", item.Summary)
+	require.Len(t, prompts, 2)
+	assert.True(t, strings.Contains(prompts[1][len(prompts[1])-1], `url "https://example.com/made-up" in summary`))
+	assert.Equal(t, []models.Violation{
+		{EntryID: "abc", Field: "summary", Kind: "url", Value: "https://example.com/made-up", Action: models.ViolationReprompted},
+	}, processor.violations)
+}
+
+func TestVerifySummary(t *testing.T) {
+	items := []models.Item{{ID: "a"}, {ID: "b"}}
+	summary := &models.SummaryResponse{KeyDevelopments: []models.KeyDevelopment{
+		{Text: "Real", ItemID: "t3_a"},
+		{Text: "Made up", ItemID: "zzz"},
+	}}
+
+	violations := VerifySummary(summary, items)
+	assert.Equal(t, "t3_a", summary.KeyDevelopments[0].ItemID)
+	assert.Empty(t, summary.KeyDevelopments[1].ItemID)
+	assert.Equal(t, []models.Violation{{Field: "keyDevelopments", Kind: "id", Value: "zzz", Action: models.ViolationStripped}}, violations)
+	assert.Nil(t, VerifySummary(nil, items))
+}
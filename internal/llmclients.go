@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/openai"
+	"github.com/bakkerme/ai-news-processor/internal/specification"
+)
+
+// newLLMClient creates the client for a model. With ANP_DEBUG_LLM_REPLAY_DIR set, responses are
+// served from recordings instead of the LLM; with ANP_DEBUG_LLM_RECORD_DIR set, every response is
+// recorded for later replay. Unless ANP_LLM_CACHE_TTL_HOURS is 0, responses are cached in the state
+// directory so identical requests in later runs are not paid for again. Structured output is
+// requested as ANP_LLM_RESPONSE_FORMAT says, and reasoning as the ANP_LLM_REASONING rule of the model
+// says.
+func newLLMClient(s *specification.Specification, model string) (openai.OpenAIClient, error) {
+	if s.DebugLLMReplayDir != "" {
+		replay, err := openai.NewReplayClient(s.DebugLLMReplayDir, model)
+		if err != nil {
+			return nil, err
+		}
+		return replay, nil
+	}
+
+	format, err := openai.ParseResponseFormat(s.LlmResponseFormat)
+	if err != nil {
+		return nil, err
+	}
+	reasoning, err := openai.ParseReasoning(s.LlmReasoning)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ANP_LLM_REASONING: %w", err)
+	}
+	apiClient := openai.NewWithSafeTimeouts(s.LlmUrl, s.LlmApiKey, model)
+	apiClient.SetResponseFormat(format, s.LlmSchemaStrict)
+	apiClient.SetReasoning(reasoning.For(model))
+
+	var client openai.OpenAIClient = apiClient
+	if s.LlmCacheTTLHours > 0 {
+		cache, err := openai.NewCachingClient(client, filepath.Join(s.SentLogBasePath, "llm_cache"), time.Duration(s.LlmCacheTTLHours)*time.Hour)
+		if err != nil {
+			return nil, err
+		}
+		if removed, err := cache.Prune(); err != nil {
+			log.Printf("Could not prune the LLM response cache: %v", err)
+		} else if removed > 0 {
+			log.Printf("Removed %d expired responses from the LLM response cache", removed)
+		}
+		client = cache
+	}
+	if s.DebugLLMRecordDir != "" {
+		recorder, err := openai.NewRecordingClient(client, s.DebugLLMRecordDir)
+		if err != nil {
+			return nil, err
+		}
+		return recorder, nil
+	}
+	return client, nil
+}
+
+// usageOf returns the token usage of a client, or no usage if the client does not track it
+func usageOf(client openai.OpenAIClient) openai.Usage {
+	if reporter, ok := client.(openai.UsageReporter); ok {
+		return reporter.Usage()
+	}
+	return openai.Usage{}
+}
@@ -0,0 +1,69 @@
+// Package megathread detects live threads and megathreads: release threads, daily discussions and
+// other posts whose value is in hundreds of comments rather than in the post. Summarized like any
+// other post, their summary retells a one-line post and misses the discussion.
+package megathread
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+)
+
+// DefaultTitlePatterns are the titles of megathreads, for personas that do not list their own
+var DefaultTitlePatterns = []string{
+	`\bmega ?thread\b`,
+	`\blive ?thread\b`,
+	`\b(daily|weekly|monthly) (discussion|thread|chat)\b`,
+	`\b(discussion|release|official) thread\b`,
+}
+
+// DefaultMinComments is the number of comments from which any post counts as a megathread
+const DefaultMinComments = 100
+
+// Detector tells megathreads apart from other posts. A nil Detector detects none.
+type Detector struct {
+	titles      []*regexp.Regexp
+	minComments int
+}
+
+// New compiles the megathread settings of a persona. It returns nil if the persona disabled them.
+func New(m persona.Megathreads) (*Detector, error) {
+	if m.Disabled {
+		return nil, nil
+	}
+
+	d := &Detector{minComments: m.MinComments}
+	if d.minComments == 0 {
+		d.minComments = DefaultMinComments
+	}
+	patterns := m.TitlePatterns
+	if len(patterns) == 0 {
+		patterns = DefaultTitlePatterns
+	}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid megathread title pattern '%s': %w", pattern, err)
+		}
+		d.titles = append(d.titles, re)
+	}
+	return d, nil
+}
+
+// Reason returns why an entry is a megathread, or an empty string if it is not
+func (d *Detector) Reason(entry feeds.Entry) string {
+	if d == nil {
+		return ""
+	}
+	for _, re := range d.titles {
+		if re.MatchString(entry.Title) {
+			return fmt.Sprintf("title matches %s", re.String()[len("(?i)"):])
+		}
+	}
+	if d.minComments > 0 && len(entry.Comments) >= d.minComments {
+		return fmt.Sprintf("%d comments", len(entry.Comments))
+	}
+	return ""
+}
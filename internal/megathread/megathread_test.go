@@ -0,0 +1,49 @@
+package megathread
+
+import (
+	"testing"
+
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func withComments(title string, n int) feeds.Entry {
+	return feeds.Entry{Title: title, Comments: make([]feeds.EntryComments, n)}
+}
+
+func TestDetector_Defaults(t *testing.T) {
+	d, err := New(persona.Megathreads{})
+	require.NoError(t, err)
+
+	assert.Equal(t, `title matches \bmega ?thread\b`, d.Reason(withComments("Qwen 3 Release MEGATHREAD", 3)))
+	assert.NotEmpty(t, d.Reason(withComments("Daily Discussion - March 7", 0)))
+	assert.NotEmpty(t, d.Reason(withComments("Official release thread for Llama 4", 0)))
+	assert.Equal(t, "120 comments", d.Reason(withComments("New quantization method", 120)))
+	assert.Empty(t, d.Reason(withComments("New quantization method", 99)))
+	assert.Empty(t, d.Reason(withComments("Threadripper build for inference", 5)))
+}
+
+func TestDetector_Custom(t *testing.T) {
+	d, err := New(persona.Megathreads{TitlePatterns: []string{`^what are you running`}, MinComments: -1})
+	require.NoError(t, err)
+
+	assert.NotEmpty(t, d.Reason(withComments("What are you running this week?", 0)))
+	assert.Empty(t, d.Reason(withComments("Weekly megathread", 0)), "custom patterns replace the defaults")
+	assert.Empty(t, d.Reason(withComments("New quantization method", 5000)), "-1 disables the comment count")
+
+	d, err = New(persona.Megathreads{MinComments: 20})
+	require.NoError(t, err)
+	assert.NotEmpty(t, d.Reason(withComments("New quantization method", 20)))
+}
+
+func TestDetector_Disabled(t *testing.T) {
+	d, err := New(persona.Megathreads{Disabled: true, TitlePatterns: []string{"("}})
+	require.NoError(t, err)
+	assert.Nil(t, d)
+	assert.Empty(t, d.Reason(withComments("Weekly megathread", 500)), "a nil detector detects nothing")
+
+	_, err = New(persona.Megathreads{TitlePatterns: []string{"(daily"}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,117 @@
+package internal
+
+import (
+	"log"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
+	"github.com/bakkerme/ai-news-processor/internal/itemstore"
+	"github.com/bakkerme/ai-news-processor/internal/llm"
+	"github.com/bakkerme/ai-news-processor/internal/openai"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/internal/prompts"
+	"github.com/bakkerme/ai-news-processor/internal/runreport"
+)
+
+// megathreadActiveWindow is how long after it was sent a megathread is summarized again. Live
+// threads and daily discussions rarely carry on for longer.
+const megathreadActiveWindow = 24 * time.Hour
+
+// refreshMegathreads summarizes the megathreads sent to each persona over the last
+// megathreadActiveWindow again from their latest comments, and stores the new summaries in place
+// of the sent ones. Nothing is sent; the dashboard and API show the new summaries.
+func refreshMegathreads(client openai.OpenAIClient, config llm.EntryProcessConfig, store *itemstore.Store, personas []persona.Persona, createProvider func(string, string) (feeds.FeedProvider, error), report *runreport.Report) {
+	since := time.Now().Add(-megathreadActiveWindow)
+
+	for _, p := range personas {
+		if p.Megathreads.Disabled {
+			continue
+		}
+		records, err := store.Since(p.Name, since)
+		if err != nil {
+			log.Printf("Could not load stored items for persona %s: %v\n", p.Name, err)
+			report.Persona(p.Name).Fail("load stored items: %v", err)
+			continue
+		}
+		sent := make(map[string]itemstore.Record)
+		for _, record := range records {
+			if record.Item.Megathread {
+				sent[record.Item.ID] = record
+			}
+		}
+		if len(sent) == 0 {
+			continue
+		}
+
+		personaReport := report.Persona(p.Name)
+		prompt, err := prompts.ComposeMegathreadPrompt(p)
+		if err != nil {
+			log.Printf("Could not compose megathread prompt for persona %s: %v\n", p.Name, err)
+			personaReport.FailWith(runreport.CauseConfig, "compose megathread prompt: %v", err)
+			continue
+		}
+		provider, err := createProvider(p.GetProvider(), p.Name)
+		if err != nil {
+			log.Printf("Could not create feed provider for persona %s: %v\n", p.Name, err)
+			personaReport.FailWith(runreport.CauseConfig, "create feed provider: %v", err)
+			continue
+		}
+
+		var entries []feeds.Entry
+		for _, record := range records {
+			if _, ok := sent[record.Item.ID]; !ok {
+				continue
+			}
+			entry := feeds.Entry{
+				ID:          record.Item.ID,
+				Title:       record.Item.Title,
+				Link:        feeds.Link{Href: record.Item.Link},
+				Published:   record.Item.Entry.Published,
+				CanonicalID: record.Item.CanonicalID,
+			}
+			entry.Comments, err = feeds.FetchEntryComments(provider, entry)
+			if err != nil {
+				log.Printf("Could not fetch the comments of megathread %s: %v\n", entry.ID, err)
+				personaReport.Error(err)
+				continue
+			}
+			entries = append(entries, entry)
+		}
+		personaReport.Fetched = len(entries)
+		if len(entries) == 0 {
+			personaReport.FailWith(runreport.CauseFeed, "fetch comments: none of the %d megathreads could be fetched", len(sent))
+			continue
+		}
+
+		log.Printf("Summarizing %d megathreads of persona %s again\n", len(entries), p.Name)
+		processor := llm.NewProcessor(client, client, config, nil, nil, nil, nil)
+		items, _, err := processor.ProcessEntries(prompt, entries, p)
+		if err != nil {
+			log.Printf("Could not summarize the megathreads of persona %s: %v\n", p.Name, err)
+			personaReport.FailWith(runreport.CauseLLM, "summarize megathreads: %v", err)
+			continue
+		}
+
+		refreshedAt := time.Now()
+		for _, item := range items {
+			record, ok := sent[item.ID]
+			if !ok {
+				continue
+			}
+			refreshed := record.Item
+			refreshed.Overview = item.Overview
+			refreshed.Summary = item.Summary
+			refreshed.CommentSummary = item.CommentSummary
+			refreshed.Topics = item.Topics
+			refreshed.Entities = item.Entities
+			refreshed.Claims = item.Claims
+			if err := store.Refresh(p.Name, refreshed, record.SentAt, refreshedAt); err != nil {
+				log.Printf("Could not store the refreshed megathread %s: %v\n", item.ID, err)
+				personaReport.Error(err)
+				continue
+			}
+			personaReport.Processed++
+		}
+		log.Printf("Refreshed %d of %d megathreads of persona %s\n", personaReport.Processed, len(sent), p.Name)
+	}
+}
@@ -0,0 +1,153 @@
+// Package metricsexport pushes per-run aggregates to a time series database in InfluxDB line
+// protocol, which InfluxDB and VictoriaMetrics both accept, for trend dashboards over longer
+// periods than the local run history keeps.
+package metricsexport
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/runreport"
+)
+
+// Measurement is the name all points are written under
+const Measurement = "ai_news_processor"
+
+// Exporter writes run metrics to a line protocol write endpoint
+type Exporter struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// New creates an exporter writing to url, such as http://victoria:8428/write or
+// http://influx:8086/api/v2/write?org=home&bucket=news. If token is not empty it is sent as an
+// InfluxDB API token; basic auth credentials can be included in the URL.
+func New(url, token string) *Exporter {
+	return &Exporter{
+		url:    url,
+		token:  token,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Export writes the metrics of a finished run. precision holds the reader precision per persona,
+// for personas whose digests readers have rated.
+func (e *Exporter) Export(report *runreport.Report, precision map[string]float64) error {
+	body := Lines(report, precision)
+	req, err := http.NewRequest(http.MethodPost, e.url, strings.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create metrics request: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if e.token != "" {
+		req.Header.Set("Authorization", "Token "+e.token)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send metrics: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("metrics endpoint returned status %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// Lines renders a run as line protocol: one point per persona and one for the whole run, all
+// timestamped with the start of the run
+func Lines(report *runreport.Report, precision map[string]float64) string {
+	var b strings.Builder
+	timestamp := strconv.FormatInt(report.StartedAt.UnixNano(), 10)
+
+	for _, p := range report.Personas {
+		fields := []field{
+			intField("fetched", p.Fetched),
+			intField("filtered", p.Filtered),
+			intField("processed", p.Processed),
+			intField("relevant", p.Relevant),
+			intField("sent", p.Sent),
+			intField("dropped", len(p.Dropped)),
+			intField("failures", len(p.Failures)),
+			intField("llm_calls", p.Usage.Calls),
+			{"prompt_tokens", strconv.FormatInt(p.Usage.PromptTokens, 10) + "i"},
+			{"completion_tokens", strconv.FormatInt(p.Usage.CompletionTokens, 10) + "i"},
+			floatField("cost", report.Pricing.Cost(p.Usage)),
+		}
+		if score, ok := precision[p.Name]; ok {
+			fields = append(fields, floatField("reader_precision", score))
+		}
+		writeLine(&b, map[string]string{"scope": "persona", "persona": p.Name}, fields, timestamp)
+	}
+
+	sent := 0
+	for _, p := range report.Personas {
+		sent += p.Sent
+	}
+	var promptTokens, completionTokens int64
+	for _, u := range report.Usage {
+		promptTokens += u.PromptTokens
+		completionTokens += u.CompletionTokens
+	}
+	writeLine(&b, map[string]string{"scope": "run"}, []field{
+		intField("personas", len(report.Personas)),
+		intField("sent", sent),
+		intField("failures", report.Failures()),
+		{"prompt_tokens", strconv.FormatInt(promptTokens, 10) + "i"},
+		{"completion_tokens", strconv.FormatInt(completionTokens, 10) + "i"},
+		floatField("cost", report.Cost()),
+		floatField("duration_seconds", report.FinishedAt.Sub(report.StartedAt).Seconds()),
+	}, timestamp)
+
+	return b.String()
+}
+
+type field struct {
+	key   string
+	value string
+}
+
+func intField(key string, value int) field {
+	return field{key, strconv.Itoa(value) + "i"}
+}
+
+func floatField(key string, value float64) field {
+	return field{key, strconv.FormatFloat(value, 'f', -1, 64)}
+}
+
+func writeLine(b *strings.Builder, tags map[string]string, fields []field, timestamp string) {
+	b.WriteString(Measurement)
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	// Sorted tags are what InfluxDB recommends for write performance
+	sort.Strings(keys)
+	for _, k := range keys {
+		b.WriteString("," + escapeTag(k) + "=" + escapeTag(tags[k]))
+	}
+	for i, f := range fields {
+		if i == 0 {
+			b.WriteString(" ")
+		} else {
+			b.WriteString(",")
+		}
+		b.WriteString(escapeTag(f.key) + "=" + f.value)
+	}
+	b.WriteString(" " + timestamp + "\n")
+}
+
+var tagEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+
+// escapeTag escapes the characters line protocol treats specially in tag keys, tag values and field keys
+func escapeTag(s string) string {
+	return tagEscaper.Replace(s)
+}
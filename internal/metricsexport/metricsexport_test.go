@@ -0,0 +1,66 @@
+package metricsexport
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/openai"
+	"github.com/bakkerme/ai-news-processor/internal/runreport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testReport() *runreport.Report {
+	start := time.Unix(1741327200, 0)
+	r := runreport.New(start, runreport.Pricing{InputPerMillion: 1, OutputPerMillion: 4})
+	r.FinishedAt = start.Add(90 * time.Second)
+
+	llama := r.Persona("Local LLaMA")
+	llama.Fetched, llama.Filtered, llama.Processed, llama.Relevant, llama.Sent = 50, 30, 30, 8, 8
+	llama.Drop("a", "A", "https://example.com/a", "already sent")
+	llama.Usage = openai.Usage{Calls: 31, PromptTokens: 500000, CompletionTokens: 50000}
+	blogs := r.Persona("Blogs")
+	blogs.Fail("fetch feed: timeout")
+
+	r.AddUsage("main", llama.Usage)
+	return r
+}
+
+func TestLines(t *testing.T) {
+	lines := Lines(testReport(), map[string]float64{"Local LLaMA": 0.75})
+
+	assert.Equal(t,
+		`ai_news_processor,persona=Local\ LLaMA,scope=persona fetched=50i,filtered=30i,processed=30i,relevant=8i,sent=8i,dropped=1i,failures=0i,llm_calls=31i,prompt_tokens=500000i,completion_tokens=50000i,cost=0.7,reader_precision=0.75 1741327200000000000`+"\n"+
+			`ai_news_processor,persona=Blogs,scope=persona fetched=0i,filtered=0i,processed=0i,relevant=0i,sent=0i,dropped=0i,failures=1i,llm_calls=0i,prompt_tokens=0i,completion_tokens=0i,cost=0 1741327200000000000`+"\n"+
+			`ai_news_processor,scope=run personas=2i,sent=8i,failures=1i,prompt_tokens=500000i,completion_tokens=50000i,cost=0.7,duration_seconds=90 1741327200000000000`+"\n",
+		lines)
+}
+
+func TestExport(t *testing.T) {
+	var body, auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		auth = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	require.NoError(t, New(server.URL+"/api/v2/write?bucket=news", "secret").Export(testReport(), nil))
+	assert.Equal(t, Lines(testReport(), nil), body)
+	assert.Equal(t, "Token secret", auth)
+}
+
+func TestExportReportsErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unable to parse points", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	err := New(server.URL, "").Export(testReport(), nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unable to parse points")
+}
@@ -0,0 +1,128 @@
+// Package notify sends push notifications through ntfy and Pushover when a persona run finishes
+// or fails, so unattended runs can be monitored from a phone.
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/internal/runreport"
+)
+
+// DefaultNtfyURL is the public ntfy server
+const DefaultNtfyURL = "https://ntfy.sh"
+
+// Message is a push notification
+type Message struct {
+	Title    string
+	Body     string
+	Priority bool // Failures are sent with high priority so they stand out
+}
+
+// Config holds the credentials for the push services. A service is used when its credentials are
+// set, globally or through the persona overrides.
+type Config struct {
+	NtfyURL       string
+	NtfyTopic     string
+	NtfyToken     string
+	PushoverToken string
+	PushoverUser  string
+}
+
+// Service sends notifications about persona runs
+type Service struct {
+	config Config
+	client *http.Client
+	// pushoverURL is the Pushover messages endpoint, overridden in tests
+	pushoverURL string
+}
+
+// NewService creates a notification service
+func NewService(config Config) *Service {
+	if config.NtfyURL == "" {
+		config.NtfyURL = DefaultNtfyURL
+	}
+	return &Service{
+		config:      config,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		pushoverURL: pushoverMessagesURL,
+	}
+}
+
+// PersonaFinished notifies about the outcome of a persona run according to the persona's notify
+// setting. cost is only mentioned when showCost is set, since it is meaningless without pricing.
+func (s *Service) PersonaFinished(p persona.Persona, report *runreport.PersonaReport, cost float64, showCost bool) error {
+	failed := len(report.Failures) > 0
+	switch p.GetNotify() {
+	case persona.NotifyOff:
+		return nil
+	case persona.NotifyFailures:
+		if !failed {
+			return nil
+		}
+	}
+	return s.Send(p, PersonaMessage(report, cost, showCost))
+}
+
+// PersonaMessage describes the outcome of a persona run
+func PersonaMessage(report *runreport.PersonaReport, cost float64, showCost bool) Message {
+	if len(report.Failures) > 0 {
+		body := strings.Join(report.Failures, "\n")
+		if showCost {
+			body += fmt.Sprintf("\nEstimated cost %.4f", cost)
+		}
+		return Message{
+			Title:    fmt.Sprintf("%s run failed", report.Name),
+			Body:     body,
+			Priority: true,
+		}
+	}
+
+	costNote := ""
+	if showCost {
+		costNote = fmt.Sprintf(", estimated cost %.4f", cost)
+	}
+	if report.Sent == 0 {
+		return Message{
+			Title: fmt.Sprintf("%s run finished", report.Name),
+			Body:  fmt.Sprintf("No new items to send (%d fetched)%s", report.Fetched, costNote),
+		}
+	}
+	items := "items"
+	if report.Sent == 1 {
+		items = "item"
+	}
+	return Message{
+		Title: fmt.Sprintf("%s digest sent", report.Name),
+		Body:  fmt.Sprintf("%d %s sent (%d fetched)%s", report.Sent, items, report.Fetched, costNote),
+	}
+}
+
+// Send delivers a message to every push service configured for the persona
+func (s *Service) Send(p persona.Persona, msg Message) error {
+	var errs []error
+	if topic := firstNonEmpty(p.NtfyTopic, s.config.NtfyTopic); topic != "" {
+		if err := s.sendNtfy(topic, msg); err != nil {
+			errs = append(errs, fmt.Errorf("ntfy: %w", err))
+		}
+	}
+	if user := firstNonEmpty(p.PushoverUser, s.config.PushoverUser); user != "" && s.config.PushoverToken != "" {
+		if err := s.sendPushover(user, msg); err != nil {
+			errs = append(errs, fmt.Errorf("pushover: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,125 @@
+package notify
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/internal/runreport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type received struct {
+	path   string
+	header http.Header
+	body   string
+}
+
+func recordingServer(t *testing.T, status int) (*httptest.Server, func() []received) {
+	var mu sync.Mutex
+	var requests []received
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, received{path: r.URL.Path, header: r.Header, body: string(body)})
+		mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []received {
+		mu.Lock()
+		defer mu.Unlock()
+		return requests
+	}
+}
+
+func TestPersonaMessage(t *testing.T) {
+	sent := &runreport.PersonaReport{Name: "LocalLLaMA", Fetched: 40, Sent: 8}
+	msg := PersonaMessage(sent, 0.01234, true)
+	assert.Equal(t, "LocalLLaMA digest sent", msg.Title)
+	assert.Equal(t, "8 items sent (40 fetched), estimated cost 0.0123", msg.Body)
+	assert.False(t, msg.Priority)
+
+	empty := &runreport.PersonaReport{Name: "LocalLLaMA", Fetched: 40}
+	assert.Equal(t, "No new items to send (40 fetched)", PersonaMessage(empty, 0, false).Body)
+
+	failed := &runreport.PersonaReport{Name: "Blogs", Failures: []string{"fetch feed: timeout"}}
+	msg = PersonaMessage(failed, 0.5, true)
+	assert.Equal(t, "Blogs run failed", msg.Title)
+	assert.Equal(t, "fetch feed: timeout\nEstimated cost 0.5000", msg.Body)
+	assert.True(t, msg.Priority)
+}
+
+func TestSendNtfy(t *testing.T) {
+	server, requests := recordingServer(t, http.StatusOK)
+	s := NewService(Config{NtfyURL: server.URL + "/", NtfyTopic: "news", NtfyToken: "tk_secret"})
+
+	require.NoError(t, s.Send(persona.Persona{Name: "LocalLLaMA"}, Message{Title: "Title", Body: "Body", Priority: true}))
+	require.NoError(t, s.Send(persona.Persona{Name: "Blogs", NtfyTopic: "blogs"}, Message{Title: "Other", Body: "Body"}))
+
+	got := requests()
+	require.Len(t, got, 2)
+	assert.Equal(t, "/news", got[0].path)
+	assert.Equal(t, "Title", got[0].header.Get("Title"))
+	assert.Equal(t, "high", got[0].header.Get("Priority"))
+	assert.Equal(t, "Bearer tk_secret", got[0].header.Get("Authorization"))
+	assert.Equal(t, "Body", got[0].body)
+	assert.Equal(t, "/blogs", got[1].path, "the persona topic overrides the global one")
+	assert.Empty(t, got[1].header.Get("Priority"))
+}
+
+func TestSendPushover(t *testing.T) {
+	server, requests := recordingServer(t, http.StatusOK)
+	s := NewService(Config{PushoverToken: "app", PushoverUser: "user"})
+	s.pushoverURL = server.URL
+
+	require.NoError(t, s.Send(persona.Persona{Name: "LocalLLaMA"}, Message{Title: "Title", Body: "Body", Priority: true}))
+
+	got := requests()
+	require.Len(t, got, 1)
+	form, err := url.ParseQuery(got[0].body)
+	require.NoError(t, err)
+	assert.Equal(t, "app", form.Get("token"))
+	assert.Equal(t, "user", form.Get("user"))
+	assert.Equal(t, "Title", form.Get("title"))
+	assert.Equal(t, "Body", form.Get("message"))
+	assert.Equal(t, "1", form.Get("priority"))
+}
+
+func TestSendReportsServiceErrors(t *testing.T) {
+	server, _ := recordingServer(t, http.StatusForbidden)
+	s := NewService(Config{NtfyURL: server.URL, NtfyTopic: "news"})
+
+	err := s.Send(persona.Persona{Name: "LocalLLaMA"}, Message{Title: "Title"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ntfy")
+	assert.Contains(t, err.Error(), "403")
+}
+
+func TestPersonaFinishedRespectsNotifySetting(t *testing.T) {
+	server, requests := recordingServer(t, http.StatusOK)
+	s := NewService(Config{NtfyURL: server.URL, NtfyTopic: "news"})
+
+	ok := &runreport.PersonaReport{Name: "LocalLLaMA", Sent: 3}
+	failed := &runreport.PersonaReport{Name: "LocalLLaMA", Failures: []string{"send email: refused"}}
+
+	require.NoError(t, s.PersonaFinished(persona.Persona{Name: "LocalLLaMA"}, ok, 0, false))
+	require.NoError(t, s.PersonaFinished(persona.Persona{Name: "LocalLLaMA", Notify: persona.NotifyFailures}, ok, 0, false))
+	require.NoError(t, s.PersonaFinished(persona.Persona{Name: "LocalLLaMA", Notify: persona.NotifyFailures}, failed, 0, false))
+	require.NoError(t, s.PersonaFinished(persona.Persona{Name: "LocalLLaMA", Notify: persona.NotifyOff}, failed, 0, false))
+
+	got := requests()
+	require.Len(t, got, 2)
+	assert.Equal(t, "LocalLLaMA digest sent", got[0].header.Get("Title"))
+	assert.Equal(t, "LocalLLaMA run failed", got[1].header.Get("Title"))
+}
+
+func TestSendWithoutServicesIsNoop(t *testing.T) {
+	s := NewService(Config{PushoverUser: "user"})
+	assert.NoError(t, s.Send(persona.Persona{Name: "LocalLLaMA"}, Message{Title: "Title"}))
+}
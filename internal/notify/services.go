@@ -0,0 +1,63 @@
+package notify
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// pushoverMessagesURL is the Pushover API endpoint for sending messages
+const pushoverMessagesURL = "https://api.pushover.net/1/messages.json"
+
+// sendNtfy publishes a message to an ntfy topic, see https://docs.ntfy.sh/publish/
+func (s *Service) sendNtfy(topic string, msg Message) error {
+	endpoint := strings.TrimSuffix(s.config.NtfyURL, "/") + "/" + url.PathEscape(topic)
+	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(msg.Body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Title", msg.Title)
+	if msg.Priority {
+		req.Header.Set("Priority", "high")
+		req.Header.Set("Tags", "warning")
+	}
+	if s.config.NtfyToken != "" {
+		req.Header.Set("Authorization", "Bearer "+s.config.NtfyToken)
+	}
+	return s.do(req)
+}
+
+// sendPushover sends a message to a Pushover user, see https://pushover.net/api
+func (s *Service) sendPushover(user string, msg Message) error {
+	form := url.Values{
+		"token":   {s.config.PushoverToken},
+		"user":    {user},
+		"title":   {msg.Title},
+		"message": {msg.Body},
+	}
+	if msg.Priority {
+		form.Set("priority", "1")
+	}
+	req, err := http.NewRequest(http.MethodPost, s.pushoverURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return s.do(req)
+}
+
+func (s *Service) do(req *http.Request) error {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("notification service returned status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
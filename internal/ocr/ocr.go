@@ -0,0 +1,93 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// MinWords is the minimum number of words recognized in an image for it to be considered
+// text-heavy. Photos and memes usually yield a handful of stray characters at most.
+const MinWords = 8
+
+// MaxChars caps the recognized text appended to an image description
+const MaxChars = 3000
+
+// Recognizer extracts text from an image
+type Recognizer interface {
+	Recognize(ctx context.Context, image []byte) (string, error)
+}
+
+// Tesseract recognizes text by running the tesseract command line tool
+type Tesseract struct {
+	path      string
+	languages string
+}
+
+// NewTesseract creates a Recognizer backed by the tesseract binary at path (looked up in PATH
+// if not absolute). languages is a tesseract language list such as "eng" or "eng+deu".
+func NewTesseract(path string, languages string) (*Tesseract, error) {
+	if path == "" {
+		path = "tesseract"
+	}
+	resolved, err := exec.LookPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("ocr: tesseract not found: %w", err)
+	}
+	if languages == "" {
+		languages = "eng"
+	}
+	return &Tesseract{path: resolved, languages: languages}, nil
+}
+
+// Recognize implements Recognizer. The image is passed on stdin and the text read from stdout.
+func (t *Tesseract) Recognize(ctx context.Context, image []byte) (string, error) {
+	cmd := exec.CommandContext(ctx, t.path, "stdin", "stdout", "-l", t.languages)
+	cmd.Stdin = bytes.NewReader(image)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("ocr: tesseract failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+var blankLines = regexp.MustCompile(`\n\s*\n+`)
+
+// Clean normalizes recognized text, trimming whitespace on each line, collapsing runs of
+// blank lines and truncating the result to MaxChars
+func Clean(text string) string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	text = strings.TrimSpace(blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+
+	if len(text) > MaxChars {
+		text = strings.ToValidUTF8(text[:MaxChars], "") + "..."
+	}
+	return text
+}
+
+// IsTextHeavy reports whether recognized text contains enough words to be worth keeping
+func IsTextHeavy(text string) bool {
+	words := 0
+	for _, field := range strings.Fields(text) {
+		// Count tokens with at least two letters or digits so OCR noise such as "|" or "~" is ignored
+		alnum := 0
+		for _, r := range field {
+			if ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') || r > 127 {
+				alnum++
+			}
+		}
+		if alnum >= 2 {
+			words++
+		}
+	}
+	return words >= MinWords
+}
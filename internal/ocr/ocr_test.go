@@ -0,0 +1,73 @@
+package ocr
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClean(t *testing.T) {
+	input := "  Model   |  MMLU \r\n\n\n\n  Llama 3 | 82.0  \n   \n GPT-4 | 86.4\n"
+	assert.Equal(t, "Model   |  MMLU\n\nLlama 3 | 82.0\n\nGPT-4 | 86.4", Clean(input))
+
+	long := strings.Repeat("a", MaxChars+100)
+	cleaned := Clean(long)
+	assert.Equal(t, MaxChars+3, len(cleaned))
+	assert.True(t, strings.HasSuffix(cleaned, "..."))
+}
+
+func TestIsTextHeavy(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected bool
+	}{
+		{"benchmark table", "Model MMLU GSM8K\nLlama 70.1 56.3\nMistral 64.2 52.1", true},
+		{"meme caption", "WHEN THE MODEL WORKS", false},
+		{"ocr noise", "| ~ . _ - | i | ~ : ; ' ` , | ~ . _", false},
+		{"empty", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, IsTextHeavy(tt.text))
+		})
+	}
+}
+
+func TestTesseract_Recognize(t *testing.T) {
+	// A stand-in for tesseract that checks its arguments and echoes stdin
+	dir := t.TempDir()
+	script := filepath.Join(dir, "tesseract")
+	require.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\n[ \"$1 $2 $3 $4\" = \"stdin stdout -l eng+deu\" ] || exit 2\ncat\n"), 0755))
+
+	recognizer, err := NewTesseract(script, "eng+deu")
+	require.NoError(t, err)
+
+	text, err := recognizer.Recognize(context.Background(), []byte("recognized text"))
+	require.NoError(t, err)
+	assert.Equal(t, "recognized text", text)
+}
+
+func TestTesseract_RecognizeError(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "tesseract")
+	require.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\necho 'Error in pixReadStream' >&2\nexit 1\n"), 0755))
+
+	recognizer, err := NewTesseract(script, "")
+	require.NoError(t, err)
+
+	_, err = recognizer.Recognize(context.Background(), []byte("not an image"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "pixReadStream")
+}
+
+func TestNewTesseract_NotFound(t *testing.T) {
+	_, err := NewTesseract(filepath.Join(t.TempDir(), "missing"), "eng")
+	assert.Error(t, err)
+}
@@ -0,0 +1,231 @@
+package openai
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/http/retry"
+)
+
+// CacheKey identifies a cached response by model and the hashes of its prompts. The user prompt
+// hash also covers the images and the parameters of the request, which change the response as much
+// as the prompt text does.
+type CacheKey struct {
+	Model            string `json:"model"`
+	SystemPromptHash string `json:"systemPromptHash"`
+	UserPromptHash   string `json:"userPromptHash"`
+}
+
+// CachedResponse is a response stored by a CachingClient
+type CachedResponse struct {
+	Key       CacheKey  `json:"key"`
+	Response  string    `json:"response"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func newCacheKey(model, systemPrompt string, userPrompts, imageURLs []string, schemaParams *SchemaParameters, temperature float64, maxTokens int) CacheKey {
+	req := newRecordedRequest(model, "", userPrompts, imageURLs, schemaParams, temperature, maxTokens)
+	req.Model = ""
+	return CacheKey{
+		Model:            model,
+		SystemPromptHash: hashString(systemPrompt),
+		UserPromptHash:   req.key(),
+	}
+}
+
+// fileName returns the name of the file the response to the key is stored in
+func (k CacheKey) fileName() string {
+	return hashString(k.Model + "\n" + k.SystemPromptHash + "\n" + k.UserPromptHash)
+}
+
+func hashString(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// Discarder is implemented by clients that keep responses for later requests, such as
+// CachingClient
+type Discarder interface {
+	Discard(result Result)
+}
+
+// Discard tells client that result could not be used, such as a response that is not valid JSON,
+// so a response cache does not serve it again when the request is retried or repeated by a later
+// run. Clients that keep no responses ignore it.
+func Discard(client OpenAIClient, result Result) {
+	if discarder, ok := client.(Discarder); ok {
+		discarder.Discard(result)
+	}
+}
+
+// CachingClient wraps a client and serves identical requests from responses stored in a directory,
+// so re-runs do not pay for completions they already have. Responses older than the TTL are
+// requested again.
+type CachingClient struct {
+	client OpenAIClient
+	dir    string
+	ttl    time.Duration
+	now    func() time.Time
+	hits   atomic.Int64
+}
+
+// NewCachingClient creates a client that caches the responses of client in dir for ttl
+func NewCachingClient(client OpenAIClient, dir string, ttl time.Duration) (*CachingClient, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating response cache directory: %w", err)
+	}
+	return &CachingClient{client: client, dir: dir, ttl: ttl, now: time.Now}, nil
+}
+
+// ChatCompletion returns the cached response to the request, or forwards the request to the wrapped
+// client and caches its response
+func (c *CachingClient) ChatCompletion(
+	systemPrompt string,
+	userPrompts []string,
+	imageURLs []string,
+	schemaParams *SchemaParameters,
+	temperature float64,
+	maxTokens int,
+) Result {
+	key := newCacheKey(c.client.GetModelName(), systemPrompt, userPrompts, imageURLs, schemaParams, temperature, maxTokens)
+	if response, ok := c.lookup(key); ok {
+		c.hits.Add(1)
+		return Result{Content: response, Model: key.Model, Usage: Usage{CachedCalls: 1}, Cached: true, cacheFile: key.fileName()}
+	}
+
+	result := c.client.ChatCompletion(systemPrompt, userPrompts, imageURLs, schemaParams, temperature, maxTokens)
+
+	// Failures are not cached, so the next run asks again
+	if result.Err == nil {
+		if err := c.store(CachedResponse{Key: key, Response: result.Content, CreatedAt: c.now()}); err != nil {
+			log.Printf("Could not cache LLM response: %v", err)
+		} else {
+			result.cacheFile = key.fileName()
+		}
+	}
+
+	return result
+}
+
+// Discard removes a response from the cache, so the request is sent to the LLM again instead of
+// being answered with a response the caller could not use
+func (c *CachingClient) Discard(result Result) {
+	if result.cacheFile == "" {
+		return
+	}
+	if err := os.Remove(filepath.Join(c.dir, result.cacheFile+".json")); err != nil && !os.IsNotExist(err) {
+		log.Printf("Could not remove cached LLM response: %v", err)
+	}
+}
+
+// lookup returns the cached response to key, if there is one that has not expired
+func (c *CachingClient) lookup(key CacheKey) (string, bool) {
+	data, err := os.ReadFile(filepath.Join(c.dir, key.fileName()+".json"))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Could not read cached LLM response: %v", err)
+		}
+		return "", false
+	}
+
+	var cached CachedResponse
+	if err := json.Unmarshal(data, &cached); err != nil {
+		log.Printf("Could not parse cached LLM response %s: %v", key.fileName(), err)
+		return "", false
+	}
+	if cached.Key != key || c.expired(cached) {
+		return "", false
+	}
+	return cached.Response, true
+}
+
+// store writes a response to a temporary file first, so concurrent requests never read half of it
+func (c *CachingClient) store(cached CachedResponse) error {
+	data, err := json.MarshalIndent(cached, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal cached response: %w", err)
+	}
+	tmp, err := os.CreateTemp(c.dir, "response-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create cache file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	return os.Rename(tmp.Name(), filepath.Join(c.dir, cached.Key.fileName()+".json"))
+}
+
+func (c *CachingClient) expired(cached CachedResponse) bool {
+	return c.now().Sub(cached.CreatedAt) > c.ttl
+}
+
+// Prune removes the expired responses from the cache directory and returns how many it removed
+func (c *CachingClient) Prune() (int, error) {
+	files, err := os.ReadDir(c.dir)
+	if err != nil {
+		return 0, fmt.Errorf("error reading response cache directory: %w", err)
+	}
+
+	removed := 0
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(c.dir, file.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var cached CachedResponse
+		if json.Unmarshal(data, &cached) == nil && !c.expired(cached) {
+			continue
+		}
+		if err := os.Remove(path); err == nil {
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// SetRetryConfig updates the retry configuration of the wrapped client
+func (c *CachingClient) SetRetryConfig(config retry.RetryConfig) {
+	c.client.SetRetryConfig(config)
+}
+
+// PreprocessYAML extracts YAML content using the wrapped client
+func (c *CachingClient) PreprocessYAML(response string) string {
+	return c.client.PreprocessYAML(response)
+}
+
+// PreprocessJSON extracts JSON content using the wrapped client
+func (c *CachingClient) PreprocessJSON(response string) string {
+	return c.client.PreprocessJSON(response)
+}
+
+// GetModelName returns the model name of the wrapped client
+func (c *CachingClient) GetModelName() string {
+	return c.client.GetModelName()
+}
+
+// Usage returns the usage of the wrapped client, with the requests served from the cache
+func (c *CachingClient) Usage() Usage {
+	var usage Usage
+	if reporter, ok := c.client.(UsageReporter); ok {
+		usage = reporter.Usage()
+	}
+	usage.CachedCalls = int(c.hits.Load())
+	return usage
+}
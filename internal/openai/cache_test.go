@@ -0,0 +1,100 @@
+package openai
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCachingClient(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "cache")
+	inner := &countingClient{response: "cached answer"}
+	cache, err := NewCachingClient(inner, dir, time.Hour)
+	if err != nil {
+		t.Fatalf("NewCachingClient: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		result := complete(cache, "prompt", nil)
+		if result.Err != nil || result.Content != "cached answer" {
+			t.Fatalf("caching client returned %+v", result)
+		}
+		if result.Cached != (i == 1) {
+			t.Errorf("request %d: expected cached to be %v, got %+v", i, i == 1, result)
+		}
+	}
+	if inner.calls != 1 {
+		t.Errorf("expected the second request to be served from the cache, got %d calls", inner.calls)
+	}
+
+	// Another prompt or other images are a different request
+	complete(cache, "other prompt", nil)
+	complete(cache, "prompt", []string{"https://example.com/a.png"})
+	if inner.calls != 3 {
+		t.Errorf("expected 3 calls, got %d", inner.calls)
+	}
+	if usage := cache.Usage(); usage.CachedCalls != 1 {
+		t.Errorf("expected 1 cached call, got %d", usage.CachedCalls)
+	}
+
+	// A new client over the same directory reuses the responses of the earlier one
+	again, _ := NewCachingClient(inner, dir, time.Hour)
+	complete(again, "other prompt", nil)
+	if inner.calls != 3 {
+		t.Errorf("expected the response to survive a restart, got %d calls", inner.calls)
+	}
+}
+
+func TestCachingClientSkipsFailuresAndExpired(t *testing.T) {
+	dir := t.TempDir()
+	inner := &countingClient{err: errors.New("server error")}
+	cache, _ := NewCachingClient(inner, dir, time.Hour)
+
+	complete(cache, "prompt", nil)
+	inner.err, inner.response = nil, "answer"
+	if result := complete(cache, "prompt", nil); result.Content != "answer" {
+		t.Fatalf("expected failures not to be cached, got %+v", result)
+	}
+
+	cache.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	complete(cache, "prompt", nil)
+	if inner.calls != 3 {
+		t.Errorf("expected an expired response to be requested again, got %d calls", inner.calls)
+	}
+
+	cache.now = func() time.Time { return time.Now().Add(4 * time.Hour) }
+	removed, err := cache.Prune()
+	if err != nil || removed != 1 {
+		t.Errorf("expected Prune to remove 1 response, got %d: %v", removed, err)
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 0 {
+		t.Errorf("expected an empty cache directory, got %d files", len(files))
+	}
+}
+
+func TestCachingClientDiscard(t *testing.T) {
+	dir := t.TempDir()
+	inner := &countingClient{response: "not json"}
+	cache, _ := NewCachingClient(inner, dir, time.Hour)
+	recorder, _ := NewRecordingClient(cache, t.TempDir())
+
+	// A response the caller could not use is requested again, whether it was fresh or cached
+	Discard(recorder, complete(recorder, "prompt", nil))
+	result := complete(recorder, "prompt", nil)
+	if inner.calls != 2 || result.Cached {
+		t.Fatalf("expected a discarded response to be requested again, got %d calls and %+v", inner.calls, result)
+	}
+	Discard(recorder, complete(recorder, "prompt", nil))
+	inner.response = "answer"
+	if result := complete(recorder, "prompt", nil); inner.calls != 3 || result.Content != "answer" {
+		t.Errorf("expected a discarded cached response to be requested again, got %d calls and %+v", inner.calls, result)
+	}
+	if result := complete(recorder, "prompt", nil); !result.Cached {
+		t.Errorf("expected the usable response to be cached, got %+v", result)
+	}
+
+	// Clients without a cache ignore it
+	Discard(inner, Result{Content: "answer"})
+}
@@ -0,0 +1,163 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/http/retry"
+	"github.com/openai/openai-go"
+)
+
+// ErrorKind classifies why an LLM request failed, which decides whether it is worth retrying
+type ErrorKind int
+
+const (
+	ErrorUnknown       ErrorKind = iota // Unclassified failures, retried as before the taxonomy existed
+	ErrorRateLimit                      // The API asked to slow down (429)
+	ErrorContextLength                  // The prompt does not fit the model's context window
+	ErrorContentFilter                  // The prompt or response was blocked by a content policy
+	ErrorNetwork                        // The request did not complete: timeouts, resets, DNS failures
+	ErrorServer                         // The API failed (5xx) or the model was still loading
+	ErrorRequest                        // The API rejected the request: authentication, unknown model, bad parameters
+	ErrorSchema                         // The response did not parse as the requested JSON
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case ErrorRateLimit:
+		return "rate limit"
+	case ErrorContextLength:
+		return "context length"
+	case ErrorContentFilter:
+		return "content filter"
+	case ErrorNetwork:
+		return "network"
+	case ErrorServer:
+		return "server"
+	case ErrorRequest:
+		return "request"
+	case ErrorSchema:
+		return "schema"
+	default:
+		return "unknown"
+	}
+}
+
+// Retryable reports whether a request that failed this way may succeed when sent again. Prompts
+// that are too long, blocked or rejected fail the same way every time.
+func (k ErrorKind) Retryable() bool {
+	switch k {
+	case ErrorContextLength, ErrorContentFilter, ErrorRequest:
+		return false
+	default:
+		return true
+	}
+}
+
+// Error is a classified LLM request failure
+type Error struct {
+	Kind       ErrorKind
+	RetryAfter time.Duration // Wait requested by the API for rate limits, 0 if none
+	Err        error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Classify returns the kind of an LLM failure. Errors already classified keep their kind; API
+// errors are classified by status and code, and JSON errors from parsing a response are schema
+// errors.
+func Classify(err error) ErrorKind {
+	if err == nil {
+		return ErrorUnknown
+	}
+
+	var classified *Error
+	if errors.As(err, &classified) {
+		return classified.Kind
+	}
+
+	var apiErr *openai.Error
+	if errors.As(err, &apiErr) {
+		return classifyAPIError(apiErr)
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+		return ErrorSchema
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return ErrorNetwork
+	}
+
+	// OpenAI-compatible servers do not all use the same codes, so fall back to their messages
+	message := strings.ToLower(err.Error())
+	switch {
+	case isContextLengthMessage(message):
+		return ErrorContextLength
+	case isContentFilterMessage(message):
+		return ErrorContentFilter
+	case strings.Contains(message, "connection reset") || strings.Contains(message, "connection refused"):
+		return ErrorNetwork
+	}
+	return ErrorUnknown
+}
+
+// classifyAPIError classifies an error response of the API
+func classifyAPIError(err *openai.Error) ErrorKind {
+	message := strings.ToLower(err.Code + " " + err.Message)
+	switch {
+	case err.StatusCode == http.StatusTooManyRequests:
+		return ErrorRateLimit
+	case isContextLengthMessage(message):
+		return ErrorContextLength
+	case isContentFilterMessage(message):
+		return ErrorContentFilter
+	case err.StatusCode == http.StatusNotFound && strings.Contains(message, "failed to load model"):
+		return ErrorServer // The model is still loading
+	case err.StatusCode == http.StatusRequestTimeout:
+		return ErrorNetwork
+	case err.StatusCode >= 500:
+		return ErrorServer
+	case err.StatusCode >= 400:
+		return ErrorRequest
+	}
+	return ErrorUnknown
+}
+
+func isContextLengthMessage(message string) bool {
+	return strings.Contains(message, "context_length_exceeded") ||
+		strings.Contains(message, "context length") ||
+		strings.Contains(message, "maximum context") ||
+		strings.Contains(message, "too many tokens")
+}
+
+func isContentFilterMessage(message string) bool {
+	return strings.Contains(message, "content_filter") ||
+		strings.Contains(message, "content_policy") ||
+		strings.Contains(message, "content management policy")
+}
+
+// classify wraps an API call error with its kind and, for rate limits, the wait the API asked for
+func classify(err error) *Error {
+	classified := &Error{Kind: Classify(err), Err: err}
+	var apiErr *openai.Error
+	if classified.Kind == ErrorRateLimit && errors.As(err, &apiErr) && apiErr.Response != nil {
+		classified.RetryAfter = retry.GetRetryAfterDuration(apiErr.Response)
+	}
+	return classified
+}
@@ -0,0 +1,103 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openai/openai-go"
+)
+
+func TestClassify(t *testing.T) {
+	var syntaxErr *json.SyntaxError
+	jsonErr := json.Unmarshal([]byte("{not json"), &struct{}{})
+	if !errors.As(jsonErr, &syntaxErr) {
+		t.Fatalf("expected a JSON syntax error, got %v", jsonErr)
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want ErrorKind
+	}{
+		{name: "rate limit", err: &openai.Error{StatusCode: http.StatusTooManyRequests}, want: ErrorRateLimit},
+		{name: "context length code", err: &openai.Error{StatusCode: http.StatusBadRequest, Code: "context_length_exceeded"}, want: ErrorContextLength},
+		{name: "context length message", err: errors.New("This model's maximum context length is 8192 tokens"), want: ErrorContextLength},
+		{name: "content filter", err: &openai.Error{StatusCode: http.StatusBadRequest, Code: "content_filter"}, want: ErrorContentFilter},
+		{name: "server error", err: &openai.Error{StatusCode: http.StatusBadGateway}, want: ErrorServer},
+		{name: "authentication", err: &openai.Error{StatusCode: http.StatusUnauthorized}, want: ErrorRequest},
+		{name: "timeout", err: fmt.Errorf("error during API call: %w", context.DeadlineExceeded), want: ErrorNetwork},
+		{name: "connection reset", err: errors.New("read tcp: connection reset by peer"), want: ErrorNetwork},
+		{name: "malformed response", err: fmt.Errorf("could not unmarshal llm response: %w", jsonErr), want: ErrorSchema},
+		{name: "already classified", err: fmt.Errorf("entry: %w", &Error{Kind: ErrorContentFilter, Err: errors.New("blocked")}), want: ErrorContentFilter},
+		{name: "unknown", err: errors.New("something odd"), want: ErrorUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(tt.err); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestErrorKindRetryable(t *testing.T) {
+	for _, kind := range []ErrorKind{ErrorUnknown, ErrorRateLimit, ErrorNetwork, ErrorServer, ErrorSchema} {
+		if !kind.Retryable() {
+			t.Errorf("expected %s errors to be retried", kind)
+		}
+	}
+	for _, kind := range []ErrorKind{ErrorContextLength, ErrorContentFilter, ErrorRequest} {
+		if kind.Retryable() {
+			t.Errorf("expected %s errors to fail fast", kind)
+		}
+	}
+}
+
+func TestClassifyRetryAfter(t *testing.T) {
+	response := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": []string{"20"}}}
+	classified := classify(&openai.Error{StatusCode: http.StatusTooManyRequests, Response: response})
+	if classified.Kind != ErrorRateLimit || classified.RetryAfter != 20*time.Second {
+		t.Errorf("expected a rate limit with a 20s wait, got %s and %s", classified.Kind, classified.RetryAfter)
+	}
+}
+
+func TestChatCompletionClassifiesErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.Contains(r.Header.Get("Authorization"), "long-prompt"):
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"code":"context_length_exceeded","message":"maximum context length exceeded"}}`))
+		case strings.Contains(r.Header.Get("Authorization"), "blocked"):
+			w.Write([]byte(`{"id":"1","object":"chat.completion","created":0,"model":"test-model","choices":[{"index":0,"finish_reason":"content_filter","message":{"role":"assistant","content":""}}]}`))
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":{"code":"invalid_api_key","message":"invalid key"}}`))
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		key  string
+		want ErrorKind
+	}{
+		{key: "long-prompt", want: ErrorContextLength},
+		{key: "blocked", want: ErrorContentFilter},
+		{key: "bad-key", want: ErrorRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			result := New(server.URL, tt.key, "test-model").ChatCompletion("system", []string{"user"}, nil, nil, 0, 0)
+			if got := Classify(result.Err); got != tt.want {
+				t.Errorf("expected a %s error, got %s: %v", tt.want, got, result.Err)
+			}
+		})
+	}
+}
@@ -2,14 +2,14 @@ package openai
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"log"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/bakkerme/ai-news-processor/internal/customerrors"
 	"github.com/bakkerme/ai-news-processor/internal/http/retry"
+	"github.com/bakkerme/ai-news-processor/internal/jsonextract"
 	"github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
 	"github.com/openai/openai-go/packages/param"
@@ -31,7 +31,7 @@ type OpenAIClient interface {
 	// schemaParams: Optional schema parameters for response formatting (can be nil)
 	// temperature: The temperature to use for the API call
 	// maxTokens: Optional max tokens parameter to limit the response length (0 means no limit)
-	// returns: Channel that will receive the response or error
+	// returns: The response with its finish reason, usage and timing, or the error in Result.Err
 	ChatCompletion(
 		systemPrompt string,
 		userPrompts []string,
@@ -39,8 +39,7 @@ type OpenAIClient interface {
 		schemaParams *SchemaParameters,
 		temperature float64,
 		maxTokens int,
-		results chan customerrors.ErrorString,
-	)
+	) Result
 
 	// SetRetryConfig updates the retry behavior configuration
 	SetRetryConfig(config retry.RetryConfig)
@@ -73,10 +72,72 @@ var SafeOpenAIRetryConfig = retry.RetryConfig{
 	MaxTotalTimeout: 5 * time.Minute, // Stricter timeout to prevent hangs
 }
 
+// Usage holds the token usage accumulated by a client over its lifetime
+type Usage struct {
+	Calls            int
+	FailedCalls      int
+	PromptTokens     int64
+	CompletionTokens int64
+	CachedCalls      int // Requests answered from a response cache, not counted in Calls
+}
+
+// TotalTokens returns the number of prompt and completion tokens combined
+func (u Usage) TotalTokens() int64 {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+// Add returns the combined usage of u and other
+func (u Usage) Add(other Usage) Usage {
+	return Usage{
+		Calls:            u.Calls + other.Calls,
+		FailedCalls:      u.FailedCalls + other.FailedCalls,
+		PromptTokens:     u.PromptTokens + other.PromptTokens,
+		CompletionTokens: u.CompletionTokens + other.CompletionTokens,
+		CachedCalls:      u.CachedCalls + other.CachedCalls,
+	}
+}
+
+// Sub returns the usage in u that is not in other, such as the usage since an earlier snapshot
+func (u Usage) Sub(other Usage) Usage {
+	return Usage{
+		Calls:            u.Calls - other.Calls,
+		FailedCalls:      u.FailedCalls - other.FailedCalls,
+		PromptTokens:     u.PromptTokens - other.PromptTokens,
+		CompletionTokens: u.CompletionTokens - other.CompletionTokens,
+		CachedCalls:      u.CachedCalls - other.CachedCalls,
+	}
+}
+
+// Result is the outcome of a chat completion request
+type Result struct {
+	Content      string
+	FinishReason string        // Why the model stopped, such as "stop" or "length"; empty if unknown
+	Model        string        // Model that answered the request
+	Usage        Usage         // Usage of this request alone
+	Duration     time.Duration // Time the request took, including retries
+	Cached       bool          // Whether the response was served from a response cache
+	Err          error
+
+	cacheFile string // Name of the response cache file holding the response, if it was cached
+}
+
+// UsageReporter is implemented by clients that track their token usage
+type UsageReporter interface {
+	Usage() Usage
+}
+
 type Client struct {
-	client *openai.Client
-	model  string
-	retry  retry.RetryConfig
+	client  *openai.Client
+	baseURL string
+	model   string
+	retry   retry.RetryConfig
+	format  ResponseFormat // How JSON matching a schema is requested
+	strict  bool           // Whether json_schema requests ask the server to follow the schema strictly
+
+	reasoning *Reasoning // How the model is asked to reason; nil for DefaultReasoning
+
+	usageMu sync.Mutex
+	usage   Usage
 }
 
 // New creates a new OpenAI client
@@ -87,9 +148,12 @@ func New(baseURL, key, model string) *Client {
 		option.WithJSONSet("cache_set", true),
 	)
 	return &Client{
-		client: &client,
-		model:  model,
-		retry:  DefaultOpenAIRetryConfig,
+		client:  &client,
+		baseURL: baseURL,
+		model:   model,
+		retry:   DefaultOpenAIRetryConfig,
+		format:  FormatAuto,
+		strict:  true,
 	}
 }
 
@@ -101,12 +165,24 @@ func NewWithSafeTimeouts(baseURL, key, model string) *Client {
 		option.WithJSONSet("cache_set", true),
 	)
 	return &Client{
-		client: &client,
-		model:  model,
-		retry:  SafeOpenAIRetryConfig,
+		client:  &client,
+		baseURL: baseURL,
+		model:   model,
+		retry:   SafeOpenAIRetryConfig,
+		format:  FormatAuto,
+		strict:  true,
 	}
 }
 
+// Ping checks that the API at baseURL is reachable and accepts key by listing its models
+func Ping(ctx context.Context, baseURL, key string) error {
+	client := openai.NewClient(option.WithAPIKey(key), option.WithBaseURL(baseURL), option.WithMaxRetries(0))
+	if _, err := client.Models.List(ctx); err != nil {
+		return fmt.Errorf("could not reach LLM API: %w", err)
+	}
+	return nil
+}
+
 // isModelLoadingError checks if the error is specifically a 404 due to model loading
 func isModelLoadingError(err error) bool {
 	if err == nil {
@@ -126,12 +202,9 @@ func (c *Client) ChatCompletion(
 	schemaParams *SchemaParameters,
 	temperature float64,
 	maxTokens int,
-	results chan customerrors.ErrorString,
-) {
-	// Prepare messages array
-	messages := []openai.ChatCompletionMessageParamUnion{
-		openai.SystemMessage(systemPrompt),
-	}
+) Result {
+	// Prepare the user messages
+	var messages []openai.ChatCompletionMessageParamUnion
 
 	// If we have image URLs, create a message with multi-modal content
 	if len(imageURLs) > 0 {
@@ -174,60 +247,69 @@ func (c *Client) ChatCompletion(
 		currentTemperature = temperature
 	}
 
-	params := openai.ChatCompletionNewParams{
-		Model:       c.model,
-		Messages:    messages,
-		Temperature: param.NewOpt(currentTemperature),
+	shouldRetry := func(err error) bool {
+		return isModelLoadingError(err)
 	}
 
-	// Add max tokens parameter if it's greater than 0
-	if maxTokens > 0 {
-		params.MaxTokens = openai.Int(int64(maxTokens))
-	}
+	start := time.Now()
+	result := Result{Model: c.model}
 
-	if schemaParams != nil {
-		schemaParam := openai.ResponseFormatJSONSchemaJSONSchemaParam{
-			Name:        schemaParams.Name,
-			Description: openai.String(schemaParams.Description),
-			Schema:      schemaParams.Schema,
-			Strict:      openai.Bool(true),
+	// Servers that reject a response format are asked again with the next one, down to JSON in the prompt
+	format := c.initialFormat()
+	var resp *openai.ChatCompletion
+	var err error
+	for {
+		var params openai.ChatCompletionNewParams
+		var opts []option.RequestOption
+		params, opts, err = c.requestParams(systemPrompt, messages, schemaParams, format, currentTemperature, maxTokens)
+		if err != nil {
+			result.Err = &Error{Kind: ErrorRequest, Err: err}
+			return result
 		}
-		params.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{
-			OfJSONSchema: &openai.ResponseFormatJSONSchemaParam{JSONSchema: schemaParam},
+
+		ChatCompletionFn := func(ctx context.Context) (*openai.ChatCompletion, error) {
+			return c.client.Chat.Completions.New(ctx, params, opts...)
 		}
-	}
 
-	shouldRetry := func(err error) bool {
-		return isModelLoadingError(err)
-	}
+		resp, err = retry.RetryWithBackoff(context.Background(), c.retry, ChatCompletionFn, shouldRetry)
+		usage := requestUsage(resp, err)
+		result.Usage = result.Usage.Add(usage)
+		c.recordUsage(usage)
 
-	ChatCompletionFn := func(ctx context.Context) (*openai.ChatCompletion, error) {
-		return c.client.Chat.Completions.New(ctx, params)
+		if err != nil && schemaParams != nil && c.negotiates() && rejectsResponseFormat(err) {
+			if next, ok := format.fallback(); ok {
+				log.Printf("LLM endpoint %s rejected response format %s, trying %s: %v\n", c.baseURL, format, next, err)
+				format = next
+				continue
+			}
+		}
+		break
 	}
-
-	resp, err := retry.RetryWithBackoff(context.Background(), c.retry, ChatCompletionFn, shouldRetry)
+	result.Duration = time.Since(start)
 
 	if err != nil {
-		var errMsg string
 		if isModelLoadingError(err) {
-			errMsg = fmt.Sprintf("model failed to load after retries: %v", err)
+			err = fmt.Errorf("model failed to load after retries: %w", err)
 		} else {
-			errMsg = fmt.Sprintf("error during API call: %v", err)
+			err = fmt.Errorf("error during API call: %w", err)
 		}
 
-		results <- customerrors.ErrorString{
-			Value: "",
-			Err:   errors.New(errMsg),
-		}
-		return
+		result.Err = classify(err)
+		return result
+	}
+	if schemaParams != nil {
+		c.negotiate(format)
 	}
 
 	if len(resp.Choices) == 0 {
-		results <- customerrors.ErrorString{
-			Value: "",
-			Err:   fmt.Errorf("empty response from llm"),
-		}
-		return
+		result.Err = &Error{Kind: ErrorServer, Err: fmt.Errorf("empty response from llm")}
+		return result
+	}
+
+	result.FinishReason = string(resp.Choices[0].FinishReason)
+	if result.FinishReason == "content_filter" {
+		result.Err = &Error{Kind: ErrorContentFilter, Err: fmt.Errorf("response blocked by the content filter")}
+		return result
 	}
 
 	// get the entire request content for calculation of input
@@ -237,8 +319,14 @@ func (c *Client) ChatCompletion(
 	}
 	requestWordCount := len(strings.Fields(requestContent))
 
-	responseContent := resp.Choices[0].Message.Content
-	responseWordCount := len(strings.Fields(responseContent))
+	result.Content = resp.Choices[0].Message.Content
+	if stripped := c.reasoningSettings().Strip(result.Content); stripped != result.Content {
+		result.Content = strings.TrimSpace(stripped)
+	}
+	if schemaParams != nil && format == FormatPrompt {
+		result.Content = extractMarkedJSON(result.Content)
+	}
+	responseWordCount := len(strings.Fields(result.Content))
 
 	// Log token usage information
 	log.Printf("LLM Token Usage - Model: %s, Input Tokens: %d, Output Tokens: %d, Total Tokens: %d, Output Word Count: %d,  Input Word Count: %d",
@@ -250,19 +338,102 @@ func (c *Client) ChatCompletion(
 		requestWordCount,
 	)
 
-	results <- customerrors.ErrorString{
-		Value: resp.Choices[0].Message.Content,
-		Err:   nil,
+	return result
+}
+
+// requestParams builds a chat completion request, asking for JSON matching schemaParams in format.
+// Guided decoding parameters that are not part of the OpenAI API are returned as request options.
+func (c *Client) requestParams(
+	systemPrompt string,
+	messages []openai.ChatCompletionMessageParamUnion,
+	schemaParams *SchemaParameters,
+	format ResponseFormat,
+	temperature float64,
+	maxTokens int,
+) (openai.ChatCompletionNewParams, []option.RequestOption, error) {
+	// Servers that do not enforce the schema are told about it in the system prompt
+	if schemaParams != nil && (format == FormatJSONObject || format == FormatPrompt) {
+		instructions, err := schemaInstructions(schemaParams, format)
+		if err != nil {
+			return openai.ChatCompletionNewParams{}, nil, err
+		}
+		systemPrompt += instructions
+	}
+
+	params := openai.ChatCompletionNewParams{
+		Model:       c.model,
+		Messages:    append([]openai.ChatCompletionMessageParamUnion{openai.SystemMessage(systemPrompt)}, messages...),
+		Temperature: param.NewOpt(temperature),
+	}
+
+	// Add max tokens parameter if it's greater than 0
+	if maxTokens > 0 {
+		params.MaxTokens = openai.Int(int64(maxTokens))
+	}
+
+	reasoning := c.reasoningSettings()
+	params.ReasoningEffort = reasoning.reasoningEffort()
+	opts := reasoning.requestOptions()
+
+	if schemaParams != nil {
+		switch format {
+		case FormatJSONSchema:
+			schemaParam := openai.ResponseFormatJSONSchemaJSONSchemaParam{
+				Name:        schemaParams.Name,
+				Description: openai.String(schemaParams.Description),
+				Schema:      schemaParams.Schema,
+				Strict:      openai.Bool(c.strict && strictCompatible(schemaParams.Schema)),
+			}
+			params.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{
+				OfJSONSchema: &openai.ResponseFormatJSONSchemaParam{JSONSchema: schemaParam},
+			}
+		case FormatJSONObject:
+			params.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{
+				OfJSONObject: &openai.ResponseFormatJSONObjectParam{},
+			}
+		case FormatGrammar:
+			grammar, err := grammarOf(schemaParams)
+			if err != nil {
+				return openai.ChatCompletionNewParams{}, nil, err
+			}
+			opts = append(opts, option.WithJSONSet("grammar", grammar))
+		case FormatGuidedJSON:
+			opts = append(opts, option.WithJSONSet("guided_json", schemaParams.Schema))
+		}
 	}
+	return params, opts, nil
+}
+
+// requestUsage returns the usage of a single chat completion request
+func requestUsage(resp *openai.ChatCompletion, err error) Usage {
+	if err != nil || resp == nil {
+		return Usage{Calls: 1, FailedCalls: 1}
+	}
+	return Usage{Calls: 1, PromptTokens: resp.Usage.PromptTokens, CompletionTokens: resp.Usage.CompletionTokens}
+}
+
+// recordUsage adds the usage of a chat completion to the client's usage totals
+func (c *Client) recordUsage(usage Usage) {
+	c.usageMu.Lock()
+	defer c.usageMu.Unlock()
+	c.usage = c.usage.Add(usage)
+}
+
+// Usage returns the token usage of all chat completions made with this client so far
+func (c *Client) Usage() Usage {
+	c.usageMu.Lock()
+	defer c.usageMu.Unlock()
+	return c.usage
 }
 
 // PreprocessYAML extracts YAML content from the API response
 func (c *Client) PreprocessYAML(response string) string {
-	return preprocess(response, "yaml")
+	return preprocess(c.reasoningSettings().Strip(response), "yaml")
 }
 
+// PreprocessJSON extracts the JSON value from the API response
 func (c *Client) PreprocessJSON(response string) string {
-	return preprocess(response, "json")
+	return preprocess(c.reasoningSettings().Strip(response), "json")
 }
 
 // GetModelName returns the model name used by this client
@@ -272,21 +443,16 @@ func (c *Client) GetModelName() string {
 
 // preprocess extracts content of the specified format from the API response
 func preprocess(response, format string) string {
-	// Remove think tags and their contents
-	thinkStart := "<think>"
-	thinkEnd := "</think>"
-	for {
-		startIdx := strings.Index(response, thinkStart)
-		if startIdx == -1 {
-			break
-		}
-		endIdx := strings.Index(response, thinkEnd)
-		if endIdx == -1 {
-			break
-		}
-		response = response[:startIdx] + response[endIdx+len(thinkEnd):]
+	if format == "json" {
+		content, _ := jsonextract.Extract(response)
+		return content
 	}
+	return extractFenced(response, format)
+}
 
+// extractFenced extracts the content of a code block of the specified format, or returns the
+// response trimmed if it has none
+func extractFenced(response, format string) string {
 	// Find the start markers with various possible formats
 	startMarkers := []string{"```" + format, "```\n" + format, "```\r\n" + format}
 	endMarker := "```"
@@ -340,62 +506,33 @@ func preprocess(response, format string) string {
 		content = strings.TrimSpace(response)
 	}
 
-	// For JSON format, escape unescaped newlines within string values
-	if format == "json" {
-		content = escapeJSONNewlines(content)
-	}
-
 	return content
 }
 
-// escapeJSONNewlines properly escapes unescaped newlines within JSON string values
-func escapeJSONNewlines(jsonStr string) string {
-	var result strings.Builder
-	inString := false
-	escaped := false
-
-	for _, char := range jsonStr {
-		switch char {
-		case '"':
-			if !escaped {
-				inString = !inString
-			}
-			result.WriteRune(char)
-			escaped = false
-		case '\\':
-			result.WriteRune(char)
-			escaped = !escaped
-		case '\n':
-			if inString && !escaped {
-				result.WriteString("\\n")
-			} else {
-				result.WriteRune(char)
-			}
-			escaped = false
-		case '\r':
-			if inString && !escaped {
-				result.WriteString("\\r")
-			} else {
-				result.WriteRune(char)
-			}
-			escaped = false
-		case '\t':
-			if inString && !escaped {
-				result.WriteString("\\t")
-			} else {
-				result.WriteRune(char)
-			}
-			escaped = false
-		default:
-			result.WriteRune(char)
-			escaped = false
-		}
-	}
-
-	return result.String()
-}
-
 // SetRetryConfig updates the retry configuration
 func (c *Client) SetRetryConfig(config retry.RetryConfig) {
 	c.retry = config
 }
+
+// SetReasoning sets how the model is asked to reason and how its reasoning is removed from
+// responses
+func (c *Client) SetReasoning(reasoning Reasoning) {
+	c.reasoning = &reasoning
+}
+
+// reasoningSettings returns the reasoning settings of the client
+func (c *Client) reasoningSettings() Reasoning {
+	if c.reasoning == nil {
+		return DefaultReasoning
+	}
+	return *c.reasoning
+}
+
+// SetResponseFormat sets how JSON matching a schema is requested. FormatAuto starts with
+// json_schema and falls back to json_object and then JSON in the prompt when the endpoint rejects
+// a format. The format that worked is remembered for the endpoint and model for the rest of the
+// process. strict sets the strict flag of json_schema requests whose schema strict mode accepts.
+func (c *Client) SetResponseFormat(format ResponseFormat, strict bool) {
+	c.format = format
+	c.strict = strict
+}
@@ -1,6 +1,12 @@
 package openai
 
-import "testing"
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
 
 func TestPreprocessJSON(t *testing.T) {
 	client := &Client{}
@@ -70,6 +76,26 @@ func TestPreprocessJSON(t *testing.T) {
 			input:    "\t  json  \n{\"key\": \"value\"}\n```",
 			expected: "{\"key\": \"value\"}",
 		},
+		{
+			name:     "code block inside a json string",
+			input:    "```json\n{\"summary\": \"Run it with\n```\npip install x\n```\"}\n```",
+			expected: "{\"summary\": \"Run it with\\n```\\npip install x\\n```\"}",
+		},
+		{
+			name:     "json between prose",
+			input:    "Here is the {requested} object:\n{\"key\": \"value\"}\nLet me know if you need more.",
+			expected: "{\"key\": \"value\"}",
+		},
+		{
+			name:     "trailing comma and comment",
+			input:    "```json\n{\"key\": \"value\", // the value\n}\n```",
+			expected: "{\"key\": \"value\" \n}",
+		},
+		{
+			name:     "closing think tag before opening one",
+			input:    "</think><think>thought</think>{\"key\": \"value\"}",
+			expected: "{\"key\": \"value\"}",
+		},
 		{
 			name:     "empty input",
 			input:    "",
@@ -209,3 +235,66 @@ func TestNewWithSafeTimeouts(t *testing.T) {
 		t.Errorf("expected MaxRetries to be %d, got %d", SafeOpenAIRetryConfig.MaxRetries, client.retry.MaxRetries)
 	}
 }
+
+func TestClientUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.Header.Get("Authorization"), "bad-key") {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"message":"bad request"}}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"1","object":"chat.completion","created":0,"model":"test-model","choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"hi"}}],"usage":{"prompt_tokens":12,"completion_tokens":5,"total_tokens":17}}`))
+	}))
+	defer server.Close()
+
+	client := New(server.URL, "test-key", "test-model")
+	for i := 0; i < 2; i++ {
+		result := client.ChatCompletion("system", []string{"user"}, nil, nil, 0, 0)
+		if result.Err != nil {
+			t.Fatalf("unexpected error: %v", result.Err)
+		}
+		if result.Usage.Calls != 1 || result.Usage.PromptTokens != 12 || result.Usage.CompletionTokens != 5 {
+			t.Errorf("unexpected usage of a single request: %+v", result.Usage)
+		}
+		if result.Model != "test-model" || result.FinishReason != "stop" || result.Duration <= 0 {
+			t.Errorf("unexpected result metadata: %+v", result)
+		}
+	}
+
+	usage := client.Usage()
+	if usage.Calls != 2 || usage.FailedCalls != 0 {
+		t.Errorf("expected 2 successful calls, got %+v", usage)
+	}
+	if usage.PromptTokens != 24 || usage.CompletionTokens != 10 || usage.TotalTokens() != 34 {
+		t.Errorf("unexpected token totals: %+v", usage)
+	}
+
+	failing := New(server.URL, "bad-key", "test-model")
+	if result := failing.ChatCompletion("system", []string{"user"}, nil, nil, 0, 0); result.Err == nil {
+		t.Fatal("expected an error")
+	}
+	if usage := failing.Usage(); usage.Calls != 1 || usage.FailedCalls != 1 || usage.TotalTokens() != 0 {
+		t.Errorf("expected one failed call without tokens, got %+v", usage)
+	}
+}
+
+func TestClientPing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/models" || r.Header.Get("Authorization") != "Bearer good-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":{"code":"invalid_api_key","message":"invalid key"}}`))
+			return
+		}
+		w.Write([]byte(`{"object":"list","data":[{"id":"test-model","object":"model","created":0,"owned_by":"test"}]}`))
+	}))
+	defer server.Close()
+
+	if err := Ping(context.Background(), server.URL, "good-key"); err != nil {
+		t.Errorf("expected the ping to succeed, got %v", err)
+	}
+	if err := Ping(context.Background(), server.URL, "bad-key"); err == nil {
+		t.Error("expected a rejected key to fail the ping")
+	}
+}
@@ -0,0 +1,146 @@
+package openai
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/openai/openai-go/option"
+	"github.com/openai/openai-go/shared"
+)
+
+// Reasoning is how a model is asked to reason and how its reasoning is removed from responses
+type Reasoning struct {
+	Effort string   // reasoning_effort sent with every request: low, medium or high; empty leaves it to the server
+	Think  *bool    // Whether the chat template enables thinking, for models that can turn it off; nil leaves it to the model
+	Tags   []string // Names of the tags the model writes its reasoning between, removed from responses with their contents
+}
+
+// DefaultReasoning is used for models without reasoning settings. Reasoning between think tags,
+// as written by Qwen and DeepSeek models, is removed from responses.
+var DefaultReasoning = Reasoning{Tags: []string{"think"}}
+
+// ReasoningRules holds the reasoning settings of models, by model name pattern
+type ReasoningRules []reasoningRule
+
+type reasoningRule struct {
+	pattern   string
+	reasoning Reasoning
+}
+
+// ParseReasoning parses per-model reasoning settings, such as
+// "qwen3*: think=off; gpt-oss*: effort=low; *: tags=think|reasoning". Rules are separated by
+// semicolons and hold a model name pattern, matched as in path.Match, and comma-separated settings:
+//   - effort: low, medium or high
+//   - think: on or off
+//   - tags: tag names separated by |, or none
+//
+// Settings a rule leaves out are those of DefaultReasoning.
+func ParseReasoning(spec string) (ReasoningRules, error) {
+	var rules ReasoningRules
+	for _, rule := range strings.Split(spec, ";") {
+		if strings.TrimSpace(rule) == "" {
+			continue
+		}
+		pattern, settings, ok := strings.Cut(rule, ":")
+		pattern = strings.TrimSpace(pattern)
+		if !ok || pattern == "" {
+			return nil, fmt.Errorf("reasoning rule %q needs a model pattern and settings, such as qwen3*: think=off", strings.TrimSpace(rule))
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid model pattern %q: %w", pattern, err)
+		}
+
+		reasoning := DefaultReasoning
+		for _, setting := range strings.Split(settings, ",") {
+			if strings.TrimSpace(setting) == "" {
+				continue
+			}
+			key, value, _ := strings.Cut(setting, "=")
+			key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+			switch key {
+			case "effort":
+				switch value = strings.ToLower(value); value {
+				case "low", "medium", "high":
+					reasoning.Effort = value
+				default:
+					return nil, fmt.Errorf("reasoning effort for %s must be low, medium or high, got %q", pattern, value)
+				}
+			case "think":
+				switch strings.ToLower(value) {
+				case "on":
+					reasoning.Think = &[]bool{true}[0]
+				case "off":
+					reasoning.Think = &[]bool{false}[0]
+				default:
+					return nil, fmt.Errorf("think for %s must be on or off, got %q", pattern, value)
+				}
+			case "tags":
+				reasoning.Tags = nil
+				if !strings.EqualFold(value, "none") {
+					for _, tag := range strings.Split(value, "|") {
+						if tag = strings.Trim(strings.TrimSpace(tag), "<>/"); tag != "" {
+							reasoning.Tags = append(reasoning.Tags, tag)
+						}
+					}
+				}
+			default:
+				return nil, fmt.Errorf("unknown reasoning setting %q for %s, use effort, think or tags", key, pattern)
+			}
+		}
+		rules = append(rules, reasoningRule{pattern: pattern, reasoning: reasoning})
+	}
+	return rules, nil
+}
+
+// For returns the reasoning settings of the first rule matching model, or DefaultReasoning
+func (r ReasoningRules) For(model string) Reasoning {
+	for _, rule := range r {
+		if matched, _ := path.Match(rule.pattern, model); matched {
+			return rule.reasoning
+		}
+	}
+	return DefaultReasoning
+}
+
+// requestOptions returns the request options asking for the reasoning settings that are not part
+// of the parameters of the openai package
+func (r Reasoning) requestOptions() []option.RequestOption {
+	if r.Think == nil {
+		return nil
+	}
+	// Qwen3 and other hybrid reasoning models read enable_thinking in their chat template, which
+	// vLLM and llama.cpp fill from chat_template_kwargs
+	return []option.RequestOption{option.WithJSONSet("chat_template_kwargs", map[string]any{"enable_thinking": *r.Think})}
+}
+
+// reasoningEffort returns the reasoning_effort parameter of the settings
+func (r Reasoning) reasoningEffort() shared.ReasoningEffort {
+	return shared.ReasoningEffort(r.Effort)
+}
+
+// Strip removes the reasoning between the tags of the settings from a response. Reasoning cut
+// off before its closing tag is removed up to the end of the response, and a closing tag without
+// an opening one, left by chat templates that open the tag in the prompt, ends reasoning that
+// started at the beginning of the response.
+func (r Reasoning) Strip(response string) string {
+	for _, tag := range r.Tags {
+		open, close := "<"+tag+">", "</"+tag+">"
+		if end := strings.Index(response, close); end != -1 && !strings.Contains(response[:end], open) {
+			response = response[end+len(close):]
+		}
+		for {
+			start := strings.Index(response, open)
+			if start == -1 {
+				break
+			}
+			end := strings.Index(response[start:], close)
+			if end == -1 {
+				response = response[:start]
+				break
+			}
+			response = response[:start] + response[start+end+len(close):]
+		}
+	}
+	return response
+}
@@ -0,0 +1,98 @@
+package openai
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestParseReasoning(t *testing.T) {
+	rules, err := ParseReasoning("qwen3*: think=off; gpt-oss-*: effort=LOW, tags=none;; *-r1: tags=think|<reasoning>")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	qwen := rules.For("qwen3-30b-a3b")
+	if qwen.Think == nil || *qwen.Think || !reflect.DeepEqual(qwen.Tags, []string{"think"}) {
+		t.Errorf("expected thinking off with the default tags, got %+v", qwen)
+	}
+	if oss := rules.For("gpt-oss-20b"); oss.Effort != "low" || oss.Tags != nil || oss.Think != nil {
+		t.Errorf("expected low effort without tags, got %+v", oss)
+	}
+	if r1 := rules.For("deepseek-r1"); !reflect.DeepEqual(r1.Tags, []string{"think", "reasoning"}) {
+		t.Errorf("expected both tags, got %v", r1.Tags)
+	}
+	if other := rules.For("llama-3"); !reflect.DeepEqual(other, DefaultReasoning) {
+		t.Errorf("expected the default for a model without a rule, got %+v", other)
+	}
+
+	for _, spec := range []string{"think=off", "qwen3*: effort=max", "qwen3*: think=maybe", "qwen3*: budget=1", "[: think=off"} {
+		if _, err := ParseReasoning(spec); err == nil {
+			t.Errorf("expected %q to fail", spec)
+		}
+	}
+}
+
+func TestReasoningStrip(t *testing.T) {
+	reasoning := Reasoning{Tags: []string{"think", "reasoning"}}
+	tests := map[string]string{
+		"<think>a</think>answer<think>b</think>": "answer",
+		"thinking in the prompt</think>answer":   "answer",
+		"<reasoning>a</reasoning>answer":         "answer",
+		"answer<think>cut off":                   "answer",
+		"answer":                                 "answer",
+	}
+	for response, want := range tests {
+		if got := reasoning.Strip(response); got != want {
+			t.Errorf("Strip(%q) = %q, want %q", response, got, want)
+		}
+	}
+	if got := (Reasoning{}).Strip("<think>a</think>b"); got != "<think>a</think>b" {
+		t.Errorf("expected no stripping without tags, got %q", got)
+	}
+}
+
+func TestChatCompletion_Reasoning(t *testing.T) {
+	var request map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		request = nil
+		if err := json.Unmarshal(body, &request); err != nil {
+			t.Errorf("could not parse request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"1","object":"chat.completion","created":0,"model":"test-model","choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"<reasoning>Let me see.</reasoning>\n\nThe answer."}}]}`))
+	}))
+	defer server.Close()
+
+	client := New(server.URL, "key", "test-model")
+	think := false
+	client.SetReasoning(Reasoning{Effort: "high", Think: &think, Tags: []string{"reasoning"}})
+	result := client.ChatCompletion("system", []string{"user"}, nil, nil, 0, 0)
+	if result.Err != nil {
+		t.Fatalf("unexpected error: %v", result.Err)
+	}
+	if result.Content != "The answer." {
+		t.Errorf("expected the reasoning to be removed, got %q", result.Content)
+	}
+	if request["reasoning_effort"] != "high" {
+		t.Errorf("expected reasoning_effort high, got %v", request["reasoning_effort"])
+	}
+	if kwargs, _ := request["chat_template_kwargs"].(map[string]any); kwargs["enable_thinking"] != false {
+		t.Errorf("expected thinking to be disabled in the chat template, got %v", request["chat_template_kwargs"])
+	}
+
+	client.SetReasoning(DefaultReasoning)
+	if result := client.ChatCompletion("system", []string{"user"}, nil, nil, 0, 0); result.Err != nil {
+		t.Fatalf("unexpected error: %v", result.Err)
+	}
+	if _, ok := request["reasoning_effort"]; ok {
+		t.Error("expected no reasoning_effort by default")
+	}
+	if _, ok := request["chat_template_kwargs"]; ok {
+		t.Error("expected no chat_template_kwargs by default")
+	}
+}
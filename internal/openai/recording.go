@@ -0,0 +1,209 @@
+package openai
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bakkerme/ai-news-processor/internal/http/retry"
+)
+
+// RecordedRequest identifies a chat completion request. Images given as data URIs are stored as
+// their SHA-256 digest to keep recordings small.
+type RecordedRequest struct {
+	Model        string   `json:"model"`
+	SystemPrompt string   `json:"systemPrompt"`
+	UserPrompts  []string `json:"userPrompts"`
+	ImageURLs    []string `json:"imageUrls,omitempty"`
+	Schema       string   `json:"schema,omitempty"` // Name of the response schema, if any
+	Temperature  float64  `json:"temperature"`
+	MaxTokens    int      `json:"maxTokens,omitempty"`
+}
+
+// Recording is a request and the response the LLM gave to it
+type Recording struct {
+	Request  RecordedRequest `json:"request"`
+	Response string          `json:"response"`
+}
+
+func newRecordedRequest(model, systemPrompt string, userPrompts, imageURLs []string, schemaParams *SchemaParameters, temperature float64, maxTokens int) RecordedRequest {
+	req := RecordedRequest{
+		Model:        model,
+		SystemPrompt: systemPrompt,
+		UserPrompts:  userPrompts,
+		Temperature:  temperature,
+		MaxTokens:    maxTokens,
+	}
+	if schemaParams != nil {
+		req.Schema = schemaParams.Name
+	}
+	for _, imageURL := range imageURLs {
+		if strings.HasPrefix(imageURL, "data:") {
+			sum := sha256.Sum256([]byte(imageURL))
+			imageURL = "sha256:" + hex.EncodeToString(sum[:])
+		}
+		req.ImageURLs = append(req.ImageURLs, imageURL)
+	}
+	return req
+}
+
+// key returns a stable identifier of the request, used as the recording's file name
+func (r RecordedRequest) key() string {
+	data, _ := json.Marshal(r) // Marshalling strings, numbers and slices cannot fail
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func recordingPath(dir string, req RecordedRequest) string {
+	return filepath.Join(dir, req.key()+".json")
+}
+
+// RecordingClient wraps a client and saves every request and response to a directory, to be served
+// back by a ReplayClient
+type RecordingClient struct {
+	client OpenAIClient
+	dir    string
+}
+
+// NewRecordingClient creates a client that records the interactions of client in dir
+func NewRecordingClient(client OpenAIClient, dir string) (*RecordingClient, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating recording directory: %w", err)
+	}
+	return &RecordingClient{client: client, dir: dir}, nil
+}
+
+// ChatCompletion forwards the request to the wrapped client and records the result
+func (c *RecordingClient) ChatCompletion(
+	systemPrompt string,
+	userPrompts []string,
+	imageURLs []string,
+	schemaParams *SchemaParameters,
+	temperature float64,
+	maxTokens int,
+) Result {
+	result := c.client.ChatCompletion(systemPrompt, userPrompts, imageURLs, schemaParams, temperature, maxTokens)
+
+	// Failed requests are not recorded, so a later recording run can fill them in
+	if result.Err == nil {
+		req := newRecordedRequest(c.client.GetModelName(), systemPrompt, userPrompts, imageURLs, schemaParams, temperature, maxTokens)
+		if err := writeRecording(c.dir, Recording{Request: req, Response: result.Content}); err != nil {
+			log.Printf("Could not record LLM response: %v", err)
+		}
+	}
+
+	return result
+}
+
+func writeRecording(dir string, recording Recording) error {
+	data, err := json.MarshalIndent(recording, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal recording: %w", err)
+	}
+	return os.WriteFile(recordingPath(dir, recording.Request), data, 0644)
+}
+
+// Discard tells the wrapped client that a response could not be used. The recording is kept, so
+// replays see the same response.
+func (c *RecordingClient) Discard(result Result) {
+	Discard(c.client, result)
+}
+
+// SetRetryConfig updates the retry configuration of the wrapped client
+func (c *RecordingClient) SetRetryConfig(config retry.RetryConfig) {
+	c.client.SetRetryConfig(config)
+}
+
+// PreprocessYAML extracts YAML content using the wrapped client
+func (c *RecordingClient) PreprocessYAML(response string) string {
+	return c.client.PreprocessYAML(response)
+}
+
+// PreprocessJSON extracts JSON content using the wrapped client
+func (c *RecordingClient) PreprocessJSON(response string) string {
+	return c.client.PreprocessJSON(response)
+}
+
+// GetModelName returns the model name of the wrapped client
+func (c *RecordingClient) GetModelName() string {
+	return c.client.GetModelName()
+}
+
+// Usage returns the usage of the wrapped client, if it tracks usage
+func (c *RecordingClient) Usage() Usage {
+	if reporter, ok := c.client.(UsageReporter); ok {
+		return reporter.Usage()
+	}
+	return Usage{}
+}
+
+// ErrNoRecording is returned by a ReplayClient for a request that was never recorded
+var ErrNoRecording = errors.New("no recording for request")
+
+// ReplayClient serves responses saved by a RecordingClient instead of calling an LLM. A request
+// matches a recording only if the model, prompts, images and parameters are identical.
+type ReplayClient struct {
+	dir   string
+	model string
+}
+
+// NewReplayClient creates a client that replays the recordings in dir for the given model
+func NewReplayClient(dir, model string) (*ReplayClient, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error opening recording directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("recording path %s is not a directory", dir)
+	}
+	return &ReplayClient{dir: dir, model: model}, nil
+}
+
+// ChatCompletion returns the recorded response to the request, or ErrNoRecording
+func (c *ReplayClient) ChatCompletion(
+	systemPrompt string,
+	userPrompts []string,
+	imageURLs []string,
+	schemaParams *SchemaParameters,
+	temperature float64,
+	maxTokens int,
+) Result {
+	req := newRecordedRequest(c.model, systemPrompt, userPrompts, imageURLs, schemaParams, temperature, maxTokens)
+	data, err := os.ReadFile(recordingPath(c.dir, req))
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = fmt.Errorf("%w %s", ErrNoRecording, req.key())
+		}
+		return Result{Model: c.model, Err: err}
+	}
+
+	var recording Recording
+	if err := json.Unmarshal(data, &recording); err != nil {
+		return Result{Model: c.model, Err: fmt.Errorf("failed to unmarshal recording %s: %w", req.key(), err)}
+	}
+	return Result{Content: recording.Response, Model: c.model}
+}
+
+// SetRetryConfig does nothing; replayed responses never need retrying
+func (c *ReplayClient) SetRetryConfig(config retry.RetryConfig) {}
+
+// PreprocessYAML extracts YAML content from the response
+func (c *ReplayClient) PreprocessYAML(response string) string {
+	return preprocess(DefaultReasoning.Strip(response), "yaml")
+}
+
+// PreprocessJSON extracts JSON content from the response
+func (c *ReplayClient) PreprocessJSON(response string) string {
+	return preprocess(DefaultReasoning.Strip(response), "json")
+}
+
+// GetModelName returns the model the recordings were made with
+func (c *ReplayClient) GetModelName() string {
+	return c.model
+}
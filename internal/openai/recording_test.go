@@ -0,0 +1,99 @@
+package openai
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bakkerme/ai-news-processor/internal/http/retry"
+)
+
+// countingClient answers every request with a fixed response and counts the calls
+type countingClient struct {
+	response string
+	err      error
+	calls    int
+}
+
+func (c *countingClient) ChatCompletion(systemPrompt string, userPrompts []string, imageURLs []string, schemaParams *SchemaParameters, temperature float64, maxTokens int) Result {
+	c.calls++
+	return Result{Content: c.response, Err: c.err}
+}
+func (c *countingClient) SetRetryConfig(config retry.RetryConfig) {}
+func (c *countingClient) PreprocessYAML(response string) string   { return response }
+func (c *countingClient) PreprocessJSON(response string) string   { return preprocess(response, "json") }
+func (c *countingClient) GetModelName() string                    { return "test-model" }
+
+func complete(client OpenAIClient, userPrompt string, imageURLs []string) Result {
+	return client.ChatCompletion("system", []string{userPrompt}, imageURLs, nil, 0.5, 0)
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "recordings")
+	inner := &countingClient{response: "```json\n{\"ok\":true}\n```"}
+
+	recorder, err := NewRecordingClient(inner, dir)
+	if err != nil {
+		t.Fatalf("NewRecordingClient: %v", err)
+	}
+	image := "data:image/png;base64,iVBORw0KGgo="
+	if result := complete(recorder, "first", []string{image}); result.Err != nil || result.Content != inner.response {
+		t.Fatalf("recording client returned %+v", result)
+	}
+	complete(recorder, "second", nil)
+
+	files, _ := os.ReadDir(dir)
+	if len(files) != 2 {
+		t.Fatalf("expected 2 recordings, got %d", len(files))
+	}
+
+	replay, err := NewReplayClient(dir, "test-model")
+	if err != nil {
+		t.Fatalf("NewReplayClient: %v", err)
+	}
+	result := complete(replay, "first", []string{image})
+	if result.Err != nil || result.Content != inner.response {
+		t.Fatalf("replay returned %+v", result)
+	}
+	if got := replay.PreprocessJSON(result.Content); got != `{"ok":true}` {
+		t.Errorf("expected preprocessed JSON, got %q", got)
+	}
+	if inner.calls != 2 {
+		t.Errorf("replay must not call the LLM, got %d calls", inner.calls)
+	}
+
+	// Any difference in the request misses the recording
+	if result := complete(replay, "third", nil); !errors.Is(result.Err, ErrNoRecording) {
+		t.Errorf("expected ErrNoRecording for an unknown prompt, got %v", result.Err)
+	}
+	if result := complete(replay, "first", []string{"data:image/png;base64,other"}); !errors.Is(result.Err, ErrNoRecording) {
+		t.Errorf("expected ErrNoRecording for a different image, got %v", result.Err)
+	}
+	otherModel, _ := NewReplayClient(dir, "other-model")
+	if result := complete(otherModel, "first", []string{image}); !errors.Is(result.Err, ErrNoRecording) {
+		t.Errorf("expected ErrNoRecording for a different model, got %v", result.Err)
+	}
+}
+
+func TestRecordingClient_SkipsFailures(t *testing.T) {
+	dir := t.TempDir()
+	inner := &countingClient{err: errors.New("timeout")}
+	recorder, err := NewRecordingClient(inner, dir)
+	if err != nil {
+		t.Fatalf("NewRecordingClient: %v", err)
+	}
+
+	if result := complete(recorder, "prompt", nil); result.Err == nil {
+		t.Fatal("expected the error to be passed through")
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 0 {
+		t.Errorf("failed requests must not be recorded, got %d files", len(files))
+	}
+}
+
+func TestNewReplayClient_MissingDir(t *testing.T) {
+	if _, err := NewReplayClient(filepath.Join(t.TempDir(), "missing"), "model"); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+}
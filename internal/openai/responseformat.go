@@ -0,0 +1,220 @@
+package openai
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/bakkerme/ai-news-processor/internal/gbnf"
+)
+
+// ResponseFormat is how a client asks the API for JSON that matches a schema
+type ResponseFormat string
+
+const (
+	FormatAuto       ResponseFormat = "auto"        // Start with json_schema and fall back to what the endpoint accepts
+	FormatJSONSchema ResponseFormat = "json_schema" // response_format json_schema, enforced by the server
+	FormatJSONObject ResponseFormat = "json_object" // response_format json_object, with the schema in the system prompt
+	FormatPrompt     ResponseFormat = "prompt"      // No response_format; the schema is in the system prompt and the JSON between markers
+	FormatGrammar    ResponseFormat = "grammar"     // llama.cpp guided decoding with a GBNF grammar generated from the schema
+	FormatGuidedJSON ResponseFormat = "guided_json" // vLLM guided decoding with the schema
+)
+
+// Markers the model is asked to put around its JSON in FormatPrompt
+const (
+	jsonStartMarker = "<json>"
+	jsonEndMarker   = "</json>"
+)
+
+// ParseResponseFormat parses a response format name. An empty name is FormatAuto.
+func ParseResponseFormat(name string) (ResponseFormat, error) {
+	switch format := ResponseFormat(strings.ToLower(strings.TrimSpace(name))); format {
+	case "":
+		return FormatAuto, nil
+	case FormatAuto, FormatJSONSchema, FormatJSONObject, FormatPrompt, FormatGrammar, FormatGuidedJSON:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown response format %q, use auto, json_schema, json_object, prompt, grammar or guided_json", name)
+	}
+}
+
+// fallback returns the format to try when an endpoint rejects f
+func (f ResponseFormat) fallback() (ResponseFormat, bool) {
+	switch f {
+	case FormatJSONSchema:
+		return FormatJSONObject, true
+	case FormatJSONObject:
+		return FormatPrompt, true
+	default:
+		return "", false
+	}
+}
+
+// negotiatedFormats holds the format each endpoint and model accepted, so every client of the
+// process starts with it instead of being rejected again
+var negotiatedFormats sync.Map
+
+// negotiationKey identifies an endpoint and model in negotiatedFormats
+func negotiationKey(baseURL, model string) string {
+	return strings.TrimRight(baseURL, "/") + " " + model
+}
+
+// negotiates reports whether the client finds out which format its endpoint accepts
+func (c *Client) negotiates() bool {
+	return c.format == FormatAuto || c.format == ""
+}
+
+// initialFormat returns the format a request with a schema starts with
+func (c *Client) initialFormat() ResponseFormat {
+	if !c.negotiates() {
+		return c.format
+	}
+	if format, ok := negotiatedFormats.Load(negotiationKey(c.baseURL, c.model)); ok {
+		return format.(ResponseFormat)
+	}
+	return FormatJSONSchema
+}
+
+// negotiate records that the endpoint accepted format, when the client negotiates its format
+func (c *Client) negotiate(format ResponseFormat) {
+	if !c.negotiates() {
+		return
+	}
+	if previous, loaded := negotiatedFormats.Swap(negotiationKey(c.baseURL, c.model), format); !loaded || previous != format {
+		log.Printf("LLM endpoint %s accepts structured output as %s for model %s\n", c.baseURL, format, c.model)
+	}
+}
+
+// rejectsResponseFormat reports whether err is an endpoint refusing the requested response format,
+// rather than the request itself
+func rejectsResponseFormat(err error) bool {
+	if Classify(err) != ErrorRequest {
+		return false
+	}
+	message := strings.ToLower(err.Error())
+	// OpenAI reports what is wrong with the schema itself as "Invalid schema for response_format".
+	// The endpoint supports json_schema, so falling back would wrongly downgrade every request.
+	if strings.Contains(message, "invalid schema") {
+		return false
+	}
+	for _, hint := range []string{"response_format", "response format", "json_schema", "json_object", "grammar", "not supported", "unsupported", "unrecognized", "extra inputs"} {
+		if strings.Contains(message, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+// strictSchemas caches whether each schema can be sent in strict mode
+var strictSchemas sync.Map
+
+// strictCompatible reports whether schema can be enforced in strict mode, where OpenAI rejects any
+// object that does not list all of its properties as required or allows additional properties.
+// Schemas with optional properties are sent without strict instead.
+func strictCompatible(schema interface{}) bool {
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return false
+	}
+	if compatible, ok := strictSchemas.Load(string(data)); ok {
+		return compatible.(bool)
+	}
+	var decoded any
+	compatible := json.Unmarshal(data, &decoded) == nil && strictNode(decoded)
+	strictSchemas.Store(string(data), compatible)
+	return compatible
+}
+
+// strictNode reports whether every object schema in node requires all of its properties and
+// allows no others
+func strictNode(node any) bool {
+	switch node := node.(type) {
+	case map[string]any:
+		if properties, ok := node["properties"].(map[string]any); ok {
+			if node["additionalProperties"] != false {
+				return false
+			}
+			required := make(map[string]bool)
+			list, _ := node["required"].([]any)
+			for _, name := range list {
+				if name, ok := name.(string); ok {
+					required[name] = true
+				}
+			}
+			for name := range properties {
+				if !required[name] {
+					return false
+				}
+			}
+		}
+		for _, value := range node {
+			if !strictNode(value) {
+				return false
+			}
+		}
+	case []any:
+		for _, value := range node {
+			if !strictNode(value) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// grammars caches the GBNF grammar of each schema, which is the same for every request
+var grammars sync.Map
+
+// grammarOf returns the GBNF grammar of the schema of schemaParams
+func grammarOf(schemaParams *SchemaParameters) (string, error) {
+	key, err := json.Marshal(schemaParams.Schema)
+	if err != nil {
+		return "", fmt.Errorf("could not encode the %s schema: %w", schemaParams.Name, err)
+	}
+	if grammar, ok := grammars.Load(string(key)); ok {
+		return grammar.(string), nil
+	}
+	grammar, err := gbnf.FromSchema(schemaParams.Schema)
+	if err != nil {
+		return "", fmt.Errorf("could not generate a grammar for the %s schema: %w", schemaParams.Name, err)
+	}
+	grammars.Store(string(key), grammar)
+	return grammar, nil
+}
+
+// schemaInstructions returns what is added to the system prompt when the server does not enforce
+// the schema itself
+func schemaInstructions(schemaParams *SchemaParameters, format ResponseFormat) (string, error) {
+	schema, err := json.Marshal(schemaParams.Schema)
+	if err != nil {
+		return "", fmt.Errorf("could not encode the %s schema: %w", schemaParams.Name, err)
+	}
+
+	var b strings.Builder
+	b.WriteString("\n\nRespond with a single JSON object")
+	if schemaParams.Description != "" {
+		fmt.Fprintf(&b, " (%s)", strings.TrimRight(schemaParams.Description, "."))
+	}
+	b.WriteString(" that matches this JSON Schema:\n")
+	b.Write(schema)
+	if format == FormatPrompt {
+		fmt.Fprintf(&b, "\n\nWrite the object between %s and %s, and nothing after %s.", jsonStartMarker, jsonEndMarker, jsonEndMarker)
+	}
+	return b.String(), nil
+}
+
+// extractMarkedJSON returns the JSON between the markers of FormatPrompt, or the response
+// unchanged if the model left them out
+func extractMarkedJSON(response string) string {
+	start := strings.LastIndex(response, jsonStartMarker)
+	if start == -1 {
+		return response
+	}
+	content := response[start+len(jsonStartMarker):]
+	if end := strings.Index(content, jsonEndMarker); end != -1 {
+		content = content[:end]
+	}
+	return strings.TrimSpace(content)
+}
@@ -0,0 +1,244 @@
+package openai
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// formatServer is an OpenAI-compatible server that only accepts the response formats in accepted.
+// "none" stands for a request without response_format.
+func formatServer(t *testing.T, accepted ...string) (*httptest.Server, *[]string) {
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var request struct {
+			Messages       []map[string]any `json:"messages"`
+			ResponseFormat *struct {
+				Type string `json:"type"`
+			} `json:"response_format"`
+		}
+		if err := json.Unmarshal(body, &request); err != nil {
+			t.Errorf("could not parse request: %v", err)
+		}
+		format := "none"
+		if request.ResponseFormat != nil {
+			format = request.ResponseFormat.Type
+		}
+		requested = append(requested, format)
+
+		for _, a := range accepted {
+			if a == format {
+				content := `{\"answer\":42}`
+				if format == "none" {
+					systemPrompt, _ := request.Messages[0]["content"].(string)
+					if !strings.Contains(systemPrompt, "<json>") || !strings.Contains(systemPrompt, `"answer"`) {
+						t.Errorf("expected the schema and markers in the system prompt, got %q", systemPrompt)
+					}
+					content = `Sure.\n<json>{\"answer\":42}</json>`
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"id":"1","object":"chat.completion","created":0,"model":"test-model","choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"` + content + `"}}],"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`))
+				return
+			}
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":{"message":"response_format type ` + format + ` is not supported"}}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requested
+}
+
+var answerSchema = &SchemaParameters{
+	Name:   "answer",
+	Schema: map[string]any{"type": "object", "properties": map[string]any{"answer": map[string]any{"type": "integer"}}},
+}
+
+func TestChatCompletion_ResponseFormatFallback(t *testing.T) {
+	server, requested := formatServer(t, "none")
+
+	client := New(server.URL, "key", "test-model")
+	result := client.ChatCompletion("system", []string{"user"}, nil, answerSchema, 0, 0)
+	if result.Err != nil {
+		t.Fatalf("unexpected error: %v", result.Err)
+	}
+	if result.Content != `{"answer":42}` {
+		t.Errorf("expected the JSON between the markers, got %q", result.Content)
+	}
+	if got := strings.Join(*requested, ","); got != "json_schema,json_object,none" {
+		t.Errorf("expected each format to be tried in turn, got %s", got)
+	}
+	if result.Usage.Calls != 3 || result.Usage.FailedCalls != 2 {
+		t.Errorf("expected the rejected requests in the usage, got %+v", result.Usage)
+	}
+
+	// The negotiated format is remembered for the endpoint, also by new clients
+	*requested = nil
+	other := New(server.URL, "key", "test-model")
+	if result := other.ChatCompletion("system", []string{"user"}, nil, answerSchema, 0, 0); result.Err != nil {
+		t.Fatalf("unexpected error: %v", result.Err)
+	}
+	if got := strings.Join(*requested, ","); got != "none" {
+		t.Errorf("expected the negotiated format to be used straight away, got %s", got)
+	}
+}
+
+func TestChatCompletion_ResponseFormatJSONObject(t *testing.T) {
+	server, requested := formatServer(t, "json_object", "none")
+
+	client := New(server.URL, "key", "test-model")
+	result := client.ChatCompletion("system", []string{"user"}, nil, answerSchema, 0, 0)
+	if result.Err != nil || result.Content != `{"answer":42}` {
+		t.Fatalf("unexpected result: %q, %v", result.Content, result.Err)
+	}
+	if got := strings.Join(*requested, ","); got != "json_schema,json_object" {
+		t.Errorf("expected json_object to be accepted after json_schema, got %s", got)
+	}
+}
+
+func TestChatCompletion_ConfiguredResponseFormat(t *testing.T) {
+	server, requested := formatServer(t, "none")
+
+	client := New(server.URL, "key", "test-model")
+	client.SetResponseFormat(FormatJSONSchema, false)
+	if result := client.ChatCompletion("system", []string{"user"}, nil, answerSchema, 0, 0); result.Err == nil {
+		t.Fatal("expected a configured format not to fall back")
+	}
+	if len(*requested) != 1 {
+		t.Errorf("expected a single request, got %v", *requested)
+	}
+}
+
+func TestChatCompletion_StrictSchema(t *testing.T) {
+	var requests []map[string]any
+	invalid := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var request map[string]any
+		if err := json.Unmarshal(body, &request); err != nil {
+			t.Errorf("could not parse request: %v", err)
+		}
+		requests = append(requests, request)
+		format, _ := request["response_format"].(map[string]any)
+		schema, _ := format["json_schema"].(map[string]any)
+		// OpenAI rejects strict schemas that leave properties out of required
+		if invalid || schema["strict"] == true && !strictCompatible(schema["schema"]) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"message":"Invalid schema for response_format 'answer': In context=(), 'required' is required to be supplied and to be an array including every key in properties. Missing 'answer'.","type":"invalid_request_error"}}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"1","object":"chat.completion","created":0,"model":"test-model","choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"{\"answer\":42}"}}]}`))
+	}))
+	defer server.Close()
+	strictFlag := func(request map[string]any) any {
+		format, _ := request["response_format"].(map[string]any)
+		schema, _ := format["json_schema"].(map[string]any)
+		return schema["strict"]
+	}
+
+	// A schema with optional properties is sent without strict
+	client := New(server.URL, "key", "test-model")
+	if result := client.ChatCompletion("system", []string{"user"}, nil, answerSchema, 0, 0); result.Err != nil {
+		t.Fatalf("unexpected error: %v", result.Err)
+	}
+	if len(requests) != 1 || strictFlag(requests[0]) != false {
+		t.Errorf("expected a single request without strict, got %v", requests)
+	}
+
+	// A schema that requires all of its properties is sent strict
+	requests = nil
+	strictSchema := &SchemaParameters{Name: "answer", Schema: map[string]any{
+		"type":                 "object",
+		"properties":           map[string]any{"answer": map[string]any{"type": "integer"}},
+		"required":             []string{"answer"},
+		"additionalProperties": false,
+	}}
+	if result := client.ChatCompletion("system", []string{"user"}, nil, strictSchema, 0, 0); result.Err != nil {
+		t.Fatalf("unexpected error: %v", result.Err)
+	}
+	if len(requests) != 1 || strictFlag(requests[0]) != true {
+		t.Errorf("expected a single strict request, got %v", requests)
+	}
+
+	// A schema the server rejects fails the request without downgrading the endpoint's format
+	requests, invalid = nil, true
+	if result := client.ChatCompletion("system", []string{"user"}, nil, answerSchema, 0, 0); result.Err == nil {
+		t.Fatal("expected an invalid schema to fail the request")
+	}
+	if len(requests) != 1 {
+		t.Errorf("expected no fallback for an invalid schema, got %d requests", len(requests))
+	}
+	if format := client.initialFormat(); format != FormatJSONSchema {
+		t.Errorf("expected the endpoint to keep json_schema, got %s", format)
+	}
+}
+
+func TestParseResponseFormat(t *testing.T) {
+	for name, want := range map[string]ResponseFormat{"": FormatAuto, "auto": FormatAuto, "JSON_OBJECT": FormatJSONObject, "prompt": FormatPrompt, "grammar": FormatGrammar} {
+		if got, err := ParseResponseFormat(name); err != nil || got != want {
+			t.Errorf("ParseResponseFormat(%q) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+	if _, err := ParseResponseFormat("regex"); err == nil {
+		t.Error("expected an unknown format to fail")
+	}
+}
+
+func TestExtractMarkedJSON(t *testing.T) {
+	tests := map[string]string{
+		"<json>{\"a\":1}</json>":                      `{"a":1}`,
+		"Thinking about <json> tags...\n<json>\n{}\n": "{}",
+		"```json\n{}\n```":                            "```json\n{}\n```",
+	}
+	for response, want := range tests {
+		if got := extractMarkedJSON(response); got != want {
+			t.Errorf("extractMarkedJSON(%q) = %q, want %q", response, got, want)
+		}
+	}
+}
+
+func TestChatCompletion_GuidedDecoding(t *testing.T) {
+	var request map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		request = nil
+		if err := json.Unmarshal(body, &request); err != nil {
+			t.Errorf("could not parse request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"1","object":"chat.completion","created":0,"model":"test-model","choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"{\"answer\":42}"}}]}`))
+	}))
+	defer server.Close()
+
+	client := New(server.URL, "key", "test-model")
+	client.SetResponseFormat(FormatGrammar, true)
+	if result := client.ChatCompletion("system", []string{"user"}, nil, answerSchema, 0, 0); result.Err != nil {
+		t.Fatalf("unexpected error: %v", result.Err)
+	}
+	grammar, _ := request["grammar"].(string)
+	if !strings.HasPrefix(grammar, "root ::= ") || !strings.Contains(grammar, `"\"answer\""`) {
+		t.Errorf("expected a GBNF grammar of the schema, got %q", grammar)
+	}
+	if _, ok := request["response_format"]; ok {
+		t.Error("expected no response_format with a grammar")
+	}
+
+	client.SetResponseFormat(FormatGuidedJSON, true)
+	if result := client.ChatCompletion("system", []string{"user"}, nil, answerSchema, 0, 0); result.Err != nil {
+		t.Fatalf("unexpected error: %v", result.Err)
+	}
+	if schema, ok := request["guided_json"].(map[string]any); !ok || schema["type"] != "object" {
+		t.Errorf("expected the schema as guided_json, got %v", request["guided_json"])
+	}
+
+	if result := client.ChatCompletion("system", []string{"user"}, nil, nil, 0, 0); result.Err != nil {
+		t.Fatalf("unexpected error: %v", result.Err)
+	}
+	if _, ok := request["guided_json"]; ok {
+		t.Error("expected requests without a schema not to be guided")
+	}
+}
@@ -0,0 +1,101 @@
+package outputs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bakkerme/ai-news-processor/internal/digest"
+)
+
+// discordMaxLength is the longest message content Discord accepts
+const discordMaxLength = 2000
+
+// formatSlack formats a digest as a Slack message in mrkdwn
+func formatSlack(p digest.Payload) string {
+	return formatChat(p, func(text string) string { return "*" + text + "*" }, func(title, link string) string {
+		// Slack links cannot contain the characters that delimit them
+		title = strings.NewReplacer("<", "", ">", "", "|", "-").Replace(title)
+		return fmt.Sprintf("<%s|%s>", link, title)
+	})
+}
+
+// formatDiscord formats a digest as a Discord message in markdown
+func formatDiscord(p digest.Payload) string {
+	return formatChat(p, func(text string) string { return "**" + text + "**" }, func(title, link string) string {
+		title = strings.NewReplacer("[", "(", "]", ")").Replace(title)
+		// Angle brackets keep Discord from embedding a preview for every link
+		return fmt.Sprintf("[%s](<%s>)", title, link)
+	})
+}
+
+// formatChat formats the key developments and items of a digest with the markup of a chat service
+func formatChat(p digest.Payload, bold func(string) string, link func(title, url string) string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n", bold(fmt.Sprintf("%s digest, %s", p.Persona, p.GeneratedAt.Format("2 January 2006"))))
+
+	if len(p.KeyDevelopments) > 0 {
+		fmt.Fprintf(&b, "\n%s\n", bold("Key developments"))
+		for _, kd := range p.KeyDevelopments {
+			fmt.Fprintf(&b, "• %s\n", kd.Text)
+		}
+	}
+
+	fmt.Fprintf(&b, "\n%s\n", bold(fmt.Sprintf("%d items", len(p.Items))))
+	for _, item := range p.Items {
+		title := item.Title
+		if item.Updated {
+			title = "Updated: " + title
+		}
+		if item.Link != "" {
+			title = link(title, item.Link)
+		}
+		summary := item.Summary
+		if len(item.Overview) > 0 {
+			summary = item.Overview[0]
+		}
+		if summary != "" {
+			fmt.Fprintf(&b, "• %s: %s\n", title, summary)
+		} else {
+			fmt.Fprintf(&b, "• %s\n", title)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// splitMessage splits text into parts of at most limit bytes, breaking between lines where possible
+func splitMessage(text string, limit int) []string {
+	var parts []string
+	var current strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		// A single line longer than the limit is cut, so no part exceeds it
+		for len(line) > limit {
+			cut := limit
+			for cut > 0 && !isRuneStart(line[cut]) {
+				cut--
+			}
+			if current.Len() > 0 {
+				parts = append(parts, current.String())
+				current.Reset()
+			}
+			parts = append(parts, line[:cut])
+			line = line[cut:]
+		}
+		if current.Len() > 0 && current.Len()+1+len(line) > limit {
+			parts = append(parts, current.String())
+			current.Reset()
+		}
+		if current.Len() > 0 {
+			current.WriteByte('\n')
+		}
+		current.WriteString(line)
+	}
+	if current.Len() > 0 {
+		parts = append(parts, current.String())
+	}
+	return parts
+}
+
+// isRuneStart reports whether b starts a UTF-8 encoded rune, so splitting before it keeps runes whole
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
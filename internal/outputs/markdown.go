@@ -0,0 +1,206 @@
+package outputs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/bakkerme/ai-news-processor/internal/digest"
+	"gopkg.in/yaml.v3"
+)
+
+// maxNoteNameLength limits the length of the title part of note file names, in characters
+const maxNoteNameLength = 100
+
+// noteFrontmatter is the YAML frontmatter of an item note. Obsidian reads tags from it, and
+// Dataview queries can use the other properties.
+type noteFrontmatter struct {
+	Title      string   `yaml:"title"`
+	ID         string   `yaml:"id,omitempty"`
+	Persona    string   `yaml:"persona"`
+	Date       string   `yaml:"date"`                // Day of the digest the item was sent in
+	Published  string   `yaml:"published,omitempty"` // Day the post was published
+	Link       string   `yaml:"link,omitempty"`
+	Importance int      `yaml:"importance,omitempty"`
+	Updated    bool     `yaml:"updated,omitempty"` // Sent again because the post was edited
+	Tags       []string `yaml:"tags,omitempty"`
+	Entities   []string `yaml:"entities,omitempty"`
+}
+
+// writeMarkdownNotes writes each item of a digest as a Markdown note with frontmatter into dir,
+// such as the folder of an Obsidian vault, together with a note for the digest that links to
+// them. An item sent again replaces its note of the same day.
+func writeMarkdownNotes(dir string, p digest.Payload) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("could not create Markdown directory: %w", err)
+	}
+
+	date := p.GeneratedAt.Format("2006-01-02")
+	names := make(map[string]string, len(p.Items)) // Note name by item ID
+	used := make(map[string]bool, len(p.Items))
+	for _, item := range p.Items {
+		name := date + " " + noteName(item.Title)
+		if used[name] {
+			name += " (" + noteName(item.ID) + ")"
+		}
+		used[name] = true
+		names[item.ID] = name
+
+		note, err := itemNote(item, p.Persona, date)
+		if err != nil {
+			return err
+		}
+		if err := writeNote(filepath.Join(dir, name+".md"), note); err != nil {
+			return err
+		}
+	}
+
+	digestName := p.GeneratedAt.Format("2006-01-02 1504") + " " + noteName(p.Persona) + " digest"
+	return writeNote(filepath.Join(dir, digestName+".md"), digestNote(p, names, date))
+}
+
+// itemNote renders an item as a Markdown note
+func itemNote(item digest.Item, personaName, date string) (string, error) {
+	front := noteFrontmatter{
+		Title:      item.Title,
+		ID:         item.ID,
+		Persona:    personaName,
+		Date:       date,
+		Link:       item.Link,
+		Importance: item.ImportanceScore,
+		Updated:    item.Updated,
+		Tags:       noteTags(personaName, item.Topics),
+	}
+	if item.PublishedAt != nil {
+		front.Published = item.PublishedAt.Format("2006-01-02")
+	}
+	for _, entity := range item.Entities {
+		front.Entities = append(front.Entities, entity.Name)
+	}
+	data, err := yaml.Marshal(front)
+	if err != nil {
+		return "", fmt.Errorf("could not marshal frontmatter of item %s: %w", item.ID, err)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "---\n%s---\n\n# %s\n\n", data, item.Title)
+	if item.Link != "" {
+		fmt.Fprintf(&b, "Source: <%s>\n\n", item.Link)
+	}
+	for _, point := range item.Overview {
+		fmt.Fprintf(&b, "- %s\n", point)
+	}
+	if len(item.Overview) > 0 {
+		b.WriteString("\n")
+	}
+	if item.Summary != "" {
+		fmt.Fprintf(&b, "## Summary\n\n%s\n\n", item.Summary)
+	}
+	if item.CommentSummary != "" {
+		fmt.Fprintf(&b, "## Discussion\n\n%s\n\n", item.CommentSummary)
+	}
+	if item.ImportanceReason != "" {
+		fmt.Fprintf(&b, "## Why It Matters\n\n%s\n\n", item.ImportanceReason)
+	}
+	if len(item.Entities) > 0 {
+		links := make([]string, len(item.Entities))
+		for i, entity := range item.Entities {
+			links[i] = "[[" + wikiLinkTarget(entity.Name) + "]]"
+		}
+		fmt.Fprintf(&b, "Mentions: %s\n", strings.Join(links, ", "))
+	}
+	return strings.TrimRight(b.String(), "\n") + "\n", nil
+}
+
+// digestNote renders the key developments of a digest and links to the notes of its items
+func digestNote(p digest.Payload, names map[string]string, date string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "---\npersona: %q\ndate: %s\ntags: [%s]\n---\n\n# %s digest, %s\n\n", p.Persona, date, noteTag(p.Persona), p.Persona, p.GeneratedAt.Format("2 January 2006"))
+	if len(p.KeyDevelopments) > 0 {
+		b.WriteString("## Key Developments\n\n")
+		for _, development := range p.KeyDevelopments {
+			if name, ok := names[development.ItemID]; ok && development.ItemID != "" {
+				fmt.Fprintf(&b, "- %s ([[%s]])\n", development.Text, name)
+			} else {
+				fmt.Fprintf(&b, "- %s\n", development.Text)
+			}
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("## Items\n\n")
+	for _, item := range p.Items {
+		fmt.Fprintf(&b, "- [[%s|%s]]\n", names[item.ID], wikiLinkTarget(item.Title))
+	}
+	return b.String()
+}
+
+// writeNote writes a note through a temporary file, so sync tools never pick up a partial note
+func writeNote(path, content string) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(content), 0644); err != nil {
+		return fmt.Errorf("could not write note: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("could not write note: %w", err)
+	}
+	return nil
+}
+
+// unsafeNoteChars are characters file systems or Obsidian links do not allow in note names
+var unsafeNoteChars = regexp.MustCompile(`[\\/:*?"<>|#^\[\]\x00-\x1f]+`)
+
+// noteName turns a title into a file name, without extension
+func noteName(title string) string {
+	name := strings.Join(strings.Fields(unsafeNoteChars.ReplaceAllString(title, " ")), " ")
+	name = strings.Trim(name, ". ")
+	if runes := []rune(name); len(runes) > maxNoteNameLength {
+		name = strings.TrimSpace(string(runes[:maxNoteNameLength]))
+	}
+	if name == "" {
+		return "Untitled"
+	}
+	return name
+}
+
+// wikiLinkTarget removes the characters that end or split an Obsidian link
+func wikiLinkTarget(name string) string {
+	return strings.Join(strings.Fields(strings.NewReplacer("[", "", "]", "", "|", "-", "#", "", "^", "").Replace(name)), " ")
+}
+
+// noteTags returns the tags of an item note: the persona and the topics of the item
+func noteTags(personaName string, topics []string) []string {
+	seen := make(map[string]bool)
+	var tags []string
+	for _, value := range append([]string{personaName}, topics...) {
+		if tag := noteTag(value); tag != "" && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// noteTag turns a value into an Obsidian tag: lowercase letters, digits, dashes and underscores,
+// not only digits
+func noteTag(value string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(value) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			b.WriteRune(r)
+			dash = false
+		case !dash && b.Len() > 0:
+			b.WriteRune('-')
+			dash = true
+		}
+	}
+	tag := strings.TrimRight(b.String(), "-")
+	if strings.IndexFunc(tag, func(r rune) bool { return !unicode.IsDigit(r) }) < 0 {
+		return ""
+	}
+	return tag
+}
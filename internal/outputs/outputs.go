@@ -0,0 +1,145 @@
+// Package outputs delivers the digest of a persona to the channels configured in its outputs
+// section: email, Slack, Discord, generic webhooks, RSS or JSON files, Markdown notes and podcasts.
+package outputs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/digest"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/models"
+)
+
+// Digest is a digest ready to be delivered, in the forms the channels need
+type Digest struct {
+	Persona persona.Persona
+	Items   []models.Item
+	Summary *models.SummaryResponse
+	Payload digest.Payload
+}
+
+// EmailSender renders and sends digest emails
+type EmailSender interface {
+	RenderAndSend(items []models.Item, summary *models.SummaryResponse, p persona.Persona) error
+}
+
+// Result is the outcome of delivering a digest to one output
+type Result struct {
+	Output string // Type of the output, with its destination when it has one
+	Err    error
+}
+
+// Dispatcher fans a digest out to every output of its persona
+type Dispatcher struct {
+	email        EmailSender
+	client       *http.Client
+	speechClient *http.Client // Speaking a long digest takes minutes on local speech servers
+}
+
+// NewDispatcher creates a dispatcher that sends email outputs through email
+func NewDispatcher(email EmailSender) *Dispatcher {
+	return &Dispatcher{
+		email:        email,
+		client:       &http.Client{Timeout: 30 * time.Second},
+		speechClient: &http.Client{Timeout: 5 * time.Minute},
+	}
+}
+
+// Deliver sends the digest to every output of its persona and returns the outcome of each. Every
+// output is tried even if an earlier one fails.
+func (d *Dispatcher) Deliver(dg Digest) []Result {
+	outputs := dg.Persona.GetOutputs()
+	results := make([]Result, len(outputs))
+	for i, output := range outputs {
+		results[i] = Result{Output: describe(output), Err: d.deliver(output, dg)}
+	}
+	return results
+}
+
+func (d *Dispatcher) deliver(output persona.Output, dg Digest) error {
+	switch output.Type {
+	case persona.OutputEmail:
+		p := dg.Persona
+		if len(output.Recipients) > 0 {
+			p.Recipients = output.Recipients
+		}
+		return d.email.RenderAndSend(dg.Items, dg.Summary, p)
+	case persona.OutputSlack:
+		return d.postJSON(os.ExpandEnv(output.URL), nil, map[string]string{"text": formatSlack(dg.Payload)})
+	case persona.OutputDiscord:
+		// Discord rejects messages over its length limit, so long digests are sent in parts
+		for _, content := range splitMessage(formatDiscord(dg.Payload), discordMaxLength) {
+			if err := d.postJSON(os.ExpandEnv(output.URL), nil, map[string]string{"content": content}); err != nil {
+				return err
+			}
+		}
+		return nil
+	case persona.OutputWebhook:
+		data, err := digest.Marshal(dg.Payload)
+		if err != nil {
+			return err
+		}
+		headers := make(map[string]string, len(output.Headers))
+		for key, value := range output.Headers {
+			headers[key] = os.ExpandEnv(value)
+		}
+		return d.post(os.ExpandEnv(output.URL), headers, data)
+	case persona.OutputRSSFile:
+		return writeRSSFile(output.Path, output.MaxItems, dg.Payload)
+	case persona.OutputJSONFile:
+		_, err := digest.WriteFile(output.Path, dg.Payload)
+		return err
+	case persona.OutputMarkdown:
+		return writeMarkdownNotes(output.Path, dg.Payload)
+	case persona.OutputPodcast:
+		return d.writePodcast(output, dg.Payload, dg.Persona.Locale)
+	default:
+		return fmt.Errorf("unsupported output type '%s'", output.Type)
+	}
+}
+
+// describe names an output for logs and reports, without URLs that may contain secrets
+func describe(output persona.Output) string {
+	if output.Path != "" {
+		return output.Type + " " + output.Path
+	}
+	return output.Type
+}
+
+func (d *Dispatcher) postJSON(url string, headers map[string]string, message any) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+	return d.post(url, headers, body)
+}
+
+func (d *Dispatcher) post(url string, headers map[string]string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post digest: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("output returned status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
@@ -0,0 +1,318 @@
+package outputs
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/digest"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubEmail struct {
+	sent []persona.Persona
+	err  error
+}
+
+func (s *stubEmail) RenderAndSend(items []models.Item, summary *models.SummaryResponse, p persona.Persona) error {
+	s.sent = append(s.sent, p)
+	return s.err
+}
+
+func testDigest(p persona.Persona) Digest {
+	items := []models.Item{
+		{ID: "a", Title: "Qwen 3 released", Link: "https://example.com/a", Overview: []string{"New model"}, Summary: "Summary"},
+		{ID: "b", Title: "Untagged"},
+	}
+	summary := &models.SummaryResponse{KeyDevelopments: []models.KeyDevelopment{{Text: "Qwen 3 is out", ItemID: "a"}}}
+	return Digest{
+		Persona: p,
+		Items:   items,
+		Summary: summary,
+		Payload: digest.New(p.Name, items, summary, time.Date(2025, time.March, 7, 12, 0, 0, 0, time.UTC)),
+	}
+}
+
+func TestDeliver_DefaultsToEmail(t *testing.T) {
+	email := &stubEmail{}
+	results := NewDispatcher(email).Deliver(testDigest(persona.Persona{Name: "LocalLLaMA", Recipients: []string{"me@example.com"}}))
+
+	require.Len(t, results, 1)
+	assert.Equal(t, "email", results[0].Output)
+	assert.NoError(t, results[0].Err)
+	require.Len(t, email.sent, 1)
+	assert.Equal(t, []string{"me@example.com"}, email.sent[0].Recipients)
+}
+
+func TestDeliver_AllOutputs(t *testing.T) {
+	var slack, discord map[string]string
+	var webhook digest.Payload
+	var webhookAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		body, _ := io.ReadAll(r.Body)
+		switch r.URL.Path {
+		case "/slack":
+			require.NoError(t, json.Unmarshal(body, &slack))
+		case "/discord":
+			require.NoError(t, json.Unmarshal(body, &discord))
+		case "/webhook":
+			webhookAuth = r.Header.Get("Authorization")
+			require.NoError(t, json.Unmarshal(body, &webhook))
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("TEST_WEBHOOK_TOKEN", "secret")
+	t.Setenv("TEST_SERVER_URL", server.URL)
+	dir := t.TempDir()
+	email := &stubEmail{}
+	p := persona.Persona{Name: "LocalLLaMA", Recipients: []string{"me@example.com"}, Outputs: []persona.Output{
+		{Type: persona.OutputEmail, Recipients: []string{"team@example.com"}},
+		{Type: persona.OutputSlack, URL: "${TEST_SERVER_URL}/slack"},
+		{Type: persona.OutputDiscord, URL: server.URL + "/discord"},
+		{Type: persona.OutputWebhook, URL: server.URL + "/webhook", Headers: map[string]string{"Authorization": "Bearer ${TEST_WEBHOOK_TOKEN}"}},
+		{Type: persona.OutputRSSFile, Path: filepath.Join(dir, "feed.xml")},
+		{Type: persona.OutputJSONFile, Path: filepath.Join(dir, "json")},
+		{Type: persona.OutputMarkdown, Path: filepath.Join(dir, "vault")},
+	}}
+
+	results := NewDispatcher(email).Deliver(testDigest(p))
+	require.Len(t, results, 7)
+	for _, result := range results {
+		assert.NoError(t, result.Err, result.Output)
+	}
+
+	require.Len(t, email.sent, 1)
+	assert.Equal(t, []string{"team@example.com"}, email.sent[0].Recipients)
+	assert.Contains(t, slack["text"], "<https://example.com/a|Qwen 3 released>: New model")
+	assert.Contains(t, discord["content"], "[Qwen 3 released](<https://example.com/a>)")
+	assert.Equal(t, "Bearer secret", webhookAuth)
+	assert.Equal(t, "LocalLLaMA", webhook.Persona)
+	assert.FileExists(t, filepath.Join(dir, "feed.xml"))
+	files, err := os.ReadDir(filepath.Join(dir, "json"))
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+	files, err = os.ReadDir(filepath.Join(dir, "vault"))
+	require.NoError(t, err)
+	assert.Len(t, files, 3, "a note per item and one for the digest")
+}
+
+func TestDeliver_ContinuesAfterFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid token", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	email := &stubEmail{}
+	p := persona.Persona{Name: "LocalLLaMA", Outputs: []persona.Output{
+		{Type: persona.OutputSlack, URL: server.URL},
+		{Type: persona.OutputEmail},
+	}}
+
+	results := NewDispatcher(email).Deliver(testDigest(p))
+	require.Len(t, results, 2)
+	require.Error(t, results[0].Err)
+	assert.Contains(t, results[0].Err.Error(), "403 Forbidden: invalid token")
+	assert.Equal(t, "slack", results[0].Output, "URLs are not included in results")
+	assert.NoError(t, results[1].Err)
+	assert.Len(t, email.sent, 1)
+}
+
+func TestSplitMessage(t *testing.T) {
+	text := strings.Repeat("a", 8) + "\n" + strings.Repeat("b", 8) + "\n" + strings.Repeat("c", 25)
+	parts := splitMessage(text, 20)
+
+	assert.Equal(t, []string{strings.Repeat("a", 8) + "\n" + strings.Repeat("b", 8), strings.Repeat("c", 20), strings.Repeat("c", 5)}, parts)
+	assert.Equal(t, []string{"short"}, splitMessage("short", 20))
+}
+
+func TestWriteRSSFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feeds", "feed.xml")
+	first := testDigest(persona.Persona{Name: "LocalLLaMA"}).Payload
+	require.NoError(t, writeRSSFile(path, 3, first))
+
+	// Items already in the feed are not repeated, new items come first and old ones are dropped
+	second := first
+	second.Items = []digest.Item{{ID: "c", Title: "Third"}, {ID: "a", Title: "Qwen 3 released"}, {ID: "d", Title: "Fourth"}}
+	require.NoError(t, writeRSSFile(path, 3, second))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var feed rssFeed
+	require.NoError(t, xml.Unmarshal(data, &feed))
+
+	var guids []string
+	for _, item := range feed.Channel.Items {
+		guids = append(guids, item.GUID.Value)
+	}
+	assert.Equal(t, []string{"c", "d", "a"}, guids)
+	assert.Equal(t, "LocalLLaMA digest", feed.Channel.Title)
+	assert.Equal(t, "<ul><li>New model</li></ul><p>Summary</p>", feed.Channel.Items[2].Description)
+}
+
+func TestWriteRSSFile_Updated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feed.xml")
+	first := testDigest(persona.Persona{Name: "LocalLLaMA"}).Payload
+	require.NoError(t, writeRSSFile(path, 10, first))
+
+	// An update of an item already in the feed is added as a new item
+	second := first
+	second.GeneratedAt = first.GeneratedAt.Add(24 * time.Hour)
+	second.Items = []digest.Item{{ID: "a", Title: "Qwen 3 released", Updated: true}}
+	require.NoError(t, writeRSSFile(path, 10, second))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var feed rssFeed
+	require.NoError(t, xml.Unmarshal(data, &feed))
+
+	require.Len(t, feed.Channel.Items, 3)
+	assert.Equal(t, "a#updated-20250308T120000Z", feed.Channel.Items[0].GUID.Value)
+	assert.Equal(t, "Updated: Qwen 3 released", feed.Channel.Items[0].Title)
+}
+
+func TestWriteMarkdownNotes(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "vault")
+	payload := testDigest(persona.Persona{Name: "LocalLLaMA"}).Payload
+	payload.Items[0].Topics = []string{"Model Releases", "2025"}
+	payload.Items[0].Entities = []digest.Entity{{Name: "Qwen 3", Type: "model"}}
+	payload.Items[0].CommentSummary = "Commenters like it"
+	payload.Items = append(payload.Items, digest.Item{ID: "c", Title: "Untagged"}, digest.Item{ID: "d", Title: "What's next: GPT-5?"})
+	require.NoError(t, writeMarkdownNotes(dir, payload))
+
+	data, err := os.ReadFile(filepath.Join(dir, "2025-03-07 Qwen 3 released.md"))
+	require.NoError(t, err)
+	assert.Equal(t, `---
+title: Qwen 3 released
+id: a
+persona: LocalLLaMA
+date: "2025-03-07"
+link: https://example.com/a
+tags:
+    - localllama
+    - model-releases
+entities:
+    - Qwen 3
+---
+
+# Qwen 3 released
+
+Source: <https://example.com/a>
+
+- New model
+
+## Summary
+
+Summary
+
+## Discussion
+
+Commenters like it
+
+Mentions: [[Qwen 3]]
+`, string(data))
+
+	assert.FileExists(t, filepath.Join(dir, "2025-03-07 Untagged.md"))
+	assert.FileExists(t, filepath.Join(dir, "2025-03-07 Untagged (c).md"), "items with the same title get their ID added")
+	assert.FileExists(t, filepath.Join(dir, "2025-03-07 What's next GPT-5.md"))
+
+	data, err = os.ReadFile(filepath.Join(dir, "2025-03-07 1200 LocalLLaMA digest.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "- Qwen 3 is out ([[2025-03-07 Qwen 3 released]])")
+	assert.Contains(t, string(data), "- [[2025-03-07 Untagged (c)|Untagged]]")
+}
+
+func TestWritePodcast(t *testing.T) {
+	var requests []speechRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		var req speechRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		requests = append(requests, req)
+		w.Write([]byte("ID3"))
+	}))
+	defer server.Close()
+
+	t.Setenv("TEST_TTS_TOKEN", "secret")
+	dir := filepath.Join(t.TempDir(), "podcast")
+	output := persona.Output{
+		Type:     persona.OutputPodcast,
+		Path:     dir,
+		URL:      server.URL,
+		Headers:  map[string]string{"Authorization": "Bearer ${TEST_TTS_TOKEN}"},
+		Voice:    "nova",
+		BaseURL:  "https://example.com/podcast/",
+		MaxItems: 1,
+	}
+	p := persona.Persona{Name: "LocalLLaMA", Locale: "en", Outputs: []persona.Output{output}}
+	first := testDigest(p)
+
+	results := NewDispatcher(&stubEmail{}).Deliver(first)
+	require.Len(t, results, 1)
+	require.NoError(t, results[0].Err)
+	require.Len(t, requests, 1)
+	assert.Equal(t, speechRequest{Model: "tts-1", Input: podcastScript(first.Payload), Voice: "nova", ResponseFormat: "mp3"}, requests[0])
+	audio, err := os.ReadFile(filepath.Join(dir, "2025-03-07-1200.mp3"))
+	require.NoError(t, err)
+	assert.Equal(t, "ID3", string(audio))
+
+	// A later episode replaces the first, which is deleted as max_items is 1
+	second := first
+	second.Payload.GeneratedAt = first.Payload.GeneratedAt.Add(24 * time.Hour)
+	require.NoError(t, NewDispatcher(&stubEmail{}).Deliver(second)[0].Err)
+	assert.NoFileExists(t, filepath.Join(dir, "2025-03-07-1200.mp3"))
+	assert.FileExists(t, filepath.Join(dir, "2025-03-08-1200.mp3"))
+
+	data, err := os.ReadFile(filepath.Join(dir, "feed.xml"))
+	require.NoError(t, err)
+	var feed podcastFeed
+	require.NoError(t, xml.Unmarshal(data, &feed))
+	assert.Equal(t, "LocalLLaMA briefing", feed.Channel.Title)
+	assert.Equal(t, "en", feed.Channel.Language)
+	require.Len(t, feed.Channel.Episodes, 1)
+	episode := feed.Channel.Episodes[0]
+	assert.Equal(t, podcastEnclosure{URL: "https://example.com/podcast/2025-03-08-1200.mp3", Length: 3, Type: "audio/mpeg"}, episode.Enclosure)
+	assert.Equal(t, `<ul><li><a href="https://example.com/a">Qwen 3 released</a></li><li>Untagged</li></ul>`, episode.Description)
+}
+
+func TestWritePodcast_SpeechFails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unknown voice", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	dir := filepath.Join(t.TempDir(), "podcast")
+	output := persona.Output{Type: persona.OutputPodcast, Path: dir, URL: server.URL, BaseURL: "https://example.com/podcast"}
+	err := NewDispatcher(&stubEmail{}).writePodcast(output, testDigest(persona.Persona{Name: "LocalLLaMA"}).Payload, "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "400 Bad Request: unknown voice")
+	assert.NoDirExists(t, dir, "nothing is written without audio")
+}
+
+func TestPodcastScript(t *testing.T) {
+	payload := testDigest(persona.Persona{Name: "LocalLLaMA"}).Payload
+	payload.Items[0].Summary = "**Qwen 3** is out, see https://example.com/qwen for details."
+	payload.Items[0].CommentSummary = "Commenters like it"
+	payload.Items[1].Updated = true
+
+	assert.Equal(t, `Your LocalLLaMA briefing for Friday, 7 March, with 2 stories.
+First, the key developments.
+Qwen 3 is out
+Now, the stories.
+Qwen 3 released.
+Qwen 3 is out, see for details.
+In the comments: Commenters like it
+An update on Untagged.
+That's all for this briefing.`, podcastScript(payload))
+}
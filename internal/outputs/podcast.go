@@ -0,0 +1,256 @@
+package outputs
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/digest"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/internal/templatefuncs"
+)
+
+// DefaultPodcastMaxEpisodes is the number of episodes a podcast output keeps when max_items is
+// not set. Older episodes are removed from the feed and their audio deleted.
+const DefaultPodcastMaxEpisodes = 20
+
+// Defaults of the speech request, matching the OpenAI speech API
+const (
+	defaultSpeechModel = "tts-1"
+	defaultSpeechVoice = "alloy"
+)
+
+// speechMaxLength is the longest input the OpenAI speech API accepts. Longer scripts are spoken in
+// parts, and the MP3 parts joined.
+const speechMaxLength = 4096
+
+// podcastFeedFile is the name of the podcast feed in the directory of a podcast output
+const podcastFeedFile = "feed.xml"
+
+type podcastFeed struct {
+	XMLName xml.Name       `xml:"rss"`
+	Version string         `xml:"version,attr"`
+	Channel podcastChannel `xml:"channel"`
+}
+
+type podcastChannel struct {
+	Title         string           `xml:"title"`
+	Link          string           `xml:"link"`
+	Description   string           `xml:"description"`
+	Language      string           `xml:"language,omitempty"`
+	LastBuildDate string           `xml:"lastBuildDate"`
+	Episodes      []podcastEpisode `xml:"item"`
+}
+
+type podcastEpisode struct {
+	Title       string           `xml:"title"`
+	Description string           `xml:"description"`
+	Enclosure   podcastEnclosure `xml:"enclosure"`
+	GUID        rssGUID          `xml:"guid"`
+	PubDate     string           `xml:"pubDate"`
+}
+
+type podcastEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+// speechRequest is the body of an OpenAI-compatible speech request
+type speechRequest struct {
+	Model          string `json:"model"`
+	Input          string `json:"input"`
+	Voice          string `json:"voice"`
+	ResponseFormat string `json:"response_format"`
+}
+
+// writePodcast speaks the digest as an MP3 episode in the output's directory and adds it to the
+// podcast feed there. Only the newest max_items episodes are kept.
+func (d *Dispatcher) writePodcast(output persona.Output, p digest.Payload, locale string) error {
+	var audio []byte
+	for _, part := range splitMessage(podcastScript(p), speechMaxLength) {
+		speech, err := d.speak(output, part)
+		if err != nil {
+			return err
+		}
+		audio = append(audio, speech...)
+	}
+
+	if err := os.MkdirAll(output.Path, 0755); err != nil {
+		return fmt.Errorf("could not create podcast directory: %w", err)
+	}
+	name := p.GeneratedAt.UTC().Format("2006-01-02-1504") + ".mp3"
+	if err := writeFileAtomic(filepath.Join(output.Path, name), audio); err != nil {
+		return fmt.Errorf("could not write podcast episode: %w", err)
+	}
+
+	feedPath := filepath.Join(output.Path, podcastFeedFile)
+	baseURL := strings.TrimSuffix(os.ExpandEnv(output.BaseURL), "/")
+	feed := podcastFeed{Version: "2.0", Channel: podcastChannel{
+		Title:       p.Persona + " briefing",
+		Link:        baseURL + "/" + podcastFeedFile,
+		Description: "Spoken " + p.Persona + " digests",
+		Language:    locale,
+	}}
+	if data, err := os.ReadFile(feedPath); err == nil {
+		if err := xml.Unmarshal(data, &feed); err != nil {
+			return fmt.Errorf("could not parse existing podcast feed %s: %w", feedPath, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("could not read podcast feed: %w", err)
+	}
+
+	url := baseURL + "/" + name
+	episodes := []podcastEpisode{{
+		Title:       fmt.Sprintf("%s briefing, %s", p.Persona, p.GeneratedAt.Format("2 January 2006 15:04")),
+		Description: podcastDescription(p),
+		Enclosure:   podcastEnclosure{URL: url, Length: int64(len(audio)), Type: "audio/mpeg"},
+		GUID:        rssGUID{Value: url, IsPermaLink: true},
+		PubDate:     p.GeneratedAt.UTC().Format(time.RFC1123Z),
+	}}
+	for _, episode := range feed.Channel.Episodes {
+		// A run in the same minute replaces the episode it wrote before
+		if episode.GUID.Value != url {
+			episodes = append(episodes, episode)
+		}
+	}
+
+	maxEpisodes := output.MaxItems
+	if maxEpisodes <= 0 {
+		maxEpisodes = DefaultPodcastMaxEpisodes
+	}
+	if len(episodes) > maxEpisodes {
+		for _, episode := range episodes[maxEpisodes:] {
+			old := filepath.Join(output.Path, path.Base(episode.Enclosure.URL))
+			if err := os.Remove(old); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("could not delete old podcast episode: %w", err)
+			}
+		}
+		episodes = episodes[:maxEpisodes]
+	}
+	feed.Channel.Episodes = episodes
+	feed.Channel.LastBuildDate = p.GeneratedAt.UTC().Format(time.RFC1123Z)
+
+	data, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not marshal podcast feed: %w", err)
+	}
+	if err := writeFileAtomic(feedPath, append([]byte(xml.Header), data...)); err != nil {
+		return fmt.Errorf("could not write podcast feed: %w", err)
+	}
+	return nil
+}
+
+// speak converts text to MP3 audio with the speech endpoint of a podcast output
+func (d *Dispatcher) speak(output persona.Output, text string) ([]byte, error) {
+	model, voice := output.Model, output.Voice
+	if model == "" {
+		model = defaultSpeechModel
+	}
+	if voice == "" {
+		voice = defaultSpeechVoice
+	}
+	body, err := json.Marshal(speechRequest{Model: model, Input: text, Voice: voice, ResponseFormat: "mp3"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal speech request: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, os.ExpandEnv(output.URL), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create speech request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range output.Headers {
+		req.Header.Set(key, os.ExpandEnv(value))
+	}
+
+	resp, err := d.speechClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request speech: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("speech endpoint returned status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	audio, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read speech: %w", err)
+	}
+	return audio, nil
+}
+
+// unspokenText matches links and the markup the LLM sometimes writes, which a speech model would
+// read out
+var unspokenText = regexp.MustCompile(`(?m)https?://\S+|\*\*|__|` + "`" + `|^#+\s*`)
+
+// podcastScript writes the digest as text to be read aloud: the key developments followed by the
+// summary of each item. Each paragraph is on its own line, so long scripts are split between them.
+func podcastScript(p digest.Payload) string {
+	spoken := func(text string) string {
+		return strings.Join(strings.Fields(unspokenText.ReplaceAllString(text, "")), " ")
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Your %s briefing for %s, with %d %s.\n", p.Persona, p.GeneratedAt.Format("Monday, 2 January"), len(p.Items), templatefuncs.Pluralize(len(p.Items), "story", "stories"))
+	if len(p.KeyDevelopments) > 0 {
+		b.WriteString("First, the key developments.\n")
+		for _, kd := range p.KeyDevelopments {
+			fmt.Fprintf(&b, "%s\n", spoken(kd.Text))
+		}
+		b.WriteString("Now, the stories.\n")
+	}
+	for _, item := range p.Items {
+		title := spoken(item.Title)
+		if item.Updated {
+			title = "An update on " + title
+		}
+		fmt.Fprintf(&b, "%s.\n", strings.TrimRight(title, ".!?"))
+		if item.Summary != "" {
+			fmt.Fprintf(&b, "%s\n", spoken(item.Summary))
+		} else if len(item.Overview) > 0 {
+			fmt.Fprintf(&b, "%s\n", spoken(strings.Join(item.Overview, " ")))
+		}
+		if item.CommentSummary != "" {
+			fmt.Fprintf(&b, "In the comments: %s\n", spoken(item.CommentSummary))
+		}
+	}
+	b.WriteString("That's all for this briefing.")
+	return b.String()
+}
+
+// podcastDescription lists the items of the digest as the HTML description of an episode
+func podcastDescription(p digest.Payload) string {
+	var b strings.Builder
+	b.WriteString("<ul>")
+	for _, item := range p.Items {
+		title := html.EscapeString(item.Title)
+		if item.Link != "" {
+			title = fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(item.Link), title)
+		}
+		fmt.Fprintf(&b, "<li>%s</li>", title)
+	}
+	b.WriteString("</ul>")
+	return b.String()
+}
+
+// writeFileAtomic writes a temporary file first and renames it, so podcast apps never download a
+// partial file
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,138 @@
+package outputs
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/digest"
+)
+
+// DefaultRSSMaxItems is the number of items an RSS file output keeps when max_items is not set
+const DefaultRSSMaxItems = 50
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link,omitempty"`
+	Description string  `xml:"description"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+type rssGUID struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+}
+
+// writeRSSFile adds the items of a digest to the RSS feed at path, creating it if needed. Items
+// already in the feed are not added twice, and only the newest maxItems are kept.
+func writeRSSFile(path string, maxItems int, p digest.Payload) error {
+	if maxItems <= 0 {
+		maxItems = DefaultRSSMaxItems
+	}
+
+	feed := rssFeed{Version: "2.0", Channel: rssChannel{
+		Title:       p.Persona + " digest",
+		Description: "Items sent in the " + p.Persona + " digest",
+	}}
+	if data, err := os.ReadFile(path); err == nil {
+		if err := xml.Unmarshal(data, &feed); err != nil {
+			return fmt.Errorf("could not parse existing RSS file %s: %w", path, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("could not read RSS file: %w", err)
+	}
+
+	known := make(map[string]bool, len(feed.Channel.Items))
+	for _, item := range feed.Channel.Items {
+		known[item.GUID.Value] = true
+	}
+
+	var added []rssItem
+	for _, item := range p.Items {
+		guid := item.ID
+		if guid == "" {
+			guid = item.Link
+		}
+		title := item.Title
+		if item.Updated {
+			// An update is a new feed item, so readers see it again
+			guid += "#updated-" + p.GeneratedAt.UTC().Format("20060102T150405Z")
+			title = "Updated: " + title
+		}
+		if known[guid] {
+			continue
+		}
+		known[guid] = true
+		added = append(added, rssItem{
+			Title:       title,
+			Link:        item.Link,
+			Description: rssDescription(item),
+			GUID:        rssGUID{Value: guid},
+			PubDate:     p.GeneratedAt.UTC().Format(time.RFC1123Z),
+		})
+	}
+
+	feed.Channel.Items = append(added, feed.Channel.Items...)
+	if len(feed.Channel.Items) > maxItems {
+		feed.Channel.Items = feed.Channel.Items[:maxItems]
+	}
+	feed.Channel.LastBuildDate = p.GeneratedAt.UTC().Format(time.RFC1123Z)
+
+	data, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not marshal RSS feed: %w", err)
+	}
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("could not create RSS file directory: %w", err)
+		}
+	}
+
+	// Write a temporary file first so feed readers never see a partial feed
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append([]byte(xml.Header), data...), 0644); err != nil {
+		return fmt.Errorf("could not write RSS file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("could not write RSS file: %w", err)
+	}
+	return nil
+}
+
+// rssDescription renders the overview and summary of an item as the HTML description of an RSS item
+func rssDescription(item digest.Item) string {
+	var b strings.Builder
+	if len(item.Overview) > 0 {
+		b.WriteString("<ul>")
+		for _, point := range item.Overview {
+			fmt.Fprintf(&b, "<li>%s</li>", html.EscapeString(point))
+		}
+		b.WriteString("</ul>")
+	}
+	if item.Summary != "" {
+		fmt.Fprintf(&b, "<p>%s</p>", html.EscapeString(item.Summary))
+	}
+	if item.CommentSummary != "" {
+		fmt.Fprintf(&b, "<p>%s</p>", html.EscapeString(item.CommentSummary))
+	}
+	return b.String()
+}
@@ -10,6 +10,11 @@ func LoadAndSelect(path string, personaName string) ([]Persona, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to load personas: %w", err)
 	}
+	return Select(personas, personaName)
+}
+
+// Select returns the persona with the given name, or all personas for "all" or an empty name
+func Select(personas []Persona, personaName string) ([]Persona, error) {
 	if len(personas) == 0 {
 		return nil, fmt.Errorf("no personas found in directory")
 	}
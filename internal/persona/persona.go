@@ -1,21 +1,46 @@
 package persona
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"text/template"
+	"time"
 
+	"github.com/bakkerme/ai-news-processor/internal/templatefuncs"
+	"github.com/bakkerme/ai-news-processor/internal/watchlist"
 	"gopkg.in/yaml.v3"
 )
 
 type Persona struct {
 	Name      string `yaml:"name" json:"name"`           // Unique name for the persona (e.g., "LocalLLaMA")
-	Provider  string `yaml:"provider" json:"provider"`   // Data source provider: "reddit" or "rss" (defaults to "reddit" if not specified)
+	Provider  string `yaml:"provider" json:"provider"`   // Data source provider: "reddit", "rss", "mastodon", "imap", "youtube", "ingest" or "plugin" (defaults to "reddit" if not specified)
 	Subreddit string `yaml:"subreddit" json:"subreddit"` // Subreddit name (e.g., "localllama") - used for reddit provider
 	FeedURL   string `yaml:"feed_url" json:"feedURL"`    // RSS feed URL - used for rss provider
 	Topic     string `yaml:"topic" json:"topic"`         // Main subject area (e.g., "AI Technology", "Gardening")
 
+	// Mastodon provider
+	MastodonInstance string   `yaml:"mastodon_instance,omitempty" json:"mastodonInstance,omitempty"` // Base URL of the instance the timelines are read from (e.g., "https://mastodon.social")
+	MastodonHashtags []string `yaml:"mastodon_hashtags,omitempty" json:"mastodonHashtags,omitempty"` // Hashtags whose public timelines are read, without the #
+	MastodonAccounts []string `yaml:"mastodon_accounts,omitempty" json:"mastodonAccounts,omitempty"` // Accounts whose posts are read, as user or user@domain
+
+	// IMAP provider
+	ImapMailboxes []string `yaml:"imap_mailboxes,omitempty" json:"imapMailboxes,omitempty"` // Mailboxes or labels newsletters are read from (defaults to INBOX)
+	ImapSenders   []string `yaml:"imap_senders,omitempty" json:"imapSenders,omitempty"`     // Sender addresses or domains whose messages are read (e.g., "substack.com")
+
+	// YouTube provider
+	YoutubeChannels  []string `yaml:"youtube_channels,omitempty" json:"youtubeChannels,omitempty"`   // Channel IDs whose latest videos are read (e.g., "UCbfYPyITQ-7l4upoX8nvctg")
+	YoutubePlaylists []string `yaml:"youtube_playlists,omitempty" json:"youtubePlaylists,omitempty"` // Playlist IDs whose latest videos are read, such as the talks of a conference
+	YoutubeLanguage  string   `yaml:"youtube_language,omitempty" json:"youtubeLanguage,omitempty"`   // Language of the transcripts (defaults to "en")
+
+	// Plugin provider
+	Plugin        string            `yaml:"plugin,omitempty" json:"plugin,omitempty"`                // Name of the executable in the plugins directory, without its extension
+	PluginOptions map[string]string `yaml:"plugin_options,omitempty" json:"pluginOptions,omitempty"` // Settings passed to the plugin with every request
+
 	// Persona identity (separated from specific task instructions)
 	PersonaIdentity string `yaml:"persona_identity" json:"personaIdentity"` // Core identity and expertise of the persona
 
@@ -29,10 +54,228 @@ type Persona struct {
 	SummaryAnalysis   []string `yaml:"summary_analysis" json:"summaryAnalysis"`     // Focus areas for summary analysis
 	ExclusionCriteria []string `yaml:"exclusion_criteria" json:"exclusionCriteria"` // List of criteria to explicitly exclude items
 
+	// Few-shot examples
+	ExamplesDir      string `yaml:"examples_dir,omitempty" json:"examplesDir,omitempty"`            // Directory of curated entries with their expected responses, shown to the LLM in the entry prompt, relative to the persona file
+	MaxExampleTokens int    `yaml:"max_example_tokens,omitempty" json:"maxExampleTokens,omitempty"` // Token budget of the examples (defaults to 1500); examples that do not fit are left out
+
+	// Claims
+	ExtractClaims bool `yaml:"extract_claims,omitempty" json:"extractClaims,omitempty"` // Ask the LLM for the factual claims of each item with their sources, shown as footnotes in the email
+
+	// Custom fields
+	Fields []Field `yaml:"fields,omitempty" json:"fields,omitempty"` // Extra structured fields the LLM fills in for each item, shown in the email and the digest JSON
+
+	// Long posts
+	DenseSummaryTokens int `yaml:"dense_summary_tokens,omitempty" json:"denseSummaryTokens,omitempty"` // Entries of at least this many tokens get their summaries rewritten in a second, denser pass (0 disables)
+
+	// Megathreads
+	Megathreads Megathreads `yaml:"megathreads,omitempty" json:"megathreads,omitempty"` // How live threads and megathreads are detected, which are summarized by the themes of their discussion
+
+	// Uncertain relevance
+	Unsure Unsure `yaml:"unsure,omitempty" json:"unsure,omitempty"` // What happens to items whose relevance the LLM is unsure of
+
 	// Quality filtering
-	CommentThreshold *int `yaml:"comment_threshold,omitempty" json:"commentThreshold,omitempty"` // Minimum number of comments for posts (optional, uses global default if not specified)
+	CommentThreshold   *int      `yaml:"comment_threshold,omitempty" json:"commentThreshold,omitempty"`      // Minimum number of comments for posts (optional, uses global default if not specified)
+	MinImportanceScore *int      `yaml:"min_importance_score,omitempty" json:"minImportanceScore,omitempty"` // Minimum LLM importance score (1-10) for relevant items to be sent (optional, uses global default if not specified)
+	MinViews           int       `yaml:"min_views,omitempty" json:"minViews,omitempty"`                      // Minimum view count for entries of providers that report views (youtube)
+	MinLikes           int       `yaml:"min_likes,omitempty" json:"minLikes,omitempty"`                      // Minimum like count for entries of providers that report likes (youtube)
+	Watchlist          []string  `yaml:"watchlist,omitempty" json:"watchlist,omitempty"`                     // Keywords or /regular expressions/ whose entries are always included, bypassing the quality and relevance filters
+	Blocklist          Blocklist `yaml:"blocklist,omitempty" json:"blocklist,omitempty"`                     // Entries dropped before any enrichment or LLM call
+
+	// RSS backfilling
+	BackfillPages   int    `yaml:"backfill_pages,omitempty" json:"backfillPages,omitempty"`     // Number of older feed pages to fetch until already-sent entries are reached (rss provider only)
+	PaginationParam string `yaml:"pagination_param,omitempty" json:"paginationParam,omitempty"` // Query parameter used for page numbers (e.g. "page" or "paged") when the feed has no RFC 5005 links
+
+	// Localization of the digest email chrome (dates, numbers, headings), independent of the LLM output language
+	Locale string `yaml:"locale,omitempty" json:"locale,omitempty"` // BCP 47 tag such as "de" or "nl-BE" (defaults to "en")
+
+	// External URL fetching
+	FetchDenyDomains  []string `yaml:"fetch_deny_domains,omitempty" json:"fetchDenyDomains,omitempty"`   // Domains whose URLs are never fetched or summarized, in addition to the global list
+	FetchAllowDomains []string `yaml:"fetch_allow_domains,omitempty" json:"fetchAllowDomains,omitempty"` // Domains that are always fetched, overriding any deny list
+
+	// Image-only post exclusion
+	ExcludeImageOnly          bool `yaml:"exclude_image_only,omitempty" json:"excludeImageOnly,omitempty"`                    // Drop posts with images but no text or links before any vision or LLM call
+	ImageOnlyCommentThreshold int  `yaml:"image_only_comment_threshold,omitempty" json:"imageOnlyCommentThreshold,omitempty"` // Image-only posts with at least this many comments are kept (0 drops them all)
+
+	// Push notifications
+	Notify       string `yaml:"notify,omitempty" json:"notify,omitempty"`              // When to send a push notification: "all" (default), "failures" or "off"
+	NtfyTopic    string `yaml:"ntfy_topic,omitempty" json:"ntfyTopic,omitempty"`       // ntfy topic for this persona, overriding ANP_NTFY_TOPIC
+	PushoverUser string `yaml:"pushover_user,omitempty" json:"pushoverUser,omitempty"` // Pushover user or group key for this persona, overriding ANP_PUSHOVER_USER
+
+	// Delivery
+	Recipients      []string `yaml:"recipients,omitempty" json:"recipients,omitempty"`            // Addresses the digest is sent to, overriding ANP_EMAIL_TO
+	SubjectTemplate string   `yaml:"subject_template,omitempty" json:"subjectTemplate,omitempty"` // Subject line template with {{.Persona}}, {{.Topic}}, {{.Date}} and {{.Count}} (defaults to the locale's title)
+	SendWindows     []string `yaml:"send_windows,omitempty" json:"sendWindows,omitempty"`         // Times the digest may be sent, such as "mon-fri 07:00-09:00"; runs outside them are skipped
+	Timezone        string   `yaml:"timezone,omitempty" json:"timezone,omitempty"`                // IANA time zone of the send windows, quiet hours and subject date (defaults to the local time zone)
+	QuietHours      []string `yaml:"quiet_hours,omitempty" json:"quietHours,omitempty"`           // Times no digest is sent, such as "sat,sun 00:00-24:00"; items found then are held for the next digest
+	MinItems        int      `yaml:"min_items,omitempty" json:"minItems,omitempty"`               // Digests with fewer relevant items are held and their items sent with the next digest
+
+	// Email template overrides
+	TemplateDir string `yaml:"template_dir,omitempty" json:"templateDir,omitempty"` // Directory with email_template.tmpl and/or rollup_template.tmpl replacing the built-in templates, relative to the persona file
+
+	// Digest layout
+	DigestOrder    string `yaml:"digest_order,omitempty" json:"digestOrder,omitempty"`        // Item order: "feed" (default) or "importance"
+	DigestGroupBy  string `yaml:"digest_group_by,omitempty" json:"digestGroupBy,omitempty"`   // Item sections: "none" (default), "topic" or "flair"
+	TopStory       bool   `yaml:"top_story,omitempty" json:"topStory,omitempty"`              // Lead the digest with the story of the first key development
+	DigestMaxItems int    `yaml:"digest_max_items,omitempty" json:"digestMaxItems,omitempty"` // Items rendered in full; the less important rest is listed as links under "Also noteworthy" (0 renders all)
+
+	// Outputs
+	Outputs []Output `yaml:"outputs,omitempty" json:"outputs,omitempty"` // Channels the digest is delivered to (defaults to email only)
+}
+
+// Output is a channel the digest of a persona is delivered to. The options that apply depend on
+// the type. URLs and headers may reference environment variables as ${NAME}, so secrets such as
+// webhook URLs stay out of the persona file.
+type Output struct {
+	Type       string            `yaml:"type" json:"type"`                                 // One of the Output* types
+	Recipients []string          `yaml:"recipients,omitempty" json:"recipients,omitempty"` // email: addresses replacing the persona's recipients
+	URL        string            `yaml:"url,omitempty" json:"url,omitempty"`               // slack, discord, webhook: URL the digest is posted to; podcast: OpenAI-compatible speech endpoint
+	Headers    map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`       // webhook, podcast: extra request headers, such as Authorization
+	Path       string            `yaml:"path,omitempty" json:"path,omitempty"`             // rss-file: feed file; json-file, markdown, podcast: directory digests are written to
+	MaxItems   int               `yaml:"max_items,omitempty" json:"maxItems,omitempty"`    // rss-file: number of items kept in the feed (defaults to 50); podcast: number of episodes kept (defaults to 20)
+	Model      string            `yaml:"model,omitempty" json:"model,omitempty"`           // podcast: speech model (defaults to "tts-1")
+	Voice      string            `yaml:"voice,omitempty" json:"voice,omitempty"`           // podcast: speech voice (defaults to "alloy")
+	BaseURL    string            `yaml:"base_url,omitempty" json:"baseUrl,omitempty"`      // podcast: public URL the path is served at, for the episode links in the feed
+}
+
+// Blocklist lists the entries a persona never wants to see. Blocked entries are dropped as soon as
+// the feed is fetched, before comments, pages or images are loaded.
+type Blocklist struct {
+	Authors       []string `yaml:"authors,omitempty" json:"authors,omitempty"`              // Reddit usernames or RSS authors, ignoring case
+	Domains       []string `yaml:"domains,omitempty" json:"domains,omitempty"`              // Domains of the entry link or linked pages, including their subdomains
+	TitlePatterns []string `yaml:"title_patterns,omitempty" json:"titlePatterns,omitempty"` // Regular expressions matched against the title, ignoring case
+}
+
+// Megathreads configures how a persona detects live threads and megathreads, posts whose value is
+// in their discussion rather than in the post. They are summarized with the megathread prompt.
+type Megathreads struct {
+	Disabled      bool     `yaml:"disabled,omitempty" json:"disabled,omitempty"`            // Summarize megathreads like any other post
+	TitlePatterns []string `yaml:"title_patterns,omitempty" json:"titlePatterns,omitempty"` // Regular expressions matched against the title, ignoring case, replacing the built-in patterns
+	MinComments   int      `yaml:"min_comments,omitempty" json:"minComments,omitempty"`     // Posts with at least this many comments are megathreads whatever their title (defaults to 100, -1 disables)
+}
+
+// Unsure configures the items whose relevance the LLM is unsure of. Rather than forcing a yes or
+// no, they are listed apart in the digest or judged again with a second opinion.
+type Unsure struct {
+	MinConfidence int    `yaml:"min_confidence,omitempty" json:"minConfidence,omitempty"` // Relevance decisions less confident than this (1-100) are unsure (0 trusts every decision)
+	Action        string `yaml:"action,omitempty" json:"action,omitempty"`                // One of the Unsure* actions (defaults to "list")
+}
+
+// Unsure actions
+const (
+	UnsureList          = "list"           // List unsure items in a collapsed section of the digest
+	UnsureSecondOpinion = "second_opinion" // Judge unsure items again with a short prompt, listing those still unsure
+)
+
+// GetAction returns what happens to unsure items, defaulting to listing them
+func (u Unsure) GetAction() string {
+	if u.Action == "" {
+		return UnsureList
+	}
+	return u.Action
+}
+
+// Field is a structured field a persona adds to its items, such as the license of a model or its
+// benchmark results
+type Field struct {
+	Name        string `yaml:"name" json:"name"`                       // Key of the field in the LLM response and the digest JSON, such as "license"
+	Type        string `yaml:"type,omitempty" json:"type,omitempty"`   // One of the Field* types (defaults to "string")
+	Description string `yaml:"description" json:"description"`         // What the LLM fills in, such as "The license the model weights are released under"
+	Label       string `yaml:"label,omitempty" json:"label,omitempty"` // Shown before the value in the email (defaults to the name)
+}
+
+// Field types
+const (
+	FieldString  = "string"
+	FieldNumber  = "number"
+	FieldInteger = "integer"
+	FieldBoolean = "boolean"
+	FieldList    = "list" // A list of strings
+)
+
+// GetType returns the type of the field, defaulting to a string
+func (f Field) GetType() string {
+	if f.Type == "" {
+		return FieldString
+	}
+	return f.Type
+}
+
+// GetLabel returns what is shown before the value of the field, defaulting to its name
+func (f Field) GetLabel() string {
+	if f.Label == "" {
+		return f.Name
+	}
+	return f.Label
+}
+
+var fieldNamePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]*$`)
+
+// ReservedFieldNames are the keys of built-in item fields, which custom fields cannot use
+var ReservedFieldNames = []string{
+	"id", "title", "link", "overview", "summary", "commentSummary", "imageDescription", "webContentSummary",
+	"isRelevant", "relevanceToCriteria", "thumbnailUrl", "entities", "topics", "unavailable", "watchlist",
+	"claims", "canonicalId", "updated", "megathread", "entry", "importanceScore", "importanceReason",
+	"relevanceConfidence", "unsure", "fields",
+}
+
+// validateFields checks that custom fields have unique names that do not shadow built-in fields
+func validateFields(fields []Field) error {
+	seen := make(map[string]bool, len(fields))
+	for _, name := range ReservedFieldNames {
+		seen[strings.ToLower(name)] = true
+	}
+	for _, f := range fields {
+		if !fieldNamePattern.MatchString(f.Name) {
+			return fmt.Errorf("invalid field name '%s', must start with a letter and contain only letters, digits and underscores", f.Name)
+		}
+		if seen[strings.ToLower(f.Name)] {
+			return fmt.Errorf("field name '%s' is used twice or by a built-in item field", f.Name)
+		}
+		seen[strings.ToLower(f.Name)] = true
+		switch f.GetType() {
+		case FieldString, FieldNumber, FieldInteger, FieldBoolean, FieldList:
+		default:
+			return fmt.Errorf("field %s: unsupported type '%s', must be 'string', 'number', 'integer', 'boolean' or 'list'", f.Name, f.Type)
+		}
+		if strings.TrimSpace(f.Description) == "" {
+			return fmt.Errorf("field %s: description is required", f.Name)
+		}
+	}
+	return nil
 }
 
+// Output types
+const (
+	OutputEmail    = "email"
+	OutputSlack    = "slack"
+	OutputDiscord  = "discord"
+	OutputWebhook  = "webhook"
+	OutputRSSFile  = "rss-file"
+	OutputJSONFile = "json-file"
+	OutputMarkdown = "markdown"
+	OutputPodcast  = "podcast"
+)
+
+// Notify settings
+const (
+	NotifyAll      = "all"
+	NotifyFailures = "failures"
+	NotifyOff      = "off"
+)
+
+// Digest orders
+const (
+	OrderFeed       = "feed"
+	OrderImportance = "importance"
+)
+
+// Digest groupings
+const (
+	GroupNone  = "none"
+	GroupTopic = "topic"
+	GroupFlair = "flair"
+)
+
 // GetProvider returns the effective provider for this persona.
 // If the persona has a provider set, it uses that. Otherwise, it defaults to "reddit" for backward compatibility.
 func (p *Persona) GetProvider() string {
@@ -42,6 +285,22 @@ func (p *Persona) GetProvider() string {
 	return "reddit" // Default to reddit for backward compatibility
 }
 
+// GetNotify returns when this persona sends push notifications, defaulting to NotifyAll
+func (p *Persona) GetNotify() string {
+	if p.Notify != "" {
+		return p.Notify
+	}
+	return NotifyAll
+}
+
+// GetOutputs returns the channels the digest is delivered to, defaulting to email only
+func (p *Persona) GetOutputs() []Output {
+	if len(p.Outputs) > 0 {
+		return p.Outputs
+	}
+	return []Output{{Type: OutputEmail}}
+}
+
 // GetCommentThreshold returns the effective comment threshold for this persona.
 // If the persona has a specific threshold set, it uses that. Otherwise, it falls back to the provided default.
 func (p *Persona) GetCommentThreshold(defaultThreshold int) int {
@@ -51,6 +310,15 @@ func (p *Persona) GetCommentThreshold(defaultThreshold int) int {
 	return defaultThreshold
 }
 
+// GetMinImportanceScore returns the effective minimum importance score for this persona, falling
+// back to the provided default if the persona has none set
+func (p *Persona) GetMinImportanceScore(defaultScore int) int {
+	if p.MinImportanceScore != nil {
+		return *p.MinImportanceScore
+	}
+	return defaultScore
+}
+
 // Validate checks if the persona configuration is valid for its provider type
 func (p *Persona) Validate() error {
 	provider := p.GetProvider()
@@ -68,40 +336,237 @@ func (p *Persona) Validate() error {
 		if !strings.HasPrefix(p.FeedURL, "http://") && !strings.HasPrefix(p.FeedURL, "https://") {
 			return fmt.Errorf("persona %s: feed_url must be a valid HTTP/HTTPS URL", p.Name)
 		}
+	case "mastodon":
+		if !strings.HasPrefix(p.MastodonInstance, "http://") && !strings.HasPrefix(p.MastodonInstance, "https://") {
+			return fmt.Errorf("persona %s: mastodon_instance must be a valid HTTP/HTTPS URL for mastodon provider", p.Name)
+		}
+		if len(p.MastodonHashtags) == 0 && len(p.MastodonAccounts) == 0 {
+			return fmt.Errorf("persona %s: mastodon_hashtags or mastodon_accounts is required for mastodon provider", p.Name)
+		}
+	case "imap":
+		if len(p.ImapMailboxes) == 0 && len(p.ImapSenders) == 0 {
+			return fmt.Errorf("persona %s: imap_mailboxes or imap_senders is required for imap provider", p.Name)
+		}
+	case "youtube":
+		if len(p.YoutubeChannels) == 0 && len(p.YoutubePlaylists) == 0 {
+			return fmt.Errorf("persona %s: youtube_channels or youtube_playlists is required for youtube provider", p.Name)
+		}
+		for _, channel := range p.YoutubeChannels {
+			if !strings.HasPrefix(channel, "UC") {
+				return fmt.Errorf("persona %s: youtube_channels must be channel IDs starting with UC, got '%s'", p.Name, channel)
+			}
+		}
+	case "ingest":
+		// Items are pushed to the daemon's ingest endpoint, there is nothing to configure
+	case "plugin":
+		if p.Plugin == "" {
+			return fmt.Errorf("persona %s: plugin is required for plugin provider", p.Name)
+		}
+		if strings.ContainsAny(p.Plugin, `/\`) || strings.HasPrefix(p.Plugin, ".") {
+			return fmt.Errorf("persona %s: plugin must be the name of an executable in the plugins directory, not a path", p.Name)
+		}
 	default:
-		return fmt.Errorf("persona %s: unsupported provider '%s', must be 'reddit' or 'rss'", p.Name, provider)
+		return fmt.Errorf("persona %s: unsupported provider '%s', must be 'reddit', 'rss', 'mastodon', 'imap', 'youtube', 'ingest' or 'plugin'", p.Name, provider)
+	}
+
+	switch p.GetNotify() {
+	case NotifyAll, NotifyFailures, NotifyOff:
+	default:
+		return fmt.Errorf("persona %s: unsupported notify setting '%s', must be 'all', 'failures' or 'off'", p.Name, p.Notify)
+	}
+
+	for _, recipient := range p.Recipients {
+		if !strings.Contains(recipient, "@") {
+			return fmt.Errorf("persona %s: invalid recipient '%s'", p.Name, recipient)
+		}
+	}
+	if p.SubjectTemplate != "" {
+		if _, err := template.New("subject").Funcs(templatefuncs.FuncMap()).Parse(p.SubjectTemplate); err != nil {
+			return fmt.Errorf("persona %s: invalid subject template: %w", p.Name, err)
+		}
+	}
+	for _, window := range p.SendWindows {
+		if _, err := parseSendWindow(window); err != nil {
+			return fmt.Errorf("persona %s: %w", p.Name, err)
+		}
+	}
+	for _, window := range p.QuietHours {
+		if _, err := parseSendWindow(window); err != nil {
+			return fmt.Errorf("persona %s: quiet hours: %w", p.Name, err)
+		}
+	}
+	if p.MinItems < 0 {
+		return fmt.Errorf("persona %s: min_items cannot be negative", p.Name)
+	}
+	if p.Timezone != "" {
+		if _, err := time.LoadLocation(p.Timezone); err != nil {
+			return fmt.Errorf("persona %s: unknown timezone '%s'", p.Name, p.Timezone)
+		}
+	}
+	switch p.DigestOrder {
+	case "", OrderFeed, OrderImportance:
+	default:
+		return fmt.Errorf("persona %s: unsupported digest_order '%s', must be 'feed' or 'importance'", p.Name, p.DigestOrder)
+	}
+	if p.DigestMaxItems < 0 {
+		return fmt.Errorf("persona %s: digest_max_items cannot be negative", p.Name)
+	}
+	switch p.DigestGroupBy {
+	case "", GroupNone, GroupTopic, GroupFlair:
+	default:
+		return fmt.Errorf("persona %s: unsupported digest_group_by '%s', must be 'none', 'topic' or 'flair'", p.Name, p.DigestGroupBy)
+	}
+	if _, err := watchlist.Compile(p.Watchlist); err != nil {
+		return fmt.Errorf("persona %s: %w", p.Name, err)
+	}
+	for _, pattern := range p.Blocklist.TitlePatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("persona %s: invalid blocklist title pattern '%s': %w", p.Name, pattern, err)
+		}
+	}
+	for _, pattern := range p.Megathreads.TitlePatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("persona %s: invalid megathread title pattern '%s': %w", p.Name, pattern, err)
+		}
+	}
+	if p.Megathreads.MinComments < -1 {
+		return fmt.Errorf("persona %s: megathreads min_comments must be -1 or more", p.Name)
+	}
+	if p.Unsure.MinConfidence < 0 || p.Unsure.MinConfidence > 100 {
+		return fmt.Errorf("persona %s: unsure min_confidence must be between 0 and 100", p.Name)
+	}
+	switch p.Unsure.GetAction() {
+	case UnsureList, UnsureSecondOpinion:
+	default:
+		return fmt.Errorf("persona %s: unsupported unsure action '%s', must be 'list' or 'second_opinion'", p.Name, p.Unsure.Action)
+	}
+	if err := validateFields(p.Fields); err != nil {
+		return fmt.Errorf("persona %s: %w", p.Name, err)
+	}
+	for i, output := range p.Outputs {
+		if err := output.validate(); err != nil {
+			return fmt.Errorf("persona %s: output %d: %w", p.Name, i+1, err)
+		}
+	}
+	if p.TemplateDir != "" {
+		if info, err := os.Stat(p.TemplateDir); err != nil || !info.IsDir() {
+			return fmt.Errorf("persona %s: template_dir '%s' is not a directory", p.Name, p.TemplateDir)
+		}
+	}
+	if p.ExamplesDir != "" {
+		if info, err := os.Stat(p.ExamplesDir); err != nil || !info.IsDir() {
+			return fmt.Errorf("persona %s: examples_dir '%s' is not a directory", p.Name, p.ExamplesDir)
+		}
+	}
+	if p.MaxExampleTokens < 0 {
+		return fmt.Errorf("persona %s: max_example_tokens cannot be negative", p.Name)
+	}
+	if p.DenseSummaryTokens < 0 {
+		return fmt.Errorf("persona %s: dense_summary_tokens cannot be negative", p.Name)
 	}
 	
 	return nil
 }
 
+// validate checks that an output has the options its type needs
+func (o Output) validate() error {
+	switch o.Type {
+	case OutputEmail:
+		for _, recipient := range o.Recipients {
+			if !strings.Contains(recipient, "@") {
+				return fmt.Errorf("invalid recipient '%s'", recipient)
+			}
+		}
+	case OutputSlack, OutputDiscord, OutputWebhook:
+		if !isHTTPURL(o.URL) {
+			return fmt.Errorf("%s output needs an HTTP/HTTPS url", o.Type)
+		}
+	case OutputRSSFile, OutputJSONFile, OutputMarkdown:
+		if o.Path == "" {
+			return fmt.Errorf("%s output needs a path", o.Type)
+		}
+	case OutputPodcast:
+		if o.Path == "" {
+			return fmt.Errorf("%s output needs a path", o.Type)
+		}
+		if !isHTTPURL(o.URL) {
+			return fmt.Errorf("%s output needs the HTTP/HTTPS url of a speech endpoint", o.Type)
+		}
+		if !isHTTPURL(o.BaseURL) {
+			return fmt.Errorf("%s output needs an HTTP/HTTPS base_url the episodes are served at", o.Type)
+		}
+	default:
+		return fmt.Errorf("unsupported output type '%s', must be 'email', 'slack', 'discord', 'webhook', 'rss-file', 'json-file', 'markdown' or 'podcast'", o.Type)
+	}
+	if o.MaxItems < 0 {
+		return fmt.Errorf("max_items cannot be negative")
+	}
+	return nil
+}
+
+// isHTTPURL reports whether url is an HTTP/HTTPS URL, or starts with an environment variable that
+// is expanded to one when the digest is delivered
+func isHTTPURL(url string) bool {
+	return strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://") || strings.HasPrefix(url, "${")
+}
+
 // LoadPersonas loads all persona YAML files from the given directory
 func LoadPersonas(dir string) ([]Persona, error) {
-	files, err := os.ReadDir(dir)
+	files, err := PersonaFiles(dir)
 	if err != nil {
 		return nil, err
 	}
 	var personas []Persona
-	for _, file := range files {
-		if file.IsDir() || filepath.Ext(file.Name()) != ".yaml" {
-			continue
-		}
-		path := filepath.Join(dir, file.Name())
-		data, err := os.ReadFile(path)
+	for _, path := range files {
+		persona, err := LoadFile(path, false)
 		if err != nil {
 			return nil, err
 		}
-		var persona Persona
-		if err := yaml.Unmarshal(data, &persona); err != nil {
-			return nil, err
-		}
-		
-		// Validate persona configuration
-		if err := persona.Validate(); err != nil {
-			return nil, fmt.Errorf("invalid persona in file %s: %w", file.Name(), err)
-		}
-		
 		personas = append(personas, persona)
 	}
 	return personas, nil
 }
+
+// PersonaFiles returns the paths of the persona YAML files in the given directory
+func PersonaFiles(dir string) ([]string, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".yaml" {
+			continue
+		}
+		paths = append(paths, filepath.Join(dir, file.Name()))
+	}
+	return paths, nil
+}
+
+// LoadFile loads and validates a single persona file. In strict mode, keys that do not match a
+// persona field are an error, which catches misspelled settings that would otherwise be ignored.
+func LoadFile(path string, strict bool) (Persona, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Persona{}, err
+	}
+
+	var persona Persona
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(strict)
+	if err := decoder.Decode(&persona); err != nil && err != io.EOF {
+		return Persona{}, fmt.Errorf("invalid persona in file %s: %w", filepath.Base(path), err)
+	}
+	if persona.TemplateDir != "" && !filepath.IsAbs(persona.TemplateDir) {
+		persona.TemplateDir = filepath.Join(filepath.Dir(path), persona.TemplateDir)
+	}
+	if persona.ExamplesDir != "" && !filepath.IsAbs(persona.ExamplesDir) {
+		persona.ExamplesDir = filepath.Join(filepath.Dir(path), persona.ExamplesDir)
+	}
+
+	// Validate persona configuration
+	if err := persona.Validate(); err != nil {
+		return Persona{}, fmt.Errorf("invalid persona in file %s: %w", filepath.Base(path), err)
+	}
+	return persona, nil
+}
@@ -249,6 +249,100 @@ func TestPersona_Validate(t *testing.T) {
 			expectError: true,
 			errorMsg:    "feed_url must be a valid HTTP/HTTPS URL",
 		},
+		{
+			name: "valid mastodon persona",
+			persona: Persona{
+				Name:             "Test",
+				Provider:         "mastodon",
+				MastodonInstance: "https://mastodon.social",
+				MastodonHashtags: []string{"llm"},
+			},
+			expectError: false,
+		},
+		{
+			name: "mastodon persona without timelines",
+			persona: Persona{
+				Name:             "Test",
+				Provider:         "mastodon",
+				MastodonInstance: "https://mastodon.social",
+			},
+			expectError: true,
+			errorMsg:    "mastodon_hashtags or mastodon_accounts is required",
+		},
+		{
+			name: "valid imap persona",
+			persona: Persona{
+				Name:        "Test",
+				Provider:    "imap",
+				ImapSenders: []string{"substack.com"},
+			},
+			expectError: false,
+		},
+		{
+			name: "imap persona without mailboxes or senders",
+			persona: Persona{
+				Name:     "Test",
+				Provider: "imap",
+			},
+			expectError: true,
+			errorMsg:    "imap_mailboxes or imap_senders is required",
+		},
+		{
+			name: "valid youtube persona",
+			persona: Persona{
+				Name:             "Test",
+				Provider:         "youtube",
+				YoutubePlaylists: []string{"PLOXw6I10VTv8VOvPNVQ8c4H9nL7VBjwq5"},
+			},
+			expectError: false,
+		},
+		{
+			name: "youtube persona with a channel handle",
+			persona: Persona{
+				Name:            "Test",
+				Provider:        "youtube",
+				YoutubeChannels: []string{"@aiexplained"},
+			},
+			expectError: true,
+			errorMsg:    "youtube_channels must be channel IDs",
+		},
+		{
+			name: "valid ingest persona",
+			persona: Persona{
+				Name:     "Test",
+				Provider: "ingest",
+			},
+			expectError: false,
+		},
+		{
+			name: "valid plugin persona",
+			persona: Persona{
+				Name:          "Test",
+				Provider:      "plugin",
+				Plugin:        "hackernews",
+				PluginOptions: map[string]string{"min_points": "100"},
+			},
+			expectError: false,
+		},
+		{
+			name: "plugin persona without plugin",
+			persona: Persona{
+				Name:     "Test",
+				Provider: "plugin",
+			},
+			expectError: true,
+			errorMsg:    "plugin is required for plugin provider",
+		},
+		{
+			name: "plugin persona with a path",
+			persona: Persona{
+				Name:     "Test",
+				Provider: "plugin",
+				Plugin:   "../bin/hackernews",
+			},
+			expectError: true,
+			errorMsg:    "plugin must be the name of an executable",
+		},
 		{
 			name: "unsupported provider",
 			persona: Persona{
@@ -258,6 +352,232 @@ func TestPersona_Validate(t *testing.T) {
 			expectError: true,
 			errorMsg:    "unsupported provider 'unsupported'",
 		},
+		{
+			name: "unsupported notify setting",
+			persona: Persona{
+				Name:      "Test",
+				Subreddit: "test",
+				Notify:    "always",
+			},
+			expectError: true,
+			errorMsg:    "unsupported notify setting 'always'",
+		},
+		{
+			name: "unsupported digest grouping",
+			persona: Persona{
+				Name:          "Test",
+				Subreddit:     "test",
+				DigestGroupBy: "author",
+			},
+			expectError: true,
+			errorMsg:    "unsupported digest_group_by 'author'",
+		},
+		{
+			name: "invalid send window",
+			persona: Persona{
+				Name:        "Test",
+				Subreddit:   "test",
+				SendWindows: []string{"weekdays 07:00-09:00"},
+			},
+			expectError: true,
+			errorMsg:    "unknown weekday",
+		},
+		{
+			name: "invalid quiet hours",
+			persona: Persona{
+				Name:       "Test",
+				Subreddit:  "test",
+				QuietHours: []string{"sat,sun all day"},
+			},
+			expectError: true,
+			errorMsg:    "quiet hours: send window",
+		},
+		{
+			name: "unsure confidence above 100",
+			persona: Persona{
+				Name:      "Test",
+				Subreddit: "test",
+				Unsure:    Unsure{MinConfidence: 101},
+			},
+			expectError: true,
+			errorMsg:    "unsure min_confidence must be between 0 and 100",
+		},
+		{
+			name: "unsupported unsure action",
+			persona: Persona{
+				Name:      "Test",
+				Subreddit: "test",
+				Unsure:    Unsure{MinConfidence: 60, Action: "drop"},
+			},
+			expectError: true,
+			errorMsg:    "unsupported unsure action 'drop'",
+		},
+		{
+			name: "negative min items",
+			persona: Persona{
+				Name:      "Test",
+				Subreddit: "test",
+				MinItems:  -1,
+			},
+			expectError: true,
+			errorMsg:    "min_items cannot be negative",
+		},
+		{
+			name: "invalid subject template",
+			persona: Persona{
+				Name:            "Test",
+				Subreddit:       "test",
+				SubjectTemplate: "{{.Topic",
+			},
+			expectError: true,
+			errorMsg:    "invalid subject template",
+		},
+		{
+			name: "valid outputs",
+			persona: Persona{
+				Name:      "Test",
+				Subreddit: "test",
+				Outputs: []Output{
+					{Type: OutputEmail, Recipients: []string{"team@example.com"}},
+					{Type: OutputSlack, URL: "${SLACK_WEBHOOK_URL}"},
+					{Type: OutputRSSFile, Path: "feeds/test.xml", MaxItems: 20},
+					{Type: OutputPodcast, Path: "podcasts/test", URL: "${TTS_URL}", BaseURL: "https://example.com/podcasts/test"},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "podcast output missing base url",
+			persona: Persona{
+				Name:      "Test",
+				Subreddit: "test",
+				Outputs:   []Output{{Type: OutputPodcast, Path: "podcasts/test", URL: "https://api.openai.com/v1/audio/speech"}},
+			},
+			expectError: true,
+			errorMsg:    "output 1: podcast output needs an HTTP/HTTPS base_url",
+		},
+		{
+			name: "webhook output missing url",
+			persona: Persona{
+				Name:      "Test",
+				Subreddit: "test",
+				Outputs:   []Output{{Type: OutputWebhook}},
+			},
+			expectError: true,
+			errorMsg:    "output 1: webhook output needs an HTTP/HTTPS url",
+		},
+		{
+			name: "unsupported output type",
+			persona: Persona{
+				Name:      "Test",
+				Subreddit: "test",
+				Outputs:   []Output{{Type: OutputEmail}, {Type: "sms"}},
+			},
+			expectError: true,
+			errorMsg:    "output 2: unsupported output type 'sms'",
+		},
+		{
+			name: "invalid watchlist pattern",
+			persona: Persona{
+				Name:      "Test",
+				Subreddit: "test",
+				Watchlist: []string{"Qwen", "/deepseek(/"},
+			},
+			expectError: true,
+			errorMsg:    "invalid watchlist pattern '/deepseek(/'",
+		},
+		{
+			name: "invalid blocklist title pattern",
+			persona: Persona{
+				Name:      "Test",
+				Subreddit: "test",
+				Blocklist: Blocklist{TitlePatterns: []string{"(meme"}},
+			},
+			expectError: true,
+			errorMsg:    "invalid blocklist title pattern '(meme'",
+		},
+		{
+			name: "invalid megathread title pattern",
+			persona: Persona{
+				Name:        "Test",
+				Subreddit:   "test",
+				Megathreads: Megathreads{TitlePatterns: []string{"(daily"}},
+			},
+			expectError: true,
+			errorMsg:    "invalid megathread title pattern '(daily'",
+		},
+		{
+			name: "megathread min comments below -1",
+			persona: Persona{
+				Name:        "Test",
+				Subreddit:   "test",
+				Megathreads: Megathreads{MinComments: -2},
+			},
+			expectError: true,
+			errorMsg:    "megathreads min_comments must be -1 or more",
+		},
+		{
+			name: "valid custom fields",
+			persona: Persona{
+				Name:      "Test",
+				Subreddit: "test",
+				Fields: []Field{
+					{Name: "license", Description: "The license of the weights"},
+					{Name: "benchmarkNumbers", Type: FieldList, Description: "Benchmark results"},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "custom field named like a built-in field",
+			persona: Persona{
+				Name:      "Test",
+				Subreddit: "test",
+				Fields:    []Field{{Name: "Summary", Description: "Another summary"}},
+			},
+			expectError: true,
+			errorMsg:    "field name 'Summary' is used twice or by a built-in item field",
+		},
+		{
+			name: "custom field with invalid name",
+			persona: Persona{
+				Name:      "Test",
+				Subreddit: "test",
+				Fields:    []Field{{Name: "model params", Description: "Parameters"}},
+			},
+			expectError: true,
+			errorMsg:    "invalid field name 'model params'",
+		},
+		{
+			name: "custom field with unsupported type",
+			persona: Persona{
+				Name:      "Test",
+				Subreddit: "test",
+				Fields:    []Field{{Name: "released", Type: "date", Description: "Release date"}},
+			},
+			expectError: true,
+			errorMsg:    "field released: unsupported type 'date'",
+		},
+		{
+			name: "negative digest max items",
+			persona: Persona{
+				Name:           "Test",
+				Subreddit:      "test",
+				DigestMaxItems: -1,
+			},
+			expectError: true,
+			errorMsg:    "digest_max_items cannot be negative",
+		},
+		{
+			name: "custom field without description",
+			persona: Persona{
+				Name:      "Test",
+				Subreddit: "test",
+				Fields:    []Field{{Name: "license"}},
+			},
+			expectError: true,
+			errorMsg:    "field license: description is required",
+		},
 		{
 			name: "default reddit provider missing subreddit",
 			persona: Persona{
@@ -287,6 +607,27 @@ func TestPersona_Validate(t *testing.T) {
 	}
 }
 
+func TestLoadFile_Strict(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "typo.yaml")
+	content := "name: Test\nsubreddit: test\nfocus_area:\n  - misspelled\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := LoadFile(path, false)
+	if err != nil {
+		t.Fatalf("unknown keys should be ignored outside strict mode: %v", err)
+	}
+	if p.Name != "Test" {
+		t.Errorf("expected name Test, got %s", p.Name)
+	}
+
+	if _, err := LoadFile(path, true); err == nil || !strings.Contains(err.Error(), "focus_area") {
+		t.Errorf("expected strict mode to report the unknown key, got %v", err)
+	}
+}
+
 // Helper function to create an int pointer
 func intPtr(i int) *int {
 	return &i
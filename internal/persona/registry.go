@@ -0,0 +1,178 @@
+package persona
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Source provides the current personas
+type Source interface {
+	Personas() ([]Persona, error)
+}
+
+// Dir is a Source that loads the personas from a directory on every call
+type Dir string
+
+// Personas loads the personas in the directory
+func (d Dir) Personas() ([]Persona, error) {
+	return LoadPersonas(string(d))
+}
+
+// Registry is a Source that keeps the personas of a directory in memory and reloads them when
+// the files change. A change that fails to load or validate is logged and ignored, so the last
+// valid personas stay in use until the files are fixed.
+type Registry struct {
+	dir string
+
+	mu       sync.RWMutex
+	personas []Persona
+	stamps   map[string]fileStamp
+}
+
+// fileStamp identifies a version of a persona file
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// NewRegistry loads the personas in dir, failing if any of them is invalid
+func NewRegistry(dir string) (*Registry, error) {
+	r := &Registry{dir: dir}
+	stamps, err := r.scan()
+	if err != nil {
+		return nil, err
+	}
+	personas, err := LoadPersonas(dir)
+	if err != nil {
+		return nil, err
+	}
+	r.personas, r.stamps = personas, stamps
+	return r, nil
+}
+
+// Personas returns the personas last loaded successfully
+func (r *Registry) Personas() ([]Persona, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]Persona(nil), r.personas...), nil
+}
+
+// scan returns the version of every persona file in the directory
+func (r *Registry) scan() (map[string]fileStamp, error) {
+	files, err := PersonaFiles(r.dir)
+	if err != nil {
+		return nil, err
+	}
+	stamps := make(map[string]fileStamp, len(files))
+	for _, path := range files {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		stamps[path] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+	}
+	return stamps, nil
+}
+
+// Reload reloads the personas if any file was added, removed or modified since the last load, and
+// returns the changes. If the new files are invalid, the current personas are kept and the error
+// is returned; the same files are not retried until they change again.
+func (r *Registry) Reload() ([]string, error) {
+	stamps, err := r.scan()
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan personas: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if reflect.DeepEqual(stamps, r.stamps) {
+		return nil, nil
+	}
+	r.stamps = stamps
+
+	personas, err := LoadPersonas(r.dir)
+	if err != nil {
+		return nil, err
+	}
+	changes := DiffPersonas(r.personas, personas)
+	r.personas = personas
+	return changes, nil
+}
+
+// Watch reloads the personas every interval until the context is cancelled, logging what changed
+func (r *Registry) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		changes, err := r.Reload()
+		if err != nil {
+			log.Printf("Warning: persona changes not applied, keeping the previous personas: %v", err)
+			continue
+		}
+		if len(changes) > 0 {
+			log.Printf("Reloaded personas from %s:\n  %s", r.dir, strings.Join(changes, "\n  "))
+		}
+	}
+}
+
+// DiffPersonas describes the differences between two sets of personas: personas that were added
+// or removed, and the settings that changed for the others
+func DiffPersonas(before, after []Persona) []string {
+	previous := make(map[string]Persona, len(before))
+	for _, p := range before {
+		previous[p.Name] = p
+	}
+
+	var changes []string
+	current := make(map[string]bool, len(after))
+	for _, p := range after {
+		current[p.Name] = true
+		old, ok := previous[p.Name]
+		if !ok {
+			changes = append(changes, fmt.Sprintf("added %s", p.Name))
+			continue
+		}
+		if fields := changedFields(old, p); len(fields) > 0 {
+			changes = append(changes, fmt.Sprintf("changed %s: %s", p.Name, strings.Join(fields, ", ")))
+		}
+	}
+	for _, p := range before {
+		if !current[p.Name] {
+			changes = append(changes, fmt.Sprintf("removed %s", p.Name))
+		}
+	}
+	sort.Strings(changes)
+	return changes
+}
+
+// changedFields returns the YAML keys of the settings that differ between two personas
+func changedFields(a, b Persona) []string {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	t := va.Type()
+
+	var fields []string
+	for i := 0; i < t.NumField(); i++ {
+		if reflect.DeepEqual(va.Field(i).Interface(), vb.Field(i).Interface()) {
+			continue
+		}
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		if name == "" {
+			name = t.Field(i).Name
+		}
+		fields = append(fields, name)
+	}
+	return fields
+}
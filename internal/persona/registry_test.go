@@ -0,0 +1,76 @@
+package persona
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// writePersona writes a persona file and moves its modification time forward, so the change is
+// seen even on file systems with coarse timestamps
+func writePersona(t *testing.T, path, content string, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRegistry_Reload(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Now().Add(-time.Hour)
+	writePersona(t, filepath.Join(dir, "a.yaml"), "name: A\nsubreddit: a\n", start)
+
+	registry, err := NewRegistry(dir)
+	if err != nil {
+		t.Fatalf("NewRegistry: %v", err)
+	}
+
+	if changes, err := registry.Reload(); err != nil || len(changes) != 0 {
+		t.Fatalf("expected no changes for untouched files, got %v, %v", changes, err)
+	}
+
+	writePersona(t, filepath.Join(dir, "a.yaml"), "name: A\nsubreddit: a\nfocus_areas:\n  - models\n", start.Add(time.Minute))
+	writePersona(t, filepath.Join(dir, "b.yaml"), "name: B\nsubreddit: b\n", start.Add(time.Minute))
+	changes, err := registry.Reload()
+	if err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	want := []string{"added B", "changed A: focus_areas"}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("expected changes %v, got %v", want, changes)
+	}
+
+	// An invalid change is reported and the previous personas stay in use
+	writePersona(t, filepath.Join(dir, "b.yaml"), "name: B\nprovider: rss\n", start.Add(2*time.Minute))
+	if _, err := registry.Reload(); err == nil {
+		t.Error("expected an error for an invalid persona")
+	}
+	personas, _ := registry.Personas()
+	if len(personas) != 2 || personas[1].Subreddit != "b" {
+		t.Errorf("expected the previous personas to be kept, got %+v", personas)
+	}
+
+	if err := os.Remove(filepath.Join(dir, "b.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	changes, err = registry.Reload()
+	if err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if !reflect.DeepEqual(changes, []string{"removed B"}) {
+		t.Errorf("expected B to be removed, got %v", changes)
+	}
+}
+
+func TestNewRegistry_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	writePersona(t, filepath.Join(dir, "bad.yaml"), "name: Bad\n", time.Now())
+	if _, err := NewRegistry(dir); err == nil {
+		t.Error("expected an error for an invalid persona")
+	}
+}
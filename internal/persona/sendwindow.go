@@ -0,0 +1,159 @@
+package persona
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// sendWindow is a daily time range, optionally limited to some weekdays, in which a persona may
+// send its digest
+type sendWindow struct {
+	days       map[time.Weekday]bool // nil means every day
+	start, end int                   // Minutes since midnight; end before start wraps past midnight
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// parseSendWindow parses a window such as "07:00-09:00", "mon-fri 07:00-09:00" or
+// "sat,sun 22:00-02:00"
+func parseSendWindow(s string) (sendWindow, error) {
+	var w sendWindow
+	fields := strings.Fields(strings.ToLower(s))
+	switch len(fields) {
+	case 1:
+	case 2:
+		days, err := parseWeekdays(fields[0])
+		if err != nil {
+			return w, err
+		}
+		w.days = days
+		fields = fields[1:]
+	default:
+		return w, fmt.Errorf("send window %q must look like \"mon-fri 07:00-09:00\"", s)
+	}
+
+	start, end, ok := strings.Cut(fields[0], "-")
+	if !ok {
+		return w, fmt.Errorf("send window %q has no time range", s)
+	}
+	var err error
+	if w.start, err = parseClock(start); err != nil {
+		return w, err
+	}
+	if w.end, err = parseClock(end); err != nil {
+		return w, err
+	}
+	return w, nil
+}
+
+// parseWeekdays parses comma separated days and day ranges such as "mon-fri,sun"
+func parseWeekdays(s string) (map[time.Weekday]bool, error) {
+	days := make(map[time.Weekday]bool)
+	for _, part := range strings.Split(s, ",") {
+		from, to, isRange := strings.Cut(part, "-")
+		first, ok := weekdays[from]
+		if !ok {
+			return nil, fmt.Errorf("unknown weekday %q", from)
+		}
+		last := first
+		if isRange {
+			if last, ok = weekdays[to]; !ok {
+				return nil, fmt.Errorf("unknown weekday %q", to)
+			}
+		}
+		for d := first; ; d = (d + 1) % 7 {
+			days[d] = true
+			if d == last {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+// parseClock parses a time of day as minutes since midnight. "24:00" ends a window at the end of
+// the day.
+func parseClock(s string) (int, error) {
+	if s == "24:00" {
+		return 24 * 60, nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, must be HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// contains reports whether t falls in the window. A window wrapping past midnight belongs to the
+// day it starts on.
+func (w sendWindow) contains(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	day := t.Weekday()
+	switch {
+	case w.start <= w.end:
+		if minute < w.start || minute >= w.end {
+			return false
+		}
+	case minute >= w.start:
+	case minute < w.end:
+		day = (day + 6) % 7
+	default:
+		return false
+	}
+	return w.days == nil || w.days[day]
+}
+
+// Location returns the time zone of the persona's send windows, quiet hours and subject dates, defaulting to
+// the local time zone
+func (p *Persona) Location() *time.Location {
+	if p.Timezone != "" {
+		if loc, err := time.LoadLocation(p.Timezone); err == nil {
+			return loc
+		}
+	}
+	return time.Local
+}
+
+// InSendWindow reports whether the persona may send a digest at t. Personas without send windows
+// may always send.
+func (p *Persona) InSendWindow(t time.Time) bool {
+	if len(p.SendWindows) == 0 {
+		return true
+	}
+	t = t.In(p.Location())
+	for _, s := range p.SendWindows {
+		w, err := parseSendWindow(s)
+		if err == nil && w.contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// InQuietHours reports whether t falls in the persona's quiet hours, in which no digest is sent
+func (p *Persona) InQuietHours(t time.Time) bool {
+	t = t.In(p.Location())
+	for _, s := range p.QuietHours {
+		w, err := parseSendWindow(s)
+		if err == nil && w.contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// HoldReason returns why a digest of count items should be held for the persona's next digest
+// rather than sent at t, or an empty string if it may be sent
+func (p *Persona) HoldReason(count int, t time.Time) string {
+	if p.InQuietHours(t) {
+		return "in quiet hours"
+	}
+	if count < p.MinItems {
+		return fmt.Sprintf("%d items, fewer than %d", count, p.MinItems)
+	}
+	return ""
+}
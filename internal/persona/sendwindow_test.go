@@ -0,0 +1,96 @@
+package persona
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPersona_InSendWindow(t *testing.T) {
+	// 2024-06-03 is a Monday
+	monday := func(hour, minute int) time.Time {
+		return time.Date(2024, 6, 3, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name     string
+		windows  []string
+		at       time.Time
+		expected bool
+	}{
+		{"no windows", nil, monday(3, 0), true},
+		{"inside daily window", []string{"07:00-09:00"}, monday(7, 0), true},
+		{"end is exclusive", []string{"07:00-09:00"}, monday(9, 0), false},
+		{"weekday range", []string{"mon-fri 07:00-09:00"}, monday(8, 30), true},
+		{"other day", []string{"sat,sun 07:00-09:00"}, monday(8, 30), false},
+		{"second window", []string{"sat,sun 07:00-09:00", "mon 12:00-13:00"}, monday(12, 15), true},
+		{"past midnight on the start day", []string{"mon 22:00-02:00"}, monday(23, 0), true},
+		{"past midnight on the next day", []string{"sun 22:00-02:00"}, monday(1, 0), true},
+		{"past midnight from another day", []string{"mon 22:00-02:00"}, monday(1, 0), false},
+		{"wrapping day range", []string{"fri-mon 07:00-09:00"}, monday(8, 0), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Persona{Name: "Test", SendWindows: tt.windows, Timezone: "UTC"}
+			if got := p.InSendWindow(tt.at); got != tt.expected {
+				t.Errorf("InSendWindow(%s) = %v, expected %v", tt.at, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPersona_InSendWindow_Timezone(t *testing.T) {
+	p := Persona{Name: "Test", SendWindows: []string{"07:00-09:00"}, Timezone: "Europe/Amsterdam"}
+	// 06:30 UTC is 08:30 in Amsterdam in summer
+	at := time.Date(2024, 6, 3, 6, 30, 0, 0, time.UTC)
+	if !p.InSendWindow(at) {
+		t.Errorf("expected %s to be inside the Amsterdam send window", at)
+	}
+}
+
+func TestPersona_InQuietHours(t *testing.T) {
+	// 2024-06-01 is a Saturday
+	saturday := time.Date(2024, 6, 1, 23, 30, 0, 0, time.UTC)
+	monday := time.Date(2024, 6, 3, 6, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		quiet    []string
+		at       time.Time
+		expected bool
+	}{
+		{"no quiet hours", nil, monday, false},
+		{"whole weekend", []string{"sat,sun 00:00-24:00"}, saturday, true},
+		{"whole weekend on a weekday", []string{"sat,sun 00:00-24:00"}, monday, false},
+		{"nights", []string{"22:00-07:00"}, monday, true},
+		{"after the night", []string{"22:00-06:00"}, monday, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Persona{Name: "Test", QuietHours: tt.quiet, Timezone: "UTC"}
+			if got := p.InQuietHours(tt.at); got != tt.expected {
+				t.Errorf("InQuietHours(%s) = %v, expected %v", tt.at, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPersona_HoldReason(t *testing.T) {
+	monday := time.Date(2024, 6, 3, 12, 0, 0, 0, time.UTC)
+	p := Persona{Name: "Test", Timezone: "UTC"}
+	if reason := p.HoldReason(1, monday); reason != "" {
+		t.Errorf("expected a persona without rules to send, got %q", reason)
+	}
+
+	p.MinItems = 3
+	if reason := p.HoldReason(2, monday); reason != "2 items, fewer than 3" {
+		t.Errorf("unexpected reason for too few items: %q", reason)
+	}
+	if reason := p.HoldReason(3, monday); reason != "" {
+		t.Errorf("expected enough items to send, got %q", reason)
+	}
+
+	p.QuietHours = []string{"mon 09:00-17:00"}
+	if reason := p.HoldReason(10, monday); reason != "in quiet hours" {
+		t.Errorf("unexpected reason in quiet hours: %q", reason)
+	}
+}
@@ -0,0 +1,117 @@
+package prompts
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/internal/tokens"
+)
+
+// DefaultMaxExampleTokens is the token budget of the few-shot examples of a persona that does not set
+// max_example_tokens
+const DefaultMaxExampleTokens = 1500
+
+// Example file suffixes. An example is a pair of files with the same name, such as
+// 01-release.input.txt and 01-release.output.json.
+const (
+	exampleInputSuffix  = ".input.txt"
+	exampleOutputSuffix = ".output.json"
+)
+
+// exampleOverhead approximates the tokens of the headings and code fences around an example
+const exampleOverhead = 12
+
+// Example is a curated entry with the response expected for it, shown to the LLM as a few-shot
+// demonstration
+type Example struct {
+	Name   string
+	Input  string // The entry as it is sent to the LLM, such as the raw input in benchmark data
+	Output string // The expected JSON response
+}
+
+// Tokens returns the estimated number of tokens the example takes in a prompt
+func (e Example) Tokens() int {
+	return tokens.Estimate(e.Input) + tokens.Estimate(e.Output) + exampleOverhead
+}
+
+// LoadExamples reads the example pairs in dir, ordered by name. Every input needs an output, and
+// outputs must be valid JSON, so a broken example fails loudly instead of teaching the wrong format.
+func LoadExamples(dir string) ([]Example, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("could not read examples directory: %w", err)
+	}
+
+	var examples []Example
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), exampleInputSuffix) {
+			continue
+		}
+		name := strings.TrimSuffix(file.Name(), exampleInputSuffix)
+
+		input, err := os.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("could not read example %s: %w", name, err)
+		}
+		output, err := os.ReadFile(filepath.Join(dir, name+exampleOutputSuffix))
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, fmt.Errorf("example %s has no %s file", name, name+exampleOutputSuffix)
+			}
+			return nil, fmt.Errorf("could not read example %s: %w", name, err)
+		}
+		if !json.Valid(output) {
+			return nil, fmt.Errorf("example %s: %s is not valid JSON", name, name+exampleOutputSuffix)
+		}
+
+		examples = append(examples, Example{
+			Name:   name,
+			Input:  strings.TrimSpace(string(input)),
+			Output: strings.TrimSpace(string(output)),
+		})
+	}
+
+	sort.Slice(examples, func(i, j int) bool { return examples[i].Name < examples[j].Name })
+	return examples, nil
+}
+
+// SelectExamples returns the examples that fit in maxTokens, in order. An example too large for
+// the remaining budget is skipped, so a smaller one after it can still be used.
+func SelectExamples(examples []Example, maxTokens int) []Example {
+	var selected []Example
+	remaining := maxTokens
+	for _, example := range examples {
+		if cost := example.Tokens(); cost <= remaining {
+			selected = append(selected, example)
+			remaining -= cost
+		}
+	}
+	return selected
+}
+
+// personaExamples loads the examples of a persona and selects those that fit its token budget
+func personaExamples(p persona.Persona) ([]Example, error) {
+	if p.ExamplesDir == "" {
+		return nil, nil
+	}
+	examples, err := LoadExamples(p.ExamplesDir)
+	if err != nil {
+		return nil, fmt.Errorf("could not load examples of persona %s: %w", p.Name, err)
+	}
+
+	budget := p.MaxExampleTokens
+	if budget == 0 {
+		budget = DefaultMaxExampleTokens
+	}
+	selected := SelectExamples(examples, budget)
+	if len(selected) < len(examples) {
+		log.Printf("Using %d of %d examples for persona %s, the others do not fit in %d tokens", len(selected), len(examples), p.Name, budget)
+	}
+	return selected, nil
+}
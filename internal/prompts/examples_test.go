@@ -0,0 +1,72 @@
+package prompts
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeExample(t *testing.T, dir, name, input, output string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name+".input.txt"), []byte(input), 0644))
+	if output != "" {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name+".output.json"), []byte(output), 0644))
+	}
+}
+
+func TestLoadExamples(t *testing.T) {
+	dir := t.TempDir()
+	writeExample(t, dir, "02-funding", "Title: Startup raises $50M\n", `{"isRelevant":false}`)
+	writeExample(t, dir, "01-release", "Title: Llama 4 released\n", "{\"isRelevant\":true}\n")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("notes"), 0644))
+
+	examples, err := LoadExamples(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []Example{
+		{Name: "01-release", Input: "Title: Llama 4 released", Output: `{"isRelevant":true}`},
+		{Name: "02-funding", Input: "Title: Startup raises $50M", Output: `{"isRelevant":false}`},
+	}, examples)
+
+	writeExample(t, dir, "03-missing", "Title: No output", "")
+	_, err = LoadExamples(dir)
+	assert.ErrorContains(t, err, "example 03-missing has no 03-missing.output.json file")
+
+	require.NoError(t, os.Remove(filepath.Join(dir, "03-missing.input.txt")))
+	writeExample(t, dir, "04-broken", "Title: Broken", "```json\n{}\n```")
+	_, err = LoadExamples(dir)
+	assert.ErrorContains(t, err, "04-broken.output.json is not valid JSON")
+}
+
+func TestSelectExamples(t *testing.T) {
+	large := Example{Name: "large", Input: strings.Repeat("word ", 200), Output: "{}"}
+	small := Example{Name: "small", Input: "Title: Short", Output: "{}"}
+	examples := []Example{small, large, small}
+
+	assert.Equal(t, []Example{small, small}, SelectExamples(examples, 2*small.Tokens()), "the large example is skipped")
+	assert.Equal(t, examples, SelectExamples(examples, 2*small.Tokens()+large.Tokens()))
+	assert.Empty(t, SelectExamples(examples, small.Tokens()-1))
+}
+
+func TestComposePromptWithExamples(t *testing.T) {
+	dir := t.TempDir()
+	writeExample(t, dir, "01-release", "Title: Llama 4 released", `{"isRelevant":true}`)
+	p := persona.Persona{Name: "Test", PersonaIdentity: "a tester", ExamplesDir: dir}
+
+	prompt, err := ComposePrompt(p, "")
+	require.NoError(t, err)
+	assert.Contains(t, prompt, "Example post:\nTitle: Llama 4 released\n\nExpected response:\n```json\n{\"isRelevant\":true}\n```")
+
+	p.MaxExampleTokens = 5
+	prompt, err = ComposePrompt(p, "")
+	require.NoError(t, err)
+	assert.NotContains(t, prompt, "Example post:", "examples over the budget are left out")
+
+	p.ExamplesDir = filepath.Join(dir, "missing")
+	_, err = ComposePrompt(p, "")
+	assert.ErrorContains(t, err, "could not load examples of persona Test")
+}
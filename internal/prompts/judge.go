@@ -0,0 +1,167 @@
+package prompts
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+)
+
+// judgeLanguage holds the judge instructions for a language other than English
+type judgeLanguage struct {
+	Name        string // English name of the language
+	Instruction string // The same instructions, written in the language itself
+}
+
+var judgeLanguages = map[string]judgeLanguage{
+	"de": {
+		Name: "German",
+		Instruction: "Die bewerteten Inhalte richten sich an ein deutschsprachiges Publikum. Bewerte sie auf Deutsch und nach denselben Kriterien wie englische Inhalte. " +
+			"Ziehe keine Punkte ab, weil Zusammenfassungen auf Deutsch verfasst sind oder englische Fachbegriffe enthalten.",
+	},
+	"nl": {
+		Name: "Dutch",
+		Instruction: "De beoordeelde inhoud is bedoeld voor een Nederlandstalig publiek. Beoordeel deze in het Nederlands en met dezelfde criteria als Engelse inhoud. " +
+			"Trek geen punten af omdat samenvattingen in het Nederlands zijn geschreven of Engelse vaktermen bevatten.",
+	},
+	"fr": {
+		Name: "French",
+		Instruction: "Le contenu évalué s'adresse à un public francophone. Évaluez-le en français, selon les mêmes critères que le contenu en anglais. " +
+			"Ne pénalisez pas les résumés parce qu'ils sont rédigés en français ou contiennent des termes techniques anglais.",
+	},
+	"es": {
+		Name: "Spanish",
+		Instruction: "El contenido evaluado está dirigido a un público hispanohablante. Evalúalo en español y con los mismos criterios que el contenido en inglés. " +
+			"No penalices los resúmenes por estar escritos en español ni por contener términos técnicos en inglés.",
+	},
+}
+
+// ComposeJudgeInstructions returns instructions to prepend to the prompt of an LLM judge evaluating
+// output for a persona with the given BCP 47 locale. Judges otherwise tend to downgrade summaries
+// that are not in English. English and empty locales need no instructions and return "".
+func ComposeJudgeInstructions(localeTag string) string {
+	tag := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(localeTag), "_", "-"))
+	base, _, _ := strings.Cut(tag, "-")
+	if base == "" || base == "en" {
+		return ""
+	}
+
+	language, ok := judgeLanguages[base]
+	if !ok {
+		return fmt.Sprintf("The content being evaluated was produced for readers using the %q locale and may not be in English. "+
+			"Judge it cross-lingually: apply exactly the same criteria as for English content, and do not penalize it for its language "+
+			"or for mixing in English technical terms.", localeTag)
+	}
+
+	return fmt.Sprintf("The content being evaluated was produced for %s-speaking readers. "+
+		"Judge it cross-lingually: apply exactly the same criteria for accuracy, completeness and relevance as for English content, "+
+		"and do not penalize summaries for being written in %s or for mixing in English technical terms.\n\n%s",
+		language.Name, language.Name, language.Instruction)
+}
+
+const judgePromptTemplate = `{{if .Instructions}}{{.Instructions}}
+
+{{end}}You are auditing the output of an LLM that curates a newsletter for {{.PersonaIdentity}}
+
+The newsletter covers {{.Topic}}. For each post, the LLM wrote a summary and decided whether the post is relevant.
+
+Relevant items include:
+{{range .FocusAreas}}* {{.}}
+{{end}}
+Relevance criteria (an item must match these to be relevant):
+{{range .RelevanceCriteria}}* {{.}}
+{{end}}
+Exclusion criteria (an item matching any of these is not relevant):
+{{range .ExclusionCriteria}}* {{.}}
+{{end}}
+You will be given the original post followed by the LLM's output. Decide independently whether the post is relevant under the criteria above, then rate the quality of the summary from 1 (inaccurate or unhelpful) to 5 (accurate, complete and concise).
+
+Respond with JSON only, in the following format:
+{
+  "relevant": true,
+  "quality": 4,
+  "reason": "One sentence explaining the verdict"
+}`
+
+// ComposeJudgePrompt generates a system prompt asking an LLM judge for a relevance verdict and a
+// quality score of a processed item. Instructions, usually from ComposeJudgeInstructions, are
+// prepended when not empty.
+func ComposeJudgePrompt(p persona.Persona, instructions string) (string, error) {
+	tmpl, err := newTemplate("judge").Parse(judgePromptTemplate)
+	if err != nil {
+		return "", err
+	}
+
+	data := struct {
+		Instructions      string
+		PersonaIdentity   string
+		Topic             string
+		FocusAreas        []string
+		RelevanceCriteria []string
+		ExclusionCriteria []string
+	}{
+		Instructions:      instructions,
+		PersonaIdentity:   p.PersonaIdentity,
+		Topic:             p.Topic,
+		FocusAreas:        p.FocusAreas,
+		RelevanceCriteria: p.RelevanceCriteria,
+		ExclusionCriteria: p.ExclusionCriteria,
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+const pairwisePromptTemplate = `{{if .Instructions}}{{.Instructions}}
+
+{{end}}You are comparing two LLM outputs for a newsletter curated by {{.PersonaIdentity}}
+
+The newsletter covers {{.Topic}}. For each post, an LLM writes a summary and decides whether the post is relevant.
+
+Relevance criteria (an item must match these to be relevant):
+{{range .RelevanceCriteria}}* {{.}}
+{{end}}
+Exclusion criteria (an item matching any of these is not relevant):
+{{range .ExclusionCriteria}}* {{.}}
+{{end}}
+You will be given the original post followed by two outputs, A and B. Decide which output serves the reader better: a correct relevance decision matters most, then an accurate, complete and concise summary. Their order is random; do not prefer an output because of its position or length. Answer "tie" only if neither is better.
+
+Respond with JSON only, in the following format:
+{
+  "preference": "A",
+  "reason": "One sentence explaining the preference"
+}`
+
+// ComposePairwisePrompt generates a system prompt asking an LLM judge which of two outputs for the
+// same item is better. Instructions, usually from ComposeJudgeInstructions, are prepended when not
+// empty.
+func ComposePairwisePrompt(p persona.Persona, instructions string) (string, error) {
+	tmpl, err := newTemplate("pairwise").Parse(pairwisePromptTemplate)
+	if err != nil {
+		return "", err
+	}
+
+	data := struct {
+		Instructions      string
+		PersonaIdentity   string
+		Topic             string
+		RelevanceCriteria []string
+		ExclusionCriteria []string
+	}{
+		Instructions:      instructions,
+		PersonaIdentity:   p.PersonaIdentity,
+		Topic:             p.Topic,
+		RelevanceCriteria: p.RelevanceCriteria,
+		ExclusionCriteria: p.ExclusionCriteria,
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,56 @@
+package prompts
+
+import (
+	"testing"
+
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComposeJudgeInstructions(t *testing.T) {
+	assert.Empty(t, ComposeJudgeInstructions(""))
+	assert.Empty(t, ComposeJudgeInstructions("en-GB"))
+
+	de := ComposeJudgeInstructions("de_AT")
+	assert.Contains(t, de, "German-speaking readers")
+	assert.Contains(t, de, "Bewerte sie auf Deutsch")
+
+	other := ComposeJudgeInstructions("pt-BR")
+	assert.Contains(t, other, `"pt-BR" locale`)
+	assert.Contains(t, other, "cross-lingually")
+}
+
+func TestComposeJudgePrompt(t *testing.T) {
+	p := persona.Persona{
+		PersonaIdentity:   "an AI researcher",
+		Topic:             "local LLMs",
+		RelevanceCriteria: []string{"Contains specific technical details"},
+		ExclusionCriteria: []string{"Memes"},
+	}
+
+	prompt, err := ComposeJudgePrompt(p, "")
+	require.NoError(t, err)
+	assert.Contains(t, prompt, "curates a newsletter for an AI researcher")
+	assert.Contains(t, prompt, "* Contains specific technical details")
+	assert.Contains(t, prompt, "* Memes")
+	assert.Contains(t, prompt, `"relevant": true`)
+
+	prompt, err = ComposeJudgePrompt(p, ComposeJudgeInstructions("de"))
+	require.NoError(t, err)
+	assert.Contains(t, prompt, "Bewerte sie auf Deutsch")
+}
+
+func TestComposePairwisePrompt(t *testing.T) {
+	p := persona.Persona{
+		PersonaIdentity:   "an AI researcher",
+		Topic:             "local LLMs",
+		RelevanceCriteria: []string{"Contains specific technical details"},
+	}
+
+	prompt, err := ComposePairwisePrompt(p, "")
+	require.NoError(t, err)
+	assert.Contains(t, prompt, "newsletter curated by an AI researcher")
+	assert.Contains(t, prompt, "* Contains specific technical details")
+	assert.Contains(t, prompt, `"preference": "A"`)
+}
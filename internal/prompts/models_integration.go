@@ -1,6 +1,8 @@
 package prompts
 
 import (
+	"strings"
+
 	"github.com/bakkerme/ai-news-processor/models"
 )
 
@@ -10,10 +12,26 @@ func GetRealItemJSONExample() (string, error) {
 	return generator.GenerateJSONExampleCompact(models.ItemSubset{})
 }
 
-// GetRealSummaryResponseJSONExample generates a JSON example using the actual models.SummaryResponse struct
+// GetRealItemWithClaimsJSONExample generates the item JSON example with the claims field that
+// personas can ask for
+func GetRealItemWithClaimsJSONExample() (string, error) {
+	item, err := GetRealItemJSONExample()
+	if err != nil {
+		return "", err
+	}
+	generator := &JSONExampleGenerator{}
+	claim, err := generator.GenerateJSONExampleCompact(models.Claim{})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(item, "}") + `,"claims":[` + claim + "]}", nil
+}
+
+// GetRealSummaryResponseJSONExample generates a JSON example using the actual models.SummaryResponse struct.
+// Only the fields the LLM is expected to produce are included.
 func GetRealSummaryResponseJSONExample() (string, error) {
 	generator := &JSONExampleGenerator{}
-	return generator.GenerateJSONExampleCompact(models.SummaryResponse{})
+	return generator.GenerateJSONExampleCompactWithAllowlist(models.SummaryResponse{}, map[string]bool{"keyDevelopments": true})
 }
 
 // GetRealKeyDevelopmentJSONExample generates a JSON example using the actual models.KeyDevelopment struct
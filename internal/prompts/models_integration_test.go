@@ -5,6 +5,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/bakkerme/ai-news-processor/internal/persona"
 	"github.com/bakkerme/ai-news-processor/models"
 )
 
@@ -24,7 +25,7 @@ func TestRealModelsIntegration(t *testing.T) {
 
 		// Verify it has the expected structure based on the real models.ItemSubset struct
 		expectedFields := []string{
-			"id", "overview", "summary", "commentSummary", "isRelevant",
+			"id", "overview", "summary", "commentSummary", "isRelevant", "entities", "topics", "importanceScore", "importanceReason",
 		}
 
 		for _, field := range expectedFields {
@@ -36,6 +37,57 @@ func TestRealModelsIntegration(t *testing.T) {
 		t.Logf("Real Item JSON Example: %s", example)
 	})
 
+	t.Run("Real Item JSON Example With Claims", func(t *testing.T) {
+		example, err := GetRealItemWithClaimsJSONExample()
+		if err != nil {
+			t.Fatalf("Failed to generate item example with claims: %v", err)
+		}
+
+		var parsed models.Item
+		if err := json.Unmarshal([]byte(example), &parsed); err != nil {
+			t.Fatalf("Generated example is not valid JSON: %v", err)
+		}
+		if len(parsed.Claims) != 1 || parsed.Claims[0].Source != models.ClaimSourceComment || parsed.Claims[0].Claim == "" {
+			t.Errorf("Expected one example claim from a comment, got %+v", parsed.Claims)
+		}
+		if parsed.Summary == "" {
+			t.Errorf("Expected the item fields to be kept, got %s", example)
+		}
+	})
+
+	t.Run("Persona Item JSON Example With Custom Fields", func(t *testing.T) {
+		p := persona.Persona{Name: "Test", PersonaIdentity: "a tester", ExtractClaims: true, Fields: []persona.Field{
+			{Name: "license", Description: "The license of the weights"},
+			{Name: "contextLength", Type: persona.FieldInteger, Description: "Context length in tokens"},
+			{Name: "benchmarkNumbers", Type: persona.FieldList, Description: "Benchmark results with their scores"},
+		}}
+		example, err := personaItemJSONExample(p)
+		if err != nil {
+			t.Fatalf("Failed to generate item example with custom fields: %v", err)
+		}
+
+		var parsed map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(example), &parsed); err != nil {
+			t.Fatalf("Generated example is not valid JSON: %v", err)
+		}
+		for field, value := range map[string]string{"license": `""`, "contextLength": "0", "benchmarkNumbers": `["example item"]`} {
+			if string(parsed[field]) != value {
+				t.Errorf("Expected %s to be %s, got %s", field, value, parsed[field])
+			}
+		}
+		if _, ok := parsed["claims"]; !ok {
+			t.Errorf("Expected the claims to be kept, got %s", example)
+		}
+
+		prompt, err := ComposePrompt(p, "")
+		if err != nil {
+			t.Fatalf("Failed to compose prompt: %v", err)
+		}
+		if !strings.Contains(prompt, "* \"benchmarkNumbers\"\n  * Benchmark results with their scores\n  * A JSON array of strings.") {
+			t.Errorf("Expected the prompt to describe the custom fields, got %s", prompt)
+		}
+	})
+
 	t.Run("Real SummaryResponse JSON Example", func(t *testing.T) {
 		example, err := GetRealSummaryResponseJSONExample()
 		if err != nil {
@@ -53,6 +105,10 @@ func TestRealModelsIntegration(t *testing.T) {
 		if _, exists := parsed["keyDevelopments"]; !exists {
 			t.Errorf("Expected keyDevelopments field not found")
 		}
+		// Rising topics come from run history, not from the LLM
+		if _, exists := parsed["risingTopics"]; exists {
+			t.Errorf("risingTopics should not be part of the LLM example")
+		}
 
 		t.Logf("Real SummaryResponse JSON Example: %s", example)
 	})
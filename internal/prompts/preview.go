@@ -0,0 +1,37 @@
+package prompts
+
+import (
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/internal/tokens"
+)
+
+// previewImageTitle is the post title used to render the image prompt for a preview
+const previewImageTitle = "Example post title"
+
+// Preview is a system prompt rendered for a persona, or the error rendering it failed with
+type Preview struct {
+	Name   string
+	Text   string
+	Tokens int
+	Err    error
+}
+
+// PreviewPrompts renders the base, summary and image prompts of a persona, so template and
+// persona problems show up before a run
+func PreviewPrompts(p persona.Persona) []Preview {
+	composers := []struct {
+		name    string
+		compose func() (string, error)
+	}{
+		{"base", func() (string, error) { return ComposePrompt(p, "") }},
+		{"summary", func() (string, error) { return ComposeSummaryPrompt(p) }},
+		{"image", func() (string, error) { return ComposeImagePrompt(p, previewImageTitle) }},
+	}
+
+	previews := make([]Preview, len(composers))
+	for i, composer := range composers {
+		text, err := composer.compose()
+		previews[i] = Preview{Name: composer.name, Text: text, Tokens: tokens.Estimate(text), Err: err}
+	}
+	return previews
+}
@@ -0,0 +1,34 @@
+package prompts
+
+import (
+	"testing"
+
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/internal/tokens"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviewPrompts(t *testing.T) {
+	p := persona.Persona{
+		Name:            "Test",
+		Topic:           "Testing",
+		PersonaIdentity: "a seasoned tester",
+		FocusAreas:      []string{"unit tests"},
+	}
+
+	previews := PreviewPrompts(p)
+	require.Len(t, previews, 3)
+	for _, preview := range previews {
+		require.NoError(t, preview.Err, preview.Name)
+		assert.Contains(t, preview.Text, "a seasoned tester", preview.Name)
+		assert.Equal(t, tokens.Estimate(preview.Text), preview.Tokens)
+	}
+	assert.Contains(t, previews[2].Text, previewImageTitle)
+
+	// The summary prompt needs a persona identity
+	p.PersonaIdentity = ""
+	previews = PreviewPrompts(p)
+	assert.NoError(t, previews[0].Err)
+	assert.Error(t, previews[1].Err)
+}
@@ -2,127 +2,159 @@ package prompts
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"text/template"
 
 	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/internal/templatefuncs"
 )
 
-const basePromptTemplate = `You are {{.PersonaIdentity}}
+const rollupPromptTemplate = `You are {{.PersonaIdentity}}
 
-{{.BasePromptTask}}
-
-Relevant items include:
-{{range .FocusAreas}}* {{.}}
-{{end}}
+{{.SummaryPromptTask}}
 
-An item must match the following criteria to be considered relevant:
-{{range .RelevanceCriteria}}* {{.}}
-{{end}}
+You are writing a review of the last {{.Days}} days. The items below were already sent in daily digests; each includes the date it was sent.
 
-An item is not relevant if it matches the following criteria:
-{{range .ExclusionCriteria}}* {{.}}
+Your analysis should focus on:
+{{range .SummaryAnalysis}}* {{.}}
 {{end}}
+Generate a structured review that includes:
+* "overview"
+  * One paragraph summarizing the period as a whole
+* "themes"
+  * 3-5 themes that connect several items, ordered by significance. Each has a "title", a 2-3 sentence "summary" and the "itemIDs" of the items it draws on, matching the IDs in the input
+* "trends"
+  * 2-4 sentences on trends over the period: what gained or lost momentum, what changed from the start to the end of the period, and what to watch next
 
-{{if .ImageDescription}}
-The following image description was generated from the post:
-{{.ImageDescription}}
-{{end}}
+Focus on connections between items rather than repeating individual summaries. This is a newsletter.
 
-If an item matches any of the exclusion criteria, set the IsRelevant field to false.
-
-For each item, provide a newsletter-style explanation that includes:
-* "ID"
-* "Title"
-* "Overview"
-	* An array of 2-3 concise bullet points summarizing the post content
-	* Each array element should be a complete sentence or bullet point
-	* Designed to help readers quickly decide if they want to read the full post
-	* Should highlight the most important aspects without going into deep technical detail
-* "Summary"
-	* 1 - 2 paragraphs, extracting key points of interest from the post, image description, comments, factoring in relevant, factual information from the comments
-	* Extrapolate on the details of these key points of interest
-	* Provide highly detailed technical analysis, if applicable
-* "CommentSummary"
-  * 1 - 2 paragraphs that
-    * Captures the community sentiment
-    * Highlights interesting discussions
-    * Notes any concerns or criticisms
-* "RelevanceToCriteria"
-  * In one sentence, explain if the item meets the relevance criteria or not. Does it match the exclusion criteria?
-* "IsRelevant"
-  * A final judgement boolean flag. If the item matches any of the exclusion criteria, IsRelevant should be false.
-
-Write in a conversational, engaging style while maintaining technical accuracy. Don't be afraid to geek out about interesting technical details!
-
-Do not start with 'This post...' or 'This item...'.
-
-Keep responses concise but comprehensive. Aim for:
-* Summary: 2-3 sentences per paragraph (500-800 words total)
-* CommentSummary: 2-3 sentences per paragraph (300-600 words total)
-
-Respond only with valid JSON. Put JSON in ` + "```json" + ` tags. Do not add "" within the JSON other than what is required by the JSON format.
-Use the following JSON structure:
-{{.ItemJSONExample}}
+Respond only with valid JSON. Put JSON in ` + "```json" + ` tags.
+{
+  "overview": "...",
+  "themes": [{"title": "...", "summary": "...", "itemIDs": ["..."]}],
+  "trends": ["..."]
+}
 `
 
-const summaryPromptTemplate = `You are {{.PersonaIdentity}}
+const imageBatchPromptTemplate = `You are {{.PersonaIdentity}}
 
-{{.SummaryPromptTask}}
+Your task is to analyze each of the {{len .Titles}} provided images and generate a separate detailed description for each one.
 
-Your analysis should focus on:
-{{range .SummaryAnalysis}}* {{.}}
+The images are from the following posts, in the same order as the images:
+{{range $i, $title := .Titles}}Image {{inc $i}}: "{{$title}}"
 {{end}}
+For each image, describe what is shown (people, objects, text, UI elements, charts, etc.), within 400 words.
 
-For the provided set of news items, generate a structured analysis that includes:
-* KeyDevelopments
-  * A list of key developments, ordered by significance. For each key development, include the ID of the referenced post as an ItemID field, so it can be linked to the original post.
+Keep each description concise but comprehensive, focusing on the most important and technically relevant details. If something is not in English, is blurry or not clear, do not describe it.
 
-The response format for KeyDevelopments should be an array of objects, each with a Text and an ItemID field, where ItemID matches the ID of a post in the input.
+Respond with one section per image, in order. Start each section with a line containing only "### Image N", where N is the image number, followed by its description. Do not describe images together.`
 
-Focus on technical accuracy while maintaining an engaging, analytical style. Avoid generic statements and focus on specific, concrete developments and their implications. This is a newsletter.
+const tuningPromptTemplate = `You maintain the relevance filter for a newsletter curated by {{.PersonaIdentity}}
 
-Keep the response concise but informative. Aim for 2-3 key developments with 1-2 sentences each.
+The newsletter covers {{.Topic}}. An LLM decides whether each post is relevant using the criteria below.
 
-Respond only with valid JSON. Put JSON in ` + "```json" + ` tags.
-{{.SummaryJSONExample}}
-`
+Relevant items include:
+{{range .FocusAreas}}* {{.}}
+{{end}}
+Current relevance criteria (an item must match these to be relevant):
+{{range .RelevanceCriteria}}* {{.}}
+{{end}}
+Current exclusion criteria (an item matching any of these is not relevant):
+{{range .ExclusionCriteria}}* {{.}}
+{{end}}
+Reviewers disagreed with the following decisions:
+{{range .Mistakes}}
+- Title: "{{.Title}}"
+  {{if .MarkedRelevant}}Marked relevant, but should have been excluded{{else}}Excluded, but should have been marked relevant{{end}}{{if .Reason}}
+  Reviewer note: {{.Reason}}{{end}}{{if .Summary}}
+  Summary: {{.Summary}}{{end}}
+{{end}}
+Propose revised relevance and exclusion criteria that would have produced the reviewers' decisions without changing the outcome for items that were classified correctly. Prefer small, targeted edits to existing criteria over rewriting them. Keep criteria general; do not mention specific posts. Keep each criterion to one sentence.
 
-const imagePromptTemplate = `You are {{.PersonaIdentity}}
+Respond with JSON only, in the following format:
+{
+  "relevanceCriteria": ["..."],
+  "exclusionCriteria": ["..."],
+  "rationale": "One short paragraph explaining the changes"
+}`
 
-Your task is to analyze the provided image and generate a detailed description.
+const secondOpinionPromptTemplate = `You are {{.PersonaIdentity}}
 
-The image is from a post titled: "{{.Title}}"
+You decide which posts belong in a newsletter about {{.Topic}}. Another reviewer was unsure whether the post below belongs in it. Give a second opinion.
 
-Describe what is shown in the image (people, objects, text, UI elements, charts, etc.), within 400 words.
+Relevant items include:
+{{range .FocusAreas}}* {{.}}
+{{end}}
+An item must match the following criteria to be considered relevant:
+{{range .RelevanceCriteria}}* {{.}}
+{{end}}
+An item is not relevant if it matches any of the following criteria:
+{{range .ExclusionCriteria}}* {{.}}
+{{end}}
+The post is given by its title and summary, followed by the first reviewer's reasoning.
+
+Respond only with valid JSON. Put JSON in ` + "```json" + ` tags.
+{
+  "isRelevant": true,
+  "relevanceConfidence": 90,
+  "relevanceToCriteria": "One sentence explaining whether the post meets the criteria"
+}
 
-Keep your description concise but comprehensive, focusing on the most important and technically relevant details.
+"relevanceConfidence" is how sure you are of "isRelevant", as a whole number from 1 (a guess) to 100 (certain).`
 
-Respond with a concise but comprehensive description focusing on technical and factual details. If something is not in English, is blurry or not clear, do not describe it.`
+// newTemplate creates a prompt template with the shared template functions
+func newTemplate(name string) *template.Template {
+	return template.New(name).Funcs(templatefuncs.FuncMap())
+}
 
 // ComposePrompt generates a system prompt for the given persona using the base template
 func ComposePrompt(p persona.Persona, imageDescription string) (string, error) {
-	tmpl, err := template.New("base").Parse(basePromptTemplate)
+	text, _ := templateText(BaseTemplate)
+	return ComposePromptFromTemplate(p, imageDescription, text)
+}
+
+// ComposeMegathreadPrompt generates the system prompt of megathreads and live threads for the
+// given persona. It gets the same data as the base template, but asks for the themes of the
+// discussion rather than a summary of the post.
+func ComposeMegathreadPrompt(p persona.Persona) (string, error) {
+	text, _ := templateText(MegathreadTemplate)
+	return ComposePromptFromTemplate(p, "", text)
+}
+
+// ComposePromptFromTemplate generates a system prompt like ComposePrompt, using templateText
+// instead of the base template. The template receives the same data as the base template, so
+// experiments can vary the prompt without changing the code.
+func ComposePromptFromTemplate(p persona.Persona, imageDescription string, templateText string) (string, error) {
+	tmpl, err := newTemplate("base").Parse(templateText)
 	if err != nil {
 		return "", err
 	}
 
 	// Generate JSON example automatically from real struct
-	itemJSONExample, err := GetRealItemJSONExample()
+	itemJSONExample, err := personaItemJSONExample(p)
 	if err != nil {
 		return "", fmt.Errorf("failed to generate item JSON example: %w", err)
 	}
 
+	examples, err := personaExamples(p)
+	if err != nil {
+		return "", err
+	}
+
 	// Create a data structure for the template that includes the image description and generated JSON example
 	data := struct {
 		persona.Persona
 		ImageDescription string
 		ItemJSONExample  string
+		Examples         []Example
 	}{
 		Persona:          p,
 		ImageDescription: imageDescription,
 		ItemJSONExample:  itemJSONExample,
+		Examples:         examples,
 	}
 
 	var buf bytes.Buffer
@@ -133,12 +165,53 @@ func ComposePrompt(p persona.Persona, imageDescription string) (string, error) {
 	return buf.String(), nil
 }
 
+// personaItemJSONExample returns the item JSON example with the claims and custom fields the
+// persona asks for
+func personaItemJSONExample(p persona.Persona) (string, error) {
+	example, err := GetRealItemJSONExample()
+	if p.ExtractClaims {
+		example, err = GetRealItemWithClaimsJSONExample()
+	}
+	if err != nil || len(p.Fields) == 0 {
+		return example, err
+	}
+
+	var fields strings.Builder
+	for _, f := range p.Fields {
+		name, err := json.Marshal(f.Name)
+		if err != nil {
+			return "", err
+		}
+		value, err := json.Marshal(fieldExample(f))
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&fields, ",%s:%s", name, value)
+	}
+	return strings.TrimSuffix(example, "}") + fields.String() + "}", nil
+}
+
+// fieldExample returns the placeholder value of a custom field in the item JSON example
+func fieldExample(f persona.Field) any {
+	switch f.GetType() {
+	case persona.FieldNumber, persona.FieldInteger:
+		return 0
+	case persona.FieldBoolean:
+		return false
+	case persona.FieldList:
+		return []string{"example item"}
+	default:
+		return ""
+	}
+}
+
 func ComposeSummaryPrompt(p persona.Persona) (string, error) {
 	if p.PersonaIdentity == "" {
 		return "", errors.New("persona identity is empty")
 	}
 
-	tmpl, err := template.New("summary").Parse(summaryPromptTemplate)
+	text, _ := templateText(SummaryTemplate)
+	tmpl, err := newTemplate("summary").Parse(text)
 	if err != nil {
 		return "", err
 	}
@@ -168,7 +241,8 @@ func ComposeSummaryPrompt(p persona.Persona) (string, error) {
 
 // ComposeImagePrompt generates a system prompt for image description
 func ComposeImagePrompt(p persona.Persona, title string) (string, error) {
-	tmpl, err := template.New("image").Parse(imagePromptTemplate)
+	text, _ := templateText(ImageTemplate)
+	tmpl, err := newTemplate("image").Parse(text)
 	if err != nil {
 		return "", err
 	}
@@ -189,3 +263,116 @@ func ComposeImagePrompt(p persona.Persona, title string) (string, error) {
 	}
 	return buf.String(), nil
 }
+
+// ComposeImageBatchPrompt generates a system prompt for describing several images from different posts in one request.
+// titles holds the post title for each image, in the order the images are sent.
+func ComposeImageBatchPrompt(p persona.Persona, titles []string) (string, error) {
+	funcMap := template.FuncMap{
+		"inc": func(i int) int { return i + 1 },
+	}
+
+	tmpl, err := newTemplate("imageBatch").Funcs(funcMap).Parse(imageBatchPromptTemplate)
+	if err != nil {
+		return "", err
+	}
+
+	data := struct {
+		PersonaIdentity string
+		Titles          []string
+	}{
+		PersonaIdentity: p.PersonaIdentity,
+		Titles:          titles,
+	}
+
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, data)
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// TuningMistake describes a relevance decision that a reviewer disagreed with
+type TuningMistake struct {
+	Title          string
+	Summary        string
+	Reason         string
+	MarkedRelevant bool // Whether the item was marked relevant (a false positive)
+}
+
+// ComposeTuningPrompt generates a system prompt asking for revised relevance and exclusion criteria
+// that would fix the given mistakes
+func ComposeTuningPrompt(p persona.Persona, mistakes []TuningMistake) (string, error) {
+	tmpl, err := newTemplate("tuning").Parse(tuningPromptTemplate)
+	if err != nil {
+		return "", err
+	}
+
+	data := struct {
+		PersonaIdentity   string
+		Topic             string
+		FocusAreas        []string
+		RelevanceCriteria []string
+		ExclusionCriteria []string
+		Mistakes          []TuningMistake
+	}{
+		PersonaIdentity:   p.PersonaIdentity,
+		Topic:             p.Topic,
+		FocusAreas:        p.FocusAreas,
+		RelevanceCriteria: p.RelevanceCriteria,
+		ExclusionCriteria: p.ExclusionCriteria,
+		Mistakes:          mistakes,
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// ComposeSecondOpinionPrompt generates a short system prompt judging again whether an item the
+// entry prompt was unsure of is relevant to the persona. It only sees the item's summary, so it is
+// far cheaper than processing the entry again.
+func ComposeSecondOpinionPrompt(p persona.Persona) (string, error) {
+	if p.PersonaIdentity == "" {
+		return "", errors.New("persona identity is empty")
+	}
+
+	tmpl, err := newTemplate("secondOpinion").Parse(secondOpinionPromptTemplate)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, p); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// ComposeRollupPrompt generates a system prompt for a review of the items sent over the last days
+func ComposeRollupPrompt(p persona.Persona, days int) (string, error) {
+	if p.PersonaIdentity == "" {
+		return "", errors.New("persona identity is empty")
+	}
+
+	tmpl, err := newTemplate("rollup").Parse(rollupPromptTemplate)
+	if err != nil {
+		return "", err
+	}
+
+	data := struct {
+		persona.Persona
+		Days int
+	}{
+		Persona: p,
+		Days:    days,
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -169,6 +169,18 @@ func (g *JSONExampleGenerator) getStringExample(jsonName string) string {
 		return "https://example.com/thumbnail.jpg"
 	case "text":
 		return "Key development description..."
+	case "claim":
+		return "Llama 3.1 405B scores 88.6 on MMLU"
+	case "source":
+		return "comment"
+	case "quote":
+		return "I measured 88.6 on MMLU with the 405B"
+	case "url":
+		return "https://example.com/article"
+	case "name":
+		return "Llama 3.1"
+	case "type":
+		return "model"
 	default:
 		return ""
 	}
@@ -184,9 +196,16 @@ func (g *JSONExampleGenerator) getBoolExample(jsonName string) bool {
 	}
 }
 
-// getIntExample returns appropriate integer examples
-func (g *JSONExampleGenerator) getIntExample(_ string) int64 {
-	return 0
+// getIntExample returns appropriate integer examples based on field names
+func (g *JSONExampleGenerator) getIntExample(jsonName string) int64 {
+	switch strings.ToLower(jsonName) {
+	case "importancescore":
+		return 7
+	case "relevanceconfidence":
+		return 90
+	default:
+		return 0
+	}
 }
 
 // getFloatExample returns appropriate float examples
@@ -215,6 +234,11 @@ func (g *JSONExampleGenerator) setSliceExample(fieldValue reflect.Value, _ refle
 			slice.Index(1).SetString("It highlights a key aspect of the content")
 			slice.Index(2).SetString("Provides a brief overview for readers")
 			fieldValue.Set(slice)
+		} else if strings.ToLower(jsonFieldName) == "topics" {
+			slice := reflect.MakeSlice(fieldValue.Type(), 2, 2)
+			slice.Index(0).SetString("quantization")
+			slice.Index(1).SetString("local inference")
+			fieldValue.Set(slice)
 		} else {
 			// Create a slice with one example element
 			slice := reflect.MakeSlice(fieldValue.Type(), 1, 1)
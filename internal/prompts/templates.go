@@ -0,0 +1,156 @@
+package prompts
+
+import (
+	"embed"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// Prompt templates that can be replaced by a file of the same name, with a .tmpl extension, in
+// the template directory
+const (
+	BaseTemplate       = "base"
+	SummaryTemplate    = "summary"
+	ImageTemplate      = "image"
+	MegathreadTemplate = "megathread"
+)
+
+// overridableTemplates lists the templates that are read from files
+var overridableTemplates = []string{BaseTemplate, SummaryTemplate, ImageTemplate, MegathreadTemplate}
+
+// The built-in templates, used when the template directory has no override
+var (
+	basePromptTemplate       = mustReadBuiltIn(BaseTemplate)
+	summaryPromptTemplate    = mustReadBuiltIn(SummaryTemplate)
+	imagePromptTemplate      = mustReadBuiltIn(ImageTemplate)
+	megathreadPromptTemplate = mustReadBuiltIn(MegathreadTemplate)
+)
+
+func mustReadBuiltIn(name string) string {
+	content, err := templateFS.ReadFile("templates/" + name + ".tmpl")
+	if err != nil {
+		panic(fmt.Sprintf("built-in prompt template %s is missing: %v", name, err))
+	}
+	return string(content)
+}
+
+func builtInTemplate(name string) string {
+	switch name {
+	case BaseTemplate:
+		return basePromptTemplate
+	case SummaryTemplate:
+		return summaryPromptTemplate
+	case MegathreadTemplate:
+		return megathreadPromptTemplate
+	default:
+		return imagePromptTemplate
+	}
+}
+
+// override is a template file read from the template directory
+type override struct {
+	modTime time.Time
+	size    int64
+	text    string
+}
+
+// templateOverrides holds the overrides read from the template directory. Files are checked for
+// changes whenever a template is used, so edits apply to the next prompt without a restart.
+var templateOverrides struct {
+	mu    sync.Mutex
+	dir   string
+	files map[string]override
+}
+
+// SetTemplateDir sets the directory whose base.tmpl, summary.tmpl, image.tmpl and megathread.tmpl
+// replace the built-in prompt templates. Every override in it must parse. An empty dir, or a
+// directory that does not exist, leaves the built-in templates in use.
+func SetTemplateDir(dir string) error {
+	templateOverrides.mu.Lock()
+	defer templateOverrides.mu.Unlock()
+
+	templateOverrides.dir = dir
+	templateOverrides.files = make(map[string]override)
+	if dir == "" {
+		return nil
+	}
+	for _, name := range overridableTemplates {
+		path := filepath.Join(dir, name+".tmpl")
+		info, err := os.Stat(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return fmt.Errorf("could not read prompt template %s: %w", path, err)
+		}
+		text, err := readOverride(name, path)
+		if err != nil {
+			return err
+		}
+		templateOverrides.files[name] = override{modTime: info.ModTime(), size: info.Size(), text: text}
+		log.Printf("Using prompt template override %s", path)
+	}
+	return nil
+}
+
+// readOverride reads and parses a template file
+func readOverride(name, path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("could not read prompt template %s: %w", path, err)
+	}
+	if _, err := newTemplate(name).Parse(string(content)); err != nil {
+		return "", fmt.Errorf("invalid prompt template %s: %w", path, err)
+	}
+	return string(content), nil
+}
+
+// templateText returns the text of a template and whether it is an override. An override is read
+// again when its file changes. If the new version does not parse, the error is logged and the last
+// working version stays in use, so a half-finished edit cannot fail a run.
+func templateText(name string) (string, bool) {
+	templateOverrides.mu.Lock()
+	defer templateOverrides.mu.Unlock()
+
+	if templateOverrides.dir == "" {
+		return builtInTemplate(name), false
+	}
+	path := filepath.Join(templateOverrides.dir, name+".tmpl")
+	current, loaded := templateOverrides.files[name]
+
+	info, err := os.Stat(path)
+	if err != nil {
+		if loaded {
+			log.Printf("Prompt template override %s was removed, using the built-in template", path)
+			delete(templateOverrides.files, name)
+		}
+		return builtInTemplate(name), false
+	}
+	if loaded && info.ModTime().Equal(current.modTime) && info.Size() == current.size {
+		return current.text, true
+	}
+
+	text, err := readOverride(name, path)
+	if err != nil {
+		if !loaded {
+			log.Printf("Warning: %v; using the built-in template", err)
+			return builtInTemplate(name), false
+		}
+		// Remember the broken version so the warning is logged once per edit
+		log.Printf("Warning: %v; keeping the previous template", err)
+		templateOverrides.files[name] = override{modTime: info.ModTime(), size: info.Size(), text: current.text}
+		return current.text, true
+	}
+	if loaded {
+		log.Printf("Reloaded prompt template override %s", path)
+	}
+	templateOverrides.files[name] = override{modTime: info.ModTime(), size: info.Size(), text: text}
+	return text, true
+}
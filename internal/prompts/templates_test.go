@@ -0,0 +1,70 @@
+package prompts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTemplate(t *testing.T, dir, name, text string, modTime time.Time) {
+	t.Helper()
+	path := filepath.Join(dir, name+".tmpl")
+	require.NoError(t, os.WriteFile(path, []byte(text), 0644))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func TestTemplateOverrides(t *testing.T) {
+	dir := t.TempDir()
+	t.Cleanup(func() { SetTemplateDir("") })
+	p := persona.Persona{Name: "Test", PersonaIdentity: "a tester"}
+	start := time.Now().Add(-time.Hour)
+
+	writeTemplate(t, dir, ImageTemplate, "Describe the image from {{.Title}} as {{.PersonaIdentity}}", start)
+	require.NoError(t, SetTemplateDir(dir))
+
+	prompt, err := ComposeImagePrompt(p, "a post")
+	require.NoError(t, err)
+	assert.Equal(t, "Describe the image from a post as a tester", prompt)
+
+	version, ok := ActiveTemplateVersion(ImageTemplate)
+	require.True(t, ok)
+	assert.Equal(t, ImageTemplate, version.Template)
+	assert.Empty(t, version.Version)
+
+	builtIn, _ := TemplateVersion(SummaryTemplate)
+	version, _ = ActiveTemplateVersion(SummaryTemplate)
+	assert.Equal(t, builtIn, version, "templates without an override use the built-in one")
+
+	// An edit applies to the next prompt
+	writeTemplate(t, dir, ImageTemplate, "Describe {{.Title}}", start.Add(time.Minute))
+	prompt, err = ComposeImagePrompt(p, "a post")
+	require.NoError(t, err)
+	assert.Equal(t, "Describe a post", prompt)
+
+	// An edit that does not parse keeps the previous version
+	writeTemplate(t, dir, ImageTemplate, "Describe {{.Title", start.Add(2*time.Minute))
+	prompt, err = ComposeImagePrompt(p, "a post")
+	require.NoError(t, err)
+	assert.Equal(t, "Describe a post", prompt)
+
+	// Removing the file brings back the built-in template
+	require.NoError(t, os.Remove(filepath.Join(dir, ImageTemplate+".tmpl")))
+	prompt, err = ComposeImagePrompt(p, "a post")
+	require.NoError(t, err)
+	assert.Contains(t, prompt, "Your task is to analyze the provided image")
+}
+
+func TestSetTemplateDir(t *testing.T) {
+	t.Cleanup(func() { SetTemplateDir("") })
+
+	assert.NoError(t, SetTemplateDir(filepath.Join(t.TempDir(), "missing")))
+
+	dir := t.TempDir()
+	writeTemplate(t, dir, BaseTemplate, "You are {{.PersonaIdentity", time.Now())
+	assert.ErrorContains(t, SetTemplateDir(dir), "invalid prompt template")
+}
@@ -0,0 +1,78 @@
+package prompts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+
+	"github.com/bakkerme/ai-news-processor/models"
+)
+
+// CustomTemplate is the template name of prompts rendered from a template given at run time
+const CustomTemplate = "custom"
+
+// versionedTemplate is a built-in template with its semantic version
+type versionedTemplate struct {
+	version string
+	text    string
+}
+
+// templates holds the version of every built-in template. Bump the version of a template whenever
+// it changes: the major version when the response format changes, the minor version when the
+// instructions change and the patch version for wording fixes. TestTemplateVersions fails when a
+// template is edited without a new version.
+var templates = map[string]versionedTemplate{
+	"base":          {"1.3.0", basePromptTemplate},
+	"summary":       {"1.0.0", summaryPromptTemplate},
+	"rollup":        {"1.0.0", rollupPromptTemplate},
+	"image":         {"1.0.0", imagePromptTemplate},
+	"megathread":    {"1.2.0", megathreadPromptTemplate},
+	"imageBatch":    {"1.0.0", imageBatchPromptTemplate},
+	"tuning":        {"1.0.0", tuningPromptTemplate},
+	"judge":         {"1.0.0", judgePromptTemplate},
+	"pairwise":      {"1.0.0", pairwisePromptTemplate},
+	"secondOpinion": {"1.0.0", secondOpinionPromptTemplate},
+}
+
+// TemplateVersion returns the version of a built-in template, or false if there is no template
+// with that name
+func TemplateVersion(name string) (models.PromptVersion, bool) {
+	t, ok := templates[name]
+	if !ok {
+		return models.PromptVersion{}, false
+	}
+	return models.PromptVersion{Template: name, Version: t.version, Hash: Hash(t.text)}, true
+}
+
+// TemplateVersions returns the versions of all built-in templates, ordered by name
+func TemplateVersions() []models.PromptVersion {
+	versions := make([]models.PromptVersion, 0, len(templates))
+	for name := range templates {
+		version, _ := TemplateVersion(name)
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Template < versions[j].Template })
+	return versions
+}
+
+// ActiveTemplateVersion returns the version of the template prompts are currently rendered from.
+// A template replaced by a file in the template directory has the hash of that file but no
+// semantic version.
+func ActiveTemplateVersion(name string) (models.PromptVersion, bool) {
+	if text, overridden := templateText(name); overridden {
+		return models.PromptVersion{Template: name, Hash: Hash(text)}, true
+	}
+	return TemplateVersion(name)
+}
+
+// CustomTemplateVersion returns the version of a template given at run time, which has a hash
+// but no semantic version
+func CustomTemplateVersion(templateText string) models.PromptVersion {
+	return models.PromptVersion{Template: CustomTemplate, Hash: Hash(templateText)}
+}
+
+// Hash returns a short hash identifying a template or rendered prompt
+func Hash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])[:12]
+}
@@ -0,0 +1,51 @@
+package prompts
+
+import (
+	"testing"
+
+	"github.com/bakkerme/ai-news-processor/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// versionedHashes are the hashes of the built-in templates at their current version. When a
+// template changes, bump its version in versions.go and update its hash here.
+var versionedHashes = map[string]string{
+	"base@1.3.0":          "a4541c877290",
+	"image@1.0.0":         "1ecb42f24738",
+	"imageBatch@1.0.0":    "19b7f4b26827",
+	"judge@1.0.0":         "b62c557e7fd6",
+	"megathread@1.2.0":    "63fd38fc4f7b",
+	"pairwise@1.0.0":      "1c03dd42d74b",
+	"rollup@1.0.0":        "4a22926696cc",
+	"secondOpinion@1.0.0": "2dc3edd11b2b",
+	"summary@1.0.0":       "093cdffda98a",
+	"tuning@1.0.0":        "3a702e7e50e0",
+}
+
+func TestTemplateVersions(t *testing.T) {
+	versions := TemplateVersions()
+	require.Len(t, versions, len(versionedHashes))
+	for _, version := range versions {
+		key := version.Template + "@" + version.Version
+		hash, ok := versionedHashes[key]
+		if !assert.True(t, ok, "no recorded hash for %s; add it to versionedHashes", key) {
+			continue
+		}
+		assert.Equal(t, hash, version.Hash, "template %s changed without a new version; bump it in versions.go", version.Template)
+	}
+}
+
+func TestTemplateVersion(t *testing.T) {
+	version, ok := TemplateVersion("base")
+	require.True(t, ok)
+	assert.Equal(t, models.PromptVersion{Template: "base", Version: "1.3.0", Hash: Hash(basePromptTemplate)}, version)
+
+	_, ok = TemplateVersion("missing")
+	assert.False(t, ok)
+
+	custom := CustomTemplateVersion("You are {{.PersonaIdentity}}")
+	assert.Equal(t, CustomTemplate, custom.Template)
+	assert.Empty(t, custom.Version)
+	assert.Len(t, custom.Hash, 12)
+}
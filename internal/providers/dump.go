@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/bakkerme/ai-news-processor/internal/providers/dumpname"
 	"github.com/vartanbeno/go-reddit/v2/reddit"
 )
 
@@ -58,7 +59,7 @@ type RedditCommentEntry struct {
 func (r *RedditProvider) dumpRedditFeed(subreddit string, posts []*reddit.Post, personaName string) error {
 	log.Printf("Dumping Reddit API feed for r/%s", subreddit)
 
-	processedName := processPersonaName(personaName)
+	processedName := dumpname.Persona(personaName)
 
 	// Convert Reddit posts to dump format
 	postData := make([]RedditPostData, len(posts))
@@ -87,7 +88,7 @@ func (r *RedditProvider) dumpRedditFeed(subreddit string, posts []*reddit.Post,
 	}
 
 	// Create directory structure
-	dir := filepath.Join("feed_mocks", "reddit", processedName)
+	dir := filepath.Join(r.dumpDir, "reddit", processedName)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return fmt.Errorf("failed to create directory: %w", err)
 	}
@@ -113,7 +114,7 @@ func (r *RedditProvider) dumpRedditFeed(subreddit string, posts []*reddit.Post,
 func (r *RedditProvider) dumpRedditComments(postID string, comments []*reddit.Comment, personaName string) error {
 	log.Printf("Dumping Reddit API comments for post %s", postID)
 
-	processedName := processPersonaName(personaName)
+	processedName := dumpname.Persona(personaName)
 
 	// Convert Reddit comments to dump format
 	commentData := make([]RedditCommentEntry, len(comments))
@@ -137,7 +138,7 @@ func (r *RedditProvider) dumpRedditComments(postID string, comments []*reddit.Co
 	}
 
 	// Create directory structure
-	dir := filepath.Join("feed_mocks", "reddit", processedName)
+	dir := filepath.Join(r.dumpDir, "reddit", processedName)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return fmt.Errorf("failed to create directory: %w", err)
 	}
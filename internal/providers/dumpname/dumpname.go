@@ -0,0 +1,13 @@
+// Package dumpname names the directories and files feeds are dumped to, which the mock provider
+// reads back. Every provider that dumps its feeds uses it, so dumps and mocks always agree.
+package dumpname
+
+import "strings"
+
+// DefaultDir is where feeds are dumped to and mocks are read from unless configured otherwise
+const DefaultDir = "feed_mocks"
+
+// Persona returns the name of a persona as used in dump paths: lower case, without slashes
+func Persona(name string) string {
+	return strings.ReplaceAll(strings.ToLower(name), "/", "")
+}
@@ -0,0 +1,12 @@
+package dumpname
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPersona(t *testing.T) {
+	assert.Equal(t, "localllama", Persona("LocalLLaMA"))
+	assert.Equal(t, "ainews", Persona("AI/News"))
+}
@@ -0,0 +1,207 @@
+package imap
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// client is a minimal IMAP4rev1 client with the commands the provider needs: it logs in, selects
+// a mailbox, searches for unread messages, fetches them without marking them read, and marks them
+// read.
+type client struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	tag    int
+}
+
+// response is a line the server sent, with the literals ({n} strings) that followed it
+type response struct {
+	text     string // The line with every literal replaced by its {n} marker
+	literals [][]byte
+}
+
+// literalPattern matches the {n} marker at the end of a line announcing a literal of n bytes
+var literalPattern = regexp.MustCompile(`\{(\d+)\}$`)
+
+// dial connects to an IMAP server and reads its greeting
+func dial(addr string, useTLS bool, timeout time.Duration) (*client, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+	var conn net.Conn
+	var err error
+	if useTLS {
+		host, _, _ := net.SplitHostPort(addr)
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{ServerName: host})
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to IMAP server: %w", err)
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	c := &client{conn: conn, reader: bufio.NewReader(conn)}
+	greeting, err := c.readResponse()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !strings.HasPrefix(greeting.text, "* OK") && !strings.HasPrefix(greeting.text, "* PREAUTH") {
+		conn.Close()
+		return nil, fmt.Errorf("unexpected IMAP greeting: %s", greeting.text)
+	}
+	return c, nil
+}
+
+// close logs out and closes the connection
+func (c *client) close() error {
+	c.command("LOGOUT")
+	return c.conn.Close()
+}
+
+// login authenticates with a username and password
+func (c *client) login(username, password string) error {
+	_, err := c.command("LOGIN " + quote(username) + " " + quote(password))
+	return err
+}
+
+// selectMailbox opens a mailbox for reading and writing
+func (c *client) selectMailbox(name string) error {
+	_, err := c.command("SELECT " + quote(name))
+	return err
+}
+
+// searchUnseen returns the UIDs of the unread messages, from any of the senders if there are any
+func (c *client) searchUnseen(senders []string) ([]uint32, error) {
+	criteria := "UNSEEN"
+	if len(senders) > 0 {
+		// OR takes two keys, so more senders are nested: OR FROM a OR FROM b FROM c
+		from := "FROM " + quote(senders[len(senders)-1])
+		for i := len(senders) - 2; i >= 0; i-- {
+			from = "OR FROM " + quote(senders[i]) + " " + from
+		}
+		criteria += " " + from
+	}
+
+	responses, err := c.command("UID SEARCH " + criteria)
+	if err != nil {
+		return nil, err
+	}
+	var uids []uint32
+	for _, r := range responses {
+		if !strings.HasPrefix(r.text, "* SEARCH") {
+			continue
+		}
+		for _, field := range strings.Fields(strings.TrimPrefix(r.text, "* SEARCH")) {
+			uid, err := strconv.ParseUint(field, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid UID in search response: %s", r.text)
+			}
+			uids = append(uids, uint32(uid))
+		}
+	}
+	return uids, nil
+}
+
+// uidPattern finds the UID of a message in a FETCH response
+var uidPattern = regexp.MustCompile(`\bUID (\d+)`)
+
+// fetch returns the full messages with the UIDs, without setting their \Seen flag
+func (c *client) fetch(uids []uint32) (map[uint32][]byte, error) {
+	responses, err := c.command("UID FETCH " + uidSet(uids) + " (UID BODY.PEEK[])")
+	if err != nil {
+		return nil, err
+	}
+	messages := make(map[uint32][]byte, len(uids))
+	for _, r := range responses {
+		if !strings.HasPrefix(r.text, "* ") || !strings.Contains(r.text, " FETCH ") || len(r.literals) == 0 {
+			continue
+		}
+		match := uidPattern.FindStringSubmatch(r.text)
+		if match == nil {
+			return nil, fmt.Errorf("FETCH response without UID: %s", r.text)
+		}
+		uid, _ := strconv.ParseUint(match[1], 10, 32)
+		messages[uint32(uid)] = r.literals[0]
+	}
+	return messages, nil
+}
+
+// markSeen sets the \Seen flag of the messages with the UIDs
+func (c *client) markSeen(uids []uint32) error {
+	_, err := c.command(`UID STORE ` + uidSet(uids) + ` +FLAGS.SILENT (\Seen)`)
+	return err
+}
+
+// command sends a command and returns the untagged responses, or an error if the command fails
+func (c *client) command(cmd string) ([]response, error) {
+	c.tag++
+	tag := fmt.Sprintf("a%d", c.tag)
+	if _, err := fmt.Fprintf(c.conn, "%s %s\r\n", tag, cmd); err != nil {
+		return nil, fmt.Errorf("failed to send IMAP command: %w", err)
+	}
+
+	var untagged []response
+	for {
+		r, err := c.readResponse()
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(r.text, tag+" ") {
+			untagged = append(untagged, r)
+			continue
+		}
+		status := strings.TrimPrefix(r.text, tag+" ")
+		if !strings.HasPrefix(status, "OK") {
+			name, _, _ := strings.Cut(cmd, " ")
+			return nil, fmt.Errorf("IMAP %s failed: %s", name, status)
+		}
+		return untagged, nil
+	}
+}
+
+// readResponse reads a line and any literals it announces
+func (c *client) readResponse() (response, error) {
+	var r response
+	var text strings.Builder
+	for {
+		line, err := c.reader.ReadString('\n')
+		if err != nil {
+			return r, fmt.Errorf("failed to read IMAP response: %w", err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+		text.WriteString(line)
+
+		match := literalPattern.FindStringSubmatch(line)
+		if match == nil {
+			r.text = text.String()
+			return r, nil
+		}
+		size, _ := strconv.Atoi(match[1])
+		literal := make([]byte, size)
+		if _, err := io.ReadFull(c.reader, literal); err != nil {
+			return r, fmt.Errorf("failed to read IMAP literal: %w", err)
+		}
+		r.literals = append(r.literals, literal)
+	}
+}
+
+// quote encodes a string as an IMAP quoted string
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// uidSet formats UIDs as a comma separated sequence set
+func uidSet(uids []uint32) string {
+	parts := make([]string, len(uids))
+	for i, uid := range uids {
+		parts[i] = strconv.FormatUint(uint64(uid), 10)
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,174 @@
+// Package imap implements a feed provider that reads email newsletters (such as Substack posts)
+// from an IMAP mailbox. Each unread message from the configured senders becomes an entry, and is
+// marked read once it has been fetched.
+package imap
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/internal/providers/dumpname"
+)
+
+const (
+	// maxMessages is the number of unread messages read from each mailbox, newest first
+	maxMessages = 50
+	// timeout bounds the whole IMAP session
+	timeout = 2 * time.Minute
+	// defaultMailbox is read when a persona only lists senders
+	defaultMailbox = "INBOX"
+)
+
+// Provider implements the feeds.FeedProvider interface for newsletters in an IMAP mailbox
+type Provider struct {
+	addr       string
+	username   string
+	password   string
+	useTLS     bool
+	enableDump bool
+	dumpDir    string
+}
+
+// NewProvider creates an IMAP provider for the server at addr (host:port)
+func NewProvider(addr, username, password string, useTLS bool, enableDump bool) *Provider {
+	return &Provider{
+		addr:       addr,
+		username:   username,
+		password:   password,
+		useTLS:     useTLS,
+		enableDump: enableDump,
+		dumpDir:    dumpname.DefaultDir,
+	}
+}
+
+// SetDumpDir sets the directory fetched messages are dumped to
+func (p *Provider) SetDumpDir(dir string) {
+	p.dumpDir = dir
+}
+
+// FetchFeed implements feeds.FeedProvider.FetchFeed, reading the unread messages from the
+// persona's senders in each of its mailboxes. Messages are marked read after they are fetched, so
+// each newsletter is only processed once.
+func (p *Provider) FetchFeed(ctx context.Context, pers persona.Persona) (*feeds.Feed, error) {
+	if p.addr == "" {
+		return nil, fmt.Errorf("IMAP server not configured for persona %s - ANP_IMAP_ADDR is required for imap provider", pers.Name)
+	}
+
+	c, err := dial(p.addr, p.useTLS, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer c.close()
+	if deadline, ok := ctx.Deadline(); ok {
+		c.conn.SetDeadline(deadline)
+	}
+
+	if err := c.login(p.username, p.password); err != nil {
+		return nil, err
+	}
+
+	mailboxes := pers.ImapMailboxes
+	if len(mailboxes) == 0 {
+		mailboxes = []string{defaultMailbox}
+	}
+
+	var entries []feeds.Entry
+	for _, mailbox := range mailboxes {
+		log.Printf("Fetching newsletters from IMAP mailbox %s for persona %s", mailbox, pers.Name)
+		mailboxEntries, err := p.fetchMailbox(c, mailbox, pers)
+		if err != nil {
+			return nil, fmt.Errorf("mailbox %s: %w", mailbox, err)
+		}
+		entries = append(entries, mailboxEntries...)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Published.After(entries[j].Published)
+	})
+
+	return &feeds.Feed{
+		Entries: entries,
+		RawData: fmt.Sprintf("Newsletters from %s in %s", p.addr, strings.Join(mailboxes, ", ")),
+	}, nil
+}
+
+// fetchMailbox reads the unread messages from the persona's senders in a mailbox and marks them read
+func (p *Provider) fetchMailbox(c *client, mailbox string, pers persona.Persona) ([]feeds.Entry, error) {
+	if err := c.selectMailbox(mailbox); err != nil {
+		return nil, err
+	}
+	uids, err := c.searchUnseen(pers.ImapSenders)
+	if err != nil {
+		return nil, err
+	}
+	if len(uids) == 0 {
+		return nil, nil
+	}
+
+	// UIDs increase with arrival, so the highest are the newest
+	sort.Slice(uids, func(i, j int) bool { return uids[i] > uids[j] })
+	if len(uids) > maxMessages {
+		uids = uids[:maxMessages]
+	}
+
+	messages, err := c.fetch(uids)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []feeds.Entry
+	var fetched []uint32
+	for _, uid := range uids {
+		raw, ok := messages[uid]
+		if !ok {
+			continue
+		}
+		fetched = append(fetched, uid)
+
+		if p.enableDump {
+			if err := p.dump(pers.Name, uid, raw); err != nil {
+				log.Printf("Warning: Failed to dump IMAP message %d: %v", uid, err)
+			}
+		}
+
+		entry, err := ParseMessage(raw)
+		if err != nil {
+			log.Printf("Warning: Skipping IMAP message %d in %s: %v", uid, mailbox, err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	if len(fetched) > 0 {
+		if err := c.markSeen(fetched); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// FetchComments implements feeds.FeedProvider.FetchComments. Newsletters have no comments, so this
+// returns an empty comment feed.
+func (p *Provider) FetchComments(ctx context.Context, entry feeds.Entry) (*feeds.CommentFeed, error) {
+	return &feeds.CommentFeed{
+		Entries: []feeds.EntryComments{},
+		RawData: fmt.Sprintf("Comments not supported for newsletter %s", entry.ID),
+	}, nil
+}
+
+// dump writes a raw message to the persona's directory, where the mock provider reads it
+func (p *Provider) dump(personaName string, uid uint32, raw []byte) error {
+	dir := filepath.Join(p.dumpDir, "imap", dumpname.Persona(personaName))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create dump directory: %w", err)
+	}
+	return os.WriteFile(filepath.Join(dir, fmt.Sprintf("%d.eml", uid)), raw, 0644)
+}
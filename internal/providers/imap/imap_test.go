@@ -0,0 +1,181 @@
+package imap
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+)
+
+const newsletter = "From: \"Import AI\" <jack@importai.substack.com>\r\n" +
+	"Subject: =?utf-8?q?Import_AI_400=3A_Caf=C3=A9_robots?=\r\n" +
+	"Date: Fri, 07 Mar 2025 10:00:00 +0000\r\n" +
+	"Message-ID: <abc123@substack.com>\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/alternative; boundary=\"b1\"\r\n" +
+	"\r\n" +
+	"--b1\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"\r\n" +
+	"Plain version\r\n" +
+	"--b1\r\n" +
+	"Content-Type: text/html; charset=utf-8\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"<html><head><style>p{color:red}</style></head><body>" +
+	"<a href=3D\"https://importai.substack.com/p/400\">View in browser</a>=\r\n" +
+	"<p>Robots in caf=C3=A9s, see <a href=3D\"https://arxiv.org/abs/1\">the paper</a>.</p>=\r\n" +
+	"<img src=3D\"https://track.example/open.gif\" width=3D\"1\" height=3D\"1\">=\r\n" +
+	"<a href=3D\"https://importai.substack.com/unsubscribe?t=3Dx\">Unsubscribe</a>" +
+	"</body></html>\r\n" +
+	"--b1--\r\n"
+
+// fakeServer serves a mailbox with the messages over a scripted IMAP session. The commands it
+// received are sent on the channel when the session ends.
+func fakeServer(t *testing.T, messages map[uint32]string) (string, <-chan []string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	done := make(chan []string, 1)
+	go func() {
+		var commands []string
+		defer func() { done <- commands }()
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		fmt.Fprint(conn, "* OK IMAP4rev1 ready\r\n")
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			tag, cmd, _ := strings.Cut(strings.TrimRight(line, "\r\n"), " ")
+			commands = append(commands, cmd)
+			switch {
+			case strings.HasPrefix(cmd, "LOGIN"):
+				if cmd != `LOGIN "reader" "se\"cret"` {
+					fmt.Fprintf(conn, "%s NO invalid credentials\r\n", tag)
+					continue
+				}
+			case strings.HasPrefix(cmd, "SELECT"):
+				fmt.Fprintf(conn, "* %d EXISTS\r\n", len(messages))
+			case strings.HasPrefix(cmd, "UID SEARCH"):
+				fmt.Fprint(conn, "* SEARCH")
+				for uid := range messages {
+					fmt.Fprintf(conn, " %d", uid)
+				}
+				fmt.Fprint(conn, "\r\n")
+			case strings.HasPrefix(cmd, "UID FETCH"):
+				for uid, msg := range messages {
+					fmt.Fprintf(conn, "* %d FETCH (UID %d BODY[] {%d}\r\n%s)\r\n", uid, uid, len(msg), msg)
+				}
+			case strings.HasPrefix(cmd, "LOGOUT"):
+				fmt.Fprintf(conn, "* BYE\r\n%s OK LOGOUT completed\r\n", tag)
+				return
+			}
+			fmt.Fprintf(conn, "%s OK done\r\n", tag)
+		}
+	}()
+	return listener.Addr().String(), done
+}
+
+func TestProvider(t *testing.T) {
+	addr, commands := fakeServer(t, map[uint32]string{7: newsletter})
+	dumpDir := t.TempDir()
+	provider := NewProvider(addr, "reader", `se"cret`, false, true)
+	provider.SetDumpDir(dumpDir)
+	p := persona.Persona{
+		Name:          "Newsletters",
+		Provider:      "imap",
+		ImapMailboxes: []string{"Newsletters"},
+		ImapSenders:   []string{"substack.com", "importai.net"},
+	}
+
+	feed, err := provider.FetchFeed(context.Background(), p)
+	if err != nil {
+		t.Fatalf("FetchFeed: %v", err)
+	}
+	if len(feed.Entries) != 1 {
+		t.Fatalf("expected one entry, got %d", len(feed.Entries))
+	}
+	entry := feed.Entries[0]
+	if entry.Title != "Import AI 400: Café robots" || entry.Author != "Import AI" {
+		t.Errorf("unexpected title or author: %q, %q", entry.Title, entry.Author)
+	}
+	if entry.Link.Href != "https://importai.substack.com/p/400" {
+		t.Errorf("expected the browser link, got %q", entry.Link.Href)
+	}
+
+	want := []string{
+		`LOGIN "reader" "se\"cret"`,
+		`SELECT "Newsletters"`,
+		`UID SEARCH UNSEEN OR FROM "substack.com" FROM "importai.net"`,
+		`UID FETCH 7 (UID BODY.PEEK[])`,
+		`UID STORE 7 +FLAGS.SILENT (\Seen)`,
+	}
+	if got := <-commands; !reflect.DeepEqual(got[:len(want)], want) {
+		t.Errorf("unexpected commands:\n got %q\nwant %q", got, want)
+	}
+
+	if _, err := os.Stat(filepath.Join(dumpDir, "imap", "newsletters", "7.eml")); err != nil {
+		t.Errorf("expected the message to be dumped: %v", err)
+	}
+}
+
+func TestProvider_LoginFails(t *testing.T) {
+	addr, _ := fakeServer(t, nil)
+	provider := NewProvider(addr, "reader", "wrong", false, false)
+
+	_, err := provider.FetchFeed(context.Background(), persona.Persona{Name: "Test", ImapSenders: []string{"substack.com"}})
+	if err == nil || !strings.Contains(err.Error(), "invalid credentials") {
+		t.Errorf("expected the login error, got %v", err)
+	}
+}
+
+func TestParseMessage(t *testing.T) {
+	entry, err := ParseMessage([]byte(newsletter))
+	if err != nil {
+		t.Fatalf("ParseMessage: %v", err)
+	}
+
+	want := `<a href="https://importai.substack.com/p/400">View in browser</a><p>Robots in cafés, see <a href="https://arxiv.org/abs/1">the paper</a>.</p>`
+	if entry.Content != want {
+		t.Errorf("expected styles, tracking pixels and unsubscribe links to be removed:\n got %s\nwant %s", entry.Content, want)
+	}
+	if entry.ID != messageID(map[string][]string{"Message-Id": {"<abc123@substack.com>"}}) || !strings.HasPrefix(entry.ID, "nl") {
+		t.Errorf("expected an ID derived from the Message-ID, got %q", entry.ID)
+	}
+	if entry.Published.IsZero() {
+		t.Error("expected the date to be parsed")
+	}
+}
+
+func TestParseMessage_PlainText(t *testing.T) {
+	raw := "From: news@example.com\r\nSubject: Weekly\r\n\r\nFirst <paragraph>\r\n\r\nRead https://example.com/post\r\n"
+	entry, err := ParseMessage([]byte(raw))
+	if err != nil {
+		t.Fatalf("ParseMessage: %v", err)
+	}
+
+	want := `<p>First &lt;paragraph&gt;</p><p>Read <a href="https://example.com/post">https://example.com/post</a></p>`
+	if entry.Content != want {
+		t.Errorf("unexpected content:\n got %s\nwant %s", entry.Content, want)
+	}
+	if entry.Author != "news@example.com" {
+		t.Errorf("expected the address as author without a name, got %q", entry.Author)
+	}
+}
@@ -0,0 +1,294 @@
+package imap
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"strings"
+
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
+	xhtml "golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+// wordDecoder decodes encoded words (=?utf-8?q?...?=) in headers, in any charset
+var wordDecoder = &mime.WordDecoder{CharsetReader: charset.NewReaderLabel}
+
+// ParseMessage converts a newsletter email to a feed entry. The subject becomes the title, the
+// sender the author and flair, and the cleaned HTML body (or the text body as HTML) the content.
+// The link is the "view in browser" link of the newsletter, or the link titled like the subject.
+func ParseMessage(raw []byte) (feeds.Entry, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return feeds.Entry{}, fmt.Errorf("failed to parse message: %w", err)
+	}
+
+	subject, err := wordDecoder.DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		subject = msg.Header.Get("Subject")
+	}
+	subject = strings.TrimSpace(subject)
+
+	author := msg.Header.Get("From")
+	parser := mail.AddressParser{WordDecoder: wordDecoder}
+	if from, err := parser.Parse(author); err == nil {
+		author = from.Address
+		if from.Name != "" {
+			author = from.Name
+		}
+	}
+
+	htmlBody, textBody, err := readBody(msg.Header, msg.Body)
+	if err != nil {
+		return feeds.Entry{}, err
+	}
+	if htmlBody == "" {
+		htmlBody = textToHTML(textBody)
+	}
+	content, link := cleanHTML(htmlBody, subject)
+
+	entry := feeds.Entry{
+		Title:   subject,
+		ID:      messageID(msg.Header),
+		Link:    feeds.Link{Href: link},
+		Content: content,
+		Author:  author,
+		Flair:   author,
+	}
+	if date, err := msg.Header.Date(); err == nil {
+		entry.Published = date
+	}
+	return entry, nil
+}
+
+// messageID derives a stable entry ID from the Message-ID header, which is too long and contains
+// characters unsuited for the IDs used in links and anchors
+func messageID(header mail.Header) string {
+	id := strings.TrimSpace(header.Get("Message-Id"))
+	if id == "" {
+		id = header.Get("From") + "\n" + header.Get("Date") + "\n" + header.Get("Subject")
+	}
+	sum := sha1.Sum([]byte(id))
+	return "nl" + hex.EncodeToString(sum[:6])
+}
+
+// readBody returns the HTML and text bodies of a message or message part, looking into nested
+// multipart parts
+func readBody(header map[string][]string, body io.Reader) (htmlBody, textBody string, err error) {
+	get := func(key string) string {
+		if values := header[key]; len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+
+	mediaType, params, err := mime.ParseMediaType(get("Content-Type"))
+	if err != nil {
+		// Messages without a valid content type are plain text
+		mediaType, params = "text/plain", map[string]string{}
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextRawPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return "", "", fmt.Errorf("failed to read message part: %w", err)
+			}
+			if strings.HasPrefix(part.Header.Get("Content-Disposition"), "attachment") {
+				continue
+			}
+			partHTML, partText, err := readBody(part.Header, part)
+			if err != nil {
+				return "", "", err
+			}
+			if htmlBody == "" {
+				htmlBody = partHTML
+			}
+			if textBody == "" {
+				textBody = partText
+			}
+		}
+		return htmlBody, textBody, nil
+	}
+
+	if mediaType != "text/html" && mediaType != "text/plain" {
+		return "", "", nil
+	}
+
+	switch strings.ToLower(get("Content-Transfer-Encoding")) {
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, &newlineSkipper{r: body})
+	}
+	if label := params["charset"]; label != "" {
+		decoded, err := charset.NewReaderLabel(label, body)
+		if err == nil {
+			body = decoded
+		}
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to decode message body: %w", err)
+	}
+
+	if mediaType == "text/html" {
+		return string(data), "", nil
+	}
+	return "", string(data), nil
+}
+
+// newlineSkipper drops the line breaks of base64 encoded bodies, which the decoder does not accept
+type newlineSkipper struct {
+	r io.Reader
+}
+
+func (s *newlineSkipper) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	kept := 0
+	for _, b := range p[:n] {
+		if b != '\r' && b != '\n' {
+			p[kept] = b
+			kept++
+		}
+	}
+	return kept, err
+}
+
+// urlPattern finds URLs in text bodies, so they can be linked
+var urlPattern = regexp.MustCompile(`https?://[^\s<>"]+`)
+
+// textToHTML converts a text body to HTML paragraphs with its URLs linked
+func textToHTML(text string) string {
+	var b strings.Builder
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		escaped := html.EscapeString(paragraph)
+		linked := urlPattern.ReplaceAllStringFunc(escaped, func(u string) string {
+			return fmt.Sprintf(`<a href="%s">%s</a>`, u, u)
+		})
+		b.WriteString("<p>" + strings.ReplaceAll(linked, "\n", "<br>") + "</p>")
+	}
+	return b.String()
+}
+
+// browserLinkPattern matches the text of the link to the web version of a newsletter
+var browserLinkPattern = regexp.MustCompile(`(?i)^(view|read|open)( (this|it|the))?( (post|email|newsletter|issue))? (online|in (your |a )?browser|on the web)$`)
+
+// unsubscribePattern matches links that manage the subscription rather than point to content
+var unsubscribePattern = regexp.MustCompile(`(?i)unsubscribe|manage (your )?(subscription|preferences)|email preferences|opt[- ]out`)
+
+// cleanHTML removes the parts of a newsletter that are not content: the head, scripts, styles,
+// tracking pixels, and subscription management links. It returns the cleaned body and the link to
+// the newsletter on the web, if one was found.
+func cleanHTML(content, subject string) (string, string) {
+	doc, err := xhtml.Parse(strings.NewReader(content))
+	if err != nil {
+		return content, ""
+	}
+
+	var link, titleLink string
+	var clean func(*xhtml.Node)
+	clean = func(n *xhtml.Node) {
+		for c := n.FirstChild; c != nil; {
+			next := c.NextSibling
+			if c.Type == xhtml.CommentNode || (c.Type == xhtml.ElementNode && isNonContent(c)) {
+				n.RemoveChild(c)
+				c = next
+				continue
+			}
+			clean(c)
+
+			if c.Type == xhtml.ElementNode && c.Data == "a" {
+				href := attr(c, "href")
+				text := strings.Join(strings.Fields(textContent(c)), " ")
+				switch {
+				case unsubscribePattern.MatchString(text) || unsubscribePattern.MatchString(href):
+					n.RemoveChild(c)
+				case link == "" && browserLinkPattern.MatchString(text):
+					link = href
+				case titleLink == "" && subject != "" && strings.EqualFold(text, subject):
+					titleLink = href
+				}
+			}
+			c = next
+		}
+	}
+	clean(doc)
+	if link == "" {
+		link = titleLink
+	}
+
+	body := find(doc, "body")
+	if body == nil {
+		body = doc
+	}
+	var b strings.Builder
+	for c := body.FirstChild; c != nil; c = c.NextSibling {
+		xhtml.Render(&b, c)
+	}
+	return strings.TrimSpace(b.String()), link
+}
+
+// isNonContent reports whether an element never holds newsletter content
+func isNonContent(n *xhtml.Node) bool {
+	switch n.Data {
+	case "head", "script", "style", "title", "meta", "link":
+		return true
+	case "img":
+		// Tracking pixels
+		return attr(n, "width") == "1" || attr(n, "height") == "1" || attr(n, "width") == "0"
+	}
+	return false
+}
+
+// attr returns the value of an attribute of a node
+func attr(n *xhtml.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// find returns the first element with the name in the tree under n
+func find(n *xhtml.Node, name string) *xhtml.Node {
+	if n.Type == xhtml.ElementNode && n.Data == name {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := find(c, name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// textContent returns the text inside a node
+func textContent(n *xhtml.Node) string {
+	if n.Type == xhtml.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(textContent(c))
+	}
+	return b.String()
+}
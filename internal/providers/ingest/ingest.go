@@ -0,0 +1,94 @@
+// Package ingest implements a feed provider for items pushed by external systems. Items posted to
+// the daemon's ingest endpoint are queued per persona and drained into the persona's next run, so
+// any upstream scraper can feed a digest.
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/internal/providers/dumpname"
+)
+
+// Provider implements the feeds.FeedProvider interface for the ingest queue
+type Provider struct {
+	queue      *Queue
+	enableDump bool
+	dumpDir    string
+	items      map[string]Item // Set by FetchFeed, since comments come with the items
+}
+
+// NewProvider creates a provider that drains the queue
+func NewProvider(queue *Queue, enableDump bool) *Provider {
+	return &Provider{
+		queue:      queue,
+		enableDump: enableDump,
+		dumpDir:    dumpname.DefaultDir,
+		items:      make(map[string]Item),
+	}
+}
+
+// SetDumpDir sets the directory drained items are dumped to
+func (p *Provider) SetDumpDir(dir string) {
+	p.dumpDir = dir
+}
+
+// FetchFeed implements feeds.FeedProvider.FetchFeed, draining the items queued for the persona.
+// Drained items are removed from the queue; items already sent are skipped by the sent log as
+// for other providers.
+func (p *Provider) FetchFeed(ctx context.Context, pers persona.Persona) (*feeds.Feed, error) {
+	items, err := p.queue.Drain(pers.Name)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Drained %d ingested items for persona %s", len(items), pers.Name)
+
+	for _, item := range items {
+		p.items[item.ID] = item
+	}
+
+	if p.enableDump && len(items) > 0 {
+		if err := p.dump(pers.Name, items); err != nil {
+			log.Printf("Warning: Failed to dump ingested items: %v", err)
+		}
+	}
+
+	return &feeds.Feed{
+		Entries: ItemsToEntries(items),
+		RawData: fmt.Sprintf("Ingested items for persona %s", pers.Name),
+	}, nil
+}
+
+// FetchComments implements feeds.FeedProvider.FetchComments, returning the comments posted with
+// the item after the item itself, matching the Reddit comment feeds
+func (p *Provider) FetchComments(ctx context.Context, entry feeds.Entry) (*feeds.CommentFeed, error) {
+	item, ok := p.items[entry.ID]
+	if !ok {
+		return nil, fmt.Errorf("item %s was not drained from the queue", entry.ID)
+	}
+	return &feeds.CommentFeed{
+		Entries: Comments(item),
+		RawData: fmt.Sprintf("Comments of ingested item %s", item.ID),
+	}, nil
+}
+
+// dump writes drained items as JSON to the persona's directory, where the mock provider reads them
+func (p *Provider) dump(personaName string, items []Item) error {
+	name := strings.ReplaceAll(strings.ToLower(personaName), "/", "")
+	dir := filepath.Join(p.dumpDir, "ingest", name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create dump directory: %w", err)
+	}
+	data, err := json.MarshalIndent(items, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal dump: %w", err)
+	}
+	return os.WriteFile(filepath.Join(dir, name+".json"), data, 0644)
+}
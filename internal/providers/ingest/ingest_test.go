@@ -0,0 +1,98 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+)
+
+func TestQueue(t *testing.T) {
+	queue := NewQueue(t.TempDir())
+	now := time.Date(2025, time.March, 7, 12, 0, 0, 0, time.UTC)
+	queue.now = func() time.Time { return now }
+
+	if err := queue.Push("Scraped", []Item{{ID: "a", Title: "First", Content: "<p>Body</p>"}, {Title: "Second", URL: "https://example.com/b"}}); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	now = now.Add(time.Minute)
+	if err := queue.Push("Scraped", []Item{{ID: "a", Title: "First, updated", Content: "<p>Body</p>"}}); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+
+	err := queue.Push("Scraped", []Item{{Title: "Fine", URL: "https://example.com/c"}, {Title: "Bad", URL: "ftp://example.com"}})
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) || invalid.Index != 1 {
+		t.Fatalf("expected a validation error for the second item, got %v", err)
+	}
+
+	items, err := queue.Drain("Scraped")
+	if err != nil {
+		t.Fatalf("Drain: %v", err)
+	}
+	var titles []string
+	for _, item := range items {
+		titles = append(titles, item.Title)
+	}
+	if !reflect.DeepEqual(titles, []string{"First, updated", "Second"}) {
+		t.Errorf("expected later pushes to replace items and invalid batches to be rejected, got %v", titles)
+	}
+	if items[1].Published.IsZero() || items[1].ID == "" {
+		t.Errorf("expected the publish time and ID to be filled in, got %+v", items[1])
+	}
+
+	if items, err := queue.Drain("Scraped"); err != nil || len(items) != 0 {
+		t.Errorf("expected drained items to be removed, got %v, %v", items, err)
+	}
+}
+
+func TestProvider(t *testing.T) {
+	queue := NewQueue(t.TempDir())
+	if err := queue.Push("Scraped", []Item{{
+		ID:       "1",
+		Title:    "New model",
+		URL:      "https://example.com/model",
+		Content:  "<p>Weights are out</p>",
+		Images:   []string{"https://example.com/chart.png"},
+		Comments: []string{"Looks good", " "},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	dumpDir := t.TempDir()
+	provider := NewProvider(queue, true)
+	provider.SetDumpDir(dumpDir)
+	feed, err := provider.FetchFeed(context.Background(), persona.Persona{Name: "Scraped", Provider: "ingest"})
+	if err != nil {
+		t.Fatalf("FetchFeed: %v", err)
+	}
+	if len(feed.Entries) != 1 {
+		t.Fatalf("expected one entry, got %d", len(feed.Entries))
+	}
+	entry := feed.Entries[0]
+	if entry.Content != `<p>Weights are out</p><p><a href="https://example.com/model">https://example.com/model</a></p>` {
+		t.Errorf("expected the URL to be linked in the content, got %s", entry.Content)
+	}
+	if len(entry.ImageURLs) != 1 || entry.Link.Href != "https://example.com/model" {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+
+	comments, err := provider.FetchComments(context.Background(), entry)
+	if err != nil {
+		t.Fatalf("FetchComments: %v", err)
+	}
+	want := []feeds.EntryComments{{Content: "New model"}, {Content: "Looks good"}}
+	if !reflect.DeepEqual(comments.Entries, want) {
+		t.Errorf("expected the item followed by its comments, got %+v", comments.Entries)
+	}
+
+	if _, err := os.Stat(filepath.Join(dumpDir, "ingest", "scraped", "scraped.json")); err != nil {
+		t.Errorf("expected the items to be dumped: %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+package ingest
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
+)
+
+// Item is an entry posted to the ingest endpoint by an external system, such as a scraper
+type Item struct {
+	ID        string    `json:"id,omitempty"`        // Stable ID, used to avoid sending an item twice (defaults to a hash of the URL)
+	Title     string    `json:"title"`               // Required
+	URL       string    `json:"url,omitempty"`       // Link of the item; a URL or content is required
+	Content   string    `json:"content,omitempty"`   // HTML or text body; links in it are summarized like those of other posts
+	Author    string    `json:"author,omitempty"`    // Author name, matched by the persona's blocklist
+	Flair     string    `json:"flair,omitempty"`     // Category, used for grouping digests by flair
+	Published time.Time `json:"published,omitempty"` // Defaults to the time the item was received
+	Images    []string  `json:"images,omitempty"`    // Image URLs, described by the vision model
+	Comments  []string  `json:"comments,omitempty"`  // Discussion of the item, counted by the comment threshold
+}
+
+// Validate checks an item has the required fields and fills in its ID
+func (i *Item) Validate() error {
+	if strings.TrimSpace(i.Title) == "" {
+		return errors.New("title is required")
+	}
+	if i.URL == "" && strings.TrimSpace(i.Content) == "" {
+		return errors.New("url or content is required")
+	}
+	if i.URL != "" {
+		u, err := url.Parse(i.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("url must be an HTTP/HTTPS URL, got %q", i.URL)
+		}
+	}
+	for _, image := range i.Images {
+		if u, err := url.Parse(image); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("images must be HTTP/HTTPS URLs, got %q", image)
+		}
+	}
+	if i.ID == "" {
+		key := i.URL
+		if key == "" {
+			key = i.Title + "\n" + i.Content
+		}
+		sum := sha1.Sum([]byte(key))
+		i.ID = "in" + hex.EncodeToString(sum[:6])
+	}
+	return nil
+}
+
+// ItemsToEntries converts queued items to feed entries
+func ItemsToEntries(items []Item) []feeds.Entry {
+	entries := make([]feeds.Entry, len(items))
+	for i, item := range items {
+		entries[i] = itemToEntry(item)
+	}
+	return entries
+}
+
+// itemToEntry converts an item to a feed entry. The URL is added to the content as a link, so the
+// page it points to is summarized like the links of other posts.
+func itemToEntry(item Item) feeds.Entry {
+	content := item.Content
+	if item.URL != "" && !strings.Contains(content, item.URL) {
+		content += fmt.Sprintf(`<p><a href="%s">%s</a></p>`, html.EscapeString(item.URL), html.EscapeString(item.URL))
+	}
+
+	entry := feeds.Entry{
+		Title:     item.Title,
+		ID:        item.ID,
+		Link:      feeds.Link{Href: item.URL},
+		Published: item.Published,
+		Content:   content,
+		Flair:     item.Flair,
+		Author:    item.Author,
+	}
+	for _, image := range item.Images {
+		if u, err := url.Parse(image); err == nil {
+			entry.ImageURLs = append(entry.ImageURLs, *u)
+		}
+	}
+	return entry
+}
+
+// Comments converts the comments of an item. The item itself comes first, as in Reddit comment
+// feeds, since feed processing drops the first comment.
+func Comments(item Item) []feeds.EntryComments {
+	comments := []feeds.EntryComments{{Content: item.Title}}
+	for _, comment := range item.Comments {
+		if comment = strings.TrimSpace(comment); comment != "" {
+			comments = append(comments, feeds.EntryComments{Content: comment})
+		}
+	}
+	return comments
+}
@@ -0,0 +1,116 @@
+package ingest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Queue holds the items posted for each persona until its next run. Every push is written to its
+// own file, so the daemon can push while the processor drains without either locking the other.
+type Queue struct {
+	dir string
+	now func() time.Time
+}
+
+// ValidationError is returned by Push for an item without the required fields
+type ValidationError struct {
+	Index int // Position of the item in the pushed batch
+	Err   error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("item %d: %v", e.Index, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// NewQueue creates a queue that stores pushed items under dir
+func NewQueue(dir string) *Queue {
+	return &Queue{dir: dir, now: time.Now}
+}
+
+// Push queues items for a persona. Items are validated first, so a batch is queued whole or not
+// at all.
+func (q *Queue) Push(personaName string, items []Item) error {
+	for i := range items {
+		if err := items[i].Validate(); err != nil {
+			return &ValidationError{Index: i, Err: err}
+		}
+		if items[i].Published.IsZero() {
+			items[i].Published = q.now()
+		}
+	}
+
+	dir := q.personaDir(personaName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create queue directory: %w", err)
+	}
+	data, err := json.Marshal(items)
+	if err != nil {
+		return fmt.Errorf("failed to marshal items: %w", err)
+	}
+
+	// Batches are named by time so they drain in the order they arrived, and written to a
+	// temporary file first so a drain never reads half a batch
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	name := fmt.Sprintf("%d-%s.json", q.now().UnixNano(), hex.EncodeToString(suffix))
+	tmp := filepath.Join(dir, "."+name)
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write items: %w", err)
+	}
+	return os.Rename(tmp, filepath.Join(dir, name))
+}
+
+// Drain removes and returns the items queued for a persona, oldest first. Items pushed more than
+// once keep their latest version.
+func (q *Queue) Drain(personaName string) ([]Item, error) {
+	dir := q.personaDir(personaName)
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list queued items: %w", err)
+	}
+	sort.Strings(paths)
+
+	var items []Item
+	index := make(map[string]int)
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read queued items: %w", err)
+		}
+		var batch []Item
+		if err := json.Unmarshal(data, &batch); err != nil {
+			return nil, fmt.Errorf("failed to parse queued items in %s: %w", filepath.Base(path), err)
+		}
+		for _, item := range batch {
+			if i, ok := index[item.ID]; ok {
+				items[i] = item
+				continue
+			}
+			index[item.ID] = len(items)
+			items = append(items, item)
+		}
+	}
+
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove drained items: %w", err)
+		}
+	}
+	return items, nil
+}
+
+// personaDir returns the directory of a persona's queue
+func (q *Queue) personaDir(personaName string) string {
+	return filepath.Join(q.dir, strings.ReplaceAll(strings.ToLower(personaName), "/", ""))
+}
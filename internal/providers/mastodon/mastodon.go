@@ -0,0 +1,236 @@
+// Package mastodon implements a feed provider that reads public posts from the hashtag and account
+// timelines of a Mastodon (or compatible Fediverse) instance through the Mastodon API.
+package mastodon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/internal/providers/dumpname"
+)
+
+// timelineLimit is the number of posts read from each timeline, the most the API returns per page
+const timelineLimit = 40
+
+// Provider implements the feeds.FeedProvider interface for Mastodon timelines. Replies and boosts
+// of a post are returned as its comments.
+type Provider struct {
+	httpClient *http.Client
+	token      string
+	enableDump bool
+	dumpDir    string
+	metrics    *feeds.FetchMetrics
+
+	// Set by FetchFeed, since comments are read from the same instance
+	instance    string
+	personaName string
+	statuses    map[string]Status
+}
+
+// NewProvider creates a Mastodon provider. The access token is optional; most instances serve
+// public timelines without one.
+func NewProvider(token string, enableDump bool) *Provider {
+	metrics := feeds.NewFetchMetrics("mastodon")
+	return &Provider{
+		httpClient: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: metrics.Transport(nil),
+		},
+		token:      token,
+		enableDump: enableDump,
+		dumpDir:    dumpname.DefaultDir,
+		metrics:    metrics,
+		statuses:   make(map[string]Status),
+	}
+}
+
+// SetDumpDir sets the directory fetched posts are dumped to
+func (p *Provider) SetDumpDir(dir string) {
+	p.dumpDir = dir
+}
+
+// Metrics implements feeds.MetricsReporter
+func (p *Provider) Metrics() feeds.FetchMetricsSnapshot {
+	return p.metrics.Snapshot()
+}
+
+// FetchFeed implements feeds.FeedProvider.FetchFeed, reading the persona's hashtag and account
+// timelines. A timeline that fails is skipped, unless all of them fail.
+func (p *Provider) FetchFeed(ctx context.Context, pers persona.Persona) (*feeds.Feed, error) {
+	if pers.MastodonInstance == "" {
+		return nil, fmt.Errorf("Mastodon instance not configured for persona %s - mastodon_instance field is required for mastodon provider", pers.Name)
+	}
+	p.instance = strings.TrimSuffix(pers.MastodonInstance, "/")
+	p.personaName = pers.Name
+
+	var statuses []Status
+	var errs []string
+	for _, tag := range pers.MastodonHashtags {
+		tag = strings.TrimPrefix(tag, "#")
+		log.Printf("Fetching Mastodon hashtag #%s from %s for persona %s", tag, p.instance, pers.Name)
+		var page []Status
+		if err := p.get(ctx, "/api/v1/timelines/tag/"+url.PathEscape(tag), url.Values{"limit": {fmt.Sprint(timelineLimit)}}, &page); err != nil {
+			log.Printf("Warning: Failed to fetch Mastodon hashtag #%s: %v", tag, err)
+			errs = append(errs, fmt.Sprintf("#%s: %v", tag, err))
+			continue
+		}
+		statuses = append(statuses, page...)
+	}
+	for _, acct := range pers.MastodonAccounts {
+		acct = strings.TrimPrefix(acct, "@")
+		log.Printf("Fetching Mastodon account @%s from %s for persona %s", acct, p.instance, pers.Name)
+		page, err := p.accountStatuses(ctx, acct)
+		if err != nil {
+			log.Printf("Warning: Failed to fetch Mastodon account @%s: %v", acct, err)
+			errs = append(errs, fmt.Sprintf("@%s: %v", acct, err))
+			continue
+		}
+		statuses = append(statuses, page...)
+	}
+	if len(errs) > 0 && len(errs) == len(pers.MastodonHashtags)+len(pers.MastodonAccounts) {
+		return nil, fmt.Errorf("failed to fetch Mastodon timelines: %s", strings.Join(errs, "; "))
+	}
+
+	statuses = Unwrap(statuses)
+	for _, status := range statuses {
+		p.statuses[status.ID] = status
+	}
+
+	if p.enableDump {
+		if err := p.dump(dumpname.Persona(pers.Name)+".json", statuses); err != nil {
+			log.Printf("Warning: Failed to dump Mastodon timelines: %v", err)
+		}
+	}
+
+	return &feeds.Feed{
+		Entries: StatusesToEntries(statuses),
+		RawData: fmt.Sprintf("Mastodon timelines from %s", p.instance),
+	}, nil
+}
+
+// accountStatuses reads the latest posts of an account, leaving out its replies to others
+func (p *Provider) accountStatuses(ctx context.Context, acct string) ([]Status, error) {
+	var account Account
+	if err := p.get(ctx, "/api/v1/accounts/lookup", url.Values{"acct": {acct}}, &account); err != nil {
+		return nil, fmt.Errorf("failed to look up account: %w", err)
+	}
+
+	var statuses []Status
+	query := url.Values{"limit": {fmt.Sprint(timelineLimit)}, "exclude_replies": {"true"}}
+	if err := p.get(ctx, "/api/v1/accounts/"+url.PathEscape(account.ID)+"/statuses", query, &statuses); err != nil {
+		return nil, err
+	}
+	return statuses, nil
+}
+
+// FetchComments implements feeds.FeedProvider.FetchComments. The replies in the thread of a post
+// and the accounts that boosted it are returned as comments, after the post itself, matching the
+// Reddit comment feeds.
+func (p *Provider) FetchComments(ctx context.Context, entry feeds.Entry) (*feeds.CommentFeed, error) {
+	status, ok := p.statuses[entry.ID]
+	if !ok {
+		return nil, fmt.Errorf("post %s was not fetched from a timeline", entry.ID)
+	}
+
+	var thread Context
+	if status.RepliesCount > 0 {
+		if err := p.get(ctx, "/api/v1/statuses/"+url.PathEscape(status.ID)+"/context", nil, &thread); err != nil {
+			return nil, fmt.Errorf("failed to fetch replies: %w", err)
+		}
+	}
+	var boosters []Account
+	if status.ReblogsCount > 0 {
+		if err := p.get(ctx, "/api/v1/statuses/"+url.PathEscape(status.ID)+"/reblogged_by", url.Values{"limit": {fmt.Sprint(timelineLimit)}}, &boosters); err != nil {
+			return nil, fmt.Errorf("failed to fetch boosts: %w", err)
+		}
+	}
+
+	comments := &feeds.CommentFeed{
+		Entries: Comments(status, thread.Descendants, boosters),
+		RawData: fmt.Sprintf("Replies and boosts of Mastodon post %s", status.ID),
+	}
+	if p.enableDump {
+		if err := p.dump(status.ID+".json", comments); err != nil {
+			log.Printf("Warning: Failed to dump Mastodon comments: %v", err)
+		}
+	}
+	return comments, nil
+}
+
+// get requests a Mastodon API endpoint of the instance and decodes the JSON response into v
+func (p *Provider) get(ctx context.Context, path string, query url.Values, v any) error {
+	endpoint := p.instance + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "ai-news-processor/1.0 (Mastodon Reader)")
+	req.Header.Set("Accept", "application/json")
+	if p.token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.token)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return nil
+}
+
+// dump writes fetched data as JSON to the persona's directory, where the mock provider reads it
+func (p *Provider) dump(name string, v any) error {
+	dir := filepath.Join(p.dumpDir, "mastodon", dumpname.Persona(p.personaName))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create dump directory: %w", err)
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal dump: %w", err)
+	}
+	return os.WriteFile(filepath.Join(dir, name), data, 0644)
+}
+
+// Unwrap replaces boosts with the posts they boost, drops posts that appear more than once, and
+// orders the posts newest first
+func Unwrap(statuses []Status) []Status {
+	seen := make(map[string]bool, len(statuses))
+	unwrapped := make([]Status, 0, len(statuses))
+	for _, status := range statuses {
+		if status.Reblog != nil {
+			status = *status.Reblog
+		}
+		if seen[status.ID] {
+			continue
+		}
+		seen[status.ID] = true
+		unwrapped = append(unwrapped, status)
+	}
+	sort.SliceStable(unwrapped, func(i, j int) bool {
+		return unwrapped[i].CreatedAt.After(unwrapped[j].CreatedAt)
+	})
+	return unwrapped
+}
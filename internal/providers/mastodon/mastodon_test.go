@@ -0,0 +1,163 @@
+package mastodon
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+)
+
+func testServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	older := time.Date(2025, time.March, 7, 10, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+	original := Status{
+		ID:           "1",
+		CreatedAt:    older,
+		URL:          "https://mastodon.social/@alice/1",
+		Content:      `<p>Qwen 3 is out <a href="https://mastodon.social/tags/llm" class="mention hashtag" rel="tag">#<span>llm</span></a></p><p><a href="https://example.com/qwen3">example.com/qwen3</a></p>`,
+		Account:      Account{ID: "10", Acct: "alice"},
+		Tags:         []Tag{{Name: "llm"}},
+		RepliesCount: 1,
+		ReblogsCount: 1,
+		MediaAttachments: []MediaAttachment{
+			{Type: "image", URL: "https://files.example/chart.png", PreviewURL: "https://files.example/chart_small.png"},
+			{Type: "video", URL: "https://files.example/demo.mp4"},
+		},
+	}
+
+	mux := http.NewServeMux()
+	reply := func(w http.ResponseWriter, v any) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(v)
+	}
+	mux.HandleFunc("/api/v1/timelines/tag/llm", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("limit") != "40" {
+			t.Errorf("expected a limit of 40, got %q", r.URL.Query().Get("limit"))
+		}
+		// A boost of a post that is also in the account timeline
+		reply(w, []Status{{ID: "2", CreatedAt: newer, Account: Account{Acct: "bob"}, Reblog: &original}})
+	})
+	mux.HandleFunc("/api/v1/timelines/tag/missing", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"Record not found"}`, http.StatusNotFound)
+	})
+	mux.HandleFunc("/api/v1/accounts/lookup", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("acct") != "alice@mastodon.social" {
+			http.NotFound(w, r)
+			return
+		}
+		reply(w, Account{ID: "10", Acct: "alice"})
+	})
+	mux.HandleFunc("/api/v1/accounts/10/statuses", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("exclude_replies") != "true" {
+			t.Error("expected replies to be excluded from account timelines")
+		}
+		reply(w, []Status{original, {ID: "3", CreatedAt: newer, Content: "<p>Later post</p>", Account: Account{Acct: "alice"}}})
+	})
+	mux.HandleFunc("/api/v1/statuses/1/context", func(w http.ResponseWriter, r *http.Request) {
+		reply(w, Context{Descendants: []Status{{ID: "4", Content: "<p>Nice, <br>trying it now</p>", Account: Account{Acct: "carol@example.org"}}}})
+	})
+	mux.HandleFunc("/api/v1/statuses/1/reblogged_by", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("expected the access token to be sent, got %q", r.Header.Get("Authorization"))
+		}
+		reply(w, []Account{{Acct: "bob"}})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestProvider(t *testing.T) {
+	server := testServer(t)
+	dumpDir := t.TempDir()
+	provider := NewProvider("token", true)
+	provider.SetDumpDir(dumpDir)
+	p := persona.Persona{
+		Name:             "Fediverse AI",
+		Provider:         "mastodon",
+		MastodonInstance: server.URL + "/",
+		MastodonHashtags: []string{"#llm", "missing"},
+		MastodonAccounts: []string{"@alice@mastodon.social"},
+	}
+
+	feed, err := provider.FetchFeed(context.Background(), p)
+	if err != nil {
+		t.Fatalf("FetchFeed: %v", err)
+	}
+
+	var ids []string
+	for _, entry := range feed.Entries {
+		ids = append(ids, entry.ID)
+	}
+	if !reflect.DeepEqual(ids, []string{"3", "1"}) {
+		t.Fatalf("expected boosts to be unwrapped and deduplicated newest first, got %v", ids)
+	}
+
+	entry := feed.Entries[1]
+	if entry.Title != "Qwen 3 is out #llm" {
+		t.Errorf("expected the first line as title, got %q", entry.Title)
+	}
+	if entry.Author != "alice" || entry.Flair != "llm" || entry.Link.Href != "https://mastodon.social/@alice/1" {
+		t.Errorf("unexpected entry fields: %+v", entry)
+	}
+	if len(entry.ImageURLs) != 1 || entry.ImageURLs[0].String() != "https://files.example/chart.png" {
+		t.Errorf("expected only the image attachment, got %v", entry.ImageURLs)
+	}
+	if entry.MediaThumbnail.URL != "https://files.example/chart_small.png" {
+		t.Errorf("expected the image preview as thumbnail, got %q", entry.MediaThumbnail.URL)
+	}
+	want := `<p>Qwen 3 is out #llm</p><p><a href="https://example.com/qwen3">example.com/qwen3</a></p>`
+	if entry.Content != want {
+		t.Errorf("expected hashtag links to be unwrapped:\n got %s\nwant %s", entry.Content, want)
+	}
+
+	comments, err := provider.FetchComments(context.Background(), entry)
+	if err != nil {
+		t.Fatalf("FetchComments: %v", err)
+	}
+	wantComments := []feeds.EntryComments{
+		{Content: "Qwen 3 is out #llm\nexample.com/qwen3"},
+		{Content: "@carol@example.org: Nice,\ntrying it now"},
+		{Content: "Boosted by @bob"},
+	}
+	if !reflect.DeepEqual(comments.Entries, wantComments) {
+		t.Errorf("expected the post followed by replies and boosts, got %+v", comments.Entries)
+	}
+
+	// Posts without replies or boosts need no requests
+	if comments, err := provider.FetchComments(context.Background(), feed.Entries[0]); err != nil || len(comments.Entries) != 1 {
+		t.Errorf("expected only the post itself, got %+v, %v", comments, err)
+	}
+
+	for _, name := range []string{"fediverse ai.json", "1.json"} {
+		if _, err := os.Stat(filepath.Join(dumpDir, "mastodon", "fediverse ai", name)); err != nil {
+			t.Errorf("expected %s to be dumped: %v", name, err)
+		}
+	}
+}
+
+func TestProvider_AllTimelinesFail(t *testing.T) {
+	server := testServer(t)
+	provider := NewProvider("", false)
+	p := persona.Persona{Name: "Test", MastodonInstance: server.URL, MastodonHashtags: []string{"missing"}}
+
+	if _, err := provider.FetchFeed(context.Background(), p); err == nil {
+		t.Error("expected an error when every timeline fails")
+	}
+}
+
+func TestStatusToEntry_ContentWarning(t *testing.T) {
+	entry := statusToEntry(Status{ID: "1", SpoilerText: "Benchmark drama", Content: "<p>Long thread</p>"})
+	if entry.Title != "Benchmark drama" {
+		t.Errorf("expected the content warning as title, got %q", entry.Title)
+	}
+}
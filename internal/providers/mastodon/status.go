@@ -0,0 +1,212 @@
+package mastodon
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
+	"github.com/bakkerme/ai-news-processor/internal/templatefuncs"
+	xhtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// titleLength is the longest title derived from the text of a post, which has no title of its own
+const titleLength = 100
+
+// Status is a Mastodon post, with the fields of the API entity the provider uses
+type Status struct {
+	ID               string            `json:"id"`
+	CreatedAt        time.Time         `json:"created_at"`
+	URL              string            `json:"url"`
+	Content          string            `json:"content"`      // HTML
+	SpoilerText      string            `json:"spoiler_text"` // Content warning, shown before the content
+	Account          Account           `json:"account"`
+	Reblog           *Status           `json:"reblog"` // The boosted post, if this is a boost
+	MediaAttachments []MediaAttachment `json:"media_attachments"`
+	Tags             []Tag             `json:"tags"`
+	RepliesCount     int               `json:"replies_count"`
+	ReblogsCount     int               `json:"reblogs_count"`
+}
+
+// Account is a Mastodon account
+type Account struct {
+	ID   string `json:"id"`
+	Acct string `json:"acct"` // user for local accounts, user@domain for remote ones
+}
+
+// MediaAttachment is an image, video or other file attached to a post
+type MediaAttachment struct {
+	Type        string `json:"type"` // image, gifv, video, audio or unknown
+	URL         string `json:"url"`
+	PreviewURL  string `json:"preview_url"`
+	Description string `json:"description"`
+}
+
+// Tag is a hashtag used in a post
+type Tag struct {
+	Name string `json:"name"`
+}
+
+// Context is the thread around a post
+type Context struct {
+	Ancestors   []Status `json:"ancestors"`
+	Descendants []Status `json:"descendants"` // Replies to the post and to its replies
+}
+
+// StatusesToEntries converts posts to feed entries
+func StatusesToEntries(statuses []Status) []feeds.Entry {
+	entries := make([]feeds.Entry, len(statuses))
+	for i, status := range statuses {
+		entries[i] = statusToEntry(status)
+	}
+	return entries
+}
+
+// statusToEntry converts a post to a feed entry. The title is the content warning or the start of
+// the text, and the first hashtag is used as the flair.
+func statusToEntry(status Status) feeds.Entry {
+	text := plainText(status.Content)
+	title := status.SpoilerText
+	if title == "" {
+		title, _, _ = strings.Cut(text, "\n")
+	}
+
+	entry := feeds.Entry{
+		Title:     templatefuncs.Truncate(titleLength, strings.TrimSpace(title)),
+		ID:        status.ID,
+		Link:      feeds.Link{Href: status.URL},
+		Published: status.CreatedAt,
+		Content:   cleanContent(status.Content),
+		Author:    status.Account.Acct,
+	}
+	if len(status.Tags) > 0 {
+		entry.Flair = status.Tags[0].Name
+	}
+
+	for _, media := range status.MediaAttachments {
+		if media.Type != "image" {
+			continue
+		}
+		if u, err := url.Parse(media.URL); err == nil {
+			entry.ImageURLs = append(entry.ImageURLs, *u)
+		}
+		if entry.MediaThumbnail.URL == "" {
+			entry.MediaThumbnail.URL = media.PreviewURL
+		}
+	}
+	return entry
+}
+
+// Comments converts the replies and boosts of a post to comments. The post itself comes first, as
+// in Reddit comment feeds, since feed processing drops the first comment.
+func Comments(status Status, replies []Status, boosters []Account) []feeds.EntryComments {
+	comments := []feeds.EntryComments{{Content: plainText(status.Content)}}
+	for _, reply := range replies {
+		if text := plainText(reply.Content); text != "" {
+			comments = append(comments, feeds.EntryComments{Content: fmt.Sprintf("@%s: %s", reply.Account.Acct, text)})
+		}
+	}
+	for _, account := range boosters {
+		comments = append(comments, feeds.EntryComments{Content: fmt.Sprintf("Boosted by @%s", account.Acct)})
+	}
+	return comments
+}
+
+// plainText converts the HTML content of a post to text, with a line per paragraph or line break
+func plainText(content string) string {
+	doc, err := xhtml.Parse(strings.NewReader(content))
+	if err != nil {
+		return content
+	}
+
+	var b strings.Builder
+	var walk func(*xhtml.Node)
+	walk = func(n *xhtml.Node) {
+		switch {
+		case n.Type == xhtml.TextNode:
+			b.WriteString(n.Data)
+		case n.Type == xhtml.ElementNode && n.Data == "br":
+			b.WriteString("\n")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+		if n.Type == xhtml.ElementNode && n.Data == "p" {
+			b.WriteString("\n")
+		}
+	}
+	walk(doc)
+
+	lines := strings.Split(b.String(), "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// cleanContent replaces the links of mentions and hashtags in the HTML content of a post with
+// their text, so only links to other pages are extracted as external URLs
+func cleanContent(content string) string {
+	nodes, err := xhtml.ParseFragment(strings.NewReader(content), &xhtml.Node{Type: xhtml.ElementNode, Data: "div", DataAtom: atom.Div})
+	if err != nil {
+		return content
+	}
+
+	var unwrap func(*xhtml.Node)
+	unwrap = func(n *xhtml.Node) {
+		for c := n.FirstChild; c != nil; {
+			next := c.NextSibling
+			unwrap(c)
+			if c.Type == xhtml.ElementNode && c.Data == "a" && isMentionOrHashtag(c) {
+				n.InsertBefore(&xhtml.Node{Type: xhtml.TextNode, Data: textContent(c)}, c)
+				n.RemoveChild(c)
+			}
+			c = next
+		}
+	}
+
+	var b strings.Builder
+	for _, node := range nodes {
+		unwrap(node)
+		if node.Type == xhtml.ElementNode && node.Data == "a" && isMentionOrHashtag(node) {
+			b.WriteString(xhtml.EscapeString(textContent(node)))
+			continue
+		}
+		if err := xhtml.Render(&b, node); err != nil {
+			return content
+		}
+	}
+	return b.String()
+}
+
+// isMentionOrHashtag reports whether a link is a mention or hashtag, which Mastodon marks with
+// these classes
+func isMentionOrHashtag(n *xhtml.Node) bool {
+	for _, attr := range n.Attr {
+		if attr.Key == "class" {
+			for _, class := range strings.Fields(attr.Val) {
+				if class == "mention" || class == "hashtag" {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// textContent returns the text inside a node
+func textContent(n *xhtml.Node) string {
+	if n.Type == xhtml.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(textContent(c))
+	}
+	return b.String()
+}
@@ -13,21 +13,35 @@ import (
 
 	"github.com/bakkerme/ai-news-processor/internal/feeds"
 	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/internal/providers/dumpname"
+	"github.com/bakkerme/ai-news-processor/internal/providers/imap"
+	"github.com/bakkerme/ai-news-processor/internal/providers/ingest"
+	"github.com/bakkerme/ai-news-processor/internal/providers/mastodon"
+	"github.com/bakkerme/ai-news-processor/internal/providers/youtube"
 )
 
 // MockProvider implements the feeds.FeedProvider interface using JSON mock data
 type MockProvider struct {
-	PersonaName string
+	PersonaName  string
+	dataDir      string
+	providerType string                 // Provider of the last feed read, which determines where comments are read from
+	items        map[string]ingest.Item // Items of the last ingest or plugin feed read, which carry their comments
 }
 
 // NewMockProvider creates a new mock provider for the specified persona
 func NewMockProvider(personaName string) *MockProvider {
-	processedName := processPersonaName(personaName)
+	processedName := dumpname.Persona(personaName)
 	return &MockProvider{
 		PersonaName: processedName,
+		dataDir:     dumpname.DefaultDir,
 	}
 }
 
+// SetDataDir sets the directory mock feeds are read from
+func (m *MockProvider) SetDataDir(dir string) {
+	m.dataDir = dir
+}
+
 // FetchFeed implements feeds.FeedProvider.FetchFeed for mocks
 func (m *MockProvider) FetchFeed(ctx context.Context, p persona.Persona) (*feeds.Feed, error) {
 	return m.GetMockFeed(ctx, p)
@@ -35,20 +49,52 @@ func (m *MockProvider) FetchFeed(ctx context.Context, p persona.Persona) (*feeds
 
 // FetchComments implements feeds.FeedProvider.FetchComments for mocks
 func (m *MockProvider) FetchComments(ctx context.Context, entry feeds.Entry) (*feeds.CommentFeed, error) {
+	switch m.providerType {
+	case "mastodon":
+		return m.getMockDumpedComments("mastodon", entry.ID)
+	case "plugin":
+		if _, err := os.Stat(filepath.Join(m.dataDir, "plugin", m.PersonaName, entry.ID+".json")); err == nil {
+			return m.getMockDumpedComments("plugin", entry.ID)
+		}
+		fallthrough
+	case "ingest":
+		item, ok := m.items[entry.ID]
+		if !ok {
+			return nil, fmt.Errorf("item %s is not in the %s mock feed", entry.ID, m.providerType)
+		}
+		return &feeds.CommentFeed{
+			Entries: ingest.Comments(item),
+			RawData: fmt.Sprintf("Mock comments of %s item %s", m.providerType, entry.ID),
+		}, nil
+	case "imap", "youtube":
+		return &feeds.CommentFeed{
+			Entries: []feeds.EntryComments{},
+			RawData: fmt.Sprintf("Comments not supported for %s entry %s", m.providerType, entry.ID),
+		}, nil
+	}
 	return m.GetMockComments(ctx, m.PersonaName, entry.ID)
 }
 
 // GetMockFeed reads mock data (JSON for Reddit, XML for RSS) and converts to feeds.Feed format
 func (m *MockProvider) GetMockFeed(ctx context.Context, p persona.Persona) (*feeds.Feed, error) {
-	processedName := processPersonaName(p.Name)
+	processedName := dumpname.Persona(p.Name)
 
 	// Determine provider type and load appropriate mock data
 	providerType := p.GetProvider()
+	m.providerType = providerType
 	switch providerType {
 	case "reddit":
 		return m.getMockRedditFeed(processedName)
 	case "rss":
 		return m.getMockRSSFeed(processedName, p.FeedURL)
+	case "mastodon":
+		return m.getMockMastodonFeed(processedName)
+	case "imap":
+		return m.getMockImapFeed(processedName)
+	case "youtube":
+		return m.getMockYoutubeFeed(processedName)
+	case "ingest", "plugin":
+		return m.getMockItemsFeed(providerType, processedName)
 	default:
 		return nil, fmt.Errorf("unsupported provider type for mock: %s", providerType)
 	}
@@ -57,7 +103,7 @@ func (m *MockProvider) GetMockFeed(ctx context.Context, p persona.Persona) (*fee
 // getMockRedditFeed reads Reddit JSON mock data and converts to feeds.Feed format
 func (m *MockProvider) getMockRedditFeed(processedName string) (*feeds.Feed, error) {
 	// Read JSON mock data
-	path := filepath.Join("feed_mocks", "reddit", processedName, fmt.Sprintf("%s.json", processedName))
+	path := filepath.Join(m.dataDir, "reddit", processedName, fmt.Sprintf("%s.json", processedName))
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read Reddit mock feed: %w", err)
@@ -86,7 +132,7 @@ func (m *MockProvider) getMockRedditFeed(processedName string) (*feeds.Feed, err
 // getMockRSSFeed reads RSS XML mock data and converts to feeds.Feed format
 func (m *MockProvider) getMockRSSFeed(processedName string, feedURL string) (*feeds.Feed, error) {
 	// Read XML mock data
-	path := filepath.Join("feed_mocks", "rss", processedName, fmt.Sprintf("%s.xml", processedName))
+	path := filepath.Join(m.dataDir, "rss", processedName, fmt.Sprintf("%s.xml", processedName))
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read RSS mock feed: %w", err)
@@ -112,13 +158,131 @@ func (m *MockProvider) getMockRSSFeed(processedName string, feedURL string) (*fe
 	return feed, nil
 }
 
+// getMockMastodonFeed reads the posts dumped by the Mastodon provider and converts them to feeds.Feed format
+func (m *MockProvider) getMockMastodonFeed(processedName string) (*feeds.Feed, error) {
+	path := filepath.Join(m.dataDir, "mastodon", processedName, fmt.Sprintf("%s.json", processedName))
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Mastodon mock feed: %w", err)
+	}
+
+	var statuses []mastodon.Status
+	if err := json.Unmarshal(data, &statuses); err != nil {
+		return nil, fmt.Errorf("failed to parse Mastodon mock feed: %w", err)
+	}
+
+	return &feeds.Feed{
+		Entries: mastodon.StatusesToEntries(statuses),
+		RawData: fmt.Sprintf("Mock Mastodon timelines for %s", processedName),
+	}, nil
+}
+
+// getMockDumpedComments reads the comments the Mastodon or plugin provider dumped for an entry
+func (m *MockProvider) getMockDumpedComments(providerType, entryID string) (*feeds.CommentFeed, error) {
+	path := filepath.Join(m.dataDir, providerType, m.PersonaName, fmt.Sprintf("%s.json", entryID))
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &feeds.CommentFeed{
+			Entries: []feeds.EntryComments{},
+			RawData: fmt.Sprintf("No mock comments for post %s", entryID),
+		}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mock comments: %w", err)
+	}
+
+	var comments feeds.CommentFeed
+	if err := json.Unmarshal(data, &comments); err != nil {
+		return nil, fmt.Errorf("failed to parse mock comments: %w", err)
+	}
+	return &comments, nil
+}
+
+// getMockImapFeed reads the messages dumped by the IMAP provider and converts them to feeds.Feed format
+func (m *MockProvider) getMockImapFeed(processedName string) (*feeds.Feed, error) {
+	paths, err := filepath.Glob(filepath.Join(m.dataDir, "imap", processedName, "*.eml"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list IMAP mock messages: %w", err)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("failed to read IMAP mock feed: no messages in %s", filepath.Join(m.dataDir, "imap", processedName))
+	}
+
+	entries := make([]feeds.Entry, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read IMAP mock message: %w", err)
+		}
+		entry, err := imap.ParseMessage(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse IMAP mock message %s: %w", filepath.Base(path), err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return &feeds.Feed{
+		Entries: entries,
+		RawData: fmt.Sprintf("Mock newsletters for %s", processedName),
+	}, nil
+}
+
+// getMockYoutubeFeed reads the videos dumped by the YouTube provider and converts them to feeds.Feed format
+func (m *MockProvider) getMockYoutubeFeed(processedName string) (*feeds.Feed, error) {
+	path := filepath.Join(m.dataDir, "youtube", processedName, fmt.Sprintf("%s.json", processedName))
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read YouTube mock feed: %w", err)
+	}
+
+	var videos []youtube.Video
+	if err := json.Unmarshal(data, &videos); err != nil {
+		return nil, fmt.Errorf("failed to parse YouTube mock feed: %w", err)
+	}
+
+	return &feeds.Feed{
+		Entries: youtube.VideosToEntries(videos),
+		RawData: fmt.Sprintf("Mock YouTube videos for %s", processedName),
+	}, nil
+}
+
+// getMockItemsFeed reads the items dumped by the ingest or plugin provider and converts them to feeds.Feed format
+func (m *MockProvider) getMockItemsFeed(providerType, processedName string) (*feeds.Feed, error) {
+	path := filepath.Join(m.dataDir, providerType, processedName, fmt.Sprintf("%s.json", processedName))
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s mock feed: %w", providerType, err)
+	}
+
+	var items []ingest.Item
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("failed to parse %s mock feed: %w", providerType, err)
+	}
+	m.items = make(map[string]ingest.Item, len(items))
+	for _, item := range items {
+		m.items[item.ID] = item
+	}
+
+	return &feeds.Feed{
+		Entries: ingest.ItemsToEntries(items),
+		RawData: fmt.Sprintf("Mock %s items for %s", providerType, processedName),
+	}, nil
+}
+
 // GetMockComments reads Reddit JSON comment mock data and converts to feeds.CommentFeed format
 func (m *MockProvider) GetMockComments(ctx context.Context, personaName string, entryID string) (*feeds.CommentFeed, error) {
-	processedName := processPersonaName(personaName)
+	processedName := dumpname.Persona(personaName)
 
 	// Read JSON mock data
-	path := filepath.Join("feed_mocks", "reddit", processedName, fmt.Sprintf("%s.json", entryID))
+	path := filepath.Join(m.dataDir, "reddit", processedName, fmt.Sprintf("%s.json", entryID))
 	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		// Comments are only dumped for posts that have them, and never for RSS feeds
+		return &feeds.CommentFeed{
+			Entries: []feeds.EntryComments{},
+			RawData: fmt.Sprintf("No mock comments for post %s", entryID),
+		}, nil
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to read mock comments: %w", err)
 	}
@@ -156,6 +320,7 @@ func mockPostToEntry(post RedditPostData) feeds.Entry {
 		ID:        post.ID,
 		Published: post.Created,
 		Content:   post.Body,
+		Author:    post.Author,
 	}
 
 	// Set the link - use full Reddit permalink
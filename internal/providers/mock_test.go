@@ -0,0 +1,73 @@
+package providers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+)
+
+func TestMockProvider_Mastodon(t *testing.T) {
+	dir := t.TempDir()
+	personaDir := filepath.Join(dir, "mastodon", "fediverse")
+	if err := os.MkdirAll(personaDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"fediverse.json": `[{"id": "1", "content": "<p>Qwen 3 is out</p>", "account": {"acct": "alice"}}]`,
+		"1.json":         `{"entries": [{"content": "Qwen 3 is out"}, {"content": "Boosted by @bob"}]}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(personaDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mock := NewMockProvider("Fediverse")
+	mock.SetDataDir(dir)
+	feed, err := mock.FetchFeed(context.Background(), persona.Persona{Name: "Fediverse", Provider: "mastodon"})
+	if err != nil {
+		t.Fatalf("FetchFeed: %v", err)
+	}
+	if len(feed.Entries) != 1 || feed.Entries[0].Title != "Qwen 3 is out" || feed.Entries[0].Author != "alice" {
+		t.Fatalf("unexpected entries: %+v", feed.Entries)
+	}
+
+	comments, err := mock.FetchComments(context.Background(), feed.Entries[0])
+	if err != nil {
+		t.Fatalf("FetchComments: %v", err)
+	}
+	if len(comments.Entries) != 2 || comments.Entries[1] != (feeds.EntryComments{Content: "Boosted by @bob"}) {
+		t.Errorf("unexpected comments: %+v", comments.Entries)
+	}
+}
+
+func TestMockProvider_Imap(t *testing.T) {
+	dir := t.TempDir()
+	personaDir := filepath.Join(dir, "imap", "newsletters")
+	if err := os.MkdirAll(personaDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	raw := "From: Import AI <jack@importai.substack.com>\r\nSubject: Import AI 400\r\nContent-Type: text/html\r\n\r\n<p>Robots</p>\r\n"
+	if err := os.WriteFile(filepath.Join(personaDir, "7.eml"), []byte(raw), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mock := NewMockProvider("Newsletters")
+	mock.SetDataDir(dir)
+	feed, err := mock.FetchFeed(context.Background(), persona.Persona{Name: "Newsletters", Provider: "imap"})
+	if err != nil {
+		t.Fatalf("FetchFeed: %v", err)
+	}
+	if len(feed.Entries) != 1 || feed.Entries[0].Title != "Import AI 400" || feed.Entries[0].Author != "Import AI" {
+		t.Fatalf("unexpected entries: %+v", feed.Entries)
+	}
+
+	comments, err := mock.FetchComments(context.Background(), feed.Entries[0])
+	if err != nil || len(comments.Entries) != 0 {
+		t.Errorf("expected no comments, got %+v, %v", comments, err)
+	}
+}
@@ -0,0 +1,286 @@
+// Package plugin implements a feed provider backed by external executables, so niche sources can
+// be added without changing the processor. A plugin is any executable in the plugins directory
+// that reads a JSON Request on standard input and writes a JSON Response to standard output.
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/internal/providers/dumpname"
+	"github.com/bakkerme/ai-news-processor/internal/providers/ingest"
+)
+
+const (
+	// callTimeout bounds a single plugin call
+	callTimeout = 2 * time.Minute
+	// maxOutputBytes is the largest response read from a plugin
+	maxOutputBytes = 32 << 20
+	// maxStderrBytes is how much of the standard error of a failed plugin is reported
+	maxStderrBytes = 2048
+)
+
+// Discover returns the names of the plugins in dir: the executable files, without their
+// extension. A missing directory has no plugins.
+func Discover(dir string) ([]string, error) {
+	paths, err := discover(dir)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(paths))
+	for name := range paths {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// discover maps the names of the plugins in dir to their paths
+func discover(dir string) (map[string]string, error) {
+	files, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plugins directory: %w", err)
+	}
+
+	// Plugins run in the plugins directory, so their paths must not be relative to the working directory
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve plugins directory: %w", err)
+	}
+	paths := make(map[string]string)
+	for _, file := range files {
+		if strings.HasPrefix(file.Name(), ".") || !file.Type().IsRegular() {
+			continue
+		}
+		info, err := file.Info()
+		if err != nil || info.Mode()&0111 == 0 {
+			continue
+		}
+		paths[strings.TrimSuffix(file.Name(), filepath.Ext(file.Name()))] = filepath.Join(dir, file.Name())
+	}
+	return paths, nil
+}
+
+// find returns the path of the named plugin in dir
+func find(dir, name string) (string, error) {
+	paths, err := discover(dir)
+	if err != nil {
+		return "", err
+	}
+	if path, ok := paths[name]; ok {
+		return path, nil
+	}
+	names, _ := Discover(dir)
+	available := "none"
+	if len(names) > 0 {
+		available = strings.Join(names, ", ")
+	}
+	return "", fmt.Errorf("plugin %s not found in %s (available: %s)", name, dir, available)
+}
+
+// Provider implements the feeds.FeedProvider interface by calling a plugin
+type Provider struct {
+	dir        string
+	enableDump bool
+	dumpDir    string
+
+	// Set by FetchFeed, since comments are fetched from the same plugin
+	path             string
+	persona          persona.Persona
+	supportsComments bool
+	items            map[string]ingest.Item
+}
+
+// NewProvider creates a provider for the plugins in dir
+func NewProvider(dir string, enableDump bool) *Provider {
+	return &Provider{
+		dir:        dir,
+		enableDump: enableDump,
+		dumpDir:    dumpname.DefaultDir,
+		items:      make(map[string]ingest.Item),
+	}
+}
+
+// SetDumpDir sets the directory plugin responses are dumped to
+func (p *Provider) SetDumpDir(dir string) {
+	p.dumpDir = dir
+}
+
+// FetchFeed implements feeds.FeedProvider.FetchFeed, checking the plugin speaks the protocol and
+// asking it for the persona's items. Invalid items are skipped with a warning.
+func (p *Provider) FetchFeed(ctx context.Context, pers persona.Persona) (*feeds.Feed, error) {
+	if pers.Plugin == "" {
+		return nil, fmt.Errorf("plugin not configured for persona %s - plugin field is required for plugin provider", pers.Name)
+	}
+	path, err := find(p.dir, pers.Plugin)
+	if err != nil {
+		return nil, err
+	}
+	p.path = path
+	p.persona = pers
+
+	description, err := p.call(ctx, Request{Method: MethodDescribe})
+	if err != nil {
+		return nil, err
+	}
+	if description.Version != ProtocolVersion {
+		return nil, fmt.Errorf("plugin %s speaks protocol version %d, expected %d", pers.Plugin, description.Version, ProtocolVersion)
+	}
+	p.supportsComments = description.SupportsComments
+
+	log.Printf("Fetching feed from plugin %s for persona %s", pers.Plugin, pers.Name)
+	response, err := p.call(ctx, Request{Method: MethodFetchFeed, Persona: p.requestPersona()})
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]ingest.Item, 0, len(response.Items))
+	for i, item := range response.Items {
+		if err := item.Validate(); err != nil {
+			log.Printf("Warning: Skipping item %d from plugin %s: %v", i, pers.Plugin, err)
+			continue
+		}
+		p.items[item.ID] = item
+		items = append(items, item)
+	}
+
+	if p.enableDump {
+		if err := p.dump(dumpname.Persona(pers.Name)+".json", items); err != nil {
+			log.Printf("Warning: Failed to dump plugin items: %v", err)
+		}
+	}
+
+	return &feeds.Feed{
+		Entries: ingest.ItemsToEntries(items),
+		RawData: fmt.Sprintf("Items from plugin %s", pers.Plugin),
+	}, nil
+}
+
+// FetchComments implements feeds.FeedProvider.FetchComments. Comments returned with the item are
+// used as they are; otherwise the plugin is asked for them if it supports comments.
+func (p *Provider) FetchComments(ctx context.Context, entry feeds.Entry) (*feeds.CommentFeed, error) {
+	item, ok := p.items[entry.ID]
+	if !ok {
+		return nil, fmt.Errorf("item %s was not returned by the plugin", entry.ID)
+	}
+
+	if len(item.Comments) == 0 && p.supportsComments {
+		response, err := p.call(ctx, Request{
+			Method:  MethodFetchComments,
+			Persona: p.requestPersona(),
+			Entry:   &Entry{ID: item.ID, Title: item.Title, URL: item.URL},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch comments: %w", err)
+		}
+		item.Comments = response.Comments
+	}
+
+	comments := &feeds.CommentFeed{
+		Entries: ingest.Comments(item),
+		RawData: fmt.Sprintf("Comments of plugin item %s", item.ID),
+	}
+	if p.enableDump && p.supportsComments {
+		if err := p.dump(item.ID+".json", comments); err != nil {
+			log.Printf("Warning: Failed to dump plugin comments: %v", err)
+		}
+	}
+	return comments, nil
+}
+
+// requestPersona describes the persona to the plugin
+func (p *Provider) requestPersona() *Persona {
+	return &Persona{Name: p.persona.Name, Topic: p.persona.Topic, FocusAreas: p.persona.FocusAreas}
+}
+
+// call runs the plugin with a request and returns its response
+func (p *Provider) call(ctx context.Context, request Request) (*Response, error) {
+	request.Version = ProtocolVersion
+	request.Options = p.persona.PluginOptions
+	input, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal plugin request: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, callTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, p.path)
+	cmd.Dir = p.dir
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout bytes.Buffer
+	stderr := &limitedBuffer{limit: maxStderrBytes}
+	cmd.Stdout = &limitedWriter{w: &stdout, remaining: maxOutputBytes}
+	cmd.Stderr = stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("plugin %s failed on %s: %w: %s", p.persona.Plugin, request.Method, err, strings.TrimSpace(stderr.String()))
+	}
+
+	var response Response
+	if err := json.Unmarshal(stdout.Bytes(), &response); err != nil {
+		return nil, fmt.Errorf("plugin %s returned an invalid %s response: %w", p.persona.Plugin, request.Method, err)
+	}
+	if response.Error != "" {
+		return nil, fmt.Errorf("plugin %s failed on %s: %s", p.persona.Plugin, request.Method, response.Error)
+	}
+	return &response, nil
+}
+
+// dump writes a plugin response as JSON to the persona's directory, where the mock provider reads it
+func (p *Provider) dump(name string, v any) error {
+	dir := filepath.Join(p.dumpDir, "plugin", dumpname.Persona(p.persona.Name))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create dump directory: %w", err)
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal dump: %w", err)
+	}
+	return os.WriteFile(filepath.Join(dir, name), data, 0644)
+}
+
+// limitedWriter fails writes beyond a size limit, so a runaway plugin cannot exhaust memory
+type limitedWriter struct {
+	w         io.Writer
+	remaining int
+}
+
+func (l *limitedWriter) Write(b []byte) (int, error) {
+	if len(b) > l.remaining {
+		return 0, fmt.Errorf("plugin output exceeds %d bytes", maxOutputBytes)
+	}
+	l.remaining -= len(b)
+	return l.w.Write(b)
+}
+
+// limitedBuffer keeps the first bytes written to it and discards the rest
+type limitedBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+func (l *limitedBuffer) Write(b []byte) (int, error) {
+	if room := l.limit - l.Len(); room > 0 {
+		if len(b) > room {
+			l.Buffer.Write(b[:room])
+		} else {
+			l.Buffer.Write(b)
+		}
+	}
+	return len(b), nil
+}
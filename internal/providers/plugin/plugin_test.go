@@ -0,0 +1,129 @@
+package plugin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+)
+
+// hackerNews is a plugin script answering each method with a fixed response
+const hackerNews = `#!/bin/sh
+input=$(cat)
+case "$input" in
+*'"method":"describe"'*)
+  echo '{"version":1,"name":"hackernews","supportsComments":true}' ;;
+*'"method":"fetch_feed"'*)
+  case "$input" in
+  *'"options":{"min_points":"100"}'*) ;;
+  *) echo '{"version":1,"error":"min_points option missing"}'; exit 0 ;;
+  esac
+  echo '{"version":1,"items":[{"id":"1","title":"Show HN: A tiny LLM","url":"https://example.com/llm"},{"id":"2","title":"Ask HN: Favourite models?","content":"<p>Which ones?</p>","comments":["Qwen"]},{"title":"No link"}]}' ;;
+*'"method":"fetch_comments"'*'"id":"1"'*)
+  echo '{"version":1,"comments":["Impressive","Benchmarks?"]}' ;;
+esac
+`
+
+func writePlugin(t *testing.T, dir, name, script string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProvider(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin scripts need a POSIX shell")
+	}
+	dir := t.TempDir()
+	writePlugin(t, dir, "hackernews.sh", hackerNews)
+	dumpDir := t.TempDir()
+	provider := NewProvider(dir, true)
+	provider.SetDumpDir(dumpDir)
+	p := persona.Persona{Name: "HN", Provider: "plugin", Plugin: "hackernews", PluginOptions: map[string]string{"min_points": "100"}}
+
+	feed, err := provider.FetchFeed(context.Background(), p)
+	if err != nil {
+		t.Fatalf("FetchFeed: %v", err)
+	}
+	if len(feed.Entries) != 2 {
+		t.Fatalf("expected the invalid item to be skipped, got %d entries", len(feed.Entries))
+	}
+
+	comments, err := provider.FetchComments(context.Background(), feed.Entries[0])
+	if err != nil {
+		t.Fatalf("FetchComments: %v", err)
+	}
+	want := []feeds.EntryComments{{Content: "Show HN: A tiny LLM"}, {Content: "Impressive"}, {Content: "Benchmarks?"}}
+	if !reflect.DeepEqual(comments.Entries, want) {
+		t.Errorf("expected comments from the plugin, got %+v", comments.Entries)
+	}
+
+	// Comments returned with an item are used without calling the plugin
+	comments, err = provider.FetchComments(context.Background(), feed.Entries[1])
+	if err != nil || len(comments.Entries) != 2 || comments.Entries[1].Content != "Qwen" {
+		t.Errorf("expected the inline comments, got %+v, %v", comments, err)
+	}
+
+	for _, name := range []string{"hn.json", "1.json"} {
+		if _, err := os.Stat(filepath.Join(dumpDir, "plugin", "hn", name)); err != nil {
+			t.Errorf("expected %s to be dumped: %v", name, err)
+		}
+	}
+}
+
+func TestProvider_Errors(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin scripts need a POSIX shell")
+	}
+	dir := t.TempDir()
+	writePlugin(t, dir, "hackernews.sh", hackerNews)
+	writePlugin(t, dir, "crashes", "#!/bin/sh\necho 'token expired' >&2\nexit 3\n")
+	writePlugin(t, dir, "future", "#!/bin/sh\necho '{\"version\":2}'\n")
+
+	tests := []struct {
+		name   string
+		plugin string
+		want   string
+	}{
+		{name: "error response", plugin: "hackernews", want: "min_points option missing"},
+		{name: "non-zero exit", plugin: "crashes", want: "token expired"},
+		{name: "other protocol version", plugin: "future", want: "protocol version 2"},
+		{name: "missing plugin", plugin: "reddit", want: "available: crashes, future, hackernews"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewProvider(dir, false).FetchFeed(context.Background(), persona.Persona{Name: "Test", Plugin: tt.plugin})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	dir := t.TempDir()
+	writePlugin(t, dir, "hackernews.py", "")
+	writePlugin(t, dir, ".hidden", "")
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	names, err := Discover(dir)
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	if runtime.GOOS != "windows" && !reflect.DeepEqual(names, []string{"hackernews"}) {
+		t.Errorf("expected only the executable, got %v", names)
+	}
+
+	if names, err := Discover(filepath.Join(dir, "missing")); err != nil || len(names) != 0 {
+		t.Errorf("expected no plugins in a missing directory, got %v, %v", names, err)
+	}
+}
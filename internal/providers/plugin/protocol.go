@@ -0,0 +1,56 @@
+package plugin
+
+import "github.com/bakkerme/ai-news-processor/internal/providers/ingest"
+
+// ProtocolVersion is the version of the plugin protocol. It changes only when a change would break
+// existing plugins; new optional fields keep the version.
+const ProtocolVersion = 1
+
+// Methods a plugin is called with
+const (
+	MethodDescribe      = "describe"       // Report the protocol version and capabilities of the plugin
+	MethodFetchFeed     = "fetch_feed"     // Return the latest items for a persona
+	MethodFetchComments = "fetch_comments" // Return the comments of an item, if the plugin supports comments
+)
+
+// Request is written as JSON to the standard input of a plugin. Each call starts the plugin
+// executable once with a single request.
+type Request struct {
+	Version int               `json:"version"`
+	Method  string            `json:"method"`
+	Persona *Persona          `json:"persona,omitempty"` // fetch_feed and fetch_comments
+	Entry   *Entry            `json:"entry,omitempty"`   // fetch_comments
+	Options map[string]string `json:"options,omitempty"` // The persona's plugin_options
+}
+
+// Persona describes the persona a feed is fetched for
+type Persona struct {
+	Name       string   `json:"name"`
+	Topic      string   `json:"topic,omitempty"`
+	FocusAreas []string `json:"focusAreas,omitempty"`
+}
+
+// Entry identifies the item whose comments are fetched
+type Entry struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	URL   string `json:"url,omitempty"`
+}
+
+// Response is read as JSON from the standard output of a plugin. A plugin that fails sets Error,
+// or exits with a non-zero status and explains why on standard error.
+type Response struct {
+	Version int    `json:"version"`
+	Error   string `json:"error,omitempty"`
+
+	// describe
+	Name             string `json:"name,omitempty"`
+	Description      string `json:"description,omitempty"`
+	SupportsComments bool   `json:"supportsComments,omitempty"` // fetch_comments is only called if set
+
+	// fetch_feed, in the shape of the ingest endpoint
+	Items []ingest.Item `json:"items,omitempty"`
+
+	// fetch_comments
+	Comments []string `json:"comments,omitempty"`
+}
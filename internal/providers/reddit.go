@@ -4,23 +4,50 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/http"
 	"net/url"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/bakkerme/ai-news-processor/internal/feeds"
 	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/internal/providers/dumpname"
 	"github.com/vartanbeno/go-reddit/v2/reddit"
 )
 
-// RedditProvider implements the feeds.FeedProvider interface using Reddit API
+// RedditProvider implements the feeds.FeedProvider interface using Reddit API. Without
+// credentials, or once the API rejects them, it reads the public .json endpoints instead.
 type RedditProvider struct {
-	client     *reddit.Client
+	client     *reddit.Client // nil without credentials
+	public     *publicReddit
 	enableDump bool
+	dumpDir    string
+	metrics    *feeds.FetchMetrics
 }
 
-// NewRedditProvider creates a new Reddit API provider
+// NewRedditProvider creates a new Reddit API provider. Without a client ID, posts and comments
+// are read from the public .json endpoints, which are read-only and rate limited.
 func NewRedditProvider(clientID, clientSecret, username, password string, enableDump bool) (*RedditProvider, error) {
+	// Route API requests (including OAuth token requests) through the metrics transport
+	metrics := feeds.NewFetchMetrics("reddit")
+	httpClient := &http.Client{Transport: metrics.Transport(nil)}
+
+	provider := &RedditProvider{
+		public: &publicReddit{
+			baseURL:    publicRedditURL,
+			httpClient: &http.Client{Timeout: 30 * time.Second, Transport: metrics.Transport(nil)},
+			limiter:    publicRedditLimiter,
+		},
+		enableDump: enableDump,
+		dumpDir:    dumpname.DefaultDir,
+		metrics:    metrics,
+	}
+	if clientID == "" {
+		log.Printf("No Reddit API credentials, reading the public JSON endpoints (read-only and rate limited)")
+		return provider, nil
+	}
+
 	credentials := reddit.Credentials{
 		ID:       clientID,
 		Secret:   clientSecret,
@@ -28,28 +55,40 @@ func NewRedditProvider(clientID, clientSecret, username, password string, enable
 		Password: password,
 	}
 
-	client, err := reddit.NewClient(credentials)
+	client, err := reddit.NewClient(credentials, reddit.WithHTTPClient(httpClient))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Reddit client: %w", err)
 	}
+	provider.client = client
+	return provider, nil
+}
 
-	return &RedditProvider{
-		client:     client,
-		enableDump: enableDump,
-	}, nil
+// SetDumpEnabled toggles writing Reddit API responses to disk for debugging and mocking
+func (r *RedditProvider) SetDumpEnabled(enabled bool) {
+	r.enableDump = enabled
+}
+
+// SetDumpDir sets the directory Reddit API responses are dumped to
+func (r *RedditProvider) SetDumpDir(dir string) {
+	r.dumpDir = dir
+}
+
+// Metrics implements feeds.MetricsReporter
+func (r *RedditProvider) Metrics() feeds.FetchMetricsSnapshot {
+	return r.metrics.Snapshot()
 }
 
 // FetchFeed implements feeds.FeedProvider.FetchFeed
 func (r *RedditProvider) FetchFeed(ctx context.Context, p persona.Persona) (*feeds.Feed, error) {
-	log.Printf("Fetching posts from r/%s via Reddit API", p.Subreddit)
-
 	// Fetch posts from Reddit API
-	posts, _, err := r.client.Subreddit.HotPosts(ctx, p.Subreddit, &reddit.ListOptions{
-		Limit: 25, // Match RSS default limit
-	})
+	listed, err := r.fetchHotPosts(ctx, p.Subreddit, 25) // Match RSS default limit
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch posts from r/%s: %w", p.Subreddit, err)
 	}
+	posts := make([]*reddit.Post, len(listed))
+	for i := range listed {
+		posts[i] = &listed[i].Post
+	}
 
 	// Dump Reddit API data if enabled
 	if r.enableDump {
@@ -58,26 +97,109 @@ func (r *RedditProvider) FetchFeed(ctx context.Context, p persona.Persona) (*fee
 		}
 	}
 
-	// Convert Reddit posts to feed entries
-	entries := make([]feeds.Entry, len(posts))
-	for i, post := range posts {
-		entries[i] = r.mapPostToEntry(post)
+	// Convert Reddit posts to feed entries, keeping one entry per original post
+	entries := make([]feeds.Entry, 0, len(listed))
+	seen := make(map[string]bool, len(listed))
+	for _, post := range listed {
+		entry := r.mapListedPost(post)
+		if seen[entry.PostID()] {
+			log.Printf("Skipping post %s of r/%s, which shares post %s with an earlier entry", entry.ID, p.Subreddit, entry.PostID())
+			continue
+		}
+		seen[entry.PostID()] = true
+		entries = append(entries, entry)
 	}
 
 	feed := &feeds.Feed{
 		Entries: entries,
-		RawData: fmt.Sprintf("Reddit API feed for r/%s", p.Subreddit),
+		RawData: fmt.Sprintf("Reddit %s feed for r/%s", r.source(), p.Subreddit),
 	}
 
 	return feed, nil
 }
 
+// flairedPost is a listed post with its link flair and, for a crosspost, the post it shares,
+// which reddit.Post does not include
+type flairedPost struct {
+	reddit.Post
+	LinkFlairText       string        `json:"link_flair_text"`
+	CrosspostParent     string        `json:"crosspost_parent"`      // Full ID of the original post of a crosspost
+	CrosspostParentList []reddit.Post `json:"crosspost_parent_list"` // The original post of a crosspost
+}
+
+// postListing is a listing of posts, as returned by the API and the public endpoints
+type postListing struct {
+	Data struct {
+		Children []struct {
+			Data flairedPost `json:"data"`
+		} `json:"children"`
+	} `json:"data"`
+}
+
+// usePublic reports whether the provider reads the public endpoints instead of the API
+func (r *RedditProvider) usePublic() bool {
+	return r.public != nil && (r.client == nil || redditAPIRejected.Load())
+}
+
+// source names where the provider reads Reddit from, for logs
+func (r *RedditProvider) source() string {
+	if r.usePublic() {
+		return "public JSON"
+	}
+	return "API"
+}
+
+// fallBack switches every Reddit provider to the public endpoints when err is the API rejecting
+// the credentials, and reports whether it did
+func (r *RedditProvider) fallBack(err error) bool {
+	if r.public == nil || !isRedditAuthError(err) {
+		return false
+	}
+	if !redditAPIRejected.Swap(true) {
+		log.Printf("Reddit API rejected the credentials, falling back to the public JSON endpoints (read-only and rate limited): %v", err)
+	}
+	return true
+}
+
+// fetchHotPosts fetches the hot posts of a subreddit from the API or the public endpoints
+func (r *RedditProvider) fetchHotPosts(ctx context.Context, subreddit string, limit int) ([]flairedPost, error) {
+	log.Printf("Fetching posts from r/%s via Reddit %s", subreddit, r.source())
+	if !r.usePublic() {
+		posts, err := r.hotPosts(ctx, subreddit, limit)
+		if err == nil || !r.fallBack(err) {
+			return posts, err
+		}
+	}
+	return r.public.hotPosts(ctx, subreddit, limit)
+}
+
+// hotPosts fetches the hot posts of a subreddit like Subreddit.HotPosts, with the link flair and
+// crosspost parent of each post
+func (r *RedditProvider) hotPosts(ctx context.Context, subreddit string, limit int) ([]flairedPost, error) {
+	req, err := r.client.NewRequest(http.MethodGet, fmt.Sprintf("r/%s/hot?limit=%d", subreddit, limit), nil)
+	if err != nil {
+		return nil, err
+	}
+	var listing postListing
+	if _, err := r.client.Do(ctx, req, &listing); err != nil {
+		return nil, err
+	}
+	return listing.posts(), nil
+}
+
+// posts returns the posts of the listing
+func (listing postListing) posts() []flairedPost {
+	posts := make([]flairedPost, 0, len(listing.Data.Children))
+	for _, child := range listing.Data.Children {
+		posts = append(posts, child.Data)
+	}
+	return posts
+}
+
 // FetchComments implements feeds.FeedProvider.FetchComments
 func (r *RedditProvider) FetchComments(ctx context.Context, entry feeds.Entry) (*feeds.CommentFeed, error) {
-	log.Printf("Fetching comments for post %s via Reddit API", entry.ID)
-
 	// Fetch comments from Reddit API
-	postAndComments, _, err := r.client.Post.Get(ctx, entry.ID)
+	postAndComments, err := r.fetchPostAndComments(ctx, entry.ID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch comments for post %s: %w", entry.ID, err)
 	}
@@ -96,27 +218,100 @@ func (r *RedditProvider) FetchComments(ctx context.Context, entry feeds.Entry) (
 	}
 
 	// Convert Reddit comments to feed comment entries (top-level only to match RSS depth=1)
-	var commentEntries []feeds.EntryComments
-	if postAndComments != nil {
-		// Access comments from the PostAndComments struct
-		for _, comment := range postAndComments.Comments {
-			// Only include top-level comments to match RSS behavior
-			if comment.ParentID == "t3_"+entry.ID {
-				commentEntries = append(commentEntries, feeds.EntryComments{
-					Content: comment.Body,
-				})
-			}
+	commentEntries := topLevelComments(postAndComments, entry.ID)
+
+	// The discussion of a crosspost or repost mostly happens on the original post
+	if entry.CanonicalID != "" && entry.CanonicalID != entry.ID {
+		original, err := r.fetchPostAndComments(ctx, entry.CanonicalID)
+		if err != nil {
+			log.Printf("Warning: Could not fetch the comments of post %s, the original of %s: %v", entry.CanonicalID, entry.ID, err)
+		} else {
+			commentEntries = append(commentEntries, topLevelComments(original, entry.CanonicalID)...)
 		}
 	}
 
 	commentFeed := &feeds.CommentFeed{
 		Entries: commentEntries,
-		RawData: fmt.Sprintf("Reddit API comments for post %s", entry.ID),
+		RawData: fmt.Sprintf("Reddit %s comments for post %s", r.source(), entry.ID),
 	}
 
 	return commentFeed, nil
 }
 
+// topLevelComments returns the comments of a post that reply to the post itself
+func topLevelComments(postAndComments *reddit.PostAndComments, postID string) []feeds.EntryComments {
+	var comments []feeds.EntryComments
+	if postAndComments == nil {
+		return comments
+	}
+	for _, comment := range postAndComments.Comments {
+		// Only include top-level comments to match RSS behavior
+		if comment.ParentID == "t3_"+postID {
+			comments = append(comments, feeds.EntryComments{Content: comment.Body})
+		}
+	}
+	return comments
+}
+
+// fetchPostAndComments fetches a post and its comments from the API or the public endpoints
+func (r *RedditProvider) fetchPostAndComments(ctx context.Context, id string) (*reddit.PostAndComments, error) {
+	log.Printf("Fetching comments for post %s via Reddit %s", id, r.source())
+	if !r.usePublic() {
+		postAndComments, _, err := r.client.Post.Get(ctx, id)
+		if err == nil || !r.fallBack(err) {
+			return postAndComments, err
+		}
+	}
+	return r.public.postAndComments(ctx, id)
+}
+
+// mapListedPost converts a listed post to a feeds.Entry. A crosspost is resolved to the post it
+// shares: the entry keeps the crosspost's ID, but has the content and link of the original post
+// and the original's ID as CanonicalID. A link post to another Reddit post is a repost, with the
+// linked post as CanonicalID.
+func (r *RedditProvider) mapListedPost(post flairedPost) feeds.Entry {
+	entry := r.mapPostToEntry(&post.Post)
+	switch {
+	case len(post.CrosspostParentList) > 0 && post.CrosspostParentList[0].ID != "":
+		original := post.CrosspostParentList[0]
+		resolved := r.mapPostToEntry(&original)
+		resolved.ID = post.ID
+		resolved.CanonicalID = original.ID
+		entry = resolved
+	case post.CrosspostParent != "":
+		entry.CanonicalID = strings.TrimPrefix(post.CrosspostParent, "t3_")
+	case !post.IsSelfPost:
+		if id := redditPostID(post.URL); id != "" && id != post.ID {
+			entry.CanonicalID = id
+		}
+	}
+	entry.Flair = strings.TrimSpace(post.LinkFlairText)
+	return entry
+}
+
+// redditPostID returns the ID of the Reddit post a URL links to, such as abc123 for
+// https://www.reddit.com/r/LocalLLaMA/comments/abc123/title/ or https://redd.it/abc123, or ""
+// if the URL is not a Reddit post
+func redditPostID(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "redd.it" {
+		return strings.Trim(u.Path, "/")
+	}
+	if host != "reddit.com" && !strings.HasSuffix(host, ".reddit.com") {
+		return ""
+	}
+	if matches := redditCommentsPath.FindStringSubmatch(u.Path); matches != nil {
+		return matches[1]
+	}
+	return ""
+}
+
+var redditCommentsPath = regexp.MustCompile(`^(?:/r/[^/]+)?/comments/([a-z0-9]+)`)
+
 // mapPostToEntry converts a Reddit API post to a feeds.Entry
 func (r *RedditProvider) mapPostToEntry(post *reddit.Post) feeds.Entry {
 	entry := feeds.Entry{
@@ -124,6 +319,7 @@ func (r *RedditProvider) mapPostToEntry(post *reddit.Post) feeds.Entry {
 		ID:        post.ID,
 		Published: post.Created.Time,
 		Content:   post.Body, // Selftext for text posts
+		Author:    post.Author,
 	}
 
 	// Set the link - use full Reddit permalink
@@ -0,0 +1,181 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/vartanbeno/go-reddit/v2/reddit"
+)
+
+const (
+	// publicRedditURL serves the public .json endpoints, which need no credentials
+	publicRedditURL = "https://www.reddit.com"
+
+	// publicRedditInterval spaces requests to the public endpoints, which allow about ten
+	// unauthenticated requests a minute
+	publicRedditInterval = 6 * time.Second
+
+	// maxPublicRedditWait caps how long a rate limited request waits, whatever Reddit asks for
+	maxPublicRedditWait = 2 * time.Minute
+
+	// publicRedditAttempts is how often a request is sent when Reddit answers 429 Too Many Requests
+	publicRedditAttempts = 3
+
+	// publicRedditUserAgent identifies the fallback, as Reddit throttles generic user agents harder
+	publicRedditUserAgent = "ai-news-processor/1.0 (read-only public JSON)"
+)
+
+// redditAPIRejected is set once the Reddit API rejected the credentials, so every Reddit provider
+// of the process reads the public endpoints from then on
+var redditAPIRejected atomic.Bool
+
+// publicRedditLimiter is shared by the providers of all personas, as the limits are per client
+var publicRedditLimiter = &requestLimiter{interval: publicRedditInterval}
+
+// publicReddit reads subreddits and comments from the public .json endpoints of Reddit. It is
+// read-only and much slower than the API, but works without credentials.
+type publicReddit struct {
+	baseURL    string
+	httpClient *http.Client
+	limiter    *requestLimiter
+}
+
+// hotPosts fetches the hot posts of a subreddit, with the link flair and crosspost parent of each post
+func (p *publicReddit) hotPosts(ctx context.Context, subreddit string, limit int) ([]flairedPost, error) {
+	var listing postListing
+	if err := p.get(ctx, fmt.Sprintf("/r/%s/hot.json?limit=%d&raw_json=1", url.PathEscape(subreddit), limit), &listing); err != nil {
+		return nil, err
+	}
+	return listing.posts(), nil
+}
+
+// postAndComments fetches a post and its comments
+func (p *publicReddit) postAndComments(ctx context.Context, id string) (*reddit.PostAndComments, error) {
+	var postAndComments reddit.PostAndComments
+	if err := p.get(ctx, fmt.Sprintf("/comments/%s.json?raw_json=1", url.PathEscape(id)), &postAndComments); err != nil {
+		return nil, err
+	}
+	return &postAndComments, nil
+}
+
+// get requests a public endpoint and decodes the JSON response into v. Requests wait for the
+// limiter, and requests answered with 429 Too Many Requests are sent again after the wait Reddit
+// asks for.
+func (p *publicReddit) get(ctx context.Context, path string, v any) error {
+	for attempt := 1; ; attempt++ {
+		if err := p.limiter.wait(ctx); err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+path, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("User-Agent", publicRedditUserAgent)
+		req.Header.Set("Accept", "application/json")
+
+		resp, err := p.httpClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to fetch %s: %w", path, err)
+		}
+		p.limiter.delay(rateLimitWait(resp))
+
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < publicRedditAttempts {
+			resp.Body.Close()
+			log.Printf("Reddit rate limited %s, retrying (attempt %d of %d)", path, attempt+1, publicRedditAttempts)
+			continue
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+			return fmt.Errorf("HTTP %d from %s: %s", resp.StatusCode, path, strings.TrimSpace(string(body)))
+		}
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			return fmt.Errorf("failed to decode %s: %w", path, err)
+		}
+		return nil
+	}
+}
+
+// rateLimitWait returns how long Reddit asks the next request to wait: the Retry-After of a 429
+// response, or the X-Ratelimit-Reset of a response that used up the remaining requests
+func rateLimitWait(resp *http.Response) time.Duration {
+	seconds := func(header string) time.Duration {
+		value, err := strconv.ParseFloat(strings.TrimSpace(resp.Header.Get(header)), 64)
+		if err != nil || value < 0 {
+			return 0
+		}
+		return min(time.Duration(value*float64(time.Second)), maxPublicRedditWait)
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		if wait := max(seconds("Retry-After"), seconds("X-Ratelimit-Reset")); wait > 0 {
+			return wait
+		}
+		return maxPublicRedditWait / 2
+	}
+	if remaining, err := strconv.ParseFloat(resp.Header.Get("X-Ratelimit-Remaining"), 64); err == nil && remaining < 1 {
+		return seconds("X-Ratelimit-Reset")
+	}
+	return 0
+}
+
+// requestLimiter spaces requests at least interval apart, and further when the server asks
+type requestLimiter struct {
+	mu       sync.Mutex
+	next     time.Time
+	interval time.Duration
+}
+
+// wait blocks until the next request may be sent
+func (l *requestLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	at := l.next
+	if now := time.Now(); at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.interval)
+	l.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(at))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// delay holds back the next request until at least d from now
+func (l *requestLimiter) delay(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if at := time.Now().Add(d); at.After(l.next) {
+		l.next = at
+	}
+}
+
+// isRedditAuthError reports whether err is the Reddit API refusing the credentials, either when
+// fetching a token or as 401 Unauthorized on a request
+func isRedditAuthError(err error) bool {
+	var response *reddit.ErrorResponse
+	if errors.As(err, &response) && response.Response != nil && response.Response.StatusCode == http.StatusUnauthorized {
+		return true
+	}
+	return strings.Contains(err.Error(), "oauth2: cannot fetch token")
+}
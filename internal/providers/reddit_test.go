@@ -0,0 +1,263 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/vartanbeno/go-reddit/v2/reddit"
+)
+
+func TestRedditFetchFeedFlair(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/token" {
+			fmt.Fprint(w, `{"access_token":"token","token_type":"bearer","expires_in":3600}`)
+			return
+		}
+		if r.URL.Path != "/r/localllama/hot" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"kind":"Listing","data":{"children":[
+			{"kind":"t3","data":{"id":"a","title":"Flaired","permalink":"/r/localllama/comments/a/","is_self":true,"selftext":"Body","created_utc":1700000000,"link_flair_text":"New Model"}},
+			{"kind":"t3","data":{"id":"b","title":"Unflaired","permalink":"/r/localllama/comments/b/","is_self":true,"created_utc":1700000000,"link_flair_text":null}}
+		]}}`)
+	}))
+	defer server.Close()
+
+	client, err := reddit.NewClient(reddit.Credentials{ID: "id", Secret: "secret", Username: "user", Password: "pass"},
+		reddit.WithBaseURL(server.URL), reddit.WithTokenURL(server.URL+"/token"))
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+	provider := &RedditProvider{client: client}
+
+	feed, err := provider.FetchFeed(context.Background(), persona.Persona{Name: "test", Subreddit: "localllama"})
+	if err != nil {
+		t.Fatalf("FetchFeed returned error: %v", err)
+	}
+	if len(feed.Entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(feed.Entries))
+	}
+	if feed.Entries[0].Title != "Flaired" || feed.Entries[0].Flair != "New Model" {
+		t.Errorf("Expected the flair of the first post, got %q (%q)", feed.Entries[0].Flair, feed.Entries[0].Title)
+	}
+	if feed.Entries[1].Flair != "" {
+		t.Errorf("Expected no flair, got %q", feed.Entries[1].Flair)
+	}
+}
+
+// publicRedditServer serves the public .json endpoints for r/localllama and post a, and 401 for the API
+func publicRedditServer(t *testing.T, userAgents *[]string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/r/localllama/hot.json":
+			*userAgents = append(*userAgents, r.UserAgent())
+			fmt.Fprint(w, `{"kind":"Listing","data":{"children":[
+				{"kind":"t3","data":{"id":"a","title":"Public","permalink":"/r/localllama/comments/a/","is_self":true,"selftext":"Body","created_utc":1700000000,"link_flair_text":"News"}}
+			]}}`)
+		case "/comments/a.json":
+			fmt.Fprint(w, `[
+				{"kind":"Listing","data":{"children":[{"kind":"t3","data":{"id":"a","title":"Public"}}]}},
+				{"kind":"Listing","data":{"children":[
+					{"kind":"t1","data":{"id":"c1","parent_id":"t3_a","body":"Top-level comment","replies":""}},
+					{"kind":"t1","data":{"id":"c2","parent_id":"t1_c0","body":"Reply","replies":""}}
+				]}}
+			]`)
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"message":"Unauthorized","error":401}`)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRedditPublicFallback(t *testing.T) {
+	var userAgents []string
+	server := publicRedditServer(t, &userAgents)
+
+	provider, err := NewRedditProvider("", "", "", "", false)
+	if err != nil {
+		t.Fatalf("NewRedditProvider returned error: %v", err)
+	}
+	provider.public.baseURL = server.URL
+	provider.public.limiter = &requestLimiter{interval: time.Millisecond}
+
+	feed, err := provider.FetchFeed(context.Background(), persona.Persona{Name: "test", Subreddit: "localllama"})
+	if err != nil {
+		t.Fatalf("FetchFeed returned error: %v", err)
+	}
+	if len(feed.Entries) != 1 || feed.Entries[0].Title != "Public" || feed.Entries[0].Flair != "News" {
+		t.Fatalf("Expected the public post with its flair, got %+v", feed.Entries)
+	}
+	if len(userAgents) != 1 || userAgents[0] != publicRedditUserAgent {
+		t.Errorf("Expected the fallback user agent, got %v", userAgents)
+	}
+
+	comments, err := provider.FetchComments(context.Background(), feed.Entries[0])
+	if err != nil {
+		t.Fatalf("FetchComments returned error: %v", err)
+	}
+	if len(comments.Entries) != 1 || comments.Entries[0].Content != "Top-level comment" {
+		t.Errorf("Expected the top-level comment only, got %+v", comments.Entries)
+	}
+}
+
+func TestRedditFallsBackWhenCredentialsAreRejected(t *testing.T) {
+	t.Cleanup(func() { redditAPIRejected.Store(false) })
+	var userAgents []string
+	server := publicRedditServer(t, &userAgents)
+
+	client, err := reddit.NewClient(reddit.Credentials{ID: "id", Secret: "revoked", Username: "user", Password: "pass"},
+		reddit.WithBaseURL(server.URL), reddit.WithTokenURL(server.URL+"/token"))
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+	provider := &RedditProvider{
+		client: client,
+		public: &publicReddit{baseURL: server.URL, httpClient: http.DefaultClient, limiter: &requestLimiter{interval: time.Millisecond}},
+	}
+
+	feed, err := provider.FetchFeed(context.Background(), persona.Persona{Name: "test", Subreddit: "localllama"})
+	if err != nil {
+		t.Fatalf("Expected the persona to continue read-only, got %v", err)
+	}
+	if len(feed.Entries) != 1 {
+		t.Fatalf("Expected the public post, got %+v", feed.Entries)
+	}
+	if !redditAPIRejected.Load() || !provider.usePublic() {
+		t.Error("Expected later requests to go to the public endpoints")
+	}
+}
+
+func TestPublicRedditRetriesRateLimitedRequests(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("Retry-After", "0.01")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, `{"kind":"Listing","data":{"children":[]}}`)
+	}))
+	defer server.Close()
+
+	public := &publicReddit{baseURL: server.URL, httpClient: http.DefaultClient, limiter: &requestLimiter{interval: time.Millisecond}}
+	start := time.Now()
+	if _, err := public.hotPosts(context.Background(), "localllama", 25); err != nil {
+		t.Fatalf("hotPosts returned error: %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("Expected the rate limited request to be sent again, got %d requests", requests)
+	}
+	if elapsed := time.Since(start); elapsed < 10*time.Millisecond {
+		t.Errorf("Expected the retry to wait for Retry-After, took %v", elapsed)
+	}
+}
+
+func TestRateLimitWait(t *testing.T) {
+	response := func(status int, headers map[string]string) *http.Response {
+		resp := &http.Response{StatusCode: status, Header: http.Header{}}
+		for k, v := range headers {
+			resp.Header.Set(k, v)
+		}
+		return resp
+	}
+	tests := []struct {
+		name string
+		resp *http.Response
+		want time.Duration
+	}{
+		{"requests left", response(http.StatusOK, map[string]string{"X-Ratelimit-Remaining": "42.0", "X-Ratelimit-Reset": "300"}), 0},
+		{"requests used up", response(http.StatusOK, map[string]string{"X-Ratelimit-Remaining": "0.0", "X-Ratelimit-Reset": "30"}), 30 * time.Second},
+		{"retry after", response(http.StatusTooManyRequests, map[string]string{"Retry-After": "5"}), 5 * time.Second},
+		{"capped", response(http.StatusTooManyRequests, map[string]string{"Retry-After": "3600"}), maxPublicRedditWait},
+		{"no headers", response(http.StatusTooManyRequests, nil), maxPublicRedditWait / 2},
+	}
+	for _, tt := range tests {
+		if got := rateLimitWait(tt.resp); got != tt.want {
+			t.Errorf("%s: rateLimitWait() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRedditResolvesCrossposts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/r/localllama/hot.json":
+			fmt.Fprint(w, `{"kind":"Listing","data":{"children":[
+				{"kind":"t3","data":{"id":"x1","title":"Crossposted","permalink":"/r/localllama/comments/x1/","is_self":false,"url":"/r/machinelearning/comments/orig/","created_utc":1700000000,
+					"crosspost_parent":"t3_orig","crosspost_parent_list":[{"id":"orig","title":"Original","permalink":"/r/machinelearning/comments/orig/","is_self":true,"selftext":"Original body","author":"op","created_utc":1690000000}]}},
+				{"kind":"t3","data":{"id":"x2","title":"Crossposted again","permalink":"/r/localllama/comments/x2/","is_self":false,"created_utc":1700000000,"crosspost_parent":"t3_orig"}},
+				{"kind":"t3","data":{"id":"r1","title":"Repost","permalink":"/r/localllama/comments/r1/","is_self":false,"url":"https://redd.it/other","created_utc":1700000000}}
+			]}}`)
+		case "/comments/x1.json", "/comments/orig.json":
+			id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/comments/"), ".json")
+			fmt.Fprintf(w, `[
+				{"kind":"Listing","data":{"children":[{"kind":"t3","data":{"id":%q}}]}},
+				{"kind":"Listing","data":{"children":[{"kind":"t1","data":{"id":"c","parent_id":"t3_%s","body":"Comment on %s","replies":""}}]}}
+			]`, id, id, id)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	provider := &RedditProvider{public: &publicReddit{baseURL: server.URL, httpClient: http.DefaultClient, limiter: &requestLimiter{interval: time.Millisecond}}}
+	feed, err := provider.FetchFeed(context.Background(), persona.Persona{Name: "test", Subreddit: "localllama"})
+	if err != nil {
+		t.Fatalf("FetchFeed returned error: %v", err)
+	}
+	if len(feed.Entries) != 2 {
+		t.Fatalf("Expected the second crosspost of the same post to be skipped, got %+v", feed.Entries)
+	}
+
+	crosspost := feed.Entries[0]
+	if crosspost.ID != "x1" || crosspost.CanonicalID != "orig" || crosspost.PostID() != "orig" {
+		t.Errorf("Expected crosspost x1 of orig, got %q of %q", crosspost.ID, crosspost.CanonicalID)
+	}
+	if crosspost.Title != "Original" || crosspost.Content != "Original body" || crosspost.Author != "op" || crosspost.Link.Href != "https://www.reddit.com/r/machinelearning/comments/orig/" {
+		t.Errorf("Expected the content of the original post, got %+v", crosspost)
+	}
+	if repost := feed.Entries[1]; repost.ID != "r1" || repost.CanonicalID != "other" {
+		t.Errorf("Expected a repost of the linked post, got %q of %q", repost.ID, repost.CanonicalID)
+	}
+
+	comments, err := provider.FetchComments(context.Background(), crosspost)
+	if err != nil {
+		t.Fatalf("FetchComments returned error: %v", err)
+	}
+	var contents []string
+	for _, comment := range comments.Entries {
+		contents = append(contents, comment.Content)
+	}
+	if strings.Join(contents, ",") != "Comment on x1,Comment on orig" {
+		t.Errorf("Expected the comments of the crosspost and the original, got %v", contents)
+	}
+}
+
+func TestRedditPostID(t *testing.T) {
+	tests := map[string]string{
+		"https://www.reddit.com/r/LocalLLaMA/comments/abc123/title/": "abc123",
+		"https://old.reddit.com/comments/abc123":                     "abc123",
+		"https://redd.it/abc123":                                     "abc123",
+		"https://i.redd.it/abc123.png":                               "",
+		"https://www.reddit.com/r/LocalLLaMA/":                       "",
+		"https://example.com/comments/abc123":                        "",
+	}
+	for rawURL, want := range tests {
+		if got := redditPostID(rawURL); got != want {
+			t.Errorf("redditPostID(%q) = %q, want %q", rawURL, got, want)
+		}
+	}
+}
@@ -9,12 +9,14 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
 
 	"github.com/bakkerme/ai-news-processor/internal/feeds"
 	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/internal/providers/dumpname"
 )
 
 // RSSProvider implements the feeds.FeedProvider interface for generic RSS feeds
@@ -23,18 +25,40 @@ import (
 type RSSProvider struct {
 	httpClient *http.Client
 	enableDump bool
+	dumpDir    string
+	knownIDs   map[string]struct{} // IDs of entries already processed, used to stop backfilling
+	metrics    *feeds.FetchMetrics
 }
 
 // NewRSSProvider creates a new generic RSS provider
 func NewRSSProvider(enableDump bool) *RSSProvider {
+	metrics := feeds.NewFetchMetrics("rss")
 	return &RSSProvider{
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: metrics.Transport(nil),
 		},
 		enableDump: enableDump,
+		dumpDir:    dumpname.DefaultDir,
+		metrics:    metrics,
 	}
 }
 
+// SetDumpEnabled toggles writing fetched feeds to disk for debugging and mocking
+func (r *RSSProvider) SetDumpEnabled(enabled bool) {
+	r.enableDump = enabled
+}
+
+// SetDumpDir sets the directory fetched feeds are dumped to
+func (r *RSSProvider) SetDumpDir(dir string) {
+	r.dumpDir = dir
+}
+
+// Metrics implements feeds.MetricsReporter
+func (r *RSSProvider) Metrics() feeds.FetchMetricsSnapshot {
+	return r.metrics.Snapshot()
+}
+
 // FetchFeed implements feeds.FeedProvider.FetchFeed for RSS feeds
 func (r *RSSProvider) FetchFeed(ctx context.Context, p persona.Persona) (*feeds.Feed, error) {
 	// Extract RSS URL from persona
@@ -60,6 +84,11 @@ func (r *RSSProvider) FetchFeed(ctx context.Context, p persona.Persona) (*feeds.
 	// Set raw data for debugging
 	feed.RawData = rssContent
 
+	// Follow pagination to backfill older entries if configured
+	if p.BackfillPages > 0 {
+		feed.Entries = r.backfill(ctx, p, rssURL, rssContent, feed.Entries)
+	}
+
 	// Dump RSS content if enabled
 	if r.enableDump {
 		if err := r.dumpRSSFeed(rssURL, rssContent, p.Name); err != nil {
@@ -70,6 +99,114 @@ func (r *RSSProvider) FetchFeed(ctx context.Context, p persona.Persona) (*feeds.
 	return feed, nil
 }
 
+// SetKnownIDs sets the IDs of entries that have already been processed.
+// Backfilling stops at the first page containing a known entry, so it only reaches far back on a persona's first runs.
+func (r *RSSProvider) SetKnownIDs(ids map[string]struct{}) {
+	r.knownIDs = ids
+}
+
+// backfill follows RFC 5005 paging/archive links, or the persona's pagination query parameter,
+// fetching up to p.BackfillPages additional pages of older entries
+func (r *RSSProvider) backfill(ctx context.Context, p persona.Persona, rssURL string, rssContent string, entries []feeds.Entry) []feeds.Entry {
+	seen := make(map[string]struct{}, len(entries))
+	for _, entry := range entries {
+		seen[entry.ID] = struct{}{}
+	}
+	if r.containsKnown(entries) {
+		return entries
+	}
+
+	pageURL := rssURL
+	pageContent := rssContent
+	for page := 2; page <= p.BackfillPages+1; page++ {
+		nextURL, err := nextPageURL(pageContent, pageURL, page, p.PaginationParam)
+		if err != nil {
+			log.Printf("Warning: Could not determine next page of RSS feed %s: %v", pageURL, err)
+			break
+		}
+		if nextURL == "" {
+			break
+		}
+
+		log.Printf("Backfilling RSS feed page %d from %s for persona %s", page, nextURL, p.Name)
+		content, err := r.fetchRSSContent(ctx, nextURL)
+		if err != nil {
+			log.Printf("Warning: Failed to fetch RSS page %s: %v", nextURL, err)
+			break
+		}
+		pageFeed, err := r.parseRSSFeed(content)
+		if err != nil {
+			log.Printf("Warning: Failed to parse RSS page %s: %v", nextURL, err)
+			break
+		}
+
+		// Feeds that ignore the page parameter return the same entries again
+		added := 0
+		for _, entry := range pageFeed.Entries {
+			if _, ok := seen[entry.ID]; ok {
+				continue
+			}
+			seen[entry.ID] = struct{}{}
+			entries = append(entries, entry)
+			added++
+		}
+		if added == 0 || r.containsKnown(pageFeed.Entries) {
+			break
+		}
+
+		pageURL = nextURL
+		pageContent = content
+	}
+
+	return entries
+}
+
+// containsKnown reports whether any of the entries have already been processed
+func (r *RSSProvider) containsKnown(entries []feeds.Entry) bool {
+	for _, entry := range entries {
+		if _, ok := r.knownIDs[entry.ID]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// nextPageURL finds the URL of the next (older) page of a feed.
+// RFC 5005 "next" (paged feeds) and "prev-archive" (archived feeds) links take precedence.
+// Otherwise, if param is set, the page number is set as that query parameter (e.g. ?page=2).
+// An empty string means there are no more pages.
+func nextPageURL(content string, currentURL string, page int, param string) (string, error) {
+	base, err := url.Parse(currentURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid feed URL: %w", err)
+	}
+
+	var rss RSSFeed
+	if err := xml.Unmarshal([]byte(content), &rss); err == nil {
+		for _, rel := range []string{"next", "prev-archive"} {
+			for _, link := range rss.Channel.AtomLinks {
+				if link.Rel != rel || link.Href == "" {
+					continue
+				}
+				ref, err := url.Parse(link.Href)
+				if err != nil {
+					return "", fmt.Errorf("invalid %s link %q: %w", rel, link.Href, err)
+				}
+				return base.ResolveReference(ref).String(), nil
+			}
+		}
+	}
+
+	if param == "" {
+		return "", nil
+	}
+
+	query := base.Query()
+	query.Set(param, fmt.Sprintf("%d", page))
+	base.RawQuery = query.Encode()
+	return base.String(), nil
+}
+
 // FetchComments implements feeds.FeedProvider.FetchComments for RSS feeds
 // Note: Most generic RSS feeds do not support comments, so this returns an empty comment feed
 func (r *RSSProvider) FetchComments(ctx context.Context, entry feeds.Entry) (*feeds.CommentFeed, error) {
@@ -134,6 +271,10 @@ func (r *RSSProvider) rssItemToEntry(item RSSItem) feeds.Entry {
 		ID:        extractIDFromGUID(item.GUID),
 		Content:   cleanHTMLContent(item.Description),
 		Published: item.PubDate.Time,
+		Author:    item.Creator,
+	}
+	if entry.Author == "" {
+		entry.Author = item.Author
 	}
 
 	// Set the link
@@ -167,6 +308,14 @@ func (r *RSSProvider) rssItemToEntry(item RSSItem) feeds.Entry {
 		}
 	}
 
+	// The first category plays the role of a Reddit flair
+	for _, category := range item.Categories {
+		if category = strings.TrimSpace(category); category != "" {
+			entry.Flair = category
+			break
+		}
+	}
+
 	// Initialize empty maps/slices
 	if entry.ExternalURLs == nil {
 		entry.ExternalURLs = []url.URL{}
@@ -275,16 +424,16 @@ func isImageURL(urlStr string) bool {
 
 // dumpRSSFeed saves RSS content to disk for debugging and mock data generation
 func (r *RSSProvider) dumpRSSFeed(feedURL, content, personaName string) error {
-	// Create directory structure: feed_mocks/rss/{personaName}/
+	// Create directory structure: {dumpDir}/rss/{personaName}/
 	processedName := strings.ToLower(strings.ReplaceAll(personaName, " ", ""))
-	dir := fmt.Sprintf("feed_mocks/rss/%s", processedName)
+	dir := filepath.Join(r.dumpDir, "rss", processedName)
 
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return fmt.Errorf("failed to create dump directory: %w", err)
 	}
 
 	// Save main feed as {personaName}.xml
-	feedPath := fmt.Sprintf("%s/%s.xml", dir, processedName)
+	feedPath := filepath.Join(dir, processedName+".xml")
 	if err := os.WriteFile(feedPath, []byte(content), 0644); err != nil {
 		return fmt.Errorf("failed to write RSS dump: %w", err)
 	}
@@ -300,9 +449,16 @@ type RSSFeed struct {
 }
 
 type RSSChannel struct {
-	Title       string    `xml:"title"`
-	Description string    `xml:"description"`
-	Items       []RSSItem `xml:"item"`
+	Title       string     `xml:"title"`
+	Description string     `xml:"description"`
+	Items       []RSSItem  `xml:"item"`
+	AtomLinks   []AtomLink `xml:"http://www.w3.org/2005/Atom link"`
+}
+
+// AtomLink represents atom:link elements, used for RFC 5005 feed paging and archiving
+type AtomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Href string `xml:"href,attr"`
 }
 
 type RSSItem struct {
@@ -313,6 +469,9 @@ type RSSItem struct {
 	PubDate        RSSTimestamp      `xml:"pubDate"`
 	MediaContent   MediaContent      `xml:"http://search.yahoo.com/mrss/ content"`
 	MediaThumbnail MediaThumbnailXML `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+	Categories     []string          `xml:"category"`
+	Author         string            `xml:"author"`                                   // Usually an email address, per RSS 2.0
+	Creator        string            `xml:"http://purl.org/dc/elements/1.1/ creator"` // Name of the author, preferred over author
 }
 
 // MediaContent represents media:content elements with attributes
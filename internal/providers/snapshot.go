@@ -0,0 +1,68 @@
+package providers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// SnapshotsDir is the directory inside the feed mocks directory holding one snapshot per dumped run
+const SnapshotsDir = "snapshots"
+
+// SnapshotURLsDir is the directory inside a snapshot holding the pages fetched during the run
+const SnapshotURLsDir = "urls"
+
+// snapshotTimeFormat names snapshots so they sort chronologically
+const snapshotTimeFormat = "20060102-150405"
+
+// NewSnapshotDir returns the directory a run started at the given time dumps its feeds to. A snapshot
+// has the same layout as the feed mocks directory, so it can be read by the mock provider.
+func NewSnapshotDir(feedMocksDir string, startedAt time.Time) string {
+	return filepath.Join(feedMocksDir, SnapshotsDir, startedAt.UTC().Format(snapshotTimeFormat))
+}
+
+// ResolveSnapshot finds the snapshot directory to replay. name is a directory, the name of a
+// snapshot in the feed mocks directory, or "latest" for the most recent snapshot.
+func ResolveSnapshot(feedMocksDir, name string) (string, error) {
+	snapshotsDir := filepath.Join(feedMocksDir, SnapshotsDir)
+
+	if name == "latest" {
+		snapshots, err := ListSnapshots(feedMocksDir)
+		if err != nil {
+			return "", err
+		}
+		if len(snapshots) == 0 {
+			return "", fmt.Errorf("no snapshots in %s", snapshotsDir)
+		}
+		return filepath.Join(snapshotsDir, snapshots[len(snapshots)-1]), nil
+	}
+
+	for _, dir := range []string{name, filepath.Join(snapshotsDir, name)} {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir, nil
+		}
+	}
+	return "", fmt.Errorf("snapshot %s not found as a directory or in %s", name, snapshotsDir)
+}
+
+// ListSnapshots returns the names of the snapshots in the feed mocks directory, oldest first
+func ListSnapshots(feedMocksDir string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(feedMocksDir, SnapshotsDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read snapshots: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
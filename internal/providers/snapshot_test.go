@@ -0,0 +1,62 @@
+package providers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
+)
+
+func TestResolveSnapshot(t *testing.T) {
+	base := t.TempDir()
+	older := NewSnapshotDir(base, time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC))
+	newer := NewSnapshotDir(base, time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC))
+	for _, dir := range []string{newer, older} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := filepath.Base(older); got != "20250102-030405" {
+		t.Errorf("unexpected snapshot name %s", got)
+	}
+
+	tests := map[string]string{
+		"latest":          newer,
+		"20250102-030405": older,
+		older:             older,
+	}
+	for name, want := range tests {
+		got, err := ResolveSnapshot(base, name)
+		if err != nil {
+			t.Errorf("ResolveSnapshot(%s): %v", name, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("ResolveSnapshot(%s) = %s, want %s", name, got, want)
+		}
+	}
+
+	if _, err := ResolveSnapshot(base, "20240101-000000"); err == nil {
+		t.Error("expected an error for an unknown snapshot")
+	}
+	if _, err := ResolveSnapshot(t.TempDir(), "latest"); err == nil {
+		t.Error("expected an error when there are no snapshots")
+	}
+}
+
+func TestMockProviderMissingComments(t *testing.T) {
+	mock := NewMockProvider("localllama")
+	mock.SetDataDir(t.TempDir())
+
+	comments, err := mock.FetchComments(context.Background(), feeds.Entry{ID: "abc"})
+	if err != nil {
+		t.Fatalf("missing comment dumps should not fail: %v", err)
+	}
+	if len(comments.Entries) != 0 {
+		t.Errorf("expected no comments, got %d", len(comments.Entries))
+	}
+}
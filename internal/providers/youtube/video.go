@@ -0,0 +1,173 @@
+package youtube
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
+	"github.com/bakkerme/ai-news-processor/internal/templatefuncs"
+)
+
+// transcriptLength is the longest transcript kept in the content of an entry, so an hour-long
+// talk does not fill the context of the LLM
+const transcriptLength = 20000
+
+// Video is a YouTube video, as read from a channel or playlist feed and its transcript
+type Video struct {
+	ID          string    `json:"id"`
+	Title       string    `json:"title"`
+	URL         string    `json:"url"`
+	Channel     string    `json:"channel"`
+	Published   time.Time `json:"published"`
+	Description string    `json:"description"`
+	Thumbnail   string    `json:"thumbnail"`
+	Views       int       `json:"views"`
+	Likes       int       `json:"likes"`
+	Transcript  string    `json:"transcript,omitempty"`
+}
+
+// atomFeed is the Atom feed YouTube serves for channels and playlists
+type atomFeed struct {
+	Title   string      `xml:"title"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	VideoID   string     `xml:"http://www.youtube.com/xml/schemas/2015 videoId"`
+	Title     string     `xml:"title"`
+	Links     []atomLink `xml:"link"`
+	Author    string     `xml:"author>name"`
+	Published time.Time  `xml:"published"`
+	Group     mediaGroup `xml:"http://search.yahoo.com/mrss/ group"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Href string `xml:"href,attr"`
+}
+
+type mediaGroup struct {
+	Description string `xml:"http://search.yahoo.com/mrss/ description"`
+	Thumbnail   struct {
+		URL string `xml:"url,attr"`
+	} `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+	Community struct {
+		StarRating struct {
+			Count int `xml:"count,attr"` // Likes, since YouTube no longer shows dislikes
+		} `xml:"http://search.yahoo.com/mrss/ starRating"`
+		Statistics struct {
+			Views int `xml:"views,attr"`
+		} `xml:"http://search.yahoo.com/mrss/ statistics"`
+	} `xml:"http://search.yahoo.com/mrss/ community"`
+}
+
+// ParseFeed reads the videos in a channel or playlist feed
+func ParseFeed(data []byte) ([]Video, error) {
+	var feed atomFeed
+	if err := xml.Unmarshal(data, &feed); err != nil {
+		return nil, fmt.Errorf("failed to parse YouTube feed: %w", err)
+	}
+
+	videos := make([]Video, 0, len(feed.Entries))
+	for _, entry := range feed.Entries {
+		video := Video{
+			ID:          entry.VideoID,
+			Title:       entry.Title,
+			Channel:     entry.Author,
+			Published:   entry.Published,
+			Description: entry.Group.Description,
+			Thumbnail:   entry.Group.Thumbnail.URL,
+			Views:       entry.Group.Community.Statistics.Views,
+			Likes:       entry.Group.Community.StarRating.Count,
+		}
+		for _, link := range entry.Links {
+			if link.Rel == "alternate" {
+				video.URL = link.Href
+			}
+		}
+		if video.URL == "" {
+			video.URL = "https://www.youtube.com/watch?v=" + video.ID
+		}
+		videos = append(videos, video)
+	}
+	return videos, nil
+}
+
+// timedText is a transcript served by the timedtext endpoint
+type timedText struct {
+	Texts []string `xml:"text"`
+}
+
+// ParseTranscript joins the captions of a timedtext transcript into text. Captions are escaped
+// twice by YouTube, so they are unescaped again after decoding the XML.
+func ParseTranscript(data []byte) (string, error) {
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return "", nil // Videos without captions return an empty body
+	}
+	var transcript timedText
+	if err := xml.Unmarshal(data, &transcript); err != nil {
+		return "", fmt.Errorf("failed to parse transcript: %w", err)
+	}
+
+	parts := make([]string, 0, len(transcript.Texts))
+	for _, text := range transcript.Texts {
+		if text = strings.Join(strings.Fields(html.UnescapeString(text)), " "); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, " "), nil
+}
+
+// VideosToEntries converts videos to feed entries
+func VideosToEntries(videos []Video) []feeds.Entry {
+	entries := make([]feeds.Entry, len(videos))
+	for i, video := range videos {
+		entries[i] = videoToEntry(video)
+	}
+	return entries
+}
+
+// videoToEntry converts a video to a feed entry. The content is the description, with its links,
+// followed by the transcript. The channel is used as the flair.
+func videoToEntry(video Video) feeds.Entry {
+	content := textToHTML(video.Description)
+	if video.Transcript != "" {
+		content += "<h3>Transcript</h3><p>" + html.EscapeString(templatefuncs.Truncate(transcriptLength, video.Transcript)) + "</p>"
+	}
+
+	return feeds.Entry{
+		Title:          video.Title,
+		ID:             video.ID,
+		Link:           feeds.Link{Href: video.URL},
+		Published:      video.Published,
+		Content:        content,
+		MediaThumbnail: feeds.MediaThumbnail{URL: video.Thumbnail},
+		Flair:          video.Channel,
+		Author:         video.Channel,
+		Views:          video.Views,
+		Likes:          video.Likes,
+	}
+}
+
+// urlPattern finds URLs in descriptions, so they can be linked
+var urlPattern = regexp.MustCompile(`https?://[^\s<>"]+`)
+
+// textToHTML converts a description to HTML paragraphs with its URLs linked
+func textToHTML(text string) string {
+	var b strings.Builder
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		linked := urlPattern.ReplaceAllStringFunc(html.EscapeString(paragraph), func(u string) string {
+			return fmt.Sprintf(`<a href="%s">%s</a>`, u, u)
+		})
+		b.WriteString("<p>" + strings.ReplaceAll(linked, "\n", "<br>") + "</p>")
+	}
+	return b.String()
+}
@@ -0,0 +1,197 @@
+// Package youtube implements a feed provider that reads the latest videos of YouTube channels and
+// playlists from their public feeds, with the transcript of each video as its content.
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/internal/providers/dumpname"
+)
+
+// defaultLanguage is the transcript language used when a persona does not set one
+const defaultLanguage = "en"
+
+// Provider implements the feeds.FeedProvider interface for YouTube channels and playlists. Videos
+// have no comments; their view and like counts are set on the entries instead.
+type Provider struct {
+	httpClient *http.Client
+	baseURL    string
+	enableDump bool
+	dumpDir    string
+	metrics    *feeds.FetchMetrics
+}
+
+// NewProvider creates a YouTube provider. No API key is needed, since the channel and playlist
+// feeds and transcripts are public.
+func NewProvider(enableDump bool) *Provider {
+	metrics := feeds.NewFetchMetrics("youtube")
+	return &Provider{
+		httpClient: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: metrics.Transport(nil),
+		},
+		baseURL:    "https://www.youtube.com",
+		enableDump: enableDump,
+		dumpDir:    dumpname.DefaultDir,
+		metrics:    metrics,
+	}
+}
+
+// SetDumpDir sets the directory fetched videos are dumped to
+func (p *Provider) SetDumpDir(dir string) {
+	p.dumpDir = dir
+}
+
+// Metrics implements feeds.MetricsReporter
+func (p *Provider) Metrics() feeds.FetchMetricsSnapshot {
+	return p.metrics.Snapshot()
+}
+
+// FetchFeed implements feeds.FeedProvider.FetchFeed, reading the latest videos of the persona's
+// channels and playlists with their transcripts. A feed that fails is skipped, unless all of them
+// fail. Videos without a transcript keep only their description.
+func (p *Provider) FetchFeed(ctx context.Context, pers persona.Persona) (*feeds.Feed, error) {
+	var sources []url.Values
+	for _, channel := range pers.YoutubeChannels {
+		sources = append(sources, url.Values{"channel_id": {channel}})
+	}
+	for _, playlist := range pers.YoutubePlaylists {
+		sources = append(sources, url.Values{"playlist_id": {playlist}})
+	}
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("YouTube channels not configured for persona %s - youtube_channels or youtube_playlists is required for youtube provider", pers.Name)
+	}
+
+	var videos []Video
+	var errs []string
+	for _, query := range sources {
+		log.Printf("Fetching YouTube feed %s for persona %s", query.Encode(), pers.Name)
+		data, err := p.get(ctx, "/feeds/videos.xml", query)
+		if err == nil {
+			var page []Video
+			if page, err = ParseFeed(data); err == nil {
+				videos = append(videos, page...)
+				continue
+			}
+		}
+		log.Printf("Warning: Failed to fetch YouTube feed %s: %v", query.Encode(), err)
+		errs = append(errs, fmt.Sprintf("%s: %v", query.Encode(), err))
+	}
+	if len(errs) == len(sources) {
+		return nil, fmt.Errorf("failed to fetch YouTube feeds: %s", strings.Join(errs, "; "))
+	}
+
+	videos = dedupe(videos)
+
+	language := pers.YoutubeLanguage
+	if language == "" {
+		language = defaultLanguage
+	}
+	for i := range videos {
+		transcript, err := p.transcript(ctx, videos[i].ID, language)
+		if err != nil {
+			log.Printf("Warning: Failed to fetch transcript of YouTube video %s: %v", videos[i].ID, err)
+			continue
+		}
+		videos[i].Transcript = transcript
+	}
+
+	if p.enableDump {
+		if err := p.dump(pers.Name, videos); err != nil {
+			log.Printf("Warning: Failed to dump YouTube videos: %v", err)
+		}
+	}
+
+	return &feeds.Feed{
+		Entries: VideosToEntries(videos),
+		RawData: fmt.Sprintf("YouTube videos for persona %s", pers.Name),
+	}, nil
+}
+
+// transcript reads the captions of a video in the language
+func (p *Provider) transcript(ctx context.Context, videoID, language string) (string, error) {
+	data, err := p.get(ctx, "/api/timedtext", url.Values{"v": {videoID}, "lang": {language}})
+	if err != nil {
+		return "", err
+	}
+	return ParseTranscript(data)
+}
+
+// FetchComments implements feeds.FeedProvider.FetchComments. YouTube comments need an API key, so
+// this returns an empty comment feed; use min_views or min_likes to filter videos instead.
+func (p *Provider) FetchComments(ctx context.Context, entry feeds.Entry) (*feeds.CommentFeed, error) {
+	return &feeds.CommentFeed{
+		Entries: []feeds.EntryComments{},
+		RawData: fmt.Sprintf("Comments not supported for YouTube video %s", entry.ID),
+	}, nil
+}
+
+// get requests a YouTube endpoint and returns the response body
+func (p *Provider) get(ctx context.Context, path string, query url.Values) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "ai-news-processor/1.0 (YouTube Reader)")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return data, nil
+}
+
+// dump writes the videos as JSON to the persona's directory, where the mock provider reads them
+func (p *Provider) dump(personaName string, videos []Video) error {
+	name := dumpname.Persona(personaName)
+	dir := filepath.Join(p.dumpDir, "youtube", name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create dump directory: %w", err)
+	}
+	data, err := json.MarshalIndent(videos, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal dump: %w", err)
+	}
+	return os.WriteFile(filepath.Join(dir, name+".json"), data, 0644)
+}
+
+// dedupe drops videos that appear in more than one feed, such as a channel and one of its
+// playlists, and orders the videos newest first
+func dedupe(videos []Video) []Video {
+	seen := make(map[string]bool, len(videos))
+	unique := make([]Video, 0, len(videos))
+	for _, video := range videos {
+		if seen[video.ID] {
+			continue
+		}
+		seen[video.ID] = true
+		unique = append(unique, video)
+	}
+	sort.SliceStable(unique, func(i, j int) bool {
+		return unique[i].Published.After(unique[j].Published)
+	})
+	return unique
+}
@@ -0,0 +1,136 @@
+package youtube
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+)
+
+const channelFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns:yt="http://www.youtube.com/xml/schemas/2015" xmlns:media="http://search.yahoo.com/mrss/" xmlns="http://www.w3.org/2005/Atom">
+ <title>AI Explained</title>
+ <entry>
+  <id>yt:video:abc</id>
+  <yt:videoId>abc</yt:videoId>
+  <title>Qwen 3 Tested</title>
+  <link rel="alternate" href="https://www.youtube.com/watch?v=abc"/>
+  <author><name>AI Explained</name></author>
+  <published>2025-03-07T10:00:00+00:00</published>
+  <media:group>
+   <media:title>Qwen 3 Tested</media:title>
+   <media:thumbnail url="https://i1.ytimg.com/vi/abc/hqdefault.jpg" width="480" height="360"/>
+   <media:description>Benchmarks &amp; more.
+
+Paper: https://arxiv.org/abs/1</media:description>
+   <media:community>
+    <media:starRating count="1200" average="5.00" min="1" max="5"/>
+    <media:statistics views="45000"/>
+   </media:community>
+  </media:group>
+ </entry>
+ <entry>
+  <yt:videoId>old</yt:videoId>
+  <title>Older video</title>
+  <published>2025-03-01T10:00:00+00:00</published>
+ </entry>
+</feed>`
+
+func testServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/feeds/videos.xml", func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Query().Get("channel_id") == "UCchannel", r.URL.Query().Get("playlist_id") == "PLtalks":
+			w.Write([]byte(channelFeed))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	mux.HandleFunc("/api/timedtext", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("lang") != "en" {
+			t.Errorf("expected English transcripts by default, got %q", r.URL.Query().Get("lang"))
+		}
+		if r.URL.Query().Get("v") != "abc" {
+			return // No captions
+		}
+		w.Write([]byte(`<transcript><text start="0" dur="2">Today we test</text><text start="2" dur="2">Qwen&amp;#39;s new model</text></transcript>`))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestProvider(t *testing.T) {
+	server := testServer(t)
+	dumpDir := t.TempDir()
+	provider := NewProvider(true)
+	provider.baseURL = server.URL
+	provider.SetDumpDir(dumpDir)
+	p := persona.Persona{
+		Name:             "AI YouTube",
+		Provider:         "youtube",
+		YoutubeChannels:  []string{"UCchannel", "UCmissing"},
+		YoutubePlaylists: []string{"PLtalks"},
+	}
+
+	feed, err := provider.FetchFeed(context.Background(), p)
+	if err != nil {
+		t.Fatalf("FetchFeed: %v", err)
+	}
+
+	var ids []string
+	for _, entry := range feed.Entries {
+		ids = append(ids, entry.ID)
+	}
+	if !reflect.DeepEqual(ids, []string{"abc", "old"}) {
+		t.Fatalf("expected videos in both feeds to be deduplicated newest first, got %v", ids)
+	}
+
+	entry := feed.Entries[0]
+	if entry.Title != "Qwen 3 Tested" || entry.Author != "AI Explained" || entry.Flair != "AI Explained" {
+		t.Errorf("unexpected entry fields: %+v", entry)
+	}
+	if entry.Views != 45000 || entry.Likes != 1200 {
+		t.Errorf("expected 45000 views and 1200 likes, got %d and %d", entry.Views, entry.Likes)
+	}
+	if entry.MediaThumbnail.URL != "https://i1.ytimg.com/vi/abc/hqdefault.jpg" {
+		t.Errorf("unexpected thumbnail %q", entry.MediaThumbnail.URL)
+	}
+	want := `<p>Benchmarks &amp; more.</p><p>Paper: <a href="https://arxiv.org/abs/1">https://arxiv.org/abs/1</a></p><h3>Transcript</h3><p>Today we test Qwen&#39;s new model</p>`
+	if entry.Content != want {
+		t.Errorf("expected the description and transcript as content:\n got %s\nwant %s", entry.Content, want)
+	}
+
+	if feed.Entries[1].Link.Href != "https://www.youtube.com/watch?v=old" || strings.Contains(feed.Entries[1].Content, "Transcript") {
+		t.Errorf("expected a watch link and no transcript for the older video, got %+v", feed.Entries[1])
+	}
+
+	if _, err := os.Stat(filepath.Join(dumpDir, "youtube", "ai youtube", "ai youtube.json")); err != nil {
+		t.Errorf("expected the videos to be dumped: %v", err)
+	}
+}
+
+func TestProvider_AllFeedsFail(t *testing.T) {
+	server := testServer(t)
+	provider := NewProvider(false)
+	provider.baseURL = server.URL
+
+	_, err := provider.FetchFeed(context.Background(), persona.Persona{Name: "Test", YoutubeChannels: []string{"UCmissing"}})
+	if err == nil {
+		t.Error("expected an error when every feed fails")
+	}
+}
+
+func TestParseTranscript_Empty(t *testing.T) {
+	transcript, err := ParseTranscript([]byte("  "))
+	if err != nil || transcript != "" {
+		t.Errorf("expected no transcript for an empty body, got %q, %v", transcript, err)
+	}
+}
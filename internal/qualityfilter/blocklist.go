@@ -0,0 +1,80 @@
+package qualityfilter
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+)
+
+// Blocklist drops the entries of blocked authors, domains and titles
+type Blocklist struct {
+	authors map[string]bool
+	domains []string
+	titles  []*regexp.Regexp
+}
+
+// NewBlocklist compiles the blocklist of a persona
+func NewBlocklist(b persona.Blocklist) (*Blocklist, error) {
+	blocklist := &Blocklist{authors: make(map[string]bool, len(b.Authors))}
+	for _, author := range b.Authors {
+		blocklist.authors[normalizeAuthor(author)] = true
+	}
+	for _, domain := range b.Domains {
+		blocklist.domains = append(blocklist.domains, strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), "www."))
+	}
+	for _, pattern := range b.TitlePatterns {
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid blocklist title pattern '%s': %w", pattern, err)
+		}
+		blocklist.titles = append(blocklist.titles, re)
+	}
+	return blocklist, nil
+}
+
+// normalizeAuthor lowercases an author name and strips the u/ prefix of Reddit usernames
+func normalizeAuthor(author string) string {
+	author = strings.ToLower(strings.TrimSpace(author))
+	author = strings.TrimPrefix(author, "/")
+	return strings.TrimPrefix(author, "u/")
+}
+
+// Reason returns why an entry is blocked, or an empty string if it is not. It can be used as a
+// feeds.EntryFilter.
+func (b *Blocklist) Reason(entry feeds.Entry) string {
+	if entry.Author != "" && b.authors[normalizeAuthor(entry.Author)] {
+		return fmt.Sprintf("blocked author %s", entry.Author)
+	}
+
+	links := append([]url.URL(nil), entry.ExternalURLs...)
+	if link, err := url.Parse(entry.Link.Href); err == nil {
+		links = append(links, *link)
+	}
+	for _, link := range links {
+		if domain := b.blockedDomain(link.Hostname()); domain != "" {
+			return fmt.Sprintf("blocked domain %s", domain)
+		}
+	}
+
+	for _, title := range b.titles {
+		if title.MatchString(entry.Title) {
+			return fmt.Sprintf("blocked title pattern %s", strings.TrimPrefix(title.String(), "(?i)"))
+		}
+	}
+	return ""
+}
+
+// blockedDomain returns the blocked domain host belongs to, if any
+func (b *Blocklist) blockedDomain(host string) string {
+	host = strings.ToLower(host)
+	for _, domain := range b.domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return domain
+		}
+	}
+	return ""
+}
@@ -0,0 +1,72 @@
+package qualityfilter
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+)
+
+func TestBlocklist_Reason(t *testing.T) {
+	blocklist, err := NewBlocklist(persona.Blocklist{
+		Authors:       []string{"u/AutoModerator"},
+		Domains:       []string{"www.medium.com"},
+		TitlePatterns: []string{`^\[meme\]`, "giveaway"},
+	})
+	if err != nil {
+		t.Fatalf("NewBlocklist: %v", err)
+	}
+	article, _ := url.Parse("https://blog.medium.com/some-post")
+
+	tests := []struct {
+		name     string
+		entry    feeds.Entry
+		expected string
+	}{
+		{
+			name:     "blocked author ignoring case",
+			entry:    feeds.Entry{Title: "Weekly thread", Author: "automoderator"},
+			expected: "blocked author automoderator",
+		},
+		{
+			name:     "blocked subdomain of a linked page",
+			entry:    feeds.Entry{Title: "Article", ExternalURLs: []url.URL{*article}},
+			expected: "blocked domain medium.com",
+		},
+		{
+			name:     "blocked entry link",
+			entry:    feeds.Entry{Title: "Article", Link: feeds.Link{Href: "https://medium.com/p/1"}},
+			expected: "blocked domain medium.com",
+		},
+		{
+			name:     "blocked title ignoring case",
+			entry:    feeds.Entry{Title: "GPU GIVEAWAY this week"},
+			expected: "blocked title pattern giveaway",
+		},
+		{
+			name:     "similar domain is not blocked",
+			entry:    feeds.Entry{Title: "Article", Link: feeds.Link{Href: "https://notmedium.com/p/1"}},
+			expected: "",
+		},
+		{
+			name:     "pattern anchored to the start",
+			entry:    feeds.Entry{Title: "Not a [meme]", Author: "someone"},
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if reason := blocklist.Reason(tt.entry); reason != tt.expected {
+				t.Errorf("expected reason %q, got %q", tt.expected, reason)
+			}
+		})
+	}
+}
+
+func TestNewBlocklist_InvalidPattern(t *testing.T) {
+	if _, err := NewBlocklist(persona.Blocklist{TitlePatterns: []string{"(meme"}}); err == nil {
+		t.Error("expected an error for an invalid title pattern")
+	}
+}
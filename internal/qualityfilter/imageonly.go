@@ -0,0 +1,37 @@
+package qualityfilter
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
+)
+
+// htmlTagPattern matches HTML tags so markup-only content (e.g. an RSS <img> wrapper) is not counted as text
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// IsImageOnly reports whether an entry consists of images with no text content or external links
+func IsImageOnly(entry feeds.Entry) bool {
+	if len(entry.ImageURLs) == 0 && entry.MediaThumbnail.URL == "" {
+		return false
+	}
+	if len(entry.ExternalURLs) > 0 {
+		return false
+	}
+
+	text := htmlTagPattern.ReplaceAllString(entry.Content, " ")
+	return strings.TrimSpace(text) == ""
+}
+
+// FilterImageOnly removes image-only entries that have fewer comments than the specified threshold.
+// A threshold of 0 removes all image-only entries.
+func FilterImageOnly(entries []feeds.Entry, commentThreshold int) []feeds.Entry {
+	filtered := make([]feeds.Entry, 0, len(entries))
+	for _, entry := range entries {
+		if IsImageOnly(entry) && (commentThreshold <= 0 || len(entry.Comments) < commentThreshold) {
+			continue
+		}
+		filtered = append(filtered, entry)
+	}
+	return filtered
+}
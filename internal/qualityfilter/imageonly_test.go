@@ -0,0 +1,94 @@
+package qualityfilter
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
+)
+
+func TestIsImageOnly(t *testing.T) {
+	imageURL, _ := url.Parse("https://i.redd.it/meme.png")
+	externalURL, _ := url.Parse("https://example.com/article")
+
+	tests := []struct {
+		name     string
+		entry    feeds.Entry
+		expected bool
+	}{
+		{
+			name:     "image with no text",
+			entry:    feeds.Entry{ImageURLs: []url.URL{*imageURL}},
+			expected: true,
+		},
+		{
+			name:     "thumbnail with markup-only content",
+			entry:    feeds.Entry{Content: `<div><img src="https://i.redd.it/meme.png" /></div>`, MediaThumbnail: feeds.MediaThumbnail{URL: "https://i.redd.it/meme.png"}},
+			expected: true,
+		},
+		{
+			name:     "image with text",
+			entry:    feeds.Entry{Content: "Benchmark results for the new model", ImageURLs: []url.URL{*imageURL}},
+			expected: false,
+		},
+		{
+			name:     "image with external link",
+			entry:    feeds.Entry{ImageURLs: []url.URL{*imageURL}, ExternalURLs: []url.URL{*externalURL}},
+			expected: false,
+		},
+		{
+			name:     "text only",
+			entry:    feeds.Entry{Content: "Discussion thread"},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsImageOnly(tt.entry); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestFilterImageOnly(t *testing.T) {
+	imageURL, _ := url.Parse("https://i.redd.it/meme.png")
+
+	entries := []feeds.Entry{
+		{Title: "Meme", ImageURLs: []url.URL{*imageURL}, Comments: make([]feeds.EntryComments, 3)},
+		{Title: "Popular meme", ImageURLs: []url.URL{*imageURL}, Comments: make([]feeds.EntryComments, 25)},
+		{Title: "Text post", Content: "A new model was released"},
+	}
+
+	tests := []struct {
+		name             string
+		commentThreshold int
+		expectedTitles   []string
+	}{
+		{
+			name:             "zero threshold drops all image-only entries",
+			commentThreshold: 0,
+			expectedTitles:   []string{"Text post"},
+		},
+		{
+			name:             "image-only entries with enough comments are kept",
+			commentThreshold: 20,
+			expectedTitles:   []string{"Popular meme", "Text post"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filtered := FilterImageOnly(entries, tt.commentThreshold)
+			if len(filtered) != len(tt.expectedTitles) {
+				t.Fatalf("expected %d entries, got %d", len(tt.expectedTitles), len(filtered))
+			}
+			for i, title := range tt.expectedTitles {
+				if filtered[i].Title != title {
+					t.Errorf("expected entry %d to have title %s, got %s", i, title, filtered[i].Title)
+				}
+			}
+		})
+	}
+}
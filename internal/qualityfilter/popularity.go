@@ -0,0 +1,17 @@
+package qualityfilter
+
+import "github.com/bakkerme/ai-news-processor/internal/feeds"
+
+// FilterPopularity removes entries with fewer views than minViews or fewer likes than minLikes.
+// A minimum of 0 is not applied. Only providers that report views and likes (YouTube) should be
+// filtered, since other entries have no counts.
+func FilterPopularity(entries []feeds.Entry, minViews, minLikes int) []feeds.Entry {
+	filtered := make([]feeds.Entry, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Views < minViews || entry.Likes < minLikes {
+			continue
+		}
+		filtered = append(filtered, entry)
+	}
+	return filtered
+}
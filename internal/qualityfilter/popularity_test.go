@@ -0,0 +1,39 @@
+package qualityfilter
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
+)
+
+func TestFilterPopularity(t *testing.T) {
+	entries := []feeds.Entry{
+		{ID: "viral", Views: 50000, Likes: 2000},
+		{ID: "few-views", Views: 300, Likes: 100},
+		{ID: "few-likes", Views: 5000, Likes: 10},
+	}
+
+	tests := []struct {
+		name     string
+		minViews int
+		minLikes int
+		expected []string
+	}{
+		{name: "no minimums", expected: []string{"viral", "few-views", "few-likes"}},
+		{name: "minimum views", minViews: 1000, expected: []string{"viral", "few-likes"}},
+		{name: "minimum views and likes", minViews: 1000, minLikes: 50, expected: []string{"viral"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ids []string
+			for _, entry := range FilterPopularity(entries, tt.minViews, tt.minLikes) {
+				ids = append(ids, entry.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, ids)
+			}
+		})
+	}
+}
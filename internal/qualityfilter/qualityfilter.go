@@ -12,3 +12,23 @@ func Filter(entries []feeds.Entry, threshold int) []feeds.Entry {
 	}
 	return filtered
 }
+
+// Protect adds back the entries of all that were removed from filtered but match keep, in the
+// order of all. It lets must-include entries bypass a filter.
+func Protect(all, filtered []feeds.Entry, keep func(feeds.Entry) bool) []feeds.Entry {
+	if len(all) == len(filtered) {
+		return filtered
+	}
+	kept := make(map[string]bool, len(filtered))
+	for _, entry := range filtered {
+		kept[entry.ID] = true
+	}
+
+	result := make([]feeds.Entry, 0, len(filtered))
+	for _, entry := range all {
+		if kept[entry.ID] || keep(entry) {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
@@ -1,6 +1,7 @@
 package qualityfilter
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/bakkerme/ai-news-processor/internal/feeds"
@@ -64,3 +65,22 @@ func TestFilter(t *testing.T) {
 		})
 	}
 }
+
+func TestProtect(t *testing.T) {
+	entries := []feeds.Entry{
+		{ID: "a", Title: "Qwen 3 rumours"},
+		{ID: "b", Title: "Busy thread", Comments: make([]feeds.EntryComments, 20)},
+		{ID: "c", Title: "Quiet thread"},
+	}
+	keep := func(entry feeds.Entry) bool { return entry.Title == "Qwen 3 rumours" }
+
+	protected := Protect(entries, Filter(entries, 10), keep)
+
+	var ids []string
+	for _, entry := range protected {
+		ids = append(ids, entry.ID)
+	}
+	if strings.Join(ids, ",") != "a,b" {
+		t.Errorf("expected entries a,b in feed order, got %v", ids)
+	}
+}
@@ -0,0 +1,162 @@
+// Package quarantine tracks entries that fail processing run after run, so they can be skipped.
+// A malformed post otherwise burns its retries again on every run it stays in the feed.
+package quarantine
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultMaxAge is how long an entry is remembered after it last failed. A quarantined entry is
+// tried again once it is forgotten, by which time it has usually left the feed.
+const DefaultMaxAge = 30 * 24 * time.Hour
+
+// Entry records the failures of a single entry
+type Entry struct {
+	ID          string    `json:"id"`
+	Title       string    `json:"title,omitempty"`
+	Failures    int       `json:"failures"` // Runs in a row the entry failed in
+	LastError   string    `json:"lastError"`
+	FirstFailed time.Time `json:"firstFailed"`
+	LastFailed  time.Time `json:"lastFailed"`
+}
+
+// Store tracks the entries that failed processing. Once an entry has failed in threshold runs in
+// a row it is quarantined until it is forgotten. A store is loaded once per run, and an entry
+// that fails for several personas of a run counts as failing once.
+type Store struct {
+	path      string
+	threshold int
+	now       func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*Entry
+	counted map[string]bool // Entries whose failure this run was already counted
+}
+
+// Load reads the quarantine from disk, forgetting entries that have not failed for maxAge.
+// If the file does not exist, an empty store is returned. A threshold of 0 or less disables
+// quarantining, but failures are still recorded.
+func Load(path string, threshold int, maxAge time.Duration) (*Store, error) {
+	s := &Store{
+		path:      path,
+		threshold: threshold,
+		now:       time.Now,
+		entries:   make(map[string]*Entry),
+		counted:   make(map[string]bool),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("could not read quarantine: %w", err)
+	}
+
+	var list []Entry
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("could not parse quarantine: %w", err)
+	}
+
+	cutoff := s.now().Add(-maxAge)
+	for i := range list {
+		if list[i].ID == "" || list[i].LastFailed.Before(cutoff) {
+			continue
+		}
+		s.entries[list[i].ID] = &list[i]
+	}
+
+	return s, nil
+}
+
+// Quarantined returns the failure record of an entry that failed too many runs in a row to be
+// processed again. A nil store quarantines nothing.
+func (s *Store) Quarantined(id string) (Entry, bool) {
+	if s == nil || s.threshold <= 0 {
+		return Entry{}, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[id]
+	if !ok || entry.Failures < s.threshold {
+		return Entry{}, false
+	}
+	return *entry, true
+}
+
+// RecordFailure registers that an entry failed processing in this run
+func (s *Store) RecordFailure(id string, title string, reason string) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	entry, ok := s.entries[id]
+	if !ok {
+		entry = &Entry{ID: id, FirstFailed: now}
+		s.entries[id] = entry
+	}
+	if !s.counted[id] {
+		entry.Failures++
+		s.counted[id] = true
+	}
+	entry.Title = title
+	entry.LastError = reason
+	entry.LastFailed = now
+}
+
+// RecordSuccess clears the failures of an entry, so it has to fail threshold runs in a row again
+func (s *Store) RecordSuccess(id string) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, id)
+	delete(s.counted, id)
+}
+
+// Save persists the store to disk as a JSON array
+func (s *Store) Save() error {
+	s.mu.Lock()
+	list := make([]Entry, 0, len(s.entries))
+	for _, entry := range s.entries {
+		list = append(list, *entry)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].ID < list[j].ID
+	})
+
+	payload, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode quarantine: %w", err)
+	}
+
+	dir := filepath.Dir(s.path)
+	if dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("could not create quarantine directory: %w", err)
+		}
+	}
+
+	if err := os.WriteFile(s.path, payload, 0644); err != nil {
+		return fmt.Errorf("could not write quarantine: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package quarantine
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_QuarantinesAfterThreshold(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quarantined_entries.json")
+
+	// Each run loads the store again, as run.go does
+	for run := 1; run <= 2; run++ {
+		store, err := Load(path, 2, DefaultMaxAge)
+		require.NoError(t, err)
+		_, ok := store.Quarantined("t3_abc")
+		assert.False(t, ok, "should not quarantine before run %d failed", run)
+
+		// Failing for two personas of the same run counts once
+		store.RecordFailure("t3_abc", "Broken post", "model overloaded")
+		store.RecordFailure("t3_abc", "Broken post", "model overloaded")
+		require.NoError(t, store.Save())
+	}
+
+	store, err := Load(path, 2, DefaultMaxAge)
+	require.NoError(t, err)
+	record, ok := store.Quarantined("t3_abc")
+	require.True(t, ok)
+	assert.Equal(t, 2, record.Failures)
+	assert.Equal(t, "Broken post", record.Title)
+	assert.Equal(t, "model overloaded", record.LastError)
+}
+
+func TestStore_SuccessResets(t *testing.T) {
+	store, err := Load(filepath.Join(t.TempDir(), "quarantined_entries.json"), 1, DefaultMaxAge)
+	require.NoError(t, err)
+
+	store.RecordFailure("t3_abc", "Post", "model overloaded")
+	_, ok := store.Quarantined("t3_abc")
+	assert.True(t, ok)
+
+	store.RecordSuccess("t3_abc")
+	_, ok = store.Quarantined("t3_abc")
+	assert.False(t, ok, "a success clears the failures")
+
+	store.RecordFailure("t3_abc", "Post", "model overloaded")
+	_, ok = store.Quarantined("t3_abc")
+	assert.True(t, ok, "a failure after a success in the same run counts again")
+}
+
+func TestStore_ForgetsOldEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quarantined_entries.json")
+	store, err := Load(path, 1, time.Hour)
+	require.NoError(t, err)
+	store.now = func() time.Time { return time.Now().Add(-2 * time.Hour) }
+	store.RecordFailure("t3_old", "Old post", "model overloaded")
+	require.NoError(t, store.Save())
+
+	store, err = Load(path, 1, time.Hour)
+	require.NoError(t, err)
+	_, ok := store.Quarantined("t3_old")
+	assert.False(t, ok, "an entry that has not failed for maxAge is tried again")
+}
+
+func TestStore_DisabledThreshold(t *testing.T) {
+	store, err := Load(filepath.Join(t.TempDir(), "quarantined_entries.json"), 0, DefaultMaxAge)
+	require.NoError(t, err)
+	store.RecordFailure("t3_abc", "Post", "model overloaded")
+	_, ok := store.Quarantined("t3_abc")
+	assert.False(t, ok)
+
+	var nilStore *Store
+	nilStore.RecordFailure("t3_abc", "Post", "model overloaded")
+	_, ok = nilStore.Quarantined("t3_abc")
+	assert.False(t, ok)
+}
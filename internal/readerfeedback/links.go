@@ -0,0 +1,56 @@
+// Package readerfeedback lets readers rate digest items from the email. Each item carries signed
+// thumbs-up and thumbs-down links to a small HTTP endpoint that records the vote in the feedback
+// file used by the tune command.
+package readerfeedback
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/url"
+	"strings"
+)
+
+// Vote values used in feedback links
+const (
+	VoteUp   = "up"
+	VoteDown = "down"
+)
+
+// Links builds signed feedback links. Signing keeps anyone who can guess item IDs from
+// recording votes, and keeps votes bound to the persona the item was sent to.
+type Links struct {
+	baseURL string
+	secret  []byte
+}
+
+// NewLinks creates a link builder for the endpoint served at baseURL
+func NewLinks(baseURL string, secret string) *Links {
+	return &Links{baseURL: strings.TrimSuffix(baseURL, "/"), secret: []byte(secret)}
+}
+
+// FeedbackURL returns the link a reader follows to mark an item as relevant or not
+func (l *Links) FeedbackURL(personaName string, itemID string, relevant bool) string {
+	vote := VoteDown
+	if relevant {
+		vote = VoteUp
+	}
+	query := url.Values{
+		"p":   {personaName},
+		"i":   {itemID},
+		"v":   {vote},
+		"sig": {l.sign(personaName, itemID, vote)},
+	}
+	return l.baseURL + "/feedback?" + query.Encode()
+}
+
+// verify reports whether sig is the signature of the vote
+func (l *Links) verify(personaName, itemID, vote, sig string) bool {
+	return hmac.Equal([]byte(sig), []byte(l.sign(personaName, itemID, vote)))
+}
+
+func (l *Links) sign(personaName, itemID, vote string) string {
+	mac := hmac.New(sha256.New, l.secret)
+	mac.Write([]byte(personaName + "\x00" + itemID + "\x00" + vote))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16])
+}
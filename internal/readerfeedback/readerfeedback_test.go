@@ -0,0 +1,121 @@
+package readerfeedback
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/itemstore"
+	"github.com/bakkerme/ai-news-processor/internal/tuning"
+	"github.com/bakkerme/ai-news-processor/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinks_FeedbackURL(t *testing.T) {
+	links := NewLinks("https://news.example.com/", "secret")
+
+	up, err := url.Parse(links.FeedbackURL("LocalLLaMA", "abc", true))
+	require.NoError(t, err)
+	assert.Equal(t, "/feedback", up.Path)
+	assert.Equal(t, "news.example.com", up.Host)
+	query := up.Query()
+	assert.Equal(t, VoteUp, query.Get("v"))
+	assert.True(t, links.verify("LocalLLaMA", "abc", VoteUp, query.Get("sig")))
+	assert.False(t, links.verify("LocalLLaMA", "abc", VoteDown, query.Get("sig")), "signature is bound to the vote")
+	assert.False(t, links.verify("Other", "abc", VoteUp, query.Get("sig")), "signature is bound to the persona")
+	assert.False(t, NewLinks("https://news.example.com", "other").verify("LocalLLaMA", "abc", VoteUp, query.Get("sig")))
+}
+
+func TestServer_RecordsVotes(t *testing.T) {
+	dir := t.TempDir()
+	store := itemstore.New(filepath.Join(dir, "items"))
+	require.NoError(t, store.Append("LocalLLaMA", []models.Item{{ID: "abc", Title: "New quant format", Summary: "Smaller files"}}, time.Now()))
+	feedbackPath := filepath.Join(dir, "feedback.jsonl")
+
+	links := NewLinks("https://news.example.com", "secret")
+	server := httptest.NewServer(NewServer(links, store, feedbackPath).Handler())
+	defer server.Close()
+
+	get := func(link string) *http.Response {
+		t.Helper()
+		resp, err := http.Get(server.URL + strings.TrimPrefix(link, "https://news.example.com"))
+		require.NoError(t, err)
+		return resp
+	}
+	// post submits the confirmation form of a feedback link
+	post := func(link string) *http.Response {
+		t.Helper()
+		parsed, err := url.Parse(link)
+		require.NoError(t, err)
+		resp, err := http.PostForm(server.URL+"/feedback", parsed.Query())
+		require.NoError(t, err)
+		return resp
+	}
+
+	// Opening a link, as mail scanners do with every link, only asks for confirmation
+	resp := get(links.FeedbackURL("LocalLLaMA", "abc", false))
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), `<form method="post">`)
+	assert.Contains(t, string(body), "New quant format")
+	resp = get(links.FeedbackURL("LocalLLaMA", "abc", true))
+	resp.Body.Close()
+	_, err := os.Stat(feedbackPath)
+	assert.True(t, os.IsNotExist(err), "a GET stores no vote")
+
+	resp = post(links.FeedbackURL("LocalLLaMA", "abc", false))
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	forged := strings.Replace(links.FeedbackURL("LocalLLaMA", "abc", false), "v=down", "v=up", 1)
+	resp = get(forged)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp = post(forged)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	records, err := tuning.LoadFeedback(feedbackPath)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "New quant format", records[0].Title)
+	assert.Equal(t, "reader", records[0].Source)
+	assert.True(t, records[0].IsRelevant)
+	assert.False(t, records[0].ExpectedRelevant)
+	assert.Len(t, tuning.Mistakes(records, "LocalLLaMA"), 1, "a thumbs-down counts as a misclassification for tune")
+
+	stats, err := LoadStats(feedbackPath)
+	require.NoError(t, err)
+	assert.Equal(t, []Precision{{Persona: "LocalLLaMA", Down: 1}}, stats)
+
+	resp = get("/feedback/stats")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "stats are only served by the API")
+}
+
+func TestTally(t *testing.T) {
+	records := []tuning.Feedback{
+		{Persona: "LocalLLaMA", EntryID: "a", ExpectedRelevant: false, Source: "reader"},
+		{Persona: "localllama", EntryID: "a", ExpectedRelevant: true, Source: "reader"},
+		{Persona: "LocalLLaMA", EntryID: "b", ExpectedRelevant: true, Source: "reader"},
+		{Persona: "LocalLLaMA", EntryID: "c", ExpectedRelevant: false, Source: "reader"},
+		{Persona: "LocalLLaMA", EntryID: "d", ExpectedRelevant: false, Source: "human"},
+		{Persona: "Apple", EntryID: "e", ExpectedRelevant: true, Source: "reader"},
+	}
+
+	stats := Tally(records)
+	require.Len(t, stats, 2)
+	assert.Equal(t, Precision{Persona: "Apple", Up: 1, Precision: 1}, stats[0])
+	assert.Equal(t, "localllama", strings.ToLower(stats[1].Persona))
+	assert.Equal(t, 2, stats[1].Up)
+	assert.Equal(t, 1, stats[1].Down)
+	assert.InDelta(t, 2.0/3, stats[1].Precision, 1e-9)
+}
@@ -0,0 +1,137 @@
+package readerfeedback
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/itemstore"
+	"github.com/bakkerme/ai-news-processor/internal/tuning"
+	"github.com/bakkerme/ai-news-processor/models"
+)
+
+// Server handles feedback links. Only the signed vote endpoints are served here; the precision
+// they add up to is served behind the API token by the API.
+type Server struct {
+	links        *Links
+	items        *itemstore.Store
+	feedbackPath string
+	now          func() time.Time
+
+	mu sync.Mutex // serializes writes to the feedback file
+}
+
+// NewServer creates a server that verifies links with links, looks up item titles in items and
+// appends votes to the feedback file at feedbackPath
+func NewServer(links *Links, items *itemstore.Store, feedbackPath string) *Server {
+	return &Server{links: links, items: items, feedbackPath: feedbackPath, now: time.Now}
+}
+
+// Handler returns the HTTP handler serving /feedback
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /feedback", s.handleConfirm)
+	mux.HandleFunc("POST /feedback", s.handleVote)
+	return mux
+}
+
+// vote is a verified feedback link
+type vote struct {
+	persona, itemID, vote, sig string
+}
+
+// parseVote reads and verifies the parameters of a feedback link. It writes the error response and
+// returns false if they are invalid.
+func (s *Server) parseVote(w http.ResponseWriter, get func(string) string) (vote, bool) {
+	v := vote{persona: get("p"), itemID: get("i"), vote: get("v"), sig: get("sig")}
+	if v.persona == "" || v.itemID == "" || (v.vote != VoteUp && v.vote != VoteDown) {
+		http.Error(w, "invalid feedback link", http.StatusBadRequest)
+		return vote{}, false
+	}
+	if !s.links.verify(v.persona, v.itemID, v.vote, v.sig) {
+		http.Error(w, "invalid feedback link", http.StatusForbidden)
+		return vote{}, false
+	}
+	return v, true
+}
+
+// handleConfirm asks the reader to confirm the vote of a feedback link. Mail scanners and link
+// prefetchers open every link of an email, so opening one records nothing.
+func (s *Server) handleConfirm(w http.ResponseWriter, r *http.Request) {
+	v, ok := s.parseVote(w, r.URL.Query().Get)
+	if !ok {
+		return
+	}
+
+	title := v.itemID
+	if item, ok := s.lookup(v.persona, v.itemID); ok {
+		title = item.Title
+	}
+	verdict, button := "relevant", "👍 Yes, it was relevant"
+	if v.vote == VoteDown {
+		verdict, button = "not relevant", "👎 No, it was not relevant"
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, `<!DOCTYPE html><html><body style="font-family: sans-serif"><form method="post"><p>Record that <b>%s</b> was %s?</p>`+
+		`<input type="hidden" name="p" value="%s"><input type="hidden" name="i" value="%s"><input type="hidden" name="v" value="%s"><input type="hidden" name="sig" value="%s">`+
+		`<button type="submit">%s</button></form></body></html>`,
+		html.EscapeString(title), verdict, html.EscapeString(v.persona), html.EscapeString(v.itemID), v.vote, html.EscapeString(v.sig), button)
+}
+
+// handleVote records a vote confirmed on the page of handleConfirm
+func (s *Server) handleVote(w http.ResponseWriter, r *http.Request) {
+	v, ok := s.parseVote(w, r.PostFormValue)
+	if !ok {
+		return
+	}
+
+	record := tuning.Feedback{
+		Persona:          v.persona,
+		EntryID:          v.itemID,
+		IsRelevant:       true, // Only items judged relevant are sent
+		ExpectedRelevant: v.vote == VoteUp,
+		Source:           "reader",
+		RecordedAt:       s.now(),
+	}
+	if item, ok := s.lookup(v.persona, v.itemID); ok {
+		record.Title = item.Title
+		record.Summary = item.Summary
+	}
+
+	s.mu.Lock()
+	err := tuning.AppendFeedback(s.feedbackPath, record)
+	s.mu.Unlock()
+	if err != nil {
+		log.Printf("Could not record reader feedback: %v", err)
+		http.Error(w, "could not record feedback", http.StatusInternalServerError)
+		return
+	}
+
+	title := record.Title
+	if title == "" {
+		title = v.itemID
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, "<!DOCTYPE html><html><body style=\"font-family: sans-serif\"><p>Thanks, your feedback on <b>%s</b> was recorded.</p></body></html>", html.EscapeString(title))
+}
+
+// lookup finds the stored item a vote refers to, so the feedback record carries its title
+func (s *Server) lookup(personaName, itemID string) (models.Item, bool) {
+	if s.items == nil {
+		return models.Item{}, false
+	}
+	records, err := s.items.Since(personaName, time.Time{})
+	if err != nil {
+		log.Printf("Could not look up item %s: %v", itemID, err)
+		return models.Item{}, false
+	}
+	for _, record := range records {
+		if record.Item.ID == itemID {
+			return record.Item, true
+		}
+	}
+	return models.Item{}, false
+}
@@ -0,0 +1,64 @@
+package readerfeedback
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/bakkerme/ai-news-processor/internal/tuning"
+)
+
+// Precision summarizes reader votes for a persona. Only the latest vote per item counts.
+type Precision struct {
+	Persona   string  `json:"persona"`
+	Up        int     `json:"up"`
+	Down      int     `json:"down"`
+	Precision float64 `json:"precision"` // Share of rated items readers found relevant
+}
+
+// Tally computes reader precision per persona from feedback records, sorted by persona name
+func Tally(records []tuning.Feedback) []Precision {
+	type key struct{ persona, item string }
+	latest := make(map[key]bool)
+	names := make(map[string]string)
+	for _, record := range records {
+		if record.Source != "reader" || record.EntryID == "" {
+			continue
+		}
+		persona := strings.ToLower(record.Persona)
+		names[persona] = record.Persona
+		latest[key{persona, record.EntryID}] = record.ExpectedRelevant
+	}
+
+	byPersona := make(map[string]*Precision)
+	for k, relevant := range latest {
+		p, ok := byPersona[k.persona]
+		if !ok {
+			p = &Precision{Persona: names[k.persona]}
+			byPersona[k.persona] = p
+		}
+		if relevant {
+			p.Up++
+		} else {
+			p.Down++
+		}
+	}
+
+	result := make([]Precision, 0, len(byPersona))
+	for _, p := range byPersona {
+		p.Precision = float64(p.Up) / float64(p.Up+p.Down)
+		result = append(result, *p)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Persona < result[j].Persona
+	})
+	return result
+}
+
+// LoadStats computes reader precision per persona from the feedback file at path
+func LoadStats(path string) ([]Precision, error) {
+	records, err := tuning.LoadFeedback(path)
+	if err != nil {
+		return nil, err
+	}
+	return Tally(records), nil
+}
@@ -0,0 +1,52 @@
+package internal
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/email"
+	"github.com/bakkerme/ai-news-processor/internal/itemstore"
+	"github.com/bakkerme/ai-news-processor/internal/llm"
+	"github.com/bakkerme/ai-news-processor/internal/openai"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/internal/runreport"
+)
+
+// runRollups sends each persona a review of the items it was sent over the last days, recording
+// the outcome of each persona in report
+func runRollups(client openai.OpenAIClient, emailService *email.Service, store *itemstore.Store, personas []persona.Persona, days int, report *runreport.Report) error {
+	if days < 1 {
+		return fmt.Errorf("rollup needs at least one day, got %d", days)
+	}
+	since := time.Now().AddDate(0, 0, -days)
+
+	for _, p := range personas {
+		personaReport := report.Persona(p.Name)
+		records, err := store.Since(p.Name, since)
+		if err != nil {
+			log.Printf("Could not load stored items for persona %s: %v\n", p.Name, err)
+			personaReport.Fail("load stored items: %v", err)
+			continue
+		}
+		if len(records) == 0 {
+			log.Printf("No items sent to persona %s in the last %d days, skipping rollup\n", p.Name, days)
+			continue
+		}
+
+		rollup, err := llm.GenerateRollup(client, records, p, days)
+		if err != nil {
+			log.Printf("Could not generate rollup for persona %s: %v\n", p.Name, err)
+			personaReport.FailWith(runreport.CauseLLM, "generate rollup: %v", err)
+			continue
+		}
+
+		if err := emailService.RenderAndSendRollup(records, rollup, p, since); err != nil {
+			log.Printf("Could not send rollup email for persona %s: %v\n", p.Name, err)
+			personaReport.FailWith(runreport.CauseDelivery, "send rollup: %v", err)
+			continue
+		}
+		personaReport.Sent = len(records)
+	}
+	return nil
+}
@@ -1,98 +1,256 @@
 package internal
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"os"
+	"path"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/bakkerme/ai-news-processor/internal/analytics"
 	"github.com/bakkerme/ai-news-processor/internal/bench"
+	"github.com/bakkerme/ai-news-processor/internal/budget"
+	"github.com/bakkerme/ai-news-processor/internal/carryover"
+	"github.com/bakkerme/ai-news-processor/internal/checkpoint"
 	"github.com/bakkerme/ai-news-processor/internal/contentextractor"
+	"github.com/bakkerme/ai-news-processor/internal/digest"
+	"github.com/bakkerme/ai-news-processor/internal/edits"
 	"github.com/bakkerme/ai-news-processor/internal/email"
+	"github.com/bakkerme/ai-news-processor/internal/failedurls"
 	"github.com/bakkerme/ai-news-processor/internal/feeds"
 	"github.com/bakkerme/ai-news-processor/internal/fetcher"
 	httputil "github.com/bakkerme/ai-news-processor/internal/http"
 	"github.com/bakkerme/ai-news-processor/internal/http/retry"
+	"github.com/bakkerme/ai-news-processor/internal/imageprep"
+	"github.com/bakkerme/ai-news-processor/internal/itemstore"
+	"github.com/bakkerme/ai-news-processor/internal/linkcheck"
 	"github.com/bakkerme/ai-news-processor/internal/llm"
+	"github.com/bakkerme/ai-news-processor/internal/megathread"
+	"github.com/bakkerme/ai-news-processor/internal/metricsexport"
+	"github.com/bakkerme/ai-news-processor/internal/notify"
+	"github.com/bakkerme/ai-news-processor/internal/ocr"
 	"github.com/bakkerme/ai-news-processor/internal/openai"
+	"github.com/bakkerme/ai-news-processor/internal/outputs"
 	"github.com/bakkerme/ai-news-processor/internal/persona"
 	"github.com/bakkerme/ai-news-processor/internal/prompts"
 	"github.com/bakkerme/ai-news-processor/internal/providers"
+	"github.com/bakkerme/ai-news-processor/internal/providers/imap"
+	"github.com/bakkerme/ai-news-processor/internal/providers/ingest"
+	"github.com/bakkerme/ai-news-processor/internal/providers/mastodon"
+	"github.com/bakkerme/ai-news-processor/internal/providers/plugin"
 	"github.com/bakkerme/ai-news-processor/internal/providers/rss"
+	"github.com/bakkerme/ai-news-processor/internal/providers/youtube"
 	"github.com/bakkerme/ai-news-processor/internal/qualityfilter"
+	"github.com/bakkerme/ai-news-processor/internal/quarantine"
+	"github.com/bakkerme/ai-news-processor/internal/readerfeedback"
+	"github.com/bakkerme/ai-news-processor/internal/runcache"
+	"github.com/bakkerme/ai-news-processor/internal/runhistory"
+	"github.com/bakkerme/ai-news-processor/internal/runreport"
+	"github.com/bakkerme/ai-news-processor/internal/runresult"
 	"github.com/bakkerme/ai-news-processor/internal/sentlog"
+	"github.com/bakkerme/ai-news-processor/internal/sitemeta"
 	"github.com/bakkerme/ai-news-processor/internal/specification"
+	"github.com/bakkerme/ai-news-processor/internal/storage"
+	"github.com/bakkerme/ai-news-processor/internal/tokens"
+	"github.com/bakkerme/ai-news-processor/internal/trends"
+	"github.com/bakkerme/ai-news-processor/internal/tuning"
 	"github.com/bakkerme/ai-news-processor/internal/urlextraction"
+	"github.com/bakkerme/ai-news-processor/internal/urlnorm"
+	"github.com/bakkerme/ai-news-processor/internal/watchlist"
 	"github.com/bakkerme/ai-news-processor/models"
 )
 
-func Run() {
+// Run processes the selected personas and returns the outcome of the run, which main turns into
+// the exit code
+func Run() (result runresult.Result) {
+	startTime := time.Now()
+
+	personaFlag := flag.String("persona", "", "Persona to use (name or 'all')")
+	rollupFlag := flag.Bool("rollup", false, "Send a review of the items sent over the last days instead of a digest")
+	rollupDaysFlag := flag.Int("rollup-days", 7, "Number of days covered by -rollup")
+	replayFlag := flag.String("replay", "", "Replay a dumped feed snapshot: a directory, a snapshot name or 'latest'")
+	resumeFlag := flag.Bool("resume", false, "Continue an interrupted run, reusing the entries it already processed")
+	refreshMegathreadsFlag := flag.Bool("refresh-megathreads", false, "Summarize the megathreads sent over the last day again from their latest comments instead of sending a digest")
+	flag.Parse()
+
+	// The flag takes precedence over ANP_REPLAY_SNAPSHOT and must be set before the configuration is validated
+	if *replayFlag != "" {
+		os.Setenv("ANP_REPLAY_SNAPSHOT", *replayFlag)
+	}
+
 	s, err := specification.GetConfig()
 	if err != nil {
-		panic(err)
+		return setupFailed(startTime, err)
+	}
+	if s.RunResultPath != "" {
+		defer func() {
+			if err := runresult.WriteFile(s.RunResultPath, result); err != nil {
+				log.Printf("Warning: could not write run result: %v", err)
+			}
+		}()
+	}
+
+	if err := prompts.SetTemplateDir(s.PromptsPath); err != nil {
+		return setupFailed(startTime, err)
 	}
 
 	// Print the duration it took to run the job
-	startTime := time.Now()
 	defer func() {
 		log.Printf("Job took %v\n", time.Since(startTime))
 	}()
 
+	report := runreport.New(startTime, runreport.Pricing{
+		InputPerMillion:  s.LlmInputCostPerMillion,
+		OutputPerMillion: s.LlmOutputCostPerMillion,
+	})
+	report.NoDelivery = s.DebugSkipEmail
+
+	// Mock mode with recordings replays them through the real pipeline instead of using mock responses
+	mockLLM := s.DebugMockLLM && s.DebugLLMReplayDir == ""
+	if s.DebugLLMReplayDir != "" {
+		log.Println("Replaying LLM responses from", s.DebugLLMReplayDir)
+	}
+
 	// Initialize the OpenAI client with safe timeouts to prevent infinite generation
-	openaiClient := openai.NewWithSafeTimeouts(s.LlmUrl, s.LlmApiKey, s.LlmModel)
+	openaiClient, err := newLLMClient(s, s.LlmModel)
+	if err != nil {
+		return setupFailed(startTime, fmt.Errorf("could not initialize LLM client: %w", err))
+	}
 
 	// Initialize the image client if image processing is enabled
 	var imageClient openai.OpenAIClient
 	if s.LlmImageEnabled {
-		imageClient = openai.NewWithSafeTimeouts(s.LlmUrl, s.LlmApiKey, s.LlmImageModel)
+		imageClient, err = newLLMClient(s, s.LlmImageModel)
+		if err != nil {
+			return setupFailed(startTime, fmt.Errorf("could not initialize image LLM client: %w", err))
+		}
 		log.Println("Image processing enabled with model:", s.LlmImageModel)
 	} else {
 		// Use the main client as a fallback
 		imageClient = openaiClient
 	}
 
+	// Second opinions on items the LLM was unsure of may be asked of a smaller model
+	triageClient := openaiClient
+	if s.LlmTriageModel != "" && s.LlmTriageModel != s.LlmModel {
+		triageClient, err = newLLMClient(s, s.LlmTriageModel)
+		if err != nil {
+			return setupFailed(startTime, fmt.Errorf("could not initialize triage LLM client: %w", err))
+		}
+	}
+
 	// Initialize email service
 	emailService, err := email.NewService(s)
 	if err != nil {
-		panic(fmt.Errorf("could not initialize email service: %w", err))
+		return setupFailed(startTime, fmt.Errorf("could not initialize email service: %w", err))
 	}
-
-	// Set up persona handling
-	personaPath := s.PersonasPath
-	if personaPath == "" {
-		personaPath = "/app/personas/" // default to Docker path
+	if s.FeedbackBaseURL != "" && s.FeedbackSecret != "" {
+		emailService.SetFeedbackLinks(readerfeedback.NewLinks(s.FeedbackBaseURL, s.FeedbackSecret))
+	}
+	if s.EmailLinkCheck {
+		emailService.SetLinkChecker(linkcheck.New(nil, linkcheck.DefaultTTL))
 	}
 
-	personaFlag := flag.String("persona", "", "Persona to use (name or 'all')")
-	flag.Parse()
+	notifier := notify.NewService(notify.Config{
+		NtfyURL:       s.NtfyURL,
+		NtfyTopic:     s.NtfyTopic,
+		NtfyToken:     s.NtfyToken,
+		PushoverToken: s.PushoverToken,
+		PushoverUser:  s.PushoverUser,
+	})
 
 	// Load and select personas
-	selectedPersonas, err := persona.LoadAndSelect(personaPath, *personaFlag)
+	selectedPersonas, err := persona.LoadAndSelect(s.PersonasPath, *personaFlag)
+	if err != nil {
+		return setupFailed(startTime, err)
+	}
+
+	// Containers without a persistent disk keep run data and feed dumps in object storage
+	runDataStore, dumpStore, err := newStores(s)
 	if err != nil {
-		panic(err)
+		return setupFailed(startTime, fmt.Errorf("could not initialize storage: %w", err))
+	}
+	if dumpStore != nil && (s.DebugMockFeeds || s.ReplaySnapshot != "") {
+		copied, err := storage.Download(context.Background(), dumpStore, s.FeedMocksPath)
+		if err != nil {
+			return setupFailed(startTime, fmt.Errorf("could not download feed dumps from %s: %w", dumpStore, err))
+		}
+		log.Printf("Downloaded %d feed dumps from %s\n", copied, dumpStore)
+	}
+	dumpsSince := time.Now()
+
+	// Replays read feeds from a snapshot, and snapshot runs dump them to a new one
+	feedDataDir, dumpDir := s.FeedMocksPath, s.FeedMocksPath
+	if s.ReplaySnapshot != "" {
+		feedDataDir, err = providers.ResolveSnapshot(s.FeedMocksPath, s.ReplaySnapshot)
+		if err != nil {
+			return setupFailed(startTime, fmt.Errorf("could not find feed snapshot: %w", err))
+		}
+		log.Println("Replaying feed snapshot", feedDataDir)
+	} else if s.DumpSnapshots {
+		dumpDir = providers.NewSnapshotDir(s.FeedMocksPath, startTime)
+		log.Println("Dumping feeds to snapshot", dumpDir)
 	}
 
 	// Create provider factory function
 	createProvider := func(providerType string, personaName string) (feeds.FeedProvider, error) {
-		if s.DebugMockFeeds {
+		if s.DebugMockFeeds || s.ReplaySnapshot != "" {
 			log.Printf("Using mock feed provider for persona %s", personaName)
-			return providers.NewMockProvider(personaName), nil
+			mockProvider := providers.NewMockProvider(personaName)
+			mockProvider.SetDataDir(feedDataDir)
+			return mockProvider, nil
 		}
 
 		switch providerType {
 		case "reddit":
 			log.Printf("Using Reddit API provider for persona %s", personaName)
-			return providers.NewRedditProvider(
+			redditProvider, err := providers.NewRedditProvider(
 				s.RedditClientID,
 				s.RedditSecret,
 				s.RedditUsername,
 				s.RedditPassword,
-				s.DebugRedditDump,
+				s.DumpEnabled("reddit"),
 			)
+			if err != nil {
+				return nil, err
+			}
+			redditProvider.SetDumpDir(dumpDir)
+			return redditProvider, nil
 		case "rss":
 			log.Printf("Using RSS provider for persona %s", personaName)
-			return rss.NewRSSProvider(s.DebugRedditDump), nil // Reuse debug flag for RSS dumps
+			rssProvider := rss.NewRSSProvider(s.DumpEnabled("rss"))
+			rssProvider.SetDumpDir(dumpDir)
+			return rssProvider, nil
+		case "mastodon":
+			log.Printf("Using Mastodon provider for persona %s", personaName)
+			mastodonProvider := mastodon.NewProvider(s.MastodonAccessToken, s.DumpEnabled("mastodon"))
+			mastodonProvider.SetDumpDir(dumpDir)
+			return mastodonProvider, nil
+		case "imap":
+			log.Printf("Using IMAP provider for persona %s", personaName)
+			imapProvider := imap.NewProvider(s.ImapAddr, s.ImapUsername, s.ImapPassword, s.ImapTLS, s.DumpEnabled("imap"))
+			imapProvider.SetDumpDir(dumpDir)
+			return imapProvider, nil
+		case "youtube":
+			log.Printf("Using YouTube provider for persona %s", personaName)
+			youtubeProvider := youtube.NewProvider(s.DumpEnabled("youtube"))
+			youtubeProvider.SetDumpDir(dumpDir)
+			return youtubeProvider, nil
+		case "ingest":
+			log.Printf("Using ingest queue provider for persona %s", personaName)
+			ingestProvider := ingest.NewProvider(ingest.NewQueue(filepath.Join(s.SentLogBasePath, "ingest")), s.DumpEnabled("ingest"))
+			ingestProvider.SetDumpDir(dumpDir)
+			return ingestProvider, nil
+		case "plugin":
+			log.Printf("Using plugin provider for persona %s", personaName)
+			pluginProvider := plugin.NewProvider(s.PluginsPath, s.DumpEnabled("plugin"))
+			pluginProvider.SetDumpDir(dumpDir)
+			return pluginProvider, nil
 		default:
 			return nil, fmt.Errorf("unsupported provider type: %s", providerType)
 		}
@@ -100,9 +258,33 @@ func Run() {
 
 	// Process each persona
 	sentLogBase := s.SentLogBasePath
-	if sentLogBase == "" {
-		sentLogBase = "."
+	itemStore := itemstore.New(filepath.Join(sentLogBase, "items"))
+
+	if *rollupFlag {
+		if err := runRollups(openaiClient, emailService, itemStore, selectedPersonas, *rollupDaysFlag, report); err != nil {
+			return setupFailed(startTime, err)
+		}
+		report.FinishedAt = time.Now()
+		return runresult.FromReport(report, nil)
 	}
+
+	if *refreshMegathreadsFlag {
+		// The images and links of a megathread were described when it was sent; only its comments change
+		config := llm.EntryProcessConfig{
+			InitialBackoff:       llm.DefaultEntryProcessConfig.InitialBackoff,
+			BackoffFactor:        llm.DefaultEntryProcessConfig.BackoffFactor,
+			MaxRetries:           llm.DefaultEntryProcessConfig.MaxRetries,
+			MaxBackoff:           llm.DefaultEntryProcessConfig.MaxBackoff,
+			ContextTokens:        s.LlmContextTokens,
+			DegradeAfterFailures: s.DegradeAfterFailures,
+			EntryTimeout:         time.Duration(s.EntryTimeoutSeconds) * time.Second,
+		}
+		report.NoDelivery = true
+		refreshMegathreads(openaiClient, config, itemStore, selectedPersonas, createProvider, report)
+		report.FinishedAt = time.Now()
+		return runresult.FromReport(report, nil)
+	}
+
 	sentLogPath := filepath.Join(sentLogBase, "sent_post_ids.json")
 	sentIDs, err := sentlog.LoadSentIDs(sentLogPath)
 	if err != nil {
@@ -110,16 +292,147 @@ func Run() {
 		sentIDs = make(map[string]struct{})
 	}
 
+	failedURLPath := filepath.Join(sentLogBase, "failed_urls.json")
+	failedURLs, err := failedurls.Load(failedURLPath, s.FailedURLThreshold, time.Duration(s.FailedURLTTLHours)*time.Hour)
+	if err != nil {
+		log.Printf("Warning: could not load failed URL store: %v", err)
+		failedURLs = nil
+	}
+
+	quarantinePath := filepath.Join(sentLogBase, "quarantined_entries.json")
+	quarantined, err := quarantine.Load(quarantinePath, s.QuarantineAfterRuns, quarantine.DefaultMaxAge)
+	if err != nil {
+		log.Printf("Warning: could not load entry quarantine: %v", err)
+		quarantined = nil
+	}
+
+	// The content sent posts had, to send them again when they are edited
+	var sentContent *edits.Store
+	if s.EditMinChange > 0 {
+		sentContent, err = edits.Load(filepath.Join(sentLogBase, "sent_content.json"), edits.DefaultMaxAge)
+		if err != nil {
+			log.Printf("Warning: could not load sent content, edited posts will not be sent again: %v", err)
+			sentContent = nil
+		}
+	}
+	if s.ReplaySnapshot != "" {
+		// Pages missing from the snapshot must not count as failures of the live site
+		failedURLs = nil
+		quarantined = nil
+	}
+
+	// Entries a run had no budget for are processed first by the persona's next run
+	deferredEntries, err := budget.LoadStore(filepath.Join(sentLogBase, "deferred_entries.json"), budget.DefaultMaxAge)
+	if err != nil {
+		log.Printf("Warning: could not load deferred entries: %v", err)
+		deferredEntries = nil
+	}
+
+	// Items of digests held in quiet hours or below min_items go out with the persona's next digest
+	heldItems, err := carryover.Load(filepath.Join(sentLogBase, "held_items.json"), carryover.DefaultMaxAge)
+	if err != nil {
+		log.Printf("Warning: could not load held items: %v", err)
+		heldItems = nil
+	}
+
+	// Run data that could not be submitted earlier is sent before this run's
+	var auditClient *bench.AuditClient
+	if s.SendBenchmarkToAuditService {
+		auditClient = bench.NewAuditClient(s.AuditServiceUrl, s.AuditServiceToken, filepath.Join(sentLogBase, "audit_outbox"))
+		auditClient.GzipMinBytes = s.AuditServiceGzipMinKB * 1024
+		auditClient.FlushAndLog()
+	}
+
+	var trendHistory *trends.History
+	if s.TrendDetectionEnabled && !mockLLM {
+		trendHistory, err = trends.LoadHistory(filepath.Join(sentLogBase, "trend_history.json"), trends.DefaultOptions.Window)
+		if err != nil {
+			log.Printf("Warning: could not load trend history: %v", err)
+		}
+	}
+
+	// Each persona is finished off when the next one starts and after the last one, since the
+	// loop below gives up on a persona in many places
+	usageSoFar := func() openai.Usage {
+		usage := usageOf(openaiClient)
+		if reporter, ok := imageClient.(openai.UsageReporter); ok && imageClient != openaiClient {
+			usage = usage.Add(reporter.Usage())
+		}
+		if reporter, ok := triageClient.(openai.UsageReporter); ok && triageClient != openaiClient {
+			usage = usage.Add(reporter.Usage())
+		}
+		return usage
+	}
+	var current *persona.Persona
+	var currentReport *runreport.PersonaReport
+	var currentCheckpoint *checkpoint.Checkpoint
+	usageMark := usageSoFar()
+	finishPersona := func() {
+		if current == nil {
+			return
+		}
+		usage := usageSoFar()
+		currentReport.Usage = usage.Sub(usageMark)
+		usageMark = usage
+		// A persona that failed keeps its checkpoint, so --resume can pick up where it stopped
+		if len(currentReport.Failures) == 0 {
+			if err := currentCheckpoint.Clear(); err != nil {
+				log.Printf("Warning: could not clear checkpoint for persona %s: %v", current.Name, err)
+			}
+		}
+		cost := report.Pricing.Cost(currentReport.Usage)
+		if err := notifier.PersonaFinished(*current, currentReport, cost, report.Pricing != (runreport.Pricing{})); err != nil {
+			log.Printf("Warning: could not send notification for persona %s: %v", current.Name, err)
+		}
+	}
+
+	// Personas reading the same sources share their feeds, pages and summaries within the run
+	var sharedCache *runcache.Cache
+	if s.SharedCacheEnabled {
+		sharedCache = runcache.New()
+	}
+
+	digests := make(map[string]*digest.Payload)
+	skipped := make(map[string]string)
+	dispatcher := outputs.NewDispatcher(emailService)
 	for _, persona := range selectedPersonas {
+		finishPersona()
+		current, currentCheckpoint = nil, nil
+
+		// Skipped digests are not marked as sent, so their items go out in the next window
+		if !s.DebugSkipEmail && !persona.InSendWindow(time.Now()) {
+			log.Printf("Skipping persona %s, outside its send windows %v\n", persona.Name, persona.SendWindows)
+			skipped[persona.Name] = "outside its send windows"
+			continue
+		}
+
 		log.Printf("Processing persona: %s (provider: %s)\n", persona.Name, persona.GetProvider())
+		personaReport := report.Persona(persona.Name)
+		current, currentReport = &persona, personaReport
+
+		var runBudget *budget.Budget
+		if limits := s.RunBudget(); !limits.IsZero() {
+			runBudget = budget.New(limits, usageSoFar)
+		}
+
+		if !s.DebugSkipEmail && needsDefaultRecipients(persona) && len(emailService.Recipients(persona)) == 0 {
+			personaReport.FailWith(runreport.CauseConfig, "no recipients, set recipients in the persona or ANP_EMAIL_TO")
+			continue
+		}
 
 		// Create provider specific to this persona
 		feedProvider, err := createProvider(persona.GetProvider(), persona.Name)
 		if err != nil {
 			log.Printf("Failed to create provider for persona %s: %v\n", persona.Name, err)
+			personaReport.FailWith(runreport.CauseConfig, "create provider: %v", err)
 			continue
 		}
 
+		// Let the RSS provider stop backfilling once it reaches entries that were already sent
+		if rssProvider, ok := feedProvider.(*rss.RSSProvider); ok {
+			rssProvider.SetKnownIDs(sentIDs)
+		}
+
 		// Create appropriate URL extractor based on provider type
 		var urlExtractor urlextraction.Extractor
 		switch persona.GetProvider() {
@@ -133,32 +446,118 @@ func Run() {
 			urlExtractor = urlextraction.NewRedditExtractor()
 		}
 
-		// 1. Fetch and process feed using FeedProvider
-		entries, err := feeds.FetchAndProcessFeed(feedProvider, urlExtractor, persona, s.DebugRedditDump)
+		blocklist, err := qualityfilter.NewBlocklist(persona.Blocklist)
+		if err != nil {
+			personaReport.FailWith(runreport.CauseConfig, "compile blocklist: %v", err)
+			continue
+		}
+
+		// Dumps and mock feeds are per persona, so only live feeds are shared
+		var sourceProvider feeds.FeedProvider = feedProvider
+		if source := sharedFeedSource(persona); sharedCache != nil && source != "" && !s.DebugMockFeeds && s.ReplaySnapshot == "" && !s.DumpEnabled(persona.GetProvider()) {
+			sourceProvider = feeds.NewSharedProvider(feedProvider, sharedCache, persona.GetProvider(), source)
+		}
+
+		// 1. Fetch and process feed using FeedProvider, dropping blocked entries before their comments and pages are loaded
+		stageStart := time.Now()
+		entries, dropped, err := feeds.FetchAndProcessFeed(sourceProvider, urlExtractor, persona, s.DumpEnabled(persona.GetProvider()), blocklist.Reason)
+		if reporter, ok := feedProvider.(feeds.MetricsReporter); ok {
+			log.Printf("Feed fetch metrics for persona %s: %s", persona.Name, reporter.Metrics())
+		}
+		if errors.Is(err, feeds.ErrNoEntries) && isQueueProvider(persona.GetProvider()) {
+			log.Printf("No new entries for persona %s\n", persona.Name)
+			continue
+		}
 		if err != nil {
 			log.Printf("Failed to process feed for persona %s: %v\n", persona.Name, err)
+			personaReport.FailWith(runreport.CauseFeed, "fetch feed: %v", err)
 			continue
 		}
+		report.Time(persona.Name, "fetch", stageStart)
+		personaReport.Fetched = len(entries) + len(dropped)
+
+		blocked := make([]models.BlockedEntry, 0, len(dropped))
+		for _, d := range dropped {
+			personaReport.Drop(d.Entry.ID, d.Entry.Title, d.Entry.Link.Href, d.Reason)
+			blocked = append(blocked, models.BlockedEntry{ID: d.Entry.ID, Title: d.Entry.Title, Link: d.Entry.Link.Href, Author: d.Entry.Author, Reason: d.Reason})
+		}
+		if len(blocked) > 0 {
+			log.Printf("Blocked %d entries for persona %s\n", len(blocked), persona.Name)
+		}
 
 		// Limit entries if DebugMaxEntries is set
 		if s.DebugMaxEntries > 0 && len(entries) > s.DebugMaxEntries {
 			entries = entries[:s.DebugMaxEntries]
 		}
 
+		// Entries matching the persona's watchlist bypass the quality and relevance filters
+		watch, err := watchlist.Compile(persona.Watchlist)
+		if err != nil {
+			personaReport.FailWith(runreport.CauseConfig, "compile watchlist: %v", err)
+			continue
+		}
+		watched := func(entry feeds.Entry) bool { return len(watch.Match(entry.Title, entry.Content)) > 0 }
+
 		// 2. Filter entries with quality filter (use persona-specific threshold)
 		threshold := persona.GetCommentThreshold(s.QualityFilterThreshold)
-		entries = qualityfilter.Filter(entries, threshold)
+		filtered := qualityfilter.Protect(entries, qualityfilter.Filter(entries, threshold), watched)
+		personaReport.DropMissing(entries, filtered, fmt.Sprintf("fewer than %d comments", threshold))
+		entries = filtered
+
+		// Drop videos with too few views or likes
+		if persona.MinViews > 0 || persona.MinLikes > 0 {
+			filtered := qualityfilter.Protect(entries, qualityfilter.FilterPopularity(entries, persona.MinViews, persona.MinLikes), watched)
+			personaReport.DropMissing(entries, filtered, fmt.Sprintf("fewer than %d views or %d likes", persona.MinViews, persona.MinLikes))
+			entries = filtered
+		}
+
+		// Drop image-only posts before any vision or LLM calls are made
+		if persona.ExcludeImageOnly {
+			filtered := qualityfilter.Protect(entries, qualityfilter.FilterImageOnly(entries, persona.ImageOnlyCommentThreshold), watched)
+			log.Printf("Excluded %d image-only entries for persona %s\n", len(entries)-len(filtered), persona.Name)
+			personaReport.DropMissing(entries, filtered, "image-only post")
+			entries = filtered
+		}
+
+		// A persona's first run would otherwise summarize the whole feed backlog; send a short starter digest instead
+		starter := false
+		if s.FirstRunMaxEntries > 0 && len(entries) > s.FirstRunMaxEntries {
+			firstRun, err := isFirstRun(itemStore, persona.Name, entries, sentIDs)
+			if err != nil {
+				log.Printf("Warning: could not check for a first run of persona %s: %v", persona.Name, err)
+			} else if firstRun {
+				log.Printf("First run for persona %s, only processing the top %d of %d entries\n", persona.Name, s.FirstRunMaxEntries, len(entries))
+				personaReport.DropMissing(entries, entries[:s.FirstRunMaxEntries], "beyond the first run cap")
+				entries = entries[:s.FirstRunMaxEntries]
+				starter = true
+			}
+		}
+
+		if deferred := deferredEntries.Entries(persona.Name); len(deferred) > 0 && !mockLLM {
+			entries = budget.Merge(deferred, entries, sentIDs)
+			log.Printf("Processing %d entries deferred by earlier runs of persona %s first\n", len(deferred), persona.Name)
+		}
+
+		personaReport.Filtered = len(entries)
 
 		// Store all raw inputs for benchmarking
 		var benchmarkData models.RunData
 		var items []models.Item
 
 		// 3. Process entries with LLM
-		if !s.DebugMockLLM {
+		if !mockLLM {
 			log.Println("Sending to LLM")
 			systemPrompt, err := prompts.ComposePrompt(persona, "")
 			if err != nil {
 				log.Printf("Could not compose prompt for persona %s: %v\n", persona.Name, err)
+				personaReport.FailWith(runreport.CauseConfig, "compose prompt: %v", err)
+				continue
+			}
+			log.Printf("System prompt for persona %s is about %d tokens\n", persona.Name, tokens.Estimate(systemPrompt))
+
+			megathreads, megathreadPrompt, err := megathreadSettings(persona)
+			if err != nil {
+				personaReport.FailWith(runreport.CauseConfig, "%v", err)
 				continue
 			}
 
@@ -169,8 +568,21 @@ func Run() {
 				MaxRetries:           llm.DefaultEntryProcessConfig.MaxRetries,
 				MaxBackoff:           llm.DefaultEntryProcessConfig.MaxBackoff,
 				ImageEnabled:         s.LlmImageEnabled,
+				ImageConcurrency:     s.LlmImageConcurrency,
+				ImageBatchSize:       s.LlmImageBatchSize,
 				URLSummaryEnabled:    s.LlmUrlSummaryEnabled,
 				DebugOutputBenchmark: s.DebugOutputBenchmark,
+				HuggingFaceEnabled:   s.HuggingFaceEnrichmentEnabled,
+				TwitterEnabled:       s.TwitterUnrollEnabled,
+				ResolveShortLinks:    s.ResolveShortLinks,
+				ArchiveEnabled:       s.ArchiveFallbackEnabled,
+				DenyDomains:          s.FetchDenyDomains,
+				AllowDomains:         s.FetchAllowDomains,
+				FailurePlaceholders:  s.FailedItemPlaceholders,
+				ContextTokens:        s.LlmContextTokens,
+				VerifyReprompt:       s.LlmVerifyReprompt,
+				DegradeAfterFailures: s.DegradeAfterFailures,
+				EntryTimeout:         time.Duration(s.EntryTimeoutSeconds) * time.Second,
 			}
 
 			// Create retry config from entry process config
@@ -182,8 +594,26 @@ func Run() {
 			}
 
 			// Initialize dependencies for the processor
-			urlFetcher := fetcher.NewHTTPFetcher(nil, retryConfig, fetcher.DefaultUserAgent)
-			imageFetcher := &httputil.DefaultImageFetcher{}
+			httpFetcher := fetcher.NewHTTPFetcher(nil, retryConfig, fetcher.DefaultUserAgent)
+			politeness := fetcher.DefaultPolitenessConfig
+			politeness.RespectRobots = s.FetchRespectRobots
+			politeness.MinDomainInterval = time.Duration(s.FetchDomainIntervalMs) * time.Millisecond
+			politeness.MaxConcurrentPerDomain = s.FetchDomainConcurrency
+			httpFetcher.SetPoliteness(politeness)
+			var urlFetcher fetcher.Fetcher = httpFetcher
+			if s.ReplaySnapshot != "" {
+				urlFetcher = fetcher.NewReplayFetcher(filepath.Join(feedDataDir, providers.SnapshotURLsDir))
+			} else if s.DumpSnapshots {
+				recorder, err := fetcher.NewRecordingFetcher(httpFetcher, filepath.Join(dumpDir, providers.SnapshotURLsDir))
+				if err != nil {
+					log.Printf("Warning: fetched pages will not be recorded: %v", err)
+				} else {
+					urlFetcher = recorder
+				}
+			}
+			imagePrep := imageprep.DefaultOptions
+			imagePrep.MaxDimension = s.LlmImageMaxDimension
+			imageFetcher := &httputil.DefaultImageFetcher{Preprocess: imagePrep}
 			articleExtractor := &contentextractor.DefaultArticleExtractor{}
 
 			// Initialize the processor with the dependencies
@@ -196,13 +626,84 @@ func Run() {
 				urlExtractor,
 				imageFetcher,
 			)
+			processor.SetFailedURLStore(failedURLs)
+			processor.SetQuarantine(quarantined)
+			processor.SetRunCache(sharedCache)
+			processor.SetBudget(runBudget)
+			processor.SetMegathreads(megathreads, megathreadPrompt)
+
+			currentCheckpoint, err = checkpoint.Open(filepath.Join(sentLogBase, "checkpoints"), persona.Name, *resumeFlag)
+			if err != nil {
+				log.Printf("Warning: entries will not be checkpointed: %v", err)
+			} else if *resumeFlag && currentCheckpoint.Len() > 0 {
+				log.Printf("Resuming persona %s with %d entries processed by an earlier run\n", persona.Name, currentCheckpoint.Len())
+			}
+			processor.SetCheckpoint(currentCheckpoint)
+
+			if s.OcrEnabled && s.LlmImageEnabled {
+				recognizer, err := ocr.NewTesseract(s.OcrTesseractPath, s.OcrLanguages)
+				if err != nil {
+					log.Printf("Warning: OCR disabled: %v", err)
+				} else {
+					processor.SetOCR(recognizer)
+				}
+			}
+
+			var siteMeta *sitemeta.Cache
+			if s.SiteMetadataEnabled {
+				siteMeta, err = sitemeta.Load(filepath.Join(sentLogBase, "site_metadata.json"), sitemeta.DefaultTTL, urlFetcher)
+				if err != nil {
+					log.Printf("Warning: could not load site metadata cache: %v", err)
+				}
+				processor.SetSiteMetadataCache(siteMeta)
+			}
 
 			// Process the entries using the processor
+			stageStart = time.Now()
 			items, benchmarkData, err = processor.ProcessEntries(systemPrompt, entries, persona)
+			stats := processor.Stats()
+			personaReport.Failed, personaReport.Retries = stats.FailedEntries, stats.Retries
+			personaReport.Degraded = stats.Degraded
+			for _, err := range stats.Errors {
+				personaReport.Error(err)
+			}
+			for _, q := range stats.Quarantined {
+				personaReport.Quarantine(q.Entry.ID, q.Entry.Title, q.Entry.Link.Href,
+					fmt.Sprintf("quarantined after failing %d runs in a row: %s", q.Record.Failures, q.Record.LastError))
+			}
+			if deferredEntries != nil {
+				deferred, reason := processor.Deferred()
+				if len(deferred) > 0 {
+					personaReport.Deferred = len(deferred)
+					deferredEntries.Defer(persona.Name, deferred, reason.Error())
+				} else {
+					deferredEntries.Defer(persona.Name, nil, "")
+				}
+				if err := deferredEntries.Save(); err != nil {
+					log.Printf("Warning: could not persist deferred entries: %v", err)
+				}
+			}
+			if failedURLs != nil {
+				if err := failedURLs.Save(); err != nil {
+					log.Printf("Warning: could not persist failed URL store: %v", err)
+				}
+			}
+			if quarantined != nil {
+				if err := quarantined.Save(); err != nil {
+					log.Printf("Warning: could not persist entry quarantine: %v", err)
+				}
+			}
+			if siteMeta != nil {
+				if err := siteMeta.Save(); err != nil {
+					log.Printf("Warning: could not persist site metadata cache: %v", err)
+				}
+			}
 			if err != nil {
 				log.Printf("Could not process entries with LLM for persona %s: %v\n", persona.Name, err)
+				personaReport.FailWith(runreport.CauseLLM, "process entries: %v", err)
 				continue
 			}
+			report.Time(persona.Name, "process entries", stageStart)
 		} else {
 			log.Println("Loading fake LLM response")
 			items = GetMockLLMResponse()
@@ -212,9 +713,93 @@ func Run() {
 			err = nil
 		}
 
+		personaReport.Processed = len(items)
+		benchmarkData.Blocked = blocked
+
+		// Flag watched items, which are kept by the relevance and importance filters
+		for i := range items {
+			items[i].Watchlist = watch.Match(items[i].Entry.Title, items[i].Entry.Content)
+		}
+
+		// Items the LLM was unsure of are judged again or listed apart, as the persona says
+		if persona.Unsure.MinConfidence > 0 && !mockLLM {
+			stageStart = time.Now()
+			asked, errs := llm.Triage(triageClient, items, persona)
+			for _, err := range errs {
+				personaReport.Error(err)
+			}
+			personaReport.Asked = asked
+			if asked > 0 {
+				report.Time(persona.Name, "second opinions", stageStart)
+			}
+		}
+
 		// 6. Filter for relevant items
 		relevantItems := llm.FilterRelevantItems(items)
-		relevantItems = filterUnsentItems(relevantItems, sentIDs)
+		for _, item := range items {
+			if !item.IsRelevant && !item.Unsure && len(item.Watchlist) == 0 {
+				personaReport.Drop(item.ID, item.Title, item.Link, "not relevant: "+item.RelevanceToCriteria)
+			}
+		}
+		if sentContent != nil {
+			markUpdated(relevantItems, sentIDs, sentContent, s.EditMinChange)
+		}
+		unsentItems := filterUnsentItems(relevantItems, sentIDs)
+		for _, item := range relevantItems {
+			if wasSent(item, sentIDs) && !item.Updated {
+				personaReport.Drop(item.ID, item.Title, item.Link, "already sent")
+			}
+		}
+		relevantItems = unsentItems
+
+		// Drop relevant items the LLM scored as too minor to send
+		if minScore := persona.GetMinImportanceScore(s.MinImportanceScore); minScore > 0 {
+			important := llm.FilterImportantItems(relevantItems, minScore)
+			for _, item := range relevantItems {
+				if item.ImportanceScore != 0 && item.ImportanceScore < minScore && len(item.Watchlist) == 0 {
+					personaReport.Drop(item.ID, item.Title, item.Link, fmt.Sprintf("importance %d below %d: %s", item.ImportanceScore, minScore, item.ImportanceReason))
+				}
+			}
+			relevantItems = important
+		}
+		personaReport.Relevant = len(relevantItems)
+		personaReport.Unsure = len(relevantItems) - len(llm.SureItems(relevantItems))
+
+		// Export every processed item with the decision made about it, for analysis across runs
+		if s.AnalyticsExportPath != "" {
+			records := analytics.Records(time.Now(), persona.Name, benchmarkData, items, relevantItems, sentIDs)
+			if err := analytics.Append(s.AnalyticsExportPath, records); err != nil {
+				log.Printf("Warning: could not export processed items for analysis: %v", err)
+			}
+		}
+
+		// Compare this run's topics with previous runs before recording it in the history
+		var risingTopics []models.RisingTopic
+		if trendHistory != nil {
+			snapshot := trends.NewSnapshot(items, time.Now())
+			risingTopics = trends.Detect(snapshot, trendHistory.Snapshots(persona.Name), relevantItems, trends.DefaultOptions)
+			trendHistory.Add(persona.Name, snapshot)
+			if err := trendHistory.Save(); err != nil {
+				log.Printf("Warning: could not persist trend history: %v", err)
+			}
+		}
+
+		// Items held by earlier runs go out with this digest, unless it is held in turn
+		relevantItems = carryover.Merge(heldItems.Items(persona.Name), relevantItems, sentIDs)
+		if !s.DebugSkipEmail && len(relevantItems) > 0 {
+			if reason := persona.HoldReason(len(relevantItems), time.Now()); reason != "" {
+				log.Printf("Holding %d items of persona %s for the next digest, %s\n", len(relevantItems), persona.Name, reason)
+				personaReport.Held, personaReport.HeldReason = len(relevantItems), reason
+				if heldItems != nil {
+					heldItems.Hold(persona.Name, relevantItems, reason)
+					if err := heldItems.Save(); err != nil {
+						log.Printf("Warning: could not persist held items: %v", err)
+					}
+				}
+				continue
+			}
+		}
+
 		if len(relevantItems) == 0 {
 			log.Println("no items to render as an email")
 			continue
@@ -222,54 +807,327 @@ func Run() {
 
 		// 9. Generate summary for relevant items
 		var summaryResponse *models.SummaryResponse
-		if !s.DebugMockLLM {
-			summaryResponse, err = llm.GenerateSummary(openaiClient, relevantItems, persona)
+		if !mockLLM {
+			stageStart = time.Now()
+			// The overview is written from the items the LLM was sure of, unless there are none
+			summaryItems := llm.SureItems(relevantItems)
+			if len(summaryItems) == 0 {
+				summaryItems = relevantItems
+			}
+			summaryResponse, err = llm.GenerateSummary(openaiClient, summaryItems, persona)
 			if err != nil {
 				log.Printf("Could not generate summary for persona %s: %v\n", persona.Name, err)
+				personaReport.FailWith(runreport.CauseLLM, "generate summary: %v", err)
 				continue
 			}
+			report.Time(persona.Name, "summary", stageStart)
 		} else {
 			// Mock summary for debug mode
 			summaryResponse = GetMockSummaryResponse(relevantItems)
 		}
 
+		benchmarkData.Violations = append(benchmarkData.Violations, llm.VerifySummary(summaryResponse, relevantItems)...)
+		summaryResponse.RisingTopics = risingTopics
+		summaryResponse.Starter = starter
+		summaryResponse.Degraded = benchmarkData.Degraded
+
+		payload := digest.New(persona.Name, relevantItems, summaryResponse, time.Now())
+		digests[persona.Name] = &payload
+		if s.DigestOutputDir != "" {
+			path, err := digest.WriteFile(s.DigestOutputDir, payload)
+			if err != nil {
+				log.Printf("Could not write digest for persona %s: %v\n", persona.Name, err)
+				personaReport.FailWith(runreport.CauseDelivery, "write digest: %v", err)
+			} else {
+				log.Printf("Digest written to %s\n", path)
+			}
+		}
+
 		// Store the overall summary in the benchmark data
 		benchmarkData.OverallSummary = summaryResponse
+		benchmarkData.Locale = persona.Locale
+		benchmarkData.JudgeInstructions = prompts.ComposeJudgeInstructions(persona.Locale)
+		benchmarkData.Tags = models.CountTags(items)
+		benchmarkData.Importance = models.SummarizeImportance(items)
+		for _, name := range []string{"base", "summary"} {
+			if version, ok := prompts.ActiveTemplateVersion(name); ok {
+				benchmarkData.PromptVersions = append(benchmarkData.PromptVersions, version)
+			}
+		}
 
 		// Output benchmark data if requested
 		if s.DebugOutputBenchmark {
-			err := bench.WriteRunDataToDisk(&benchmarkData)
+			err := bench.WriteRunData(context.Background(), runDataStore, &benchmarkData)
 			if err != nil {
 				log.Printf("Error writing benchmark data to disk for persona %s: %v\n", persona.Name, err)
 			}
 		}
 
-		if s.SendBenchmarkToAuditService {
-			err = bench.SubmitRunDataToAuditService(&benchmarkData, s.AuditServiceUrl)
+		if auditClient != nil {
+			err = auditClient.Submit(&benchmarkData)
 			if err != nil {
 				log.Printf("Warning: Failed to submit run data to audit service for persona %s: %v\n", persona.Name, err)
+				personaReport.FailWith(runreport.CauseDelivery, "submit to audit service: %v", err)
 			}
 		}
 
-		// 10. Render and send email
+		// 10. Deliver the digest to the persona's outputs
 		if !s.DebugSkipEmail {
-			err = emailService.RenderAndSend(relevantItems, summaryResponse, persona.Name)
-			if err != nil {
-				log.Printf("Could not send email for persona %s: %v\n", persona.Name, err)
+			stageStart = time.Now()
+			delivered := 0
+			for _, result := range dispatcher.Deliver(outputs.Digest{Persona: persona, Items: relevantItems, Summary: summaryResponse, Payload: payload}) {
+				if result.Err != nil {
+					log.Printf("Could not deliver digest for persona %s to %s: %v\n", persona.Name, result.Output, result.Err)
+					personaReport.FailWith(runreport.CauseDelivery, "deliver %s: %v", result.Output, result.Err)
+					continue
+				}
+				delivered++
+			}
+			// Items are only marked as sent if at least one output received them
+			if delivered == 0 {
 				continue
 			}
-			// Persist newly emailed items so future runs skip them.
+			report.Time(persona.Name, "delivery", stageStart)
+			personaReport.Sent = len(relevantItems)
+			// Persist newly sent items so future runs skip them.
 			for _, item := range relevantItems {
 				if item.ID == "" {
 					continue
 				}
 				sentIDs[item.ID] = struct{}{}
+				// Other personas skip crossposts of the post and the original itself
+				if item.CanonicalID != "" {
+					sentIDs[item.CanonicalID] = struct{}{}
+				}
+				if key := sentLinkKey(item); key != "" {
+					sentIDs[key] = struct{}{}
+				}
+				sentContent.Record(item.ID, edits.Compute(item.Entry.Title, item.Entry.Content))
 			}
 			if err := sentlog.SaveSentIDs(sentLogPath, sentIDs); err != nil {
 				log.Printf("Warning: could not persist sent log: %v", err)
 			}
+			// Keep the sent items so they can be reviewed in later rollups
+			if err := itemStore.Append(persona.Name, relevantItems, time.Now()); err != nil {
+				log.Printf("Warning: could not store sent items: %v", err)
+			}
+			if len(heldItems.Items(persona.Name)) > 0 {
+				heldItems.Release(persona.Name)
+				if err := heldItems.Save(); err != nil {
+					log.Printf("Warning: could not persist held items: %v", err)
+				}
+			}
 		} else {
-			log.Println("Skipping email")
+			log.Println("Skipping delivery")
+		}
+	}
+
+	finishPersona()
+
+	if sentContent != nil {
+		if err := sentContent.Save(); err != nil {
+			log.Printf("Warning: could not persist sent content: %v", err)
+		}
+	}
+
+	if hits, misses := sharedCache.Stats(); hits > 0 {
+		log.Printf("Shared %d of %d feeds, pages, images and summaries between personas\n", hits, hits+misses)
+	}
+
+	report.FinishedAt = time.Now()
+	report.AddUsage(openaiClient.GetModelName(), usageOf(openaiClient))
+	if imageClient != openaiClient {
+		if reporter, ok := imageClient.(openai.UsageReporter); ok {
+			report.AddUsage(imageClient.GetModelName(), reporter.Usage())
+		}
+	}
+	if triageClient != openaiClient {
+		if reporter, ok := triageClient.(openai.UsageReporter); ok {
+			report.AddUsage(triageClient.GetModelName(), reporter.Usage())
+		}
+	}
+	sendRunReport(report, emailService, s)
+
+	if dumpStore != nil {
+		uploaded, err := storage.Upload(context.Background(), dumpStore, s.FeedMocksPath, dumpsSince)
+		if err != nil {
+			log.Printf("Warning: could not upload feed dumps to %s: %v", dumpStore, err)
+		} else if uploaded > 0 {
+			log.Printf("Uploaded %d feed dumps to %s\n", uploaded, dumpStore)
+		}
+	}
+
+	runs := runhistory.New(filepath.Join(sentLogBase, "runs"))
+	if err := runs.Save(runhistory.FromReport(report, digests)); err != nil {
+		log.Printf("Warning: could not store run history: %v", err)
+	}
+
+	if s.MetricsURL != "" {
+		exportMetrics(report, s, filepath.Join(sentLogBase, "feedback.jsonl"))
+	}
+
+	return runresult.FromReport(report, skipped)
+}
+
+// megathreadSettings returns the megathread detector of a persona and the prompt megathreads are
+// summarized with, or a nil detector if the persona disabled megathreads
+func megathreadSettings(p persona.Persona) (*megathread.Detector, string, error) {
+	detector, err := megathread.New(p.Megathreads)
+	if err != nil || detector == nil {
+		return nil, "", err
+	}
+	prompt, err := prompts.ComposeMegathreadPrompt(p)
+	if err != nil {
+		return nil, "", fmt.Errorf("compose megathread prompt: %w", err)
+	}
+	return detector, prompt, nil
+}
+
+// setupFailed logs why a run could not start and returns its result
+func setupFailed(startTime time.Time, err error) runresult.Result {
+	log.Printf("Could not start the run: %v", err)
+	return runresult.Setup(startTime, err)
+}
+
+// newStores returns where run data is written, and the object storage feed dumps are copied to.
+// With the local backend run data stays in the benchmark directory and there is no dump store.
+func newStores(s *specification.Specification) (runData storage.Store, dumps storage.Store, err error) {
+	if s.StorageBackend != "s3" {
+		return storage.NewLocal(s.BenchmarkPath), nil, nil
+	}
+	newS3 := func(prefix string) (storage.Store, error) {
+		return storage.NewS3(storage.S3Config{
+			Bucket:          s.S3Bucket,
+			Prefix:          path.Join(s.S3Prefix, prefix),
+			Region:          s.S3Region,
+			Endpoint:        s.S3Endpoint,
+			PathStyle:       s.S3PathStyle,
+			AccessKeyID:     s.S3AccessKeyID,
+			SecretAccessKey: s.S3SecretAccessKey,
+			SessionToken:    s.S3SessionToken,
+		})
+	}
+	if runData, err = newS3("benchmarkresults"); err != nil {
+		return nil, nil, err
+	}
+	if dumps, err = newS3("feed_mocks"); err != nil {
+		return nil, nil, err
+	}
+	return runData, dumps, nil
+}
+
+// sharedFeedSource identifies the feed a persona reads, so personas reading the same feed in one
+// run fetch it once. It is empty for providers whose feeds are not shared.
+func sharedFeedSource(p persona.Persona) string {
+	switch p.GetProvider() {
+	case "reddit":
+		return strings.ToLower(p.Subreddit)
+	case "rss":
+		// Backfilling changes how many pages are read
+		return fmt.Sprintf("%s|%d|%s", p.FeedURL, p.BackfillPages, p.PaginationParam)
+	default:
+		return ""
+	}
+}
+
+// isQueueProvider reports whether a provider only returns entries that arrived since the last run,
+// so an empty feed means nothing new rather than a broken feed
+func isQueueProvider(provider string) bool {
+	return provider == "imap" || provider == "ingest"
+}
+
+// needsDefaultRecipients reports whether a persona has an email output that sends to the persona's
+// recipients, rather than to recipients of its own
+func needsDefaultRecipients(p persona.Persona) bool {
+	for _, output := range p.GetOutputs() {
+		if output.Type == persona.OutputEmail && len(output.Recipients) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// exportMetrics pushes the run aggregates to the configured time series database, including
+// reader precision for personas whose digests have been rated
+func exportMetrics(report *runreport.Report, s *specification.Specification, feedbackPath string) {
+	precision := make(map[string]float64)
+	feedback, err := tuning.LoadFeedback(feedbackPath)
+	if err != nil {
+		log.Printf("Warning: could not load feedback for metrics: %v", err)
+	}
+	for _, p := range readerfeedback.Tally(feedback) {
+		precision[p.Persona] = p.Precision
+	}
+
+	if err := metricsexport.New(s.MetricsURL, s.MetricsToken).Export(report, precision); err != nil {
+		log.Printf("Warning: could not export metrics: %v", err)
+	}
+}
+
+// sendRunReport logs the run report and emails it to the operator if an operator address is configured
+func sendRunReport(report *runreport.Report, emailService *email.Service, s *specification.Specification) {
+	log.Printf("Run report:\n%s", report)
+	if s.OperatorEmailTo == "" {
+		return
+	}
+	if err := emailService.SendOperatorReport(s.OperatorEmailTo, report.Subject(), report.String()); err != nil {
+		log.Printf("Warning: could not send operator report: %v", err)
+	}
+}
+
+// isFirstRun reports whether a persona has never been sent a digest: nothing is stored for it and
+// none of its entries are in the sent log. Checking the sent log too keeps personas that were
+// sent digests before the item store existed from being treated as new.
+func isFirstRun(store *itemstore.Store, personaName string, entries []feeds.Entry, sentIDs map[string]struct{}) (bool, error) {
+	hasItems, err := store.HasItems(personaName)
+	if err != nil || hasItems {
+		return false, err
+	}
+	for _, entry := range entries {
+		if _, ok := sentIDs[entry.ID]; ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// wasSent reports whether an item, the original post of a crosspost or repost, or the page the
+// item links to was sent before
+func wasSent(item models.Item, sentIDs map[string]struct{}) bool {
+	for _, id := range []string{item.ID, item.CanonicalID, sentLinkKey(item)} {
+		if _, ok := sentIDs[id]; ok && id != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// sentLinkKey is the sent log entry of the page an item links to, so the same article reached
+// through another feed or with other tracking parameters counts as sent
+func sentLinkKey(item models.Item) string {
+	if item.Link == "" {
+		return ""
+	}
+	return "link:" + urlnorm.Key(item.Link)
+}
+
+// markUpdated flags the items that were sent before but whose post changed by at least minChange
+// since, so they are sent again as updates. Sent items whose content was not recorded, such as
+// those sent before edits were tracked, are recorded now for later runs to compare against.
+func markUpdated(items []models.Item, sentIDs map[string]struct{}, sentContent *edits.Store, minChange float64) {
+	for i, item := range items {
+		if _, ok := sentIDs[item.ID]; !ok || item.ID == "" {
+			continue
+		}
+		fingerprint := edits.Compute(item.Entry.Title, item.Entry.Content)
+		change, ok := sentContent.Change(item.ID, fingerprint)
+		if !ok {
+			sentContent.Record(item.ID, fingerprint)
+			continue
+		}
+		if change >= minChange {
+			log.Printf("Item %s was edited since it was sent, %.0f%% of it changed, sending it again as an update", item.ID, change*100)
+			items[i].Updated = true
 		}
 	}
 }
@@ -281,7 +1139,7 @@ func filterUnsentItems(items []models.Item, sentIDs map[string]struct{}) []model
 		if item.ID == "" {
 			continue
 		}
-		if _, exists := sentIDs[item.ID]; exists {
+		if wasSent(item, sentIDs) && !item.Updated {
 			sentCount++
 			continue
 		}
@@ -0,0 +1,80 @@
+// Package runcache shares work between the personas of one run. When personas read the same
+// subreddit or link the same article, its feed, pages, image descriptions and summaries are
+// fetched and generated once and reused from memory.
+package runcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Cache holds the results of work by key for the duration of a run. A nil cache caches nothing.
+type Cache struct {
+	mu      sync.Mutex
+	entries map[string]*entry
+	hits    atomic.Int64
+	misses  atomic.Int64
+}
+
+type entry struct {
+	done  chan struct{} // Closed once value and err are set
+	value any
+	err   error
+}
+
+// New creates an empty cache
+func New() *Cache {
+	return &Cache{entries: make(map[string]*entry)}
+}
+
+// Do returns the value cached for key, or computes it with fn and caches it. Concurrent calls for
+// the same key wait for the first one instead of repeating the work. Errors are not cached, so a
+// later call tries again.
+func Do[T any](c *Cache, key string, fn func() (T, error)) (T, error) {
+	if c == nil {
+		return fn()
+	}
+
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok {
+		c.mu.Unlock()
+		<-e.done
+		if e.err == nil {
+			c.hits.Add(1)
+			return e.value.(T), nil
+		}
+		// The call that was waited for failed, so try again
+		return Do(c, key, fn)
+	}
+	e := &entry{done: make(chan struct{})}
+	c.entries[key] = e
+	c.mu.Unlock()
+	c.misses.Add(1)
+
+	value, err := fn()
+	e.value, e.err = value, err
+	if err != nil {
+		c.mu.Lock()
+		delete(c.entries, key)
+		c.mu.Unlock()
+	}
+	close(e.done)
+	return value, err
+}
+
+// Stats returns how many calls were served from the cache and how many did the work
+func (c *Cache) Stats() (hits, misses int64) {
+	if c == nil {
+		return 0, 0
+	}
+	return c.hits.Load(), c.misses.Load()
+}
+
+// Key joins parts into a cache key, hashing it so long inputs such as prompts keep keys short
+func Key(kind string, parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return kind + ":" + hex.EncodeToString(sum[:16])
+}
@@ -0,0 +1,90 @@
+package runcache
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDo(t *testing.T) {
+	c := New()
+	calls := 0
+	fetch := func() (string, error) {
+		calls++
+		return "feed", nil
+	}
+
+	for range 3 {
+		value, err := Do(c, "feed:reddit:localllama", fetch)
+		require.NoError(t, err)
+		assert.Equal(t, "feed", value)
+	}
+	assert.Equal(t, 1, calls)
+
+	_, err := Do(c, "feed:reddit:machinelearning", fetch)
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls, "other keys do their own work")
+
+	hits, misses := c.Stats()
+	assert.Equal(t, int64(2), hits)
+	assert.Equal(t, int64(2), misses)
+}
+
+func TestDo_ErrorsAreNotCached(t *testing.T) {
+	c := New()
+	_, err := Do(c, "page", func() ([]byte, error) { return nil, errors.New("timeout") })
+	require.Error(t, err)
+
+	value, err := Do(c, "page", func() ([]byte, error) { return []byte("<html>"), nil })
+	require.NoError(t, err)
+	assert.Equal(t, "<html>", string(value))
+}
+
+func TestDo_ConcurrentCallsWait(t *testing.T) {
+	c := New()
+	var calls atomic.Int32
+	release := make(chan struct{})
+
+	var wg sync.WaitGroup
+	results := make([]string, 5)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _ = Do(c, "summary", func() (string, error) {
+				calls.Add(1)
+				<-release
+				return "summary", nil
+			})
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, []string{"summary", "summary", "summary", "summary", "summary"}, results)
+}
+
+func TestDo_NilCache(t *testing.T) {
+	var c *Cache
+	calls := 0
+	for range 2 {
+		_, err := Do(c, "key", func() (int, error) { calls++; return calls, nil })
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, calls)
+	hits, misses := c.Stats()
+	assert.Zero(t, hits+misses)
+}
+
+func TestKey(t *testing.T) {
+	assert.Equal(t, Key("summary", "model", "prompt"), Key("summary", "model", "prompt"))
+	assert.NotEqual(t, Key("summary", "model", "prompt"), Key("summary", "modelprompt"))
+	assert.Regexp(t, `^summary:[0-9a-f]{32}$`, Key("summary", "model", "prompt"))
+}
@@ -0,0 +1,179 @@
+// Package runhistory keeps a record of every processing run on disk, so the daemon can list past
+// runs and serve the digests they produced without parsing benchmark files.
+package runhistory
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/digest"
+	"github.com/bakkerme/ai-news-processor/internal/runreport"
+)
+
+// ErrNotFound is returned by Get when no run has the requested ID
+var ErrNotFound = errors.New("run not found")
+
+// Run is the stored record of one processing run
+type Run struct {
+	ID         string    `json:"id"`
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+	Personas   []Persona `json:"personas"`
+	Stages     []Stage   `json:"stages"`
+	Usage      []Usage   `json:"usage"`
+	Cost       float64   `json:"cost"` // Estimated cost of the tokens used, 0 if no pricing is configured
+}
+
+// Persona holds the counts, failures and digest of one persona in a run
+type Persona struct {
+	Name      string          `json:"name"`
+	Fetched   int             `json:"fetched"`
+	Filtered  int             `json:"filtered"`
+	Processed int             `json:"processed"`
+	Relevant  int             `json:"relevant"`
+	Sent      int             `json:"sent"`
+	Failures  []string        `json:"failures,omitempty"`
+	Dropped   []Dropped       `json:"dropped,omitempty"` // Entries left out of the digest and why
+	Digest    *digest.Payload `json:"digest,omitempty"`  // Items and summary, if a digest was produced
+}
+
+// Dropped is a fetched entry that did not make it into the digest
+type Dropped struct {
+	ID     string `json:"id"`
+	Title  string `json:"title"`
+	Link   string `json:"link,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// Stage is the duration of one stage of the pipeline for a persona
+type Stage struct {
+	Persona    string `json:"persona"`
+	Name       string `json:"name"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+// Usage is the token usage of one model over the run
+type Usage struct {
+	Model            string `json:"model"`
+	Calls            int    `json:"calls"`
+	FailedCalls      int    `json:"failedCalls"`
+	PromptTokens     int64  `json:"promptTokens"`
+	CompletionTokens int64  `json:"completionTokens"`
+}
+
+// FromReport builds the record of a finished run from its report and the digests produced per persona
+func FromReport(report *runreport.Report, digests map[string]*digest.Payload) Run {
+	run := Run{
+		ID:         report.StartedAt.UTC().Format("20060102T150405Z"),
+		StartedAt:  report.StartedAt,
+		FinishedAt: report.FinishedAt,
+		Cost:       report.Cost(),
+	}
+	for _, p := range report.Personas {
+		var dropped []Dropped
+		for _, d := range p.Dropped {
+			dropped = append(dropped, Dropped{ID: d.ID, Title: d.Title, Link: d.Link, Reason: d.Reason})
+		}
+		run.Personas = append(run.Personas, Persona{
+			Name:      p.Name,
+			Fetched:   p.Fetched,
+			Filtered:  p.Filtered,
+			Processed: p.Processed,
+			Relevant:  p.Relevant,
+			Sent:      p.Sent,
+			Failures:  p.Failures,
+			Dropped:   dropped,
+			Digest:    digests[p.Name],
+		})
+	}
+	for _, s := range report.Stages {
+		run.Stages = append(run.Stages, Stage{Persona: s.Persona, Name: s.Name, DurationMs: s.Duration.Milliseconds()})
+	}
+	for _, u := range report.Usage {
+		run.Usage = append(run.Usage, Usage{
+			Model:            u.Model,
+			Calls:            u.Calls,
+			FailedCalls:      u.FailedCalls,
+			PromptTokens:     u.PromptTokens,
+			CompletionTokens: u.CompletionTokens,
+		})
+	}
+	return run
+}
+
+// Store keeps one JSON file per run in a directory
+type Store struct {
+	dir string
+}
+
+// New creates a store that keeps its files in dir
+func New(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Save writes a run to the store, replacing any run with the same ID
+func (s *Store) Save(run Run) error {
+	data, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode run: %w", err)
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("could not create run history directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(s.dir, run.ID+".json"), data, 0644); err != nil {
+		return fmt.Errorf("could not write run: %w", err)
+	}
+	return nil
+}
+
+// Get returns the run with the given ID
+func (s *Store) Get(id string) (*Run, error) {
+	// IDs are timestamps, anything else could escape the directory
+	if id == "" || strings.ContainsAny(id, `/\.`) {
+		return nil, ErrNotFound
+	}
+	run, err := s.load(filepath.Join(s.dir, id+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return run, err
+}
+
+// List returns all stored runs, most recent first
+func (s *Store) List() ([]Run, error) {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("could not list runs: %w", err)
+	}
+
+	runs := make([]Run, 0, len(paths))
+	for _, path := range paths {
+		run, err := s.load(path)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, *run)
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].StartedAt.After(runs[j].StartedAt)
+	})
+	return runs, nil
+}
+
+func (s *Store) load(path string) (*Run, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var run Run
+	if err := json.Unmarshal(data, &run); err != nil {
+		return nil, fmt.Errorf("could not parse run %s: %w", filepath.Base(path), err)
+	}
+	return &run, nil
+}
@@ -0,0 +1,73 @@
+package runhistory
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/digest"
+	"github.com/bakkerme/ai-news-processor/internal/openai"
+	"github.com/bakkerme/ai-news-processor/internal/runreport"
+	"github.com/bakkerme/ai-news-processor/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromReport(t *testing.T) {
+	started := time.Date(2025, time.March, 7, 6, 0, 0, 0, time.UTC)
+	report := runreport.New(started, runreport.Pricing{InputPerMillion: 1, OutputPerMillion: 2})
+	report.FinishedAt = started.Add(time.Minute)
+	p := report.Persona("LocalLLaMA")
+	p.Fetched, p.Sent = 25, 3
+	p.Fail("send email: %s", "timeout")
+	p.Drop("b", "Meme", "", "not relevant: meme")
+	report.Persona("Apple")
+	report.Stages = append(report.Stages, runreport.Stage{Persona: "LocalLLaMA", Name: "fetch", Duration: 1500 * time.Millisecond})
+	report.AddUsage("model", openai.Usage{Calls: 4, PromptTokens: 1000000, CompletionTokens: 500000})
+
+	payload := digest.New("LocalLLaMA", []models.Item{{ID: "a", Title: "Item"}}, nil, started)
+	run := FromReport(report, map[string]*digest.Payload{"LocalLLaMA": &payload})
+
+	assert.Equal(t, "20250307T060000Z", run.ID)
+	require.Len(t, run.Personas, 2)
+	assert.Equal(t, 25, run.Personas[0].Fetched)
+	assert.Equal(t, []string{"send email: timeout"}, run.Personas[0].Failures)
+	assert.Equal(t, []Dropped{{ID: "b", Title: "Meme", Reason: "not relevant: meme"}}, run.Personas[0].Dropped)
+	assert.Same(t, &payload, run.Personas[0].Digest)
+	assert.Nil(t, run.Personas[1].Digest)
+	assert.Equal(t, []Stage{{Persona: "LocalLLaMA", Name: "fetch", DurationMs: 1500}}, run.Stages)
+	assert.Equal(t, int64(1000000), run.Usage[0].PromptTokens)
+	assert.InDelta(t, 2.0, run.Cost, 1e-9)
+}
+
+func TestStore(t *testing.T) {
+	store := New(filepath.Join(t.TempDir(), "runs"))
+
+	runs, err := store.List()
+	require.NoError(t, err)
+	assert.Empty(t, runs)
+
+	older := Run{ID: "20250306T060000Z", StartedAt: time.Date(2025, time.March, 6, 6, 0, 0, 0, time.UTC)}
+	newer := Run{ID: "20250307T060000Z", StartedAt: time.Date(2025, time.March, 7, 6, 0, 0, 0, time.UTC), Personas: []Persona{{Name: "LocalLLaMA", Sent: 3}}}
+	require.NoError(t, store.Save(older))
+	require.NoError(t, store.Save(newer))
+
+	runs, err = store.List()
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	assert.Equal(t, newer.ID, runs[0].ID, "most recent run first")
+
+	run, err := store.Get(newer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, run.Personas[0].Sent)
+
+	for _, id := range []string{"missing", "", "../runs/" + newer.ID, newer.ID + ".json"} {
+		_, err = store.Get(id)
+		assert.ErrorIs(t, err, ErrNotFound, id)
+	}
+
+	require.NoError(t, os.WriteFile(filepath.Join(store.dir, "broken.json"), []byte("{"), 0644))
+	_, err = store.List()
+	assert.ErrorContains(t, err, "broken.json")
+}
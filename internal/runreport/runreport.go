@@ -0,0 +1,312 @@
+// Package runreport collects an operator-facing summary of a processing run: what was processed
+// for each persona, what failed, how many tokens were used and which stages took longest.
+package runreport
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
+	"github.com/bakkerme/ai-news-processor/internal/openai"
+)
+
+// SlowestStageCount is the number of stages listed in the slowest stages section
+const SlowestStageCount = 5
+
+// MaxListedErrors is the number of entry errors listed for each persona
+const MaxListedErrors = 5
+
+// PersonaReport holds the counts and failures for one persona
+type PersonaReport struct {
+	Name        string
+	Fetched     int // Entries returned by the feed provider
+	Filtered    int // Entries left after quality filtering
+	Processed   int // Items returned by the LLM
+	Relevant    int // Relevant items that had not been sent before
+	Sent        int // Items included in the sent digest
+	Deferred    int // Entries left for the next run because the run budget was exceeded
+	Held        int // Relevant items held for the next digest, in quiet hours or below min_items
+	HeldReason  string
+	Unsure      int // Items the LLM was unsure of, listed apart in the digest
+	Asked       int // Unsure items judged again by a second opinion
+	Failed      int // Entries the LLM could not process
+	Retries     int // LLM requests that were retried
+	Failures    []string
+	Cause       Cause    // What caused the first failure, if it was recorded with FailWith
+	Errors      []string // Errors of single entries, which do not fail the persona
+	Degraded    []string // Enrichments skipped after repeated failures, such as "images" or "links"
+	Dropped     []DroppedEntry
+	Quarantined []string     // Entries skipped after failing processing too many runs in a row, with the reason
+	Usage       openai.Usage // LLM usage of this persona over all models
+}
+
+// DroppedEntry is a fetched entry that did not make it into the digest
+type DroppedEntry struct {
+	ID     string
+	Title  string
+	Link   string
+	Reason string
+}
+
+// Cause is what made a persona fail, so a broken feed can be told apart from an unreachable LLM
+type Cause string
+
+const (
+	CauseConfig   Cause = "config"   // The persona, its prompts or its recipients are misconfigured
+	CauseFeed     Cause = "feed"     // The feed could not be fetched
+	CauseLLM      Cause = "llm"      // The LLM could not process the entries or write the summary
+	CauseDelivery Cause = "delivery" // The digest could not be written, submitted or delivered
+)
+
+// Fail records a failure for the persona
+func (p *PersonaReport) Fail(format string, args ...interface{}) {
+	p.Failures = append(p.Failures, fmt.Sprintf(format, args...))
+}
+
+// FailWith records a failure for the persona and what caused it. The cause of the first failure
+// is kept, as later failures usually follow from it.
+func (p *PersonaReport) FailWith(cause Cause, format string, args ...interface{}) {
+	if len(p.Failures) == 0 {
+		p.Cause = cause
+	}
+	p.Fail(format, args...)
+}
+
+// Error records an error of a single entry
+func (p *PersonaReport) Error(err error) {
+	p.Errors = append(p.Errors, err.Error())
+}
+
+// Drop records why an entry was left out of the digest
+func (p *PersonaReport) Drop(id, title, link, reason string) {
+	p.Dropped = append(p.Dropped, DroppedEntry{ID: id, Title: title, Link: link, Reason: reason})
+}
+
+// Quarantine records an entry skipped because it failed processing too many runs in a row. It is
+// dropped from the digest and listed in the report, as it only returns once it is forgotten.
+func (p *PersonaReport) Quarantine(id, title, link, reason string) {
+	p.Drop(id, title, link, reason)
+	p.Quarantined = append(p.Quarantined, fmt.Sprintf("%s (%s): %s", title, id, reason))
+}
+
+// DropMissing records every entry of before that is not in after, such as the entries removed by a filter
+func (p *PersonaReport) DropMissing(before, after []feeds.Entry, reason string) {
+	kept := make(map[string]struct{}, len(after))
+	for _, entry := range after {
+		kept[entry.ID] = struct{}{}
+	}
+	for _, entry := range before {
+		if _, ok := kept[entry.ID]; !ok {
+			p.Drop(entry.ID, entry.Title, entry.Link.Href, reason)
+		}
+	}
+}
+
+// Stage is the duration of one stage of the pipeline for a persona
+type Stage struct {
+	Persona  string
+	Name     string
+	Duration time.Duration
+}
+
+// ModelUsage is the token usage of one model over the run
+type ModelUsage struct {
+	Model string
+	openai.Usage
+}
+
+// Pricing is the cost of a model in currency units per million tokens
+type Pricing struct {
+	InputPerMillion  float64
+	OutputPerMillion float64
+}
+
+// Cost returns the estimated cost of usage
+func (p Pricing) Cost(usage openai.Usage) float64 {
+	return float64(usage.PromptTokens)/1e6*p.InputPerMillion + float64(usage.CompletionTokens)/1e6*p.OutputPerMillion
+}
+
+// Report is the summary of a run. It is not safe for concurrent use.
+type Report struct {
+	StartedAt  time.Time
+	FinishedAt time.Time
+	NoDelivery bool // The run delivers no digests by design, such as a megathread refresh or a run that skips email
+	Personas   []*PersonaReport
+	Stages     []Stage
+	Usage      []ModelUsage
+	Pricing    Pricing
+}
+
+// New creates a report for a run started at startedAt
+func New(startedAt time.Time, pricing Pricing) *Report {
+	return &Report{StartedAt: startedAt, Pricing: pricing}
+}
+
+// Persona returns the report for the named persona, adding it if needed
+func (r *Report) Persona(name string) *PersonaReport {
+	for _, p := range r.Personas {
+		if p.Name == name {
+			return p
+		}
+	}
+	p := &PersonaReport{Name: name}
+	r.Personas = append(r.Personas, p)
+	return p
+}
+
+// Time records a stage of a persona that started at start and has just finished
+func (r *Report) Time(persona string, stage string, start time.Time) {
+	r.Stages = append(r.Stages, Stage{Persona: persona, Name: stage, Duration: time.Since(start)})
+}
+
+// AddUsage records the token usage of a model. Usage for a model that was already added is summed,
+// and models that were never called are left out.
+func (r *Report) AddUsage(model string, usage openai.Usage) {
+	if usage.Calls == 0 && usage.CachedCalls == 0 {
+		return
+	}
+	for i := range r.Usage {
+		if r.Usage[i].Model == model {
+			r.Usage[i].Calls += usage.Calls
+			r.Usage[i].FailedCalls += usage.FailedCalls
+			r.Usage[i].PromptTokens += usage.PromptTokens
+			r.Usage[i].CompletionTokens += usage.CompletionTokens
+			r.Usage[i].CachedCalls += usage.CachedCalls
+			return
+		}
+	}
+	r.Usage = append(r.Usage, ModelUsage{Model: model, Usage: usage})
+}
+
+// Failures returns the total number of failures over all personas
+func (r *Report) Failures() int {
+	count := 0
+	for _, p := range r.Personas {
+		count += len(p.Failures)
+	}
+	return count
+}
+
+// Cost returns the estimated cost of the tokens used, or 0 if no pricing is configured
+func (r *Report) Cost() float64 {
+	var cost float64
+	for _, u := range r.Usage {
+		cost += r.Pricing.Cost(u.Usage)
+	}
+	return cost
+}
+
+// StagesOf returns the stages of a persona in the order they ran
+func (r *Report) StagesOf(persona string) []Stage {
+	var stages []Stage
+	for _, s := range r.Stages {
+		if s.Persona == persona {
+			stages = append(stages, s)
+		}
+	}
+	return stages
+}
+
+// SlowestStages returns up to n stages, longest first
+func (r *Report) SlowestStages(n int) []Stage {
+	stages := make([]Stage, len(r.Stages))
+	copy(stages, r.Stages)
+	sort.SliceStable(stages, func(i, j int) bool {
+		return stages[i].Duration > stages[j].Duration
+	})
+	if len(stages) > n {
+		stages = stages[:n]
+	}
+	return stages
+}
+
+// Subject returns a one-line summary suitable for an email subject
+func (r *Report) Subject() string {
+	status := "OK"
+	if failures := r.Failures(); failures == 1 {
+		status = "1 failure"
+	} else if failures > 1 {
+		status = fmt.Sprintf("%d failures", failures)
+	}
+	return fmt.Sprintf("AI News Processor run %s: %d personas, %s", r.StartedAt.Format("2006-01-02 15:04"), len(r.Personas), status)
+}
+
+// String renders the report as plain text
+func (r *Report) String() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Run started %s, took %s\n", r.StartedAt.Format(time.RFC3339), r.FinishedAt.Sub(r.StartedAt).Round(time.Second))
+	fmt.Fprintf(&b, "Personas processed: %d, failures: %d\n", len(r.Personas), r.Failures())
+
+	b.WriteString("\nPersonas\n")
+	for _, p := range r.Personas {
+		fmt.Fprintf(&b, "  %s: %d fetched, %d after filtering, %d processed, %d new relevant, %d sent\n",
+			p.Name, p.Fetched, p.Filtered, p.Processed, p.Relevant, p.Sent)
+		for _, failure := range p.Failures {
+			fmt.Fprintf(&b, "    failed: %s\n", failure)
+		}
+		if p.Deferred > 0 {
+			fmt.Fprintf(&b, "    deferred: %d entries to the next run\n", p.Deferred)
+		}
+		if p.Held > 0 {
+			fmt.Fprintf(&b, "    held: %d items for the next digest, %s\n", p.Held, p.HeldReason)
+		}
+		if p.Unsure > 0 || p.Asked > 0 {
+			fmt.Fprintf(&b, "    unsure: %d items listed apart, %d second opinions asked\n", p.Unsure, p.Asked)
+		}
+		if p.Failed > 0 || p.Retries > 0 {
+			fmt.Fprintf(&b, "    %d entries failed processing, %d LLM requests retried\n", p.Failed, p.Retries)
+		}
+		if len(p.Degraded) > 0 {
+			fmt.Fprintf(&b, "    degraded: continued without %s after repeated failures\n", strings.Join(p.Degraded, " and "))
+		}
+		for _, entry := range p.Quarantined {
+			fmt.Fprintf(&b, "    quarantined: %s\n", entry)
+		}
+		for i, err := range p.Errors {
+			if i == MaxListedErrors {
+				fmt.Fprintf(&b, "    ... and %d more errors\n", len(p.Errors)-i)
+				break
+			}
+			fmt.Fprintf(&b, "    error: %s\n", err)
+		}
+		if stages := r.StagesOf(p.Name); len(stages) > 0 {
+			timings := make([]string, len(stages))
+			for i, s := range stages {
+				timings[i] = fmt.Sprintf("%s %s", s.Name, s.Duration.Round(time.Millisecond))
+			}
+			fmt.Fprintf(&b, "    timing: %s\n", strings.Join(timings, ", "))
+		}
+	}
+
+	if len(r.Usage) > 0 {
+		b.WriteString("\nLLM usage\n")
+		var total openai.Usage
+		for _, u := range r.Usage {
+			fmt.Fprintf(&b, "  %s: %d calls (%d failed), %d input + %d output = %d tokens",
+				u.Model, u.Calls, u.FailedCalls, u.PromptTokens, u.CompletionTokens, u.TotalTokens())
+			if u.CachedCalls > 0 {
+				fmt.Fprintf(&b, ", %d served from cache", u.CachedCalls)
+			}
+			b.WriteString("\n")
+			total.PromptTokens += u.PromptTokens
+			total.CompletionTokens += u.CompletionTokens
+		}
+		fmt.Fprintf(&b, "  Total: %d tokens", total.TotalTokens())
+		if r.Pricing != (Pricing{}) {
+			fmt.Fprintf(&b, ", estimated cost %.4f", r.Cost())
+		}
+		b.WriteString("\n")
+	}
+
+	if slowest := r.SlowestStages(SlowestStageCount); len(slowest) > 0 {
+		b.WriteString("\nSlowest stages\n")
+		for _, s := range slowest {
+			fmt.Fprintf(&b, "  %s / %s: %s\n", s.Persona, s.Name, s.Duration.Round(time.Millisecond))
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,120 @@
+package runreport
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/feeds"
+	"github.com/bakkerme/ai-news-processor/internal/openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReport(t *testing.T) {
+	start := time.Date(2025, time.March, 7, 6, 0, 0, 0, time.UTC)
+	r := New(start, Pricing{InputPerMillion: 1, OutputPerMillion: 4})
+	r.FinishedAt = start.Add(95 * time.Second)
+
+	llama := r.Persona("LocalLLaMA")
+	llama.Fetched, llama.Filtered, llama.Processed, llama.Relevant, llama.Sent = 50, 30, 30, 8, 8
+	rss := r.Persona("Blogs")
+	rss.Fail("fetch feed: %v", errors.New("timeout"))
+	require.Same(t, llama, r.Persona("LocalLLaMA"), "personas are looked up by name")
+
+	r.Stages = []Stage{
+		{Persona: "LocalLLaMA", Name: "fetch", Duration: 2 * time.Second},
+		{Persona: "LocalLLaMA", Name: "process entries", Duration: 80 * time.Second},
+		{Persona: "LocalLLaMA", Name: "summary", Duration: 10 * time.Second},
+	}
+
+	r.AddUsage("main", openai.Usage{Calls: 30, PromptTokens: 600000, CompletionTokens: 50000})
+	r.AddUsage("main", openai.Usage{Calls: 1, FailedCalls: 1})
+	r.AddUsage("vision", openai.Usage{})
+	require.Len(t, r.Usage, 1, "usage is merged per model and unused models are skipped")
+	assert.Equal(t, 31, r.Usage[0].Calls)
+
+	assert.Equal(t, 1, r.Failures())
+	assert.InDelta(t, 0.8, r.Cost(), 1e-9)
+
+	slowest := r.SlowestStages(2)
+	require.Len(t, slowest, 2)
+	assert.Equal(t, "process entries", slowest[0].Name)
+	assert.Equal(t, "summary", slowest[1].Name)
+	assert.Equal(t, "fetch", r.Stages[0].Name, "SlowestStages does not reorder the report")
+
+	assert.Equal(t, "AI News Processor run 2025-03-07 06:00: 2 personas, 1 failure", r.Subject())
+
+	text := r.String()
+	assert.Contains(t, text, "took 1m35s")
+	assert.Contains(t, text, "LocalLLaMA: 50 fetched, 30 after filtering, 30 processed, 8 new relevant, 8 sent")
+	assert.Contains(t, text, "failed: fetch feed: timeout")
+	assert.Contains(t, text, "main: 31 calls (1 failed), 600000 input + 50000 output = 650000 tokens")
+	assert.Contains(t, text, "Total: 650000 tokens, estimated cost 0.8000")
+	assert.Contains(t, text, "LocalLLaMA / process entries: 1m20s")
+	assert.Contains(t, text, "    timing: fetch 2s, process entries 1m20s, summary 10s\n")
+	assert.NotContains(t, text, "failed processing", "personas without entry failures or retries leave the line out")
+}
+
+func TestReport_EntryErrors(t *testing.T) {
+	r := New(time.Now(), Pricing{})
+	p := r.Persona("LocalLLaMA")
+	p.Failed, p.Retries = 2, 7
+	p.Degraded = []string{"images", "links"}
+	for i := 0; i < MaxListedErrors+2; i++ {
+		p.Error(errors.New("entry failed"))
+	}
+
+	text := r.String()
+	assert.Contains(t, text, "    2 entries failed processing, 7 LLM requests retried\n")
+	assert.Contains(t, text, "    error: entry failed\n")
+	assert.Contains(t, text, "    ... and 2 more errors\n")
+	assert.Contains(t, text, "    degraded: continued without images and links after repeated failures\n")
+	assert.Equal(t, 0, r.Failures(), "entry errors do not fail the persona")
+}
+
+func TestPersonaReport_Quarantine(t *testing.T) {
+	r := New(time.Now(), Pricing{})
+	p := r.Persona("LocalLLaMA")
+	p.Quarantine("t3_abc", "Broken post", "https://example.com/abc", "quarantined after failing 3 runs in a row: model overloaded")
+
+	require.Len(t, p.Dropped, 1)
+	assert.Equal(t, "t3_abc", p.Dropped[0].ID)
+	assert.Contains(t, r.String(), "    quarantined: Broken post (t3_abc): quarantined after failing 3 runs in a row: model overloaded\n")
+	assert.Equal(t, 0, r.Failures(), "quarantined entries do not fail the persona")
+}
+
+func TestPersonaReport_FailWith(t *testing.T) {
+	p := &PersonaReport{Name: "LocalLLaMA"}
+	p.FailWith(CauseLLM, "process entries: %v", errors.New("connection refused"))
+	p.FailWith(CauseDelivery, "deliver email: %v", errors.New("smtp down"))
+	p.Fail("something else")
+
+	assert.Equal(t, CauseLLM, p.Cause, "the first failure decides the cause")
+	assert.Equal(t, []string{"process entries: connection refused", "deliver email: smtp down", "something else"}, p.Failures)
+}
+
+func TestReport_WithoutPricing(t *testing.T) {
+	r := New(time.Now(), Pricing{})
+	r.AddUsage("main", openai.Usage{Calls: 1, PromptTokens: 10})
+	assert.NotContains(t, r.String(), "estimated cost")
+	assert.Contains(t, r.Subject(), "0 personas, OK")
+}
+
+func TestReport_CachedCalls(t *testing.T) {
+	r := New(time.Now(), Pricing{})
+	r.AddUsage("main", openai.Usage{CachedCalls: 4})
+	r.AddUsage("main", openai.Usage{Calls: 2, PromptTokens: 10, CachedCalls: 1})
+	require.Len(t, r.Usage, 1, "a model answered only from the cache is still reported")
+	assert.Contains(t, r.String(), "main: 2 calls (0 failed), 10 input + 0 output = 10 tokens, 5 served from cache")
+}
+
+func TestPersonaReport_DropMissing(t *testing.T) {
+	p := &PersonaReport{Name: "LocalLLaMA"}
+	before := []feeds.Entry{
+		{ID: "a", Title: "Kept"},
+		{ID: "b", Title: "Dropped", Link: feeds.Link{Href: "https://example.com/b"}},
+	}
+	p.DropMissing(before, before[:1], "fewer than 10 comments")
+	assert.Equal(t, []DroppedEntry{{ID: "b", Title: "Dropped", Link: "https://example.com/b", Reason: "fewer than 10 comments"}}, p.Dropped)
+}
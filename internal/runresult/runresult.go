@@ -0,0 +1,173 @@
+// Package runresult is the machine-readable outcome of a run, for the wrappers and schedulers
+// that start it. The outcome is written as JSON and mapped to the process exit code, so a run
+// that found nothing to send can be told apart from one that could not reach the LLM.
+package runresult
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/runreport"
+)
+
+// Exit codes of a run. 2 is left out, as Go exits with it on a panic or a bad flag.
+const (
+	ExitOK          = 0 // Every persona that ran succeeded and at least one digest was sent, or the run sends none by design
+	ExitSetup       = 1 // The run could not start, such as with an invalid configuration
+	ExitNothingSent = 3 // Every persona that ran succeeded, but none had anything to send or all held their digests
+	ExitPartial     = 4 // Some personas failed, while others succeeded or failed for another cause
+	ExitConfig      = 5 // Every persona that ran failed on its configuration
+	ExitFeed        = 6 // Every persona that ran failed to fetch its feed
+	ExitLLM         = 7 // Every persona that ran failed on the LLM
+	ExitDelivery    = 8 // Every persona that ran failed to deliver its digest
+)
+
+// causeExitCodes are the exit codes of runs where every persona failed with the same cause
+var causeExitCodes = map[runreport.Cause]int{
+	runreport.CauseConfig:   ExitConfig,
+	runreport.CauseFeed:     ExitFeed,
+	runreport.CauseLLM:      ExitLLM,
+	runreport.CauseDelivery: ExitDelivery,
+}
+
+// Status is the outcome of a persona
+type Status string
+
+const (
+	StatusSent    Status = "sent"     // The digest was delivered
+	StatusNoItems Status = "no_items" // Nothing new and relevant was found, so no digest was sent
+	StatusHeld    Status = "held"     // The digest was held for the next one, in quiet hours or below min_items
+	StatusDone    Status = "done"     // The persona ran in a mode that sends no digest, such as a megathread refresh
+	StatusSkipped Status = "skipped"  // The persona was not run, such as outside its send windows
+	StatusFailed  Status = "failed"   // The persona failed; Cause and Errors say why
+)
+
+// Persona is the outcome of one persona
+type Persona struct {
+	Name      string          `json:"name"`
+	Status    Status          `json:"status"`
+	Cause     runreport.Cause `json:"cause,omitempty"`  // What made a failed persona fail
+	Reason    string          `json:"reason,omitempty"` // Why a skipped persona was not run or a held digest was not sent
+	Fetched   int             `json:"fetched"`
+	Filtered  int             `json:"filtered"`
+	Processed int             `json:"processed"`
+	Relevant  int             `json:"relevant"`
+	Sent      int             `json:"sent"`
+	Failed    int             `json:"failedEntries"` // Entries the LLM could not process
+	Deferred  int             `json:"deferredEntries"`
+	Held      int             `json:"heldItems"`
+	Errors    []string        `json:"errors,omitempty"`      // Failures of the persona
+	Entries   []string        `json:"entryErrors,omitempty"` // Errors of single entries, which do not fail the persona
+}
+
+// Result is the outcome of a run
+type Result struct {
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+	ExitCode   int       `json:"exitCode"`
+	Error      string    `json:"error,omitempty"` // Why the run could not start
+	Personas   []Persona `json:"personas"`
+}
+
+// Setup returns the result of a run that could not start
+func Setup(startedAt time.Time, err error) Result {
+	return Result{StartedAt: startedAt, FinishedAt: time.Now(), ExitCode: ExitSetup, Error: err.Error(), Personas: []Persona{}}
+}
+
+// FromReport builds the result of a finished run from its report and the personas it skipped,
+// with the reason each was skipped
+func FromReport(report *runreport.Report, skipped map[string]string) Result {
+	result := Result{StartedAt: report.StartedAt, FinishedAt: report.FinishedAt, Personas: []Persona{}}
+	for _, p := range report.Personas {
+		persona := Persona{
+			Name:      p.Name,
+			Status:    StatusNoItems,
+			Fetched:   p.Fetched,
+			Filtered:  p.Filtered,
+			Processed: p.Processed,
+			Relevant:  p.Relevant,
+			Sent:      p.Sent,
+			Failed:    p.Failed,
+			Deferred:  p.Deferred,
+			Held:      p.Held,
+			Errors:    p.Failures,
+			Entries:   p.Errors,
+		}
+		switch {
+		case len(p.Failures) > 0:
+			persona.Status, persona.Cause = StatusFailed, p.Cause
+		case p.Sent > 0:
+			persona.Status = StatusSent
+		case p.Held > 0:
+			persona.Status, persona.Reason = StatusHeld, p.HeldReason
+		case report.NoDelivery:
+			persona.Status = StatusDone
+		}
+		result.Personas = append(result.Personas, persona)
+	}
+	names := make([]string, 0, len(skipped))
+	for name := range skipped {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		result.Personas = append(result.Personas, Persona{Name: name, Status: StatusSkipped, Reason: skipped[name]})
+	}
+	result.ExitCode = exitCode(result.Personas, report.NoDelivery)
+	return result
+}
+
+// exitCode maps the outcome of the personas to the exit code of the run. A run that delivers no
+// digests by design succeeds without sending one.
+func exitCode(personas []Persona, noDelivery bool) int {
+	sent, succeeded := false, false
+	var causes []runreport.Cause
+	for _, p := range personas {
+		switch p.Status {
+		case StatusFailed:
+			causes = append(causes, p.Cause)
+		case StatusSent:
+			sent, succeeded = true, true
+		case StatusNoItems, StatusHeld, StatusDone:
+			succeeded = true
+		}
+	}
+
+	if len(causes) == 0 {
+		if sent || noDelivery {
+			return ExitOK
+		}
+		return ExitNothingSent
+	}
+	if succeeded {
+		return ExitPartial
+	}
+	for _, cause := range causes[1:] {
+		if cause != causes[0] {
+			return ExitPartial
+		}
+	}
+	if code, ok := causeExitCodes[causes[0]]; ok {
+		return code
+	}
+	return ExitPartial
+}
+
+// WriteFile writes the result to path as JSON
+func WriteFile(path string, result Result) error {
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode run result: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("could not create run result directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("could not write run result: %w", err)
+	}
+	return nil
+}
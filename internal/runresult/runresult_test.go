@@ -0,0 +1,126 @@
+package runresult
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/runreport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromReport(t *testing.T) {
+	start := time.Date(2026, time.October, 16, 7, 0, 0, 0, time.UTC)
+	report := runreport.New(start, runreport.Pricing{})
+	report.FinishedAt = start.Add(time.Minute)
+
+	llama := report.Persona("LocalLLaMA")
+	llama.Fetched, llama.Processed, llama.Relevant, llama.Sent = 20, 20, 5, 5
+	llama.Error(errors.New("entry abc: timeout"))
+	quiet := report.Persona("Quiet")
+	quiet.Fetched, quiet.Processed = 10, 10
+	rust := report.Persona("Rust")
+	rust.FailWith(runreport.CauseFeed, "fetch feed: %v", errors.New("403"))
+	weekly := report.Persona("Weekly")
+	weekly.Relevant, weekly.Held, weekly.HeldReason = 2, 3, "3 items, fewer than 5"
+
+	result := FromReport(report, map[string]string{"Weekend": "outside its send windows"})
+
+	assert.Equal(t, start, result.StartedAt)
+	assert.Equal(t, ExitPartial, result.ExitCode)
+	require.Len(t, result.Personas, 5)
+	assert.Equal(t, Persona{Name: "LocalLLaMA", Status: StatusSent, Fetched: 20, Processed: 20, Relevant: 5, Sent: 5, Entries: []string{"entry abc: timeout"}}, result.Personas[0])
+	assert.Equal(t, StatusNoItems, result.Personas[1].Status)
+	assert.Equal(t, Persona{Name: "Rust", Status: StatusFailed, Cause: runreport.CauseFeed, Errors: []string{"fetch feed: 403"}}, result.Personas[2])
+	assert.Equal(t, Persona{Name: "Weekly", Status: StatusHeld, Reason: "3 items, fewer than 5", Relevant: 2, Held: 3}, result.Personas[3])
+	assert.Equal(t, Persona{Name: "Weekend", Status: StatusSkipped, Reason: "outside its send windows"}, result.Personas[4])
+}
+
+func TestExitCode(t *testing.T) {
+	sent := Persona{Status: StatusSent}
+	noItems := Persona{Status: StatusNoItems}
+	held := Persona{Status: StatusHeld}
+	skipped := Persona{Status: StatusSkipped}
+	failed := func(cause runreport.Cause) Persona { return Persona{Status: StatusFailed, Cause: cause} }
+
+	tests := []struct {
+		name     string
+		personas []Persona
+		want     int
+	}{
+		{"sent", []Persona{sent, noItems, skipped}, ExitOK},
+		{"nothing to send", []Persona{noItems, skipped}, ExitNothingSent},
+		{"nothing ran", nil, ExitNothingSent},
+		{"held", []Persona{held, noItems}, ExitNothingSent},
+		{"held and failed", []Persona{held, failed(runreport.CauseFeed)}, ExitPartial},
+		{"some failed", []Persona{noItems, failed(runreport.CauseLLM)}, ExitPartial},
+		{"mixed causes", []Persona{failed(runreport.CauseLLM), failed(runreport.CauseFeed)}, ExitPartial},
+		{"no cause", []Persona{failed("")}, ExitPartial},
+		{"config", []Persona{failed(runreport.CauseConfig), skipped}, ExitConfig},
+		{"feed", []Persona{failed(runreport.CauseFeed)}, ExitFeed},
+		{"llm down", []Persona{failed(runreport.CauseLLM), failed(runreport.CauseLLM)}, ExitLLM},
+		{"delivery", []Persona{failed(runreport.CauseDelivery)}, ExitDelivery},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, exitCode(tt.personas, false))
+		})
+	}
+}
+
+func TestExitCode_NoDelivery(t *testing.T) {
+	done := Persona{Status: StatusDone}
+	held := Persona{Status: StatusHeld}
+	failed := Persona{Status: StatusFailed, Cause: runreport.CauseLLM}
+
+	assert.Equal(t, ExitOK, exitCode([]Persona{done, done}, true), "a megathread refresh or a run that skips email succeeds without sending")
+	assert.Equal(t, ExitOK, exitCode(nil, true), "a refresh without megathreads to refresh succeeds")
+	assert.Equal(t, ExitOK, exitCode([]Persona{held, done}, true))
+	assert.Equal(t, ExitPartial, exitCode([]Persona{done, failed}, true))
+	assert.Equal(t, ExitLLM, exitCode([]Persona{failed}, true), "failures still fail the run")
+}
+
+func TestFromReport_NoDelivery(t *testing.T) {
+	report := runreport.New(time.Now(), runreport.Pricing{})
+	report.NoDelivery = true
+	report.Persona("LocalLLaMA").Processed = 3
+	report.Persona("Rust").FailWith(runreport.CauseFeed, "fetch feed: %v", errors.New("403"))
+
+	result := FromReport(report, nil)
+	require.Len(t, result.Personas, 2)
+	assert.Equal(t, StatusDone, result.Personas[0].Status)
+	assert.Equal(t, StatusFailed, result.Personas[1].Status)
+	assert.Equal(t, ExitPartial, result.ExitCode)
+
+	report.Personas = report.Personas[:1]
+	assert.Equal(t, ExitOK, FromReport(report, nil).ExitCode)
+}
+
+func TestSetup(t *testing.T) {
+	result := Setup(time.Now(), errors.New("ANP_LLM_URL is required"))
+	assert.Equal(t, ExitSetup, result.ExitCode)
+	assert.Equal(t, "ANP_LLM_URL is required", result.Error)
+	assert.NotNil(t, result.Personas, "personas is an empty list, not null")
+}
+
+func TestWriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "run_result.json")
+	want := Result{
+		StartedAt:  time.Date(2026, time.October, 16, 7, 0, 0, 0, time.UTC),
+		FinishedAt: time.Date(2026, time.October, 16, 7, 1, 0, 0, time.UTC),
+		ExitCode:   ExitLLM,
+		Personas:   []Persona{{Name: "LocalLLaMA", Status: StatusFailed, Cause: runreport.CauseLLM, Errors: []string{"process entries: down"}}},
+	}
+	require.NoError(t, WriteFile(path, want))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var got Result
+	require.NoError(t, json.Unmarshal(data, &got))
+	assert.Equal(t, want, got)
+	assert.Contains(t, string(data), `"cause": "llm"`)
+}
@@ -0,0 +1,242 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Embedder turns texts into vectors whose cosine similarity reflects how close their meanings are
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float64, error)
+}
+
+// embeddingBatchSize is the number of texts sent in one embeddings request
+const embeddingBatchSize = 64
+
+// HTTPEmbedder requests embeddings from an OpenAI-compatible /embeddings endpoint
+type HTTPEmbedder struct {
+	baseURL string
+	apiKey  string
+	model   string
+	client  *http.Client
+}
+
+// NewHTTPEmbedder creates an embedder for model served at baseURL, such as the ANP_LLM_URL of
+// the processor
+func NewHTTPEmbedder(baseURL, apiKey, model string) *HTTPEmbedder {
+	return &HTTPEmbedder{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   model,
+		client:  &http.Client{Timeout: 2 * time.Minute},
+	}
+}
+
+type embeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type embeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float64 `json:"embedding"`
+	} `json:"data"`
+}
+
+// Embed returns an embedding for each of texts, in order
+func (e *HTTPEmbedder) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	vectors := make([][]float64, 0, len(texts))
+	for start := 0; start < len(texts); start += embeddingBatchSize {
+		end := min(start+embeddingBatchSize, len(texts))
+		batch, err := e.embedBatch(ctx, texts[start:end])
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, batch...)
+	}
+	return vectors, nil
+}
+
+func (e *HTTPEmbedder) embedBatch(ctx context.Context, texts []string) ([][]float64, error) {
+	body, err := json.Marshal(embeddingRequest{Model: e.model, Input: texts})
+	if err != nil {
+		return nil, fmt.Errorf("could not encode embeddings request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.baseURL+"/embeddings", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("could not create embeddings request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.apiKey)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("embeddings request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("embeddings request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+
+	var parsed embeddingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("could not decode embeddings response: %w", err)
+	}
+	if len(parsed.Data) != len(texts) {
+		return nil, fmt.Errorf("embeddings response has %d embeddings for %d texts", len(parsed.Data), len(texts))
+	}
+	vectors := make([][]float64, len(texts))
+	for i, data := range parsed.Data {
+		index := data.Index
+		if index < 0 || index >= len(texts) || vectors[index] != nil {
+			// Servers that leave out the index return the embeddings in order
+			index = i
+		}
+		vectors[index] = data.Embedding
+	}
+	return vectors, nil
+}
+
+// EmbeddingCacheFile is the name of the file in the state directory that caches item embeddings
+const EmbeddingCacheFile = "embeddings.json"
+
+// NewEmbedder returns the embedder semantic searches use: model, served at the OpenAI-compatible
+// baseURL, with its embeddings cached in the state directory. It returns nil if no model is set.
+func NewEmbedder(baseURL, apiKey, model, stateDir string) Embedder {
+	if model == "" || baseURL == "" {
+		return nil
+	}
+	return NewCachingEmbedder(NewHTTPEmbedder(baseURL, apiKey, model), filepath.Join(stateDir, EmbeddingCacheFile), model)
+}
+
+// CachingEmbedder keeps the embeddings of an embedder in a JSON file, so stored items are only
+// embedded once instead of on every search
+type CachingEmbedder struct {
+	embedder Embedder
+	path     string
+	model    string
+
+	mu      sync.Mutex
+	vectors map[string][]float64 // Keyed by the hash of the model and the text
+	loaded  bool
+}
+
+// NewCachingEmbedder caches the embeddings embedder makes with model in the file at path
+func NewCachingEmbedder(embedder Embedder, path, model string) *CachingEmbedder {
+	return &CachingEmbedder{embedder: embedder, path: path, model: model}
+}
+
+// Embed returns an embedding for each of texts, requesting only those that are not cached
+func (c *CachingEmbedder) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+
+	vectors := make([][]float64, len(texts))
+	var missing []string
+	var missingIndexes []int
+	for i, text := range texts {
+		if vector, ok := c.vectors[c.key(text)]; ok {
+			vectors[i] = vector
+			continue
+		}
+		missing = append(missing, text)
+		missingIndexes = append(missingIndexes, i)
+	}
+	if len(missing) == 0 {
+		return vectors, nil
+	}
+
+	embedded, err := c.embedder.Embed(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	if len(embedded) != len(missing) {
+		return nil, fmt.Errorf("embedding model returned %d embeddings for %d texts", len(embedded), len(missing))
+	}
+	for i, vector := range embedded {
+		vectors[missingIndexes[i]] = vector
+		c.vectors[c.key(missing[i])] = vector
+	}
+	if err := c.save(); err != nil {
+		return nil, err
+	}
+	return vectors, nil
+}
+
+func (c *CachingEmbedder) key(text string) string {
+	sum := sha256.Sum256([]byte(c.model + "\n" + text))
+	return hex.EncodeToString(sum[:16])
+}
+
+func (c *CachingEmbedder) load() error {
+	if c.loaded {
+		return nil
+	}
+	c.vectors = make(map[string][]float64)
+	data, err := os.ReadFile(c.path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("could not read embedding cache: %w", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &c.vectors); err != nil {
+			return fmt.Errorf("could not parse embedding cache %s: %w", c.path, err)
+		}
+	}
+	c.loaded = true
+	return nil
+}
+
+func (c *CachingEmbedder) save() error {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("could not create embedding cache directory: %w", err)
+	}
+	data, err := json.Marshal(c.vectors)
+	if err != nil {
+		return fmt.Errorf("could not encode embedding cache: %w", err)
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("could not write embedding cache: %w", err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("could not write embedding cache: %w", err)
+	}
+	return nil
+}
+
+// cosine returns the cosine similarity of two vectors, or 0 when either is empty or their
+// lengths differ
+func cosine(a, b []float64) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
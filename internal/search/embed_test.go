@@ -0,0 +1,65 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPEmbedder(t *testing.T) {
+	var request embeddingRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/embeddings", r.URL.Path)
+		assert.Equal(t, "Bearer key", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		// Out of order, as the index says which input each embedding belongs to
+		w.Write([]byte(`{"data":[{"index":1,"embedding":[0,1]},{"index":0,"embedding":[1,0]}]}`))
+	}))
+	defer server.Close()
+
+	vectors, err := NewHTTPEmbedder(server.URL+"/v1/", "key", "nomic-embed-text").Embed(context.Background(), []string{"a", "b"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float64{{1, 0}, {0, 1}}, vectors)
+	assert.Equal(t, embeddingRequest{Model: "nomic-embed-text", Input: []string{"a", "b"}}, request)
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "model not found", http.StatusNotFound)
+	}))
+	defer failing.Close()
+	_, err = NewHTTPEmbedder(failing.URL, "", "missing").Embed(context.Background(), []string{"a"})
+	assert.ErrorContains(t, err, "model not found")
+}
+
+func TestCachingEmbedder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), EmbeddingCacheFile)
+	inner := &fakeEmbedder{words: []string{"a", "b"}}
+
+	vectors, err := NewCachingEmbedder(inner, path, "model").Embed(context.Background(), []string{"a", "b"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float64{{1, 0}, {0, 1}}, vectors)
+
+	// A new embedder reads the cache file and only requests texts it has not seen
+	vectors, err = NewCachingEmbedder(inner, path, "model").Embed(context.Background(), []string{"b", "ab", "a"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float64{{0, 1}, {1, 1}, {1, 0}}, vectors)
+	assert.Equal(t, 2, inner.calls)
+	assert.Equal(t, 3, inner.texts)
+
+	// Embeddings of another model are not reused
+	_, err = NewCachingEmbedder(inner, path, "other").Embed(context.Background(), []string{"a"})
+	require.NoError(t, err)
+	assert.Equal(t, 4, inner.texts)
+}
+
+func TestCosine(t *testing.T) {
+	assert.InDelta(t, 1.0, cosine([]float64{1, 2}, []float64{2, 4}), 0.0001)
+	assert.InDelta(t, 0.0, cosine([]float64{1, 0}, []float64{0, 1}), 0.0001)
+	assert.Zero(t, cosine([]float64{1}, []float64{1, 2}))
+	assert.Zero(t, cosine(nil, nil))
+}
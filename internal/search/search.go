@@ -0,0 +1,278 @@
+// Package search finds items in the item store by keyword or by meaning, so readers can find a
+// story they remember from an earlier digest.
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode"
+
+	"github.com/bakkerme/ai-news-processor/internal/itemstore"
+	"github.com/bakkerme/ai-news-processor/models"
+)
+
+// DefaultLimit is the number of results returned when a query sets no limit
+const DefaultLimit = 20
+
+// ErrNoEmbedder is returned for semantic searches when no embedding model is configured
+var ErrNoEmbedder = errors.New("semantic search needs an embedding model")
+
+// Query describes a search
+type Query struct {
+	Text     string
+	Personas []string  // Personas whose items are searched
+	Since    time.Time // Only items sent at or after this time (zero for all)
+	Until    time.Time // Only items sent before this time (zero for no end)
+	Limit    int       // Maximum number of results (defaults to DefaultLimit)
+	Semantic bool      // Rank by similarity of meaning instead of by keywords
+}
+
+// Result is an item that matched a query
+type Result struct {
+	Persona string           `json:"persona"`
+	Record  itemstore.Record `json:"record"`
+	Score   float64          `json:"score"` // Keyword weight or cosine similarity; higher is better
+}
+
+// Searcher searches the items of an item store
+type Searcher struct {
+	items    *itemstore.Store
+	embedder Embedder // Embeds text for semantic search (nil when it is not available)
+}
+
+// New creates a searcher over an item store. embedder may be nil, in which case only keyword
+// search is available.
+func New(items *itemstore.Store, embedder Embedder) *Searcher {
+	return &Searcher{items: items, embedder: embedder}
+}
+
+// Search returns the items of the query's personas that match it, best first. Keyword searches
+// rank items by how many of the query's terms they mention, then by where: a term in the title
+// or tags weighs more than one in the summary. Semantic searches rank every item by how close
+// its meaning is to the query.
+func (s *Searcher) Search(ctx context.Context, q Query) ([]Result, error) {
+	if strings.TrimSpace(q.Text) == "" {
+		return nil, fmt.Errorf("the search query is empty")
+	}
+	if q.Semantic && s.embedder == nil {
+		return nil, ErrNoEmbedder
+	}
+
+	var candidates []Result
+	for _, name := range q.Personas {
+		records, err := s.items.Since(name, q.Since)
+		if err != nil {
+			return nil, fmt.Errorf("could not read items of persona %s: %w", name, err)
+		}
+		for _, record := range records {
+			if !q.Until.IsZero() && !record.SentAt.Before(q.Until) {
+				continue
+			}
+			candidates = append(candidates, Result{Persona: name, Record: record})
+		}
+	}
+
+	var results []Result
+	var err error
+	if q.Semantic {
+		results, err = s.rankSemantic(ctx, q.Text, candidates)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		results = rankKeywords(q.Text, candidates)
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// Weights of the fields of an item in keyword searches
+const (
+	titleWeight   = 3
+	tagWeight     = 2
+	summaryWeight = 1
+)
+
+// queryStopwords are words of natural queries that say nothing about the item searched for
+var queryStopwords = map[string]struct{}{}
+
+func init() {
+	for _, w := range strings.Fields(`
+		a an the and or of in on at to for with about from by that this these those which
+		post posts item items article story thread news what when where who how was were is are
+		some any find show me my i
+	`) {
+		queryStopwords[w] = struct{}{}
+	}
+}
+
+// terms splits text into lowercase terms. Dots stay inside terms so versions such as "3.1"
+// survive, while dashes split them, so "KV-cache" matches "KV cache".
+func terms(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '.'
+	})
+	result := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if field = strings.Trim(field, "."); field != "" {
+			result = append(result, field)
+		}
+	}
+	return result
+}
+
+// queryTerms returns the distinct terms of a query, without stopwords unless the query is
+// nothing but stopwords
+func queryTerms(text string) []string {
+	all := terms(text)
+	seen := make(map[string]struct{})
+	var kept []string
+	for _, term := range all {
+		if _, ok := queryStopwords[term]; ok {
+			continue
+		}
+		if _, ok := seen[term]; ok {
+			continue
+		}
+		seen[term] = struct{}{}
+		kept = append(kept, term)
+	}
+	if len(kept) == 0 {
+		return all
+	}
+	return kept
+}
+
+// weightedText is a part of an item with the weight of the terms in it
+type weightedText struct {
+	text   string
+	weight int
+}
+
+// keywordFields returns the parts of an item that keyword searches look at
+func keywordFields(item models.Item) []weightedText {
+	tags := make([]string, 0, len(item.Entities)+len(item.Topics))
+	for _, entity := range item.Entities {
+		tags = append(tags, entity.Name)
+	}
+	tags = append(tags, item.Topics...)
+	return []weightedText{
+		{item.Title, titleWeight},
+		{strings.Join(tags, " "), tagWeight},
+		{strings.Join(item.Overview, " "), summaryWeight},
+		{item.Summary, summaryWeight},
+		{item.CommentSummary, summaryWeight},
+	}
+}
+
+// rankKeywords scores candidates by the query's terms and drops those that mention none
+func rankKeywords(text string, candidates []Result) []Result {
+	queried := queryTerms(text)
+	type scored struct {
+		Result
+		matched int
+	}
+	var matches []scored
+	for _, candidate := range candidates {
+		counts := make(map[string]int)
+		for _, field := range keywordFields(candidate.Record.Item) {
+			for _, term := range terms(field.text) {
+				counts[term] += field.weight
+			}
+		}
+		matched, weight := 0, 0
+		for _, term := range queried {
+			if counts[term] > 0 {
+				matched++
+				weight += counts[term]
+			}
+		}
+		if matched == 0 {
+			continue
+		}
+		candidate.Score = float64(weight)
+		matches = append(matches, scored{Result: candidate, matched: matched})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].matched != matches[j].matched {
+			return matches[i].matched > matches[j].matched
+		}
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Record.SentAt.After(matches[j].Record.SentAt)
+	})
+	results := make([]Result, len(matches))
+	for i, match := range matches {
+		results[i] = match.Result
+	}
+	return results
+}
+
+// rankSemantic scores every candidate by the cosine similarity of its embedding to the query's
+func (s *Searcher) rankSemantic(ctx context.Context, text string, candidates []Result) ([]Result, error) {
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	texts := make([]string, 0, len(candidates)+1)
+	texts = append(texts, text)
+	for _, candidate := range candidates {
+		texts = append(texts, embeddingText(candidate.Record.Item))
+	}
+	vectors, err := s.embedder.Embed(ctx, texts)
+	if err != nil {
+		return nil, fmt.Errorf("could not embed items: %w", err)
+	}
+	if len(vectors) != len(texts) {
+		return nil, fmt.Errorf("embedding model returned %d embeddings for %d texts", len(vectors), len(texts))
+	}
+
+	results := make([]Result, len(candidates))
+	for i, candidate := range candidates {
+		candidate.Score = cosine(vectors[0], vectors[i+1])
+		results[i] = candidate
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	return results, nil
+}
+
+// embeddingText is the text of an item that is embedded for semantic search
+func embeddingText(item models.Item) string {
+	var b strings.Builder
+	b.WriteString(item.Title)
+	if len(item.Topics) > 0 {
+		b.WriteString("\nTopics: " + strings.Join(item.Topics, ", "))
+	}
+	b.WriteString("\n" + item.Summary)
+	return b.String()
+}
+
+// WriteTable writes results as a table, one item per row
+func WriteTable(w io.Writer, results []Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SCORE\tSENT\tPERSONA\tTITLE\tLINK")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%.2f\t%s\t%s\t%s\t%s\n",
+			r.Score,
+			r.Record.SentAt.Format("2006-01-02"),
+			r.Persona,
+			r.Record.Item.Title,
+			r.Record.Item.Link,
+		)
+	}
+	return tw.Flush()
+}
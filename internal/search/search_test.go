@@ -0,0 +1,107 @@
+package search
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/itemstore"
+	"github.com/bakkerme/ai-news-processor/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var searchNow = time.Date(2025, time.May, 20, 6, 0, 0, 0, time.UTC)
+
+func newTestStore(t *testing.T) *itemstore.Store {
+	t.Helper()
+	store := itemstore.New(filepath.Join(t.TempDir(), "items"))
+	require.NoError(t, store.Append("LocalLLaMA", []models.Item{
+		{ID: "kv", Title: "KV cache quantization lands in llama.cpp", Summary: "Halves memory use of long contexts."},
+		{ID: "qwen", Title: "Qwen 3 released", Summary: "New models with a llama.cpp day-one port.", Topics: []string{"Qwen"}},
+	}, searchNow.AddDate(0, 0, -30)))
+	require.NoError(t, store.Append("LocalLLaMA", []models.Item{
+		{ID: "bench", Title: "Benchmarks of quantization formats", Summary: "Comparing GGUF and EXL2.", Entities: []models.Entity{{Name: "llama.cpp"}}},
+	}, searchNow.AddDate(0, 0, -2)))
+	require.NoError(t, store.Append("MachineLearning", []models.Item{
+		{ID: "paper", Title: "A survey of cache eviction", Summary: "Covers KV cache eviction policies."},
+	}, searchNow.AddDate(0, 0, -5)))
+	return store
+}
+
+func ids(results []Result) []string {
+	var result []string
+	for _, r := range results {
+		result = append(result, r.Record.Item.ID)
+	}
+	return result
+}
+
+func TestSearchKeywords(t *testing.T) {
+	searcher := New(newTestStore(t), nil)
+	personas := []string{"LocalLLaMA", "MachineLearning"}
+
+	results, err := searcher.Search(context.Background(), Query{Text: "the KV-cache quantization post", Personas: personas})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kv", "paper", "bench"}, ids(results), "items matching more terms rank first, stopwords are ignored")
+
+	results, err = searcher.Search(context.Background(), Query{Text: "llama.cpp", Personas: personas})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kv", "bench", "qwen"}, ids(results), "a title match outweighs a tag, which outweighs the summary")
+
+	results, err = searcher.Search(context.Background(), Query{Text: "cache", Personas: []string{"MachineLearning"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"paper"}, ids(results))
+	assert.Equal(t, "MachineLearning", results[0].Persona)
+
+	results, err = searcher.Search(context.Background(), Query{Text: "llama.cpp", Personas: personas, Since: searchNow.AddDate(0, 0, -7)})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bench"}, ids(results))
+
+	results, err = searcher.Search(context.Background(), Query{Text: "llama.cpp", Personas: personas, Until: searchNow.AddDate(0, 0, -7), Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kv"}, ids(results))
+
+	results, err = searcher.Search(context.Background(), Query{Text: "diffusion", Personas: personas})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+
+	_, err = searcher.Search(context.Background(), Query{Text: "  ", Personas: personas})
+	assert.Error(t, err)
+}
+
+// fakeEmbedder embeds texts as counts of a few words
+type fakeEmbedder struct {
+	words []string
+	calls int
+	texts int
+}
+
+func (e *fakeEmbedder) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	e.calls++
+	e.texts += len(texts)
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		vectors[i] = make([]float64, len(e.words))
+		for j, word := range e.words {
+			vectors[i][j] = float64(strings.Count(strings.ToLower(text), word))
+		}
+	}
+	return vectors, nil
+}
+
+func TestSearchSemantic(t *testing.T) {
+	store := newTestStore(t)
+	_, err := New(store, nil).Search(context.Background(), Query{Text: "memory", Personas: []string{"LocalLLaMA"}, Semantic: true})
+	assert.ErrorIs(t, err, ErrNoEmbedder)
+
+	embedder := &fakeEmbedder{words: []string{"memory", "models", "benchmark"}}
+	searcher := New(store, embedder)
+	results, err := searcher.Search(context.Background(), Query{Text: "memory savings", Personas: []string{"LocalLLaMA"}, Semantic: true})
+	require.NoError(t, err)
+	require.Len(t, results, 3, "semantic searches rank every item")
+	assert.Equal(t, "kv", results[0].Record.Item.ID)
+	assert.InDelta(t, 1.0, results[0].Score, 0.001)
+}
@@ -0,0 +1,115 @@
+// Package secrets looks up API keys and passwords in environment variables, in files named by
+// *_FILE variables (the Docker secrets convention) or in a HashiCorp Vault KV secret, so they do
+// not have to live in the environment.
+package secrets
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+)
+
+// FileSuffix is appended to the name of a variable to read its value from a file instead
+const FileSuffix = "_FILE"
+
+// Resolver looks up secrets, first in the environment, then in files, then in Vault
+type Resolver struct {
+	vault *Vault // nil without Vault
+
+	once      sync.Once
+	vaultData map[string]string
+	vaultErr  error
+}
+
+// New creates a resolver that falls back to vault, which may be nil
+func New(vault *Vault) *Resolver {
+	return &Resolver{vault: vault}
+}
+
+// FromEnv creates a resolver that uses Vault if ANP_VAULT_ADDR (or VAULT_ADDR) and ANP_VAULT_PATH
+// are set. The Vault token is itself read with the file convention, from ANP_VAULT_TOKEN or
+// VAULT_TOKEN.
+func FromEnv() (*Resolver, error) {
+	files := New(nil)
+	addr := firstNonEmpty(os.Getenv("ANP_VAULT_ADDR"), os.Getenv("VAULT_ADDR"))
+	secretPath := os.Getenv("ANP_VAULT_PATH")
+	if addr == "" || secretPath == "" {
+		return files, nil
+	}
+
+	token, err := files.Lookup("ANP_VAULT_TOKEN")
+	if err != nil {
+		return nil, err
+	}
+	if token == "" {
+		if token, err = files.Lookup("VAULT_TOKEN"); err != nil {
+			return nil, err
+		}
+	}
+	if token == "" {
+		return nil, fmt.Errorf("a Vault token is required to read %s", secretPath)
+	}
+	namespace := firstNonEmpty(os.Getenv("ANP_VAULT_NAMESPACE"), os.Getenv("VAULT_NAMESPACE"))
+	return New(NewVault(addr, token, namespace, secretPath)), nil
+}
+
+// Lookup returns the secret named key: the variable key if it is set, else the trimmed contents of
+// the file named by key_FILE, else the field key of the Vault secret. A secret that is not found is
+// empty.
+func (r *Resolver) Lookup(key string) (string, error) {
+	if value := os.Getenv(key); value != "" {
+		return value, nil
+	}
+	if path := os.Getenv(key + FileSuffix); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("could not read %s from %s: %w", key, key+FileSuffix, err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+	if r.vault == nil {
+		return "", nil
+	}
+
+	// The secret is read once, on the first lookup that gets this far
+	r.once.Do(func() {
+		r.vaultData, r.vaultErr = r.vault.Read()
+	})
+	if r.vaultErr != nil {
+		return "", r.vaultErr
+	}
+	return r.vaultData[key], nil
+}
+
+var (
+	defaultOnce     sync.Once
+	defaultResolver *Resolver
+)
+
+// Getenv looks up a secret with a resolver configured from the environment, for commands without
+// a specification. Failures are logged and leave the secret empty.
+func Getenv(key string) string {
+	defaultOnce.Do(func() {
+		var err error
+		if defaultResolver, err = FromEnv(); err != nil {
+			log.Printf("Warning: could not configure Vault: %v", err)
+			defaultResolver = New(nil)
+		}
+	})
+	value, err := defaultResolver.Lookup(key)
+	if err != nil {
+		log.Printf("Warning: could not look up %s: %v", key, err)
+	}
+	return value
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
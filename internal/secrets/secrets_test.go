@@ -0,0 +1,113 @@
+package secrets
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookup(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "llm_api_key")
+	require.NoError(t, os.WriteFile(keyFile, []byte("from-file\n"), 0600))
+
+	t.Setenv("ANP_TEST_SECRET", "from-env")
+	t.Setenv("ANP_TEST_SECRET_FILE", keyFile)
+	t.Setenv("ANP_TEST_FILE_SECRET_FILE", keyFile)
+	t.Setenv("ANP_TEST_MISSING_FILE", filepath.Join(dir, "missing"))
+
+	r := New(nil)
+	value, err := r.Lookup("ANP_TEST_SECRET")
+	require.NoError(t, err)
+	assert.Equal(t, "from-env", value, "the variable takes precedence over the file")
+
+	value, err = r.Lookup("ANP_TEST_FILE_SECRET")
+	require.NoError(t, err)
+	assert.Equal(t, "from-file", value, "the trailing newline is trimmed")
+
+	value, err = r.Lookup("ANP_TEST_UNSET")
+	require.NoError(t, err)
+	assert.Empty(t, value)
+
+	_, err = r.Lookup("ANP_TEST_MISSING")
+	assert.ErrorContains(t, err, "ANP_TEST_MISSING_FILE")
+}
+
+func TestLookup_Vault(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "/v1/secret/data/anp", r.URL.Path)
+		assert.Equal(t, "s.token", r.Header.Get("X-Vault-Token"))
+		assert.Equal(t, "team", r.Header.Get("X-Vault-Namespace"))
+		w.Write([]byte(`{"data":{"data":{"ANP_TEST_VAULT_KEY":"from-vault","ANP_TEST_PORT":587},"metadata":{"version":3}}}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("ANP_TEST_ENV_KEY", "from-env")
+	r := New(NewVault(server.URL+"/", "s.token", "team", "/secret/data/anp"))
+
+	value, err := r.Lookup("ANP_TEST_ENV_KEY")
+	require.NoError(t, err)
+	assert.Equal(t, "from-env", value)
+	assert.Zero(t, requests, "Vault is only read for secrets the environment does not have")
+
+	value, err = r.Lookup("ANP_TEST_VAULT_KEY")
+	require.NoError(t, err)
+	assert.Equal(t, "from-vault", value)
+	value, err = r.Lookup("ANP_TEST_PORT")
+	require.NoError(t, err)
+	assert.Equal(t, "587", value)
+	value, err = r.Lookup("ANP_TEST_UNSET")
+	require.NoError(t, err)
+	assert.Empty(t, value)
+	assert.Equal(t, 1, requests, "the secret is read once")
+}
+
+func TestVault_Read(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/kv/anp":
+			w.Write([]byte(`{"data":{"ANP_LLM_API_KEY":"v1-key","data":"not nested"}}`))
+		default:
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+		}
+	}))
+	defer server.Close()
+
+	values, err := NewVault(server.URL, "s.token", "", "kv/anp").Read()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"ANP_LLM_API_KEY": "v1-key", "data": "not nested"}, values)
+
+	_, err = NewVault(server.URL, "s.token", "", "secret/data/other").Read()
+	assert.ErrorContains(t, err, "permission denied")
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("ANP_VAULT_ADDR", "")
+	t.Setenv("VAULT_ADDR", "")
+	r, err := FromEnv()
+	require.NoError(t, err)
+	assert.Nil(t, r.vault)
+
+	t.Setenv("VAULT_ADDR", "http://vault:8200")
+	t.Setenv("ANP_VAULT_PATH", "secret/data/anp")
+	t.Setenv("ANP_VAULT_TOKEN", "")
+	t.Setenv("VAULT_TOKEN", "")
+	_, err = FromEnv()
+	assert.Error(t, err, "a token is required")
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("s.token"), 0600))
+	t.Setenv("VAULT_TOKEN_FILE", tokenFile)
+	r, err = FromEnv()
+	require.NoError(t, err)
+	require.NotNil(t, r.vault)
+	assert.Equal(t, "s.token", r.vault.token)
+}
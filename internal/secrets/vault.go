@@ -0,0 +1,91 @@
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Vault reads a secret from a HashiCorp Vault key/value secrets engine, version 1 or 2
+type Vault struct {
+	addr      string
+	token     string
+	namespace string // Vault Enterprise namespace, if any
+	path      string // API path of the secret, e.g. "secret/data/anp" for KV version 2
+	client    *http.Client
+}
+
+// NewVault creates a client that reads the secret at path from the Vault server at addr
+func NewVault(addr, token, namespace, path string) *Vault {
+	return &Vault{
+		addr:      strings.TrimRight(addr, "/"),
+		token:     token,
+		namespace: namespace,
+		path:      strings.Trim(path, "/"),
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Read returns the fields of the secret, with values that are not strings formatted as text
+func (v *Vault) Read() (map[string]string, error) {
+	req, err := http.NewRequest(http.MethodGet, v.addr+"/v1/"+v.path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not create Vault request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+	if v.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.namespace)
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not read Vault secret %s: %w", v.path, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("could not read Vault secret %s: %w", v.path, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Vault returned status %s for secret %s: %s", resp.Status, v.path, vaultErrors(body))
+	}
+
+	var secret struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return nil, fmt.Errorf("could not parse Vault secret %s: %w", v.path, err)
+	}
+
+	// KV version 2 nests the fields in data.data, next to data.metadata
+	fields := secret.Data
+	if nested, ok := fields["data"].(map[string]any); ok {
+		if _, ok := fields["metadata"]; ok {
+			fields = nested
+		}
+	}
+
+	values := make(map[string]string, len(fields))
+	for key, value := range fields {
+		if text, ok := value.(string); ok {
+			values[key] = text
+		} else if value != nil {
+			values[key] = fmt.Sprint(value)
+		}
+	}
+	return values, nil
+}
+
+// vaultErrors extracts the messages of a Vault error response
+func vaultErrors(body []byte) string {
+	var response struct {
+		Errors []string `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &response); err == nil && len(response.Errors) > 0 {
+		return strings.Join(response.Errors, "; ")
+	}
+	return strings.TrimSpace(string(body))
+}
@@ -0,0 +1,201 @@
+package sitemeta
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/fetcher"
+)
+
+// DefaultTTL is how long cached site metadata is trusted before it is refreshed
+const DefaultTTL = 30 * 24 * time.Hour
+
+// Metadata describes the site an external URL belongs to
+type Metadata struct {
+	Domain     string    `json:"domain"`
+	SiteName   string    `json:"siteName"`
+	FaviconURL string    `json:"faviconUrl,omitempty"`
+	FetchedAt  time.Time `json:"fetchedAt"`
+}
+
+// Cache resolves and caches favicons and site names per domain so they only need
+// to be looked up once across runs.
+type Cache struct {
+	path    string
+	ttl     time.Duration
+	fetcher fetcher.Fetcher
+
+	mu      sync.Mutex
+	entries map[string]Metadata
+}
+
+// Load reads the site metadata cache from disk.
+// If the file does not exist, an empty cache is returned.
+// If ttl is 0, DefaultTTL will be used.
+func Load(path string, ttl time.Duration, f fetcher.Fetcher) (*Cache, error) {
+	if ttl == 0 {
+		ttl = DefaultTTL
+	}
+
+	c := &Cache{
+		path:    path,
+		ttl:     ttl,
+		fetcher: f,
+		entries: make(map[string]Metadata),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return c, nil
+		}
+		return nil, fmt.Errorf("could not read site metadata cache: %w", err)
+	}
+
+	var list []Metadata
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("could not parse site metadata cache: %w", err)
+	}
+	for _, m := range list {
+		if m.Domain == "" {
+			continue
+		}
+		c.entries[m.Domain] = m
+	}
+
+	return c, nil
+}
+
+// Lookup returns the metadata for the domain of u.
+// hint carries any site name or favicon already discovered while extracting the page; it takes
+// precedence over cached values so the cache improves over time. If nothing is known, the site
+// name falls back to the domain and /favicon.ico is probed.
+func (c *Cache) Lookup(ctx context.Context, u *url.URL, hint Metadata) Metadata {
+	domain := Domain(u)
+
+	c.mu.Lock()
+	cached, ok := c.entries[domain]
+	c.mu.Unlock()
+
+	fresh := ok && time.Since(cached.FetchedAt) < c.ttl
+	if fresh && (hint.SiteName == "" || hint.SiteName == cached.SiteName) && (hint.FaviconURL == "" || hint.FaviconURL == cached.FaviconURL) {
+		return cached
+	}
+
+	meta := Metadata{
+		Domain:     domain,
+		SiteName:   hint.SiteName,
+		FaviconURL: resolveReference(u, hint.FaviconURL),
+		FetchedAt:  time.Now(),
+	}
+
+	if meta.SiteName == "" {
+		meta.SiteName = cached.SiteName
+	}
+	if meta.SiteName == "" {
+		meta.SiteName = domain
+	}
+
+	if meta.FaviconURL == "" && fresh {
+		meta.FaviconURL = cached.FaviconURL
+	}
+	if meta.FaviconURL == "" {
+		meta.FaviconURL = c.probeFavicon(ctx, u)
+	}
+
+	c.mu.Lock()
+	c.entries[domain] = meta
+	c.mu.Unlock()
+
+	return meta
+}
+
+// probeFavicon checks whether the site serves a favicon at the conventional /favicon.ico location
+func (c *Cache) probeFavicon(ctx context.Context, u *url.URL) string {
+	if c.fetcher == nil {
+		return ""
+	}
+
+	faviconURL := &url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/favicon.ico"}
+	if faviconURL.Scheme == "" {
+		faviconURL.Scheme = "https"
+	}
+
+	resp, err := c.fetcher.Fetch(ctx, faviconURL)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		log.Printf("warning: Could not fetch favicon for %s: %v\n", u.Host, err)
+		return ""
+	}
+	if resp.StatusCode != http.StatusOK {
+		return ""
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if contentType != "" && !strings.HasPrefix(contentType, "image/") {
+		return ""
+	}
+
+	return faviconURL.String()
+}
+
+// Save persists the cache to disk as a JSON array
+func (c *Cache) Save() error {
+	c.mu.Lock()
+	list := make([]Metadata, 0, len(c.entries))
+	for _, m := range c.entries {
+		list = append(list, m)
+	}
+	c.mu.Unlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Domain < list[j].Domain
+	})
+
+	payload, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode site metadata cache: %w", err)
+	}
+
+	dir := filepath.Dir(c.path)
+	if dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("could not create site metadata cache directory: %w", err)
+		}
+	}
+
+	if err := os.WriteFile(c.path, payload, 0644); err != nil {
+		return fmt.Errorf("could not write site metadata cache: %w", err)
+	}
+
+	return nil
+}
+
+// Domain returns the lower-cased host of u without a leading www.
+func Domain(u *url.URL) string {
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+// resolveReference makes a possibly relative favicon reference absolute against the page URL
+func resolveReference(base *url.URL, ref string) string {
+	if ref == "" {
+		return ""
+	}
+	parsed, err := url.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	return base.ResolveReference(parsed).String()
+}
@@ -0,0 +1,78 @@
+package sitemeta
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/fetcher"
+	"github.com/bakkerme/ai-news-processor/internal/http/retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_LookupUsesHint(t *testing.T) {
+	cache, err := Load(filepath.Join(t.TempDir(), "site_metadata.json"), 0, nil)
+	require.NoError(t, err)
+
+	u, _ := url.Parse("https://www.example.com/posts/1")
+	meta := cache.Lookup(context.Background(), u, Metadata{SiteName: "Example News", FaviconURL: "/static/icon.png"})
+
+	assert.Equal(t, "example.com", meta.Domain)
+	assert.Equal(t, "Example News", meta.SiteName)
+	assert.Equal(t, "https://www.example.com/static/icon.png", meta.FaviconURL)
+}
+
+func TestCache_LookupProbesFaviconOnce(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.URL.Path != "/favicon.ico" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "image/x-icon")
+		w.Write([]byte{0, 0, 1, 0})
+	}))
+	defer server.Close()
+
+	f := fetcher.NewHTTPFetcher(server.Client(), retry.RetryConfig{}, "")
+	path := filepath.Join(t.TempDir(), "site_metadata.json")
+	cache, err := Load(path, time.Hour, f)
+	require.NoError(t, err)
+
+	u, _ := url.Parse(server.URL + "/article")
+	meta := cache.Lookup(context.Background(), u, Metadata{})
+	assert.Equal(t, server.URL+"/favicon.ico", meta.FaviconURL)
+	assert.Equal(t, Domain(u), meta.SiteName, "site name should fall back to the domain")
+
+	// A second lookup should be served from the cache
+	cache.Lookup(context.Background(), u, Metadata{})
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	// And the cache should survive a reload
+	require.NoError(t, cache.Save())
+	reloaded, err := Load(path, time.Hour, f)
+	require.NoError(t, err)
+	assert.Equal(t, meta.FaviconURL, reloaded.Lookup(context.Background(), u, Metadata{}).FaviconURL)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+}
+
+func TestCache_LookupWithoutFavicon(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	f := fetcher.NewHTTPFetcher(server.Client(), retry.RetryConfig{}, "")
+	cache, err := Load(filepath.Join(t.TempDir(), "site_metadata.json"), time.Hour, f)
+	require.NoError(t, err)
+
+	u, _ := url.Parse(server.URL + "/article")
+	meta := cache.Lookup(context.Background(), u, Metadata{SiteName: "No Icon"})
+	assert.Equal(t, "No Icon", meta.SiteName)
+	assert.Empty(t, meta.FaviconURL)
+}
@@ -0,0 +1,79 @@
+package specification
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// dockerPersonasPath is where the Docker image keeps the bundled personas
+const dockerPersonasPath = "/app/personas"
+
+// Paths are the directories the processor and its commands read from and write to
+type Paths struct {
+	DataRoot   string // Base directory for everything below that is not set explicitly
+	Personas   string // Persona YAML files
+	State      string // Sent log, item store, run history, feedback and caches
+	FeedMocks  string // Dumped feeds, read back by the mock provider
+	Benchmarks string // Benchmark run data written with ANP_DEBUG_OUTPUT_BENCHMARK
+	Plugins    string // Executables of provider plugins
+	Prompts    string // Prompt template files that replace the built-in templates
+}
+
+// LoadPaths resolves the data directories from the environment. Each directory can be set on its
+// own; the rest live under ANP_DATA_ROOT when it is set. Without ANP_DATA_ROOT the defaults
+// match the Docker image layout, and a source checkout falls back to paths relative to the
+// working directory.
+func LoadPaths() Paths {
+	return resolvePaths(
+		os.Getenv("ANP_DATA_ROOT"),
+		os.Getenv("ANP_PERSONAS_PATH"),
+		os.Getenv("ANP_SENT_LOG_BASE_PATH"),
+		os.Getenv("ANP_FEED_MOCKS_PATH"),
+		os.Getenv("ANP_BENCHMARK_PATH"),
+		os.Getenv("ANP_PLUGINS_PATH"),
+		os.Getenv("ANP_PROMPTS_PATH"),
+		isDir(dockerPersonasPath),
+	)
+}
+
+func resolvePaths(dataRoot, personas, state, feedMocks, benchmarks, plugins, prompts string, dockerLayout bool) Paths {
+	p := Paths{DataRoot: filepath.Clean(filepath.FromSlash(orDefault(dataRoot, ".")))}
+
+	defaultPersonas := filepath.Join(p.DataRoot, "personas")
+	defaultBenchmarks := filepath.Join(p.DataRoot, "benchmarkresults")
+	if dataRoot == "" {
+		if dockerLayout {
+			defaultPersonas = filepath.FromSlash(dockerPersonasPath)
+		}
+		// Benchmarks were historically written next to the checkout for the audit service to pick up
+		defaultBenchmarks = filepath.Join("..", "benchmarkresults")
+	}
+
+	p.Personas = clean(personas, defaultPersonas)
+	p.State = clean(state, p.DataRoot)
+	p.FeedMocks = clean(feedMocks, filepath.Join(p.DataRoot, "feed_mocks"))
+	p.Benchmarks = clean(benchmarks, defaultBenchmarks)
+	p.Plugins = clean(plugins, filepath.Join(p.DataRoot, "plugins"))
+	p.Prompts = clean(prompts, filepath.Join(p.DataRoot, "prompts"))
+	return p
+}
+
+// clean converts a configured path to the separators of the current OS, or returns the default
+func clean(path, defaultPath string) string {
+	if path == "" {
+		return defaultPath
+	}
+	return filepath.Clean(filepath.FromSlash(path))
+}
+
+func orDefault(value, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
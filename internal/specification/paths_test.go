@@ -0,0 +1,53 @@
+package specification
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolvePaths(t *testing.T) {
+	t.Run("defaults outside Docker", func(t *testing.T) {
+		p := resolvePaths("", "", "", "", "", "", "", false)
+		assert.Equal(t, Paths{
+			DataRoot:   ".",
+			Personas:   "personas",
+			State:      ".",
+			FeedMocks:  "feed_mocks",
+			Benchmarks: filepath.Join("..", "benchmarkresults"),
+			Plugins:    "plugins",
+			Prompts:    "prompts",
+		}, p)
+	})
+
+	t.Run("defaults in Docker", func(t *testing.T) {
+		p := resolvePaths("", "", "", "", "", "", "", true)
+		assert.Equal(t, filepath.FromSlash("/app/personas"), p.Personas)
+		assert.Equal(t, ".", p.State)
+	})
+
+	t.Run("data root", func(t *testing.T) {
+		p := resolvePaths("data/anp/", "", "", "", "", "", "", true)
+		root := filepath.Join("data", "anp")
+		assert.Equal(t, Paths{
+			DataRoot:   root,
+			Personas:   filepath.Join(root, "personas"),
+			State:      root,
+			FeedMocks:  filepath.Join(root, "feed_mocks"),
+			Benchmarks: filepath.Join(root, "benchmarkresults"),
+			Plugins:    filepath.Join(root, "plugins"),
+			Prompts:    filepath.Join(root, "prompts"),
+		}, p)
+	})
+
+	t.Run("explicit paths win", func(t *testing.T) {
+		p := resolvePaths("data", "/etc/anp/personas/", "state", "mocks", "bench", "/opt/anp/plugins", "/opt/anp/prompts", false)
+		assert.Equal(t, filepath.FromSlash("/etc/anp/personas"), p.Personas)
+		assert.Equal(t, "state", p.State)
+		assert.Equal(t, "mocks", p.FeedMocks)
+		assert.Equal(t, "bench", p.Benchmarks)
+		assert.Equal(t, filepath.FromSlash("/opt/anp/plugins"), p.Plugins)
+		assert.Equal(t, filepath.FromSlash("/opt/anp/prompts"), p.Prompts)
+	})
+}
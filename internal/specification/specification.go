@@ -5,7 +5,13 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/bakkerme/ai-news-processor/internal/budget"
+	"github.com/bakkerme/ai-news-processor/internal/domainfilter"
+	"github.com/bakkerme/ai-news-processor/internal/imageprep"
+	"github.com/bakkerme/ai-news-processor/internal/secrets"
 	"github.com/joho/godotenv"
 )
 
@@ -16,7 +22,33 @@ type Specification struct {
 
 	LlmImageEnabled      bool
 	LlmImageModel        string
+	LlmImageConcurrency  int
+	LlmImageBatchSize    int
+	LlmImageMaxDimension int
 	LlmUrlSummaryEnabled bool
+	LlmContextTokens     int
+	LlmCacheTTLHours     int
+	LlmVerifyReprompt    bool
+	LlmEmbeddingModel    string
+	LlmTriageModel       string
+	LlmResponseFormat    string // auto, json_schema, json_object, prompt, grammar or guided_json
+	LlmSchemaStrict      bool
+	LlmReasoning         string // Per-model reasoning settings, see openai.ParseReasoning
+
+	LlmInputCostPerMillion  float64
+	LlmOutputCostPerMillion float64
+
+	OcrEnabled       bool
+	OcrTesseractPath string
+	OcrLanguages     string
+
+	HuggingFaceEnrichmentEnabled bool
+	TwitterUnrollEnabled         bool
+	ResolveShortLinks            bool
+	SiteMetadataEnabled          bool
+	ArchiveFallbackEnabled       bool
+	TrendDetectionEnabled        bool
+	SharedCacheEnabled           bool
 
 	EmailTo       string
 	EmailFrom     string
@@ -25,20 +57,108 @@ type Specification struct {
 	EmailUsername string
 	EmailPassword string
 
+	EmailTransport string
+
+	SendGridApiKey     string
+	SesRegion          string
+	SesAccessKeyID     string
+	SesSecretAccessKey string
+	SesSessionToken    string
+
+	EmailTLS            string
+	EmailAuth           string
+	EmailTimeoutSeconds int
+	EmailRetries        int
+
+	EmailInlineImages     bool
+	EmailInlineImageMaxKB int
+	EmailLinkCheck        bool
+
+	OperatorEmailTo string
+
+	DigestOutputDir     string
+	AnalyticsExportPath string
+
+	FeedbackBaseURL string
+	FeedbackSecret  string
+
+	DaemonAddr           string
+	ApiToken             string
+	PersonaReloadSeconds int
+
+	NtfyURL       string
+	NtfyTopic     string
+	NtfyToken     string
+	PushoverToken string
+	PushoverUser  string
+
+	MetricsURL   string
+	MetricsToken string
+
 	DebugMockFeeds       bool
 	DebugMockLLM         bool
 	DebugSkipEmail       bool
 	DebugOutputBenchmark bool
 	DebugMaxEntries      int
 	DebugRedditDump      bool
+	DebugLLMRecordDir    string
+	DebugLLMReplayDir    string
+
+	DumpProviders  []string
+	DumpSnapshots  bool
+	ReplaySnapshot string
 
 	QualityFilterThreshold int
+	MinImportanceScore     int
 
-	PersonasPath string
+	FirstRunMaxEntries int
 
-	SentLogBasePath string
+	BudgetMaxCalls   int
+	BudgetMaxTokens  int
+	BudgetMaxMinutes int
 
-	AuditServiceUrl string
+	FailedItemPlaceholders bool
+	FailedItemNote         string
+	DegradeAfterFailures   int
+	EntryTimeoutSeconds    int
+	QuarantineAfterRuns    int
+	EditMinChange          float64 // Share of a sent post that has to change for it to be sent again as an update; 0 disables
+
+	MegathreadRefreshMinutes int // How often the daemon summarizes the megathreads sent over the last day again; 0 disables
+
+	RunResultPath string // Where the outcome of each run is written as JSON, if set
+
+	DataRoot        string
+	PersonasPath    string
+	SentLogBasePath string
+	FeedMocksPath   string
+	BenchmarkPath   string
+	PluginsPath     string
+	PromptsPath     string
+
+	// Where run data and feed dumps are kept: "local" disk, or an "s3" compatible bucket
+	StorageBackend    string
+	S3Bucket          string
+	S3Prefix          string
+	S3Region          string
+	S3Endpoint        string
+	S3PathStyle       bool
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+	S3SessionToken    string
+
+	FailedURLThreshold int
+	FailedURLTTLHours  int
+
+	FetchRespectRobots     bool
+	FetchDomainIntervalMs  int
+	FetchDomainConcurrency int
+	FetchDenyDomains       []string
+	FetchAllowDomains      []string
+
+	AuditServiceUrl       string
+	AuditServiceToken     string
+	AuditServiceGzipMinKB int
 
 	SendBenchmarkToAuditService bool
 
@@ -47,31 +167,59 @@ type Specification struct {
 	RedditSecret   string
 	RedditUsername string
 	RedditPassword string
+
+	// Mastodon API configuration
+	MastodonAccessToken string // Optional, for instances that only serve timelines to signed-in users
+
+	// IMAP configuration, for newsletter personas
+	ImapAddr     string // host:port of the IMAP server
+	ImapUsername string
+	ImapPassword string
+	ImapTLS      bool // Connect with implicit TLS (port 993); plain connections are only for local servers
 }
 
 // Validate checks if the specification is valid
 func (s *Specification) Validate() error {
 	// Email configuration validation
-	if s.EmailHost == "" {
-		return fmt.Errorf("email host is required")
-	}
-	if s.EmailPort == "" {
-		return fmt.Errorf("email port is required")
-	}
-	if _, err := strconv.Atoi(s.EmailPort); err != nil {
-		return fmt.Errorf("invalid email port: %w", err)
-	}
-	if s.EmailUsername == "" {
-		return fmt.Errorf("email username is required")
-	}
-	if s.EmailPassword == "" {
-		return fmt.Errorf("email password is required")
+	switch s.EmailTransport {
+	case "", "smtp":
+		if s.EmailHost == "" {
+			return fmt.Errorf("email host is required")
+		}
+		if s.EmailPort == "" {
+			return fmt.Errorf("email port is required")
+		}
+		if _, err := strconv.Atoi(s.EmailPort); err != nil {
+			return fmt.Errorf("invalid email port: %w", err)
+		}
+		if s.EmailUsername == "" {
+			return fmt.Errorf("email username is required")
+		}
+		if s.EmailPassword == "" {
+			return fmt.Errorf("email password is required")
+		}
+	case "sendgrid":
+		if s.SendGridApiKey == "" {
+			return fmt.Errorf("SendGrid API key is required for the sendgrid email transport")
+		}
+	case "ses":
+		if s.SesRegion == "" || s.SesAccessKeyID == "" || s.SesSecretAccessKey == "" {
+			return fmt.Errorf("SES region, access key ID and secret access key are required for the ses email transport")
+		}
+	default:
+		return fmt.Errorf("unsupported email transport %q, must be smtp, sendgrid or ses", s.EmailTransport)
 	}
 	if s.EmailFrom == "" {
 		return fmt.Errorf("email from address is required")
 	}
-	if s.EmailTo == "" {
-		return fmt.Errorf("email to address is required")
+	if s.EmailTimeoutSeconds < 0 {
+		return fmt.Errorf("email timeout cannot be negative")
+	}
+	if s.EmailRetries < 0 {
+		return fmt.Errorf("email retries cannot be negative")
+	}
+	if s.EmailInlineImageMaxKB < 0 {
+		return fmt.Errorf("email inline image size limit cannot be negative")
 	}
 
 	// LLM configuration validation
@@ -90,19 +238,116 @@ func (s *Specification) Validate() error {
 		if s.LlmImageEnabled && s.LlmImageModel == "" {
 			return fmt.Errorf("LLM image model is required when image processing is enabled")
 		}
+		if s.LlmImageConcurrency < 1 {
+			return fmt.Errorf("LLM image concurrency must be at least 1")
+		}
+		if s.LlmImageBatchSize < 1 {
+			return fmt.Errorf("LLM image batch size must be at least 1")
+		}
+		if s.LlmImageMaxDimension < 0 {
+			return fmt.Errorf("LLM image max dimension cannot be negative")
+		}
+		if s.LlmContextTokens < 0 {
+			return fmt.Errorf("LLM context tokens cannot be negative")
+		}
+		if s.LlmCacheTTLHours < 0 {
+			return fmt.Errorf("LLM cache TTL cannot be negative")
+		}
+		switch s.LlmResponseFormat {
+		case "auto", "json_schema", "json_object", "prompt", "grammar", "guided_json":
+		default:
+			return fmt.Errorf("LLM response format must be auto, json_schema, json_object, prompt, grammar or guided_json, got %q", s.LlmResponseFormat)
+		}
+	}
+
+	if s.LlmInputCostPerMillion < 0 || s.LlmOutputCostPerMillion < 0 {
+		return fmt.Errorf("LLM token costs cannot be negative")
+	}
+	if s.BudgetMaxCalls < 0 || s.BudgetMaxTokens < 0 || s.BudgetMaxMinutes < 0 {
+		return fmt.Errorf("run budget limits cannot be negative")
+	}
+	if s.DegradeAfterFailures < 0 {
+		return fmt.Errorf("degrade after failures cannot be negative")
+	}
+	if s.EntryTimeoutSeconds < 0 {
+		return fmt.Errorf("entry timeout cannot be negative")
+	}
+	if s.QuarantineAfterRuns < 0 {
+		return fmt.Errorf("quarantine after runs cannot be negative")
+	}
+	if s.EditMinChange < 0 || s.EditMinChange > 1 {
+		return fmt.Errorf("edit min change must be between 0 and 1")
+	}
+	if s.MegathreadRefreshMinutes < 0 {
+		return fmt.Errorf("megathread refresh minutes cannot be negative")
 	}
 
 	// Debug configuration validation
 	if s.DebugMaxEntries < 0 {
 		return fmt.Errorf("debug max entries cannot be negative")
 	}
+	if s.DebugLLMRecordDir != "" && s.DebugLLMReplayDir != "" {
+		return fmt.Errorf("LLM requests cannot be recorded and replayed at the same time")
+	}
+	if s.DebugLLMRecordDir != "" && s.DebugMockLLM {
+		return fmt.Errorf("LLM recording requires a real LLM, disable mock mode")
+	}
+	if s.DebugLLMReplayDir != "" {
+		if !s.DebugMockLLM {
+			return fmt.Errorf("LLM replay requires mock mode")
+		}
+		if s.LlmModel == "" {
+			return fmt.Errorf("LLM model is required to replay recordings")
+		}
+		if s.LlmImageEnabled && s.LlmImageModel == "" {
+			return fmt.Errorf("LLM image model is required to replay recordings when image processing is enabled")
+		}
+	}
+
+	if s.FailedURLTTLHours < 0 {
+		return fmt.Errorf("failed URL TTL hours cannot be negative")
+	}
+
+	if s.FetchDomainIntervalMs < 0 {
+		return fmt.Errorf("fetch domain interval cannot be negative")
+	}
+	if s.FetchDomainConcurrency < 0 {
+		return fmt.Errorf("fetch domain concurrency cannot be negative")
+	}
 
 	if s.DebugOutputBenchmark && s.AuditServiceUrl == "" {
 		return fmt.Errorf("audit service URL is required when benchmark output is enabled")
 	}
 
-	// Reddit API configuration validation (required unless using mock feeds)
-	if !s.DebugMockFeeds {
+	if s.SendBenchmarkToAuditService && s.AuditServiceUrl == "" {
+		return fmt.Errorf("audit service URL is required when sending benchmarks to the audit service")
+	}
+
+	if s.PersonaReloadSeconds < 0 {
+		return fmt.Errorf("persona reload interval cannot be negative")
+	}
+
+	if s.AuditServiceGzipMinKB < 0 {
+		return fmt.Errorf("audit service gzip threshold cannot be negative")
+	}
+
+	switch s.StorageBackend {
+	case "", "local":
+	case "s3":
+		if s.S3Bucket == "" || s.S3Region == "" || s.S3AccessKeyID == "" || s.S3SecretAccessKey == "" {
+			return fmt.Errorf("S3 bucket, region, access key ID and secret access key are required for the s3 storage backend")
+		}
+	default:
+		return fmt.Errorf("storage backend must be local or s3, got %q", s.StorageBackend)
+	}
+
+	if s.ReplaySnapshot != "" && (s.DumpSnapshots || s.DebugRedditDump || len(s.DumpProviders) > 0) {
+		return fmt.Errorf("feeds cannot be dumped while replaying a snapshot")
+	}
+
+	// Reddit API configuration validation. Without any credentials Reddit is read from the public
+	// JSON endpoints; credentials that are set have to be complete.
+	if !s.DebugMockFeeds && s.ReplaySnapshot == "" && s.HasRedditCredentials() {
 		if s.RedditClientID == "" {
 			return fmt.Errorf("Reddit client ID is required")
 		}
@@ -120,27 +365,138 @@ func (s *Specification) Validate() error {
 	return nil
 }
 
+// HasRedditCredentials reports whether any Reddit API credential is set
+func (s *Specification) HasRedditCredentials() bool {
+	return s.RedditClientID != "" || s.RedditSecret != "" || s.RedditUsername != "" || s.RedditPassword != ""
+}
+
+// RunBudget returns the limits of each persona run
+func (s *Specification) RunBudget() budget.Limits {
+	return budget.Limits{
+		MaxCalls:    s.BudgetMaxCalls,
+		MaxTokens:   int64(s.BudgetMaxTokens),
+		MaxDuration: time.Duration(s.BudgetMaxMinutes) * time.Minute,
+	}
+}
+
+// DumpEnabled reports whether the named feed provider should dump fetched data to disk.
+// The legacy ANP_DEBUG_REDDIT_DUMP flag and ANP_DUMP_SNAPSHOTS enable dumping for every provider.
+func (s *Specification) DumpEnabled(provider string) bool {
+	if s.DebugRedditDump || s.DumpSnapshots {
+		return true
+	}
+	for _, p := range s.DumpProviders {
+		if strings.EqualFold(p, provider) || p == "all" {
+			return true
+		}
+	}
+	return false
+}
+
 func GetConfig() (*Specification, error) {
 	// Load .env file if it exists
 	if err := godotenv.Load(); err != nil {
 		log.Printf("No .env file found or error loading it: %v", err)
 	}
 
+	paths := LoadPaths()
+
+	// API keys and passwords can also come from *_FILE variables or Vault
+	resolver, err := secrets.FromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("could not configure secrets: %w", err)
+	}
+	var secretErr error
+	secret := func(key, defaultValue string) string {
+		value, err := resolver.Lookup(key)
+		if err != nil && secretErr == nil {
+			secretErr = err
+		}
+		if value == "" {
+			return defaultValue
+		}
+		return value
+	}
+
 	s := &Specification{
 		LlmUrl:    os.Getenv("ANP_LLM_URL"),
-		LlmApiKey: os.Getenv("ANP_LLM_API_KEY"),
+		LlmApiKey: secret("ANP_LLM_API_KEY", ""),
 		LlmModel:  os.Getenv("ANP_LLM_MODEL"),
 
 		LlmImageEnabled:      getBoolEnv("ANP_LLM_IMAGE_ENABLED", false),
 		LlmImageModel:        os.Getenv("ANP_LLM_IMAGE_MODEL"),
+		LlmImageConcurrency:  getIntEnv("ANP_LLM_IMAGE_CONCURRENCY", 2),
+		LlmImageBatchSize:    getIntEnv("ANP_LLM_IMAGE_BATCH_SIZE", 1),
+		LlmImageMaxDimension: getIntEnv("ANP_LLM_IMAGE_MAX_DIMENSION", imageprep.DefaultOptions.MaxDimension),
 		LlmUrlSummaryEnabled: getBoolEnv("ANP_LLM_URL_SUMMARY_ENABLED", true),
+		LlmContextTokens:     getIntEnv("ANP_LLM_CONTEXT_TOKENS", 0),
+		LlmCacheTTLHours:     getIntEnv("ANP_LLM_CACHE_TTL_HOURS", 24),
+		LlmVerifyReprompt:    getBoolEnv("ANP_LLM_VERIFY_REPROMPT", false),
+		LlmEmbeddingModel:    os.Getenv("ANP_LLM_EMBEDDING_MODEL"),
+		LlmTriageModel:       os.Getenv("ANP_LLM_TRIAGE_MODEL"),
+		LlmResponseFormat:    getEnv("ANP_LLM_RESPONSE_FORMAT", "auto"),
+		LlmSchemaStrict:      getBoolEnv("ANP_LLM_SCHEMA_STRICT", true),
+		LlmReasoning:         os.Getenv("ANP_LLM_REASONING"),
+
+		LlmInputCostPerMillion:  getFloatEnv("ANP_LLM_INPUT_COST_PER_MILLION", 0),
+		LlmOutputCostPerMillion: getFloatEnv("ANP_LLM_OUTPUT_COST_PER_MILLION", 0),
+
+		OcrEnabled:       getBoolEnv("ANP_OCR_ENABLED", false),
+		OcrTesseractPath: getEnv("ANP_OCR_TESSERACT_PATH", "tesseract"),
+		OcrLanguages:     getEnv("ANP_OCR_LANGUAGES", "eng"),
+
+		HuggingFaceEnrichmentEnabled: getBoolEnv("ANP_HUGGINGFACE_ENRICHMENT_ENABLED", true),
+		TwitterUnrollEnabled:         getBoolEnv("ANP_TWITTER_UNROLL_ENABLED", true),
+		ResolveShortLinks:            getBoolEnv("ANP_RESOLVE_SHORT_LINKS", true),
+		SiteMetadataEnabled:          getBoolEnv("ANP_SITE_METADATA_ENABLED", true),
+		ArchiveFallbackEnabled:       getBoolEnv("ANP_ARCHIVE_FALLBACK_ENABLED", true),
+		TrendDetectionEnabled:        getBoolEnv("ANP_TREND_DETECTION_ENABLED", true),
+		SharedCacheEnabled:           getBoolEnv("ANP_SHARED_CACHE_ENABLED", true),
 
 		EmailTo:       os.Getenv("ANP_EMAIL_TO"),
 		EmailFrom:     os.Getenv("ANP_EMAIL_FROM"),
 		EmailHost:     os.Getenv("ANP_EMAIL_HOST"),
 		EmailPort:     os.Getenv("ANP_EMAIL_PORT"),
 		EmailUsername: os.Getenv("ANP_EMAIL_USERNAME"),
-		EmailPassword: os.Getenv("ANP_EMAIL_PASSWORD"),
+		EmailPassword: secret("ANP_EMAIL_PASSWORD", ""),
+
+		EmailTransport: getEnv("ANP_EMAIL_TRANSPORT", "smtp"),
+
+		SendGridApiKey:     secret("ANP_SENDGRID_API_KEY", ""),
+		SesRegion:          getEnv("ANP_SES_REGION", os.Getenv("AWS_REGION")),
+		SesAccessKeyID:     getEnv("ANP_SES_ACCESS_KEY_ID", os.Getenv("AWS_ACCESS_KEY_ID")),
+		SesSecretAccessKey: secret("ANP_SES_SECRET_ACCESS_KEY", secret("AWS_SECRET_ACCESS_KEY", "")),
+		SesSessionToken:    secret("ANP_SES_SESSION_TOKEN", secret("AWS_SESSION_TOKEN", "")),
+
+		EmailTLS:            getEnv("ANP_EMAIL_TLS", "auto"),
+		EmailAuth:           getEnv("ANP_EMAIL_AUTH", "plain"),
+		EmailTimeoutSeconds: getIntEnv("ANP_EMAIL_TIMEOUT_SECONDS", 30),
+		EmailRetries:        getIntEnv("ANP_EMAIL_RETRIES", 3),
+
+		EmailInlineImages:     getBoolEnv("ANP_EMAIL_INLINE_IMAGES", false),
+		EmailInlineImageMaxKB: getIntEnv("ANP_EMAIL_INLINE_IMAGE_MAX_KB", 200),
+		EmailLinkCheck:        getBoolEnv("ANP_EMAIL_LINK_CHECK", true),
+
+		OperatorEmailTo: os.Getenv("ANP_OPERATOR_EMAIL_TO"),
+
+		DigestOutputDir:     os.Getenv("ANP_DIGEST_OUTPUT_DIR"),
+		AnalyticsExportPath: os.Getenv("ANP_ANALYTICS_EXPORT_PATH"),
+
+		FeedbackBaseURL: os.Getenv("ANP_FEEDBACK_BASE_URL"),
+		FeedbackSecret:  secret("ANP_FEEDBACK_SECRET", ""),
+
+		DaemonAddr:           getEnv("ANP_DAEMON_ADDR", ":8080"),
+		ApiToken:             secret("ANP_API_TOKEN", ""),
+		PersonaReloadSeconds: getIntEnv("ANP_PERSONA_RELOAD_SECONDS", 5),
+
+		NtfyURL:       getEnv("ANP_NTFY_URL", "https://ntfy.sh"),
+		NtfyTopic:     os.Getenv("ANP_NTFY_TOPIC"),
+		NtfyToken:     secret("ANP_NTFY_TOKEN", ""),
+		PushoverToken: secret("ANP_PUSHOVER_TOKEN", ""),
+		PushoverUser:  os.Getenv("ANP_PUSHOVER_USER"),
+
+		MetricsURL:   os.Getenv("ANP_METRICS_URL"),
+		MetricsToken: secret("ANP_METRICS_TOKEN", ""),
 
 		DebugMockFeeds:       getBoolEnv("ANP_DEBUG_MOCK_FEEDS", false),
 		DebugMockLLM:         getBoolEnv("ANP_DEBUG_MOCK_LLM", false),
@@ -148,22 +504,84 @@ func GetConfig() (*Specification, error) {
 		DebugOutputBenchmark: getBoolEnv("ANP_DEBUG_OUTPUT_BENCHMARK", false),
 		DebugMaxEntries:      getIntEnv("ANP_DEBUG_MAX_ENTRIES", 0),
 		DebugRedditDump:      getBoolEnv("ANP_DEBUG_REDDIT_DUMP", false),
+		DebugLLMRecordDir:    getEnv("ANP_DEBUG_LLM_RECORD_DIR", ""),
+		DebugLLMReplayDir:    getEnv("ANP_DEBUG_LLM_REPLAY_DIR", ""),
 
-		QualityFilterThreshold: getIntEnv("ANP_QUALITY_FILTER_THRESHOLD", 10),
-
-		PersonasPath: os.Getenv("ANP_PERSONAS_PATH"),
-
-		SentLogBasePath: os.Getenv("ANP_SENT_LOG_BASE_PATH"),
+		DumpProviders:  getListEnv("ANP_DUMP_PROVIDERS", nil),
+		DumpSnapshots:  getBoolEnv("ANP_DUMP_SNAPSHOTS", false),
+		ReplaySnapshot: getEnv("ANP_REPLAY_SNAPSHOT", ""),
 
-		AuditServiceUrl: os.Getenv("ANP_AUDIT_SERVICE_URL"),
+		QualityFilterThreshold: getIntEnv("ANP_QUALITY_FILTER_THRESHOLD", 10),
+		MinImportanceScore:     getIntEnv("ANP_MIN_IMPORTANCE_SCORE", 0),
+
+		FirstRunMaxEntries: getIntEnv("ANP_FIRST_RUN_MAX_ENTRIES", 10),
+
+		BudgetMaxCalls:   getIntEnv("ANP_BUDGET_MAX_CALLS", 0),
+		BudgetMaxTokens:  getIntEnv("ANP_BUDGET_MAX_TOKENS", 0),
+		BudgetMaxMinutes: getIntEnv("ANP_BUDGET_MAX_MINUTES", 0),
+
+		FailedItemPlaceholders: getBoolEnv("ANP_FAILED_ITEM_PLACEHOLDERS", true),
+		FailedItemNote:         os.Getenv("ANP_FAILED_ITEM_NOTE"),
+		DegradeAfterFailures:   getIntEnv("ANP_DEGRADE_AFTER_FAILURES", 5),
+		EntryTimeoutSeconds:    getIntEnv("ANP_ENTRY_TIMEOUT_SECONDS", 600),
+		QuarantineAfterRuns:    getIntEnv("ANP_QUARANTINE_AFTER_RUNS", 3),
+		EditMinChange:          getFloatEnv("ANP_EDIT_MIN_CHANGE", 0.3),
+
+		MegathreadRefreshMinutes: getIntEnv("ANP_MEGATHREAD_REFRESH_MINUTES", 0),
+
+		RunResultPath: os.Getenv("ANP_RUN_RESULT_PATH"),
+
+		DataRoot:        paths.DataRoot,
+		PersonasPath:    paths.Personas,
+		SentLogBasePath: paths.State,
+		FeedMocksPath:   paths.FeedMocks,
+		BenchmarkPath:   paths.Benchmarks,
+		PluginsPath:     paths.Plugins,
+		PromptsPath:     paths.Prompts,
+
+		StorageBackend:    strings.ToLower(getEnv("ANP_STORAGE_BACKEND", "local")),
+		S3Bucket:          os.Getenv("ANP_S3_BUCKET"),
+		S3Prefix:          os.Getenv("ANP_S3_PREFIX"),
+		S3Region:          getEnv("ANP_S3_REGION", os.Getenv("AWS_REGION")),
+		S3Endpoint:        os.Getenv("ANP_S3_ENDPOINT"),
+		S3PathStyle:       getBoolEnv("ANP_S3_PATH_STYLE", os.Getenv("ANP_S3_ENDPOINT") != ""),
+		S3AccessKeyID:     getEnv("ANP_S3_ACCESS_KEY_ID", os.Getenv("AWS_ACCESS_KEY_ID")),
+		S3SecretAccessKey: secret("ANP_S3_SECRET_ACCESS_KEY", secret("AWS_SECRET_ACCESS_KEY", "")),
+		S3SessionToken:    secret("ANP_S3_SESSION_TOKEN", secret("AWS_SESSION_TOKEN", "")),
+
+		FailedURLThreshold: getIntEnv("ANP_FAILED_URL_THRESHOLD", 3),
+		FailedURLTTLHours:  getIntEnv("ANP_FAILED_URL_TTL_HOURS", 168),
+
+		FetchRespectRobots:     getBoolEnv("ANP_FETCH_RESPECT_ROBOTS", true),
+		FetchDomainIntervalMs:  getIntEnv("ANP_FETCH_DOMAIN_INTERVAL_MS", 1000),
+		FetchDomainConcurrency: getIntEnv("ANP_FETCH_DOMAIN_CONCURRENCY", 2),
+		FetchDenyDomains:       getListEnv("ANP_FETCH_DENY_DOMAINS", domainfilter.DefaultDenyDomains),
+		FetchAllowDomains:      getListEnv("ANP_FETCH_ALLOW_DOMAINS", nil),
+
+		AuditServiceUrl:       os.Getenv("ANP_AUDIT_SERVICE_URL"),
+		AuditServiceToken:     secret("ANP_AUDIT_SERVICE_TOKEN", ""),
+		AuditServiceGzipMinKB: getIntEnv("ANP_AUDIT_SERVICE_GZIP_MIN_KB", 64),
 
 		SendBenchmarkToAuditService: getBoolEnv("ANP_SEND_BENCHMARK_TO_AUDIT_SERVICE", false),
 
 		// Reddit API configuration
 		RedditClientID: os.Getenv("ANP_REDDIT_CLIENT_ID"),
-		RedditSecret:   os.Getenv("ANP_REDDIT_CLIENT_SECRET"),
+		RedditSecret:   secret("ANP_REDDIT_CLIENT_SECRET", ""),
 		RedditUsername: os.Getenv("ANP_REDDIT_USERNAME"),
-		RedditPassword: os.Getenv("ANP_REDDIT_PASSWORD"),
+		RedditPassword: secret("ANP_REDDIT_PASSWORD", ""),
+
+		// Mastodon API configuration
+		MastodonAccessToken: secret("ANP_MASTODON_ACCESS_TOKEN", ""),
+
+		// IMAP configuration
+		ImapAddr:     os.Getenv("ANP_IMAP_ADDR"),
+		ImapUsername: os.Getenv("ANP_IMAP_USERNAME"),
+		ImapPassword: secret("ANP_IMAP_PASSWORD", ""),
+		ImapTLS:      getBoolEnv("ANP_IMAP_TLS", true),
+	}
+
+	if secretErr != nil {
+		return nil, fmt.Errorf("could not load secrets: %w", secretErr)
 	}
 
 	// Validate the configuration
@@ -174,6 +592,14 @@ func GetConfig() (*Specification, error) {
 	return s, nil
 }
 
+// getEnv gets a string environment variable with a default value
+func getEnv(key string, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
 // getBoolEnv gets a boolean environment variable with a default value
 func getBoolEnv(key string, defaultValue bool) bool {
 	value := os.Getenv(key)
@@ -199,3 +625,31 @@ func getIntEnv(key string, defaultValue int) int {
 	}
 	return intValue
 }
+
+// getFloatEnv gets a floating point environment variable with a default value
+func getFloatEnv(key string, defaultValue float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	floatValue, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return defaultValue
+	}
+	return floatValue
+}
+
+// getListEnv gets a comma-separated list environment variable with a default value
+func getListEnv(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,206 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/http/awsauth"
+)
+
+// S3Config configures an S3 or S3-compatible store, such as MinIO or Cloudflare R2
+type S3Config struct {
+	Bucket          string
+	Prefix          string // Prepended to every key, e.g. "anp/benchmarkresults"
+	Region          string
+	Endpoint        string // Base URL of an S3-compatible service; empty for AWS
+	PathStyle       bool   // Address the bucket in the path instead of the host name
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // Only for temporary credentials
+}
+
+// S3 stores blobs as objects in an S3 bucket, signing requests with AWS Signature Version 4
+type S3 struct {
+	config S3Config
+	base   *url.URL // URL of the bucket
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewS3 creates a store that keeps its blobs in the bucket of config
+func NewS3(config S3Config) (*S3, error) {
+	if config.Bucket == "" || config.Region == "" || config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, errors.New("S3 bucket, region, access key ID and secret access key are required")
+	}
+	config.Prefix = strings.Trim(config.Prefix, "/")
+
+	var base *url.URL
+	var err error
+	switch {
+	case config.Endpoint == "":
+		base, err = url.Parse(fmt.Sprintf("https://%s.s3.%s.amazonaws.com", config.Bucket, config.Region))
+		if config.PathStyle {
+			base, err = url.Parse(fmt.Sprintf("https://s3.%s.amazonaws.com/%s", config.Region, config.Bucket))
+		}
+	case config.PathStyle:
+		base, err = url.Parse(strings.TrimRight(config.Endpoint, "/") + "/" + config.Bucket)
+	default:
+		base, err = url.Parse(config.Endpoint)
+		if err == nil {
+			base.Host = config.Bucket + "." + base.Host
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3 endpoint: %w", err)
+	}
+
+	return &S3{
+		config: config,
+		base:   base,
+		client: &http.Client{Timeout: 2 * time.Minute},
+		now:    time.Now,
+	}, nil
+}
+
+// objectKey returns the key of a blob in the bucket, with the prefix
+func (s *S3) objectKey(key string) string {
+	if s.config.Prefix == "" {
+		return key
+	}
+	return s.config.Prefix + "/" + key
+}
+
+// request builds and signs a request for the object key, or the bucket if key is empty
+func (s *S3) request(ctx context.Context, method, key string, query url.Values, body []byte) (*http.Request, error) {
+	u := *s.base
+	u.Path = strings.TrimRight(u.Path, "/") + "/" + key
+	u.RawPath = awsauth.EscapePath(u.Path)
+	u.RawQuery = strings.ReplaceAll(query.Encode(), "+", "%20") // As signed
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("could not create S3 request: %w", err)
+	}
+	sum := sha256.Sum256(body)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(sum[:]))
+	if s.config.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.config.SessionToken)
+	}
+	awsauth.SignV4(req, body, s.config.AccessKeyID, s.config.SecretAccessKey, s.config.Region, "s3", s.now())
+	return req, nil
+}
+
+// do sends a request and returns the response body, or an error for statuses other than 200
+func (s *S3) do(req *http.Request) ([]byte, int, error) {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("S3 request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.StatusCode, fmt.Errorf("could not read S3 response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.StatusCode, fmt.Errorf("S3 %s %s returned status %s: %s", req.Method, req.URL.Path, resp.Status, s3ErrorMessage(body))
+	}
+	return body, resp.StatusCode, nil
+}
+
+// Put uploads data as the object of key
+func (s *S3) Put(ctx context.Context, key string, data []byte) error {
+	req, err := s.request(ctx, http.MethodPut, s.objectKey(key), nil, data)
+	if err != nil {
+		return err
+	}
+	_, _, err = s.do(req)
+	return err
+}
+
+// Get downloads the object of key
+func (s *S3) Get(ctx context.Context, key string) ([]byte, error) {
+	req, err := s.request(ctx, http.MethodGet, s.objectKey(key), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	body, status, err := s.do(req)
+	if status == http.StatusNotFound {
+		return nil, ErrNotExist
+	}
+	return body, err
+}
+
+type listBucketResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List returns the keys of the objects that start with prefix, without the store's prefix
+func (s *S3) List(ctx context.Context, prefix string) ([]string, error) {
+	full := s.objectKey(prefix)
+	if prefix == "" && s.config.Prefix != "" {
+		full = s.config.Prefix + "/"
+	}
+
+	var keys []string
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {full}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		req, err := s.request(ctx, http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+		body, _, err := s.do(req)
+		if err != nil {
+			return nil, err
+		}
+		var result listBucketResult
+		if err := xml.Unmarshal(body, &result); err != nil {
+			return nil, fmt.Errorf("could not parse S3 listing: %w", err)
+		}
+		for _, object := range result.Contents {
+			key := object.Key
+			if s.config.Prefix != "" {
+				key = strings.TrimPrefix(key, s.config.Prefix+"/")
+			}
+			keys = append(keys, key)
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return keys, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+func (s *S3) String() string {
+	return "s3://" + path.Join(s.config.Bucket, s.config.Prefix)
+}
+
+// s3ErrorMessage extracts the message of an S3 error response
+func s3ErrorMessage(body []byte) string {
+	var s3Error struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	if err := xml.Unmarshal(body, &s3Error); err == nil && s3Error.Code != "" {
+		return s3Error.Code + ": " + s3Error.Message
+	}
+	return strings.TrimSpace(string(body))
+}
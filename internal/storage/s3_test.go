@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBucket serves a path-style S3 bucket named "anp" from memory, listing one key per page
+func fakeBucket(t *testing.T) *httptest.Server {
+	var mu sync.Mutex
+	objects := make(map[string][]byte)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/"))
+		assert.Contains(t, r.Header.Get("Authorization"), "/us-east-1/s3/aws4_request")
+		assert.NotEmpty(t, r.Header.Get("X-Amz-Content-Sha256"))
+
+		key, ok := strings.CutPrefix(r.URL.Path, "/anp/")
+		if !ok {
+			http.Error(w, "<Error><Code>NoSuchBucket</Code><Message>The specified bucket does not exist</Message></Error>", http.StatusNotFound)
+			return
+		}
+		switch {
+		case r.Method == http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			objects[key] = body
+		case key == "" && r.URL.Query().Get("list-type") == "2":
+			var keys []string
+			for k := range objects {
+				if strings.HasPrefix(k, r.URL.Query().Get("prefix")) && k > r.URL.Query().Get("continuation-token") {
+					keys = append(keys, k)
+				}
+			}
+			sort.Strings(keys)
+			fmt.Fprint(w, "<ListBucketResult>")
+			if len(keys) > 0 {
+				fmt.Fprintf(w, "<Contents><Key>%s</Key></Contents>", keys[0])
+			}
+			if len(keys) > 1 {
+				fmt.Fprintf(w, "<IsTruncated>true</IsTruncated><NextContinuationToken>%s</NextContinuationToken>", keys[0])
+			}
+			fmt.Fprint(w, "</ListBucketResult>")
+		default:
+			data, ok := objects[key]
+			if !ok {
+				http.Error(w, "<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>", http.StatusNotFound)
+				return
+			}
+			w.Write(data)
+		}
+	}))
+}
+
+func TestS3(t *testing.T) {
+	server := fakeBucket(t)
+	defer server.Close()
+	ctx := context.Background()
+
+	store, err := NewS3(S3Config{
+		Bucket:          "anp",
+		Prefix:          "/prod/benchmarkresults/",
+		Region:          "us-east-1",
+		Endpoint:        server.URL,
+		PathStyle:       true,
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "secret",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "s3://anp/prod/benchmarkresults", store.String())
+
+	require.NoError(t, store.Put(ctx, "benchmark.json", []byte(`{"a":1}`)))
+	require.NoError(t, store.Put(ctx, "backup/benchmark.json", []byte(`{}`)))
+	require.NoError(t, store.Put(ctx, "benchmark_Local LLaMA_20250307.json", []byte(`{}`)))
+
+	data, err := store.Get(ctx, "benchmark.json")
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":1}`, string(data))
+
+	_, err = store.Get(ctx, "missing.json")
+	assert.ErrorIs(t, err, ErrNotExist)
+
+	keys, err := store.List(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"backup/benchmark.json", "benchmark.json", "benchmark_Local LLaMA_20250307.json"}, keys)
+
+	keys, err = store.List(ctx, "benchmark")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"benchmark.json", "benchmark_Local LLaMA_20250307.json"}, keys)
+}
+
+func TestS3_Errors(t *testing.T) {
+	server := fakeBucket(t)
+	defer server.Close()
+
+	_, err := NewS3(S3Config{Bucket: "anp", Region: "us-east-1"})
+	assert.Error(t, err, "credentials are required")
+
+	store, err := NewS3(S3Config{Bucket: "other", Region: "us-east-1", Endpoint: server.URL, PathStyle: true, AccessKeyID: "AKID", SecretAccessKey: "secret"})
+	require.NoError(t, err)
+	err = store.Put(context.Background(), "benchmark.json", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "NoSuchBucket: The specified bucket does not exist")
+}
+
+func TestNewS3_Addressing(t *testing.T) {
+	tests := []struct {
+		name   string
+		config S3Config
+		want   string
+	}{
+		{"aws virtual host", S3Config{Region: "eu-west-1"}, "https://anp.s3.eu-west-1.amazonaws.com"},
+		{"aws path style", S3Config{Region: "eu-west-1", PathStyle: true}, "https://s3.eu-west-1.amazonaws.com/anp"},
+		{"endpoint path style", S3Config{Region: "auto", Endpoint: "http://minio:9000/", PathStyle: true}, "http://minio:9000/anp"},
+		{"endpoint virtual host", S3Config{Region: "auto", Endpoint: "https://r2.example.com"}, "https://anp.r2.example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.Bucket = "anp"
+			tt.config.AccessKeyID = "AKID"
+			tt.config.SecretAccessKey = "secret"
+			store, err := NewS3(tt.config)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, store.base.String())
+		})
+	}
+}
@@ -0,0 +1,169 @@
+// Package storage keeps run data and feed dumps on local disk or in S3-compatible object
+// storage, so containerized deployments keep them when the container is replaced.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNotExist is returned by Get for a key that is not stored
+var ErrNotExist = errors.New("object does not exist")
+
+// Store keeps blobs by slash-separated key, such as "snapshots/20250307-060000/reddit/llama.json"
+type Store interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	List(ctx context.Context, prefix string) ([]string, error) // Keys starting with prefix, sorted
+	String() string                                            // Location of the store, for logs
+}
+
+// Local stores blobs as files below a directory
+type Local struct {
+	dir string
+}
+
+// NewLocal creates a store that keeps its files below dir
+func NewLocal(dir string) *Local {
+	return &Local{dir: dir}
+}
+
+func (l *Local) path(key string) string {
+	return filepath.Join(l.dir, filepath.FromSlash(key))
+}
+
+// Put writes data to the file of key, creating its directory if needed
+func (l *Local) Put(ctx context.Context, key string, data []byte) error {
+	p := l.path(key)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return fmt.Errorf("could not create directory for %s: %w", key, err)
+	}
+	if err := os.WriteFile(p, data, 0644); err != nil {
+		return fmt.Errorf("could not write %s: %w", key, err)
+	}
+	return nil
+}
+
+// Get reads the file of key
+func (l *Local) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := os.ReadFile(l.path(key))
+	if os.IsNotExist(err) {
+		return nil, ErrNotExist
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", key, err)
+	}
+	return data, nil
+}
+
+// List returns the keys of the files below the directory that start with prefix
+func (l *Local) List(ctx context.Context, prefix string) ([]string, error) {
+	keys, err := listFiles(l.dir, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	var matched []string
+	for _, key := range keys {
+		if strings.HasPrefix(key, prefix) {
+			matched = append(matched, key)
+		}
+	}
+	return matched, nil
+}
+
+func (l *Local) String() string {
+	return l.dir
+}
+
+// listFiles returns the slash-separated paths, relative to dir, of the files below it that were
+// modified at or after since. A missing directory has no files.
+func listFiles(dir string, since time.Time) ([]string, error) {
+	var keys []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && p == dir {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if !since.IsZero() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			if info.ModTime().Before(since) {
+				return nil
+			}
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not list %s: %w", dir, err)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Upload copies the files below dir that were modified at or after since into store, keyed by
+// their path relative to dir. It returns the number of files copied.
+func Upload(ctx context.Context, store Store, dir string, since time.Time) (int, error) {
+	keys, err := listFiles(dir, since)
+	if err != nil {
+		return 0, err
+	}
+	for i, key := range keys {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(key)))
+		if err != nil {
+			return i, fmt.Errorf("could not read %s: %w", key, err)
+		}
+		if err := store.Put(ctx, key, data); err != nil {
+			return i, err
+		}
+	}
+	return len(keys), nil
+}
+
+// Download copies the blobs of store that are not in dir yet into it, so a new container can
+// replay feeds dumped by an earlier one. It returns the number of files copied.
+func Download(ctx context.Context, store Store, dir string) (int, error) {
+	keys, err := store.List(ctx, "")
+	if err != nil {
+		return 0, err
+	}
+	copied := 0
+	for _, key := range keys {
+		// Keys come from the bucket, so keep them from writing outside dir
+		if clean := path.Clean(key); path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+			continue
+		}
+		p := filepath.Join(dir, filepath.FromSlash(key))
+		if _, err := os.Stat(p); err == nil {
+			continue
+		}
+		data, err := store.Get(ctx, key)
+		if err != nil {
+			return copied, err
+		}
+		if err := NewLocal(dir).Put(ctx, key, data); err != nil {
+			return copied, err
+		}
+		copied++
+	}
+	return copied, nil
+}
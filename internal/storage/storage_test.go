@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocal(t *testing.T) {
+	ctx := context.Background()
+	store := NewLocal(filepath.Join(t.TempDir(), "results"))
+
+	keys, err := store.List(ctx, "")
+	require.NoError(t, err)
+	assert.Empty(t, keys, "a missing directory has no files")
+
+	require.NoError(t, store.Put(ctx, "snapshots/b.json", []byte("b")))
+	require.NoError(t, store.Put(ctx, "snapshots/a.json", []byte("a")))
+	require.NoError(t, store.Put(ctx, "benchmark.json", []byte("{}")))
+
+	data, err := store.Get(ctx, "snapshots/a.json")
+	require.NoError(t, err)
+	assert.Equal(t, "a", string(data))
+
+	_, err = store.Get(ctx, "missing.json")
+	assert.ErrorIs(t, err, ErrNotExist)
+
+	keys, err = store.List(ctx, "snapshots/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"snapshots/a.json", "snapshots/b.json"}, keys)
+}
+
+func TestUploadAndDownload(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	remote := NewLocal(t.TempDir())
+
+	old := filepath.Join(dir, "old.json")
+	require.NoError(t, os.WriteFile(old, []byte("old"), 0644))
+	past := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(old, past, past))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "reddit"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "reddit", "new.json"), []byte("new"), 0644))
+
+	uploaded, err := Upload(ctx, remote, dir, time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, uploaded, "only files modified since the run started are uploaded")
+
+	require.NoError(t, remote.Put(ctx, "snapshots/20250307-060000/reddit/llama.json", []byte("snapshot")))
+
+	fresh := filepath.Join(t.TempDir(), "feed_mocks")
+	downloaded, err := Download(ctx, remote, fresh)
+	require.NoError(t, err)
+	assert.Equal(t, 2, downloaded)
+
+	data, err := os.ReadFile(filepath.Join(fresh, "snapshots", "20250307-060000", "reddit", "llama.json"))
+	require.NoError(t, err)
+	assert.Equal(t, "snapshot", string(data))
+	downloaded, err = Download(ctx, remote, fresh)
+	require.NoError(t, err)
+	assert.Equal(t, 0, downloaded, "files already on disk are kept")
+}
+
+// keyStore is a store with fixed keys, for keys a local directory cannot hold
+type keyStore []string
+
+func (k keyStore) Put(ctx context.Context, key string, data []byte) error { return nil }
+func (k keyStore) Get(ctx context.Context, key string) ([]byte, error)    { return []byte(key), nil }
+func (k keyStore) List(ctx context.Context, prefix string) ([]string, error) {
+	return k, nil
+}
+func (k keyStore) String() string { return "keys" }
+
+func TestDownload_SkipsKeysOutsideDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "feed_mocks")
+	downloaded, err := Download(context.Background(), keyStore{"../escape.json", "/abs.json", "ok.json"}, dir)
+	require.NoError(t, err)
+	assert.Equal(t, 1, downloaded)
+
+	_, err = os.Stat(filepath.Join(filepath.Dir(dir), "escape.json"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(dir, "ok.json"))
+	assert.NoError(t, err)
+}
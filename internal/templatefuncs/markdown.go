@@ -0,0 +1,77 @@
+package templatefuncs
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	mdCode   = regexp.MustCompile("`([^`]+)`")
+	mdLink   = regexp.MustCompile(`\[([^\]]+)\]\((https?://[^\s)]+)\)`)
+	mdBold   = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	mdItalic = regexp.MustCompile(`\*([^*\s][^*]*)\*|\b_([^_\s][^_]*)_\b`)
+	mdBullet = regexp.MustCompile(`^\s*(?:[-*•]|\d+[.)])\s+`)
+)
+
+// MarkdownToHTML converts the Markdown LLMs commonly produce to HTML: paragraphs separated by
+// blank lines, bulleted and numbered lists, **bold**, *italics*, `code` and [links](https://...).
+// Everything else is escaped, so the output is safe to embed in an email.
+func MarkdownToHTML(md string) string {
+	var b strings.Builder
+	var paragraph []string
+	inList := false
+
+	flushParagraph := func() {
+		if len(paragraph) > 0 {
+			b.WriteString("<p>" + strings.Join(paragraph, "<br>") + "</p>")
+			paragraph = nil
+		}
+	}
+	closeList := func() {
+		if inList {
+			b.WriteString("</ul>")
+			inList = false
+		}
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(md, "\r\n", "\n"), "\n") {
+		if strings.TrimSpace(line) == "" {
+			flushParagraph()
+			closeList()
+			continue
+		}
+		if marker := mdBullet.FindString(line); marker != "" {
+			flushParagraph()
+			if !inList {
+				b.WriteString("<ul>")
+				inList = true
+			}
+			b.WriteString("<li>" + mdInline(line[len(marker):]) + "</li>")
+			continue
+		}
+		closeList()
+		paragraph = append(paragraph, mdInline(strings.TrimSpace(line)))
+	}
+	flushParagraph()
+	closeList()
+	return b.String()
+}
+
+// mdInline escapes a line and converts its inline formatting
+func mdInline(s string) string {
+	s = html.EscapeString(s)
+	// Keep code spans out of the other replacements
+	var code []string
+	s = mdCode.ReplaceAllStringFunc(s, func(m string) string {
+		code = append(code, "<code>"+mdCode.FindStringSubmatch(m)[1]+"</code>")
+		return "\x00"
+	})
+	s = mdLink.ReplaceAllString(s, `<a href="$2">$1</a>`)
+	s = mdBold.ReplaceAllString(s, "<strong>$1$2</strong>")
+	s = mdItalic.ReplaceAllString(s, "<em>$1$2</em>")
+	for _, c := range code {
+		s = strings.Replace(s, "\x00", c, 1)
+	}
+	return s
+}
@@ -0,0 +1,110 @@
+// Package templatefuncs is the function library shared by the email and prompt templates, so
+// templates can do common formatting without code changes.
+package templatefuncs
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// FuncMap returns the shared template functions. Templates may add their own functions on top;
+// a template-specific function with the same name takes precedence.
+//
+//	truncate n s           s cut to at most n characters, ending in "…" if it was cut
+//	mdToHTML s             s with basic Markdown (paragraphs, lists, bold, italics, code, links) as HTML
+//	humanizeTime t         t relative to now, such as "3 hours ago"
+//	pluralize n one many   one if n is 1, many otherwise
+//	joinAnd list           list joined as "a, b and c"
+func FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"truncate": Truncate,
+		"mdToHTML": MarkdownToHTML,
+		"humanizeTime": func(t time.Time) string {
+			return HumanizeTime(t, time.Now())
+		},
+		"pluralize": Pluralize,
+		"joinAnd":   JoinAnd,
+	}
+}
+
+// Truncate cuts s to at most n characters, replacing the end with "…" if it was cut. Words are
+// kept whole where possible. The argument order allows {{.Summary | truncate 100}}.
+func Truncate(n int, s string) string {
+	if n <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	runes := []rune(s)
+	cut := string(runes[:n-1])
+	// Back off to the previous word boundary unless the cut already falls on one
+	if !unicode.IsSpace(runes[n-1]) {
+		if i := strings.LastIndexAny(cut, " \n\t"); i > len(cut)/2 {
+			cut = cut[:i]
+		}
+	}
+	return strings.TrimRight(cut, " \n\t.,;:") + "…"
+}
+
+// HumanizeTime describes t relative to now in English, such as "just now", "5 minutes ago",
+// "yesterday" or "in 2 hours". Times more than a week apart are shown as dates.
+func HumanizeTime(t, now time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	d := now.Sub(t)
+	suffix := " ago"
+	prefix := ""
+	if d < 0 {
+		d = -d
+		suffix = ""
+		prefix = "in "
+	}
+
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return prefix + countNoun(int(d/time.Minute), "minute", "minutes") + suffix
+	case d < 24*time.Hour:
+		return prefix + countNoun(int(d/time.Hour), "hour", "hours") + suffix
+	case d < 48*time.Hour:
+		if prefix != "" {
+			return "tomorrow"
+		}
+		return "yesterday"
+	case d < 7*24*time.Hour:
+		return prefix + countNoun(int(d/(24*time.Hour)), "day", "days") + suffix
+	default:
+		return t.Format("January 2, 2006")
+	}
+}
+
+// Pluralize returns one if n is 1 and many otherwise
+func Pluralize(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+func countNoun(n int, one, many string) string {
+	return fmt.Sprintf("%d %s", n, Pluralize(n, one, many))
+}
+
+// JoinAnd joins items as an English list: "a", "a and b", "a, b and c"
+func JoinAnd(items []string) string {
+	switch len(items) {
+	case 0:
+		return ""
+	case 1:
+		return items[0]
+	default:
+		return strings.Join(items[:len(items)-1], ", ") + " and " + items[len(items)-1]
+	}
+}
@@ -0,0 +1,64 @@
+package templatefuncs
+
+import (
+	"bytes"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "short", Truncate(10, "short"))
+	assert.Equal(t, "The quick brown fox…", Truncate(20, "The quick brown fox jumps over the lazy dog"))
+	assert.Equal(t, "The quick…", Truncate(14, "The quick brown fox"), "does not split words")
+	assert.Equal(t, "Überlänge…", Truncate(10, "Überlängeeeeeeee"), "counts characters, not bytes")
+	assert.Equal(t, "", Truncate(0, "anything"))
+}
+
+func TestHumanizeTime(t *testing.T) {
+	now := time.Date(2025, time.March, 7, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, "just now", HumanizeTime(now.Add(-30*time.Second), now))
+	assert.Equal(t, "1 minute ago", HumanizeTime(now.Add(-time.Minute), now))
+	assert.Equal(t, "5 hours ago", HumanizeTime(now.Add(-5*time.Hour), now))
+	assert.Equal(t, "yesterday", HumanizeTime(now.Add(-30*time.Hour), now))
+	assert.Equal(t, "3 days ago", HumanizeTime(now.AddDate(0, 0, -3), now))
+	assert.Equal(t, "in 2 hours", HumanizeTime(now.Add(2*time.Hour), now))
+	assert.Equal(t, "February 1, 2025", HumanizeTime(time.Date(2025, time.February, 1, 0, 0, 0, 0, time.UTC), now))
+	assert.Equal(t, "", HumanizeTime(time.Time{}, now))
+}
+
+func TestJoinAnd(t *testing.T) {
+	assert.Equal(t, "", JoinAnd(nil))
+	assert.Equal(t, "a", JoinAnd([]string{"a"}))
+	assert.Equal(t, "a and b", JoinAnd([]string{"a", "b"}))
+	assert.Equal(t, "a, b and c", JoinAnd([]string{"a", "b", "c"}))
+}
+
+func TestMarkdownToHTML(t *testing.T) {
+	md := "**Qwen 3** is out with *great* `gguf` files, see [the card](https://huggingface.co/Qwen?a=1&b=2).\nSecond line <b>not bold</b>\n\n- first\n- second_item here\n1. numbered\n\nsnake_case_name stays"
+	assert.Equal(t,
+		`<p><strong>Qwen 3</strong> is out with <em>great</em> <code>gguf</code> files, see <a href="https://huggingface.co/Qwen?a=1&amp;b=2">the card</a>.<br>Second line &lt;b&gt;not bold&lt;/b&gt;</p>`+
+			`<ul><li>first</li><li>second_item here</li><li>numbered</li></ul>`+
+			`<p>snake_case_name stays</p>`,
+		MarkdownToHTML(md))
+
+	assert.Equal(t, `<p><code>**not bold**</code></p>`, MarkdownToHTML("`**not bold**`"))
+	assert.Equal(t, `<p>[x](javascript:alert(1))</p>`, MarkdownToHTML("[x](javascript:alert(1))"), "only http(s) links")
+}
+
+func TestFuncMap(t *testing.T) {
+	tmpl, err := template.New("test").Funcs(FuncMap()).Parse(
+		`{{.Title | truncate 12}} · {{.Count}} {{pluralize .Count "comment" "comments"}} · {{joinAnd .Tags}}`)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, tmpl.Execute(&buf, map[string]interface{}{
+		"Title": "A very long title indeed",
+		"Count": 1,
+		"Tags":  []string{"llm", "quantization"},
+	}))
+	assert.Equal(t, "A very long… · 1 comment · llm and quantization", buf.String())
+}
@@ -0,0 +1,247 @@
+// Package tokens estimates how many tokens text takes in an LLM prompt, and cuts text to a token
+// budget at sentence boundaries. Estimates follow tiktoken's cl100k_base and o200k_base encodings
+// closely enough to budget prompts, without shipping their vocabularies.
+package tokens
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Estimate returns the approximate number of tokens in text. Text is split into pieces the way
+// tiktoken's pre-tokenizer does (words with their leading space, numbers in groups of up to three
+// digits, runs of punctuation and whitespace) and each piece is counted by its length and script.
+func Estimate(text string) int {
+	total := 0
+	scan(text, func(_, _, cost int) bool {
+		total += cost
+		return true
+	})
+	return total
+}
+
+// Fits reports whether text is estimated to take at most budget tokens
+func Fits(text string, budget int) bool {
+	total := 0
+	fits := true
+	scan(text, func(_, _, cost int) bool {
+		total += cost
+		fits = total <= budget
+		return fits
+	})
+	return fits
+}
+
+// Truncate returns the start of text that fits within maxTokens, and whether anything was cut.
+// The cut is made after the last sentence that fits, or after the last word if the sentences are
+// too long to keep at least half the budget.
+func Truncate(text string, maxTokens int) (string, bool) {
+	cut := prefixEnd(text, maxTokens)
+	if cut == len(text) {
+		return text, false
+	}
+	return trimSpaceRight(text[:boundary(text, cut)]), true
+}
+
+// Split cuts text into consecutive parts of at most maxTokens each, at sentence boundaries where
+// possible
+func Split(text string, maxTokens int) []string {
+	var parts []string
+	for text != "" {
+		cut := prefixEnd(text, maxTokens)
+		if cut < len(text) {
+			cut = boundary(text, cut)
+		}
+		if cut == 0 {
+			// A single piece larger than the budget is kept whole rather than looping forever
+			_, cut = firstPiece(text)
+		}
+		if part := trimSpace(text[:cut]); part != "" {
+			parts = append(parts, part)
+		}
+		text = text[cut:]
+	}
+	return parts
+}
+
+// prefixEnd returns the byte offset where the pieces of text stop fitting in maxTokens
+func prefixEnd(text string, maxTokens int) int {
+	end, total := 0, 0
+	scan(text, func(_, pieceEnd, cost int) bool {
+		if total+cost > maxTokens {
+			return false
+		}
+		total += cost
+		end = pieceEnd
+		return true
+	})
+	return end
+}
+
+// boundary moves a cut at byte offset cut back to the end of the last sentence before it, or to
+// the last whitespace when no sentence ends in the second half of the text before the cut
+func boundary(text string, cut int) int {
+	for i := cut - 1; i >= cut/2; i-- {
+		if isSentenceEnd(text, i) {
+			return i + 1
+		}
+	}
+	for i := cut - 1; i > 0; i-- {
+		if text[i] == ' ' || text[i] == '\n' || text[i] == '\t' {
+			return i
+		}
+	}
+	return cut
+}
+
+// isSentenceEnd reports whether the byte at i ends a sentence: a line break, or a full stop,
+// question or exclamation mark (with any closing quotes or brackets) followed by whitespace
+func isSentenceEnd(text string, i int) bool {
+	if text[i] == '\n' {
+		return true
+	}
+	if i+1 >= len(text) || (text[i+1] != ' ' && text[i+1] != '\n') {
+		return false
+	}
+	for j := i; j >= 0; j-- {
+		switch text[j] {
+		case '"', '\'', ')', ']':
+			continue
+		case '.', '!', '?':
+			return true
+		}
+		return false
+	}
+	return false
+}
+
+// scan calls piece with the byte range and estimated token cost of each piece of text, until it
+// returns false
+func scan(text string, piece func(start, end, cost int) bool) {
+	for start := 0; start < len(text); {
+		cost, end := firstPiece(text[start:])
+		if !piece(start, start+end, cost) {
+			return
+		}
+		start += end
+	}
+}
+
+// firstPiece returns the estimated cost and the length in bytes of the first piece of text
+func firstPiece(text string) (int, int) {
+	r, size := utf8.DecodeRuneInString(text)
+	end := size
+
+	// Words and punctuation take the single space before them, as in tiktoken
+	if r == ' ' && len(text) > 1 {
+		next, nextSize := utf8.DecodeRuneInString(text[1:])
+		if unicode.IsLetter(next) || isPunct(next) {
+			r, end = next, 1+nextSize
+		}
+	}
+
+	switch {
+	case unicode.IsLetter(r):
+		ascii, wide, other := 0, 0, 0
+		count := func(r rune) {
+			switch {
+			case r < utf8.RuneSelf:
+				ascii++
+			case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+				wide++
+			default:
+				other++
+			}
+		}
+		count(r)
+		for end < len(text) {
+			next, nextSize := utf8.DecodeRuneInString(text[end:])
+			if !unicode.IsLetter(next) && !unicode.Is(unicode.Mn, next) {
+				break
+			}
+			count(next)
+			end += nextSize
+		}
+		return wordCost(ascii) + wide + (other+1)/2, end
+
+	case unicode.IsDigit(r):
+		digits := 1
+		for end < len(text) && digits < 3 {
+			next, nextSize := utf8.DecodeRuneInString(text[end:])
+			if !unicode.IsDigit(next) {
+				break
+			}
+			digits++
+			end += nextSize
+		}
+		return 1, end
+
+	case unicode.IsSpace(r):
+		for end < len(text) {
+			next, nextSize := utf8.DecodeRuneInString(text[end:])
+			if !unicode.IsSpace(next) {
+				break
+			}
+			end += nextSize
+		}
+		return 1, end
+
+	default:
+		ascii, other := 0, 0
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+		for end < len(text) {
+			next, nextSize := utf8.DecodeRuneInString(text[end:])
+			if !isPunct(next) {
+				break
+			}
+			if next < utf8.RuneSelf {
+				ascii++
+			} else {
+				other++
+			}
+			end += nextSize
+		}
+		return (ascii+1)/2 + other, end
+	}
+}
+
+// wordCost estimates the tokens of a word of n ASCII letters: common words up to seven letters
+// are single tokens, and longer words take about one more token for every five letters
+func wordCost(n int) int {
+	switch {
+	case n == 0:
+		return 0
+	case n <= 7:
+		return 1
+	default:
+		return 1 + (n-7+4)/5
+	}
+}
+
+func isPunct(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsSpace(r)
+}
+
+func trimSpace(s string) string {
+	start := 0
+	for start < len(s) && isASCIISpace(s[start]) {
+		start++
+	}
+	return trimSpaceRight(s[start:])
+}
+
+func trimSpaceRight(s string) string {
+	end := len(s)
+	for end > 0 && isASCIISpace(s[end-1]) {
+		end--
+	}
+	return s[:end]
+}
+
+func isASCIISpace(b byte) bool {
+	return b == ' ' || b == '\n' || b == '\t' || b == '\r'
+}
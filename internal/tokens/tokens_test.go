@@ -0,0 +1,68 @@
+package tokens
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEstimate(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"Hello, world!", 4},
+		{"The quick brown fox", 4},
+		{"internationalization", 4},
+		{"1234567", 3},
+		{"   \n\n", 1},
+		{"日本語", 3},
+		{"äöü", 2},
+		{"```go", 3},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Estimate(tt.text), tt.text)
+	}
+
+	// English prose comes out near tiktoken's four characters per token
+	prose := strings.Repeat("The model was trained on a larger dataset and beats the previous release. ", 20)
+	assert.InDelta(t, len(prose)/4, Estimate(prose), float64(len(prose))/16)
+}
+
+func TestFits(t *testing.T) {
+	assert.True(t, Fits("Hello, world!", 4))
+	assert.False(t, Fits("Hello, world!", 3))
+	assert.True(t, Fits("", 0))
+}
+
+func TestTruncate(t *testing.T) {
+	text := "First sentence is here. Second one follows it. Third sentence never fits."
+
+	truncated, cut := Truncate(text, 11)
+	assert.True(t, cut)
+	assert.Equal(t, "First sentence is here. Second one follows it.", truncated)
+
+	truncated, cut = Truncate(text, 100)
+	assert.False(t, cut)
+	assert.Equal(t, text, truncated)
+
+	// Without a sentence end in reach the cut falls back to the last word
+	truncated, cut = Truncate("one two three four five six", 4)
+	assert.True(t, cut)
+	assert.Equal(t, "one two three", truncated)
+}
+
+func TestSplit(t *testing.T) {
+	parts := Split("First sentence is here. Second one follows it. Third sentence is last.", 6)
+	assert.Equal(t, []string{"First sentence is here.", "Second one follows it.", "Third sentence is last."}, parts)
+
+	for _, part := range Split(strings.Repeat("word ", 100), 7) {
+		assert.LessOrEqual(t, Estimate(part), 7)
+	}
+
+	// A piece larger than the budget is kept whole
+	assert.Equal(t, []string{strings.Repeat("a", 100)}, Split(strings.Repeat("a", 100), 3))
+	assert.Empty(t, Split("", 3))
+}
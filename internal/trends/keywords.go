@@ -0,0 +1,69 @@
+package trends
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/bakkerme/ai-news-processor/models"
+)
+
+// minTermLength is the shortest term that is counted
+const minTermLength = 3
+
+// stopwords are common words that say nothing about the topic of an item
+var stopwords = map[string]struct{}{}
+
+func init() {
+	for _, w := range strings.Fields(`
+		the and for with that this from are was were has have had not but you your they their them
+		its it's his her our out all any can could would should will just more most some than then
+		there these those what when where which who whom why how into onto over under about after
+		before between through during also very much many such only other others each both been being
+		does did doing done get gets got make makes made use used uses using one two three new now
+		may might must like well even still yet via per way ways here own same too off let lets
+		post posts posted thread comment comments user users people someone something anyone anything
+		discussion question questions answer answers think thoughts really today week year years time
+		good better best great lot lots thing things want need know see look looks looking
+	`) {
+		stopwords[w] = struct{}{}
+	}
+}
+
+// Terms returns the distinct terms mentioned in an item's title and summary, lowercased.
+// Version-like tokens such as "llama-3.1" or "gpt-4o" are kept whole.
+func Terms(item models.Item) []string {
+	seen := make(map[string]struct{})
+	var terms []string
+	for _, field := range strings.FieldsFunc(item.Title+" "+item.Summary, isSeparator) {
+		term := strings.ToLower(strings.Trim(field, ".-_"))
+		if len(term) < minTermLength || !strings.ContainsFunc(term, unicode.IsLetter) {
+			continue
+		}
+		if _, ok := stopwords[term]; ok {
+			continue
+		}
+		if _, ok := seen[term]; ok {
+			continue
+		}
+		seen[term] = struct{}{}
+		terms = append(terms, term)
+	}
+	return terms
+}
+
+// isSeparator reports whether r separates terms. Dots, dashes and underscores are kept inside
+// terms so model names and versions survive.
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '.' && r != '-' && r != '_'
+}
+
+// Count returns the number of items that mention each term
+func Count(items []models.Item) map[string]int {
+	counts := make(map[string]int)
+	for _, item := range items {
+		for _, term := range Terms(item) {
+			counts[term]++
+		}
+	}
+	return counts
+}
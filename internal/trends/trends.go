@@ -0,0 +1,190 @@
+// Package trends tracks how often terms are mentioned across runs and detects terms whose
+// frequency spikes compared to their trailing average.
+package trends
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/models"
+)
+
+// maxTermsPerSnapshot limits how many terms are stored for each run to keep the history small
+const maxTermsPerSnapshot = 500
+
+// Snapshot holds the term counts of a single run
+type Snapshot struct {
+	RunAt  time.Time      `json:"runAt"`
+	Items  int            `json:"items"`
+	Counts map[string]int `json:"counts"`
+}
+
+// NewSnapshot counts the terms mentioned in the items of a run
+func NewSnapshot(items []models.Item, runAt time.Time) Snapshot {
+	return Snapshot{RunAt: runAt, Items: len(items), Counts: Count(items)}
+}
+
+// Options controls when a term counts as rising
+type Options struct {
+	Window     int     // Number of previous runs the trailing average is taken over
+	MinHistory int     // Minimum number of previous runs before anything is reported
+	MinCount   int     // Minimum number of items that must mention a term in the current run
+	Factor     float64 // How many times the trailing average (plus one) the current count must be
+	MaxTopics  int     // Maximum number of rising topics returned
+}
+
+// DefaultOptions are the options used by the processor
+var DefaultOptions = Options{
+	Window:     7,
+	MinHistory: 3,
+	MinCount:   3,
+	Factor:     2,
+	MaxTopics:  5,
+}
+
+// Detect returns the terms of current whose count spiked compared to their average over the
+// previous runs, most significant first. Items mentioning a rising term are linked by ID.
+// Nothing is returned until there is enough history to compare against.
+func Detect(current Snapshot, previous []Snapshot, items []models.Item, opts Options) []models.RisingTopic {
+	if len(previous) < opts.MinHistory || len(previous) == 0 {
+		return nil
+	}
+	if len(previous) > opts.Window {
+		previous = previous[len(previous)-opts.Window:]
+	}
+
+	type candidate struct {
+		topic models.RisingTopic
+		ratio float64
+	}
+	var candidates []candidate
+	for term, count := range current.Counts {
+		if count < opts.MinCount {
+			continue
+		}
+		total := 0
+		for _, snapshot := range previous {
+			total += snapshot.Counts[term]
+		}
+		average := float64(total) / float64(len(previous))
+		// Adding one keeps terms that were never seen before from spiking on a single mention
+		ratio := float64(count) / (average + 1)
+		if ratio < opts.Factor {
+			continue
+		}
+		candidates = append(candidates, candidate{
+			topic: models.RisingTopic{Term: term, Count: count, Average: average},
+			ratio: ratio,
+		})
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].ratio != candidates[j].ratio {
+			return candidates[i].ratio > candidates[j].ratio
+		}
+		return candidates[i].topic.Term < candidates[j].topic.Term
+	})
+	if len(candidates) > opts.MaxTopics {
+		candidates = candidates[:opts.MaxTopics]
+	}
+
+	topics := make([]models.RisingTopic, len(candidates))
+	for i, c := range candidates {
+		topics[i] = c.topic
+		for _, item := range items {
+			for _, term := range Terms(item) {
+				if term == c.topic.Term {
+					topics[i].ItemIDs = append(topics[i].ItemIDs, item.ID)
+					break
+				}
+			}
+		}
+	}
+	return topics
+}
+
+// History holds the term snapshots of previous runs per persona
+type History struct {
+	path     string
+	window   int
+	personas map[string][]Snapshot
+}
+
+// LoadHistory reads the history from disk, keeping at most window snapshots per persona.
+// If the file does not exist, an empty history is returned.
+func LoadHistory(path string, window int) (*History, error) {
+	h := &History{path: path, window: window, personas: make(map[string][]Snapshot)}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return h, nil
+		}
+		return nil, fmt.Errorf("could not read trend history: %w", err)
+	}
+	if err := json.Unmarshal(data, &h.personas); err != nil {
+		return nil, fmt.Errorf("could not parse trend history: %w", err)
+	}
+	return h, nil
+}
+
+// Snapshots returns the snapshots recorded for a persona, oldest first
+func (h *History) Snapshots(persona string) []Snapshot {
+	return h.personas[persona]
+}
+
+// Add records the snapshot of a run for a persona, dropping the oldest snapshots beyond the window
+func (h *History) Add(persona string, snapshot Snapshot) {
+	snapshot.Counts = topCounts(snapshot.Counts, maxTermsPerSnapshot)
+	snapshots := append(h.personas[persona], snapshot)
+	if h.window > 0 && len(snapshots) > h.window {
+		snapshots = snapshots[len(snapshots)-h.window:]
+	}
+	h.personas[persona] = snapshots
+}
+
+// Save persists the history to disk as JSON
+func (h *History) Save() error {
+	payload, err := json.MarshalIndent(h.personas, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode trend history: %w", err)
+	}
+
+	dir := filepath.Dir(h.path)
+	if dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("could not create trend history directory: %w", err)
+		}
+	}
+
+	if err := os.WriteFile(h.path, payload, 0644); err != nil {
+		return fmt.Errorf("could not write trend history: %w", err)
+	}
+	return nil
+}
+
+// topCounts returns the n most mentioned terms of counts
+func topCounts(counts map[string]int, n int) map[string]int {
+	if len(counts) <= n {
+		return counts
+	}
+	terms := make([]string, 0, len(counts))
+	for term := range counts {
+		terms = append(terms, term)
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if counts[terms[i]] != counts[terms[j]] {
+			return counts[terms[i]] > counts[terms[j]]
+		}
+		return terms[i] < terms[j]
+	})
+	top := make(map[string]int, n)
+	for _, term := range terms[:n] {
+		top[term] = counts[term]
+	}
+	return top
+}
@@ -0,0 +1,84 @@
+package trends
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTerms(t *testing.T) {
+	item := models.Item{
+		Title:   "Llama-3.1 beats GPT-4o on the new benchmark!",
+		Summary: "The llama-3.1 release (via llama.cpp) runs at 40 tok/s. This is big.",
+	}
+	assert.Equal(t, []string{"llama-3.1", "beats", "gpt-4o", "benchmark", "release", "llama.cpp", "runs", "tok", "big"}, Terms(item))
+}
+
+func TestDetect(t *testing.T) {
+	snapshot := func(counts map[string]int) Snapshot {
+		return Snapshot{Counts: counts}
+	}
+	previous := []Snapshot{
+		snapshot(map[string]int{"qwen": 1, "benchmark": 4}),
+		snapshot(map[string]int{"benchmark": 5}),
+		snapshot(map[string]int{"qwen": 1, "benchmark": 4}),
+	}
+	items := []models.Item{
+		{ID: "a", Title: "Qwen 3 released"},
+		{ID: "b", Title: "Benchmarks for Qwen"},
+		{ID: "c", Title: "Unrelated"},
+	}
+	current := Snapshot{Counts: map[string]int{
+		"qwen":      6, // average 0.67, ratio 3.6
+		"benchmark": 6, // average 4.33, ratio 1.1
+		"mistral":   3, // never seen, ratio 3
+		"gemma":     2, // below MinCount
+	}}
+
+	rising := Detect(current, previous, items, DefaultOptions)
+	require.Len(t, rising, 2)
+	assert.Equal(t, "qwen", rising[0].Term)
+	assert.Equal(t, 6, rising[0].Count)
+	assert.InDelta(t, 0.667, rising[0].Average, 0.01)
+	assert.Equal(t, []string{"a", "b"}, rising[0].ItemIDs)
+	assert.Equal(t, "mistral", rising[1].Term)
+	assert.Empty(t, rising[1].ItemIDs)
+
+	assert.Nil(t, Detect(current, previous[:2], items, DefaultOptions), "not enough history yet")
+
+	opts := DefaultOptions
+	opts.MaxTopics = 1
+	assert.Len(t, Detect(current, previous, items, opts), 1)
+}
+
+func TestHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trends", "history.json")
+	history, err := LoadHistory(path, 2)
+	require.NoError(t, err)
+	assert.Empty(t, history.Snapshots("LocalLLaMA"))
+
+	start := time.Date(2025, time.March, 1, 6, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		items := []models.Item{{Title: "Qwen release"}, {Title: "Qwen benchmark"}}
+		history.Add("LocalLLaMA", NewSnapshot(items, start.AddDate(0, 0, i)))
+	}
+	require.NoError(t, history.Save())
+
+	loaded, err := LoadHistory(path, 2)
+	require.NoError(t, err)
+	snapshots := loaded.Snapshots("LocalLLaMA")
+	require.Len(t, snapshots, 2, "only the window is kept")
+	assert.True(t, start.AddDate(0, 0, 1).Equal(snapshots[0].RunAt))
+	assert.Equal(t, 2, snapshots[1].Items)
+	assert.Equal(t, map[string]int{"qwen": 2, "release": 1, "benchmark": 1}, snapshots[1].Counts)
+}
+
+func TestTopCounts(t *testing.T) {
+	counts := map[string]int{"a": 1, "b": 3, "c": 2, "d": 2}
+	assert.Equal(t, map[string]int{"b": 3, "c": 2}, topCounts(counts, 2))
+	assert.Equal(t, counts, topCounts(counts, 10))
+}
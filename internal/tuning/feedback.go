@@ -0,0 +1,108 @@
+package tuning
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Feedback records a relevance judgement by a human reviewer, a reader or an LLM judge
+type Feedback struct {
+	Persona          string    `json:"persona"`
+	EntryID          string    `json:"entryId"`
+	Title            string    `json:"title"`
+	Summary          string    `json:"summary,omitempty"`
+	IsRelevant       bool      `json:"isRelevant"`       // The processor's judgement
+	ExpectedRelevant bool      `json:"expectedRelevant"` // The reviewer's judgement
+	Reason           string    `json:"reason,omitempty"` // Why the reviewer disagreed
+	Source           string    `json:"source,omitempty"` // "human", "reader" or "judge"
+	RecordedAt       time.Time `json:"recordedAt,omitempty"`
+}
+
+// IsMistake reports whether the reviewer disagreed with the processor
+func (f Feedback) IsMistake() bool {
+	return f.IsRelevant != f.ExpectedRelevant
+}
+
+// LoadFeedback reads feedback records from a JSON Lines file.
+// A missing file yields no records. Blank lines are skipped.
+func LoadFeedback(path string) ([]Feedback, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open feedback file: %w", err)
+	}
+	defer file.Close()
+
+	var records []Feedback
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var record Feedback
+		if err := json.Unmarshal([]byte(text), &record); err != nil {
+			return nil, fmt.Errorf("invalid feedback on line %d: %w", line, err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read feedback file: %w", err)
+	}
+	return records, nil
+}
+
+// AppendFeedback appends a feedback record to a JSON Lines file, creating it if needed.
+// Callers writing from several goroutines must serialize calls.
+func AppendFeedback(path string, record Feedback) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode feedback: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open feedback file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write feedback: %w", err)
+	}
+	return nil
+}
+
+// Mistakes returns the feedback for the named persona where the reviewer disagreed with the processor.
+// Later records for the same entry replace earlier ones.
+func Mistakes(records []Feedback, personaName string) []Feedback {
+	latest := make(map[string]int)
+	var mistakes []Feedback
+	for _, record := range records {
+		if !strings.EqualFold(record.Persona, personaName) {
+			continue
+		}
+		if i, ok := latest[record.EntryID]; ok && record.EntryID != "" {
+			mistakes[i] = record
+			continue
+		}
+		latest[record.EntryID] = len(mistakes)
+		mistakes = append(mistakes, record)
+	}
+
+	filtered := mistakes[:0]
+	for _, record := range mistakes {
+		if record.IsMistake() {
+			filtered = append(filtered, record)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,83 @@
+package tuning
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/bakkerme/ai-news-processor/internal/llm"
+	"github.com/bakkerme/ai-news-processor/internal/openai"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/bakkerme/ai-news-processor/internal/prompts"
+)
+
+// MaxMistakes caps how many mistakes are included in one tuning prompt. The most recent are kept.
+const MaxMistakes = 40
+
+// Suggestion holds revised criteria proposed for a persona
+type Suggestion struct {
+	RelevanceCriteria []string `json:"relevanceCriteria"`
+	ExclusionCriteria []string `json:"exclusionCriteria"`
+	Rationale         string   `json:"rationale"`
+}
+
+// suggestionSchema is the schema of a suggestion, requested in the client's response format
+var suggestionSchema = &openai.SchemaParameters{
+	Schema:      llm.GenerateSchema[Suggestion](),
+	Name:        "suggestion",
+	Description: "revised relevance and exclusion criteria of a persona",
+}
+
+// Suggest asks the LLM for relevance and exclusion criteria that would fix the given mistakes
+func Suggest(client openai.OpenAIClient, p persona.Persona, mistakes []Feedback) (*Suggestion, error) {
+	if len(mistakes) == 0 {
+		return nil, fmt.Errorf("no misclassifications recorded for persona %s", p.Name)
+	}
+	if len(mistakes) > MaxMistakes {
+		mistakes = mistakes[len(mistakes)-MaxMistakes:]
+	}
+
+	examples := make([]prompts.TuningMistake, len(mistakes))
+	for i, mistake := range mistakes {
+		examples[i] = prompts.TuningMistake{
+			Title:          mistake.Title,
+			Summary:        mistake.Summary,
+			Reason:         mistake.Reason,
+			MarkedRelevant: mistake.IsRelevant,
+		}
+	}
+
+	systemPrompt, err := prompts.ComposeTuningPrompt(p, examples)
+	if err != nil {
+		return nil, fmt.Errorf("could not create tuning prompt: %w", err)
+	}
+
+	result := client.ChatCompletion(
+		systemPrompt,
+		[]string{"Propose the revised criteria."},
+		[]string{},
+		suggestionSchema,
+		0.2, // temperature
+		0,   // max tokens (0 means no limit - needed for complete JSON generation)
+	)
+	if result.Err != nil {
+		return nil, fmt.Errorf("tuning request failed: %w", result.Err)
+	}
+
+	var suggestion Suggestion
+	if err := json.Unmarshal([]byte(client.PreprocessJSON(result.Content)), &suggestion); err != nil {
+		return nil, fmt.Errorf("could not parse tuning response: %w", err)
+	}
+	if len(suggestion.RelevanceCriteria) == 0 && len(suggestion.ExclusionCriteria) == 0 {
+		return nil, fmt.Errorf("tuning response contained no criteria")
+	}
+
+	// An omitted list means the model proposed no change to it
+	if len(suggestion.RelevanceCriteria) == 0 {
+		suggestion.RelevanceCriteria = p.RelevanceCriteria
+	}
+	if len(suggestion.ExclusionCriteria) == 0 {
+		suggestion.ExclusionCriteria = p.ExclusionCriteria
+	}
+
+	return &suggestion, nil
+}
@@ -0,0 +1,182 @@
+package tuning
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bakkerme/ai-news-processor/internal/http/retry"
+	"github.com/bakkerme/ai-news-processor/internal/openai"
+	"github.com/bakkerme/ai-news-processor/internal/persona"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const personaYAML = `name: "LocalLLaMa"
+subreddit: "localllama"
+
+# Relevance criteria (positive attributes to look for)
+relevance_criteria:
+  - "Contains specific technical details"
+  - "Explains significance"
+
+# Exclusion criteria (what to filter out)
+exclusion_criteria:
+  - "Content unrelated to AI"
+  # memes are common
+  - "Memes"
+
+comment_threshold: 10
+`
+
+func TestLoadFeedbackAndMistakes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feedback.jsonl")
+	content := `{"persona":"LocalLLaMa","entryId":"a","title":"Funding round","isRelevant":true,"expectedRelevant":false,"reason":"business news"}
+
+{"persona":"LocalLLaMa","entryId":"b","title":"New quant format","isRelevant":false,"expectedRelevant":true}
+{"persona":"LocalLLaMa","entryId":"c","title":"Correct call","isRelevant":true,"expectedRelevant":true}
+{"persona":"Other","entryId":"d","title":"Other persona","isRelevant":true,"expectedRelevant":false}
+{"persona":"localllama","entryId":"b","title":"New quant format","isRelevant":false,"expectedRelevant":false,"reason":"on second look, fine"}
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	records, err := LoadFeedback(path)
+	require.NoError(t, err)
+	require.Len(t, records, 5)
+
+	mistakes := Mistakes(records, "LocalLLaMa")
+	require.Len(t, mistakes, 1, "the later record for b overrides the earlier mistake")
+	assert.Equal(t, "a", mistakes[0].EntryID)
+
+	missing, err := LoadFeedback(filepath.Join(t.TempDir(), "missing.jsonl"))
+	require.NoError(t, err)
+	assert.Empty(t, missing)
+
+	require.NoError(t, os.WriteFile(path, []byte("{not json\n"), 0644))
+	_, err = LoadFeedback(path)
+	assert.ErrorContains(t, err, "line 1")
+}
+
+func TestAppendFeedback(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feedback.jsonl")
+	require.NoError(t, AppendFeedback(path, Feedback{Persona: "LocalLLaMa", EntryID: "a", IsRelevant: true, Source: "reader"}))
+	require.NoError(t, AppendFeedback(path, Feedback{Persona: "LocalLLaMa", EntryID: "b", IsRelevant: true, ExpectedRelevant: true, Source: "reader"}))
+
+	records, err := LoadFeedback(path)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "a", records[0].EntryID)
+	assert.True(t, records[1].ExpectedRelevant)
+}
+
+func TestReplaceList(t *testing.T) {
+	out := ReplaceList(personaYAML, "exclusion_criteria", []string{"Content unrelated to AI", `Funding news without "technical" content`})
+
+	expected := `name: "LocalLLaMa"
+subreddit: "localllama"
+
+# Relevance criteria (positive attributes to look for)
+relevance_criteria:
+  - "Contains specific technical details"
+  - "Explains significance"
+
+# Exclusion criteria (what to filter out)
+exclusion_criteria:
+  - "Content unrelated to AI"
+  - "Funding news without \"technical\" content"
+
+comment_threshold: 10
+`
+	assert.Equal(t, expected, out)
+
+	appended := ReplaceList("name: test", "exclusion_criteria", []string{"Spam"})
+	assert.Equal(t, "name: test\n\nexclusion_criteria:\n  - \"Spam\"\n", appended)
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	updated := ApplySuggestion(personaYAML, &Suggestion{
+		RelevanceCriteria: []string{"Contains specific technical details", "Explains significance"},
+		ExclusionCriteria: []string{"Content unrelated to AI", "Memes", "Funding rounds"},
+	})
+
+	diff := UnifiedDiff("a/localllama.yaml", "b/localllama.yaml", personaYAML, updated)
+	expected := strings.Join([]string{
+		"--- a/localllama.yaml",
+		"+++ b/localllama.yaml",
+		"@@ -9,7 +9,7 @@",
+		" # Exclusion criteria (what to filter out)",
+		" exclusion_criteria:",
+		`   - "Content unrelated to AI"`,
+		"-  # memes are common",
+		`   - "Memes"`,
+		`+  - "Funding rounds"`,
+		" ",
+		" comment_threshold: 10",
+		"",
+	}, "\n")
+	assert.Equal(t, expected, diff)
+	assert.Empty(t, UnifiedDiff("a", "b", personaYAML, personaYAML))
+
+	// The diff must apply cleanly with patch(1) when it is available
+	if _, err := exec.LookPath("patch"); err == nil {
+		dir := t.TempDir()
+		file := filepath.Join(dir, "localllama.yaml")
+		require.NoError(t, os.WriteFile(file, []byte(personaYAML), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "change.patch"), []byte(diff), 0644))
+
+		cmd := exec.Command("patch", "-p1", "-i", "change.patch", "localllama.yaml")
+		cmd.Dir = dir
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(output))
+
+		patched, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, updated, string(patched))
+	}
+}
+
+type stubClient struct {
+	response     string
+	systemPrompt string
+	schemaParams *openai.SchemaParameters
+}
+
+func (c *stubClient) ChatCompletion(systemPrompt string, userPrompts []string, imageURLs []string, schemaParams *openai.SchemaParameters, temperature float64, maxTokens int) openai.Result {
+	c.systemPrompt = systemPrompt
+	c.schemaParams = schemaParams
+	return openai.Result{Content: c.response}
+}
+func (c *stubClient) SetRetryConfig(config retry.RetryConfig) {}
+func (c *stubClient) PreprocessYAML(response string) string   { return response }
+func (c *stubClient) PreprocessJSON(response string) string   { return response }
+func (c *stubClient) GetModelName() string                    { return "stub" }
+
+func TestSuggest(t *testing.T) {
+	p := persona.Persona{
+		Name:              "LocalLLaMa",
+		RelevanceCriteria: []string{"Contains specific technical details"},
+		ExclusionCriteria: []string{"Memes"},
+	}
+	mistakes := []Feedback{{Title: "Funding round", IsRelevant: true, Reason: "business news"}}
+
+	client := &stubClient{response: `{"exclusionCriteria":["Memes","Funding news without technical content"],"rationale":"Funding posts slipped through."}`}
+	suggestion, err := Suggest(client, p, mistakes)
+	require.NoError(t, err)
+
+	assert.Equal(t, p.RelevanceCriteria, suggestion.RelevanceCriteria, "omitted lists are left unchanged")
+	assert.Equal(t, []string{"Memes", "Funding news without technical content"}, suggestion.ExclusionCriteria)
+	assert.Contains(t, client.systemPrompt, `Title: "Funding round"`)
+	assert.Contains(t, client.systemPrompt, "Marked relevant, but should have been excluded")
+	assert.Contains(t, client.systemPrompt, "Reviewer note: business news")
+	require.NotNil(t, client.schemaParams, "suggestions are requested with their schema")
+	assert.Equal(t, "suggestion", client.schemaParams.Name)
+
+	_, err = Suggest(client, p, nil)
+	assert.Error(t, err)
+
+	client.response = "not json"
+	_, err = Suggest(client, p, mistakes)
+	assert.Error(t, err)
+}
@@ -0,0 +1,123 @@
+package tuning
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var topLevelKey = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*):`)
+
+// ReplaceList replaces the items of the top-level YAML sequence under key, leaving the rest of the
+// document (including comments and formatting) untouched. If the key is missing it is appended.
+func ReplaceList(doc string, key string, items []string) string {
+	lines := strings.SplitAfter(doc, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	start := -1
+	for i, line := range lines {
+		if m := topLevelKey.FindStringSubmatch(line); m != nil && m[1] == key {
+			start = i
+			break
+		}
+	}
+
+	if start == -1 {
+		if doc != "" && !strings.HasSuffix(doc, "\n") {
+			doc += "\n"
+		}
+		return doc + "\n" + key + ":\n" + renderItems("  ", items)
+	}
+
+	// The list body runs until the last indented line before the next top-level key.
+	// Blank lines and comments after it belong to whatever follows.
+	indent := "  "
+	end := start + 1
+	for i := start + 1; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") && !isIndented(lines[i]) {
+			continue
+		}
+		if !isIndented(lines[i]) && !strings.HasPrefix(trimmed, "- ") {
+			break
+		}
+		if end == start+1 && strings.HasPrefix(trimmed, "- ") {
+			indent = lines[i][:len(lines[i])-len(strings.TrimLeft(lines[i], " \t"))]
+		}
+		end = i + 1
+	}
+
+	var out strings.Builder
+	for _, line := range lines[:start] {
+		out.WriteString(line)
+	}
+	out.WriteString(key + ":\n")
+	out.WriteString(renderItems(indent, items))
+	for _, line := range lines[end:] {
+		out.WriteString(line)
+	}
+	return out.String()
+}
+
+// isIndented reports whether a line starts with whitespace
+func isIndented(line string) bool {
+	return strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")
+}
+
+// renderItems renders sequence items as double-quoted YAML scalars
+func renderItems(indent string, items []string) string {
+	var out strings.Builder
+	for _, item := range items {
+		out.WriteString(indent + "- " + quoteYAML(item) + "\n")
+	}
+	return out.String()
+}
+
+// quoteYAML quotes s as a YAML double-quoted scalar. JSON strings are valid YAML double-quoted scalars.
+func quoteYAML(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(s); err != nil {
+		return fmt.Sprintf("%q", s)
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// ApplySuggestion returns the persona YAML with its relevance and exclusion criteria replaced
+func ApplySuggestion(doc string, suggestion *Suggestion) string {
+	doc = ReplaceList(doc, "relevance_criteria", suggestion.RelevanceCriteria)
+	return ReplaceList(doc, "exclusion_criteria", suggestion.ExclusionCriteria)
+}
+
+// FindPersonaFile returns the path of the YAML file in dir that defines the named persona
+func FindPersonaFile(dir string, name string) (string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return "", fmt.Errorf("failed to list persona files: %w", err)
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("failed to read persona file %s: %w", file, err)
+		}
+		var header struct {
+			Name string `yaml:"name"`
+		}
+		if err := yaml.Unmarshal(data, &header); err != nil {
+			return "", fmt.Errorf("failed to parse persona file %s: %w", file, err)
+		}
+		if header.Name == name {
+			return file, nil
+		}
+	}
+	return "", fmt.Errorf("no persona file found for %s in %s", name, dir)
+}
@@ -0,0 +1,432 @@
+// Package twitter reads tweets and threads linked from entries. X serves its pages as a JavaScript
+// application, so fetching them yields no text; the public endpoints behind embedded tweets return
+// the tweet itself instead.
+package twitter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bakkerme/ai-news-processor/internal/fetcher"
+	xhtml "golang.org/x/net/html"
+)
+
+const (
+	// DefaultSyndicationURL is the base URL of the endpoint behind embedded tweets
+	DefaultSyndicationURL = "https://cdn.syndication.twimg.com"
+
+	// DefaultOEmbedURL is the base URL of the oEmbed endpoint, used when the syndication endpoint fails
+	DefaultOEmbedURL = "https://publish.twitter.com"
+)
+
+// maxThreadLength limits how many earlier posts of the author are followed up a thread
+const maxThreadLength = 25
+
+// statusHosts are the hosts serving tweets, including the embed fixers that are often linked
+// instead of X itself
+var statusHosts = map[string]bool{
+	"twitter.com":        true,
+	"x.com":              true,
+	"mobile.twitter.com": true,
+	"mobile.x.com":       true,
+	"fxtwitter.com":      true,
+	"vxtwitter.com":      true,
+	"fixupx.com":         true,
+	"fixvx.com":          true,
+	"nitter.net":         true,
+}
+
+var statusIDPattern = regexp.MustCompile(`^[0-9]{1,20}$`)
+
+// Tweet is a single post
+type Tweet struct {
+	ID        string
+	Author    string // Display name
+	Handle    string // Screen name without the @
+	Text      string
+	CreatedAt time.Time
+	Quoted    *Tweet // Post quoted by this one, if any
+}
+
+// Thread is a post with the earlier posts its author replied to, oldest first
+type Thread struct {
+	Tweets []Tweet
+}
+
+// syndicationTweet mirrors the subset of the tweet-result response we use
+type syndicationTweet struct {
+	Typename          string `json:"__typename"`
+	IDStr             string `json:"id_str"`
+	Text              string `json:"text"`
+	DisplayTextRange  []int  `json:"display_text_range"`
+	CreatedAt         string `json:"created_at"`
+	InReplyToStatusID string `json:"in_reply_to_status_id_str"`
+	InReplyToHandle   string `json:"in_reply_to_screen_name"`
+	User              struct {
+		Name       string `json:"name"`
+		ScreenName string `json:"screen_name"`
+	} `json:"user"`
+	Entities struct {
+		URLs []struct {
+			URL         string `json:"url"`
+			ExpandedURL string `json:"expanded_url"`
+		} `json:"urls"`
+		Media []struct {
+			URL string `json:"url"`
+		} `json:"media"`
+	} `json:"entities"`
+	NoteTweet *struct {
+		NoteTweetResults struct {
+			Result struct {
+				Text string `json:"text"`
+			} `json:"result"`
+		} `json:"note_tweet_results"`
+	} `json:"note_tweet"` // Full text of posts longer than 280 characters
+	Parent      *syndicationTweet `json:"parent"`
+	QuotedTweet *syndicationTweet `json:"quoted_tweet"`
+}
+
+// oEmbedResponse mirrors the subset of the oEmbed response we use
+type oEmbedResponse struct {
+	AuthorName string `json:"author_name"`
+	AuthorURL  string `json:"author_url"`
+	HTML       string `json:"html"`
+}
+
+// Client reads tweets from the embed endpoints of X
+type Client struct {
+	fetcher        fetcher.Fetcher
+	syndicationURL string
+	oEmbedURL      string
+}
+
+// NewClient creates a new client using the given fetcher.
+// Empty base URLs are replaced with DefaultSyndicationURL and DefaultOEmbedURL.
+func NewClient(f fetcher.Fetcher, syndicationURL string, oEmbedURL string) *Client {
+	if syndicationURL == "" {
+		syndicationURL = DefaultSyndicationURL
+	}
+	if oEmbedURL == "" {
+		oEmbedURL = DefaultOEmbedURL
+	}
+	return &Client{
+		fetcher:        f,
+		syndicationURL: strings.TrimRight(syndicationURL, "/"),
+		oEmbedURL:      strings.TrimRight(oEmbedURL, "/"),
+	}
+}
+
+// ParseStatusURL detects links to a single tweet, such as https://x.com/user/status/123, and
+// returns the ID of the tweet. The second return value is false for other URLs, including
+// profiles and searches on X.
+func ParseStatusURL(u *url.URL) (string, bool) {
+	if u == nil {
+		return "", false
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	if !statusHosts[host] {
+		return "", false
+	}
+
+	// Tweets live at /user/status/id, /i/web/status/id or /i/status/id, optionally followed by
+	// /photo/1 and the like
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i := 1; i+1 < len(segments); i++ {
+		if (segments[i] == "status" || segments[i] == "statuses") && statusIDPattern.MatchString(segments[i+1]) {
+			return segments[i+1], true
+		}
+	}
+	return "", false
+}
+
+// FetchThread reads the tweet with the given ID, and the earlier posts of the thread it belongs
+// to as long as they are by the same author. The embed endpoints do not list replies, so posts
+// after the linked one are not included. When the syndication endpoint fails, the tweet alone is
+// read from the oEmbed endpoint.
+func (c *Client) FetchThread(ctx context.Context, id string) (*Thread, error) {
+	tweet, err := c.fetchSyndication(ctx, id)
+	if err != nil {
+		single, oEmbedErr := c.fetchOEmbed(ctx, id)
+		if oEmbedErr != nil {
+			return nil, fmt.Errorf("could not read tweet %s: %w (oEmbed: %v)", id, err, oEmbedErr)
+		}
+		return &Thread{Tweets: []Tweet{*single}}, nil
+	}
+
+	tweets := []Tweet{tweet.toTweet()}
+	current := tweet
+	for len(tweets) < maxThreadLength && current.InReplyToStatusID != "" && strings.EqualFold(current.InReplyToHandle, tweet.User.ScreenName) {
+		parent := current.Parent
+		if parent == nil || parent.IDStr != current.InReplyToStatusID {
+			// Only the direct parent of the requested tweet is embedded in its response
+			if parent, err = c.fetchSyndication(ctx, current.InReplyToStatusID); err != nil {
+				break
+			}
+		}
+		tweets = append([]Tweet{parent.toTweet()}, tweets...)
+		current = parent
+	}
+
+	return &Thread{Tweets: tweets}, nil
+}
+
+// fetchSyndication reads a tweet from the syndication endpoint
+func (c *Client) fetchSyndication(ctx context.Context, id string) (*syndicationTweet, error) {
+	query := url.Values{}
+	query.Set("id", id)
+	query.Set("lang", "en")
+	query.Set("token", syndicationToken(id))
+
+	body, err := c.get(ctx, c.syndicationURL+"/tweet-result?"+query.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch tweet %s: %w", id, err)
+	}
+
+	var tweet syndicationTweet
+	if err := json.Unmarshal(body, &tweet); err != nil {
+		return nil, fmt.Errorf("could not parse tweet %s: %w", id, err)
+	}
+	// Deleted, protected and age-restricted tweets come back as a TweetTombstone or an empty object
+	if tweet.Typename != "Tweet" || tweet.IDStr == "" {
+		return nil, fmt.Errorf("tweet %s is not available", id)
+	}
+	return &tweet, nil
+}
+
+// fetchOEmbed reads a tweet from the oEmbed endpoint, which returns the text as HTML
+func (c *Client) fetchOEmbed(ctx context.Context, id string) (*Tweet, error) {
+	query := url.Values{}
+	query.Set("url", "https://twitter.com/i/status/"+id)
+	query.Set("omit_script", "true")
+	query.Set("dnt", "true")
+
+	body, err := c.get(ctx, c.oEmbedURL+"/oembed?"+query.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch tweet %s: %w", id, err)
+	}
+
+	var resp oEmbedResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("could not parse tweet %s: %w", id, err)
+	}
+	text, err := oEmbedText(resp.HTML)
+	if err != nil || text == "" {
+		return nil, fmt.Errorf("tweet %s has no text", id)
+	}
+
+	handle := ""
+	if authorURL, err := url.Parse(resp.AuthorURL); err == nil {
+		handle = strings.Trim(authorURL.Path, "/")
+	}
+	return &Tweet{ID: id, Author: resp.AuthorName, Handle: handle, Text: text}, nil
+}
+
+// get fetches the given URL and returns the response body
+func (c *Client) get(ctx context.Context, rawURL string) ([]byte, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url %s: %w", rawURL, err)
+	}
+
+	resp, err := c.fetcher.Fetch(ctx, u)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// toTweet converts a syndication response into a Tweet, with the links expanded
+func (t *syndicationTweet) toTweet() Tweet {
+	text := t.Text
+	if t.NoteTweet != nil && t.NoteTweet.NoteTweetResults.Result.Text != "" {
+		text = t.NoteTweet.NoteTweetResults.Result.Text
+	} else if len(t.DisplayTextRange) == 2 {
+		// The range leaves out the @mentions replies start with and the trailing media link
+		runes := []rune(text)
+		start, end := t.DisplayTextRange[0], t.DisplayTextRange[1]
+		if 0 <= start && start <= end && end <= len(runes) {
+			text = string(runes[start:end])
+		}
+	}
+
+	for _, link := range t.Entities.URLs {
+		text = strings.ReplaceAll(text, link.URL, link.ExpandedURL)
+	}
+	for _, media := range t.Entities.Media {
+		text = strings.ReplaceAll(text, media.URL, "")
+	}
+
+	tweet := Tweet{
+		ID:     t.IDStr,
+		Author: t.User.Name,
+		Handle: t.User.ScreenName,
+		Text:   strings.TrimSpace(html.UnescapeString(text)),
+	}
+	if createdAt, err := time.Parse(time.RFC3339, t.CreatedAt); err == nil {
+		tweet.CreatedAt = createdAt
+	}
+	if t.QuotedTweet != nil && t.QuotedTweet.IDStr != "" {
+		quoted := t.QuotedTweet.toTweet()
+		tweet.Quoted = &quoted
+	}
+	return tweet
+}
+
+// oEmbedText returns the text of the tweet in an oEmbed blockquote, which is its first paragraph
+func oEmbedText(embed string) (string, error) {
+	doc, err := xhtml.Parse(strings.NewReader(embed))
+	if err != nil {
+		return "", err
+	}
+
+	var paragraph *xhtml.Node
+	var find func(n *xhtml.Node)
+	find = func(n *xhtml.Node) {
+		if paragraph != nil {
+			return
+		}
+		if n.Type == xhtml.ElementNode && n.Data == "p" {
+			paragraph = n
+			return
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			find(child)
+		}
+	}
+	find(doc)
+	if paragraph == nil {
+		return "", nil
+	}
+
+	var text strings.Builder
+	var collect func(n *xhtml.Node)
+	collect = func(n *xhtml.Node) {
+		switch {
+		case n.Type == xhtml.TextNode:
+			text.WriteString(n.Data)
+		case n.Type == xhtml.ElementNode && n.Data == "br":
+			text.WriteString("\n")
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			collect(child)
+		}
+	}
+	collect(paragraph)
+	return strings.TrimSpace(text.String()), nil
+}
+
+// syndicationToken computes the token the syndication endpoint expects next to a tweet ID, as the
+// embed script does: (id / 1e15 * π).toString(36) with the zeros and the point removed
+func syndicationToken(id string) string {
+	value, _ := strconv.ParseFloat(id, 64)
+	token := formatBase36(value / 1e15 * math.Pi)
+	return strings.NewReplacer("0", "", ".", "").Replace(token)
+}
+
+// formatBase36 formats a non-negative number in base 36 the way JavaScript's toString(36) does,
+// with as many fractional digits as are needed to tell the number apart from its neighbours
+func formatBase36(value float64) string {
+	const digits = "0123456789abcdefghijklmnopqrstuvwxyz"
+	integer := math.Floor(value)
+	fraction := value - integer
+
+	// Digits are generated until the rest is smaller than half the gap to the next float
+	delta := max(0.5*(math.Nextafter(value, math.Inf(1))-value), math.SmallestNonzeroFloat64)
+	var fractionDigits []byte
+	if fraction >= delta {
+		for {
+			fraction *= 36
+			delta *= 36
+			digit := int(fraction)
+			fractionDigits = append(fractionDigits, digits[digit])
+			fraction -= float64(digit)
+			if fraction > 0.5 || (fraction == 0.5 && digit&1 == 1) {
+				if fraction+delta > 1 {
+					// Round up, carrying into the earlier digits and the integer part
+					for {
+						last := len(fractionDigits) - 1
+						if last < 0 {
+							integer++
+							break
+						}
+						d := strings.IndexByte(digits, fractionDigits[last])
+						if d+1 < 36 {
+							fractionDigits[last] = digits[d+1]
+							break
+						}
+						fractionDigits = fractionDigits[:last]
+					}
+					break
+				}
+			}
+			if fraction < delta {
+				break
+			}
+		}
+	}
+
+	var integerDigits []byte
+	for {
+		remainder := math.Mod(integer, 36)
+		integerDigits = append([]byte{digits[int(remainder)]}, integerDigits...)
+		integer = (integer - remainder) / 36
+		if integer <= 0 {
+			break
+		}
+	}
+
+	if len(fractionDigits) == 0 {
+		return string(integerDigits)
+	}
+	return string(integerDigits) + "." + string(fractionDigits)
+}
+
+// Title names the author of the thread, for use as the page title
+func (t *Thread) Title() string {
+	if len(t.Tweets) == 0 {
+		return ""
+	}
+	first := t.Tweets[0]
+	if first.Handle == "" {
+		return first.Author + " on X"
+	}
+	return fmt.Sprintf("%s (@%s) on X", first.Author, first.Handle)
+}
+
+// String renders the thread as plain text for summarization, one numbered post after another
+func (t *Thread) String() string {
+	var s strings.Builder
+
+	if len(t.Tweets) > 1 {
+		fmt.Fprintf(&s, "Thread by %s, %d posts\n\n", t.Title(), len(t.Tweets))
+	} else {
+		fmt.Fprintf(&s, "Post by %s\n\n", t.Title())
+	}
+	for i, tweet := range t.Tweets {
+		if len(t.Tweets) > 1 {
+			fmt.Fprintf(&s, "%d/%d ", i+1, len(t.Tweets))
+		}
+		s.WriteString(tweet.Text)
+		s.WriteString("\n")
+		if tweet.Quoted != nil {
+			fmt.Fprintf(&s, "Quoting %s (@%s): %s\n", tweet.Quoted.Author, tweet.Quoted.Handle, tweet.Quoted.Text)
+		}
+		s.WriteString("\n")
+	}
+
+	return strings.TrimSpace(s.String())
+}
@@ -0,0 +1,161 @@
+package twitter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/bakkerme/ai-news-processor/internal/fetcher"
+	"github.com/bakkerme/ai-news-processor/internal/http/retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStatusURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		expected string
+		ok       bool
+	}{
+		{name: "x.com status", url: "https://x.com/karpathy/status/1683920951807971329", expected: "1683920951807971329", ok: true},
+		{name: "twitter.com with query", url: "https://twitter.com/karpathy/status/1683920951807971329?s=20", expected: "1683920951807971329", ok: true},
+		{name: "mobile photo link", url: "https://mobile.twitter.com/user/status/123/photo/1", expected: "123", ok: true},
+		{name: "web status path", url: "https://x.com/i/web/status/456", expected: "456", ok: true},
+		{name: "embed fixer", url: "https://fxtwitter.com/user/status/789", expected: "789", ok: true},
+		{name: "profile", url: "https://x.com/karpathy", ok: false},
+		{name: "search", url: "https://x.com/search?q=llama", ok: false},
+		{name: "other domain", url: "https://example.com/user/status/123", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			require.NoError(t, err)
+
+			id, ok := ParseStatusURL(u)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, id)
+		})
+	}
+}
+
+func TestClient_FetchThread(t *testing.T) {
+	tweets := map[string]string{
+		// The linked post, with its parent embedded and a quoted post
+		"3": `{
+			"__typename": "Tweet",
+			"id_str": "3",
+			"text": "@dev And the weights are up: https://t.co/abc https://t.co/pic",
+			"display_text_range": [5, 45],
+			"created_at": "2025-05-01T12:00:00.000Z",
+			"in_reply_to_status_id_str": "2",
+			"in_reply_to_screen_name": "dev",
+			"user": {"name": "Dev", "screen_name": "dev"},
+			"entities": {
+				"urls": [{"url": "https://t.co/abc", "expanded_url": "https://huggingface.co/dev/model"}],
+				"media": [{"url": "https://t.co/pic"}]
+			},
+			"parent": {
+				"__typename": "Tweet",
+				"id_str": "2",
+				"text": "It beats the old one &amp; runs on a laptop.",
+				"in_reply_to_status_id_str": "1",
+				"in_reply_to_screen_name": "dev",
+				"user": {"name": "Dev", "screen_name": "dev"}
+			},
+			"quoted_tweet": {
+				"__typename": "Tweet",
+				"id_str": "9",
+				"text": "Waiting for this",
+				"user": {"name": "Fan", "screen_name": "fan"}
+			}
+		}`,
+		"1": `{
+			"__typename": "Tweet",
+			"id_str": "1",
+			"text": "We are releasing a new model.",
+			"in_reply_to_status_id_str": "0",
+			"in_reply_to_screen_name": "someone_else",
+			"user": {"name": "Dev", "screen_name": "dev"}
+		}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/tweet-result", r.URL.Path)
+		assert.NotEmpty(t, r.URL.Query().Get("token"))
+		body, ok := tweets[r.URL.Query().Get("id")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	f := fetcher.NewHTTPFetcher(server.Client(), retry.RetryConfig{}, "")
+	client := NewClient(f, server.URL, server.URL)
+
+	thread, err := client.FetchThread(context.Background(), "3")
+	require.NoError(t, err)
+	require.Len(t, thread.Tweets, 3)
+
+	assert.Equal(t, "We are releasing a new model.", thread.Tweets[0].Text)
+	assert.Equal(t, "It beats the old one & runs on a laptop.", thread.Tweets[1].Text)
+	assert.Equal(t, "And the weights are up: https://huggingface.co/dev/model", thread.Tweets[2].Text)
+	require.NotNil(t, thread.Tweets[2].Quoted)
+	assert.Equal(t, "Waiting for this", thread.Tweets[2].Quoted.Text)
+
+	assert.Equal(t, "Dev (@dev) on X", thread.Title())
+	text := thread.String()
+	assert.Contains(t, text, "Thread by Dev (@dev) on X, 3 posts")
+	assert.Contains(t, text, "1/3 We are releasing a new model.")
+	assert.Contains(t, text, "Quoting Fan (@fan): Waiting for this")
+}
+
+func TestClient_FetchThread_OEmbedFallback(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/tweet-result", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"__typename": "TweetTombstone"}`))
+	})
+	mux.HandleFunc("/oembed", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "https://twitter.com/i/status/5", r.URL.Query().Get("url"))
+		w.Write([]byte(`{
+			"author_name": "Dev",
+			"author_url": "https://twitter.com/dev",
+			"html": "<blockquote class=\"twitter-tweet\"><p lang=\"en\" dir=\"ltr\">First line<br>Second &amp; last <a href=\"https://t.co/x\">pic.twitter.com/x</a></p>&mdash; Dev (@dev) <a href=\"https://twitter.com/dev/status/5\">May 1, 2025</a></blockquote>"
+		}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	f := fetcher.NewHTTPFetcher(server.Client(), retry.RetryConfig{}, "")
+	client := NewClient(f, server.URL, server.URL)
+
+	thread, err := client.FetchThread(context.Background(), "5")
+	require.NoError(t, err)
+	require.Len(t, thread.Tweets, 1)
+	assert.Equal(t, "dev", thread.Tweets[0].Handle)
+	assert.Equal(t, "First line\nSecond & last pic.twitter.com/x", thread.Tweets[0].Text)
+	assert.Equal(t, "Post by Dev (@dev) on X\n\nFirst line\nSecond & last pic.twitter.com/x", thread.String())
+}
+
+func TestClient_FetchThread_Unavailable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	f := fetcher.NewHTTPFetcher(server.Client(), retry.RetryConfig{}, "")
+	client := NewClient(f, server.URL, server.URL)
+
+	_, err := client.FetchThread(context.Background(), "404")
+	assert.Error(t, err)
+}
+
+func TestSyndicationToken(t *testing.T) {
+	// Tokens computed by the embed script, ((id / 1e15) * Math.PI).toString(36) without zeros and the point
+	assert.Equal(t, "42y6zv7ufp", syndicationToken("1683920951807971329"))
+	assert.Equal(t, "2zqic77uqyk", syndicationToken("1234567890123456789"))
+	assert.Equal(t, "bhi2ay3f28n", syndicationToken("1"))
+}
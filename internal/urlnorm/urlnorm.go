@@ -0,0 +1,288 @@
+// Package urlnorm cleans URLs before they are fetched, cached, compared or sent. Links shared on
+// social sites and in newsletters carry tracking parameters and go through link shorteners, so
+// the same article turns up under many URLs; Clean and Key reduce them to one.
+package urlnorm
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// trackingParams are query parameters that only identify the campaign, click or sharer
+var trackingParams = map[string]bool{
+	"fbclid":        true,
+	"gclid":         true,
+	"gclsrc":        true,
+	"dclid":         true,
+	"gbraid":        true,
+	"wbraid":        true,
+	"msclkid":       true,
+	"yclid":         true,
+	"twclid":        true,
+	"ttclid":        true,
+	"li_fat_id":     true,
+	"igshid":        true,
+	"igsh":          true,
+	"mc_cid":        true,
+	"mc_eid":        true,
+	"_hsenc":        true,
+	"_hsmi":         true,
+	"__hstc":        true,
+	"__hssc":        true,
+	"__hsfp":        true,
+	"hsctatracking": true,
+	"mkt_tok":       true,
+	"oly_anon_id":   true,
+	"oly_enc_id":    true,
+	"vero_id":       true,
+	"vero_conv":     true,
+	"_ga":           true,
+	"_gl":           true,
+	"ref_src":       true,
+	"ref_url":       true,
+}
+
+// trackingPrefixes are prefixes of the query parameters of campaign trackers
+var trackingPrefixes = []string{"utm_", "pk_", "mtm_"}
+
+// siteTrackingParams are parameters that only track sharing on the site they are listed for
+var siteTrackingParams = map[string][]string{
+	"youtube.com": {"si", "feature", "pp"},
+	"youtu.be":    {"si", "feature"},
+	"x.com":       {"s", "t"},
+	"twitter.com": {"s", "t"},
+	"spotify.com": {"si"},
+}
+
+// Clean returns a copy of u with the scheme and host lowercased, the default port removed, user
+// info, tracking parameters and text fragments (#:~:text=) dropped and the remaining query sorted.
+// youtu.be links are rewritten to the youtube.com video they point at.
+func Clean(u *url.URL) *url.URL {
+	cleaned := *u
+	cleaned.User = nil
+	cleaned.Scheme = strings.ToLower(cleaned.Scheme)
+	cleaned.Host = strings.ToLower(cleaned.Host)
+	if port := cleaned.Port(); (port == "80" && cleaned.Scheme == "http") || (port == "443" && cleaned.Scheme == "https") {
+		cleaned.Host = cleaned.Hostname()
+	}
+	if strings.HasPrefix(cleaned.Fragment, ":~:") {
+		cleaned.Fragment, cleaned.RawFragment = "", ""
+	}
+
+	// A query that does not parse is kept as it is, rather than losing the parameters that do not
+	query, err := url.ParseQuery(cleaned.RawQuery)
+	if err != nil {
+		return &cleaned
+	}
+	host := strings.TrimPrefix(cleaned.Hostname(), "www.")
+	if host == "youtu.be" && len(strings.Trim(cleaned.Path, "/")) > 0 {
+		query.Set("v", strings.Trim(cleaned.Path, "/"))
+		cleaned.Host, cleaned.Path, cleaned.RawPath = "www.youtube.com", "/watch", ""
+		host = "youtube.com"
+	}
+	for name := range query {
+		if isTrackingParam(host, name) {
+			query.Del(name)
+		}
+	}
+	// Encode sorts the parameters, so their order does not make two URLs different
+	cleaned.RawQuery = query.Encode()
+	cleaned.ForceQuery = false
+
+	if cleaned.Path == "" && cleaned.Host != "" {
+		cleaned.Path = "/"
+	}
+	return &cleaned
+}
+
+// CleanString cleans a URL given as a string. Strings that are not absolute URLs are returned as they are.
+func CleanString(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || !u.IsAbs() {
+		return raw
+	}
+	return Clean(u).String()
+}
+
+// Key returns the form of a URL used to tell whether two URLs are the same page: the cleaned URL
+// without the scheme, a leading www., the fragment and a trailing slash. Strings that are not
+// absolute URLs are their own key.
+func Key(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || !u.IsAbs() {
+		return raw
+	}
+	cleaned := Clean(u)
+	key := strings.TrimPrefix(cleaned.Host, "www.") + strings.TrimRight(cleaned.EscapedPath(), "/")
+	if cleaned.RawQuery != "" {
+		key += "?" + cleaned.RawQuery
+	}
+	return key
+}
+
+// CleanAll cleans urls and drops those with the same Key as an earlier one
+func CleanAll(urls []url.URL) []url.URL {
+	if urls == nil {
+		return nil
+	}
+	seen := make(map[string]bool, len(urls))
+	cleaned := make([]url.URL, 0, len(urls))
+	for _, u := range urls {
+		c := Clean(&u)
+		key := Key(c.String())
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		cleaned = append(cleaned, *c)
+	}
+	return cleaned
+}
+
+// isTrackingParam reports whether the query parameter name of a URL on host only tracks the click
+func isTrackingParam(host string, name string) bool {
+	name = strings.ToLower(name)
+	if trackingParams[name] {
+		return true
+	}
+	for _, prefix := range trackingPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	for site, params := range siteTrackingParams {
+		if host != site && !strings.HasSuffix(host, "."+site) {
+			continue
+		}
+		for _, param := range params {
+			if name == param {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// shortenerHosts are link shorteners whose links are resolved to the page they redirect to
+var shortenerHosts = map[string]bool{
+	"bit.ly":      true,
+	"buff.ly":     true,
+	"cutt.ly":     true,
+	"dlvr.it":     true,
+	"fb.me":       true,
+	"goo.gl":      true,
+	"is.gd":       true,
+	"j.mp":        true,
+	"lnkd.in":     true,
+	"ow.ly":       true,
+	"rb.gy":       true,
+	"rebrand.ly":  true,
+	"shorturl.at": true,
+	"t.co":        true,
+	"t.ly":        true,
+	"tiny.cc":     true,
+	"tinyurl.com": true,
+	"trib.al":     true,
+}
+
+// maxRedirects limits how many redirects of chained shorteners are followed
+const maxRedirects = 5
+
+// resolverUserAgent identifies the resolver, as some shorteners serve browsers a page instead of a redirect
+const resolverUserAgent = "ai-news-processor-resolver/1.0"
+
+// Resolver resolves the links of link shorteners to the URL they redirect to, without fetching
+// the page itself. Resolved links are cached for the life of the resolver.
+type Resolver struct {
+	client *http.Client
+	hosts  map[string]bool
+
+	mu    sync.Mutex
+	cache map[string]*url.URL
+}
+
+// NewResolver creates a resolver. If client is nil, a client with a 10-second timeout is used.
+// The client does not follow redirects, the resolver reads them one at a time.
+func NewResolver(client *http.Client) *Resolver {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	noFollow := *client
+	noFollow.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return &Resolver{
+		client: &noFollow,
+		hosts:  shortenerHosts,
+		cache:  make(map[string]*url.URL),
+	}
+}
+
+// Resolve returns the cleaned URL a shortened link redirects to. Other URLs, and short links that
+// cannot be resolved, are returned cleaned.
+func (r *Resolver) Resolve(ctx context.Context, u *url.URL) *url.URL {
+	if r == nil || !r.isShortener(u) {
+		return Clean(u)
+	}
+
+	r.mu.Lock()
+	cached, ok := r.cache[u.String()]
+	r.mu.Unlock()
+	if ok {
+		resolved := *cached
+		return &resolved
+	}
+
+	current := u
+	for hop := 0; hop < maxRedirects && r.isShortener(current); hop++ {
+		next, ok := r.redirect(ctx, current)
+		if !ok {
+			break
+		}
+		current = next
+	}
+
+	resolved := Clean(current)
+	r.mu.Lock()
+	r.cache[u.String()] = resolved
+	r.mu.Unlock()
+	copied := *resolved
+	return &copied
+}
+
+// redirect returns the Location a short link redirects to. HEAD is tried first, as it does not
+// transfer a body; shorteners that refuse it are asked with GET.
+func (r *Resolver) redirect(ctx context.Context, u *url.URL) (*url.URL, bool) {
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
+		if err != nil {
+			return nil, false
+		}
+		req.Header.Set("User-Agent", resolverUserAgent)
+
+		resp, err := r.client.Do(req)
+		if err != nil {
+			return nil, false
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode < 300 || resp.StatusCode >= 400 {
+			continue
+		}
+		location, err := resp.Location()
+		if err != nil {
+			return nil, false
+		}
+		return location, true
+	}
+	return nil, false
+}
+
+// isShortener reports whether u is on the host of a link shortener
+func (r *Resolver) isShortener(u *url.URL) bool {
+	return r.hosts[strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")]
+}
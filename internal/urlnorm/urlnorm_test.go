@@ -0,0 +1,150 @@
+package urlnorm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCleanString(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		expected string
+	}{
+		{
+			name:     "tracking parameters",
+			url:      "https://example.com/post?utm_source=twitter&id=7&fbclid=abc&UTM_Campaign=x",
+			expected: "https://example.com/post?id=7",
+		},
+		{
+			name:     "host and scheme lowercased, default port dropped",
+			url:      "HTTPS://Example.COM:443/Path",
+			expected: "https://example.com/Path",
+		},
+		{
+			name:     "query sorted",
+			url:      "https://example.com/search?q=llm&page=2",
+			expected: "https://example.com/search?page=2&q=llm",
+		},
+		{
+			name:     "text fragment dropped, anchors kept",
+			url:      "https://example.com/a#:~:text=model",
+			expected: "https://example.com/a",
+		},
+		{
+			name:     "anchor kept",
+			url:      "https://example.com/a#results",
+			expected: "https://example.com/a#results",
+		},
+		{
+			name:     "youtu.be rewritten",
+			url:      "https://youtu.be/dQw4w9WgXcQ?si=share&t=42",
+			expected: "https://www.youtube.com/watch?t=42&v=dQw4w9WgXcQ",
+		},
+		{
+			name:     "site parameters only dropped on their site",
+			url:      "https://example.com/a?si=1&s=2",
+			expected: "https://example.com/a?s=2&si=1",
+		},
+		{
+			name:     "empty path",
+			url:      "https://example.com?utm_source=x",
+			expected: "https://example.com/",
+		},
+		{
+			name:     "invalid query kept",
+			url:      "https://example.com/a?x=%zz&utm_source=y",
+			expected: "https://example.com/a?x=%zz&utm_source=y",
+		},
+		{
+			name:     "not a url",
+			url:      "not a url",
+			expected: "not a url",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, CleanString(tt.url))
+		})
+	}
+}
+
+func TestKey(t *testing.T) {
+	same := []string{
+		"https://example.com/article",
+		"http://www.example.com/article/",
+		"https://EXAMPLE.com/article?utm_source=newsletter",
+		"https://example.com/article?fbclid=xyz#:~:text=quote",
+	}
+	for _, u := range same {
+		assert.Equal(t, "example.com/article", Key(u), u)
+	}
+	assert.NotEqual(t, Key("https://example.com/article?id=1"), Key("https://example.com/article?id=2"))
+}
+
+func TestCleanAll(t *testing.T) {
+	parse := func(s string) url.URL {
+		u, err := url.Parse(s)
+		require.NoError(t, err)
+		return *u
+	}
+	cleaned := CleanAll([]url.URL{
+		parse("https://example.com/a?utm_source=x"),
+		parse("https://www.example.com/a/"),
+		parse("https://example.com/b"),
+	})
+	require.Len(t, cleaned, 2)
+	assert.Equal(t, "https://example.com/a", cleaned[0].String())
+	assert.Equal(t, "https://example.com/b", cleaned[1].String())
+	assert.Nil(t, CleanAll(nil))
+}
+
+func TestResolver_Resolve(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/short":
+			// Some shorteners refuse HEAD requests
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			http.Redirect(w, r, "/chained", http.StatusMovedPermanently)
+		case "/chained":
+			http.Redirect(w, r, "https://example.com/article?utm_source=short", http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	resolver := NewResolver(server.Client())
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	resolver.hosts = map[string]bool{serverURL.Hostname(): true}
+
+	short, _ := url.Parse(server.URL + "/short")
+	resolved := resolver.Resolve(context.Background(), short)
+	assert.Equal(t, "https://example.com/article", resolved.String())
+
+	// Resolved links are cached
+	before := requests
+	assert.Equal(t, "https://example.com/article", resolver.Resolve(context.Background(), short).String())
+	assert.Equal(t, before, requests)
+
+	// Links that do not redirect are kept
+	missing, _ := url.Parse(server.URL + "/missing?utm_source=x")
+	assert.Equal(t, server.URL+"/missing", resolver.Resolve(context.Background(), missing).String())
+
+	// Other hosts are only cleaned
+	other, _ := url.Parse("https://example.org/a?fbclid=1")
+	assert.Equal(t, "https://example.org/a", resolver.Resolve(context.Background(), other).String())
+}
@@ -0,0 +1,77 @@
+// Package watchlist matches feed entries against the must-include keywords and patterns of a
+// persona. Entries on a watchlist are never dropped by the quality or relevance filters.
+package watchlist
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Watchlist is a compiled list of keywords and patterns. A nil Watchlist matches nothing.
+type Watchlist struct {
+	entries []entry
+}
+
+type entry struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// Compile compiles watchlist entries. An entry enclosed in slashes, such as /qwen-?3/, is a
+// regular expression; any other entry is a keyword matched as a whole word, ignoring case.
+// Compile returns nil if there are no entries.
+func Compile(entries []string) (*Watchlist, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	w := &Watchlist{}
+	for _, e := range entries {
+		e = strings.TrimSpace(e)
+		if e == "" {
+			return nil, fmt.Errorf("watchlist entries cannot be empty")
+		}
+
+		var expr string
+		if len(e) > 2 && strings.HasPrefix(e, "/") && strings.HasSuffix(e, "/") {
+			expr = e[1 : len(e)-1]
+		} else {
+			expr = `(?i)` + wordBoundary(e[0]) + regexp.QuoteMeta(e) + wordBoundary(e[len(e)-1])
+		}
+
+		pattern, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid watchlist pattern '%s': %w", e, err)
+		}
+		w.entries = append(w.entries, entry{name: e, pattern: pattern})
+	}
+	return w, nil
+}
+
+// wordBoundary returns a word boundary for a keyword starting or ending with c. Keywords such as
+// "C++" end in a character that is not part of a word, where a boundary would never match.
+func wordBoundary(c byte) string {
+	if c == '_' || ('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') {
+		return `\b`
+	}
+	return ""
+}
+
+// Match returns the watchlist entries found in any of the texts, in watchlist order
+func (w *Watchlist) Match(texts ...string) []string {
+	if w == nil {
+		return nil
+	}
+
+	var matched []string
+	for _, e := range w.entries {
+		for _, text := range texts {
+			if e.pattern.MatchString(text) {
+				matched = append(matched, e.name)
+				break
+			}
+		}
+	}
+	return matched
+}
@@ -0,0 +1,33 @@
+package watchlist
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatch(t *testing.T) {
+	w, err := Compile([]string{"Qwen", "/deepseek[- ]?r\\d/", "C++", "Mistral AI"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"Qwen"}, w.Match("New qwen 3 weights"))
+	assert.Empty(t, w.Match("Qwenlike models"), "keywords match whole words")
+	assert.Equal(t, []string{"/deepseek[- ]?r\\d/"}, w.Match("Title", "running deepseek-r2 locally"))
+	assert.Equal(t, []string{"C++"}, w.Match("llama.cpp is written in C++."))
+	assert.Equal(t, []string{"Qwen", "Mistral AI"}, w.Match("mistral ai and Qwen benchmarks"))
+	assert.Empty(t, w.Match("Nothing to see"))
+}
+
+func TestCompile(t *testing.T) {
+	w, err := Compile(nil)
+	require.NoError(t, err)
+	assert.Nil(t, w)
+	assert.Empty(t, w.Match("anything"), "a nil watchlist matches nothing")
+
+	_, err = Compile([]string{"/qwen(/"})
+	assert.ErrorContains(t, err, "invalid watchlist pattern '/qwen(/'")
+
+	_, err = Compile([]string{" "})
+	assert.Error(t, err)
+}
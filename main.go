@@ -1,9 +1,11 @@
 package main
 
 import (
+	"os"
+
 	"github.com/bakkerme/ai-news-processor/internal"
 )
 
 func main() {
-	internal.Run()
+	os.Exit(internal.Run().ExitCode)
 }
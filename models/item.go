@@ -3,6 +3,7 @@ package models
 import (
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/bakkerme/ai-news-processor/internal/feeds"
@@ -20,8 +21,71 @@ type Item struct {
 	Link                string      `json:"link,omitempty"`
 	IsRelevant          bool        `json:"isRelevant"`
 	RelevanceToCriteria string      `json:"relevanceToCriteria,omitempty"`
+	RelevanceConfidence int         `json:"relevanceConfidence,omitempty"` // How sure the LLM is of IsRelevant, from 1 (a guess) to 100 (certain); 0 if unknown
+	Unsure              bool        `json:"unsure,omitempty"`              // Relevance too uncertain to decide, so the item is listed apart in the digest
 	ThumbnailURL        string      `json:"thumbnailUrl,omitempty"`
+	Entities            []Entity    `json:"entities,omitempty"`
+	Topics              []string    `json:"topics,omitempty"`
+	Unavailable         bool        `json:"unavailable,omitempty"` // Placeholder for an entry that failed processing, with only a title and link
+	Watchlist           []string    `json:"watchlist,omitempty"`   // Watchlist entries of the persona the item matched; such items are always sent
+	Claims              []Claim     `json:"claims,omitempty"`      // Factual claims of the summaries with their sources, for personas that ask for them
+	CanonicalID         string      `json:"canonicalId,omitempty"` // ID of the original post of a Reddit crosspost or repost
+	Updated             bool        `json:"updated,omitempty"`     // Sent before, and sent again because the post was edited substantially since
+	Megathread          bool        `json:"megathread,omitempty"`  // A live thread or megathread, summarized by the themes of its discussion
+	Fields              []Field     `json:"fields,omitempty"`      // Values of the custom fields of the persona, in the order it lists them
 	Entry               feeds.Entry `json:"entry,omitempty"`
+
+	// Link health found just before the digest is rendered, not stored
+	ThumbnailDead bool     `json:"-"` // The thumbnail no longer loads and was removed from ThumbnailURL
+	DeadLinks     []string `json:"-"` // The item link and source links that no longer resolve
+
+	// Importance assigned by the LLM
+	ImportanceScore  int    `json:"importanceScore,omitempty" jsonschema:"minimum=1,maximum=10"` // 1 (minor) to 10 (major news); 0 if unknown
+	ImportanceReason string `json:"importanceReason,omitempty"`                                  // One-line justification of the score
+}
+
+// Entity types extracted from items
+const (
+	EntityModel   = "model"
+	EntityCompany = "company"
+	EntityLibrary = "library"
+	EntityDataset = "dataset"
+	EntityPerson  = "person"
+	EntityOther   = "other"
+)
+
+// Entity is a named thing mentioned in an item, such as a model, company or library
+type Entity struct {
+	Name string `json:"name"`
+	Type string `json:"type"` // One of the Entity* constants
+}
+
+// Sources a claim can be attributed to
+const (
+	ClaimSourcePost    = "post"
+	ClaimSourceComment = "comment"
+	ClaimSourceLink    = "link"
+	ClaimSourceImage   = "image"
+)
+
+// Claim is a factual claim made in an item's summaries, with the part of the entry it comes from
+type Claim struct {
+	Claim  string `json:"claim"`
+	Source string `json:"source"`          // One of the ClaimSource* constants
+	Quote  string `json:"quote,omitempty"` // Short verbatim quote of the source backing the claim
+	URL    string `json:"url,omitempty"`   // Linked page of a claim from a link
+}
+
+// Field is the value of a custom field a persona adds to its items
+type Field struct {
+	Name  string `json:"name"`
+	Label string `json:"label"`
+	Value any    `json:"value"` // A string, float64, int64, bool or []string, depending on the type of the field
+}
+
+// IsDeadLink reports whether u, the item link or one of its source links, no longer resolves
+func (item Item) IsDeadLink(u string) bool {
+	return slices.Contains(item.DeadLinks, u)
 }
 
 // ToSummaryString creates a concise string representation of the Item for summary generation
@@ -44,6 +108,11 @@ type ItemSubset struct {
 	CommentSummary      string   `json:"commentSummary,omitempty"`
 	RelevanceToCriteria string   `json:"relevanceToCriteria"`
 	IsRelevant          bool     `json:"isRelevant"`
+	RelevanceConfidence int      `json:"relevanceConfidence" jsonschema:"minimum=1,maximum=100"`
+	Entities            []Entity `json:"entities"`
+	Topics              []string `json:"topics"`
+	ImportanceScore     int      `json:"importanceScore" jsonschema:"minimum=1,maximum=10"`
+	ImportanceReason    string   `json:"importanceReason"`
 }
 
 // KeyDevelopment represents a key development and its referenced item
@@ -55,6 +124,17 @@ type KeyDevelopment struct {
 // SummaryResponse represents an overall summary of multiple relevant AI news items
 type SummaryResponse struct {
 	KeyDevelopments []KeyDevelopment `json:"keyDevelopments"`
+	RisingTopics    []RisingTopic    `json:"risingTopics,omitempty"` // Filled in from run history, not by the LLM
+	Starter         bool             `json:"-"`                      // Set for a persona's first, capped digest, not by the LLM
+	Degraded        []string         `json:"-"`                      // Enrichments the run skipped after repeated failures, not by the LLM
+}
+
+// RisingTopic is a term mentioned noticeably more often in this run than in previous runs
+type RisingTopic struct {
+	Term    string   `json:"term"`
+	Count   int      `json:"count"`   // Number of items mentioning the term in this run
+	Average float64  `json:"average"` // Average number of items mentioning the term in previous runs
+	ItemIDs []string `json:"itemIDs,omitempty"`
 }
 
 // UnmarshalJSON implements custom unmarshaling for SummaryResponse to handle both object and array formats
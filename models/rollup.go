@@ -0,0 +1,15 @@
+package models
+
+// RollupTheme is a theme that ran through several items over the rollup period
+type RollupTheme struct {
+	Title   string   `json:"title"`
+	Summary string   `json:"summary"`
+	ItemIDs []string `json:"itemIDs"`
+}
+
+// RollupResponse is a week-in-review style summary of the items sent over several days
+type RollupResponse struct {
+	Overview string        `json:"overview"`
+	Themes   []RollupTheme `json:"themes"`
+	Trends   []string      `json:"trends"`
+}
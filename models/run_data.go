@@ -1,6 +1,8 @@
 package models
 
 import (
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/bakkerme/ai-news-processor/internal/persona" // Import for persona.Persona
@@ -8,9 +10,19 @@ import (
 
 // EntrySummary represents the raw input and results for the entire processing pipeline
 type EntrySummary struct {
-	RawInput       string `json:"rawInput"`         // The raw input strings sent to the LLM
-	Results        Item   `json:"results"`          // The processed results from the LLM, uses models.Item
-	ProcessingTime int64  `json:"processingTimeMs"` // Time taken to process the entry in milliseconds
+	RawInput         string `json:"rawInput"`                   // The raw input strings sent to the LLM
+	Results          Item   `json:"results"`                    // The processed results from the LLM, uses models.Item
+	ProcessingTime   int64  `json:"processingTimeMs"`           // Time taken to process the entry in milliseconds
+	PromptTokens     int64  `json:"promptTokens,omitempty"`     // Prompt tokens of the entry's requests, including retries
+	CompletionTokens int64  `json:"completionTokens,omitempty"` // Completion tokens of the entry's requests, including retries
+	SystemPromptHash string `json:"systemPromptHash,omitempty"` // Hash of the system prompt the entry was processed with, a key of RunData.SystemPrompts
+}
+
+// PromptVersion identifies the template a system prompt was rendered from
+type PromptVersion struct {
+	Template string `json:"template"`          // Name of the template, such as "base", or "custom" for a template given at run time
+	Version  string `json:"version,omitempty"` // Semantic version of the template, bumped whenever it is changed
+	Hash     string `json:"hash"`              // Hash of the template text, which changes with any edit
 }
 
 // ImageSummary represents the benchmark data for image processing
@@ -32,6 +44,31 @@ type WebContentSummary struct {
 	ProcessingTime  int64  `json:"processingTimeMs"`  // Time taken to process the web content in milliseconds
 }
 
+// BlockedEntry is an entry dropped by the persona's blocklist before it was processed
+type BlockedEntry struct {
+	ID     string `json:"id"`
+	Title  string `json:"title"`
+	Link   string `json:"link,omitempty"`
+	Author string `json:"author,omitempty"`
+	Reason string `json:"reason"` // Blocklist rule that matched
+}
+
+// What was done about a URL or ID the LLM made up
+const (
+	ViolationStripped   = "stripped"   // Removed from the response
+	ViolationReplaced   = "replaced"   // Replaced with the ID of the entry
+	ViolationReprompted = "reprompted" // Gone from the response to a corrective re-prompt
+)
+
+// Violation is a URL or item ID in an LLM response that is not in the input it was given
+type Violation struct {
+	EntryID string `json:"entryId,omitempty"` // Entry whose response held it; empty for the overall summary
+	Field   string `json:"field"`             // Response field, such as "summary" or "keyDevelopments"
+	Kind    string `json:"kind"`              // "url" or "id"
+	Value   string `json:"value"`             // The URL or ID
+	Action  string `json:"action"`            // One of the Violation* constants
+}
+
 // RunData represents the data collected during a run, intended for auditing and benchmarking.
 // This was formerly BenchmarkData in bench.go
 type RunData struct {
@@ -49,4 +86,88 @@ type RunData struct {
 	ImageTotalProcessingTime      int64               `json:"imageTotalProcessingTime,omitempty"`
 	WebContentTotalProcessingTime int64               `json:"webContentTotalProcessingTime,omitempty"`
 	SuccessRate                   float64             `json:"successRate,omitempty"`
+	Locale                        string              `json:"locale,omitempty"`            // BCP 47 locale of the persona
+	JudgeInstructions             string              `json:"judgeInstructions,omitempty"` // Language guidance to prepend to judge prompts for non-English personas
+	Tags                          []TagCount          `json:"tags,omitempty"`              // Entities and topics over all processed items
+	Importance                    *ImportanceStats    `json:"importance,omitempty"`        // Importance scores over all processed items
+	Blocked                       []BlockedEntry      `json:"blocked,omitempty"`           // Entries dropped by the blocklist before processing
+	PromptVersions                []PromptVersion     `json:"promptVersions,omitempty"`    // Templates of the entry and summary prompts
+	SystemPrompts                 map[string]string   `json:"systemPrompts,omitempty"`     // Rendered system prompts by hash, stored once however many entries used them
+	Violations                    []Violation         `json:"violations,omitempty"`        // URLs and IDs the LLM made up, and what was done about them
+	Degraded                      []string            `json:"degraded,omitempty"`          // Enrichments skipped after repeated failures, such as "images" or "links"
+}
+
+// ImportanceStats summarizes the importance scores the LLM gave the items of a run, so audits can
+// check the scores are spread out and agree with the relevance verdicts
+type ImportanceStats struct {
+	Scored       int     `json:"scored"`       // Items with a score
+	Unscored     int     `json:"unscored"`     // Items the LLM gave no valid score
+	Mean         float64 `json:"mean"`         // Mean score of all scored items
+	RelevantMean float64 `json:"relevantMean"` // Mean score of the scored relevant items
+	Counts       [10]int `json:"counts"`       // Number of items with each score from 1 to 10
+}
+
+// SummarizeImportance computes the importance statistics of a run's items. It returns nil if
+// there are no items.
+func SummarizeImportance(items []Item) *ImportanceStats {
+	if len(items) == 0 {
+		return nil
+	}
+	stats := &ImportanceStats{}
+	total, relevantTotal, relevant := 0, 0, 0
+	for _, item := range items {
+		if item.ImportanceScore < 1 || item.ImportanceScore > len(stats.Counts) {
+			stats.Unscored++
+			continue
+		}
+		stats.Scored++
+		stats.Counts[item.ImportanceScore-1]++
+		total += item.ImportanceScore
+		if item.IsRelevant {
+			relevant++
+			relevantTotal += item.ImportanceScore
+		}
+	}
+	if stats.Scored > 0 {
+		stats.Mean = float64(total) / float64(stats.Scored)
+	}
+	if relevant > 0 {
+		stats.RelevantMean = float64(relevantTotal) / float64(relevant)
+	}
+	return stats
+}
+
+// TagCount is the number of items tagged with an entity or topic in a run
+type TagCount struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"` // The entity type, or "topic"
+	Items int    `json:"items"`
+}
+
+// CountTags counts the items mentioning each entity and topic, most common first
+func CountTags(items []Item) []TagCount {
+	index := make(map[string]int)
+	var counts []TagCount
+	add := func(name, tagType string) {
+		key := tagType + "\x00" + strings.ToLower(name)
+		if i, ok := index[key]; ok {
+			counts[i].Items++
+			return
+		}
+		index[key] = len(counts)
+		counts = append(counts, TagCount{Name: name, Type: tagType, Items: 1})
+	}
+	for _, item := range items {
+		for _, entity := range item.Entities {
+			add(entity.Name, entity.Type)
+		}
+		for _, topic := range item.Topics {
+			add(topic, "topic")
+		}
+	}
+
+	sort.SliceStable(counts, func(i, j int) bool {
+		return counts[i].Items > counts[j].Items
+	})
+	return counts
 }
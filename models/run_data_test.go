@@ -0,0 +1,40 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountTags(t *testing.T) {
+	items := []Item{
+		{Entities: []Entity{{Name: "Llama 3.1", Type: EntityModel}, {Name: "Meta", Type: EntityCompany}}, Topics: []string{"benchmarks"}},
+		{Entities: []Entity{{Name: "llama 3.1", Type: EntityModel}}, Topics: []string{"quantization", "benchmarks"}},
+		{},
+	}
+
+	assert.Equal(t, []TagCount{
+		{Name: "Llama 3.1", Type: EntityModel, Items: 2},
+		{Name: "benchmarks", Type: "topic", Items: 2},
+		{Name: "Meta", Type: EntityCompany, Items: 1},
+		{Name: "quantization", Type: "topic", Items: 1},
+	}, CountTags(items))
+	assert.Empty(t, CountTags(nil))
+}
+
+func TestSummarizeImportance(t *testing.T) {
+	items := []Item{
+		{ImportanceScore: 8, IsRelevant: true},
+		{ImportanceScore: 6, IsRelevant: true},
+		{ImportanceScore: 1},
+		{IsRelevant: true},
+	}
+
+	stats := SummarizeImportance(items)
+	assert.Equal(t, 3, stats.Scored)
+	assert.Equal(t, 1, stats.Unscored)
+	assert.InDelta(t, 5.0, stats.Mean, 0.001)
+	assert.InDelta(t, 7.0, stats.RelevantMean, 0.001)
+	assert.Equal(t, [10]int{1, 0, 0, 0, 0, 1, 0, 1, 0, 0}, stats.Counts)
+	assert.Nil(t, SummarizeImportance(nil))
+}